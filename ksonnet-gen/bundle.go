@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// bundle generates a library from the CRDs of the sources a bundle
+// file lists (see `kubespec.Bundle`), added to the spec if one is
+// given, and otherwise to `crdOnlySpec`. Every source is resolved, and
+// the library emitted, before anything is written, so that a source
+// that can't be fetched, or doesn't have the digest the bundle pins,
+// leaves the output as it was.
+func bundle(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	file := flags.String("file", "", "the bundle file, listing the sources of CRDs to generate from")
+	target := flags.String("o", "", "the directory or archive to write the library to")
+	format := outputFormatFlag(flags)
+	opts := emitOptionFlags(flags)
+	logger := logFlags(flags)
+	checksums := checksumsFlag(flags)
+	noIndexDoc := noIndexDocFlag(flags)
+	includeUnserved := flags.Bool(
+		"include-unserved", false,
+		"also generate the versions that the bundle's CRDs don't serve")
+	noEmbeddedMeta := flags.Bool(
+		"no-embedded-meta", false,
+		"don't add the built-in apimachinery definitions (e.g., 'ObjectMeta') that the spec lacks and the bundle's CRDs need")
+	var onConflict kubespec.ConflictPolicy
+	flags.Var(
+		(*conflictPolicyFlag)(&onConflict), "on-conflict",
+		"what to do with a definition that the bundle's sources add different schemas of, rather than what the bundle's 'onConflict' says: 'error' or 'fork'")
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if *file == "" || *target == "" || flags.NArg() > 1 {
+		log.Fatal(usage)
+	}
+	opts.Logger = logger
+	opts.Progress = logger.progressFunc()
+
+	text, err := ioutil.ReadFile(*file)
+	if err != nil {
+		fatal(kubespec.Categorize(kubespec.ErrSpecLoad, fmt.Errorf(
+			"Could not read bundle at '%s':\n%w", *file, err)))
+	}
+	b, err := kubespec.ReadBundle(*file, text)
+	if err != nil {
+		fatal(err)
+	}
+	if onConflict == "" {
+		onConflict = b.OnConflict
+	}
+
+	start := time.Now()
+	resolved, err := kubespec.ResolveBundle(ctx, nil, b, filepath.Dir(*file))
+	if err != nil {
+		fatal(err)
+	}
+	sources := []*kubespec.CRDSource{}
+	for _, source := range resolved {
+		logger.Log(
+			"resolve", "source", source.Location, "label", source.Label,
+			"crds", len(source.CRDs), "sha256", source.SHA256)
+		sources = append(sources, source.CRDSource)
+	}
+	logger.Log("resolve bundle", "path", *file, "sources", len(sources), "duration", time.Since(start))
+
+	s := crdOnlySpec()
+	if flags.NArg() == 1 {
+		s = loadSpec(ctx, flags.Arg(0), logger)
+	}
+	s = addCRDSources(s, sources, *includeUnserved, *noEmbeddedMeta, onConflict, prefixes, logger)
+	opts.Parser = prefixes.parser()
+	checkVersion(s, logger)
+
+	out := openOutput(*format, *target)
+	artifacts := writeLibrary(ctx, s, *opts, out, !*noIndexDoc, logger)
+	if *checksums {
+		writeChecksums(artifacts, out, logger)
+	}
+	out.finalize()
+	logger.printTiming()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// check generates the symbol index for a swagger spec and compares it
+// against a committed baseline, failing if any symbol in the baseline
+// has been removed or has changed signature. This lets us catch spec
+// bumps that would break Jsonnet code built on top of the library.
+func check(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	baselinePath := flags.String(
+		"baseline", "", "path to the baseline symbol index (e.g., symbols.json)")
+	updateBaseline := flags.Bool(
+		"update-baseline", false, "rewrite the baseline with the current symbol index")
+	opts := emitOptionFlags(flags)
+	logger := logFlags(flags)
+	strict := strictExtensionsFlag(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if *baselinePath == "" || flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+	opts.Logger = logger
+	opts.Progress = logger.progressFunc()
+	opts.Parser = prefixes.parser()
+
+	s := loadSpec(ctx, flags.Arg(0), logger)
+	checkVersion(s, logger)
+	checkExtensions(s, *strict)
+	current, err := ksonnet.BuildSymbolIndex(s, *opts)
+	if err != nil {
+		fatal(fmt.Errorf("Could not build symbol index:\n%w", err))
+	}
+
+	if *updateBaseline {
+		text, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not serialize symbol index:\n%v", err)
+		}
+		err = ioutil.WriteFile(*baselinePath, append(text, '\n'), 0644)
+		if err != nil {
+			log.Fatalf("Could not write baseline '%s':\n%v", *baselinePath, err)
+		}
+		log.Printf(
+			"Wrote %d symbols to baseline '%s'", len(current.Symbols), *baselinePath)
+		logger.printTiming()
+		return
+	}
+
+	start := time.Now()
+	text, err := ioutil.ReadFile(*baselinePath)
+	if err != nil {
+		log.Fatalf("Could not read baseline at '%s':\n%v", *baselinePath, err)
+	}
+	baseline := ksonnet.SymbolIndex{}
+	if err = json.Unmarshal(text, &baseline); err != nil {
+		log.Fatalf("Could not deserialize baseline '%s':\n%v", *baselinePath, err)
+	}
+
+	diff := ksonnet.DiffSymbolIndexes(&baseline, current)
+	logger.Log(
+		"verify", "baseline", *baselinePath, "added", len(diff.Added),
+		"removed", len(diff.Removed), "changed", len(diff.Changed),
+		"duration", time.Since(start))
+	logger.printTiming()
+
+	for _, symbol := range diff.Removed {
+		fmt.Printf("removed: %s\n", symbol.Path)
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf(
+			"changed: %s (%s) -> (%s)\n",
+			change.Old.Path, signature(change.Old), signature(change.New))
+	}
+
+	if diff.IsBreaking() {
+		log.Printf(
+			"Symbol check failed: %d removed, %d changed, %d added",
+			len(diff.Removed), len(diff.Changed), len(diff.Added))
+		os.Exit(exitCode(ksonnet.ErrVerifyFailed))
+	}
+
+	log.Printf(
+		"Symbol check passed: %d added since baseline", len(diff.Added))
+	for _, symbol := range diff.Added {
+		fmt.Printf("added: %s\n", symbol.Path)
+	}
+}
+
+// signature renders a human-readable summary of a symbol's kind and
+// parameters, for reporting changes.
+func signature(symbol *ksonnet.Symbol) string {
+	switch symbol.Kind {
+	case ksonnet.SymbolAlias:
+		return fmt.Sprintf("%s of %s", symbol.Kind, symbol.Target)
+	case ksonnet.SymbolFunction:
+		// Show defaults, so that a changed default is visible.
+		params := []string{}
+		for _, param := range symbol.Params {
+			if value, ok := symbol.Defaults[param]; ok {
+				param = fmt.Sprintf("%s=%s", param, value)
+			}
+			params = append(params, param)
+		}
+		if symbol.ListType != "" {
+			return fmt.Sprintf(
+				"%s%v on %s list", symbol.Kind, params, symbol.ListType)
+		}
+		return fmt.Sprintf("%s%v", symbol.Kind, params)
+	default:
+		return string(symbol.Kind)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// checksumsFlag registers `--checksums` on `flags`.
+func checksumsFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool(
+		"checksums", false,
+		"also write the SHA-256 digest of every generated file to '[output dir]/SHA256SUMS'")
+}
+
+// writeChecksums writes `SHA256SUMS` to `out`, with the digests of
+// `artifacts`, which are computed from the text that was written
+// rather than by reading the files back.
+func writeChecksums(
+	artifacts map[string]*ksonnet.Artifact, out *output, logger *cliLogger,
+) {
+	start := time.Now()
+	out.writeFile(ksonnet.ChecksumsFile, ksonnet.Checksums(artifacts))
+	logger.Log(
+		"write", "path", out.path(ksonnet.ChecksumsFile), "files", len(artifacts),
+		"duration", time.Since(start))
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// crdFlags are the CRD manifests that `--crd` adds to the spec,
+// whether `--include-unserved` adds the versions they don't serve, and
+// whether `--no-embedded-meta` leaves out the fallback apimachinery
+// definitions (see `withEmbeddedMeta`). `onConflict` is what
+// `--on-conflict` does with a definition that two files add different
+// schemas of, and `labels` are the `--source-label`s of the files,
+// which name their forks. `inputs` are the digests of the files as
+// `addCRDs` read them, for `--provenance`.
+type crdFlags struct {
+	files           crdFilesFlag
+	includeUnserved bool
+	noEmbeddedMeta  bool
+	onConflict      kubespec.ConflictPolicy
+	labels          sourceLabelsFlag
+
+	inputs []*ksonnet.ProvenanceInput
+}
+
+// crdFlagsFor registers `--crd`, `--include-unserved`,
+// `--no-embedded-meta`, `--on-conflict`, and `--source-label` on
+// `flags`.
+func crdFlagsFor(flags *flag.FlagSet) *crdFlags {
+	crds := &crdFlags{}
+	flags.Var(
+		&crds.files, "crd",
+		"also generate the custom resources of the CRDs in this JSON or YAML file, a namespace per served version; may be repeated")
+	flags.BoolVar(
+		&crds.includeUnserved, "include-unserved", false,
+		"also generate the versions that --crd's CRDs don't serve")
+	flags.BoolVar(
+		&crds.noEmbeddedMeta, "no-embedded-meta", false,
+		"don't add the built-in apimachinery definitions (e.g., 'ObjectMeta') that the spec lacks and its references or --crd's CRDs need")
+	flags.Var(
+		(*conflictPolicyFlag)(&crds.onConflict), "on-conflict",
+		"what to do with a definition that --crd files add different schemas of: 'error' (default) or 'fork', which keeps both, grouping the later file's apart under its --source-label")
+	flags.Var(
+		&crds.labels, "source-label",
+		"label the forks of a --crd file's definitions, e.g., 'databases-v2.yaml=v2'; defaults to the file's name, without its extension; may be repeated")
+	return crds
+}
+
+// sourceLabel returns the label of the forks of `file`'s definitions:
+// its `--source-label`, or else `kubespec.SourceLabel` of it, e.g.,
+// `databases_v2` for `crds/databases-v2.yaml`.
+func (crds *crdFlags) sourceLabel(file string) string {
+	if label, ok := crds.labels[file]; ok {
+		return label
+	}
+	return kubespec.SourceLabel(file)
+}
+
+// conflictPolicyFlag adapts `kubespec.ConflictPolicy` to `flag.Value`.
+type conflictPolicyFlag kubespec.ConflictPolicy
+
+func (f *conflictPolicyFlag) String() string {
+	if f == nil || *f == "" {
+		return string(kubespec.ConflictsError)
+	}
+	return string(*f)
+}
+
+func (f *conflictPolicyFlag) Set(value string) error {
+	policy, err := kubespec.ParseConflictPolicy(value)
+	if err != nil {
+		return err
+	}
+	*f = conflictPolicyFlag(policy)
+	return nil
+}
+
+// sourceLabelsFlag adapts a repeated `file=label` flag to the labels of
+// files.
+type sourceLabelsFlag map[string]string
+
+func (f *sourceLabelsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	entries := []string{}
+	for file, label := range *f {
+		entries = append(entries, file+"="+label)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (f *sourceLabelsFlag) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i == -1 {
+		return fmt.Errorf("Expected 'file=label', got '%s'", value)
+	}
+	if *f == nil {
+		*f = map[string]string{}
+	}
+	(*f)[value[:i]] = value[i+1:]
+	return nil
+}
+
+// crdFilesFlag adapts a repeated flag to a list of files.
+type crdFilesFlag []string
+
+func (f *crdFilesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+func (f *crdFilesFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// has reports whether `file` is one of the files.
+func (f crdFilesFlag) has(file string) bool {
+	for _, other := range f {
+		if other == file {
+			return true
+		}
+	}
+	return false
+}
+
+// addCRDs returns `s` with the custom resources of the CRDs of
+// `crds.files`, each file a source labelled by `sourceLabel`, merged by
+// `crds.onConflict` (see `kubespec.APISpec.WithCRDSources`), adding
+// `kubespec.CRDPrefix` to `prefixes`, so that their definitions parse.
+// Unless `crds.noEmbeddedMeta` is set, the fallback apimachinery
+// definitions that the CRDs or the dangling references of `s` need are
+// added first.
+func addCRDs(
+	s *kubespec.APISpec, crds *crdFlags, prefixes *prefixesFlag, logger *cliLogger,
+) *kubespec.APISpec {
+	if len(crds.files) == 0 {
+		if crds.noEmbeddedMeta {
+			return s
+		}
+		return withEmbeddedMeta(s, logger)
+	}
+	for file := range crds.labels {
+		if !crds.files.has(file) {
+			fatal(fmt.Errorf("--source-label labels '%s', which isn't a --crd file", file))
+		}
+	}
+	sources := []*kubespec.CRDSource{}
+	for _, file := range crds.files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			fatal(kubespec.Categorize(kubespec.ErrSpecLoad, fmt.Errorf(
+				"Could not read CRDs at '%s':\n%w", file, err)))
+		}
+		read, err := kubespec.ReadCRDs(file, text)
+		if err != nil {
+			fatal(err)
+		}
+		crds.inputs = append(crds.inputs, &ksonnet.ProvenanceInput{
+			Kind: "crd", Name: file, SHA256: sha256Hex(text),
+		})
+		sources = append(sources, &kubespec.CRDSource{Label: crds.sourceLabel(file), CRDs: read})
+	}
+
+	return addCRDSources(s, sources, crds.includeUnserved, crds.noEmbeddedMeta, crds.onConflict, prefixes, logger)
+}
+
+// addCRDSources returns `s` with the custom resources of the CRDs of
+// `sources`, as `addCRDs` adds those of its files.
+func addCRDSources(
+	s *kubespec.APISpec, sources []*kubespec.CRDSource, includeUnserved, noEmbeddedMeta bool,
+	onConflict kubespec.ConflictPolicy, prefixes *prefixesFlag, logger *cliLogger,
+) *kubespec.APISpec {
+	if !noEmbeddedMeta {
+		s = withEmbeddedMeta(s, logger, kubespec.ObjectMetaName)
+	}
+	withCRDs, err := s.WithCRDSources(sources, includeUnserved, onConflict)
+	if err != nil {
+		fatal(err)
+	}
+	for _, prefix := range *prefixes {
+		if prefix == kubespec.CRDPrefix {
+			return withCRDs
+		}
+	}
+	*prefixes = append(*prefixes, kubespec.CRDPrefix)
+	return withCRDs
+}
+
+// crdOnlySpec is the spec that `--crd` adds its CRDs to when no spec
+// is given: one without definitions, of the newest version of
+// Kubernetes there's data for, to which `addCRDs` adds the definitions
+// of `kubespec.EmbeddedMeta` unless `--no-embedded-meta` is passed.
+func crdOnlySpec() *kubespec.APISpec {
+	supported := kubeversion.Default().Supported()
+	return &kubespec.APISpec{
+		SwaggerVersion: "2.0",
+		Info: &kubespec.SchemaInfo{
+			Title:   "Kubernetes",
+			Version: supported[len(supported)-1].Version,
+		},
+		Definitions: kubespec.SchemaDefinitions{},
+		// Like a fetched spec, it has no file of its own.
+		FilePath: ".",
+	}
+}
+
+// withEmbeddedMeta returns `s` with the definitions of
+// `kubespec.EmbeddedMeta` that its dangling references, and `names`,
+// need (see `kubespec.APISpec.WithEmbeddedMeta`), logging each, and
+// saying once that the fallback was used.
+func withEmbeddedMeta(
+	s *kubespec.APISpec, logger *cliLogger, names ...kubespec.DefinitionName,
+) *kubespec.APISpec {
+	withMeta, added := s.WithEmbeddedMeta(names...)
+	if len(added) == 0 {
+		return s
+	}
+	for _, name := range added {
+		logger.Log("embedded meta", "definition", name)
+	}
+	log.Printf(
+		"The spec lacks %d apimachinery definitions, so they're taken from the built-in spec of Kubernetes %s; pass --no-embedded-meta to leave them out",
+		len(added), kubespec.EmbeddedMeta().Info.Version)
+	return withMeta
+}
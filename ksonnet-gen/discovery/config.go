@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// Config is the part of a kubeconfig file that `Client` needs to reach
+// a cluster: its clusters, users, and the contexts that pair them.
+// Credentials from exec and auth-provider plugins aren't supported.
+type Config struct {
+	CurrentContext string         `json:"current-context"`
+	Clusters       []namedCluster `json:"clusters"`
+	Users          []namedUser    `json:"users"`
+	Contexts       []namedContext `json:"contexts"`
+
+	// Dir is the directory that relative paths of files in the config
+	// (e.g., `certificate-authority`) are relative to, i.e., the
+	// directory of the kubeconfig file.
+	Dir string `json:"-"`
+}
+
+type namedCluster struct {
+	Name    string `json:"name"`
+	Cluster struct {
+		Server                   string `json:"server"`
+		CertificateAuthority     string `json:"certificate-authority"`
+		CertificateAuthorityData string `json:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+	} `json:"cluster"`
+}
+
+type namedUser struct {
+	Name string `json:"name"`
+	User struct {
+		ClientCertificate     string `json:"client-certificate"`
+		ClientCertificateData string `json:"client-certificate-data"`
+		ClientKey             string `json:"client-key"`
+		ClientKeyData         string `json:"client-key-data"`
+		Token                 string `json:"token"`
+		TokenFile             string `json:"tokenFile"`
+		Username              string `json:"username"`
+		Password              string `json:"password"`
+	} `json:"user"`
+}
+
+type namedContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster string `json:"cluster"`
+		User    string `json:"user"`
+	} `json:"context"`
+}
+
+// ParseConfig deserializes a kubeconfig file, which may be YAML (as
+// `kubectl` writes it) or JSON. `dir` is the directory of the file,
+// which relative paths in it are relative to.
+func ParseConfig(text []byte, dir string) (*Config, error) {
+	if !json.Valid(text) {
+		j, err := yamljson.ToJSON(text)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read kubeconfig as YAML:\n%v", err)
+		}
+		text = j
+	}
+	config := Config{}
+	if err := json.Unmarshal(text, &config); err != nil {
+		return nil, fmt.Errorf("Could not deserialize kubeconfig:\n%v", err)
+	}
+	config.Dir = dir
+	return &config, nil
+}
+
+// Client returns a client for the cluster and user of the context
+// named `contextName`, or of the current context if it's empty. It's
+// an error if the context, or the cluster it names, isn't in the
+// config, or if its certificates don't load.
+func (config *Config) Client(contextName string) (*Client, error) {
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("Kubeconfig has no current context; pass one")
+	}
+
+	var context *namedContext
+	for i := range config.Contexts {
+		if config.Contexts[i].Name == contextName {
+			context = &config.Contexts[i]
+		}
+	}
+	if context == nil {
+		return nil, fmt.Errorf("Kubeconfig has no context '%s'", contextName)
+	}
+
+	var cluster *namedCluster
+	for i := range config.Clusters {
+		if config.Clusters[i].Name == context.Context.Cluster {
+			cluster = &config.Clusters[i]
+		}
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf(
+			"Context '%s' names cluster '%s', which isn't in the kubeconfig",
+			contextName, context.Context.Cluster)
+	}
+
+	client := &Client{Server: cluster.Cluster.Server}
+	tlsConfig := &tls.Config{InsecureSkipVerify: cluster.Cluster.InsecureSkipTLSVerify}
+	ca, err := config.data(cluster.Cluster.CertificateAuthorityData, cluster.Cluster.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load certificate authority of cluster '%s':\n%v", cluster.Name, err)
+	} else if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Certificate authority of cluster '%s' has no PEM certificates", cluster.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// A context without a user connects anonymously.
+	for _, user := range config.Users {
+		if user.Name != context.Context.User {
+			continue
+		}
+		cert, err := config.data(user.User.ClientCertificateData, user.User.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load client certificate of user '%s':\n%v", user.Name, err)
+		}
+		key, err := config.data(user.User.ClientKeyData, user.User.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load client key of user '%s':\n%v", user.Name, err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("Could not load client certificate of user '%s':\n%v", user.Name, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+
+		client.BearerToken = user.User.Token
+		if client.BearerToken == "" && user.User.TokenFile != "" {
+			token, err := ioutil.ReadFile(config.path(user.User.TokenFile))
+			if err != nil {
+				return nil, fmt.Errorf("Could not read token of user '%s':\n%v", user.Name, err)
+			}
+			client.BearerToken = strings.TrimSpace(string(token))
+		}
+		client.Username = user.User.Username
+		client.Password = user.User.Password
+	}
+
+	client.HTTPClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return client, nil
+}
+
+// `data` returns the bytes of a field that's given either inline,
+// base64-encoded (e.g., `certificate-authority-data`), or as the path
+// of a file (e.g., `certificate-authority`), or nil if neither is set.
+func (config *Config) data(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return base64.StdEncoding.DecodeString(inline)
+	} else if file != "" {
+		return ioutil.ReadFile(config.path(file))
+	}
+	return nil, nil
+}
+
+func (config *Config) path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(config.Dir, file)
+}
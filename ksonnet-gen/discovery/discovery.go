@@ -0,0 +1,122 @@
+// Package discovery reads the group/versions a Kubernetes API server
+// serves from its discovery endpoints, `/api` and `/apis`, over plain
+// HTTP, without depending on client-go.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Client talks to the discovery endpoints of one API server. See
+// `Config.Client` to make one from a kubeconfig.
+type Client struct {
+	// Server is the base URL of the API server, e.g.,
+	// `https://10.0.0.1:6443`, or `http://localhost:8001` for
+	// `kubectl proxy`.
+	Server string
+
+	// HTTPClient makes the requests; `http.DefaultClient` if nil. It
+	// carries the TLS configuration of the cluster and of client
+	// certificates.
+	HTTPClient *http.Client
+
+	// BearerToken, or else Username and Password, authenticate each
+	// request, if set.
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+// apiVersions is the body of `/api`, the versions of the core group.
+type apiVersions struct {
+	Versions []string `json:"versions"`
+}
+
+// apiGroupList is the body of `/apis`, every other group.
+type apiGroupList struct {
+	Groups []struct {
+		Name     string `json:"name"`
+		Versions []struct {
+			GroupVersion string `json:"groupVersion"`
+			Version      string `json:"version"`
+		} `json:"versions"`
+	} `json:"groups"`
+}
+
+// GroupVersions returns every group/version the server serves, as in
+// `apiVersion`, sorted: e.g., `apps/v1`, `batch/v1`, and `v1` for the
+// core group.
+func (c *Client) GroupVersions(ctx context.Context) ([]string, error) {
+	core := apiVersions{}
+	if err := c.get(ctx, "/api", &core); err != nil {
+		return nil, err
+	}
+	groups := apiGroupList{}
+	if err := c.get(ctx, "/apis", &groups); err != nil {
+		return nil, err
+	}
+
+	groupVersions := append([]string{}, core.Versions...)
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			groupVersion := version.GroupVersion
+			if groupVersion == "" {
+				groupVersion = fmt.Sprintf("%s/%s", group.Name, version.Version)
+			}
+			groupVersions = append(groupVersions, groupVersion)
+		}
+	}
+	sort.Strings(groupVersions)
+	return groupVersions, nil
+}
+
+// `get` fetches `path` from the server and deserializes the JSON it
+// responds with into `v`. The request is cancelled if `ctx` is, in
+// which case the error wraps `ctx.Err()`.
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	url := strings.TrimSuffix(c.Server, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("Could not fetch '%s':\n%v", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("Could not fetch '%s': %w", url, ctx.Err())
+		}
+		return fmt.Errorf("Could not fetch '%s':\n%v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Could not fetch '%s': server responded '%s'", url, resp.Status)
+	}
+
+	text, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("Could not fetch '%s': %w", url, ctx.Err())
+		}
+		return fmt.Errorf("Could not read '%s':\n%v", url, err)
+	}
+	if err := json.Unmarshal(text, v); err != nil {
+		return fmt.Errorf("Could not deserialize '%s':\n%v", url, err)
+	}
+	return nil
+}
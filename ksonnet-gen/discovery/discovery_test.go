@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	coreVersions = `{"kind": "APIVersions", "versions": ["v1"]}`
+	groupList    = `{
+  "kind": "APIGroupList",
+  "groups": [
+    {"name": "apps", "versions": [{"groupVersion": "apps/v1", "version": "v1"}, {"groupVersion": "apps/v1beta2", "version": "v1beta2"}]},
+    {"name": "batch", "versions": [{"version": "v1"}]}
+  ]
+}`
+)
+
+// `discoveryHandler` serves `/api` and `/apis`, checking that requests
+// carry `token`, if it's set.
+func discoveryHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api":
+			w.Write([]byte(coreVersions))
+		case "/apis":
+			w.Write([]byte(groupList))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+var expectedGroupVersions = []string{"apps/v1", "apps/v1beta2", "batch/v1", "v1"}
+
+func TestGroupVersions(t *testing.T) {
+	server := httptest.NewServer(discoveryHandler("secret"))
+	defer server.Close()
+
+	client := &Client{Server: server.URL + "/", BearerToken: "secret"}
+	groupVersions, err := client.GroupVersions(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to discover group/versions:\n%v", err)
+	}
+	if !reflect.DeepEqual(groupVersions, expectedGroupVersions) {
+		t.Errorf("Expected %v, got %v", expectedGroupVersions, groupVersions)
+	}
+
+	client.BearerToken = ""
+	_, err = client.GroupVersions(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an error naming the status, got %v", err)
+	}
+}
+
+func TestGroupVersionsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := (&Client{Server: server.URL}).GroupVersions(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to wrap 'context.Canceled', got %v", err)
+	}
+}
+
+func TestConfigClient(t *testing.T) {
+	server := httptest.NewTLSServer(discoveryHandler("secret"))
+	defer server.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	text := fmt.Sprintf(`{
+  "current-context": "dev",
+  "clusters": [
+    {"name": "prod", "cluster": {"server": %q, "certificate-authority-data": %q}},
+    {"name": "dev", "cluster": {"server": "https://dev.example.com"}}
+  ],
+  "users": [{"name": "admin", "user": {"token": "secret"}}],
+  "contexts": [
+    {"name": "prod", "context": {"cluster": "prod", "user": "admin"}},
+    {"name": "dev", "context": {"cluster": "dev", "user": "admin"}},
+    {"name": "broken", "context": {"cluster": "missing", "user": "admin"}}
+  ]
+}`, server.URL, base64.StdEncoding.EncodeToString(ca))
+	config, err := ParseConfig([]byte(text), ".")
+	if err != nil {
+		t.Fatalf("Failed to parse kubeconfig:\n%v", err)
+	}
+
+	// The cluster's certificate authority is trusted, and the user's
+	// token sent.
+	client, err := config.Client("prod")
+	if err != nil {
+		t.Fatalf("Failed to make client:\n%v", err)
+	}
+	groupVersions, err := client.GroupVersions(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to discover group/versions:\n%v", err)
+	}
+	if !reflect.DeepEqual(groupVersions, expectedGroupVersions) {
+		t.Errorf("Expected %v, got %v", expectedGroupVersions, groupVersions)
+	}
+
+	if client, err := config.Client(""); err != nil || client.Server != "https://dev.example.com" {
+		t.Errorf("Expected the current context to be used, got %#v (%v)", client, err)
+	}
+	for _, context := range []string{"missing", "broken"} {
+		if _, err := config.Client(context); err == nil {
+			t.Errorf("Expected an error for context '%s'", context)
+		}
+	}
+
+	// Kubeconfigs are usually YAML, as `kubectl` writes them.
+	yaml := `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+`
+	config, err = ParseConfig([]byte(yaml), ".")
+	if err != nil {
+		t.Fatalf("Failed to parse YAML kubeconfig:\n%v", err)
+	}
+	if client, err := config.Client(""); err != nil || client.Server != "https://dev.example.com" {
+		t.Errorf("Expected the YAML kubeconfig's current context to be used, got %#v (%v)", client, err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// exitCodes are the statuses ksonnet-gen exits with for the categories
+// of errors (see `kubespec.ErrSpecLoad`), so that scripts can tell
+// them apart as programs that embed the library do. An error of more
+// than one exits with the first; any other failure exits with 1, bad
+// flags with 2, and an interrupt with 130 (see `exitIfInterrupted`).
+var exitCodes = []struct {
+	category error
+	code     int
+}{
+	{kubespec.ErrSpecLoad, 3},
+	{kubespec.ErrUnparsableName, 4},
+	{kubespec.ErrUnsupportedSchema, 5},
+	{kubespec.ErrDanglingRef, 6},
+	{kubespec.ErrVerifyFailed, 7},
+}
+
+// exitCode returns the status to exit with for `err`; see `exitCodes`.
+func exitCode(err error) int {
+	for _, c := range exitCodes {
+		if errors.Is(err, c.category) {
+			return c.code
+		}
+	}
+	return 1
+}
+
+// fatal logs `err` and exits with its status; see `exitCodes`.
+func fatal(err error) {
+	exitIfInterrupted(err)
+	log.Print(err)
+	os.Exit(exitCode(err))
+}
+
+// exitIfPanicked, deferred by `main`, exits as `fatal` does if the
+// command panicked with an error of one of `exitCodes`, as the emitter
+// does, e.g., for a definition name that doesn't parse without
+// `--lenient`. Any other panic is a bug, so it's panicked again.
+func exitIfPanicked() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if err, ok := r.(error); ok && exitCode(err) != 1 {
+		fatal(err)
+	}
+	panic(r)
+}
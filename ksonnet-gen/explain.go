@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// explain prints the parsed form of a single definition in a swagger
+// spec, which is useful for debugging spec issues without generating
+// the whole library.
+func explain(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the explanation as JSON")
+	depth := flags.Int(
+		"recursive", 0, "expand referenced definitions inline, up to this depth")
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Fatal(usage)
+	}
+
+	s := loadSpec(ctx, flags.Arg(0), &cliLogger{})
+	name, err := s.FindDefinition(flags.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	explanation, err := prefixes.parser().Explain(s, name, *depth)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		text, err := json.MarshalIndent(explanation, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not serialize explanation:\n%v", err)
+		}
+		fmt.Println(string(text))
+		return
+	}
+
+	writeExplanation(os.Stdout, explanation, 0)
+}
+
+func writeExplanation(
+	w io.Writer, de *kubespec.DefinitionExplanation, depth int,
+) {
+	indent := strings.Repeat("    ", depth)
+	fmt.Fprintf(w, "%sNAME:        %s\n", indent, de.Name)
+	if de.Parsed != nil {
+		p := de.Parsed
+		fmt.Fprintf(w, "%sCODEBASE:    %s\n", indent, p.Codebase)
+		if p.HasGroup() {
+			fmt.Fprintf(w, "%sGROUP:       %s\n", indent, p.Group)
+		}
+		if p.HasVersion() {
+			fmt.Fprintf(w, "%sVERSION:     %s\n", indent, p.Version)
+		}
+		fmt.Fprintf(w, "%sKIND:        %s\n", indent, p.Kind)
+	} else {
+		fmt.Fprintf(w, "%sPARSE ERROR: %s\n", indent, de.ParseError)
+	}
+	for _, ext := range sortedExtensionNames(de.Extensions) {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, ext, de.Extensions[ext])
+	}
+	fmt.Fprintf(w, "%sDESCRIPTION: %s\n", indent, de.Description)
+	if example, ok := formatExample(de.Example); ok {
+		fmt.Fprintf(w, "%sEXAMPLE:\n", indent)
+		writeIndented(w, indent+"  ", example)
+	}
+
+	if len(de.Properties) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%sPROPERTIES:\n", indent)
+	for _, pe := range de.Properties {
+		fmt.Fprintf(w, "%s  %s  %s", indent, pe.Name, propertyType(pe))
+		if pe.Required {
+			fmt.Fprint(w, "  (required)")
+		}
+		if pe.Cycle {
+			fmt.Fprint(w, "  (cycle)")
+		}
+		fmt.Fprintln(w)
+		for _, ext := range sortedExtensionNames(pe.Extensions) {
+			fmt.Fprintf(w, "%s      %s: %s\n", indent, ext, pe.Extensions[ext])
+		}
+		if example, ok := formatExample(pe.Example); ok {
+			fmt.Fprintf(w, "%s      example:\n", indent)
+			writeIndented(w, indent+"        ", example)
+		}
+		if pe.Definition != nil {
+			writeExplanation(w, pe.Definition, depth+1)
+		}
+	}
+}
+
+// propertyType renders the type of a property, e.g., `integer
+// (int32)`, or `array of $ref io.k8s.kubernetes.pkg.api.v1.Container`.
+func propertyType(pe *kubespec.PropertyExplanation) string {
+	var text string
+	switch {
+	case pe.Ref != "":
+		text = fmt.Sprintf("$ref %s", pe.Ref)
+	case pe.ItemsRef != "":
+		text = fmt.Sprintf("%s of $ref %s", pe.Type, pe.ItemsRef)
+	case pe.Type != "":
+		text = pe.Type
+	default:
+		text = "untyped"
+	}
+
+	if pe.Format != "" {
+		text = fmt.Sprintf("%s (%s)", text, pe.Format)
+	}
+	return text
+}
+
+// formatExample renders an example from an explanation for people;
+// see `kubespec.FormatExample`.
+func formatExample(raw json.RawMessage) (string, bool) {
+	if raw == nil {
+		return "", false
+	}
+	var example interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&example); err != nil {
+		return string(raw), true
+	}
+	text, _ := kubespec.FormatExample(example)
+	return text, true
+}
+
+func writeIndented(w io.Writer, indent, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+func sortedExtensionNames(exts map[string]string) []string {
+	names := []string{}
+	for name := range exts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
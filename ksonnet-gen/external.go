@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// externalRefsFlag adapts a repeated `library=dir` flag to
+// `ksonnet.Options.ExternalRefs`.
+type externalRefsFlag map[string]string
+
+func (f *externalRefsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	entries := []string{}
+	for library, dir := range *f {
+		entries = append(entries, library+"="+dir)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (f *externalRefsFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i == -1 {
+		return fmt.Errorf("Expected 'library=dir', e.g., 'core=./k8s', got '%s'", value)
+	}
+	if *f == nil {
+		*f = map[string]string{}
+	}
+	(*f)[value[:i]] = value[i+1:]
+	return nil
+}
+
+// externalIndexesFlag adapts a repeated `library=symbols.json` flag to
+// `ksonnet.Options.ExternalIndexes`, reading each index as it's set.
+type externalIndexesFlag map[string]*ksonnet.SymbolIndex
+
+func (f *externalIndexesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	libraries := []string{}
+	for library := range *f {
+		libraries = append(libraries, library)
+	}
+	sort.Strings(libraries)
+	return strings.Join(libraries, ",")
+}
+
+func (f *externalIndexesFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i == -1 {
+		return fmt.Errorf("Expected 'library=symbols.json', e.g., 'core=./k8s/symbols.json', got '%s'", value)
+	}
+	index, err := loadSymbolIndex(value[i+1:])
+	if err != nil {
+		return err
+	}
+	if *f == nil {
+		*f = map[string]*ksonnet.SymbolIndex{}
+	}
+	(*f)[value[:i]] = index
+	return nil
+}
+
+// loadSymbolIndex reads the symbol index at `file`, as
+// `check --update-baseline` writes it.
+func loadSymbolIndex(file string) (*ksonnet.SymbolIndex, error) {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read symbol index '%s':\n%v", file, err)
+	}
+	index := &ksonnet.SymbolIndex{}
+	if err := json.Unmarshal(text, index); err != nil {
+		return nil, fmt.Errorf("Could not deserialize symbol index '%s':\n%v", file, err)
+	}
+	return index, nil
+}
+
+// extendsFlag adapts a `symbols.json` flag to `ksonnet.Options.Extends`,
+// reading the index as it's set, and remembering its file.
+type extendsFlag struct {
+	index **ksonnet.SymbolIndex
+	file  string
+}
+
+func (f *extendsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.file
+}
+
+func (f *extendsFlag) Set(value string) error {
+	index, err := loadSymbolIndex(value)
+	if err != nil {
+		return err
+	}
+	*f.index, f.file = index, value
+	return nil
+}
+
+// extensionsFlag adapts a repeated `hook=Kind:Manages` flag to
+// `ksonnet.Options.Extensions`.
+type extensionsFlag []ksonnet.Extension
+
+func (f *extensionsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	extensions := []string{}
+	for _, e := range *f {
+		extensions = append(extensions, e.String())
+	}
+	return strings.Join(extensions, ",")
+}
+
+func (f *extensionsFlag) Set(value string) error {
+	e, err := ksonnet.ParseExtension(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, e)
+	return nil
+}
+
+// hasExternal reports whether one of `indexes` has the definition
+// `name` where another library can reach it.
+func hasExternal(indexes map[string]*ksonnet.SymbolIndex, name kubespec.DefinitionName) bool {
+	for _, index := range indexes {
+		if _, ok := index.DefinitionPath(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// externalizeEmbeddedMeta returns `s`, the spec `loaded` with its
+// CRDs and the fallback apimachinery definitions (see `addCRDs`),
+// without the fallback definitions that one of `indexes` has, so that
+// the library refers to them in its external library rather than
+// emitting copies (e.g., of `ObjectMeta`, which CRDs refer to). The
+// fallback definitions nothing else refers to any more are dropped
+// too. Each externalized definition is logged.
+func externalizeEmbeddedMeta(
+	loaded, s *kubespec.APISpec, indexes map[string]*ksonnet.SymbolIndex, logger *cliLogger,
+) *kubespec.APISpec {
+	if len(indexes) == 0 {
+		return s
+	}
+	embedded := func(name kubespec.DefinitionName) bool {
+		_, inLoaded := loaded.Definitions[name]
+		_, inFallback := kubespec.EmbeddedMeta().Definitions[name]
+		return inFallback && !inLoaded
+	}
+	externalized := s.Exclude(func(name kubespec.DefinitionName, def *kubespec.SchemaDefinition) bool {
+		return embedded(name) && hasExternal(indexes, name)
+	})
+	removed := []string{}
+	for name := range s.Definitions {
+		if _, ok := externalized.Definitions[name]; !ok {
+			removed = append(removed, string(name))
+		}
+	}
+	if len(removed) == 0 {
+		return s
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		logger.Log("externalize embedded meta", "definition", name)
+	}
+	log.Printf(
+		"The external libraries have %d of the built-in apimachinery definitions, so the library refers to theirs instead",
+		len(removed))
+	return externalized.Filter(func(name kubespec.DefinitionName, def *kubespec.SchemaDefinition) bool {
+		return !embedded(name)
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// graph writes the reference graph of a swagger spec, from one kind or
+// of the whole spec, as Graphviz DOT, which helps decide what to prune.
+func graph(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	kind := flags.String(
+		"kind", "",
+		"only graph the definitions reachable from this kind (e.g., Pod, or apps/v1beta2/Deployment), rather than the whole spec")
+	depth := flags.Int("depth", 0, "follow references at most this many levels deep (0 follows them all)")
+	excludeMeta := flags.Bool(
+		"exclude-meta", false,
+		"leave out the definitions of the meta group (e.g., 'ObjectMeta'), which almost every kind refers to")
+	output := flags.String("o", "", "the file to write the graph to, rather than stdout")
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *depth < 0 {
+		log.Fatal(usage)
+	}
+
+	parser := prefixes.parser()
+	s := loadSpec(ctx, flags.Arg(0), &cliLogger{})
+	var roots []kubespec.DefinitionName
+	if *kind != "" {
+		roots = findRoots(s, *kind)
+	}
+	var exclude func(kubespec.DefinitionName) bool
+	if *excludeMeta {
+		exclude = func(name kubespec.DefinitionName) bool { return isMetaDefinition(parser, name) }
+	}
+	g := s.ReferenceGraph(roots, *depth, exclude)
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Could not write `%s`:\n%v", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeDOT(w, s, parser, g, *kind != ""); err != nil {
+		log.Fatalf("Could not write graph:\n%v", err)
+	}
+}
+
+// findRoots returns the definitions named by `query`, which is either
+// a bare kind (e.g., `Pod`), in which case every version of it is a
+// root, or anything `FindDefinition` accepts.
+func findRoots(s *kubespec.APISpec, query string) []kubespec.DefinitionName {
+	if name, err := s.FindDefinition(query); err == nil {
+		return []kubespec.DefinitionName{name}
+	} else if !strings.Contains(query, "/") {
+		if roots := s.FindKind(kubespec.ObjectKind(query)); len(roots) > 0 {
+			return roots
+		}
+	}
+	log.Fatalf("Could not find kind '%s'", query)
+	return nil
+}
+
+// isMetaDefinition reports whether a definition is in the meta group,
+// e.g., `io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta`.
+func isMetaDefinition(parser *kubespec.Parser, name kubespec.DefinitionName) bool {
+	parsed, err := parser.ParseName(name)
+	return err == nil && parsed.Group == "meta"
+}
+
+// writeDOT writes a reference graph as a Graphviz `digraph`. Nodes are
+// labeled by group/version/kind, and edges by the property that makes
+// the reference. References that are part of a cycle are red and
+// dashed, definitions that aren't in the spec are dashed, and, if
+// `markRoots` is set, the roots the graph was walked from are bold.
+// Names are parsed with `parser`.
+func writeDOT(
+	w io.Writer, s *kubespec.APISpec, parser *kubespec.Parser, g *kubespec.ReferenceGraph, markRoots bool,
+) error {
+	roots := map[kubespec.DefinitionName]bool{}
+	if markRoots {
+		for _, root := range g.Roots {
+			roots[root] = true
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph references {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, "  node [shape=box];")
+	for _, name := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(nodeLabel(s, parser, name))}
+		if _, ok := s.Definitions[name]; !ok {
+			attrs = append(attrs, "style=dashed")
+		} else if roots[name] {
+			attrs = append(attrs, "style=bold")
+		}
+		fmt.Fprintf(b, "  %s [%s];\n", dotQuote(string(name)), strings.Join(attrs, ", "))
+	}
+	for _, ref := range g.References {
+		attrs := []string{"label=" + dotQuote(string(ref.Property))}
+		if ref.Cycle {
+			attrs = append(attrs, "color=red", "style=dashed")
+		}
+		fmt.Fprintf(
+			b, "  %s -> %s [%s];\n",
+			dotQuote(string(ref.From)), dotQuote(string(ref.To)), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// nodeLabel names a definition by its group/version/kind (e.g.,
+// `apps/v1beta2/Deployment`, or `v1/Container` for the core group),
+// falling back to its full name if it doesn't parse.
+func nodeLabel(s *kubespec.APISpec, parser *kubespec.Parser, name kubespec.DefinitionName) string {
+	if def, ok := s.Definitions[name]; ok && len(def.TopLevelSpecs) > 0 {
+		return def.TopLevelSpecs[0].String()
+	}
+	parsed, err := parser.ParseName(name)
+	if err != nil {
+		return string(name)
+	} else if !parsed.HasVersion() {
+		return string(parsed.Kind)
+	}
+	gvk := kubespec.TopLevelSpec{Group: parsed.Group, Version: parsed.Version, Kind: parsed.Kind}
+	return gvk.String()
+}
+
+// dotQuote quotes a string as a DOT ID.
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
@@ -0,0 +1,116 @@
+package jsonnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// Literal converts a JSON value, as decoded by `encoding/json`, into
+// the text of a Jsonnet literal that evaluates to the same value: `nil`
+// is `null`, and booleans, strings, numbers (`float64`s, or
+// `json.Number`s, whose text is kept as it is), arrays
+// (`[]interface{}`), and objects (`map[string]interface{}`) have the
+// same syntax as in JSON, except that object keys are only quoted if
+// they aren't identifiers, or are Jsonnet keywords (e.g., `{name: "x",
+// "$ref": "y", "local": true}`). Fields are sorted by key, so that
+// equal values have the same literal.
+//
+// It's an error if `value`, or anything in it, is of another type,
+// or is a number JSON can't represent (e.g., NaN, or a `json.Number`
+// that isn't one).
+func Literal(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := writeLiteral(&buf, value); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jsonNumberPattern matches the numbers of JSON, all of which are
+// numbers in Jsonnet too.
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// identifierPattern matches Jsonnet identifiers, which may be used as
+// object keys without quotes, unless they're keywords.
+var identifierPattern = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+func writeLiteral(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("Can't write %v as a Jsonnet literal", v)
+		}
+		buf.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	case json.Number:
+		if !jsonNumberPattern.MatchString(string(v)) {
+			return fmt.Errorf("Can't write '%s' as a Jsonnet literal; it isn't a number", v)
+		}
+		buf.WriteString(string(v))
+	case string:
+		buf.WriteString(stringLiteral(v))
+	case []interface{}:
+		buf.WriteString("[")
+		for i, element := range v {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeLiteral(buf, element); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(objectKey(key))
+			buf.WriteString(": ")
+			if err := writeLiteral(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	default:
+		return fmt.Errorf("Can't write a value of type %T as a Jsonnet literal", value)
+	}
+	return nil
+}
+
+// `stringLiteral` quotes `s` as JSON does, whose escapes Jsonnet's
+// double-quoted strings share, but without escaping HTML characters.
+func stringLiteral(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string can't fail.
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// `objectKey` returns `key` as the key of a field of an object literal,
+// quoted unless it's an identifier that isn't a keyword.
+func objectKey(key string) string {
+	if _, ok := jsonnetKeywordSet[kubespec.PropertyName(key)]; ok || !identifierPattern.MatchString(key) {
+		return stringLiteral(key)
+	}
+	return key
+}
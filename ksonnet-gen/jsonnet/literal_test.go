@@ -0,0 +1,178 @@
+package jsonnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+var literalTests = []struct {
+	value    interface{}
+	expected string
+}{
+	// Scalars.
+	{nil, "null"},
+	{true, "true"},
+	{false, "false"},
+	{"", `""`},
+	{"nginx:1.7.9", `"nginx:1.7.9"`},
+	{`say "hi"\now`, `"say \"hi\"\\now"`},
+	{"two\nlines\tand a tab", `"two\nlines\tand a tab"`},
+	{"\x00\x1f", `"\u0000\u001f"`},
+	{"<a & b>", `"<a & b>"`},
+	{"héllo, 世界", `"héllo, 世界"`},
+
+	// Floats, as `encoding/json` decodes every number by default.
+	{float64(0), "0"},
+	{float64(80), "80"},
+	{float64(-1), "-1"},
+	{0.5, "0.5"},
+	{-0.25, "-0.25"},
+	{1e21, "1e+21"},
+	{1.5e-7, "1.5e-07"},
+	{math.MaxFloat64, "1.7976931348623157e+308"},
+	{math.Copysign(0, -1), "-0"},
+
+	// `json.Number`s keep their text, e.g., integers too large for a
+	// float64 to hold exactly.
+	{json.Number("12345678901234567890"), "12345678901234567890"},
+	{json.Number("1.50"), "1.50"},
+	{json.Number("-2E+3"), "-2E+3"},
+	{json.Number("0"), "0"},
+
+	// Arrays, including nested and empty ones.
+	{[]interface{}{}, "[]"},
+	{[]interface{}{nil}, "[null]"},
+	{[]interface{}{float64(1), "a", true, nil}, `[1, "a", true, null]`},
+	{[]interface{}{[]interface{}{}, []interface{}{[]interface{}{float64(1)}}}, "[[], [[1]]]"},
+
+	// Objects, whose fields are sorted, and whose keys are only quoted
+	// if they must be.
+	{map[string]interface{}{}, "{}"},
+	{map[string]interface{}{"name": "x"}, `{name: "x"}`},
+	{map[string]interface{}{"b": float64(2), "a": float64(1), "_c": float64(3)}, "{_c: 3, a: 1, b: 2}"},
+	{
+		map[string]interface{}{
+			"$ref":                            "#/definitions/io.k8s.api.core.v1.Pod",
+			"x-kubernetes-group-version-kind": nil,
+			"local":                           true,
+			"self":                            false,
+			"1st":                             float64(1),
+			"":                                "empty",
+			"has space":                       "",
+			"a.b":                             "",
+			"quo\"te":                         "",
+		},
+		`{"": "empty", "$ref": "#/definitions/io.k8s.api.core.v1.Pod", "1st": 1, "a.b": "", "has space": "", "local": true, "quo\"te": "", "self": false, "x-kubernetes-group-version-kind": null}`,
+	},
+	{
+		map[string]interface{}{
+			"properties": map[string]interface{}{
+				"ports": map[string]interface{}{
+					"type":    "array",
+					"items":   map[string]interface{}{"type": "integer"},
+					"example": []interface{}{float64(80), float64(443)},
+				},
+			},
+			"required": []interface{}{"ports"},
+		},
+		`{properties: {ports: {example: [80, 443], items: {type: "integer"}, type: "array"}}, required: ["ports"]}`,
+	},
+}
+
+func TestLiteral(t *testing.T) {
+	for _, test := range literalTests {
+		actual, err := Literal(test.value)
+		if err != nil {
+			t.Errorf("Failed to write %#v:\n%v", test.value, err)
+		} else if actual != test.expected {
+			t.Errorf("Expected %#v to be written as:\n%s\ngot:\n%s", test.value, test.expected, actual)
+		}
+	}
+}
+
+func TestLiteralKeywordKeys(t *testing.T) {
+	for keyword := range jsonnetKeywordSet {
+		actual, err := Literal(map[string]interface{}{string(keyword): nil})
+		expected := `{"` + string(keyword) + `": null}`
+		if err != nil || actual != expected {
+			t.Errorf("Expected keyword key to be quoted as '%s', got '%s' (%v)", expected, actual, err)
+		}
+	}
+}
+
+func TestLiteralErrors(t *testing.T) {
+	for _, value := range []interface{}{
+		math.NaN(),
+		math.Inf(1),
+		math.Inf(-1),
+		json.Number(""),
+		json.Number("0x10"),
+		json.Number("1e"),
+		json.Number("01"),
+		json.Number("NaN"),
+		float32(1),
+		1,
+		[]string{"a"},
+		map[string]string{"a": "b"},
+		struct{}{},
+		// Nested values are checked too.
+		[]interface{}{float64(1), math.NaN()},
+		map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+	} {
+		if actual, err := Literal(value); err == nil {
+			t.Errorf("Expected an error writing %#v, got '%s'", value, actual)
+		}
+	}
+}
+
+// Decoding JSON, with numbers as `json.Number`s or as floats, and
+// writing it as a literal gives the same text for the same value, no
+// matter how the JSON's fields are ordered or spaced.
+func TestLiteralFromJSON(t *testing.T) {
+	documents := []string{
+		`{"type": "object", "properties": {"weight": {"type": "number", "example": 0.75}, "count": {"type": "integer", "example": 3}}, "example": null, "x-kubernetes-preserve-unknown-fields": true}`,
+		`{
+		  "x-kubernetes-preserve-unknown-fields": true,
+		  "example": null,
+		  "properties": {"count": {"example": 3, "type": "integer"}, "weight": {"example": 0.75, "type": "number"}},
+		  "type": "object"
+		}`,
+	}
+	expected := `{example: null, properties: {count: {example: 3, type: "integer"}, weight: {example: 0.75, type: "number"}}, type: "object", "x-kubernetes-preserve-unknown-fields": true}`
+
+	for _, document := range documents {
+		for _, useNumber := range []bool{false, true} {
+			decoder := json.NewDecoder(strings.NewReader(document))
+			if useNumber {
+				decoder.UseNumber()
+			}
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				t.Fatalf("Could not decode:\n%v", err)
+			}
+			actual, err := Literal(value)
+			if err != nil || actual != expected {
+				t.Errorf("Expected (useNumber: %t):\n%s\ngot:\n%s (%v)", useNumber, expected, actual, err)
+			}
+		}
+	}
+
+	// Literals with only quoted keys are JSON, and decode to the value
+	// they were written from.
+	value := []interface{}{"a\u2028b", float64(-3.5), map[string]interface{}{"$x": []interface{}{nil, false}}}
+	text, err := Literal(value)
+	if err != nil {
+		t.Fatalf("Failed to write literal:\n%v", err)
+	}
+	var decoded interface{}
+	if err := json.NewDecoder(bytes.NewReader([]byte(text))).Decode(&decoded); err != nil {
+		t.Fatalf("Expected '%s' to be JSON:\n%v", text, err)
+	}
+	again, _ := Literal(decoded)
+	if again != text {
+		t.Errorf("Expected '%s' to round-trip, got '%s'", text, again)
+	}
+}
@@ -0,0 +1,209 @@
+package jsonnet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// NamingStrategy decides how identifiers from the spec (e.g., the
+// property `externalIPs`) are turned into Jsonnet identifiers (e.g.,
+// the setter `externalIps`). It never affects field keys, which must
+// match the spec exactly.
+type NamingStrategy string
+
+const (
+	// NamingStrategyCurated lowercases the first letter of an
+	// identifier, and otherwise only rewrites the identifiers in the
+	// hand-curated per-version alias list (e.g., `hostIPC` ->
+	// `hostIpc`). The zero value of `NamingStrategy` is the same.
+	NamingStrategyCurated NamingStrategy = "curated"
+
+	// NamingStrategyInitialisms lowercases every initialism after its
+	// first letter, in the style of Go's initialisms (e.g.,
+	// `targetCPUUtilizationPercentage` ->
+	// `targetCpuUtilizationPercentage`, `externalIPs` -> `externalIps`),
+	// except for the per-version overrides in `kubeversion`.
+	NamingStrategyInitialisms NamingStrategy = "go-initialisms"
+
+	// NamingStrategyUniformParams names identifiers as
+	// `NamingStrategyCurated` does, and the parameters of every
+	// function of the library that takes a property (setters, mixins,
+	// constructors, and helpers) by a single rule; see
+	// `Naming.ParamName`.
+	NamingStrategyUniformParams NamingStrategy = "uniform-params"
+)
+
+// NamingStrategies lists the valid naming strategies.
+var NamingStrategies = []NamingStrategy{
+	NamingStrategyCurated, NamingStrategyInitialisms, NamingStrategyUniformParams,
+}
+
+// ParseNamingStrategy returns the naming strategy called `name`.
+func ParseNamingStrategy(name string) (NamingStrategy, error) {
+	names := []string{}
+	for _, ns := range NamingStrategies {
+		if string(ns) == name {
+			return ns, nil
+		}
+		names = append(names, string(ns))
+	}
+	return "", fmt.Errorf(
+		"Unknown naming strategy '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// RewriteAsIdentifier is `RewriteAsIdentifier`, using this naming
+// strategy.
+func (ns NamingStrategy) RewriteAsIdentifier(
+	k8sVersion string, rawID fmt.Stringer,
+) Identifier {
+	return Naming{Strategy: ns}.RewriteAsIdentifier(k8sVersion, rawID)
+}
+
+// RewriteAsFuncParam is `RewriteAsFuncParam`, using this naming
+// strategy.
+func (ns NamingStrategy) RewriteAsFuncParam(
+	k8sVersion string, text kubespec.PropertyName,
+) FuncParam {
+	return Naming{Strategy: ns}.RewriteAsFuncParam(k8sVersion, text)
+}
+
+// Naming is a naming strategy, along with the version-specific data
+// whose identifier aliases and initialism overrides it applies. Its
+// zero value is the curated strategy, with `kubeversion.Default`.
+type Naming struct {
+	Strategy NamingStrategy
+	Data     *kubeversion.Data // Nil means `kubeversion.Default()`.
+}
+
+func (n Naming) data() *kubeversion.Data {
+	if n.Data == nil {
+		return kubeversion.Default()
+	}
+	return n.Data
+}
+
+// RewriteAsIdentifier is `RewriteAsIdentifier`, using this naming
+// strategy and data.
+func (n Naming) RewriteAsIdentifier(
+	k8sVersion string, rawID fmt.Stringer,
+) Identifier {
+	id := rawID.String()
+	if len(id) == 0 {
+		log.Panicf("Can't lowercase first letter of 0-rune string")
+	}
+
+	if n.Strategy != NamingStrategyInitialisms {
+		kindString := n.data().MapIdentifier(k8sVersion, id)
+		upper := strings.ToLower(kindString[:1])
+		return Identifier(upper + kindString[1:])
+	}
+	if override, ok := n.data().MapInitialism(k8sVersion, id); ok {
+		return Identifier(override)
+	}
+	return Identifier(normalizeInitialisms(id))
+}
+
+// SanitizePropertyID returns the identifier a property is preferably
+// written as, e.g., the name of its setter: `RewriteAsIdentifier`'s,
+// with a trailing underscore if that's reserved (e.g., `std_` for
+// `std`; see `IsReservedIdentifier`). Properties of the same object can
+// still have the same identifier (e.g., `replicas` and `Replicas`),
+// which the caller has to disambiguate.
+func (n Naming) SanitizePropertyID(k8sVersion string, name kubespec.PropertyName) Identifier {
+	id := n.RewriteAsIdentifier(k8sVersion, name)
+	if IsReservedIdentifier(id) {
+		return id + "_"
+	}
+	return id
+}
+
+// RewriteAsFuncParam is `RewriteAsFuncParam`, using this naming
+// strategy and data. With `NamingStrategyUniformParams`, it's
+// `ParamName`, so that keywords get a trailing underscore (e.g.,
+// `error_`) rather than a `Param` suffix.
+func (n Naming) RewriteAsFuncParam(
+	k8sVersion string, text kubespec.PropertyName,
+) FuncParam {
+	if n.UniformParams() {
+		return n.ParamName(k8sVersion, text, nil)
+	}
+	id := n.RewriteAsIdentifier(k8sVersion, text)
+	if _, ok := jsonnetKeywordSet[kubespec.PropertyName(id)]; ok {
+		return FuncParam(fmt.Sprintf("%sParam", id))
+	}
+	return FuncParam(id)
+}
+
+// UniformParams reports whether parameters are named by `ParamName`,
+// i.e., whether the strategy is `NamingStrategyUniformParams`.
+func (n Naming) UniformParams() bool {
+	return n.Strategy == NamingStrategyUniformParams
+}
+
+// ParamName returns the name of a parameter that takes the property
+// `name`, by the rule of `NamingStrategyUniformParams`: its lowerCamel
+// identifier, as `RewriteAsIdentifier` writes it, which keeps plurals
+// (e.g., `volumeClaimTemplates`), with a trailing underscore while
+// that's reserved (e.g., `std_`, `local_`; see `IsReservedIdentifier`)
+// or in `taken`, the names of the function's other parameters, and of
+// properties it collides with.
+func (n Naming) ParamName(
+	k8sVersion string, name kubespec.PropertyName, taken map[string]bool,
+) FuncParam {
+	id := string(n.RewriteAsIdentifier(k8sVersion, name))
+	for IsReservedIdentifier(Identifier(id)) || taken[id] {
+		id += "_"
+	}
+	return FuncParam(id)
+}
+
+// `normalizeInitialisms` rewrites `id` as lowerCamelCase, treating
+// each run of upper-case letters as a word, e.g., `podCIDR` ->
+// `podCidr`. If the run is followed by a lower-case letter, its last
+// letter starts the next word (`CPUUtilization` -> `CpuUtilization`),
+// unless the letter is a plural `s` ending the word (`IPs` -> `Ips`).
+// A leading run is lowercased entirely (`APIService` -> `apiService`).
+func normalizeInitialisms(id string) string {
+	runes := []rune(id)
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		if !unicode.IsUpper(runes[i]) {
+			out = append(out, runes[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && unicode.IsUpper(runes[j]) {
+			j++
+		}
+		// Leave the start of the next word upper-case, unless the run
+		// is a pluralized initialism.
+		end := j
+		if j-i > 1 && j < len(runes) && unicode.IsLower(runes[j]) {
+			plural := runes[j] == 's' && (j+1 == len(runes) || !unicode.IsLower(runes[j+1]))
+			if !plural {
+				end = j - 1
+			}
+		}
+
+		for k := i; k < end; k++ {
+			if k == i && i > 0 {
+				out = append(out, runes[k])
+			} else {
+				out = append(out, unicode.ToLower(runes[k]))
+			}
+		}
+		if end < j {
+			out = append(out, runes[end])
+		}
+		i = j
+	}
+	return string(out)
+}
@@ -0,0 +1,122 @@
+package jsonnet
+
+import (
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+var initialismTests = map[string]Identifier{
+	"name":                       "name",
+	"readOnly":                   "readOnly",
+	"Pod":                        "pod",
+	"APIService":                 "apiService",
+	"HTTPGetAction":              "httpGetAction",
+	"containerID":                "containerId",
+	"clientCIDR":                 "clientCidr",
+	"serverAddressByClientCIDRs": "serverAddressByClientCidrs",
+	"nonResourceURLs":            "nonResourceUrls",
+	"insecureSkipTLSVerify":      "insecureSkipTlsVerify",
+	"SELinuxOptions":             "seLinuxOptions",
+	"CephFSVolumeSource":         "cephfsVolumeSource", // Override.
+}
+
+func TestNamingStrategyInitialisms(t *testing.T) {
+	for id, target := range initialismTests {
+		actual := NamingStrategyInitialisms.RewriteAsIdentifier(
+			"v1.7.0", kubespec.PropertyName(id))
+		if target != actual {
+			t.Errorf("Expected '%s' got '%s'", target, actual)
+		}
+	}
+
+	// The normalizer should agree with every hand-curated alias.
+	for id, target := range identifierTests {
+		actual := NamingStrategyInitialisms.RewriteAsIdentifier("v1.7.0", id)
+		if target != actual {
+			t.Errorf("Expected '%s' got '%s'", target, actual)
+		}
+	}
+
+	for keyword, target := range funcParamTests {
+		actual := NamingStrategyInitialisms.RewriteAsFuncParam("v1.7.0", keyword)
+		if target != actual {
+			t.Errorf("Expected '%s' got '%s'", target, actual)
+		}
+	}
+}
+
+func TestNamingStrategyCurated(t *testing.T) {
+	// Both the zero value and the named strategy preserve the default
+	// behavior.
+	for _, ns := range []NamingStrategy{"", NamingStrategyCurated} {
+		for id := range initialismTests {
+			name := kubespec.PropertyName(id)
+			target := RewriteAsIdentifier("v1.7.0", name)
+			if actual := ns.RewriteAsIdentifier("v1.7.0", name); target != actual {
+				t.Errorf("Expected '%s' got '%s'", target, actual)
+			}
+		}
+	}
+}
+
+func TestSanitizePropertyID(t *testing.T) {
+	for name, expected := range map[kubespec.PropertyName]Identifier{
+		"hostIPC":  "hostIpc",
+		"std":      "std_",
+		"error":    "error_",
+		"Replicas": "replicas",
+	} {
+		naming := Naming{Strategy: NamingStrategyInitialisms}
+		if actual := naming.SanitizePropertyID("v1.7.0", name); actual != expected {
+			t.Errorf("Expected '%s' to be sanitized as '%s', got '%s'", name, expected, actual)
+		}
+	}
+}
+
+func TestParamName(t *testing.T) {
+	naming := Naming{Strategy: NamingStrategyUniformParams}
+	for name, expected := range map[kubespec.PropertyName]FuncParam{
+		"storageClassName":     "storageClassName",
+		"volumeClaimTemplates": "volumeClaimTemplates", // Plurals are kept.
+		"hostIPC":              "hostIpc",              // Identifiers are curated.
+		"Replicas":             "replicas",
+		"std":                  "std_",
+		"local":                "local_",
+		"error":                "error_",
+	} {
+		if actual := naming.ParamName("v1.7.0", name, nil); actual != expected {
+			t.Errorf("Expected the parameter of '%s' to be '%s', got '%s'", name, expected, actual)
+		}
+		if actual := naming.RewriteAsFuncParam("v1.7.0", name); actual != expected {
+			t.Errorf("Expected '%s' to be rewritten as '%s', got '%s'", name, expected, actual)
+		}
+	}
+
+	// Names that are taken get underscores until they're free.
+	taken := map[string]bool{"name": true, "name_": true, "std_": true}
+	for name, expected := range map[kubespec.PropertyName]FuncParam{
+		"name": "name__",
+		"std":  "std__",
+		"key":  "key",
+	} {
+		if actual := naming.ParamName("v1.7.0", name, taken); actual != expected {
+			t.Errorf("Expected the parameter of '%s' to be '%s', got '%s'", name, expected, actual)
+		}
+	}
+	if naming.RewriteAsIdentifier("v1.7.0", kubespec.PropertyName("hostIPC")) != "hostIpc" ||
+		!naming.UniformParams() || (Naming{}).UniformParams() {
+		t.Errorf("Expected the uniform strategy to name identifiers as the curated one does")
+	}
+}
+
+func TestParseNamingStrategy(t *testing.T) {
+	for _, ns := range NamingStrategies {
+		if parsed, err := ParseNamingStrategy(string(ns)); err != nil || parsed != ns {
+			t.Errorf("Expected '%s' to parse, got '%s' and %v", ns, parsed, err)
+		}
+	}
+	if _, err := ParseNamingStrategy("snake_case"); err == nil {
+		t.Errorf("Expected unknown naming strategy to fail to parse")
+	}
+}
@@ -6,11 +6,8 @@ package jsonnet
 
 import (
 	"fmt"
-	"log"
-	"strings"
 
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
-	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
 )
 
 // FieldKey represents the literal text of a key for some JSON object
@@ -64,11 +61,7 @@ func RewriteAsFieldKey(text kubespec.PropertyName) FieldKey {
 func RewriteAsFuncParam(
 	k8sVersion string, text kubespec.PropertyName,
 ) FuncParam {
-	id := RewriteAsIdentifier(k8sVersion, text)
-	if _, ok := jsonnetKeywordSet[kubespec.PropertyName(id)]; ok {
-		return FuncParam(fmt.Sprintf("%sParam", id))
-	}
-	return FuncParam(id)
+	return Naming{}.RewriteAsFuncParam(k8sVersion, text)
 }
 
 // RewriteAsIdentifier takes a `GroupName`, `ObjectKind`,
@@ -83,15 +76,17 @@ func RewriteAsFuncParam(
 func RewriteAsIdentifier(
 	k8sVersion string, rawID fmt.Stringer,
 ) Identifier {
-	var id = rawID.String()
-
-	if len(id) == 0 {
-		log.Fatalf("Can't lowercase first letter of 0-rune string")
-	}
-	kindString := kubeversion.MapIdentifier(k8sVersion, id)
+	return Naming{}.RewriteAsIdentifier(k8sVersion, rawID)
+}
 
-	upper := strings.ToLower(kindString[:1])
-	return Identifier(upper + kindString[1:])
+// RewriteGroupAsIdentifier takes the name of an API group, which may
+// be a DNS name (e.g., `route.openshift.io`), and converts it to a
+// Jsonnet-style identifier, by lowerCamelCase'ing its labels (e.g.,
+// `routeOpenshiftIo`). Names that are already identifiers (e.g.,
+// `apps`) are unchanged. It's `kubespec.SanitizeGroupID`, which
+// `kubespec.GroupIDMap` disambiguates.
+func RewriteGroupAsIdentifier(group string) string {
+	return kubespec.SanitizeGroupID(group)
 }
 
 var jsonnetKeywordSet = map[kubespec.PropertyName]string{
@@ -113,3 +108,25 @@ var jsonnetKeywordSet = map[kubespec.PropertyName]string{
 	"super":      "super",
 	"true":       "true",
 }
+
+// reservedIdentifierSet is the names that mean something in every
+// Jsonnet scope, and so mustn't be reused as generated identifiers
+// even where they'd parse: `std`, which a parameter of the same name
+// would shadow in the function's body (e.g., `std(std):: if
+// std.type(std) == ...`), and `$`. The keywords, which include `self`
+// and `super`, are reserved too; see `IsReservedIdentifier`.
+var reservedIdentifierSet = map[Identifier]bool{
+	"std": true,
+	"$":   true,
+}
+
+// IsReservedIdentifier reports whether `id` is a Jsonnet keyword or
+// otherwise reserved (e.g., `std`), so that it can't be used as the
+// name of a generated setter, namespace, or parameter without changing
+// what the generated code means.
+func IsReservedIdentifier(id Identifier) bool {
+	if _, ok := jsonnetKeywordSet[kubespec.PropertyName(id)]; ok {
+		return true
+	}
+	return reservedIdentifierSet[id]
+}
@@ -118,3 +118,40 @@ func TestRewriteAsIdentifier(t *testing.T) {
 		}
 	}
 }
+
+var groupIdentifierTests = map[string]string{
+	"apps":               "apps",
+	"route.openshift.io": "routeOpenshiftIo",
+	"apps.openshift.io":  "appsOpenshiftIo",
+	"apps.openshift":     "appsOpenshift",
+	"my-group.io":        "myGroupIo",
+}
+
+func TestRewriteGroupAsIdentifier(t *testing.T) {
+	for group, target := range groupIdentifierTests {
+		actual := RewriteGroupAsIdentifier(group)
+		if target != actual {
+			t.Errorf("Expected '%s' got '%s'", target, actual)
+		}
+	}
+}
+
+func TestIsReservedIdentifier(t *testing.T) {
+	for keyword := range fieldKeyTests {
+		if !IsReservedIdentifier(Identifier(keyword)) {
+			t.Errorf("Expected keyword '%s' to be reserved", keyword)
+		}
+	}
+	for _, id := range []Identifier{"std", "$", "self", "super"} {
+		if !IsReservedIdentifier(id) {
+			t.Errorf("Expected '%s' to be reserved", id)
+		}
+	}
+
+	// Identifiers that only contain a reserved one aren't.
+	for _, id := range []Identifier{"std_", "stdin", "Std", "selfLink", "superset", "localhost", "replicas"} {
+		if IsReservedIdentifier(id) {
+			t.Errorf("Expected '%s' not to be reserved", id)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// EmitAliases takes a swagger API specification, and returns the text
+// of `k.libsonnet`, which extends the library `Emit` generates with a
+// flattened alias for every top-level kind, e.g., `deployment` for
+// `apps.v1beta1.deployment`. Each alias points at the most stable
+// version of the kind. Under `Options.NamespacePrefix`, the aliases
+// are nested with the tree, e.g., `lib.k8s.deployment`.
+//
+// If the same kind exists in more than one API group (e.g., `Event`
+// in `core` and `events`), `Options.KindCollisions` decides the
+// aliases: by default, the group `kubeversion.PreferredGroup` names
+// gets the unqualified alias, and the others are qualified with their
+// group's short name (e.g., `eventsEvent`). Without a preference, the
+// first group in alphabetical order wins. Each collision is logged
+// with what was chosen.
+func EmitAliases(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	root := newRoot(spec, opts)
+	if err := joinErrors(root.errors); err != nil {
+		return nil, err
+	}
+
+	m := root.newLibraryWriter(newIndentWriter())
+	root.emitAliases(m)
+	return m.bytes()
+}
+
+// `alias` is a flattened name for a top-level API object.
+type alias struct {
+	name   string
+	object *apiObject
+}
+
+func (root *root) emitAliases(m *indentWriter) {
+	emitTemplatedHeader(m, root.spec, root.opts, AliasesFile, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", root.spec.Info.Version))
+	})
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf("local k8s = import %q;", root.importPath(LibraryFile)))
+	m.writeLine("")
+	m.writeLine("k8s + {")
+	m.indent()
+	root.openPrefix(m, "+::")
+
+	for _, alias := range root.aliases() {
+		if warning := alias.object.removalWarning(); warning != "" {
+			m.writeLine("// " + warning)
+		}
+		m.writeLine(fmt.Sprintf(
+			"%s:: k8s.%s,", alias.name, root.libraryPath(alias.object.path())))
+	}
+
+	root.closePrefix(m)
+	m.dedent()
+	m.writeLine("}")
+}
+
+// `aliases` returns the flattened alias of every top-level kind of
+// every group, as `newKindNames` named it, sorted by name.
+func (root *root) aliases() []*alias {
+	aliases := []*alias{}
+	for _, decision := range root.kindNames {
+		aliases = append(aliases, &alias{name: decision.name, object: decision.object})
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].name < aliases[j].name
+	})
+	return aliases
+}
+
+// `qualifiedAlias` returns the alias of the kind `id` qualified with
+// the namespace of its group, for groups whose short names collide,
+// e.g., `dbMysqlIoDatabase` for `database` in `db.mysql.io`.
+func qualifiedAlias(group *group, id string) string {
+	return group.path() + strings.ToUpper(id[:1]) + id[1:]
+}
+
+// `moreStableVersion` reports whether API version `a` should be
+// preferred over `b`, following Kubernetes' own ordering: GA versions
+// come before beta, which come before alpha, and higher numbers come
+// first within each. Versions that don't follow the convention come
+// last, in alphabetical order.
+func moreStableVersion(a, b kubespec.VersionString) bool {
+	rank := func(v kubespec.VersionString) (int, int, int, bool) {
+		version, ok := v.Parse()
+		stability := map[kubespec.VersionStage]int{
+			kubespec.StageGA: 2, kubespec.StageBeta: 1, kubespec.StageAlpha: 0,
+		}[version.Stage]
+		return stability, version.Major, version.Minor, ok
+	}
+
+	aStability, aMajor, aMinor, aOK := rank(a)
+	bStability, bMajor, bMinor, bOK := rank(b)
+	switch {
+	case aOK != bOK:
+		return aOK
+	case !aOK:
+		return a < b
+	case aStability != bStability:
+		return aStability > bStability
+	case aMajor != bMajor:
+		return aMajor > bMajor
+	default:
+		return aMinor > bMinor
+	}
+}
@@ -0,0 +1,82 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmitAliases(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/collisions.json")
+
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	text, err := EmitAliases(spec, Options{Logger: logger})
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	aliases := string(text)
+
+	expected := []string{
+		"deployment:: k8s.apps.v1.deployment,",
+		"event:: k8s.core.v1.event,",
+		"eventsEvent:: k8s.events.v1beta1.event,",
+		"extensionsDeployment:: k8s.extensions.v1beta1.deployment,",
+		"extensionsNetworkPolicy:: k8s.extensions.v1beta1.networkPolicy,",
+		"ingress:: k8s.extensions.v1beta1.ingress,",
+		"networkPolicy:: k8s.networking.v1.networkPolicy,",
+		"networkingIngress:: k8s.networking.v1beta1.ingress,",
+		"service:: k8s.core.v1.service,",
+	}
+	lines := []string{}
+	for _, line := range strings.Split(aliases, "\n") {
+		if strings.Contains(line, ":: k8s.") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected aliases:\n%s\ngot:\n%s",
+			strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+
+	collisions := map[string]string{}
+	for _, event := range logger.events["alias collision"] {
+		collisions[event["alias"].(string)] = event["chosen"].(string)
+	}
+	expectedCollisions := map[string]string{
+		"deployment":    "apps.v1.deployment",
+		"event":         "core.v1.event",
+		"ingress":       "extensions.v1beta1.ingress",
+		"networkPolicy": "networking.v1.networkPolicy",
+	}
+	if len(collisions) != len(expectedCollisions) {
+		t.Errorf("Expected collisions %v got %v", expectedCollisions, collisions)
+	}
+	for alias, chosen := range expectedCollisions {
+		if collisions[alias] != chosen {
+			t.Errorf("Expected '%s' to resolve to '%s' got '%s'", alias, chosen, collisions[alias])
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		again, err := EmitAliases(spec, Options{})
+		if err != nil {
+			t.Fatalf("Failed to emit aliases:\n%v", err)
+		}
+		if string(again) != aliases {
+			t.Fatalf("Expected aliases to be the same across runs")
+		}
+	}
+}
+
+func TestMoreStableVersion(t *testing.T) {
+	ordered := []kubespec.VersionString{
+		"v2", "v1", "v2beta1", "v1beta2", "v1beta1", "v1alpha1", "unversioned",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := ordered[i], ordered[i+1]
+		if !moreStableVersion(a, b) || moreStableVersion(b, a) {
+			t.Errorf("Expected '%s' to be more stable than '%s'", a, b)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// apiVersionsHeader begins the line of the header of `k8s.libsonnet`
+// that lists the group/versions of its kinds; see
+// `LibraryAPIVersions`.
+const apiVersionsHeader = "// API versions: "
+
+// `apiVersions` returns the `apiVersion` of every version of the
+// library that has top-level API objects, e.g., `apps/v1beta2` and
+// `v1`, sorted. Versions with only hidden objects aren't listed, since
+// nothing is created with them.
+func (root *root) apiVersions() []string {
+	apiVersions := []string{}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				if ao.isTopLevel {
+					apiVersions = append(apiVersions, va.apiVersion())
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(apiVersions)
+	return apiVersions
+}
+
+// LibraryAPIVersions returns the group/versions of the kinds of a
+// generated `k8s.libsonnet` (e.g., `apps/v1beta2`, `v1`), as its header
+// lists them. It reports false if the header doesn't, e.g., because
+// the library was generated by an older `ksonnet-gen`, or with
+// `Options.DiffFriendly`, which lists them in `apiVersions.json`
+// instead; see `ParseAPIVersions`.
+func LibraryAPIVersions(library []byte) ([]string, bool) {
+	for _, line := range strings.Split(string(library), "\n") {
+		if !strings.HasPrefix(line, "//") {
+			// The header is the comment at the top of the file.
+			break
+		} else if strings.HasPrefix(line, apiVersionsHeader) {
+			list := strings.TrimPrefix(line, apiVersionsHeader)
+			if list == "" {
+				return []string{}, true
+			}
+			return strings.Split(list, ", "), true
+		}
+	}
+	return nil, false
+}
+
+// APIVersions returns the group/versions of the kinds in the index,
+// as in `apiVersion` (e.g., `apps/v1beta2`, or `v1` for the core
+// group), sorted.
+func (si *SymbolIndex) APIVersions() []string {
+	seen := map[string]bool{}
+	for _, kind := range si.Kinds {
+		apiVersion := kind.Version
+		if kind.Group != "" {
+			apiVersion = fmt.Sprintf("%s/%s", kind.Group, kind.Version)
+		}
+		seen[apiVersion] = true
+	}
+	apiVersions := []string{}
+	for apiVersion := range seen {
+		apiVersions = append(apiVersions, apiVersion)
+	}
+	sort.Strings(apiVersions)
+	return apiVersions
+}
@@ -0,0 +1,36 @@
+package ksonnet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestLibraryAPIVersions(t *testing.T) {
+	library := emitTestSpec(t, "testdata/swagger.json", Options{})
+	expected := []string{"apps/v1beta1", "batch/v1", "batch/v2alpha1", "v1"}
+	apiVersions, ok := LibraryAPIVersions([]byte(library))
+	if !ok || !reflect.DeepEqual(apiVersions, expected) {
+		t.Errorf("Expected header to list %v, got %v (%t)", expected, apiVersions, ok)
+	}
+
+	// The symbol index agrees.
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	if actual := index.APIVersions(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected symbol index to list %v, got %v", expected, actual)
+	}
+
+	// Only versions with kinds are listed.
+	library = emitTestSpec(t, "testdata/versions.json", Options{OnlyVersions: []kubespec.GroupVersion{{Group: "apps", Version: "v1beta2"}}})
+	if apiVersions, _ := LibraryAPIVersions([]byte(library)); !reflect.DeepEqual(apiVersions, []string{"apps/v1beta2"}) {
+		t.Errorf("Expected header to list only 'apps/v1beta2', got %v", apiVersions)
+	}
+
+	if _, ok := LibraryAPIVersions([]byte(withoutHeader(library))); ok {
+		t.Errorf("Expected no group/versions from a library without the header")
+	}
+}
@@ -0,0 +1,129 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// The names of the files of the library, as `EmitArtifacts` keys them.
+const (
+	LibraryFile   = "k8s.libsonnet"
+	AliasesFile   = "k.libsonnet"
+	LabelsFile    = "labels.libsonnet"
+	ChecksumsFile = "SHA256SUMS" // See `Checksums`.
+)
+
+// Artifact is the text of a generated file, along with its SHA-256
+// digest, so that the file can be published with a checksum without
+// reading it back after it's written.
+type Artifact struct {
+	Text   []byte
+	SHA256 string // Hex-encoded.
+}
+
+// Artifacts are the files of a library, keyed by file name.
+type Artifacts map[string]*Artifact
+
+// NewArtifact computes the digest of `text`.
+func NewArtifact(text []byte) *Artifact {
+	digest := sha256.Sum256(text)
+	return &Artifact{Text: text, SHA256: hex.EncodeToString(digest[:])}
+}
+
+// EmitArtifacts returns the files `Emit`, `EmitAliases`, `EmitLabels`,
+// and `EmitIndexDoc` generate for `spec`, keyed by file name (see
+// `LibraryFile`, `AliasesFile`, `LabelsFile`, and `IndexDocFile`), or,
+// with `Options.SplitByGroup`, those of `EmitSplit` in place of
+// `Emit`'s, and, with `Options.DiffFriendly`, `EmitAPIVersions`'s
+// (`APIVersionsFile`) too, as, with `Options.EmitGVKIndex`, are
+// `EmitGVKIndex`'s (`GVKIndexFile`), and, with `Options.Extends`,
+// `EmitCoreExtensions`'s (`CoreExtensionsFile`). It fails if the files' imports of
+// each other don't resolve; see `VerifyImports`. Cancelling `ctx` stops `Emit`,
+// which is by far the slowest.
+func EmitArtifacts(
+	ctx context.Context, spec *kubespec.APISpec, opts Options,
+) (Artifacts, error) {
+	artifacts := Artifacts{}
+	library := func(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+		return Emit(ctx, spec, opts)
+	}
+	if opts.SplitByGroup {
+		files, err := EmitSplit(ctx, spec, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Could not emit '%s':\n%w", LibraryFile, err)
+		}
+		for name, text := range files {
+			artifacts[name] = NewArtifact(text)
+		}
+		library = nil
+	}
+	var apiVersions, gvkIndex, coreExtensions func(*kubespec.APISpec, Options) ([]byte, error)
+	if opts.DiffFriendly {
+		apiVersions = EmitAPIVersions
+	}
+	if opts.EmitGVKIndex {
+		gvkIndex = EmitGVKIndex
+	}
+	if opts.Extends != nil || len(opts.Extensions) > 0 {
+		coreExtensions = EmitCoreExtensions
+	}
+	emitters := []struct {
+		name string
+		emit func(*kubespec.APISpec, Options) ([]byte, error)
+	}{
+		{LibraryFile, library},
+		{AliasesFile, EmitAliases},
+		{LabelsFile, EmitLabels},
+		{IndexDocFile, EmitIndexDoc},
+		{APIVersionsFile, apiVersions},
+		{GVKIndexFile, gvkIndex},
+		{CoreExtensionsFile, coreExtensions},
+	}
+	// Only the library reports `Options.Metrics`, so that each
+	// generation counts its definitions once.
+	others := opts
+	others.Metrics = nil
+	for _, emitter := range emitters {
+		if emitter.emit == nil {
+			continue
+		}
+		emitterOpts := others
+		if emitter.name == LibraryFile {
+			emitterOpts = opts
+		}
+		text, err := emitter.emit(spec, emitterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("Could not emit '%s':\n%w", emitter.name, err)
+		}
+		artifacts[emitter.name] = NewArtifact(text)
+	}
+	if err := VerifyImports(artifacts, opts.ImportBase, opts.externalImports()...); err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+// Checksums returns the text of a `SHA256SUMS` file for `artifacts`,
+// in the format `sha256sum` reads and writes: a line per file, sorted
+// by name, with its digest and name separated by two spaces. Names
+// are as given, e.g., `tests/apps.jsonnet`, relative to the directory
+// the file is written to.
+func Checksums(artifacts map[string]*Artifact) []byte {
+	names := []string{}
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s  %s\n", artifacts[name].SHA256, name)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,52 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestEmitArtifacts(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	artifacts, err := EmitArtifacts(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if len(artifacts) != 4 {
+		t.Errorf("Expected 4 artifacts, got %d", len(artifacts))
+	}
+	if !bytes.Equal(artifacts[LibraryFile].Text, library) {
+		t.Errorf("Expected '%s' to be the text of the library", LibraryFile)
+	}
+	for name, artifact := range artifacts {
+		digest := sha256.Sum256(artifact.Text)
+		if expected := hex.EncodeToString(digest[:]); artifact.SHA256 != expected {
+			t.Errorf("Expected digest of '%s' to be '%s', got '%s'", name, expected, artifact.SHA256)
+		}
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	artifacts := map[string]*Artifact{
+		"tests/apps.jsonnet": NewArtifact([]byte("{}\n")),
+		"k8s.libsonnet":      NewArtifact([]byte("")),
+	}
+
+	// As `sha256sum` prints them.
+	expected := strings.Join([]string{
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  k8s.libsonnet",
+		"ca3d163bab055381827226140568f3bef7eaac187cebd76878e0b63e9e442356  tests/apps.jsonnet",
+		"",
+	}, "\n")
+	if actual := string(Checksums(artifacts)); actual != expected {
+		t.Errorf("Expected checksums:\n%s\ngot:\n%s", expected, actual)
+	}
+}
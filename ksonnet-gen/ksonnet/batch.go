@@ -0,0 +1,191 @@
+package ksonnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// VariantOptions describes one of the libraries `EmitMultiple`
+// generates from a spec.
+type VariantOptions struct {
+	// Name keys the artifacts and the error of the variant. It must be
+	// unique within a batch.
+	Name string
+
+	Options
+
+	// Keep, if set, restricts the variant to the definitions for which
+	// it returns true, and the definitions they refer to, as
+	// `kubespec.APISpec.Filter` does. It may be called concurrently.
+	Keep func(name kubespec.DefinitionName, def *kubespec.SchemaDefinition) bool
+}
+
+// Batch configures `EmitMultiple`.
+type Batch struct {
+	// Parallelism is the number of variants emitted at once. Values
+	// below 1 emit them one at a time. When it's above 1, the
+	// `Logger` and `Progress` callbacks of the variants may be called
+	// concurrently.
+	Parallelism int
+
+	// FailFast stops the batch at the first variant that fails, rather
+	// than reporting every failure once the rest have been emitted.
+	FailFast bool
+}
+
+// VariantErrors reports the variants of a batch that couldn't be
+// emitted, keyed by name.
+type VariantErrors map[string]error
+
+func (errs VariantErrors) Error() string {
+	names := []string{}
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := []string{}
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("Could not emit variant '%s':\n%v", name, errs[name]))
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Is reports whether the error of any variant is `target`, or wraps
+// it, e.g., `ErrUnparsableName`.
+func (errs VariantErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// EmitMultiple emits `variants` of the library for `spec` one at a
+// time, and reports every variant that fails; see `Batch.EmitMultiple`.
+func EmitMultiple(
+	ctx context.Context, spec *kubespec.APISpec, variants []VariantOptions,
+) (map[string]Artifacts, error) {
+	return Batch{}.EmitMultiple(ctx, spec, variants)
+}
+
+// EmitMultiple returns the artifacts `EmitArtifacts` generates for
+// each of `variants`, keyed by variant name. The result for a variant
+// is identical to that of `EmitArtifacts` for `spec` filtered by its
+// `Keep`, but the parsed spec and the closures of its definitions,
+// which filtering follows, are shared by every variant, rather than
+// recomputed for each.
+//
+// Unless `FailFast` is set, a variant that fails doesn't stop the
+// others: the artifacts of those that succeed are returned along with
+// a `VariantErrors` for those that don't. With `FailFast`, the
+// variants still being emitted are cancelled, and only the error of
+// the first one that failed is returned.
+func (b Batch) EmitMultiple(
+	ctx context.Context, spec *kubespec.APISpec, variants []VariantOptions,
+) (map[string]Artifacts, error) {
+	names := map[string]bool{}
+	for _, variant := range variants {
+		if variant.Name == "" {
+			return nil, fmt.Errorf("Every variant must have a name")
+		} else if names[variant.Name] {
+			return nil, fmt.Errorf("Variant '%s' is listed more than once", variant.Name)
+		}
+		names[variant.Name] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	closures := kubespec.NewClosureCache(spec)
+
+	type result struct {
+		name      string
+		artifacts Artifacts
+		err       error
+	}
+	jobs := make(chan VariantOptions)
+	results := make(chan result)
+	go func() {
+		defer close(jobs)
+		for _, variant := range variants {
+			select {
+			case jobs <- variant:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	workers := b.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for variant := range jobs {
+				artifacts, err := emitVariant(ctx, spec, closures, variant)
+				results <- result{variant.Name, artifacts, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	all, errs := map[string]Artifacts{}, VariantErrors{}
+	var failed VariantErrors
+	for r := range results {
+		switch {
+		case r.err == nil:
+			all[r.name] = r.artifacts
+		case !b.FailFast:
+			errs[r.name] = r.err
+		case failed == nil:
+			// The variants this cancels fail too, but only because of
+			// this one, so they aren't reported.
+			failed = VariantErrors{r.name: r.err}
+			cancel()
+		}
+	}
+	if failed != nil {
+		return nil, failed
+	} else if len(errs) > 0 {
+		return all, errs
+	}
+	return all, nil
+}
+
+// `emitVariant` emits one variant of a batch. The emitter panics on
+// conflicts in the spec, which would take down every other variant
+// along with it, so panics are reported as the variant's error: an
+// error panicked with (e.g., of `ErrUnparsableName`) as it is, and
+// anything else as of `ErrUnsupportedSchema`.
+func emitVariant(
+	ctx context.Context, spec *kubespec.APISpec, closures *kubespec.ClosureCache,
+	variant VariantOptions,
+) (artifacts Artifacts, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked, ok := r.(error)
+			if !ok {
+				panicked = kubespec.Categorize(ErrUnsupportedSchema, fmt.Errorf("%v", r))
+			}
+			artifacts, err = nil, panicked
+		}
+	}()
+	if variant.Keep != nil {
+		spec = closures.Filter(variant.Keep)
+	}
+	return EmitArtifacts(ctx, spec, variant.Options)
+}
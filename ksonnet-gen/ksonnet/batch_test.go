@@ -0,0 +1,138 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// Run with `-race`.
+func TestEmitMultiple(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	variants := []VariantOptions{
+		{Name: "all"},
+		{Name: "apps", Keep: kubespec.InGroups([]kubespec.GroupName{"apps"})},
+		{Name: "core", Keep: kubespec.InGroups([]kubespec.GroupName{"core"})},
+		{
+			Name:    "curated-batch",
+			Options: Options{DeprecateClusterScopedNamespace: true},
+			Keep:    kubespec.InGroups([]kubespec.GroupName{"batch"}),
+		},
+	}
+
+	for _, batch := range []Batch{{}, {Parallelism: 3}} {
+		artifacts, err := batch.EmitMultiple(context.Background(), spec, variants)
+		if err != nil {
+			t.Fatalf("Failed to emit variants:\n%v", err)
+		}
+		if len(artifacts) != len(variants) {
+			t.Errorf("Expected %d variants, got %d", len(variants), len(artifacts))
+		}
+
+		// Every variant matches the artifacts emitted for it alone.
+		for _, variant := range variants {
+			filtered := spec
+			if variant.Keep != nil {
+				filtered = spec.Filter(variant.Keep)
+			}
+			expected, err := EmitArtifacts(context.Background(), filtered, variant.Options)
+			if err != nil {
+				t.Fatalf("Failed to emit '%s':\n%v", variant.Name, err)
+			}
+			for name, artifact := range expected {
+				got, ok := artifacts[variant.Name][name]
+				if !ok || !bytes.Equal(got.Text, artifact.Text) || got.SHA256 != artifact.SHA256 {
+					t.Errorf("Expected '%s' of variant '%s' to match 'EmitArtifacts'", name, variant.Name)
+				}
+			}
+		}
+	}
+}
+
+// Batches of specs in different directories, only some of which are
+// in a git repository, emit at once without changing the working
+// directory. Run with `-race`.
+func TestEmitMultipleDirectories(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Could not get working directory:\n%v", err)
+	}
+	outside, err := ioutil.TempDir("", "spec")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(outside)
+	variants := []VariantOptions{
+		{Name: "apps", Keep: kubespec.InGroups([]kubespec.GroupName{"apps"})},
+		{Name: "core", Keep: kubespec.InGroups([]kubespec.GroupName{"core"})},
+	}
+
+	var wg sync.WaitGroup
+	for i, dir := range []string{outside, "testdata", outside, ".."} {
+		spec := loadTestSpec(t, "testdata/swagger.json")
+		spec.FilePath = dir
+		_, inRepository := getSHARevision(dir)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			artifacts, err := Batch{Parallelism: 2}.EmitMultiple(context.Background(), spec, variants)
+			if err != nil {
+				t.Errorf("Failed to emit variants of spec %d:\n%v", i, err)
+				return
+			}
+			for name, variant := range artifacts {
+				library := string(variant[LibraryFile].Text)
+				if strings.Contains(library, "OpenAPI spec is generated from") != inRepository {
+					t.Errorf("Expected the header of '%s' of spec %d to name the SHA of its repository only if it's in one", name, i)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if now, err := os.Getwd(); err != nil || now != cwd {
+		t.Errorf("Expected the working directory to stay '%s', got '%s' (%v)", cwd, now, err)
+	}
+}
+
+func TestEmitMultipleErrors(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	variants := []VariantOptions{
+		{Name: "all"},
+		{Name: "broken", Options: Options{Customizations: map[string]string{"nothing.here": "foo:: 1"}}},
+		{Name: "apps", Keep: kubespec.InGroups([]kubespec.GroupName{"apps"})},
+	}
+
+	// A failure is reported for its variant, and the others still emit.
+	artifacts, err := Batch{Parallelism: 2}.EmitMultiple(context.Background(), spec, variants)
+	var errs VariantErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs["broken"] == nil {
+		t.Fatalf("Expected an error for variant 'broken' alone, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Could not emit variant 'broken':\n") ||
+		!strings.Contains(err.Error(), "'nothing.here'") {
+		t.Errorf("Expected the error to name the variant and its problem, got:\n%v", err)
+	}
+	if len(artifacts) != 2 || artifacts["all"] == nil || artifacts["apps"] == nil {
+		t.Errorf("Expected the other variants to be emitted, got %d", len(artifacts))
+	}
+
+	// With `FailFast`, the first failure is all that's returned.
+	artifacts, err = Batch{FailFast: true}.EmitMultiple(context.Background(), spec, variants)
+	if !errors.As(err, &errs) || len(errs) != 1 || errs["broken"] == nil || artifacts != nil {
+		t.Errorf("Expected only the error for variant 'broken', got %v", err)
+	}
+
+	// Names must be unique.
+	duplicated := []VariantOptions{{Name: "all"}, {Name: "all"}}
+	if _, err := EmitMultiple(context.Background(), spec, duplicated); err == nil {
+		t.Errorf("Expected duplicate variant names to be an error")
+	}
+}
@@ -0,0 +1,233 @@
+package ksonnet_test
+
+// The benchmarks of this file are the baseline for changes made for
+// performance, and use only the exported API of `ksonnet` and
+// `kubespec`, as a program that embeds the generator would, so that
+// they double as checks that the API suffices for it. Run them with
+// `make bench`, which writes the results in the format `benchstat`
+// compares.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// benchmarkSources are the specs of the corpus whose definitions make
+// up the spec the benchmarks load; see `scaledSpec`.
+var benchmarkSources = []string{
+	"testdata/corpus/v1.9.0.json",
+	"testdata/corpus/v1.9.0-networking.json",
+}
+
+// benchmarkDefinitions is roughly the number of definitions of the
+// spec of Kubernetes 1.9, which `scaledSpec` copies the corpus's until
+// it has.
+const benchmarkDefinitions = 600
+
+// generationBudget is how long `TestGenerationBudget` lets generating
+// every spec of the corpus take. It's many times what it takes, so
+// that only a catastrophic slowdown fails it, even on a slow machine
+// or with `-race`.
+const generationBudget = 30 * time.Second
+
+// versionPattern matches the group and version of the names of the
+// definitions of API groups (e.g., `io.k8s.api.apps.v1beta1`), whose
+// major version `scaledSpec` renumbers.
+var versionPattern = regexp.MustCompile(`^(io\.k8s\.api\.[^.]+\.)v\d+`)
+
+// majorPattern matches the major version of a version, e.g., `v1` of
+// `v1beta1`.
+var majorPattern = regexp.MustCompile(`^v\d+`)
+
+// scaledSpec returns the JSON of a spec of the size of Kubernetes 1.9's,
+// made of the definitions of `benchmarkSources`, and copies of those of
+// their API groups in other versions, renumbered from `v2` on (e.g.,
+// `io.k8s.api.apps.v2beta1.Deployment`), whose refs and kinds are
+// renumbered with them, until it has `benchmarkDefinitions`.
+func scaledSpec(tb testing.TB) []byte {
+	var spec struct {
+		Swagger     string                            `json:"swagger"`
+		Info        *kubespec.SchemaInfo              `json:"info"`
+		Definitions map[string]map[string]interface{} `json:"definitions"`
+	}
+	sources := map[string]map[string]interface{}{}
+	for _, path := range benchmarkSources {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			tb.Fatalf("Could not read '%s':\n%v", path, err)
+		}
+		if err := json.Unmarshal(text, &spec); err != nil {
+			tb.Fatalf("Could not deserialize '%s':\n%v", path, err)
+		}
+		for name, def := range spec.Definitions {
+			sources[name] = def
+		}
+	}
+
+	names := []string{}
+	for name := range sources {
+		if versionPattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	spec.Definitions = map[string]map[string]interface{}{}
+	for name, def := range sources {
+		spec.Definitions[name] = def
+	}
+	for major := 2; len(spec.Definitions) < benchmarkDefinitions; major++ {
+		renumber := func(name string) string {
+			return versionPattern.ReplaceAllString(name, fmt.Sprintf("${1}v%d", major))
+		}
+		for _, name := range names {
+			spec.Definitions[renumber(name)] = renumbered(sources[name], major, renumber).(map[string]interface{})
+		}
+	}
+
+	text, err := json.Marshal(spec)
+	if err != nil {
+		tb.Fatalf("Could not serialize the scaled spec:\n%v", err)
+	}
+	return text
+}
+
+// renumbered returns a copy of `value`, part of the JSON of a
+// definition, with its refs renamed by `renumber`, and the versions of
+// its kinds renumbered to `major`.
+func renumbered(value interface{}, major int, renumber func(string) string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := map[string]interface{}{}
+		for key, field := range value {
+			switch ref, ok := field.(string); {
+			case key == "$ref" && ok:
+				copied[key] = "#/definitions/" + renumber(ref[len("#/definitions/"):])
+			case key == "version" && ok:
+				copied[key] = majorPattern.ReplaceAllString(ref, fmt.Sprintf("v%d", major))
+			default:
+				copied[key] = renumbered(field, major, renumber)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := []interface{}{}
+		for _, item := range value {
+			copied = append(copied, renumbered(item, major, renumber))
+		}
+		return copied
+	}
+	return value
+}
+
+// readSpec reads the spec of `text`, which is from the corpus, so that
+// the SHA of the repository can be recorded in what's generated.
+func readSpec(tb testing.TB, name string, text []byte) *kubespec.APISpec {
+	s, err := kubespec.ReadSpec(name, bytes.NewReader(text))
+	if err != nil {
+		tb.Fatalf("Failed to read spec:\n%v", err)
+	}
+	s.FilePath = "."
+	return s
+}
+
+func BenchmarkCorpusLoad(b *testing.B) {
+	text := scaledSpec(b)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readSpec(b, "v1.9.0.json", text)
+	}
+}
+
+func BenchmarkCorpusParseNames(b *testing.B) {
+	s := readSpec(b, "v1.9.0.json", scaledSpec(b))
+	names := []kubespec.DefinitionName{}
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// A fresh parser each time, so that every name is parsed rather
+		// than answered from the cache of an earlier iteration.
+		p := &kubespec.Parser{Prefixes: []string{kubespec.NativePrefix}}
+		for _, name := range names {
+			if _, err := p.ParseName(name); err != nil {
+				b.Fatalf("Failed to parse '%s':\n%v", name, err)
+			}
+		}
+	}
+}
+
+func BenchmarkCorpusReferenceGraph(b *testing.B) {
+	s := readSpec(b, "v1.9.0.json", scaledSpec(b))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if graph := s.ReferenceGraph(nil, 0, nil); len(graph.Nodes) != len(s.Definitions) {
+			b.Fatalf("Expected the graph to have %d nodes, got %d", len(s.Definitions), len(graph.Nodes))
+		}
+	}
+}
+
+func BenchmarkCorpusEmitAppsCore(b *testing.B) {
+	s := readSpec(b, "v1.9.0.json", scaledSpec(b)).
+		Filter(kubespec.InGroups([]kubespec.GroupName{"apps", "core"}))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ksonnet.Emit(context.Background(), s, ksonnet.Options{}); err != nil {
+			b.Fatalf("Failed to emit library:\n%v", err)
+		}
+	}
+}
+
+// BenchmarkCorpusGenerate reads the spec and emits every file of the
+// library from it, as `ksonnet-gen` does.
+func BenchmarkCorpusGenerate(b *testing.B) {
+	text := scaledSpec(b)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := readSpec(b, "v1.9.0.json", text)
+		if _, err := ksonnet.EmitArtifacts(context.Background(), s, ksonnet.Options{}); err != nil {
+			b.Fatalf("Failed to generate library:\n%v", err)
+		}
+	}
+}
+
+// TestGenerationBudget fails if generating every file of the library
+// from each spec of the corpus takes longer than `generationBudget`,
+// which catches catastrophic slowdowns without benchmarking.
+func TestGenerationBudget(t *testing.T) {
+	paths, err := filepath.Glob("testdata/corpus/*.json")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("Could not find the specs of the corpus:\n%v", err)
+	}
+	start := time.Now()
+	for _, path := range paths {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Could not read '%s':\n%v", path, err)
+		}
+		if _, err := ksonnet.EmitArtifacts(context.Background(), readSpec(t, path, text), ksonnet.Options{}); err != nil {
+			t.Fatalf("Failed to generate library from '%s':\n%v", path, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > generationBudget {
+		t.Errorf("Expected generating the corpus to take at most %v, took %v", generationBudget, elapsed)
+	}
+}
@@ -0,0 +1,143 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `newBetaAliases` chooses the top-level kinds of beta versions that
+// are emitted as aliases of the same kind in a GA version of their
+// group, because they're identical, as they often are right after a
+// kind is promoted. It maps the definition name of each to the GA kind
+// it's an alias of, or returns nil if `Options.FullBetaDuplicates` is
+// set.
+//
+// A beta kind is identical to a GA kind of the same name if their
+// structural hashes are the same (see
+// `kubespec.APISpec.StructuralHashes`), and their fields are emitted
+// the same, but for the hidden objects they refer to, as for
+// `Options.ShareIdenticalKinds`, so kinds whose schemas differ at all
+// are emitted in full. So are kinds whose namespaces are customized.
+// Of several identical GA versions, the latest is the one aliased.
+func (root *root) newBetaAliases() map[kubespec.DefinitionName]*apiObject {
+	if root.opts.FullBetaDuplicates {
+		return nil
+	}
+	hashes := root.spec.StructuralHashes()
+	text := func(ao *apiObject) string {
+		return hiddenRefPattern.ReplaceAllString(root.fieldsText(ao), "hidden")
+	}
+
+	aliases := map[kubespec.DefinitionName]*apiObject{}
+	for _, group := range root.groups.toSortedSlice() {
+		// The GA kinds of each name, latest first.
+		ga := map[kubespec.ObjectKind][]*apiObject{}
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.emitOrder() {
+				if ao.isTopLevel && va.version.Stage() == kubespec.StageGA && !root.customizes(ao.path()) {
+					ga[ao.name] = insertByMajor(ga[ao.name], ao)
+				}
+			}
+		}
+		if len(ga) == 0 {
+			continue
+		}
+
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			if va.version.Stage() != kubespec.StageBeta {
+				continue
+			}
+			for _, ao := range va.emitOrder() {
+				if !ao.isTopLevel || root.customizes(ao.path()) {
+					continue
+				}
+				hash := hashes[ao.parsedName.Unparse()]
+				for _, candidate := range ga[ao.name] {
+					if hashes[candidate.parsedName.Unparse()] == hash && text(candidate) == text(ao) {
+						aliases[ao.parsedName.Unparse()] = candidate
+						break
+					}
+				}
+			}
+		}
+	}
+	root.logger().Log("alias beta kinds", "kinds", len(aliases))
+	return aliases
+}
+
+// `insertByMajor` inserts `ao` into `objects`, which are sorted by the
+// major version of their versions, latest first.
+func insertByMajor(objects []*apiObject, ao *apiObject) []*apiObject {
+	major := func(ao *apiObject) int {
+		version, _ := ao.parent.version.Parse()
+		return version.Major
+	}
+	i := 0
+	for i < len(objects) && major(objects[i]) >= major(ao) {
+		i++
+	}
+	objects = append(objects, nil)
+	copy(objects[i+1:], objects[i:])
+	objects[i] = ao
+	return objects
+}
+
+// `betaAliasOf` returns the GA kind that the top-level API object `ao`
+// is an alias of, if it is one; see `newBetaAliases`.
+func (root *root) betaAliasOf(ao *apiObject) (*apiObject, bool) {
+	ga, ok := root.betaAliases[ao.parsedName.Unparse()]
+	return ga, ok
+}
+
+// `libraryRef` returns the Jsonnet expression that refers to the
+// namespace of the top-level API object `ao` from anywhere in the file
+// it's in, e.g., `$.apps.v1.deployment` (`$.lib.k8s.apps.v1.deployment`
+// under `Options.NamespacePrefix`), or `$.v1.deployment` in the file of
+// its group, if the library is split by group.
+func (ao *apiObject) libraryRef() string {
+	if ao.root().opts.SplitByGroup {
+		return "$" + strings.TrimPrefix(ao.path(), ao.parent.parent.path())
+	}
+	return ao.root().rootRef() + "." + ao.path()
+}
+
+// `emitBetaAlias` emits the namespace of the beta kind `ao`, named
+// `jsonnetName`, as the namespace of the identical GA kind `ga`, whose
+// constructor sets the `apiVersion` of `ao`'s version instead, with a
+// comment that recommends `ga`, e.g.,
+// `deployment:: $.apps.v1.deployment + {new(labels=null):: super.new(labels) + apiVersion},`.
+// The namespace `emitNamespace` would emit is emitted to a scratch
+// writer, so that it's indexed as though it were.
+func (ao *apiObject) emitBetaAlias(
+	m *indentWriter, jsonnetName kubespec.ObjectKind, ga *apiObject,
+) {
+	scratch := newIndentWriter()
+	scratch.depth = m.depth
+	ao.root().withoutCounting(func() { ao.emitNamespace(scratch, jsonnetName) })
+
+	constructor := ao.root().index.lookup(fmt.Sprintf("%s.%s", ao.path(), constructorName))
+	signature := []string{}
+	params := []string{}
+	if constructor != nil {
+		for _, param := range constructor.Params {
+			params = append(params, param)
+			if value, ok := constructor.Defaults[param]; ok {
+				param = fmt.Sprintf("%s=%s", param, value)
+			}
+			signature = append(signature, param)
+		}
+	}
+
+	if len(ao.comments) > 0 {
+		m.writeLine("//")
+	}
+	m.writeLine(fmt.Sprintf(
+		"// Identical to `%s` (`%s`), its GA version, which is recommended instead. This is that namespace, whose constructor sets the apiVersion of this version.",
+		ga.path(), ga.parent.apiVersion()))
+	m.writeLine(fmt.Sprintf(
+		"%s:: %s + {%s(%s):: super.%s(%s) + apiVersion},",
+		jsonnetName, ga.libraryRef(), constructorName, strings.Join(signature, ", "),
+		constructorName, strings.Join(params, ", ")))
+}
@@ -0,0 +1,93 @@
+package ksonnet
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBetaAliases(t *testing.T) {
+	const path = "testdata/promoted.json"
+	library := emitTestSpec(t, path, Options{})
+
+	// `apps/v1beta2` `Deployment` is identical to `apps/v1`'s, but for
+	// the hidden object its spec refers to, which is identical too.
+	beta := versionText(library, "apps", "v1beta2")
+	const alias = "\n      deployment:: $.apps.v1.deployment + {new():: super.new() + apiVersion},\n"
+	if !strings.Contains(beta, alias) || strings.Contains(beta, "deployment:: {") {
+		t.Errorf("Expected 'apps.v1beta2.deployment' to be an alias of 'apps.v1.deployment':\n%s", beta)
+	}
+	if !strings.Contains(beta, "\n      // Identical to `apps.v1.deployment` (`apps/v1`), its GA version, which is recommended instead.") {
+		t.Errorf("Expected the alias to recommend the GA kind:\n%s", beta)
+	}
+
+	// `StatefulSet` differs by one property, so it's emitted in full.
+	if strings.Contains(beta, "statefulSet:: $") || !strings.Contains(objectText(beta, "statefulSet"), "revisionHistoryLimit(revisionHistoryLimit)::") {
+		t.Errorf("Expected 'apps.v1beta2.statefulSet' to be emitted in full:\n%s", beta)
+	}
+
+	full := emitTestSpec(t, path, Options{FullBetaDuplicates: true})
+	if text := versionText(full, "apps", "v1beta2"); strings.Contains(text, "Identical to") || !strings.Contains(text, "\n      deployment:: {\n") {
+		t.Errorf("Expected FullBetaDuplicates to emit 'apps.v1beta2.deployment' in full:\n%s", text)
+	}
+
+	// The symbols (and so the aliases) are those of the full kinds.
+	spec := loadTestSpec(t, path)
+	aliased, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	expanded, err := BuildSymbolIndex(spec, Options{FullBetaDuplicates: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if !reflect.DeepEqual(aliased, expanded) {
+		t.Errorf("Expected aliasing not to change the symbol index")
+	}
+
+	// A split library refers to the GA kind from its group's file.
+	split := emitSplitTestSpec(t, path, Options{SplitByGroup: true})["apps.libsonnet"]
+	if !strings.Contains(split, "deployment:: $.v1.deployment + {new():: super.new() + apiVersion},") {
+		t.Errorf("Expected the alias to refer to 'v1.deployment' of its group's file:\n%s", split)
+	}
+
+	// An alias keeps the signature of the constructor.
+	reviews := emitTestSpec(t, "testdata/reviews.json", Options{})
+	const constructor = "subjectAccessReview:: $.authorization.v1.subjectAccessReview + {new(user, verb, resource, namespace):: super.new(user, verb, resource, namespace) + apiVersion},"
+	if !strings.Contains(reviews, constructor) {
+		t.Errorf("Expected 'authorization.v1beta1.subjectAccessReview' to be:\n%s\nin:\n%s", constructor, reviews)
+	}
+}
+
+func TestBetaAliasesEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	spec := loadTestSpec(t, "testdata/promoted.json")
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// Each version's kind, made by its constructor, with every one of
+	// its one-parameter functions applied.
+	calls := []string{}
+	for _, kind := range []string{"apps.v1.deployment", "apps.v1beta2.deployment", "apps.v1beta2.statefulSet"} {
+		calls = append(calls, fmt.Sprintf("  %q: k.%s.new(),", kind, kind))
+		for _, symbol := range index.Symbols {
+			if symbol.Kind == SymbolFunction && len(symbol.Params) == 1 && strings.HasPrefix(symbol.Path, kind+".") {
+				calls = append(calls, fmt.Sprintf("  %q: k.%s.new() + k.%s(1),", symbol.Path, kind, symbol.Path))
+			}
+		}
+	}
+	program := "local k = import 'k8s.libsonnet';\n{\n" + strings.Join(calls, "\n") + "\n}\n"
+
+	expanded := evaluateLibrary(t, jsonnet, spec, Options{FullBetaDuplicates: true}, program)
+	if aliased := evaluateLibrary(t, jsonnet, spec, Options{}, program); aliased != expanded {
+		t.Errorf("Expected the aliased kinds to evaluate to:\n%s\ngot:\n%s", expanded, aliased)
+	}
+}
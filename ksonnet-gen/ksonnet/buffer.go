@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
 )
 
 // indentWriter abstracts the task of writing out indented text to a
@@ -21,6 +23,14 @@ type indentWriter struct {
 	depth  int
 	err    error
 	buffer bytes.Buffer
+
+	// Set only for writers made by `newCountingWriter`.
+	counts map[kubespec.DefinitionName]int
+	owners []kubespec.DefinitionName
+
+	// omitComments drops comment lines, for the library with
+	// `Options.OmitComments`; see `root.newLibraryWriter`.
+	omitComments bool
 }
 
 func newIndentWriter() *indentWriter {
@@ -32,15 +42,79 @@ func newIndentWriter() *indentWriter {
 	}
 }
 
+// newCountingWriter returns an `indentWriter` that discards its text,
+// and instead counts the bytes written on behalf of each definition,
+// i.e., while it is the innermost definition passed to `push`. Bytes
+// written outside of any definition are counted under "".
+func newCountingWriter() *indentWriter {
+	return &indentWriter{counts: map[kubespec.DefinitionName]int{}}
+}
+
 func (m *indentWriter) writeLine(text string) {
-	if m.err != nil {
+	if m.err != nil || (m.omitComments && strings.HasPrefix(text, "//")) {
 		return
 	}
 	prefix := strings.Repeat("  ", m.depth)
+	if text == "" {
+		prefix = ""
+	}
 	line := fmt.Sprintf("%s%s\n", prefix, text)
+	if m.counts != nil {
+		var owner kubespec.DefinitionName
+		if len(m.owners) > 0 {
+			owner = m.owners[len(m.owners)-1]
+		}
+		m.counts[owner] += len(line)
+		return
+	}
 	_, m.err = m.buffer.WriteString(line)
 }
 
+// `keepComments` calls `write`, keeping the comment lines it writes
+// even if the writer omits comments, e.g., for the header of a file.
+func (m *indentWriter) keepComments(write func()) {
+	omitComments := m.omitComments
+	m.omitComments = false
+	defer func() { m.omitComments = omitComments }()
+	write()
+}
+
+// `fork` returns a writer at the same depth, which omits comments and
+// counts bytes as `m` does, so that what's written to it can be written
+// to `m` later, with `join`, after text that depends on it.
+func (m *indentWriter) fork() *indentWriter {
+	return &indentWriter{
+		depth:        m.depth,
+		counts:       m.counts,
+		owners:       append([]kubespec.DefinitionName{}, m.owners...),
+		omitComments: m.omitComments,
+	}
+}
+
+// `join` writes the text of `fork`, a writer returned by `m.fork`, to
+// `m`. Counting writers have counted it already.
+func (m *indentWriter) join(fork *indentWriter) {
+	if m.err != nil {
+		return
+	} else if fork.err != nil {
+		m.err = fork.err
+		return
+	}
+	if m.counts == nil {
+		_, m.err = m.buffer.Write(fork.buffer.Bytes())
+	}
+}
+
+// `push` attributes the lines written until the matching `pop` to the
+// definition `name`, for counting writers.
+func (m *indentWriter) push(name kubespec.DefinitionName) {
+	m.owners = append(m.owners, name)
+}
+
+func (m *indentWriter) pop() {
+	m.owners = m.owners[:len(m.owners)-1]
+}
+
 func (m *indentWriter) bytes() ([]byte, error) {
 	if m.err != nil {
 		return nil, m.err
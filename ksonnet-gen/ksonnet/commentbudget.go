@@ -0,0 +1,165 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// CommentMode is how much of each description the library's comments
+// keep; see `CommentBudget`.
+type CommentMode string
+
+const (
+	// CommentsFull keeps the whole description. It's the default.
+	CommentsFull CommentMode = "full"
+
+	// CommentsFirstSentence keeps only the description's first
+	// sentence, e.g., `PodSpec is a description of a pod.`.
+	CommentsFirstSentence CommentMode = "first-sentence"
+)
+
+// CommentModes are the supported comment modes.
+var CommentModes = []CommentMode{CommentsFull, CommentsFirstSentence}
+
+// ParseCommentMode returns the comment mode named `s`.
+func ParseCommentMode(s string) (CommentMode, error) {
+	for _, mode := range CommentModes {
+		if s == string(mode) {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(CommentModes))
+	for i, mode := range CommentModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf(
+		"Unknown comment mode '%s'; expected one of: %s", s, strings.Join(names, ", "))
+}
+
+// CommentSection is a kind of comment of the library that
+// `Options.CommentBudgets` can bound on its own.
+type CommentSection string
+
+const (
+	// CommentSectionDefault is the budget of the sections that have
+	// none of their own.
+	CommentSectionDefault CommentSection = "default"
+
+	// CommentSectionKinds is the descriptions of the namespaces of
+	// definitions, e.g., `apps.v1.deployment`.
+	CommentSectionKinds CommentSection = "kinds"
+
+	// CommentSectionProperties is the descriptions of the setters and
+	// mixin namespaces of properties, e.g.,
+	// `apps.v1.deployment.mixin.spec.replicas`.
+	CommentSectionProperties CommentSection = "properties"
+)
+
+// CommentSections are the supported comment sections.
+var CommentSections = []CommentSection{
+	CommentSectionDefault, CommentSectionKinds, CommentSectionProperties,
+}
+
+// ParseCommentSection returns the comment section named `s`.
+func ParseCommentSection(s string) (CommentSection, error) {
+	for _, section := range CommentSections {
+		if s == string(section) {
+			return section, nil
+		}
+	}
+	names := make([]string, len(CommentSections))
+	for i, section := range CommentSections {
+		names[i] = string(section)
+	}
+	return "", fmt.Errorf(
+		"Unknown comment section '%s'; expected one of: %s", s, strings.Join(names, ", "))
+}
+
+// CommentBudget bounds how much of the spec's descriptions a section
+// of the library's comments keeps, to keep the library's size down: a
+// `Mode`, and at most `MaxLines` lines of each description, or no limit
+// if it's 0. A description cut short by `MaxLines` ends with a line
+// saying so, which points at `ksonnet-gen explain` for the whole of it.
+// The notes the library adds to descriptions (e.g., `@type` lines) are
+// always kept.
+type CommentBudget struct {
+	Mode     CommentMode `yaml:"mode"`
+	MaxLines int         `yaml:"maxLines"`
+}
+
+// truncationNote ends a description cut short by
+// `CommentBudget.MaxLines`; it's formatted with the name of the
+// definition it's from.
+const truncationNote = "… (truncated; `ksonnet-gen explain %s` has the full description)"
+
+// `trim` returns the lines of the comment that `description`, of the
+// definition `name` or one of its properties, becomes within the
+// budget.
+func (budget CommentBudget) trim(description string, name kubespec.DefinitionName) comments {
+	if description == "" {
+		return newComments(description)
+	}
+	if budget.Mode == CommentsFirstSentence {
+		description = firstSentence(description)
+	}
+	lines := newComments(description)
+	if budget.MaxLines <= 0 || len(lines) <= budget.MaxLines {
+		return lines
+	}
+	lines = lines[:budget.MaxLines]
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return append(lines, fmt.Sprintf(truncationNote, name))
+}
+
+// `commentBudget` returns the budget of a section of the library's
+// comments: its own, or else `CommentSectionDefault`'s.
+func (opts *Options) commentBudget(section CommentSection) CommentBudget {
+	if budget, ok := opts.CommentBudgets[section]; ok {
+		return budget
+	}
+	return opts.CommentBudgets[CommentSectionDefault]
+}
+
+// abbreviations end with a period, but not a sentence, except `etc.`
+// when the next word is capitalized.
+var abbreviations = map[string]bool{
+	"e.g.": true, "i.e.": true, "etc.": true, "vs.": true, "cf.": true, "approx.": true,
+}
+
+// `firstSentence` returns the first sentence of `text`, with its lines
+// joined by spaces: its words up to the first that ends with `.`, `?`
+// or `!` and is followed by another, unless it's an abbreviation (e.g.,
+// `e.g.`) or an initial; or the end of its first paragraph. Periods
+// within a word, as in `v1.16` or `kubernetes.io`, don't end it.
+func firstSentence(text string) string {
+	paragraph := strings.SplitN(strings.TrimSpace(text), "\n\n", 2)[0]
+	words := strings.Fields(paragraph)
+	for i := 0; i+1 < len(words); i++ {
+		if endsSentence(words[i], words[i+1]) {
+			return strings.Join(words[:i+1], " ")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// `endsSentence` reports whether `word`, followed by `next`, ends a
+// sentence; see `firstSentence`.
+func endsSentence(word, next string) bool {
+	if !strings.ContainsAny(word[len(word)-1:], ".?!") {
+		return false
+	}
+	bare := strings.ToLower(strings.TrimLeft(word, "(\"'`"))
+	if abbreviations[bare] {
+		return bare == "etc." && unicode.IsUpper([]rune(next)[0])
+	}
+	// An initial, e.g., the `A.` of `A. Name`.
+	if runes := []rune(bare); len(runes) == 2 && unicode.IsLetter(runes[0]) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package ksonnet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFirstSentence(t *testing.T) {
+	tests := map[string]string{
+		"Pod is a collection of containers. It runs on a host.": "Pod is a collection of containers.",
+		"Spans\nlines. And more.":                               "Spans lines.",
+		"Version 1.2 of something.":                             "Version 1.2 of something.",
+		"Deprecated in v1.16. Use apps/v1 instead.":             "Deprecated in v1.16.",
+		"A name, e.g. \"web\", or i.e. a label. More.":          "A name, e.g. \"web\", or i.e. a label.",
+		"Labels, annotations, etc. are kept. More.":             "Labels, annotations, etc. are kept.",
+		"Labels, annotations, etc. More follows.":               "Labels, annotations, etc.",
+		"Written by J. Smith. More.":                            "Written by J. Smith.",
+		"Is it set? If not, it's ignored.":                      "Is it set?",
+		"See kubernetes.io/docs.":                               "See kubernetes.io/docs.",
+		"A title\n\nThe first paragraph has no period":          "A title",
+		"": "",
+	}
+	for text, expected := range tests {
+		if actual := firstSentence(text); actual != expected {
+			t.Errorf("Expected the first sentence of %q to be %q, got %q", text, expected, actual)
+		}
+	}
+}
+
+func TestCommentBudgetTrim(t *testing.T) {
+	const description = "First line. Second sentence.\n\nSecond paragraph.\nLast line."
+	tests := []struct {
+		budget   CommentBudget
+		expected comments
+	}{
+		{CommentBudget{}, comments{"First line. Second sentence.", "", "Second paragraph.", "Last line."}},
+		{CommentBudget{Mode: CommentsFull, MaxLines: 4}, comments{"First line. Second sentence.", "", "Second paragraph.", "Last line."}},
+		{CommentBudget{Mode: CommentsFirstSentence}, comments{"First line."}},
+		{CommentBudget{Mode: CommentsFirstSentence, MaxLines: 1}, comments{"First line."}},
+		// Trailing blank lines aren't kept before the note.
+		{CommentBudget{MaxLines: 2}, comments{
+			"First line. Second sentence.",
+			"… (truncated; `ksonnet-gen explain io.k8s.api.core.v1.Pod` has the full description)",
+		}},
+	}
+	for _, test := range tests {
+		if actual := test.budget.trim(description, "io.k8s.api.core.v1.Pod"); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %+v to trim the description to %q, got %q", test.budget, test.expected, actual)
+		}
+	}
+	if actual := (CommentBudget{MaxLines: 1}).trim("", "x"); !reflect.DeepEqual(actual, comments{""}) {
+		t.Errorf("Expected an empty description to stay empty, got %q", actual)
+	}
+}
+
+func TestCommentBudgets(t *testing.T) {
+	const path = "testdata/comments.json"
+	const kind = "// Pod is a collection of containers that can run on a host. This resource is created by clients and scheduled onto hosts.\n      //\n      // Pods are the smallest deployable units of computing."
+	const property = "// Specifies the hostname of the Pod, e.g. \"web-0\", as of v1.16. If not specified, the pod's hostname will be set to a system-defined value."
+	full := emitTestSpec(t, path, Options{})
+	if !strings.Contains(full, kind) || !strings.Contains(full, property+"\n            // It must be a DNS label.") {
+		t.Fatalf("Expected whole descriptions by default:\n%s", full)
+	}
+
+	// Kinds stay whole while properties are trimmed.
+	opts := Options{CommentBudgets: map[CommentSection]CommentBudget{
+		CommentSectionDefault: {Mode: CommentsFirstSentence},
+		CommentSectionKinds:   {Mode: CommentsFull},
+	}}
+	library := emitTestSpec(t, path, opts)
+	if !strings.Contains(library, kind) {
+		t.Errorf("Expected the kind's description to be whole:\n%s", library)
+	}
+	const first = "// Specifies the hostname of the Pod, e.g. \"web-0\", as of v1.16.\n"
+	if !strings.Contains(library, first) || strings.Contains(library, "DNS label") {
+		t.Errorf("Expected only the property's first sentence:\n%s", library)
+	}
+
+	opts = Options{CommentBudgets: map[CommentSection]CommentBudget{
+		CommentSectionProperties: {MaxLines: 2},
+	}}
+	library = emitTestSpec(t, path, opts)
+	const truncated = "            // It must be a DNS label.\n            // … (truncated; `ksonnet-gen explain io.k8s.api.core.v1.PodSpec` has the full description)\n"
+	if !strings.Contains(library, truncated) || strings.Contains(library, "Longer names") {
+		t.Errorf("Expected the property's description to be truncated:\n%s", library)
+	}
+	if !strings.Contains(library, kind) {
+		t.Errorf("Expected the kind's description to be whole:\n%s", library)
+	}
+
+	// INDEX.md always summarizes the whole description.
+	opts = Options{CommentBudgets: map[CommentSection]CommentBudget{CommentSectionDefault: {MaxLines: 1}}}
+	doc, err := EmitIndexDoc(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit '%s':\n%v", IndexDocFile, err)
+	}
+	if !strings.Contains(string(doc), "| Pod is a collection of containers that can run on a host. |") {
+		t.Errorf("Expected '%s' to summarize the whole description:\n%s", IndexDocFile, doc)
+	}
+}
@@ -0,0 +1,217 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// DefaultCompactThreshold is the default `Options.CompactThreshold`.
+const DefaultCompactThreshold = 2
+
+// sharedMixinParam is the parameter of the shared mixins of
+// `Options.Compact`: the function that merges a partial object into
+// the object the mixins are mixed into, e.g., `function(metadata)
+// {metadata+: metadata}`.
+const sharedMixinParam = "__mixin"
+
+// `compaction` is the state of `Options.Compact`: which definitions
+// can have their mixins shared, which do, and how many bytes sharing
+// saves.
+type compaction struct {
+	// candidates are the definitions that more than
+	// `Options.CompactThreshold` properties refer to, and order all the
+	// definitions, each after those it refers to.
+	candidates map[kubespec.DefinitionName]bool
+	order      []kubespec.DefinitionName
+
+	// shared are the objects whose mixins are referred to, keyed by
+	// definition name.
+	shared map[kubespec.DefinitionName]*apiObject
+
+	// expanding is set while a namespace is emitted as it would be
+	// without `Options.Compact`, e.g., to index its symbols, and
+	// sharing while the shared mixins are, whose symbols aren't
+	// indexed, since they have no path of their own.
+	expanding bool
+	sharing   bool
+
+	// uncounted is set while text that isn't in the library is emitted,
+	// e.g., the namespaces of kinds that `Options.ShareIdenticalKinds`
+	// shares the body of, so that what it refers to isn't counted.
+	uncounted bool
+
+	// expanded and references are the bytes of the namespaces that
+	// refer to shared mixins, as they'd be without `Options.Compact`,
+	// and as they are; see `logCompaction`.
+	expanded   int
+	references int
+	sharedSize int
+}
+
+// `newCompaction` chooses the definitions whose mixins `Options.Compact`
+// shares, by how many properties refer to them in the spec's reference
+// graph, or returns nil if the option isn't set.
+func (root *root) newCompaction() *compaction {
+	if !root.opts.Compact {
+		return nil
+	}
+	threshold := root.opts.CompactThreshold
+	if threshold == 0 {
+		threshold = DefaultCompactThreshold
+	}
+
+	graph := root.spec.ReferenceGraph(nil, 0, nil)
+	c := &compaction{
+		candidates: map[kubespec.DefinitionName]bool{},
+		order:      graph.TopologicalOrder(),
+		shared:     map[kubespec.DefinitionName]*apiObject{},
+	}
+	for name, count := range graph.InDegrees() {
+		if count > threshold {
+			c.candidates[name] = true
+		}
+	}
+	return c
+}
+
+// `shares` reports whether the namespace at `path`, of the mixins of
+// the API object `ao`, refers to mixins shared with the other
+// properties that refer to it, rather than expanding them: those of
+// objects `Options.Compact` chooses, unless the namespace, or one in
+// it, is customized.
+func (root *root) shares(ao *apiObject, path string) bool {
+	c := root.compaction
+	if c == nil || c.expanding || !c.candidates[ao.parsedName.Unparse()] {
+		return false
+	}
+	return !root.customizes(path)
+}
+
+// `sharedMixinsName` is the name of the local function that returns the
+// shared mixins of an API object, e.g., `__hidden_meta_v1_objectMetaMixins`.
+func (ao *apiObject) sharedMixinsName() string {
+	return fmt.Sprintf("__%sMixins", strings.Replace(ao.path(), ".", "_", -1))
+}
+
+// `emitSharedRef` emits the namespace of the mixins of the API object
+// `ao`, which `p` (under `parentPath`) refers to, as a call of its shared
+// mixins, e.g., `metadata:: __hidden_meta_v1_objectMetaMixins(function(metadata)
+// {metadata+: metadata}),`, which evaluates to the same namespace as
+// `emitAsRefMixins` would expand. It's also expanded to a scratch
+// writer, so that it's indexed, and its size measured, as though it
+// were.
+func (ao *apiObject) emitSharedRef(
+	m *indentWriter, p *property, parentMixinName *string, parentPath string,
+) {
+	root := ao.root()
+	c := root.compaction
+	functionName := p.parent.identifier(p.name)
+	paramName := p.parent.funcParam(p.name)
+	merge := p.fieldObject(true, string(paramName))
+	if parentMixinName != nil {
+		merge = fmt.Sprintf("%s(%s)", *parentMixinName, merge)
+	}
+	line := fmt.Sprintf(
+		"%s:: %s(function(%s) %s),", functionName, ao.sharedMixinsName(), paramName, merge)
+
+	if !c.sharing {
+		expanded := newIndentWriter()
+		expanded.depth = m.depth
+		expanded.omitComments = m.omitComments
+		c.expanding = true
+		ao.emitAsRefMixins(expanded, p, parentMixinName, parentPath)
+		c.expanding = false
+		text, _ := expanded.bytes()
+		if !c.uncounted {
+			c.expanded += len(text)
+			c.references += len(strings.Repeat("  ", m.depth)) + len(line) + 1
+		}
+	}
+	c.shared[ao.parsedName.Unparse()] = ao
+	m.writeLine(line)
+}
+
+// `emitSharedMixins` emits each shared mixins function the library
+// refers to, as a local of the library, each after the mixins it refers
+// to. Since shared mixins refer to others, those are found first.
+func (root *root) emitSharedMixins(m *indentWriter) {
+	c := root.compaction
+	if c == nil {
+		return
+	}
+	index := root.index
+	root.index = newSymbolIndex(root.spec.Info.Version)
+	c.sharing = true
+	defer func() {
+		root.index = index
+		c.sharing = false
+	}()
+
+	for emitted := map[kubespec.DefinitionName]bool{}; len(emitted) < len(c.shared); {
+		names := []kubespec.DefinitionName{}
+		for name := range c.shared {
+			if !emitted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+		for _, name := range names {
+			c.shared[name].emitSharedMixins(newIndentWriter())
+			emitted[name] = true
+		}
+	}
+
+	before := m.buffer.Len()
+	for _, name := range c.order {
+		if ao, ok := c.shared[name]; ok {
+			ao.emitSharedMixins(m)
+		}
+	}
+	c.sharedSize = m.buffer.Len() - before
+}
+
+// `emitSharedMixins` emits the local function that returns the shared
+// mixins of an API object, given the function that merges a partial
+// object into the object they're mixed into.
+func (ao *apiObject) emitSharedMixins(m *indentWriter) {
+	m.push(ao.parsedName.Unparse())
+	defer m.pop()
+
+	m.writeLine(fmt.Sprintf(
+		"// The mixins of `%s`, which the namespaces of the properties that refer to it share.",
+		ao.parsedName.Unparse()))
+	m.writeLine(fmt.Sprintf("local %s(%s) = {", ao.sharedMixinsName(), sharedMixinParam))
+	m.indent()
+	ao.emitRefMixinFields(m, nil, sharedMixinParam, ao.sharedMixinsName(), false)
+	m.dedent()
+	m.writeLine("},")
+}
+
+// `withoutCounting` calls `emit`, which emits text that isn't in the
+// library, without counting what `Options.Compact` saves in it.
+func (root *root) withoutCounting(emit func()) {
+	c := root.compaction
+	if c == nil {
+		emit()
+		return
+	}
+	uncounted := c.uncounted
+	c.uncounted = true
+	defer func() { c.uncounted = uncounted }()
+	emit()
+}
+
+// `logCompaction` logs how much smaller `Options.Compact` made the
+// library, which is `size` bytes.
+func (root *root) logCompaction(size int) {
+	c := root.compaction
+	if c == nil {
+		return
+	}
+	root.logger().Log(
+		"compact", "shared", len(c.shared), "bytes", size,
+		"expanded", size-c.sharedSize-c.references+c.expanded)
+}
@@ -0,0 +1,155 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestCompact(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	library := emitTestSpec(t, "testdata/workloads.json", Options{})
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	opts := Options{Compact: true, Logger: logger}
+	compact := emitTestSpec(t, "testdata/workloads.json", opts)
+
+	for _, shared := range []string{
+		"\n  local __hidden_meta_v1_objectMetaMixins(__mixin) = {\n",
+		"\n          metadata:: __hidden_meta_v1_objectMetaMixins(function(metadata) {metadata+: metadata}),\n",
+		"\n            template:: __hidden_core_v1_podTemplateSpecMixins(function(template) __specMixin({template+: template})),\n",
+		// Shared mixins refer to each other, after which they're emitted.
+		"\n    metadata:: __hidden_meta_v1_objectMetaMixins(function(metadata) __mixin({metadata+: metadata})),\n",
+	} {
+		if !strings.Contains(compact, shared) {
+			t.Errorf("Expected the compact library to contain:\n%s", shared)
+		}
+	}
+	if strings.Index(compact, "local __hidden_meta_v1_objectMetaMixins(") > strings.Index(compact, "local __hidden_core_v1_podTemplateSpecMixins(") {
+		t.Errorf("Expected the mixins of 'ObjectMeta' before those of 'PodTemplateSpec', which refer to them")
+	}
+	if strings.Contains(library, "__mixin") {
+		t.Errorf("Expected no shared mixins by default")
+	}
+
+	// The summary's sizes are those of both libraries.
+	events := logger.events["compact"]
+	if len(events) != 1 {
+		t.Fatalf("Expected one 'compact' event, got %v", events)
+	}
+	if events[0]["bytes"] != len(compact) || events[0]["expanded"] != len(library) {
+		t.Errorf(
+			"Expected 'compact' to log %d bytes, expanded from %d, got %v",
+			len(compact), len(library), events[0])
+	}
+	if len(compact) >= len(library)*3/4 {
+		t.Errorf("Expected the compact library to be at least a quarter smaller, got %d bytes from %d", len(compact), len(library))
+	}
+
+	// The same symbols are indexed, since they're the same functions.
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	compactIndex, err := BuildSymbolIndex(spec, Options{Compact: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if !reflect.DeepEqual(index, compactIndex) {
+		t.Errorf("Expected the compact library to index the same symbols")
+	}
+
+	// Definitions referred to no more than the threshold are expanded.
+	if strings.Contains(emitTestSpec(t, "testdata/workloads.json", Options{Compact: true, CompactThreshold: 100}), "__mixin") {
+		t.Errorf("Expected no shared mixins above the threshold")
+	}
+}
+
+func TestCompactCustomizations(t *testing.T) {
+	compact := emitTestSpec(t, "testdata/workloads.json", Options{
+		Compact: true,
+		Customizations: map[string]string{
+			"apps.v1.deployment.mixin.spec.template.spec": "withNoRestarts():: self.restartPolicy('Never'),\n",
+		},
+	})
+
+	// The namespaces the customization is in are expanded, but those
+	// in them that aren't customized are still shared.
+	deployment := objectText(compact, "deployment")
+	if strings.Contains(deployment, "template:: __hidden_core_v1_podTemplateSpecMixins(") ||
+		!strings.Contains(deployment, "withNoRestarts():: self.restartPolicy('Never'),") ||
+		!strings.Contains(deployment, "metadata:: __hidden_meta_v1_objectMetaMixins(function(metadata) __templateMixin({metadata+: metadata})),") {
+		t.Errorf("Expected the customized template to be expanded, got:\n%s", deployment)
+	}
+	if !strings.Contains(objectText(compact, "job"), "template:: __hidden_core_v1_podTemplateSpecMixins(") {
+		t.Errorf("Expected the template of 'job' to be shared")
+	}
+}
+
+// The compact library evaluates the same as the expanded one. This
+// needs the `jsonnet` command.
+func TestCompactEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// Every one-parameter function of some representative kinds, called
+	// with `1`, which a setter accepts whatever its type.
+	calls := []string{}
+	for _, symbol := range index.Symbols {
+		for _, kind := range []string{"apps.v1.deployment.", "batch.v1.job.", "extensions.v1beta1.daemonSet."} {
+			if symbol.Kind == SymbolFunction && len(symbol.Params) == 1 && strings.HasPrefix(symbol.Path, kind) {
+				calls = append(calls, fmt.Sprintf("  %q: k.%s(1),", symbol.Path, symbol.Path))
+			}
+		}
+	}
+	if len(calls) == 0 {
+		t.Fatalf("Expected functions to call")
+	}
+	program := "local k = import 'k8s.libsonnet';\n{\n" + strings.Join(calls, "\n") + "\n}\n"
+
+	evaluate := func(opts Options) string { return evaluateLibrary(t, jsonnet, spec, opts, program) }
+	if expanded, compact := evaluate(Options{}), evaluate(Options{Compact: true}); expanded != compact {
+		t.Errorf("Expected the compact library to evaluate to:\n%s\ngot:\n%s", expanded, compact)
+	}
+}
+
+// `evaluateLibrary` evaluates the Jsonnet `program`, which imports the
+// library emitted from `spec` with `opts` as `k8s.libsonnet`, returning
+// its output.
+func evaluateLibrary(t *testing.T, jsonnet string, spec *kubespec.APISpec, opts Options, program string) string {
+	dir, err := ioutil.TempDir("", "evaluate")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+		t.Fatalf("Could not write library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.jsonnet"), []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	out, err := exec.Command(jsonnet, "-J", dir, filepath.Join(dir, "main.jsonnet")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Could not evaluate the library with %+v:\n%v\n%s", opts, err, out)
+	}
+	return string(out)
+}
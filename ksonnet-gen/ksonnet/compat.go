@@ -0,0 +1,64 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Compat is a legacy generator whose libraries `Options.Compat` keeps
+// the library compatible with, for apps written against them.
+type Compat string
+
+const (
+	// CompatKsonnet0 additionally emits the hidden helpers of the mixin
+	// namespaces of ksonnet 0.x's libraries (e.g.,
+	// `deployment.mixin.spec.mixinInstance(spec)`), as wrappers of the
+	// namespace's mixin, with a comment that deprecates them; see
+	// `kubeversion.LegacyHelpers`.
+	CompatKsonnet0 Compat = "ksonnet-0.x"
+)
+
+// Compats lists the valid compatibility modes.
+var Compats = []Compat{CompatKsonnet0}
+
+// ParseCompat returns the compatibility mode called `name`.
+func ParseCompat(name string) (Compat, error) {
+	names := []string{}
+	for _, compat := range Compats {
+		if string(compat) == name {
+			return compat, nil
+		}
+		names = append(names, string(compat))
+	}
+	return "", fmt.Errorf(
+		"Unknown compatibility mode '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// `emitLegacyHelpers` emits, with `CompatKsonnet0`, the legacy helpers
+// of the mixin namespace at `path`, for the property whose identifier
+// is `functionName`, as wrappers of its mixin, the local function
+// `mixinName`; e.g., `mixinInstance(spec):: __specMixin(spec),`. Which
+// helpers are emitted depends on the top-level kind the namespace is
+// under, the outermost definition `m` writes for.
+func (ao *apiObject) emitLegacyHelpers(
+	m *indentWriter, functionName, mixinName, paramName, path string,
+) {
+	root := ao.root()
+	if root.opts.Compat != CompatKsonnet0 || len(m.owners) == 0 {
+		return
+	}
+	owner, err := root.parser.ParseName(m.owners[0])
+	if err != nil {
+		return
+	}
+	helpers := root.kubeVersions.LegacyHelpers(root.spec.Info.Version, string(owner.Kind))
+	for _, helper := range helpers {
+		name := helper.Identifier(functionName)
+		m.writeLine(fmt.Sprintf(
+			"// DEPRECATED: ksonnet 0.x's `%s`; use the other functions of `%s` instead.",
+			name, path))
+		m.writeLine(fmt.Sprintf("%s(%s):: %s(%s),", name, paramName, mixinName, paramName))
+		root.index.add(path+"."+name, SymbolFunction, paramName)
+	}
+}
@@ -0,0 +1,84 @@
+package ksonnet
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCompatKsonnet0(t *testing.T) {
+	const path = "testdata/workloads.json"
+	if library := emitTestSpec(t, path, Options{}); strings.Contains(library, "mixinInstance") {
+		t.Errorf("Expected no legacy helpers by default:\n%s", library)
+	}
+
+	opts := Options{Compat: CompatKsonnet0}
+	library := emitTestSpec(t, path, opts)
+	const spec = `          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            // DEPRECATED: ksonnet 0.x's ` + "`mixinInstance`; use the other functions of `apps.v1.deployment.mixin.spec`" + ` instead.
+            mixinInstance(spec):: __specMixin(spec),
+            // DEPRECATED: ksonnet 0.x's ` + "`__specMixin`; use the other functions of `apps.v1.deployment.mixin.spec`" + ` instead.
+            __specMixin(spec):: __specMixin(spec),
+`
+	if !strings.Contains(library, spec) {
+		t.Errorf("Expected the legacy helpers of 'apps.v1.deployment.mixin.spec':\n%s\ngot:\n%s", spec, library)
+	}
+	// Nested namespaces wrap their own mixins.
+	if !strings.Contains(library, "mixinInstance(selector):: __selectorMixin(selector),") {
+		t.Errorf("Expected the legacy helpers of nested namespaces:\n%s", library)
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	for _, path := range []string{
+		"apps.v1.deployment.mixin.spec.mixinInstance",
+		"apps.v1.deployment.mixin.spec.template.spec.__specMixin",
+		"extensions.v1beta1.daemonSet.mixin.metadata.mixinInstance",
+	} {
+		if symbol, ok := symbols[path]; !ok || symbol.Kind != SymbolFunction {
+			t.Errorf("Expected the legacy helper '%s' to be indexed as a function, got %#v", path, symbol)
+		}
+	}
+	// Only the kinds listed by `kubeversion` have them.
+	if _, ok := symbols["batch.v1.job.mixin.spec.mixinInstance"]; ok {
+		t.Errorf("Expected 'batch.v1.job' to have no legacy helpers")
+	}
+}
+
+func TestCompatKsonnet0Evaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// An app written against ksonnet 0.x, against the shimmed library,
+	// evaluates to the same manifest as its modern equivalent, against
+	// the library without the shim.
+	const legacy = `local k = import 'k8s.libsonnet';
+local deployment = k.apps.v1.deployment;
+deployment.new({app: "web"}) +
+deployment.mixin.metadata.mixinInstance({name: "web", labels: {app: "web"}}) +
+deployment.mixin.spec.mixinInstance({replicas: 3}) +
+deployment.mixin.spec.template.spec.__specMixin({hostNetwork: true})
+`
+	const modern = `local k = import 'k8s.libsonnet';
+local deployment = k.apps.v1.deployment;
+deployment.new({app: "web"}) +
+deployment.mixin.metadata.name("web") +
+deployment.mixin.metadata.labels({app: "web"}) +
+deployment.mixin.spec.replicas(3) +
+deployment.mixin.spec.template.spec.hostNetwork(true)
+`
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	expected := evaluateLibrary(t, jsonnet, spec, Options{}, modern)
+	if out := evaluateLibrary(t, jsonnet, spec, Options{Compat: CompatKsonnet0}, legacy); out != expected {
+		t.Errorf("Expected the legacy app to evaluate to:\n%s\ngot:\n%s", expected, out)
+	}
+}
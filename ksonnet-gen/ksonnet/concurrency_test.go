@@ -0,0 +1,66 @@
+package ksonnet
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// Two generations with different `kubeversion` data, each with a
+// `Parser` of its own, can run at once without affecting each other.
+// Run it with `go test -race` to check that they share no mutable
+// state.
+func TestConcurrentGenerations(t *testing.T) {
+	overridden, err := kubeversion.Default().WithOverrides("v1.7.0", kubeversion.Overrides{
+		IDAliases: map[string]string{"image": "containerImage"},
+		BlacklistedProperties: map[string][]string{
+			"io.k8s.kubernetes.pkg.api.v1.Container": {"ports"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not override the data:\n%v", err)
+	}
+
+	const path = "testdata/workloads.json"
+	const rounds = 4
+	generations := []Options{{}, {KubeVersions: overridden}}
+	expected := []string{}
+	for _, opts := range generations {
+		expected = append(expected, emitTestSpec(t, path, opts))
+	}
+	if expected[0] == expected[1] {
+		t.Fatalf("Expected the overridden data to change the library")
+	}
+	if !strings.Contains(expected[1], "containerImage(containerImage):: {image: containerImage},") ||
+		strings.Contains(expected[1], "ports(ports)::") {
+		t.Fatalf("Expected the overrides in the library:\n%s", expected[1])
+	}
+
+	var wg sync.WaitGroup
+	for i, opts := range generations {
+		specs := []*kubespec.APISpec{}
+		for round := 0; round < rounds; round++ {
+			specs = append(specs, loadTestSpec(t, path))
+		}
+		wg.Add(1)
+		go func(i int, opts Options, specs []*kubespec.APISpec) {
+			defer wg.Done()
+			for _, spec := range specs {
+				text, err := Emit(context.Background(), spec, opts)
+				if err != nil {
+					t.Errorf("Failed to emit library %d:\n%v", i, err)
+					return
+				}
+				if string(text) != expected[i] {
+					t.Errorf("Expected library %d to be its sequential result, got:\n%s", i, text)
+					return
+				}
+			}
+		}(i, opts, specs)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,671 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+//-----------------------------------------------------------------------------
+// Constructor overrides.
+//-----------------------------------------------------------------------------
+
+// `constructorParam` is a parameter of a constructor override, along
+// with the fields of the constructed object it sets, written as dotted
+// paths (e.g., `spec.template.spec.containers`).
+type constructorParam struct {
+	name         string
+	defaultValue string // Jsonnet expression; empty if the param is required.
+	value        string // Jsonnet expression to set; defaults to `name`.
+	fields       []string
+
+	// dummy is the argument the smoke tests pass for the param, if a
+	// dummy of the type of its first field wouldn't do (e.g., because
+	// `value` wraps it in an object).
+	dummy string
+}
+
+// `constructorOverride` replaces the default `new()` constructor of an
+// API object with one that takes parameters and assembles the nested
+// fields they belong to. A param with no fields of its own is only
+// used in the values of the others (e.g., `servicePort`, which goes
+// in the same backend object as `serviceName`).
+type constructorOverride struct {
+	comment string
+	params  []constructorParam
+	tests   []helperTest
+}
+
+// containersValue accepts either a single container or an array of
+// them.
+const containersValue = `if std.type(containers) == "array" then containers else [containers]`
+
+// `constructorOverrides` is keyed by group and kind, rather than by
+// definition name, so that an override applies to every version of a
+// kind that is present in the spec (e.g., `CronJob` in both
+// `batch/v2alpha1` and `batch/v1beta1`). An override is only emitted
+// if every field it sets exists in that version's schema.
+var constructorOverrides = map[kubespec.GroupName]map[kubespec.ObjectKind]*constructorOverride{
+	"batch": {
+		"Job": {
+			comment: "Creates a `Job` that runs `containers` to completion. `labels` are set on both the job and its pod template.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"metadata.name"}},
+				{
+					name:   "containers",
+					value:  containersValue,
+					fields: []string{"spec.template.spec.containers"},
+				},
+				{
+					name:         "restartPolicy",
+					defaultValue: `"OnFailure"`,
+					fields:       []string{"spec.template.spec.restartPolicy"},
+				},
+				{
+					name:         "labels",
+					defaultValue: "{name: name}",
+					fields:       []string{"metadata.labels", "spec.template.metadata.labels"},
+				},
+			},
+		},
+		"CronJob": {
+			comment: "Creates a `CronJob` that runs `containers` to completion on `schedule`. `labels` are set on the cron job, its job template, and the job's pod template.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"metadata.name"}},
+				{name: "schedule", fields: []string{"spec.schedule"}},
+				{
+					name:   "containers",
+					value:  containersValue,
+					fields: []string{"spec.jobTemplate.spec.template.spec.containers"},
+				},
+				{
+					name:         "restartPolicy",
+					defaultValue: `"OnFailure"`,
+					fields:       []string{"spec.jobTemplate.spec.template.spec.restartPolicy"},
+				},
+				{
+					name:         "labels",
+					defaultValue: "{name: name}",
+					fields: []string{
+						"metadata.labels",
+						"spec.jobTemplate.metadata.labels",
+						"spec.jobTemplate.spec.template.metadata.labels",
+					},
+				},
+			},
+		},
+	},
+	"authentication": {
+		"TokenReview": {
+			comment: "Creates a review that asks the server to authenticate the bearer token `token`.",
+			params: []constructorParam{
+				{name: "token", fields: []string{"spec.token"}},
+			},
+			tests: []helperTest{{
+				name:       "tokenReview.new",
+				expression: `v.tokenReview.new("abc").spec`,
+				expected:   `{token: "abc"}`,
+			}},
+		},
+	},
+	"authorization": {
+		"SubjectAccessReview": {
+			comment: "Creates a review that asks whether `user` may `verb` (e.g., `\"get\"`) `resource` (e.g., `\"pods\"`) in `namespace`.",
+			params: []constructorParam{
+				{name: "user", fields: []string{"spec.user"}},
+				{name: "verb", fields: []string{"spec.resourceAttributes.verb"}},
+				{name: "resource", fields: []string{"spec.resourceAttributes.resource"}},
+				{name: "namespace", fields: []string{"spec.resourceAttributes.namespace"}},
+			},
+			tests: []helperTest{{
+				name:       "subjectAccessReview.new",
+				expression: `v.subjectAccessReview.new("jane", "get", "pods", "default").spec`,
+				expected:   `{resourceAttributes: {namespace: "default", resource: "pods", verb: "get"}, user: "jane"}`,
+			}},
+		},
+		"SelfSubjectAccessReview": {
+			comment: "Creates a review that asks whether the user making the request may `verb` (e.g., `\"get\"`) `resource` (e.g., `\"pods\"`) in `namespace`.",
+			params: []constructorParam{
+				{name: "verb", fields: []string{"spec.resourceAttributes.verb"}},
+				{name: "resource", fields: []string{"spec.resourceAttributes.resource"}},
+				{name: "namespace", fields: []string{"spec.resourceAttributes.namespace"}},
+			},
+			tests: []helperTest{{
+				name:       "selfSubjectAccessReview.new",
+				expression: `v.selfSubjectAccessReview.new("list", "secrets", "kube-system").spec`,
+				expected:   `{resourceAttributes: {namespace: "kube-system", resource: "secrets", verb: "list"}}`,
+			}},
+		},
+	},
+	"core": {
+		"PersistentVolumeClaim": {
+			comment: "Creates a claim named `name` for `storage` (a quantity, e.g., `\"10Gi\"`) of persistent storage, which may be mounted with `accessModes` (a mode, or an array of them).",
+			params: []constructorParam{
+				{name: "name", fields: []string{"metadata.name"}},
+				{
+					name:   "storage",
+					value:  `assert std.type(storage) == "string" : "'storage' must be a quantity string (e.g., \"10Gi\"), got " + std.toString(storage); {storage: storage}`,
+					fields: []string{"spec.resources.requests"},
+					dummy:  `"1Gi"`,
+				},
+				{
+					name:         "accessModes",
+					defaultValue: `["ReadWriteOnce"]`,
+					value:        `if std.type(accessModes) == "array" then accessModes else [accessModes]`,
+					fields:       []string{"spec.accessModes"},
+				},
+			},
+			tests: []helperTest{{
+				name:       "persistentVolumeClaim.new",
+				expression: `v.persistentVolumeClaim.new("data", "10Gi", "ReadOnlyMany").spec`,
+				expected:   `{accessModes: ["ReadOnlyMany"], resources: {requests: {storage: "10Gi"}}}`,
+			}},
+		},
+
+		// The objects that refer to others, which the helpers of
+		// `EnvVar`, `EnvFromSource` and `Volume` also accept.
+		"SecretKeySelector": {
+			comment: "Selects the key `key` of the secret named `name`.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"name"}},
+				{name: "key", fields: []string{"key"}},
+			},
+		},
+		"ConfigMapKeySelector": {
+			comment: "Selects the key `key` of the config map named `name`.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"name"}},
+				{name: "key", fields: []string{"key"}},
+			},
+		},
+		"ObjectFieldSelector": {
+			comment: "Selects the field at `fieldPath` (e.g., `\"metadata.name\"`) of the pod.",
+			params: []constructorParam{
+				{name: "fieldPath", fields: []string{"fieldPath"}},
+			},
+		},
+		"ResourceFieldSelector": {
+			comment: "Selects the resource `resource` (e.g., `\"limits.cpu\"`) of the container.",
+			params: []constructorParam{
+				{name: "resource", fields: []string{"resource"}},
+			},
+		},
+		"LocalObjectReference": {
+			comment: "Refers to the object named `name` in the same namespace.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"name"}},
+			},
+		},
+		"ObjectReference": {
+			comment: "Refers to the object of kind `kind` named `name` in `namespace`.",
+			params: []constructorParam{
+				{name: "kind", fields: []string{"kind"}},
+				{name: "name", fields: []string{"name"}},
+				{name: "namespace", fields: []string{"namespace"}},
+			},
+		},
+		"SecretReference": {
+			comment: "Refers to the secret named `name` in `namespace`.",
+			params: []constructorParam{
+				{name: "name", fields: []string{"name"}},
+				{name: "namespace", fields: []string{"namespace"}},
+			},
+		},
+		"TypedLocalObjectReference": {
+			comment: "Refers to the object of kind `kind` in the API group `apiGroup` named `name` in the same namespace.",
+			params: []constructorParam{
+				{name: "apiGroup", fields: []string{"apiGroup"}},
+				{name: "kind", fields: []string{"kind"}},
+				{name: "name", fields: []string{"name"}},
+			},
+		},
+	},
+	"extensions": {
+		"IngressTLS": ingressTLSOverride,
+	},
+	"networking": {
+		"IngressTLS": ingressTLSOverride,
+	},
+}
+
+// `ingressTLSOverride` is the same in every version of `Ingress`.
+var ingressTLSOverride = &constructorOverride{
+	comment: "Creates a TLS configuration that terminates TLS for `hosts` (a host, or an array of them) with the certificate in the secret `secretName`.",
+	params: []constructorParam{
+		{
+			name:   "hosts",
+			value:  `if std.type(hosts) == "array" then hosts else [hosts]`,
+			fields: []string{"hosts"},
+		},
+		{name: "secretName", fields: []string{"secretName"}},
+	},
+	tests: []helperTest{{
+		name:       "ingress.mixin.spec.tlsType.new",
+		expression: `v.ingress.mixin.spec.tlsType.new("example.com", "example-tls")`,
+		expected:   `{hosts: ["example.com"], secretName: "example-tls"}`,
+	}},
+}
+
+// `ingressPathV1beta1` and `ingressPathV1` are the path of an
+// `IngressRule`, whose backend names the service and port to route to.
+// `networking/v1` nests the backend's fields, takes the port as either
+// a name or a number, and requires a `pathType`.
+const (
+	ingressPathV1beta1 = `{backend: {serviceName: serviceName, servicePort: servicePort}, path: path}`
+	ingressPathV1      = `{backend: {service: {name: serviceName, port: if std.type(servicePort) == "string" then {name: servicePort} else {number: servicePort}}}, path: path, pathType: "Prefix"}`
+)
+
+const (
+	ingressRuleTestV1beta1 = `{host: "example.com", http: {paths: [{backend: {serviceName: "web", servicePort: 80}, path: "/"}]}}`
+	ingressRuleTestV1      = `{host: "example.com", http: {paths: [{backend: {service: {name: "web", port: {number: 80}}}, path: "/", pathType: "Prefix"}]}}`
+)
+
+// `ingressRuleOverride` returns a constructor for an `IngressRule` that
+// routes requests for `host` to one service, with `httpPath` as the
+// only element of `http.paths`.
+func ingressRuleOverride(httpPath, expected string) *constructorOverride {
+	return &constructorOverride{
+		comment: "Creates a rule that routes requests for `host` under `path` to port `servicePort` (a name or a number) of the service `serviceName`.",
+		params: []constructorParam{
+			{name: "host", fields: []string{"host"}},
+			{
+				name:   "serviceName",
+				value:  fmt.Sprintf("[%s]", httpPath),
+				fields: []string{"http.paths"},
+			},
+			{name: "servicePort"},
+			{name: "path", defaultValue: `"/"`},
+		},
+		tests: []helperTest{{
+			name:       "ingress.mixin.spec.rulesType.new",
+			expression: `v.ingress.mixin.spec.rulesType.new("example.com", "web", 80)`,
+			expected:   expected,
+		}},
+	}
+}
+
+// `versionedConstructorOverrides` is keyed by group and version (e.g.,
+// `networking/v1`), and then by kind, for kinds whose shape changed
+// between versions, so that each version gets a constructor that
+// builds the right one. It takes precedence over
+// `constructorOverrides`.
+var versionedConstructorOverrides = map[string]map[kubespec.ObjectKind]*constructorOverride{
+	"extensions/v1beta1": {
+		"IngressRule": ingressRuleOverride(ingressPathV1beta1, ingressRuleTestV1beta1),
+	},
+	"networking/v1beta1": {
+		"IngressRule": ingressRuleOverride(ingressPathV1beta1, ingressRuleTestV1beta1),
+	},
+	"networking/v1": {
+		"IngressRule": ingressRuleOverride(ingressPathV1, ingressRuleTestV1),
+	},
+}
+
+// `constructorOverride` returns the constructor override for an API
+// object, or nil if it has none (or if its schema lacks one of the
+// fields the override sets).
+func (ao *apiObject) constructorOverride() *constructorOverride {
+	groupVersion := fmt.Sprintf("%s/%s", ao.parent.parent.name, ao.parent.version)
+	override, ok := versionedConstructorOverrides[groupVersion][ao.name]
+	if !ok {
+		override, ok = constructorOverrides[ao.parent.parent.name][ao.name]
+	}
+	if !ok {
+		return nil
+	}
+
+	for _, param := range override.params {
+		for _, field := range param.fields {
+			if !ao.hasField(strings.Split(field, ".")) {
+				return nil
+			}
+		}
+	}
+	return override
+}
+
+// `constructorParams` returns the parameters of the constructor that
+// `emitConstructor` generates for the API object, which is nil for
+// the default `new()`.
+func (ao *apiObject) constructorParams() []constructorParam {
+	if override := ao.constructorOverride(); override != nil {
+		return override.params
+	} else if ls := ao.labelSelector(); ls != nil {
+		return []constructorParam{ls.constructorParam()}
+	}
+	return nil
+}
+
+// `hasField` reports whether the API object has the field at `path`,
+// following `$ref`s into the definitions of nested objects.
+func (ao *apiObject) hasField(path []string) bool {
+	return ao.field(path) != nil
+}
+
+// `field` returns the property at `path`, following `$ref`s into the
+// definitions of nested objects, or nil if there isn't one.
+func (ao *apiObject) field(path []string) *property {
+	pm, ok := ao.properties[kubespec.PropertyName(path[0])]
+	if !ok || pm.kind == typeAlias {
+		return nil
+	} else if len(path) == 1 {
+		return pm
+	}
+	child := pm.refObject()
+	if child == nil {
+		return nil
+	}
+	return child.field(path[1:])
+}
+
+// `refObject` returns the API object a property `$ref`s, whether it's
+// hidden or not, or nil if it doesn't refer to one whose fields are
+// emitted.
+func (p *property) refObject() *apiObject {
+	if p.ref == nil || p.opaque {
+		return nil
+	}
+	parsedName := p.root().parseName(*p.root().refName(p.ref))
+	if !parsedName.HasVersion() {
+		return nil
+	}
+	child, err := p.root().getAPIObjectHelper(parsedName, false)
+	if err != nil {
+		child, err = p.root().getAPIObjectHelper(parsedName, true)
+		if err != nil {
+			return nil
+		}
+	}
+	return child
+}
+
+func (co *constructorOverride) emit(
+	m *indentWriter, path string, isTopLevel bool, index *SymbolIndex,
+) {
+	paramNames := []string{}
+	signature := []string{}
+	defaults := map[string]string{}
+	fields := newFieldTree()
+	for _, param := range co.params {
+		paramNames = append(paramNames, param.name)
+		if param.defaultValue == "" {
+			signature = append(signature, param.name)
+		} else {
+			signature = append(
+				signature, fmt.Sprintf("%s=%s", param.name, param.defaultValue))
+			defaults[param.name] = param.defaultValue
+		}
+
+		value := param.value
+		if value == "" {
+			value = param.name
+		}
+		for _, field := range param.fields {
+			fields.set(strings.Split(field, "."), value)
+		}
+	}
+
+	// Only top-level objects have an `apiVersion` and `kind`.
+	base := ""
+	if isTopLevel {
+		base = "apiVersion + kind + "
+	}
+	m.writeLine("// " + co.comment)
+	m.writeLine(fmt.Sprintf(
+		"%s(%s):: %s{",
+		constructorName, strings.Join(signature, ", "), base))
+	m.indent()
+	fields.emit(m)
+	m.dedent()
+	m.writeLine("},")
+	symbol := index.add(
+		fmt.Sprintf("%s.%s", path, constructorName), SymbolFunction, paramNames...)
+	if len(defaults) > 0 {
+		symbol.Defaults = defaults
+	}
+}
+
+// `fieldTree` is a tree of nested object fields, emitted as a Jsonnet
+// object literal. Leaves hold the Jsonnet expression for their value.
+type fieldTree struct {
+	value    string
+	children map[string]*fieldTree
+}
+
+func newFieldTree() *fieldTree {
+	return &fieldTree{children: map[string]*fieldTree{}}
+}
+
+func (ft *fieldTree) set(path []string, value string) {
+	child, ok := ft.children[path[0]]
+	if !ok {
+		child = newFieldTree()
+		ft.children[path[0]] = child
+	}
+
+	if len(path) == 1 {
+		child.value = value
+	} else {
+		child.set(path[1:], value)
+	}
+}
+
+func (ft *fieldTree) emit(m *indentWriter) {
+	for _, name := range ft.sortedNames() {
+		child := ft.children[name]
+		fieldName := jsonnet.RewriteAsFieldKey(kubespec.PropertyName(name))
+		if len(child.children) == 0 {
+			m.writeLine(fmt.Sprintf("%s: %s,", fieldName, child.value))
+			continue
+		}
+
+		m.writeLine(fmt.Sprintf("%s: {", fieldName))
+		m.indent()
+		child.emit(m)
+		m.dedent()
+		m.writeLine("},")
+	}
+}
+
+// `sortedNames` returns the names of the tree's children in sorted
+// order, so that we can diff the output.
+func (ft *fieldTree) sortedNames() []string {
+	names := []string{}
+	for name := range ft.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// `mixin` returns the tree as a single-line Jsonnet object that merges
+// each nested object into the object it's added to, e.g.,
+// `{spec+: {replicas: replicas}}`.
+func (ft *fieldTree) mixin() string {
+	fields := []string{}
+	for _, name := range ft.sortedNames() {
+		child := ft.children[name]
+		fieldName := jsonnet.RewriteAsFieldKey(kubespec.PropertyName(name))
+		if len(child.children) == 0 {
+			fields = append(fields, fmt.Sprintf("%s: %s", fieldName, child.value))
+		} else {
+			fields = append(fields, fmt.Sprintf("%s+: %s", fieldName, child.mixin()))
+		}
+	}
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+//-----------------------------------------------------------------------------
+// Matching labels.
+//-----------------------------------------------------------------------------
+
+const (
+	matchingLabelsName        = "__matchingLabels"
+	withMatchingLabelsName    = "withMatchingLabels"
+	assertSelectorMatchesName = "assertSelectorMatches"
+)
+
+// `labelSelector` records where a workload (e.g., `Deployment`,
+// `StatefulSet`, `DaemonSet`) keeps its label selector and the labels
+// of its pod template, which the server requires to match.
+type labelSelector struct {
+	selector []string // e.g., `spec.selector.matchLabels`.
+	labels   []string // e.g., `spec.template.metadata.labels`.
+	required bool     // e.g., in `apps/v1`, where `spec.selector` is required.
+}
+
+// `labelSelector` finds the label selector of a top-level API object
+// by following the reference graph: the object's `spec` must have
+// exactly one property of type `LabelSelector`, and exactly one of
+// type `PodTemplateSpec`. It returns nil if the object isn't such a
+// workload, or if the server generates the selector itself, which is
+// the case for kinds with a `spec.manualSelector` field (e.g., `Job`).
+func (ao *apiObject) labelSelector() *labelSelector {
+	if !ao.isTopLevel {
+		return nil
+	}
+	spec, ok := ao.properties["spec"]
+	if !ok || spec.ref == nil || spec.opaque {
+		return nil
+	}
+	specName := *ao.root().refName(spec.ref)
+	specDef, ok := ao.root().spec.Definitions[specName]
+	if !ok {
+		return nil
+	} else if _, ok := specDef.Properties["manualSelector"]; ok {
+		return nil
+	}
+
+	var selectorName, templateName *kubespec.PropertyName
+	for propName, prop := range specDef.Properties {
+		if prop.Ref == nil {
+			continue
+		}
+		parsed, err := ao.root().parser.ParseName(*ao.root().refName(prop.Ref))
+		if err != nil {
+			continue
+		}
+
+		name := propName
+		switch parsed.Kind {
+		case "LabelSelector":
+			if selectorName != nil {
+				return nil
+			}
+			selectorName = &name
+		case "PodTemplateSpec":
+			if templateName != nil {
+				return nil
+			}
+			templateName = &name
+		}
+	}
+	if selectorName == nil || templateName == nil {
+		return nil
+	}
+
+	ls := &labelSelector{
+		selector: []string{"spec", string(*selectorName), "matchLabels"},
+		labels:   []string{"spec", string(*templateName), "metadata", "labels"},
+	}
+	if !ao.hasField(ls.selector) || !ao.hasField(ls.labels) {
+		return nil
+	}
+	for _, required := range specDef.Required {
+		if required == string(*selectorName) {
+			ls.required = true
+		}
+	}
+	return ls
+}
+
+// `emitMixin` emits a local mixin that sets both the selector and the
+// pod template labels, which the constructor and helpers are built on.
+func (ls *labelSelector) emitMixin(m *indentWriter) {
+	fields := newFieldTree()
+	fields.set(ls.selector, "labels")
+	fields.set(ls.labels, "labels")
+	m.writeLine(fmt.Sprintf(
+		"local %s(labels) = %s,", matchingLabelsName, fields.mixin()))
+}
+
+// `emitHelpers` emits `withMatchingLabels` and `assertSelectorMatches`.
+func (ls *labelSelector) emitHelpers(m *indentWriter, path string, index *SymbolIndex) {
+	selector := strings.Join(ls.selector, ".")
+	labels := strings.Join(ls.labels, ".")
+
+	m.writeLine(fmt.Sprintf(
+		"// Sets both `%s` and `%s` to `labels`, so that the selector matches the pods created from the template.",
+		selector, labels))
+	m.writeLine(fmt.Sprintf(
+		"%s(labels):: %s(labels),", withMatchingLabelsName, matchingLabelsName))
+	index.add(
+		fmt.Sprintf("%s.%s", path, withMatchingLabelsName), SymbolFunction, "labels")
+
+	m.writeLine(fmt.Sprintf(
+		"// Errors unless every label in `%s` is also in `%s`. Since it checks the final object, it can be added before or after other mixins.",
+		selector, labels))
+	m.writeLine(fmt.Sprintf("%s():: {", assertSelectorMatchesName))
+	m.indent()
+	m.writeLine(fmt.Sprintf("local selector = %s,", selfFieldOrEmpty(ls.selector)))
+	m.writeLine(fmt.Sprintf("local labels = %s,", selfFieldOrEmpty(ls.labels)))
+	m.writeLine(fmt.Sprintf(
+		"assert std.length([l for l in std.objectFields(selector) if !std.objectHas(labels, l) || labels[l] != selector[l]]) == 0 : \"'%s' must match '%s'\",",
+		selector, labels))
+	m.dedent()
+	m.writeLine("},")
+	index.add(
+		fmt.Sprintf("%s.%s", path, assertSelectorMatchesName), SymbolFunction)
+}
+
+// `emitConstructor` emits a constructor that sets the selector and
+// pod template labels from one map. `labels` is optional unless the
+// selector is required.
+func (ls *labelSelector) emitConstructor(m *indentWriter, path string, index *SymbolIndex) {
+	if ls.required {
+		m.writeLine(fmt.Sprintf(
+			"%s(labels):: apiVersion + kind + %s(labels),",
+			constructorName, matchingLabelsName))
+	} else {
+		m.writeLine(fmt.Sprintf(
+			"%s(labels=null):: apiVersion + kind + (if labels == null then {} else %s(labels)),",
+			constructorName, matchingLabelsName))
+	}
+	symbol := index.add(
+		fmt.Sprintf("%s.%s", path, constructorName), SymbolFunction, "labels")
+	if !ls.required {
+		symbol.Defaults = map[string]string{"labels": "null"}
+	}
+}
+
+// `constructorParam` describes the `labels` parameter of the
+// constructor emitted by `emitConstructor`.
+func (ls *labelSelector) constructorParam() constructorParam {
+	param := constructorParam{
+		name: "labels",
+		fields: []string{
+			strings.Join(ls.selector, "."),
+			strings.Join(ls.labels, "."),
+		},
+	}
+	if !ls.required {
+		param.defaultValue = "null"
+	}
+	return param
+}
+
+// `selfFieldOrEmpty` returns a Jsonnet expression for the field of
+// `self` at `path`, or `{}` if any part of it is missing.
+func selfFieldOrEmpty(path []string) string {
+	checks := []string{}
+	object := "self"
+	for _, name := range path {
+		checks = append(checks, fmt.Sprintf("std.objectHas(%s, \"%s\")", object, name))
+		object = fmt.Sprintf("%s.%s", object, name)
+	}
+	return fmt.Sprintf(
+		"if %s then %s else {}", strings.Join(checks, " && "), object)
+}
@@ -0,0 +1,225 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// corpusDir holds the end-to-end corpus: a trimmed spec for each of
+// `corpusSpecs`, as `[name].json`, and, in `[name]/[option]/`, the
+// files `EmitArtifacts` generates from it with each of
+// `corpusOptions`.
+const corpusDir = "testdata/corpus"
+
+// corpusSpecs are the specs of the corpus. Each is the subset (see
+// `kubespec.APISpec.MarshalSubset`) of `source` with the kinds of
+// `groups`, and the definitions they use, which `-update` rewrites
+// from the source. There's one for each supported version (see
+// `kubeversion.Supported`); other versions are rejected before
+// generation.
+var corpusSpecs = []struct {
+	name   string
+	source string
+	groups []kubespec.GroupName
+}{
+	{"v1.7.0", "testdata/swagger.json", []kubespec.GroupName{"apps", "batch"}},
+	{"v1.9.0", "testdata/versions.json", []kubespec.GroupName{"apps", "core"}},
+	{"v1.9.0-networking", "testdata/ingress.json", []kubespec.GroupName{"networking"}},
+}
+
+// corpusOptions are the options each spec of the corpus is generated
+// with, named as the directories of their goldens.
+var corpusOptions = []struct {
+	name string
+	opts Options
+}{
+	{"default", Options{}},
+	{"split", Options{SplitByGroup: true}},
+	{"compact", Options{Compact: true}},
+	{"no-comments", Options{OmitComments: true}},
+	{"uniform-params", Options{NamingStrategy: jsonnet.NamingStrategyUniformParams}},
+}
+
+// shaPattern matches the lines of the header of `k8s.libsonnet` with
+// the SHA of the repository, which changes with every commit.
+var shaPattern = regexp.MustCompile(`(?m)^(// SHA of [^:]*: ).*$`)
+
+// TestCorpus generates every file of the library from each spec of the
+// corpus with each of its options, and compares them to the goldens.
+// Run it with `-update` to rewrite the specs from their sources, and
+// the goldens from the specs.
+func TestCorpus(t *testing.T) {
+	for _, spec := range corpusSpecs {
+		specPath := filepath.Join(corpusDir, spec.name+".json")
+		subset := corpusSubset(t, spec.source, spec.groups)
+		if *updateGolden {
+			if err := ioutil.WriteFile(specPath, subset, 0644); err != nil {
+				t.Fatalf("Could not write corpus spec '%s':\n%v", specPath, err)
+			}
+		}
+		if text, err := ioutil.ReadFile(specPath); err != nil || string(text) != string(subset) {
+			t.Errorf("Expected '%s' to be the subset of '%s' with %v (run with -update to rewrite it)",
+				specPath, spec.source, spec.groups)
+		}
+
+		for _, c := range corpusOptions {
+			t.Run(spec.name+"/"+c.name, func(t *testing.T) {
+				artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, specPath), c.opts)
+				if err != nil {
+					t.Fatalf("Failed to emit library:\n%v", err)
+				}
+				files := map[string]string{}
+				for name, artifact := range artifacts {
+					files[name] = shaPattern.ReplaceAllString(string(artifact.Text), "${1}[SHA]")
+				}
+
+				dir := filepath.Join(corpusDir, spec.name, c.name)
+				if *updateGolden {
+					writeGoldenDir(t, dir, files)
+				}
+				compareGoldenDir(t, dir, files)
+			})
+		}
+	}
+}
+
+// `corpusSubset` returns the subset of the spec at `source` with the
+// kinds of `groups`, as `ksonnet-gen subset` writes it.
+func corpusSubset(t *testing.T, source string, groups []kubespec.GroupName) []byte {
+	text, err := loadTestSpec(t, source).Filter(kubespec.InGroups(groups)).MarshalSubset(true)
+	if err != nil {
+		t.Fatalf("Could not write the subset of '%s':\n%v", source, err)
+	}
+	return text
+}
+
+// `writeGoldenDir` replaces the goldens in `dir` with `files`, keyed by
+// their names in it, removing the files that aren't generated anymore.
+func writeGoldenDir(t *testing.T, dir string, files map[string]string) {
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("Could not remove golden directory '%s':\n%v", dir, err)
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Could not create golden directory '%s':\n%v", filepath.Dir(path), err)
+		}
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatalf("Could not write golden file '%s':\n%v", path, err)
+		}
+	}
+}
+
+// `compareGoldenDir` reports each of `files` that differs from its
+// golden in `dir`, with the lines that differ, and each golden there
+// that isn't generated.
+func compareGoldenDir(t *testing.T, dir string, files map[string]string) {
+	goldens := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		goldens[filepath.ToSlash(name)] = true
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Could not list golden directory '%s' (run with -update to write it):\n%v", dir, err)
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if !goldens[name] {
+			t.Errorf("Generated '%s', which has no golden (run with -update to write it)", path)
+			continue
+		}
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Could not read golden file '%s':\n%v", path, err)
+		}
+		if diff := lineDiff(string(expected), files[name]); diff != "" {
+			t.Errorf("Generated '%s' differs from its golden (run with -update to accept it):\n%s", path, diff)
+		}
+	}
+	for name := range goldens {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected '%s' to be generated (run with -update to remove it)", filepath.Join(dir, name))
+		}
+	}
+}
+
+// maxDiffLines is how many lines of each side `lineDiff` shows.
+const maxDiffLines = 20
+
+// `lineDiff` returns the lines that differ between `expected` and
+// `actual`, as a hunk of a unified diff: the lines between those
+// they start and end with in common, with the line before for context.
+// It returns "" if they're the same.
+func lineDiff(expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+	a, b := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	start := prefix - 1
+	if start < 0 {
+		start = 0
+	}
+	removed, added := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lines := []string{fmt.Sprintf(
+		"@@ -%d,%d +%d,%d @@", start+1, len(removed)+prefix-start, start+1, len(added)+prefix-start)}
+	for _, line := range a[start:prefix] {
+		lines = append(lines, " "+line)
+	}
+	for _, side := range []struct {
+		marker string
+		lines  []string
+	}{{"-", removed}, {"+", added}} {
+		for i, line := range side.lines {
+			if i == maxDiffLines {
+				lines = append(lines, fmt.Sprintf("%s... (%d more lines)", side.marker, len(side.lines)-i))
+				break
+			}
+			lines = append(lines, side.marker+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLineDiff(t *testing.T) {
+	if diff := lineDiff("a\nb\nc\n", "a\nb\nc\n"); diff != "" {
+		t.Errorf("Expected no diff of equal texts, got:\n%s", diff)
+	}
+	expected := "@@ -2,3 +2,2 @@\n b\n-c\n-d\n+x"
+	if diff := lineDiff("a\nb\nc\nd\ne\n", "a\nb\nx\ne\n"); diff != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+	expected = "@@ -1,0 +1,2 @@\n+z\n+a"
+	if diff := lineDiff("b\n", "z\na\nb\n"); diff != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+}
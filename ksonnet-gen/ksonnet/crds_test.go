@@ -0,0 +1,227 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// crdVersionsSpec returns the spec of `crd.json` with the CronTabs of
+// `crdversions.yaml`, in each version they serve, or, with
+// `includeUnserved`, that they list.
+func crdVersionsSpec(t *testing.T, includeUnserved bool) *kubespec.APISpec {
+	text, err := ioutil.ReadFile("testdata/crdversions.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	crds, err := kubespec.ReadCRDs("crdversions.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDs(crds, includeUnserved)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	return spec
+}
+
+// versionCronTab returns the text of the namespace of the CronTabs of
+// `version` of `stable.example.com`, or "" if there isn't one.
+func versionCronTab(library, version string) string {
+	start := strings.Index(library, "\n  stableExampleCom:: {\n")
+	if start == -1 {
+		return ""
+	}
+	at := strings.Index(library[start:], "\n    "+version+":: {\n")
+	if at == -1 {
+		return ""
+	}
+	return objectText(library[start+at:], "cronTab")
+}
+
+func TestCRDVersions(t *testing.T) {
+	opts := Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+	library, err := Emit(context.Background(), crdVersionsSpec(t, false), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+
+	// Each served version has a namespace, whose constructor sets its
+	// own apiVersion, with the fields of its own schema.
+	alpha := versionCronTab(string(library), "v1alpha1")
+	beta := versionCronTab(string(library), "v1beta1")
+	expected := []string{
+		`  stableExampleCom:: {`,
+		`      local apiVersion = {apiVersion: "stable.example.com/v1alpha1"},`,
+		`      local apiVersion = {apiVersion: "stable.example.com/v1beta1"},`,
+		"      // " + storageVersionNote,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(library), "\n"+line+"\n") {
+			t.Errorf("Expected library to contain line:\n%s", line)
+		}
+	}
+	if !strings.Contains(alpha, "cronSpec(cronSpec)::") || strings.Contains(alpha, "replicas(replicas)::") {
+		t.Errorf("Expected v1alpha1 to have the fields of its own schema:\n%s", alpha)
+	}
+	if !strings.Contains(beta, "replicas(replicas)::") {
+		t.Errorf("Expected v1beta1 to have the fields of its own schema:\n%s", beta)
+	}
+	if strings.Count(string(library), storageVersionNote) != 1 {
+		t.Errorf("Expected only the storage version to be noted:\n%s", library)
+	}
+	if strings.Contains(string(library), "v2alpha1") {
+		t.Errorf("Expected no namespace for the unserved version:\n%s", library)
+	}
+
+	index, err := BuildSymbolIndex(crdVersionsSpec(t, false), opts)
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	storage := map[string]bool{}
+	for _, kind := range index.Kinds {
+		if kind.Group == "stable.example.com" {
+			storage[kind.Version] = kind.Storage
+		}
+	}
+	if len(storage) != 2 || !storage["v1beta1"] || storage["v1alpha1"] {
+		t.Errorf("Expected the symbol index to mark only v1beta1 as stored, got %v", storage)
+	}
+
+	// Unserved versions are emitted if they're asked for, with the
+	// CRD's `validation` schema if they have none of their own.
+	library, err = Emit(context.Background(), crdVersionsSpec(t, true), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if unserved := versionCronTab(string(library), "v2alpha1"); !strings.Contains(unserved, "schedule(schedule)::") {
+		t.Errorf("Expected the unserved version to have the fields of `validation`:\n%s", unserved)
+	}
+}
+
+func TestCRDAPIVersions(t *testing.T) {
+	// `apiextensions.k8s.io/v1beta1` and `apiextensions.k8s.io/v1` CRDs,
+	// side by side.
+	crds := []*kubespec.CustomResourceDefinition{}
+	for _, file := range []string{"crdversions.yaml", "crdv1.yaml"} {
+		text, err := ioutil.ReadFile("testdata/" + file)
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		read, err := kubespec.ReadCRDs(file, text)
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		crds = append(crds, read...)
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	opts := Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if versionCronTab(string(library), "v1beta1") == "" {
+		t.Errorf("Expected the CronTabs of the v1beta1 CRD:\n%s", library)
+	}
+
+	runner := objectText(string(library), "jobRunner")
+	expected := []string{
+		// A field that preserves unknown fields accepts arbitrary JSON.
+		"The schema sets `x-kubernetes-preserve-unknown-fields`, so the server keeps fields it does not recognize.",
+		"configMixin(config):: __specMixin({config+: config}),",
+		// An embedded resource has setters for its apiVersion and kind,
+		// and the mixins of its metadata.
+		"apiVersion(apiVersion):: __templateMixin({apiVersion: apiVersion}),",
+		"kind(kind):: __templateMixin({kind: kind}),",
+		"local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),",
+	}
+	for _, line := range expected {
+		if !strings.Contains(runner, line) {
+			t.Errorf("Expected 'jobRunner' to contain:\n%s\ngot:\n%s", line, runner)
+		}
+	}
+	// Other objects' apiVersion and kind have none.
+	if strings.Contains(runner, "__specMixin({kind: kind})") {
+		t.Errorf("Expected no 'kind' setter outside the embedded resource:\n%s", runner)
+	}
+}
+
+func TestCRDsWithoutSpec(t *testing.T) {
+	// CRDs alone, with the apimachinery definitions of the embedded
+	// spec, and no others.
+	text, err := ioutil.ReadFile("testdata/crdv1.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	crds, err := kubespec.ReadCRDs("crdv1.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	empty := &kubespec.APISpec{
+		Info:        &kubespec.SchemaInfo{Title: "Kubernetes", Version: "v1.9.0"},
+		Definitions: kubespec.SchemaDefinitions{},
+		FilePath:    ".",
+	}
+	withMeta, added := empty.WithEmbeddedMeta(kubespec.ObjectMetaName)
+	if len(added) == 0 {
+		t.Fatalf("Expected the embedded spec to add ObjectMeta")
+	}
+	spec, err := withMeta.WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	opts := Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	runner := objectText(string(library), "jobRunner")
+	for _, line := range []string{
+		"local __metadataMixin(metadata) = {metadata+: metadata},",
+		"labels(labels):: __metadataMixin({labels+: labels}),",
+		"name(name):: __metadataMixin({name: name}),",
+		"metadataType:: hidden.meta.v1.objectMeta,",
+	} {
+		if !strings.Contains(runner, line) {
+			t.Errorf("Expected 'jobRunner' to contain:\n%s\ngot:\n%s", line, runner)
+		}
+	}
+	if !strings.Contains(string(library), "managedFieldsType:: hidden.meta.v1.managedFieldsEntry,") {
+		t.Errorf("Expected the hidden objects of the embedded spec:\n%s", library)
+	}
+
+	// Without it, custom resources have no metadata.
+	withoutMeta, err := empty.WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	library, err = Emit(context.Background(), withoutMeta, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Contains(string(library), "__metadataMixin") {
+		t.Errorf("Expected no metadata mixins without ObjectMeta:\n%s", library)
+	}
+
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping evaluation, since `jsonnet` isn't installed")
+	}
+	const program = `local k = import "k8s.libsonnet";
+local runner = k.batchExampleCom.v1.jobRunner;
+runner.new() + runner.mixin.metadata.name("nightly") + runner.mixin.metadata.labels({team: "data"})
+`
+	out := evaluateLibrary(t, jsonnet, spec, opts, program)
+	for _, field := range []string{`"name": "nightly"`, `"team": "data"`, `"kind": "JobRunner"`} {
+		if !strings.Contains(out, field) {
+			t.Errorf("Expected the custom resource to have %s, got:\n%s", field, out)
+		}
+	}
+}
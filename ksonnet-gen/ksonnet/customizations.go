@@ -0,0 +1,235 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `closeNamespace` ends the object emitted for the namespace at `path`
+// (e.g., `apps.v1beta1.deployment`), first merging in the user's
+// customizations for it, if any (see `Options.Customizations`). The
+// customizations are included verbatim, in an object merged into the
+// generated one, so they can refer to the generated fields with
+// `self` and `super`.
+func (root *root) closeNamespace(m *indentWriter, path string) {
+	root.closeObject(m, path, "},")
+}
+
+// `closeObject` is `closeNamespace`, ending the object with `end`,
+// e.g., `}` for an object that's the whole of a file.
+func (root *root) closeObject(m *indentWriter, path, end string) {
+	m.dedent()
+	text, ok := root.opts.Customizations[path]
+	if !ok {
+		m.writeLine(end)
+		return
+	}
+	root.customized[path] = true
+
+	fields, err := topLevelFields(text)
+	if err != nil {
+		root.errors = append(root.errors, fmt.Errorf(
+			"Could not parse customizations for '%s':\n%v", path, err))
+	}
+	generated := root.index.children(path)
+	for _, field := range fields {
+		if generated[field] && !root.opts.AllowOverrides {
+			root.errors = append(root.errors, fmt.Errorf(
+				"Customizations for '%s' define '%s', which is already generated; rename it, or allow overrides",
+				path, field))
+		}
+	}
+
+	m.writeLine("} + {")
+	m.indent()
+	m.writeLine(fmt.Sprintf("// Customizations for `%s`.", path))
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		m.writeLine(strings.TrimRight(line, " \t"))
+	}
+	m.dedent()
+	m.writeLine(end)
+}
+
+// `customizationErrors` reports the problems found while merging the
+// user's customizations into the library, including customizations
+// for namespaces that weren't generated.
+func (root *root) customizationErrors() error {
+	errors := root.errors
+	paths := []string{}
+	for path := range root.opts.Customizations {
+		if !root.customized[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		errors = append(errors, fmt.Errorf(
+			"Can't customize '%s', because the library has no such namespace", path))
+	}
+
+	return joinErrors(errors)
+}
+
+// `joinErrors` returns one error with the message of each of
+// `errors` on a line of its own, of each of their categories, or nil
+// if there are none; see `kubespec.JoinErrors`.
+func joinErrors(errors []error) error {
+	return kubespec.JoinErrors(errors)
+}
+
+// `topLevelFields` returns the names of the fields defined by `text`,
+// the body of a Jsonnet object (i.e., without the enclosing braces),
+// e.g., `withFoo` and `bar` for `withFoo(foo):: {foo: foo}, bar: 1`.
+// Object locals and assertions are skipped.
+func topLevelFields(text string) ([]string, error) {
+	fields := []string{}
+	s := &scanner{text: text}
+	for {
+		s.skipSpace()
+		if s.done() {
+			return fields, nil
+		}
+
+		name, err := s.fieldName()
+		if err != nil {
+			return nil, err
+		}
+		if name != "local" && name != "assert" {
+			fields = append(fields, name)
+		}
+		if err := s.skipToComma(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// `scanner` understands just enough Jsonnet lexical structure
+// (strings, comments, and brackets) to split an object body into
+// fields.
+type scanner struct {
+	text string
+	pos  int
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.text)
+}
+
+func (s *scanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.text[s.pos:], prefix)
+}
+
+// `skipSpace` skips whitespace and comments.
+func (s *scanner) skipSpace() {
+	for !s.done() {
+		switch {
+		case unicode.IsSpace(rune(s.text[s.pos])):
+			s.pos++
+		case s.hasPrefix("//") || s.hasPrefix("#"):
+			if end := strings.Index(s.text[s.pos:], "\n"); end != -1 {
+				s.pos += end + 1
+			} else {
+				s.pos = len(s.text)
+			}
+		case s.hasPrefix("/*"):
+			if end := strings.Index(s.text[s.pos+2:], "*/"); end != -1 {
+				s.pos += end + 4
+			} else {
+				s.pos = len(s.text)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// `fieldName` reads a field name, either an identifier or a quoted
+// string.
+func (s *scanner) fieldName() (string, error) {
+	if c := s.text[s.pos]; c == '"' || c == '\'' {
+		start := s.pos
+		if err := s.skipString(); err != nil {
+			return "", err
+		}
+		return s.text[start+1 : s.pos-1], nil
+	}
+
+	start := s.pos
+	for !s.done() {
+		c := rune(s.text[s.pos])
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			break
+		}
+		s.pos++
+	}
+	if start == s.pos {
+		return "", fmt.Errorf("Expected a field name at '%s'", s.excerpt())
+	}
+	return s.text[start:s.pos], nil
+}
+
+// `skipString` skips a single- or double-quoted string.
+func (s *scanner) skipString() error {
+	quote := s.text[s.pos]
+	start := s.pos
+	for s.pos++; !s.done(); s.pos++ {
+		switch s.text[s.pos] {
+		case '\\':
+			s.pos++
+		case quote:
+			s.pos++
+			return nil
+		}
+	}
+	s.pos = start
+	return fmt.Errorf("Unterminated string at '%s'", s.excerpt())
+}
+
+// `skipToComma` skips the rest of the current field, up to and
+// including the comma that ends it, if any.
+func (s *scanner) skipToComma() error {
+	closing := []byte{}
+	for {
+		s.skipSpace()
+		if s.done() {
+			if len(closing) > 0 {
+				return fmt.Errorf("Expected '%c' at end of customizations", closing[len(closing)-1])
+			}
+			return nil
+		}
+
+		switch c := s.text[s.pos]; c {
+		case '"', '\'':
+			if err := s.skipString(); err != nil {
+				return err
+			}
+			continue
+		case '{', '[', '(':
+			closing = append(closing, map[byte]byte{'{': '}', '[': ']', '(': ')'}[c])
+		case '}', ']', ')':
+			if len(closing) == 0 || closing[len(closing)-1] != c {
+				return fmt.Errorf("Unexpected '%c' at '%s'", c, s.excerpt())
+			}
+			closing = closing[:len(closing)-1]
+		case ',':
+			if len(closing) == 0 {
+				s.pos++
+				return nil
+			}
+		}
+		s.pos++
+	}
+}
+
+// `excerpt` returns the text at the current position, for errors.
+func (s *scanner) excerpt() string {
+	excerpt := s.text[s.pos:]
+	if end := strings.Index(excerpt, "\n"); end != -1 {
+		excerpt = excerpt[:end]
+	}
+	return excerpt
+}
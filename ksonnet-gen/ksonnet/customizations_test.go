@@ -0,0 +1,97 @@
+package ksonnet
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rolloutCustomization = `// Records why the deployment was rolled out.
+withRolloutAnnotation(reason):: self.mixin.metadata.annotations({"example.com/rollout-reason": reason}),
+
+local replicas = 3,
+withDefaultReplicas():: self.mixin.spec.replicas(replicas),
+`
+
+func TestCustomizations(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+
+	text, err := Emit(context.Background(), spec, Options{Customizations: map[string]string{
+		"apps.v1beta1.deployment":            rolloutCustomization,
+		"apps.v1beta1.deployment.mixin.spec": "withNoRevisionHistory():: self.revisionHistoryLimit(0),\n",
+	}})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+
+	// Customizations are merged into the end of their namespace.
+	deployment := objectText(library, "deployment")
+	expected := `      } + {
+        // Customizations for ` + "`apps.v1beta1.deployment`" + `.
+        // Records why the deployment was rolled out.
+        withRolloutAnnotation(reason):: self.mixin.metadata.annotations({"example.com/rollout-reason": reason}),
+
+        local replicas = 3,
+        withDefaultReplicas():: self.mixin.spec.replicas(replicas),`
+	if !strings.HasSuffix(deployment, expected) {
+		t.Errorf("Expected deployment to end with customizations:\n%s\ngot:\n%s", expected, deployment)
+	}
+	if !strings.Contains(deployment, "withNoRevisionHistory():: self.revisionHistoryLimit(0),") {
+		t.Errorf("Expected nested mixin namespace to be customized")
+	}
+	if strings.Count(library, "} + {") != 2 {
+		t.Errorf("Expected only customized namespaces to change")
+	}
+
+	// Customizations can't silently replace generated fields.
+	override := map[string]string{"apps.v1beta1.deployment": "new():: {},\n"}
+	_, err = Emit(context.Background(), spec, Options{Customizations: override})
+	if err == nil || !strings.Contains(err.Error(), "define 'new', which is already generated") {
+		t.Errorf("Expected an error overriding 'new', got %v", err)
+	}
+	if _, err = Emit(context.Background(), spec, Options{Customizations: override, AllowOverrides: true}); err != nil {
+		t.Errorf("Expected overrides to be allowed, got %v", err)
+	}
+
+	// Customizations must name a generated namespace.
+	_, err = Emit(context.Background(), spec, Options{Customizations: map[string]string{"apps.v1.deployment": "foo:: 1,\n"}})
+	if err == nil || !strings.Contains(err.Error(), "Can't customize 'apps.v1.deployment'") {
+		t.Errorf("Expected an error for an unknown namespace, got %v", err)
+	}
+	_, err = BuildSymbolIndex(spec, Options{Customizations: map[string]string{"apps.v1.deployment": "foo:: 1,\n"}})
+	if err == nil {
+		t.Errorf("Expected symbol index to check customizations too")
+	}
+}
+
+var topLevelFieldsTests = map[string][]string{
+	"":                 {},
+	"foo:: 1":          {"foo"},
+	"foo:: 1, bar: 2,": {"foo", "bar"},
+	"foo(a, b):: {x: a, y: [b, c]}, bar+: {}":                        {"foo", "bar"},
+	`"quoted-name": "a, b", 'single': 'c}'`:                          {"quoted-name", "single"},
+	"// comment, with: commas\n# and more\n/* block, c: 1 */ foo: 1": {"foo"},
+	"local x = {a: 1}, assert x.a == 1, foo: x":                      {"foo"},
+	rolloutCustomization:                                             {"withRolloutAnnotation", "withDefaultReplicas"},
+}
+
+func TestTopLevelFields(t *testing.T) {
+	for text, expected := range topLevelFieldsTests {
+		fields, err := topLevelFields(text)
+		if err != nil {
+			t.Errorf("Failed to parse fields of '%s':\n%v", text, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("Expected fields %v of '%s', got %v", expected, text, fields)
+		}
+	}
+
+	for _, text := range []string{"foo: {", "foo: )", "foo: \"unterminated", ", foo: 1"} {
+		if _, err := topLevelFields(text); err == nil {
+			t.Errorf("Expected '%s' to fail to parse", text)
+		}
+	}
+}
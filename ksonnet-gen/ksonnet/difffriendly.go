@@ -0,0 +1,72 @@
+package ksonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// APIVersionsFile is the file that lists the group/versions of the
+// kinds of a library emitted with `Options.DiffFriendly`, in place of
+// the header of `k8s.libsonnet`; see `EmitAPIVersions`.
+const APIVersionsFile = "apiVersions.json"
+
+// EmitAPIVersions takes a swagger API specification, and returns a
+// JSON array of the group/versions of the kinds of the library `Emit`
+// generates (e.g., `apps/v1beta2`, `v1`), sorted, one per line, so that
+// adding one only adds a line. It's also a Jsonnet value, so it can be
+// imported.
+func EmitAPIVersions(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	root := newRoot(spec, opts)
+	if err := root.customizationErrors(); err != nil {
+		return nil, err
+	}
+	apiVersions := root.apiVersions()
+	if len(apiVersions) == 0 {
+		return []byte("[]\n"), nil
+	}
+	lines := []string{}
+	for _, apiVersion := range apiVersions {
+		lines = append(lines, fmt.Sprintf("  %q", apiVersion))
+	}
+	return []byte("[\n" + strings.Join(lines, ",\n") + "\n]\n"), nil
+}
+
+// ParseAPIVersions returns the group/versions listed in the text of an
+// `apiVersions.json`; see `EmitAPIVersions`.
+func ParseAPIVersions(text []byte) ([]string, error) {
+	apiVersions := []string{}
+	if err := json.Unmarshal(text, &apiVersions); err != nil {
+		return nil, fmt.Errorf("Could not deserialize '%s':\n%v", APIVersionsFile, err)
+	}
+	return apiVersions, nil
+}
+
+// `emitBanner` emits the comment that heads the namespace of an API
+// object with `Options.DiffFriendly`, after a blank line: its
+// definition name, which doesn't change when other definitions are
+// added, so that diffs line up with it.
+func (ao *apiObject) emitBanner(m *indentWriter) {
+	if !ao.root().opts.DiffFriendly {
+		return
+	}
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf("// ===== %s =====", ao.parsedName.Unparse()))
+}
+
+// `emitOrder` returns the API objects of a version in the order they're
+// emitted: by kind, or, with `Options.DiffFriendly`, by definition name,
+// which differ when `Options.Namespaces` puts definitions of more than
+// one codebase in the version.
+func (va *versionedAPI) emitOrder() apiObjectSlice {
+	objects := va.apiObjects.toSortedSlice()
+	if va.root().opts.DiffFriendly {
+		sort.SliceStable(objects, func(i, j int) bool {
+			return objects[i].parsedName.Unparse() < objects[j].parsedName.Unparse()
+		})
+	}
+	return objects
+}
@@ -0,0 +1,113 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffFriendly(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"default":       {},
+		"diff-friendly": {DiffFriendly: true},
+	} {
+		library := withoutHeader(emitTestSpec(t, "testdata/collisions.json", opts))
+
+		golden := fmt.Sprintf("testdata/collisions.%s.golden", name)
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, []byte(library), 0644); err != nil {
+				t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+			}
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+		}
+		if library != string(expected) {
+			t.Errorf(
+				"Library in the %s mode differs from '%s'; run `go test -update` and diff:\n%s",
+				name, golden, library)
+		}
+	}
+
+	// The header doesn't list the group/versions, which are in their
+	// own file.
+	opts := Options{DiffFriendly: true}
+	library := []byte(emitTestSpec(t, "testdata/collisions.json", opts))
+	if _, ok := LibraryAPIVersions(library); ok {
+		t.Errorf("Expected the header not to list the group/versions:\n%s", library)
+	}
+	artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/collisions.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	artifact, ok := artifacts[APIVersionsFile]
+	if !ok {
+		t.Fatalf("Expected artifact '%s'", APIVersionsFile)
+	}
+	const expected = `[
+  "apps/v1",
+  "apps/v1beta1",
+  "apps/v1beta2",
+  "events.k8s.io/v1beta1",
+  "extensions/v1beta1",
+  "networking.k8s.io/v1",
+  "networking.k8s.io/v1beta1",
+  "v1"
+]
+`
+	if string(artifact.Text) != expected {
+		t.Errorf("Expected '%s':\n%s\ngot:\n%s", APIVersionsFile, expected, artifact.Text)
+	}
+	apiVersions, err := ParseAPIVersions(artifact.Text)
+	if err != nil {
+		t.Fatalf("Failed to parse '%s':\n%v", APIVersionsFile, err)
+	}
+	if header, _ := LibraryAPIVersions([]byte(emitTestSpec(t, "testdata/collisions.json", Options{}))); !reflect.DeepEqual(apiVersions, header) {
+		t.Errorf("Expected '%s' to list the header's group/versions %v, got %v", APIVersionsFile, header, apiVersions)
+	}
+	artifacts, err = EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/collisions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if _, ok := artifacts[APIVersionsFile]; ok {
+		t.Errorf("Expected no '%s' by default", APIVersionsFile)
+	}
+}
+
+func TestDiffFriendlyOrder(t *testing.T) {
+	// Folding the CRD kinds into `admissionregistration` puts hidden
+	// definitions of two codebases in one version.
+	namespaces := map[string]string{"apiextensions-apiserver": "admissionregistration"}
+	for _, c := range []struct {
+		opts     Options
+		expected []string
+	}{
+		{
+			Options{Namespaces: namespaces},
+			[]string{"customResourceDefinitionSpec", "serviceReference"},
+		},
+		{
+			Options{Namespaces: namespaces, DiffFriendly: true},
+			[]string{"serviceReference", "customResourceDefinitionSpec"},
+		},
+	} {
+		library := emitTestSpec(t, "testdata/namespaces.json", c.opts)
+		hidden := library[strings.Index(library, "\n  local hidden = {\n"):]
+		version := hidden[strings.Index(hidden, "\n    admissionregistration:: {\n"):]
+		version = version[:strings.Index(version, "\n    },\n")]
+
+		kinds := []string{}
+		for _, line := range strings.Split(version, "\n") {
+			if strings.HasPrefix(line, "        ") && !strings.HasPrefix(line, "         ") && strings.HasSuffix(line, ":: {") {
+				kinds = append(kinds, strings.TrimSuffix(strings.TrimSpace(line), ":: {"))
+			}
+		}
+		if !reflect.DeepEqual(kinds, c.expected) {
+			t.Errorf("Expected order %v with %+v, got %v", c.expected, c.opts, kinds)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// embedFromName is the function the namespaces of the objects CRD
+// schemas embed get, which sets the embedded object from an object
+// built with the library; see `emitEmbedFrom`.
+const embedFromName = "embedFrom"
+
+// embedPrune is the local function of `embedFromName` that prunes
+// `value` by the table `schema` (see `embedPruneTable`): the fields of
+// an object that an object table doesn't have are dropped, and those
+// it does are pruned by their own tables, while `true` keeps a value
+// whole.
+const embedPrune = `local prune(schema, value) = if std.type(schema) != "object" || std.type(value) != "object" then value else {[k]: prune(schema[k], value[k]) for k in std.objectFields(value) if std.objectHas(schema, k)};`
+
+// `emitEmbedFrom` emits `embedFrom(obj)` for an API object a CRD
+// schema embeds, which merges `obj`, e.g., a `deployment.new(...)`,
+// into it through `mixinName`. Unless its schema sets
+// `x-kubernetes-preserve-unknown-fields`, the fields it doesn't declare
+// are pruned first, as the server would prune them.
+func (ao *apiObject) emitEmbedFrom(m *indentWriter, mixinName, path string) {
+	if dm, ok := ao.properties[embedFromName]; ok {
+		log.Panicf(
+			"Attempted to create helper '%s', but the property already existed at '%s'",
+			embedFromName, dm.path)
+	}
+
+	const assertion = `assert std.type(obj) == "object" && std.objectHas(obj, "apiVersion") && std.objectHas(obj, "kind") : "'embedFrom' takes an object with an apiVersion and a kind";`
+	table := ao.root().embedPruneTable(ao.parsedName.Unparse())
+	if table == true {
+		m.writeLine("// Merges `obj`, an object built with the library, into the embedded object, with all of its fields, since the schema sets `x-kubernetes-preserve-unknown-fields`.")
+		m.writeLine(fmt.Sprintf("%s(obj):: %s %s(obj),", embedFromName, assertion, mixinName))
+	} else {
+		text, err := jsonnet.Literal(table)
+		if err != nil {
+			log.Panicf("Could not write the fields of '%s':\n%v", ao.parsedName.Unparse(), err)
+		}
+		m.writeLine("// Merges `obj`, an object built with the library, into the embedded object, with only the fields its schema declares, since the server prunes the others.")
+		m.writeLine(fmt.Sprintf(
+			"%s(obj):: %s %s %s(prune(%s, obj)),",
+			embedFromName, assertion, embedPrune, mixinName, text))
+	}
+	ao.root().index.add(fmt.Sprintf("%s.%s", path, embedFromName), SymbolFunction, "obj")
+}
+
+// `embedPruneTable` returns the table `embedPrune` prunes an object of
+// the definition `name` by: `true` if its schema preserves unknown
+// fields, and otherwise an object with the table of each field it
+// declares. Objects the fields declare inline (even if they're
+// synthesized definitions) have tables of their own; anything else,
+// including the objects of other definitions (e.g., `metadata`), and
+// lists, whose items' schemas aren't kept, is kept whole.
+func (root *root) embedPruneTable(name kubespec.DefinitionName) interface{} {
+	def, ok := root.spec.Definitions[name]
+	if !ok || def.PreserveUnknownFields {
+		return true
+	}
+	return root.embedPruneFields(def.Properties)
+}
+
+// `embedPruneFields` returns the table of an object whose fields are
+// `properties`; see `embedPruneTable`.
+func (root *root) embedPruneFields(properties kubespec.Properties) map[string]interface{} {
+	table := map[string]interface{}{}
+	for name, prop := range properties {
+		var field interface{} = true
+		switch {
+		case prop.PreserveUnknownFields:
+		case prop.Ref != nil && root.synthesized[*root.refName(prop.Ref)]:
+			field = root.embedPruneTable(*root.refName(prop.Ref))
+		case prop.Ref == nil && prop.Type != nil && *prop.Type == "object" && len(prop.Properties) > 0:
+			field = root.embedPruneFields(prop.Properties)
+		}
+		table[string(name)] = field
+	}
+	return table
+}
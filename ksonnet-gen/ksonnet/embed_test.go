@@ -0,0 +1,98 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// embeddedSpec is `workloads.json`, for the Jobs and Deployments to
+// embed, with the CRD of `embedded.yaml`.
+func embeddedSpec(t *testing.T) (*kubespec.APISpec, Options) {
+	text, err := ioutil.ReadFile("testdata/embedded.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	crds, err := kubespec.ReadCRDs("embedded.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/workloads.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	return spec, Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+}
+
+func TestEmbedFrom(t *testing.T) {
+	spec, opts := embeddedSpec(t)
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	schedule := objectText(string(library), "jobSchedule")
+	for _, line := range []string{
+		// The Job is pruned to the fields its schema declares, at any
+		// depth, except in the pod template, which preserves unknown
+		// fields, and `metadata`, an `ObjectMeta`.
+		`__jobTemplateMixin(prune({apiVersion: true, kind: true, metadata: true, spec: {backoffLimit: true, limits: {retries: true}, parallelism: true, template: true}}, obj)),`,
+		// The Deployment is kept whole.
+		`"'embedFrom' takes an object with an apiVersion and a kind"; __canaryMixin(obj),`,
+		// Both have the setters and mixins of objects.
+		"apiVersion(apiVersion):: __jobTemplateMixin({apiVersion: apiVersion}),",
+		"local __metadataMixin(metadata) = __canaryMixin({metadata+: metadata}),",
+	} {
+		if !strings.Contains(schedule, line) {
+			t.Errorf("Expected 'jobSchedule' to contain:\n%s\ngot:\n%s", line, schedule)
+		}
+	}
+	if count := strings.Count(schedule, "embedFrom(obj)::"); count != 2 {
+		t.Errorf("Expected only the embedded objects to have 'embedFrom', got %d:\n%s", count, schedule)
+	}
+
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	const path = "opsExampleCom.v1.jobSchedule.mixin.spec.jobTemplate.embedFrom"
+	if symbol := symbols[path]; symbol == nil || symbol.Kind != SymbolFunction || strings.Join(symbol.Params, ", ") != "obj" {
+		t.Errorf("Expected '%s(obj)' to be indexed, got %#v", path, symbol)
+	}
+}
+
+func TestEmbedFromEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// The fields of the Job its schema doesn't declare (`selector`,
+	// `manualSelector`, `status`, and `limits.timeout`) are pruned,
+	// while the pod template and the Deployment keep all of theirs.
+	const program = `local k = import 'k8s.libsonnet';
+local job = k.batch.v1.job;
+local deployment = k.apps.v1.deployment;
+local schedule = k.opsExampleCom.v1.jobSchedule;
+local built = job.new("task", {name: "task", image: "task:1"}, "Never") + job.mixin.spec.manualSelector(true) +
+  {spec+: {backoffLimit: 3, limits: {retries: 2, timeout: 60}, selector: {matchLabels: {name: "task"}}}, status: {active: 1}};
+local canary = deployment.new({app: "canary"}) + deployment.mixin.spec.replicas(1) + {spec+: {paused: true}};
+local s = schedule.new() + schedule.mixin.spec.jobTemplate.embedFrom(built) + schedule.mixin.spec.canary.embedFrom(canary);
+[
+  std.assertEqual(s.spec.jobTemplate, {apiVersion: "batch/v1", kind: "Job", metadata: built.metadata, spec: {backoffLimit: 3, limits: {retries: 2}, template: built.spec.template}}),
+  std.assertEqual(s.spec.canary, canary),
+  std.assertEqual((schedule.mixin.spec.jobTemplate.embedFrom(built) + schedule.mixin.spec.jobTemplate.spec.parallelism(2)).spec.jobTemplate.spec.parallelism, 2),
+]
+`
+	spec, opts := embeddedSpec(t)
+	if out := evaluateLibrary(t, jsonnet, spec, opts, program); strings.Contains(out, "false") {
+		t.Errorf("Expected the embedded objects to be pruned as expected, got:\n%s", out)
+	}
+}
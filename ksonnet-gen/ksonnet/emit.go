@@ -1,10 +1,12 @@
 package ksonnet
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
@@ -12,13 +14,103 @@ import (
 )
 
 // Emit takes a swagger API specification, and returns the text of
-// `ksonnet-lib`, written in Jsonnet.
-func Emit(spec *kubespec.APISpec) ([]byte, error) {
-	root := newRoot(spec)
+// `ksonnet-lib`, written in Jsonnet. If `ctx` is cancelled, it stops
+// before the next API group, and returns an error that wraps
+// `ctx.Err()` and says how many groups were emitted.
+func Emit(
+	ctx context.Context, spec *kubespec.APISpec, opts Options,
+) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("Could not emit library: %w", err)
+	}
+	root := newRoot(spec, opts)
+
+	start := time.Now()
+	m := root.newLibraryWriter(newIndentWriter())
+	if err := root.emitContext(ctx, m); err != nil {
+		return nil, err
+	}
+	text, err := m.bytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := root.customizationErrors(); err != nil {
+		return nil, err
+	}
+	root.logger().Log(
+		"emit", "bytes", len(text), "duration", time.Since(start))
+	root.reportOutput(len(text), start)
+	root.logCompaction(len(text))
+	return text, nil
+}
+
+// BuildSymbolIndex takes a swagger API specification, and returns an
+// index of every symbol (namespaces, functions, and type aliases)
+// that `Emit` would generate for it. The index is populated during
+// emission, so it can't drift from the generated code.
+func BuildSymbolIndex(
+	spec *kubespec.APISpec, opts Options,
+) (*SymbolIndex, error) {
+	root := newRoot(spec, opts)
 
 	m := newIndentWriter()
-	root.emit(m)
-	return m.bytes()
+	if err := root.emitContext(context.Background(), m); err != nil {
+		return nil, err
+	}
+	if _, err := m.bytes(); err != nil {
+		return nil, err
+	}
+	if err := root.customizationErrors(); err != nil {
+		return nil, err
+	}
+
+	root.index.Codebases = root.parser.Codebases(root.spec)
+	root.index.Provenance = root.provenance()
+	root.index.Aliases = map[string]string{}
+	for _, alias := range root.aliases() {
+		root.index.Aliases[alias.name] = alias.object.path()
+	}
+	root.index.KindCollisions = root.collisionPolicy()
+	root.index.KindNames = root.kindNameIndex()
+	root.index.Forks = root.forks
+	root.index.GroupIDs = root.groupIDs
+	if root.opts.SplitByGroup {
+		root.index.Files = root.fileIndex()
+	}
+	root.index.nest(root.opts.NamespacePrefix)
+	root.index.sort()
+	return root.index, nil
+}
+
+// EmittedSizes takes a swagger API specification, and returns how
+// many bytes of the library `Emit` generates for it are contributed by
+// each definition, along with the size of the whole library. A
+// definition contributes both its own API object and the mixins
+// inlined wherever another object references it (e.g., each `spec`
+// mixin of a `Deployment` is counted against `DeploymentSpec`). Bytes
+// that belong to no definition (e.g., the group namespaces) are
+// counted under "".
+func EmittedSizes(
+	spec *kubespec.APISpec, opts Options,
+) (map[kubespec.DefinitionName]int, int, error) {
+	root := newRoot(spec, opts)
+
+	m := root.newLibraryWriter(newCountingWriter())
+	if err := root.emitContext(context.Background(), m); err != nil {
+		return nil, 0, err
+	}
+	if _, err := m.bytes(); err != nil {
+		return nil, 0, err
+	}
+	if err := root.customizationErrors(); err != nil {
+		return nil, 0, err
+	}
+
+	total := 0
+	for _, size := range m.counts {
+		total += size
+	}
+	return m.counts, total, nil
 }
 
 //-----------------------------------------------------------------------------
@@ -34,71 +126,320 @@ func Emit(spec *kubespec.APISpec) ([]byte, error) {
 // `kubespec.APISpec`.
 type root struct {
 	spec         *kubespec.APISpec
+	opts         Options
 	groups       groupSet // set of groups, e.g., core, apps, extensions.
 	hiddenGroups groupSet
+	index        *SymbolIndex // populated as a side effect of `emit`.
+
+	// Populated as a side effect of `emit`; see `closeNamespace`.
+	// `errors` starts with the property types that can't be forced
+	// (see `forcePropertyTypes`), the collisions of
+	// `Options.Namespaces`, and the kinds removed by
+	// `Options.FailOnRemovedIn`.
+	customized map[string]bool
+	errors     []error
+
+	// The definitions that aren't emitted; see `SkippedDefinitions`.
+	skipped []*SkippedDefinition
+
+	// API groups of the packages of definitions with non-native
+	// prefixes, and the labels of the sources of those that are forks;
+	// see `groupName`.
+	packageGroups map[string]kubespec.GroupName
+	packageForks  map[string]string
+
+	// API groups of the packages of native definitions, where they
+	// differ from the group in the name; see `nativeAPIGroups`.
+	nativeGroups map[string]kubespec.GroupName
+
+	// The identifiers of the groups, and the API groups they're
+	// sanitized from; see `newGroupIDs`.
+	groupIDs kubespec.GroupIDMap
+
+	// The definitions `kubespec.APISpec.WithCRDSources` forked; see
+	// `newForks`.
+	forks []*DefinitionFork
+
+	// Set only with `Options.Compact`, and
+	// `Options.ShareIdenticalKinds`.
+	compaction  *compaction
+	kindSharing *kindSharing
+
+	// The beta kinds emitted as aliases of GA ones, unless
+	// `Options.FullBetaDuplicates` is set; see `newBetaAliases`.
+	betaAliases map[kubespec.DefinitionName]*apiObject
+
+	// The name every top-level kind of every group goes by; see
+	// `newKindNames`.
+	kindNames map[kindNameKey]*kindName
+
+	// Where the definitions the spec refers to but doesn't have are in
+	// the libraries of `Options.ExternalRefs`; see `newExternalRefs`.
+	externals map[kubespec.DefinitionName]*externalRef
+
+	// The merge strategy tables of `Options.EmitMerge`, or nil; see
+	// `newMergeStrategies`.
+	mergeStrategies *mergeStrategies
+
+	// `Options.KubeVersions` and `Options.Parser`, or their defaults.
+	kubeVersions *kubeversion.Data
+	parser       *kubespec.Parser
+
+	// The parser's `Stats` as of the last `countParses`.
+	parserHits, parserMisses uint64
+
+	// The definitions `kubespec.APISpec.WithInlineDefinitions`
+	// synthesized for inline schemas; see `embedPruneTable`.
+	synthesized map[kubespec.DefinitionName]bool
 }
 
-func newRoot(spec *kubespec.APISpec) *root {
+func newRoot(spec *kubespec.APISpec, opts Options) *root {
+	start := time.Now()
+	maxInlineDepth := opts.MaxInlineDepth
+	if maxInlineDepth == 0 {
+		maxInlineDepth = DefaultMaxInlineDepth
+	}
 	root := root{
-		spec:         spec,
+		opts:         opts,
 		groups:       make(groupSet),
 		hiddenGroups: make(groupSet),
+		index:        newSymbolIndex(spec.Info.Version),
+		customized:   map[string]bool{},
+		skipped:      []*SkippedDefinition{},
+		kubeVersions: opts.kubeVersions(),
+		parser:       opts.Parser,
 	}
-
+	if root.parser == nil {
+		root.parser = &kubespec.Parser{Prefixes: []string{kubespec.NativePrefix}}
+	}
+	// The names a model recorded aren't parsed again.
+	root.parser.Preload(spec)
+	root.parserHits, root.parserMisses, _ = root.parser.Stats()
+	root.errors = append(root.errors, root.namespaceMappingErrors(spec)...)
+	spec, inline := root.forcePropertyTypes(root.filterVersions(root.excludeSkipped(spec))).
+		WithInlineDefinitions(maxInlineDepth)
+	root.spec = spec
+	root.synthesized = map[kubespec.DefinitionName]bool{}
+	for _, name := range inline {
+		root.synthesized[name] = true
+	}
+	root.logger().Log(
+		"synthesize", "definitions", len(inline), "duration", time.Since(start))
+	root.observePhase("synthesize", start)
+	root.packageGroups = root.definitionGroups(spec)
+	root.nativeGroups = root.nativeAPIGroups(spec)
+	root.packageForks = definitionForks(spec)
+	root.groupIDs = root.newGroupIDs(spec)
+	externals, externalErrors := root.newExternalRefs()
+	root.externals = externals
+	root.errors = append(root.errors, externalErrors...)
+	collisions, collided := root.namespaceCollisions()
+	root.errors = append(root.errors, collisions...)
+
+	start = time.Now()
+	parsed, unsupported := 0, 0
 	for defName, def := range spec.Definitions {
-		root.addDefinition(defName, def)
+		if collided[defName] {
+			unsupported++
+		} else if root.addDefinition(defName, def) {
+			parsed++
+		} else {
+			unsupported++
+		}
+		root.progress("parse", parsed+unsupported, len(spec.Definitions))
 	}
+	root.logger().Log(
+		"parse", "definitions", parsed, "skipped", len(root.skipped),
+		"duration", time.Since(start))
+	root.observePhase("parse", start)
+	root.metrics().Counter(MetricDefinitionsParsed, float64(parsed), root.metricLabels())
+	root.metrics().Counter(MetricDefinitionsSkipped, float64(len(root.skipped)), root.metricLabels())
+	root.errors = append(root.errors, root.removalErrors()...)
+	root.compaction = root.newCompaction()
+	root.betaAliases = root.newBetaAliases()
+	// Before sharing, which compares the kinds' `merge` mixins too.
+	root.mergeStrategies = root.newMergeStrategies()
+	root.kindSharing = root.newKindSharing()
+	kindNames, kindNameErrors := root.newKindNames()
+	root.kindNames = kindNames
+	root.errors = append(root.errors, kindNameErrors...)
+	root.forks = root.newForks()
 
 	return &root
 }
 
-func (root *root) emit(m *indentWriter) {
-	m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
-	m.writeLine(fmt.Sprintf("// Kubernetes version: %s", root.spec.Info.Version))
-	m.writeLine(fmt.Sprintf(
-		"// SHA of ksonnet-lib HEAD: %s", getSHARevision(".")))
-	m.writeLine(fmt.Sprintf(
-		"// SHA of Kubernetes HEAD OpenAPI spec is generated from: %s",
-		getSHARevision(root.spec.FilePath)))
-	m.writeLine("")
+// `naming` returns the strategy used to turn identifiers from the
+// spec into Jsonnet identifiers.
+func (root *root) naming() jsonnet.Naming {
+	return jsonnet.Naming{Strategy: root.opts.NamingStrategy, Data: root.kubeVersions}
+}
+
+// `parseName` parses a definition name with the root's parser,
+// panicking if it's malformed, like `kubespec.DefinitionName.ParseName`.
+func (root *root) parseName(dn kubespec.DefinitionName) kubespec.ParsedName {
+	parsed, err := root.parser.ParseName(dn)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
 
+// `refName` parses the definition name of a ref with the root's
+// parser, panicking if it's malformed, like `kubespec.ObjectRef.Name`.
+func (root *root) refName(ref *kubespec.ObjectRef) *kubespec.DefinitionName {
+	name, err := root.parser.ParseRef(*ref)
+	if err != nil {
+		panic(err)
+	}
+	return name
+}
+
+// `progress` reports progress to `Options.Progress`, if it's set, and
+// to `Options.Metrics`.
+func (root *root) progress(phase string, done, total int) {
+	if root.opts.Progress != nil {
+		root.opts.Progress(phase, done, total)
+	}
+	root.metrics().Gauge(
+		MetricPhaseProgress, progressRatio(done, total),
+		root.metricLabels(MetricLabelPhase, phase))
+}
+
+func (root *root) logger() Logger {
+	if root.opts.Logger == nil {
+		return nopLogger{}
+	}
+	return root.opts.Logger
+}
+
+// `newLibraryWriter` returns `m`, which is to write the text of the
+// library, omitting comments if `Options.OmitComments` is set.
+func (root *root) newLibraryWriter(m *indentWriter) *indentWriter {
+	m.omitComments = root.opts.OmitComments
+	return m
+}
+
+// `emitContext` emits the library, checking whether `ctx` is cancelled
+// before each group.
+func (root *root) emitContext(ctx context.Context, m *indentWriter) error {
+	root.emitHeader(m)
+	if root.emitExternalImports(m) {
+		m.writeLine("")
+	}
 	m.writeLine("{")
 	m.indent()
+	root.openPrefix(m, "::")
 
 	// Emit in sorted order so that we can diff the output.
+	done, total := 0, len(root.groups)+len(root.hiddenGroups)
+	cancelled := func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf(
+				"Could not emit library, cancelled after %d of %d groups: %w",
+				done, total, err)
+		}
+		return nil
+	}
 	for _, group := range root.groups.toSortedSlice() {
+		if err := cancelled(); err != nil {
+			return err
+		}
 		group.emit(m)
+		done++
+		root.progress("emit", done, total)
+	}
+
+	if root.opts.EmitPresets {
+		root.emitPresets(m, false)
+	}
+	root.emitUtil(m)
+	if root.opts.EmbedRawSchemas {
+		if err := root.emitRawSchemas(m); err != nil {
+			return err
+		}
 	}
 
+	root.emitMergeLocal(m)
+
 	m.writeLine("local hidden = {")
 	m.indent()
 
 	for _, hiddenGroup := range root.hiddenGroups.toSortedSlice() {
+		if err := cancelled(); err != nil {
+			return err
+		}
 		hiddenGroup.emit(m)
+		done++
+		root.progress("emit", done, total)
 	}
 
 	m.dedent()
 	m.writeLine("},")
+	root.emitSharedKinds(m)
+	root.emitSharedMixins(m)
 
+	root.closePrefix(m)
 	m.dedent()
 	m.writeLine("}")
+	return nil
 }
 
+// `emitHeader` emits the comments that start `k8s.libsonnet`: what
+// it's generated from (including the provenance that
+// `Options.ProvenanceFields` selects), and, unless `Options.DiffFriendly` moves them to
+// `apiVersions.json`, the apiVersions it has, or what
+// `Options.HeaderTemplate` makes of them.
+func (root *root) emitHeader(m *indentWriter) {
+	// The header is kept with `Options.OmitComments`: it says how the
+	// library was generated, and `LibraryAPIVersions` reads it.
+	emitTemplatedHeader(m, root.spec, root.opts, LibraryFile, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", root.spec.Info.Version))
+		root.emitProvenance(m)
+		if !root.opts.DiffFriendly {
+			m.writeLine(apiVersionsHeader + strings.Join(root.apiVersions(), ", "))
+		}
+		// Either SHA is left out if its directory isn't in a git
+		// repository, e.g., for a spec fetched from a cluster.
+		if sha, ok := getSHARevision("."); ok {
+			m.writeLine(fmt.Sprintf("// SHA of ksonnet-lib HEAD: %s", sha))
+		}
+		if sha, ok := getSHARevision(root.spec.FilePath); ok {
+			m.writeLine(fmt.Sprintf(
+				"// SHA of Kubernetes HEAD OpenAPI spec is generated from: %s", sha))
+		}
+	})
+	m.writeLine("")
+}
+
+// `addDefinition` adds a definition to the library, reporting whether
+// it was added. Unversioned definitions (e.g., `RawExtension`) are
+// skipped.
 func (root *root) addDefinition(
 	path kubespec.DefinitionName, def *kubespec.SchemaDefinition,
-) {
-	parsedName := path.Parse()
-	if parsedName.Version == nil {
-		return
+) bool {
+	parsedName := root.parseName(path)
+	if !parsedName.HasVersion() {
+		root.skip(path, SkipUnsupported, "has no API version, so it has no namespace in the library")
+		return false
 	}
-	apiObject := root.createAPIObject(parsedName, def)
+	apiObject := root.createAPIObject(parsedName, def, root.spec.Classify(path))
+	apiObject.identifiers = root.propertyIdentifiers(path, def)
+	root.addPropertyIDs(path, def, apiObject)
 
 	for propName, prop := range def.Properties {
 		pm := newPropertyMethod(propName, path, prop, apiObject)
 		apiObject.properties[propName] = pm
 
+		// NOTE: Opaque properties have no structure to alias.
 		st := prop.Type
-		if pm.ref != nil || (st != nil && *st == "array" && prop.Items.Ref != nil) {
+		isRefArray := st != nil && *st == "array" && prop.Items.Ref != nil &&
+			!root.isUntypedRef(prop.Items.Ref)
+		// Properties that refer to external definitions are opaque, but
+		// their type aliases point into the external library.
+		isExternal := root.externalRef(prop.Ref) != nil ||
+			(st != nil && *st == "array" && root.externalRef(prop.Items.Ref) != nil)
+		if pm.isMixin() || (!pm.opaque && isRefArray) || isExternal {
 			typeAliasName := propName + "Type"
 			ta, ok := apiObject.properties[typeAliasName]
 			if ok && ta.kind != typeAlias {
@@ -110,27 +451,24 @@ func (root *root) addDefinition(
 			apiObject.properties[typeAliasName] = ta
 		}
 	}
+	return true
 }
 
 func (root *root) createAPIObject(
-	parsedName *kubespec.ParsedDefinitionName, def *kubespec.SchemaDefinition,
+	parsedName kubespec.ParsedName, def *kubespec.SchemaDefinition,
+	class kubespec.ObjectClass,
 ) *apiObject {
-	if parsedName.Version == nil {
+	if !parsedName.HasVersion() {
 		log.Panicf(
-			"Can't make API object from name with nil version in path: '%s'",
+			"Can't make API object from name with no version in path: '%s'",
 			parsedName.Unparse())
 	}
 
-	var groupName kubespec.GroupName
-	if parsedName.Group == nil {
-		groupName = "core"
-	} else {
-		groupName = *parsedName.Group
-	}
+	groupName, apiGroup := root.groupName(parsedName)
 
 	// Separate out top-level definitions from everything else.
 	var groups groupSet
-	if len(def.TopLevelSpecs) > 0 {
+	if class.IsTopLevel() {
 		groups = root.groups
 	} else {
 		groups = root.hiddenGroups
@@ -138,27 +476,149 @@ func (root *root) createAPIObject(
 
 	group, ok := groups[groupName]
 	if !ok {
-		group = newGroup(groupName, root)
+		group = newGroup(groupName, root, !class.IsTopLevel())
+		group.apiGroup = apiGroup
 		groups[groupName] = group
 	}
 
-	versionedAPI, ok := group.versionedAPIs[*parsedName.Version]
+	versionedAPI, ok := group.versionedAPIs[parsedName.Version]
 	if !ok {
-		versionedAPI = newVersionedAPI(*parsedName.Version, group)
-		group.versionedAPIs[*parsedName.Version] = versionedAPI
+		versionedAPI = newVersionedAPI(parsedName.Version, group)
+		group.versionedAPIs[parsedName.Version] = versionedAPI
 	}
 
 	apiObject, ok := versionedAPI.apiObjects[parsedName.Kind]
 	if ok {
 		log.Panicf("Duplicate object kinds with name '%s'", parsedName.Unparse())
 	}
-	apiObject = newAPIObject(parsedName, versionedAPI, def)
+	apiObject = newAPIObject(parsedName, versionedAPI, def, class)
 	versionedAPI.apiObjects[parsedName.Kind] = apiObject
 	return apiObject
 }
 
+// `groupName` returns the name of the group a definition belongs to
+// in the library (e.g., `apps`), and, if it differs, the name of its
+// API group (e.g., `route.openshift.io` for the group `routeOpenshiftIo`).
+//
+// Native definitions are grouped by the group in their name, with the
+// API group of their `x-kubernetes-group-version-kind` (e.g.,
+// `rbac.authorization.k8s.io` for `rbac`). The
+// groups in the names of other definitions (e.g., `route` in
+// `com.github.openshift.api.route.v1.Route`) don't follow Kubernetes'
+// conventions, and may collide with native ones (e.g., OpenShift's
+// `apps`), so they're grouped by the API group that the
+// `x-kubernetes-group-version-kind` of their package names instead,
+// rewritten as an identifier. Failing that, they're grouped by the
+// group in their name, qualified by the last label of their prefix
+// (e.g., `appsOpenshift`). Either is sanitized by
+// `kubespec.SanitizeGroupID`, and numbered if another group has the
+// same identifier (see `newGroupIDs`), and suffixed with the label of the
+// source of a fork (e.g., `mysqlExampleCom_v2`), so that it's grouped
+// apart from the definition it was forked from; see `newForks`.
+//
+// `Options.Namespaces` can move a definition to another group, which
+// keeps the API group of the one it came from.
+func (root *root) groupName(
+	parsedName kubespec.ParsedName,
+) (kubespec.GroupName, kubespec.GroupName) {
+	name, apiGroup := root.defaultGroupName(parsedName)
+	if namespace, ok := root.mappedNamespace(parsedName); ok && namespace != name {
+		if apiGroup == "" {
+			apiGroup = name
+		}
+		return namespace, apiGroup
+	}
+	return name, apiGroup
+}
+
+func (root *root) defaultGroupName(
+	parsedName kubespec.ParsedName,
+) (kubespec.GroupName, kubespec.GroupName) {
+	if parsedName.Prefix == "" {
+		if !parsedName.HasGroup() {
+			return "core", ""
+		}
+		return parsedName.Group, root.nativeGroups[definitionPackage(parsedName.Unparse())]
+	}
+
+	pkg := definitionPackage(parsedName.Unparse())
+	fork := ""
+	if label, ok := root.packageForks[pkg]; ok {
+		fork = "_" + label
+	}
+	id := kubespec.GroupName(root.groupIDs.ID(root.groupOrigin(parsedName)) + fork)
+	return id, root.packageGroups[pkg]
+}
+
+// `definitionGroups` maps the package of every top-level definition with
+// a non-native prefix (e.g., `com.github.openshift.api.route.v1`) to
+// the API group of its `x-kubernetes-group-version-kind`.
+func (root *root) definitionGroups(spec *kubespec.APISpec) map[string]kubespec.GroupName {
+	groups := map[string]kubespec.GroupName{}
+	for name, def := range spec.Definitions {
+		parsed, err := root.parser.ParseName(name)
+		if err != nil || parsed.Prefix == "" || !spec.Classify(name).IsTopLevel() {
+			continue
+		}
+		if group := def.TopLevelSpecs[0].Group; group != "" {
+			groups[definitionPackage(name)] = group
+		}
+	}
+	return groups
+}
+
+// `nativeAPIGroups` maps the package of every native top-level
+// definition of a single group/version/kind (e.g.,
+// `io.k8s.api.rbac.v1`) to the API group of its
+// `x-kubernetes-group-version-kind` (e.g., `rbac.authorization.k8s.io`),
+// where that qualifies the group in the definition's name. Definitions
+// of several (e.g., `DeleteOptions`) don't say which group is theirs,
+// and one whose extension names another group (e.g., an `Ingress` of
+// `networking` annotated as `extensions`) is misannotated, so both keep
+// the group in their name.
+func (root *root) nativeAPIGroups(spec *kubespec.APISpec) map[string]kubespec.GroupName {
+	groups := map[string]kubespec.GroupName{}
+	for name, def := range spec.Definitions {
+		parsed, err := root.parser.ParseName(name)
+		if err != nil || parsed.Prefix != "" || len(def.TopLevelSpecs) != 1 || !spec.Classify(name).IsTopLevel() {
+			continue
+		}
+		if group := def.TopLevelSpecs[0].Group; strings.HasPrefix(string(group), string(parsed.Group)+".") {
+			groups[definitionPackage(name)] = group
+		}
+	}
+	return groups
+}
+
+// `definitionPackage` returns the package of a definition, i.e., its
+// name without the kind.
+func definitionPackage(name kubespec.DefinitionName) string {
+	return string(name)[:strings.LastIndex(string(name), ".")]
+}
+
+// `isUntypedRef` reports whether `ref` refers to a definition with
+// effectively no schema, e.g., `RawExtension`, or to one that isn't in
+// the spec because it was skipped (see `excludeSkipped`).
+func (root *root) isUntypedRef(ref *kubespec.ObjectRef) bool {
+	if ref == nil {
+		return false
+	}
+	def, ok := root.spec.Definitions[*root.refName(ref)]
+	return !ok || def.IsUntyped()
+}
+
+// `isIntOrStringRef` reports whether `ref` refers to a definition whose
+// values are integers or strings, i.e., `intstr.IntOrString`.
+func (root *root) isIntOrStringRef(ref *kubespec.ObjectRef) bool {
+	if ref == nil {
+		return false
+	}
+	def, ok := root.spec.Definitions[*root.refName(ref)]
+	return ok && def.IsIntOrString()
+}
+
 func (root *root) getAPIObject(
-	parsedName *kubespec.ParsedDefinitionName,
+	parsedName kubespec.ParsedName,
 ) *apiObject {
 	ao, err := root.getAPIObjectHelper(parsedName, false)
 	if err == nil {
@@ -173,19 +633,14 @@ func (root *root) getAPIObject(
 }
 
 func (root *root) getAPIObjectHelper(
-	parsedName *kubespec.ParsedDefinitionName, hidden bool,
+	parsedName kubespec.ParsedName, hidden bool,
 ) (*apiObject, error) {
-	if parsedName.Version == nil {
+	if !parsedName.HasVersion() {
 		log.Panicf(
-			"Can't get API object with nil version: '%s'", parsedName.Unparse())
+			"Can't get API object with no version: '%s'", parsedName.Unparse())
 	}
 
-	var groupName kubespec.GroupName
-	if parsedName.Group == nil {
-		groupName = "core"
-	} else {
-		groupName = *parsedName.Group
-	}
+	groupName, _ := root.groupName(parsedName)
 
 	var groups groupSet
 	if hidden {
@@ -201,7 +656,7 @@ func (root *root) getAPIObjectHelper(
 			parsedName.Unparse())
 	}
 
-	versionedAPI, ok := group.versionedAPIs[*parsedName.Version]
+	versionedAPI, ok := group.versionedAPIs[parsedName.Version]
 	if !ok {
 		return nil, fmt.Errorf(
 			"Could not retrieve object, versioned API in path '%s' doesn't exist",
@@ -228,17 +683,20 @@ func (root *root) getAPIObjectHelper(
 // though the logic for creating them is handled largely by `root`.
 type group struct {
 	name          kubespec.GroupName // e.g., core, apps, extensions.
+	apiGroup      kubespec.GroupName // if not `name`, e.g., route.openshift.io.
 	versionedAPIs versionedAPISet    // e.g., v1, v1beta1.
 	parent        *root
+	hidden        bool // whether group is emitted in `local hidden`.
 }
 type groupSet map[kubespec.GroupName]*group
 type groupSlice []*group
 
-func newGroup(name kubespec.GroupName, parent *root) *group {
+func newGroup(name kubespec.GroupName, parent *root, hidden bool) *group {
 	return &group{
 		name:          name,
 		versionedAPIs: make(versionedAPISet),
 		parent:        parent,
+		hidden:        hidden,
 	}
 }
 
@@ -246,20 +704,56 @@ func (group *group) root() *root {
 	return group.parent
 }
 
+// `path` returns the dotted path of the group in the generated
+// library, e.g., `apps`, or `hidden.meta` for hidden groups.
+func (group *group) path() string {
+	k8sVersion := group.root().spec.Info.Version
+	id := string(group.root().naming().RewriteAsIdentifier(k8sVersion, group.name))
+	if group.hidden {
+		return hiddenNamespace + "." + id
+	}
+	return id
+}
+
 func (group *group) emit(m *indentWriter) {
 	k8sVersion := group.root().spec.Info.Version
-	mixinName := jsonnet.RewriteAsIdentifier(k8sVersion, group.name)
+	mixinName := group.root().naming().RewriteAsIdentifier(k8sVersion, group.name)
 	line := fmt.Sprintf("%s:: {", mixinName)
 	m.writeLine(line)
+	group.emitVersions(m)
+	group.root().closeNamespace(m, group.path())
+}
+
+// `emitVersions` emits the fields of the group's namespace, i.e., its
+// versions, indented, so that the caller only opens and closes it.
+func (group *group) emitVersions(m *indentWriter) {
+	start := time.Now()
+	defer func() {
+		group.root().logger().Log(
+			"emit group", "group", group.path(), "objects", group.size(),
+			"duration", time.Since(start))
+		group.root().metrics().Observe(
+			MetricGroupDuration, time.Since(start).Seconds(),
+			group.root().metricLabels(MetricLabelGroup, group.path()))
+	}()
+
 	m.indent()
+	group.root().index.add(group.path(), SymbolNamespace)
 
 	// Emit in sorted order so that we can diff the output.
 	for _, versioned := range group.versionedAPIs.toSortedSlice() {
 		versioned.emit(m)
 	}
+}
 
-	m.dedent()
-	m.writeLine("},")
+// `size` returns the number of API objects in the group, across all
+// of its versions.
+func (group *group) size() int {
+	size := 0
+	for _, va := range group.versionedAPIs {
+		size += len(va.apiObjects)
+	}
+	return size
 }
 
 func (gs groupSet) toSortedSlice() groupSlice {
@@ -307,28 +801,39 @@ func (va *versionedAPI) root() *root {
 	return va.parent.parent
 }
 
+func (va *versionedAPI) path() string {
+	return fmt.Sprintf("%s.%s", va.parent.path(), va.version)
+}
+
+// `apiVersion` returns the `apiVersion` of objects in this version
+// of the API, e.g., `apps/v1beta1`, or just `v1` for the core group.
+func (va *versionedAPI) apiVersion() string {
+	apiGroup := va.parent.apiGroup
+	if apiGroup == "" {
+		apiGroup = va.parent.name
+	}
+	if apiGroup == "core" {
+		return string(va.version)
+	}
+	return fmt.Sprintf("%s/%s", apiGroup, va.version)
+}
+
 func (va *versionedAPI) emit(m *indentWriter) {
 	// NOTE: Do not need to call `jsonnet.RewriteAsIdentifier`.
 	line := fmt.Sprintf("%s:: {", va.version)
 	m.writeLine(line)
 	m.indent()
+	va.root().index.add(va.path(), SymbolNamespace)
 
-	gn := va.parent.name
-	if gn == "core" {
-		m.writeLine(fmt.Sprintf(
-			"local apiVersion = {apiVersion: \"%s\"},", va.version))
-	} else {
-		m.writeLine(fmt.Sprintf(
-			"local apiVersion = {apiVersion: \"%s/%s\"},", gn, va.version))
-	}
+	m.writeLine(fmt.Sprintf(
+		"local apiVersion = {apiVersion: \"%s\"},", va.apiVersion()))
 
 	// Emit in sorted order so that we can diff the output.
-	for _, object := range va.apiObjects.toSortedSlice() {
+	for _, object := range va.emitOrder() {
 		object.emit(m)
 	}
 
-	m.dedent()
-	m.writeLine("},")
+	va.root().closeNamespace(m, va.path())
 }
 
 func (vas versionedAPISet) toSortedSlice() versionedAPISlice {
@@ -356,20 +861,64 @@ func (vas versionedAPISet) toSortedSlice() versionedAPISlice {
 type apiObject struct {
 	name       kubespec.ObjectKind // e.g., `Container` in `v1.Container`
 	properties propertySet         // e.g., container.image, container.env
-	parsedName *kubespec.ParsedDefinitionName
+	parsedName kubespec.ParsedName
 	comments   comments
 	parent     *versionedAPI
 	isTopLevel bool
+	gvks       kubespec.TopLevelSpecs // nil unless `isTopLevel`.
+
+	// What the spec says about the definition, e.g., that it's a list;
+	// see `kubespec.APISpec.Classify`. `isTopLevel` is its level.
+	class kubespec.ObjectClass
+
+	// The definition's whole description, which `comments` has within
+	// `Options.CommentBudgets`, for `INDEX.md`.
+	description comments
+
+	// The definition's example value, if `hasExample`; see
+	// `kubespec.SchemaDefinition.Example`.
+	example    interface{}
+	hasExample bool
+
+	// identifiers of the properties; see `propertyIdentifiers`.
+	identifiers map[kubespec.PropertyName]jsonnet.Identifier
+
+	// The definition's discriminated unions, from
+	// `x-kubernetes-unions`; see `emitUnionHelpers`.
+	unions []*kubespec.Union
+
+	// Whether the definition is of the version a CRD stores; see
+	// `kubespec.SchemaDefinition.StorageVersion`.
+	isStorageVersion bool
+
+	// The names of a top-level kind's properties, in the order the spec
+	// declares them; see `fieldOrder`.
+	declared []kubespec.PropertyName
+
+	// Whether the definition is of an object a CRD schema embeds, whose
+	// `apiVersion` and `kind`, unlike other objects', have setters; see
+	// `kubespec.Property.EmbeddedResource`.
+	isEmbeddedResource bool
 }
 type apiObjectSet map[kubespec.ObjectKind]*apiObject
 type apiObjectSlice []*apiObject
 
 func newAPIObject(
-	name *kubespec.ParsedDefinitionName, parent *versionedAPI,
-	def *kubespec.SchemaDefinition,
+	name kubespec.ParsedName, parent *versionedAPI,
+	def *kubespec.SchemaDefinition, class kubespec.ObjectClass,
 ) *apiObject {
-	isTopLevel := len(def.TopLevelSpecs) > 0
-	comments := newComments(def.Description)
+	isTopLevel := class.IsTopLevel()
+	comments := parent.root().opts.commentBudget(CommentSectionKinds).trim(def.Description, name.Unparse())
+	if isTopLevel && name.Version.Stage() == kubespec.StageAlpha {
+		comments = append(comments, "", alphaWarning)
+	}
+	if isTopLevel && def.StorageVersion {
+		comments = append(comments, "", storageVersionNote)
+	}
+	var declared []kubespec.PropertyName
+	if isTopLevel {
+		declared = def.DeclaredPropertyNames()
+	}
 	return &apiObject{
 		name:       name.Kind,
 		parsedName: name,
@@ -377,7 +926,53 @@ func newAPIObject(
 		comments:   comments,
 		parent:     parent,
 		isTopLevel: isTopLevel,
+		gvks:       def.TopLevelSpecs,
+		class:      class,
+
+		description: newComments(def.Description),
+		example:     def.Example,
+		hasExample:  def.HasExample,
+		unions:      def.Unions,
+
+		isStorageVersion:   def.StorageVersion,
+		declared:           declared,
+		isEmbeddedResource: def.EmbeddedResource,
+	}
+}
+
+// alphaWarning is appended to the comments of top-level kinds in alpha
+// versions, which clusters don't serve unless they're enabled.
+const alphaWarning = "alpha API: not enabled by default."
+
+// storageVersionNote is appended to the comments of the custom
+// resources in the version their CRD stores; see
+// `kubespec.APISpec.WithCRDs`.
+const storageVersionNote = "storage version: the API server stores objects of this kind in this version."
+
+// `scope` reports whether a top-level API object is namespaced or
+// cluster-scoped, according to the paths in the spec.
+func (ao *apiObject) scope() kubespec.Scope {
+	scope := kubespec.ScopeUnknown
+	for _, gvk := range ao.gvks {
+		switch ao.root().spec.Scope(*gvk) {
+		case kubespec.ScopeNamespaced:
+			return kubespec.ScopeNamespaced
+		case kubespec.ScopeCluster:
+			scope = kubespec.ScopeCluster
+		}
+	}
+	return scope
+}
+
+// `resource` returns the REST resource that serves a top-level API
+// object, or nil if the object doesn't appear in the spec's paths.
+func (ao *apiObject) resource() *kubespec.Resource {
+	for _, gvk := range ao.gvks {
+		if resource := ao.root().spec.Resource(*gvk); resource != nil {
+			return resource
+		}
 	}
+	return nil
 }
 
 func (ao apiObject) toRefPropertyMethod(
@@ -398,10 +993,16 @@ func (ao *apiObject) root() *root {
 	return ao.parent.parent.parent
 }
 
+func (ao *apiObject) path() string {
+	k8sVersion := ao.root().spec.Info.Version
+	return fmt.Sprintf(
+		"%s.%s", ao.parent.path(), ao.root().naming().RewriteAsIdentifier(k8sVersion, ao.name))
+}
+
 func (ao *apiObject) emit(m *indentWriter) {
 	k8sVersion := ao.root().spec.Info.Version
 	jsonnetName := kubespec.ObjectKind(
-		jsonnet.RewriteAsIdentifier(k8sVersion, ao.name))
+		ao.root().naming().RewriteAsIdentifier(k8sVersion, ao.name))
 	if _, ok := ao.parent.apiObjects[jsonnetName]; ok {
 		log.Panicf(
 			"Tried to lowercase first character of object kind '%s', but lowercase name was already present in version '%s'",
@@ -409,46 +1010,102 @@ func (ao *apiObject) emit(m *indentWriter) {
 			ao.parent.version)
 	}
 
+	m.push(ao.parsedName.Unparse())
+	defer m.pop()
+
+	ao.emitBanner(m)
+	if warning := ao.removalWarning(); warning != "" {
+		m.writeLine("// " + warning)
+		m.writeLine("//")
+	}
 	ao.comments.emit(m)
 
+	if ga, ok := ao.root().betaAliasOf(ao); ok {
+		ao.emitBetaAlias(m, jsonnetName, ga)
+		return
+	}
+	if ao.root().sharesKind(ao) {
+		ao.emitSharedKindRef(m, jsonnetName)
+		return
+	}
+	ao.emitNamespace(m, jsonnetName)
+}
+
+// `emitNamespace` emits the namespace of an API object, named
+// `jsonnetName`.
+func (ao *apiObject) emitNamespace(m *indentWriter, jsonnetName kubespec.ObjectKind) {
 	m.writeLine(fmt.Sprintf("%s:: {", jsonnetName))
 	m.indent()
+	path := ao.path()
+	if ao.isTopLevel && !m.omitComments {
+		ao.emitFieldsWithExample(m, path)
+	} else {
+		ao.emitFields(m, path)
+	}
+	ao.root().closeNamespace(m, path)
+}
+
+// `emitFields` emits the fields of the namespace of an API object,
+// whose path is `path`.
+func (ao *apiObject) emitFields(m *indentWriter, path string) {
+	symbol := ao.root().index.add(path, SymbolNamespace)
+	symbol.Definition = ao.parsedName.Unparse()
+	symbol.Level = ao.class.Level.String()
 
 	if ao.isTopLevel {
 		// NOTE: It is important to NOT capitalize `ao.name` here.
 		m.writeLine(fmt.Sprintf("local kind = {kind: \"%s\"},", ao.name))
+		kinds := ao.root().index.addKind(
+			path, ao.gvks, ao.scope(), ao.resource(), ao.hasScaleHelpers(), ao.isStorageVersion)
+		for _, kind := range kinds {
+			ao.root().addRemoval(kind)
+		}
 	}
-	ao.emitConstructor(m)
+	ls := ao.labelSelector()
+	if ls != nil && ao.constructorOverride() == nil {
+		ls.emitMixin(m)
+		ao.emitConstructor(m, path)
+		ls.emitHelpers(m, path, ao.root().index)
+	} else {
+		ao.emitConstructor(m, path)
+	}
+	ao.emitScaleHelpers(m, path)
+	ao.emitObjectHelpers(m, path)
+	ao.emitUnionHelpers(m, path)
+	ao.emitPatchHelpers(m, path)
+	ao.emitFieldOrder(m)
+	ao.emitMergeMixin(m, path)
 
 	for _, pm := range ao.properties.sortAndFilterBlacklisted() {
 		// Skip special properties and fields that `$ref` another API
 		// object type, since those will go in the `mixin` namespace.
-		if isSpecialProperty(pm.name) || pm.ref != nil {
+		if ao.isHandledSpecially(pm.name) || pm.isMixin() {
 			continue
 		}
-		pm.emit(m)
+		pm.emit(m, path)
 	}
+	ao.emitDeprecatedShims(m, path)
+	ao.emitExtensionPoints(m, path)
 
 	// Emit the properties that `$ref` another API object type in the
 	// `mixin:: {` namespace.
 	m.writeLine("mixin:: {")
 	m.indent()
+	mixinPath := path + ".mixin"
+	ao.root().index.add(mixinPath, SymbolNamespace)
 
 	for _, pm := range ao.properties.sortAndFilterBlacklisted() {
 		// TODO: Emit mixin code also for arrays whose elements are
 		// `$ref`.
-		if pm.ref == nil {
+		if !pm.isMixin() {
 			continue
 		}
 
-		pm.emit(m)
+		pm.emit(m, mixinPath)
 	}
+	ao.emitDeprecatedShims(m, mixinPath)
 
-	m.dedent()
-	m.writeLine("},")
-
-	m.dedent()
-	m.writeLine("},")
+	ao.root().closeNamespace(m, mixinPath)
 }
 
 // `emitAsRefMixins` recursively emits an API object as a collection
@@ -461,21 +1118,19 @@ func (ao *apiObject) emit(m *indentWriter) {
 // and create mixin methods, so that we can do something like
 // `someDeployment + deployment.mixin.spec.minReadySeconds(3)`.
 func (ao *apiObject) emitAsRefMixins(
-	m *indentWriter, p *property, parentMixinName *string,
+	m *indentWriter, p *property, parentMixinName *string, parentPath string,
 ) {
-	k8sVersion := ao.root().spec.Info.Version
-	functionName := jsonnet.RewriteAsIdentifier(k8sVersion, p.name)
-	paramName := jsonnet.RewriteAsFuncParam(k8sVersion, p.name)
-	fieldName := jsonnet.RewriteAsFieldKey(p.name)
+	functionName := p.parent.identifier(p.name)
+	paramName := p.parent.funcParam(p.name)
 	mixinName := fmt.Sprintf("__%sMixin", functionName)
 	var mixinText string
 	if parentMixinName == nil {
 		mixinText = fmt.Sprintf(
-			"local %s(%s) = {%s+: %s},", mixinName, paramName, fieldName, paramName)
+			"local %s(%s) = %s,", mixinName, paramName, p.fieldObject(true, string(paramName)))
 	} else {
 		mixinText = fmt.Sprintf(
-			"local %s(%s) = %s({%s+: %s}),",
-			mixinName, paramName, *parentMixinName, fieldName, paramName)
+			"local %s(%s) = %s(%s),",
+			mixinName, paramName, *parentMixinName, p.fieldObject(true, string(paramName)))
 	}
 
 	if _, ok := ao.parent.apiObjects[kubespec.ObjectKind(functionName)]; ok {
@@ -487,38 +1142,171 @@ func (ao *apiObject) emitAsRefMixins(
 
 	// NOTE: Comments are emitted by `property#emit`, before we
 	// call this method.
+	m.push(ao.parsedName.Unparse())
+	defer m.pop()
+
+	path := fmt.Sprintf("%s.%s", parentPath, functionName)
+
+	// The server ignores `metadata.namespace` on cluster-scoped kinds,
+	// so we omit (or deprecate) its mixin for them.
+	clusterScoped := parentMixinName == nil &&
+		p.name == "metadata" &&
+		p.parent.isTopLevel &&
+		p.parent.scope() == kubespec.ScopeCluster
+
+	if !clusterScoped && ao.root().shares(ao, path) {
+		ao.emitSharedRef(m, p, parentMixinName, parentPath)
+		return
+	}
 
 	line := fmt.Sprintf("%s:: {", functionName)
 	m.writeLine(line)
 	m.indent()
+	p.addSymbol(path, SymbolNamespace)
 
 	m.writeLine(mixinText)
+	ao.emitLegacyHelpers(m, string(functionName), mixinName, string(paramName), path)
+	ao.emitRefMixinFields(m, p, mixinName, path, clusterScoped)
+	ao.root().closeNamespace(m, path)
+}
 
+// `emitRefMixinFields` emits the mixins of the properties of an API
+// object that `p` refers to, which merge into the object through the
+// local function `mixinName`; see `emitAsRefMixins`.
+func (ao *apiObject) emitRefMixinFields(
+	m *indentWriter, p *property, mixinName, path string, clusterScoped bool,
+) {
 	for _, pm := range ao.properties.sortAndFilterBlacklisted() {
-		if isSpecialProperty(pm.name) {
+		if ao.isHandledSpecially(pm.name) {
 			continue
 		}
-		pm.emitAsRefMixin(m, mixinName)
+		if clusterScoped && pm.name == "namespace" {
+			if !ao.root().opts.DeprecateClusterScopedNamespace {
+				continue
+			}
+			m.writeLine(fmt.Sprintf(
+				"// DEPRECATED: '%s' is cluster-scoped, so the server ignores this field.",
+				p.parent.name))
+		}
+		pm.emitAsRefMixin(m, mixinName, path)
+	}
+	ao.emitDeprecatedShims(m, path)
+	if ao.isEmbeddedResource {
+		ao.emitEmbedFrom(m, mixinName, path)
 	}
-
-	m.dedent()
-	m.writeLine("},")
 }
 
-func (ao *apiObject) emitConstructor(m *indentWriter) {
+func (ao *apiObject) emitConstructor(m *indentWriter, path string) {
 	if dm, ok := ao.properties[constructorName]; ok {
 		log.Panicf(
 			"Attempted to create constructor, but 'new' property already existed at '%s'",
 			dm.path)
 	}
 
+	if override := ao.constructorOverride(); override != nil {
+		override.emit(m, path, ao.isTopLevel, ao.root().index)
+		return
+	} else if ls := ao.labelSelector(); ls != nil {
+		ls.emitConstructor(m, path, ao.root().index)
+		return
+	}
+
 	if ao.isTopLevel {
 		m.writeLine(fmt.Sprintf("%s():: apiVersion + kind,", constructorName))
 	} else {
 		m.writeLine(fmt.Sprintf("%s():: {},", constructorName))
 	}
+	ao.root().index.add(
+		fmt.Sprintf("%s.%s", path, constructorName), SymbolFunction)
+}
+
+// `hasScaleHelpers` reports whether the API object gets scale helpers
+// (see `emitScaleHelpers`): it has a `/scale` subresource, which reads
+// and writes its `spec.replicas` field, an integer.
+func (ao *apiObject) hasScaleHelpers() bool {
+	resource := ao.resource()
+	if resource == nil || !resource.HasSubresource("scale") {
+		return false
+	}
+
+	spec, ok := ao.properties["spec"]
+	if !ok || spec.ref == nil {
+		return false
+	}
+	specObject := ao.root().getAPIObject(ao.root().parseName(*ao.root().refName(spec.ref)))
+	replicas, ok := specObject.properties["replicas"]
+	return ok && replicas.schemaType != nil && *replicas.schemaType == "integer"
+}
+
+// `emitScaleHelpers` emits conveniences for top-level API objects that
+// have a `/scale` subresource: `withReplicas`, which sets
+// `spec.replicas`, and the `scale` namespace, whose `new(replicas)`
+// returns a `Scale` object to write to the subresource (see
+// `scaleKind`).
+func (ao *apiObject) emitScaleHelpers(m *indentWriter, path string) {
+	if !ao.hasScaleHelpers() {
+		return
+	}
+	for _, name := range []kubespec.PropertyName{scaleName, withReplicasName} {
+		if dm, ok := ao.properties[name]; ok {
+			log.Panicf(
+				"Attempted to create scale helpers, but '%s' property already existed at '%s'",
+				name, dm.path)
+		}
+	}
+
+	m.writeLine("// Number of desired replicas, i.e., the `spec.replicas` field read and written by the `/scale` subresource.")
+	m.writeLine(fmt.Sprintf("%s(replicas):: {spec+: {replicas: replicas}},", withReplicasName))
+	ao.root().index.add(fmt.Sprintf("%s.%s", path, withReplicasName), SymbolFunction, "replicas")
+
+	scalePath := fmt.Sprintf("%s.%s", path, scaleName)
+	m.writeLine("// Conveniences for the `/scale` subresource of this object.")
+	m.writeLine(fmt.Sprintf("%s:: {", scaleName))
+	m.indent()
+	ao.root().index.add(scalePath, SymbolNamespace)
+
+	if gvk, ok := ao.scaleKind(); ok {
+		// `Scale` is never in the core group.
+		apiVersion := fmt.Sprintf("%s/%s", gvk.Group, gvk.Version)
+		m.writeLine(fmt.Sprintf(
+			"// A `%s` object (`%s`) with `spec.replicas` set, to write to the `/scale` subresource.",
+			gvk.Kind, apiVersion))
+		m.writeLine(fmt.Sprintf(
+			"%s(replicas):: {apiVersion: %q, kind: %q, spec: {replicas: replicas}},",
+			constructorName, apiVersion, gvk.Kind))
+		ao.root().index.add(
+			fmt.Sprintf("%s.%s", scalePath, constructorName), SymbolFunction, "replicas")
+	}
+
+	m.writeLine("// Number of desired replicas, i.e., the `spec.replicas` field read and written by the `/scale` subresource.")
+	m.writeLine("withReplicas(replicas):: {spec+: {replicas: replicas}},")
+	ao.root().index.add(scalePath+".withReplicas", SymbolFunction, "replicas")
+
+	ao.root().closeNamespace(m, scalePath)
+}
+
+// `scaleKind` returns the kind of the `Scale` objects the `/scale`
+// subresource of the API object reads and writes: the kind its
+// operations give (see `kubespec.Resource.ScaleKind`), or else
+// `autoscaling/v1` Scale, if the spec defines it, under either naming
+// layout (e.g., `io.k8s.api.autoscaling.v1.Scale`, or
+// `io.k8s.kubernetes.pkg.apis.autoscaling.v1.Scale`).
+func (ao *apiObject) scaleKind() (kubespec.TopLevelSpec, bool) {
+	if resource := ao.resource(); resource != nil && resource.ScaleKind != nil {
+		return *resource.ScaleKind, true
+	}
+	for name := range ao.root().spec.Definitions {
+		parsed, err := ao.root().parser.ParseName(name)
+		if err == nil && parsed.Group == autoscalingScale.Group &&
+			parsed.Version == autoscalingScale.Version && parsed.Kind == autoscalingScale.Kind {
+			return autoscalingScale, true
+		}
+	}
+	return kubespec.TopLevelSpec{}, false
 }
 
+var autoscalingScale = kubespec.TopLevelSpec{Group: "autoscaling", Version: "v1", Kind: "Scale"}
+
 func (aos apiObjectSet) toSortedSlice() apiObjectSlice {
 	apiObjects := apiObjectSlice{}
 	for _, apiObject := range aos {
@@ -541,6 +1329,11 @@ const (
 	typeAlias
 )
 
+// intOrStringValue is the body of the setter of an `intOrString` (or
+// `int64String`) property, which checks that its parameter is an
+// integer or a string before setting the field.
+const intOrStringValue = `assert std.type(%[1]s) == "number" || std.type(%[1]s) == "string" : "'%[1]s' must be an integer or a string, got " + std.type(%[1]s); %[2]s`
+
 // `property` is an abstract representation of a ksonnet-lib's
 // property methods, which can be emitted as Jsonnet code using the
 // `emit` method.
@@ -566,6 +1359,51 @@ type property struct {
 	path       kubespec.DefinitionName
 	comments   comments
 	parent     *apiObject
+
+	// opaque is set for properties with effectively no schema (e.g.,
+	// `RawExtension`), which get plain setters and are never recursed
+	// into.
+	opaque bool
+
+	// intOrString is set for properties whose values are integers or
+	// strings, whether they `$ref` `intstr.IntOrString` or (as in CRD
+	// schemas) set `x-kubernetes-int-or-string`. They get setters that
+	// check the type of their argument.
+	intOrString bool
+
+	// int64String is set for `int64` properties whose values may be
+	// too large for a Jsonnet number (see
+	// `kubeversion.IsInt64StringProperty`). They get setters that
+	// accept a string as well, and pass it through unchanged.
+	int64String bool
+
+	// stringified is set, with `Options.EmitStringifiedSetters`, for
+	// maps of strings whose values are often passed as numbers or
+	// booleans (see `kubeversion.IsStringifiedProperty`). They get a
+	// second setter, which converts the values with `util.toString`.
+	stringified bool
+
+	// typeString describes the type of the property for people (see
+	// `kubespec.APISpec.TypeString`), for its comment and its symbols.
+	typeString string
+
+	// The property's example value, if `hasExample`; see
+	// `kubespec.Property.Example`.
+	example    interface{}
+	hasExample bool
+
+	// Server-side apply metadata for arrays. Atomic lists get setters
+	// that replace, rather than append to, the list.
+	listType    kubespec.ListType
+	listMapKeys []string
+
+	// The field that identifies the items of a list in a strategic-merge
+	// patch (`x-kubernetes-patch-merge-key`); see `emitPatchHelpers`.
+	patchMergeKey string
+
+	// The bounds the schema sets on the property's values, which its
+	// setter asserts with `Options.EmitValidation`.
+	constraints []constraint
 }
 type propertySet map[kubespec.PropertyName]*property
 type propertySlice []*property
@@ -574,7 +1412,82 @@ func newPropertyMethod(
 	name kubespec.PropertyName, path kubespec.DefinitionName,
 	prop *kubespec.Property, parent *apiObject,
 ) *property {
-	comments := newComments(prop.Description)
+	root := parent.root()
+	comments := root.opts.commentBudget(CommentSectionProperties).trim(prop.Description, path)
+
+	intOrString := prop.IsIntOrString() || root.isIntOrStringRef(prop.Ref)
+	opaque := !intOrString && (prop.IsUntyped() || root.isUntypedRef(prop.Ref))
+	if intOrString {
+		comments = append(comments, "", "This field accepts integer or string, e.g., `8080` or `\"http\"`.")
+	} else if opaque {
+		functionName := parent.identifier(name)
+		comments = append(comments,
+			"",
+			fmt.Sprintf(
+				"This field is not validated by the schema, and accepts arbitrary JSON. `%s` replaces its value, and `%sMixin` merges into it.",
+				functionName, functionName))
+		if external := root.externalRef(prop.Ref); external != nil {
+			comments = append(comments, fmt.Sprintf(
+				"Its type is in the external library `%s`, at `%s`, which `%s` points at.",
+				external.library, external.path, parent.identifier(name+"Type")))
+		}
+		if prop.PreserveUnknownFields {
+			comments = append(comments,
+				"The schema sets `x-kubernetes-preserve-unknown-fields`, so the server keeps fields it does not recognize.")
+		}
+	} else if prop.Type != nil && *prop.Type == "array" && root.isUntypedRef(prop.Items.Ref) {
+		comments = append(comments,
+			"",
+			"The elements of this array are not validated by the schema, and accept arbitrary JSON.")
+		if external := root.externalRef(prop.Items.Ref); external != nil {
+			comments = append(comments, fmt.Sprintf(
+				"Their type is in the external library `%s`, at `%s`, which `%s` points at.",
+				external.library, external.path, parent.identifier(name+"Type")))
+		}
+	}
+	if !opaque && prop.IsAtomicList() {
+		comments = append(comments,
+			"",
+			"This list is atomic (`x-kubernetes-list-type: atomic`), so the server replaces it as a whole rather than merging its elements. To match, this function replaces the list rather than appending to it.")
+	}
+	int64String := false
+	if prop.IsInt64() {
+		int64String = root.kubeVersions.IsInt64StringProperty(root.spec.Info.Version, name)
+		comments = append(comments,
+			"",
+			"Values of this field are 64-bit integers (`int64`), but Jsonnet numbers are doubles, which only hold integers exactly up to 2^53 (9007199254740992).")
+		if int64String {
+			comments = append(comments,
+				"Larger values can be passed as strings, e.g., `\"12345678901234567890\"`, which this function passes through unchanged.")
+		}
+	}
+	stringified := root.opts.EmitStringifiedSetters && !opaque && isStringMap(prop) &&
+		root.kubeVersions.IsStringifiedProperty(root.spec.Info.Version, parent.name, name)
+	if stringified {
+		comments = append(comments,
+			"",
+			fmt.Sprintf(
+				"The server requires the values to be strings, which this function passes through unchanged; `%sStringified` converts ones that aren't (e.g., `3` or `true`).",
+				parent.identifier(name)))
+	}
+	constraints := propertyConstraints(prop)
+	if bounds := root.constraintComments(prop, constraints); len(bounds) > 0 {
+		comments = append(comments, "")
+		comments = append(comments, bounds...)
+	}
+	if root.opts.CommentExamples && prop.HasExample {
+		if example, ok := kubespec.ScalarExample(prop.Example); ok {
+			comments = append(comments, "", fmt.Sprintf("Example: `%s`.", example))
+		}
+	}
+	typeString := root.spec.TypeString(prop)
+	if len(comments) == 1 && comments[0] == "" {
+		comments = comments[:0]
+	} else {
+		comments = append(comments, "")
+	}
+	comments = append(comments, typeTag+typeString)
+
 	return &property{
 		kind:       method,
 		ref:        prop.Ref,
@@ -584,14 +1497,38 @@ func newPropertyMethod(
 		path:       path,
 		comments:   comments,
 		parent:     parent,
+		opaque:     opaque,
+
+		intOrString: intOrString,
+		int64String: int64String,
+		stringified: stringified,
+		typeString:  typeString,
+		example:     prop.Example,
+		hasExample:  prop.HasExample,
+
+		listType:    prop.ListType,
+		listMapKeys: prop.ListMapKeys,
+
+		patchMergeKey: prop.PatchMergeKey,
+		constraints:   constraints,
+	}
+}
+
+// `isStringMap` reports whether `prop` is a map whose values are
+// strings, e.g., a ConfigMap's `data`.
+func isStringMap(prop *kubespec.Property) bool {
+	if prop.Type == nil || *prop.Type != "object" || prop.AdditionalProperties == nil {
+		return false
 	}
+	values := prop.AdditionalProperties.Schema
+	return values != nil && values.Type != nil && *values.Type == "string"
 }
 
 func newPropertyTypeAlias(
 	name kubespec.PropertyName, path kubespec.DefinitionName,
 	prop *kubespec.Property, parent *apiObject,
 ) *property {
-	comments := newComments(prop.Description)
+	comments := parent.root().opts.commentBudget(CommentSectionProperties).trim(prop.Description, path)
 	return &property{
 		kind:       typeAlias,
 		ref:        prop.Ref,
@@ -604,12 +1541,66 @@ func newPropertyTypeAlias(
 	}
 }
 
+// typeTag starts the last line of the comment of each property, which
+// gives its type, e.g., `@type integer (int64)`.
+const typeTag = "@type "
+
 func (p *property) root() *root {
 	return p.parent.parent.parent.parent
 }
 
-func (p *property) emit(m *indentWriter) {
-	p.emitHelper(m, nil)
+// `schemaName` is the name of the property in the spec, which, for
+// type aliases, is their name without `Type` (e.g., `containers` for
+// `containersType`).
+func (p *property) schemaName() kubespec.PropertyName {
+	if p.kind == typeAlias {
+		return kubespec.PropertyName(strings.TrimSuffix(string(p.name), "Type"))
+	}
+	return p.name
+}
+
+// `addSymbol` adds a symbol emitted for the property to the index,
+// recording its type and where it is in the spec.
+func (p *property) addSymbol(path string, kind SymbolKind, params ...string) *Symbol {
+	symbol := p.root().index.add(path, kind, params...)
+	symbol.Type = p.typeString
+	symbol.Definition = p.path
+	symbol.Property = p.schemaName()
+	if preferred, ok := p.parent.renamedFrom(p.name); ok {
+		symbol.RenamedFrom = string(preferred)
+	}
+	return symbol
+}
+
+// `isMixin` reports whether a property `$ref`s an API object whose
+// properties are emitted as mixins, in the `mixin` namespace.
+func (p *property) isMixin() bool {
+	return p.ref != nil && !p.opaque && !p.intOrString
+}
+
+// `fieldObject` returns the object that sets the property's field to
+// `value`, e.g., `{image: image}`, or, if `merge` is set, merges
+// `value` into it, e.g., `{spec+: spec}`. If the property is a member
+// of a union with a discriminator (see `kubespec.Union`), the object
+// also sets the discriminator to the value that names it, e.g.,
+// `{httpGet+: httpGet, type: "HTTPGet"}`.
+func (p *property) fieldObject(merge bool, value string) string {
+	op := ":"
+	if merge {
+		op = "+:"
+	}
+	fields := fmt.Sprintf("%s%s %s", jsonnet.RewriteAsFieldKey(p.name), op, value)
+	for _, union := range p.parent.unions {
+		if discriminatorValue, ok := union.Fields[p.name]; ok && union.Discriminator != "" {
+			fields += fmt.Sprintf(
+				", %s: %q", jsonnet.RewriteAsFieldKey(union.Discriminator), discriminatorValue)
+		}
+	}
+	return "{" + fields + "}"
+}
+
+func (p *property) emit(m *indentWriter, path string) {
+	p.emitHelper(m, nil, path)
 }
 
 // `emitAsRefMixin` will emit a property as a mixin method, so that it
@@ -623,40 +1614,44 @@ func (p *property) emit(m *indentWriter) {
 // This method will take the `property`, which specifies a
 // property method, and use it to emit such a "mixin method".
 func (p *property) emitAsRefMixin(
-	m *indentWriter, parentMixinName string,
+	m *indentWriter, parentMixinName string, path string,
 ) {
-	p.emitHelper(m, &parentMixinName)
+	p.emitHelper(m, &parentMixinName, path)
 }
 
-func (p *property) emitAsTypeAlias(m *indentWriter) {
-	var path kubespec.DefinitionName
+func (p *property) emitAsTypeAlias(m *indentWriter, path string) {
+	var defName kubespec.DefinitionName
 	if p.ref != nil {
-		path = *p.ref.Name()
+		defName = *p.root().refName(p.ref)
 	} else {
-		path = *p.itemTypes.Ref.Name()
-	}
-	parsedPath := path.Parse()
-	if parsedPath.Version == nil {
-		log.Printf("Could not emit type alias for '%s'\n", path)
-		return
+		defName = *p.root().refName(p.itemTypes.Ref)
 	}
-
-	k8sVersion := p.root().spec.Info.Version
-	typeName := jsonnet.RewriteAsIdentifier(k8sVersion, p.name)
-
-	var group kubespec.GroupName
-	if parsedPath.Group == nil {
-		group = "core"
+	typeName := p.parent.identifier(p.name)
+	var target string
+	if external := p.root().externals[defName]; external != nil {
+		target = fmt.Sprintf("%s.%s", external.local(), external.path)
 	} else {
-		group = *parsedPath.Group
-	}
+		parsedPath := p.root().parseName(defName)
+		if !parsedPath.HasVersion() {
+			log.Printf("Could not emit type alias for '%s'\n", defName)
+			return
+		}
 
-	id := jsonnet.RewriteAsIdentifier(k8sVersion, parsedPath.Kind)
-	line := fmt.Sprintf(
-		"%s:: hidden.%s.%s.%s,",
-		typeName, group, parsedPath.Version, id)
+		k8sVersion := p.root().spec.Info.Version
+		group, _ := p.root().groupName(parsedPath)
+		id := p.root().naming().RewriteAsIdentifier(k8sVersion, parsedPath.Kind)
+		target = fmt.Sprintf("hidden.%s.%s.%s", group, parsedPath.Version, id)
+	}
+	line := fmt.Sprintf("%s:: %s,", typeName, target)
 
 	m.writeLine(line)
+	symbol := p.root().index.addAlias(fmt.Sprintf("%s.%s", path, typeName), target)
+	symbol.Definition = p.path
+	symbol.Property = p.schemaName()
+	symbol.Items = p.schemaType != nil && *p.schemaType == "array"
+	if preferred, ok := p.parent.renamedFrom(p.name); ok {
+		symbol.RenamedFrom = string(preferred)
+	}
 }
 
 // `emitHelper` emits the Jsonnet program text for a `property`,
@@ -669,54 +1664,93 @@ func (p *property) emitAsTypeAlias(m *indentWriter) {
 // `emitHelper` to emit this property as a normal, non-mixin property
 // method, it is necessary for `parentMixinName == nil`.
 func (p *property) emitHelper(
-	m *indentWriter, parentMixinName *string,
+	m *indentWriter, parentMixinName *string, path string,
 ) {
 	if p.kind == typeAlias {
-		p.emitAsTypeAlias(m)
+		p.emitAsTypeAlias(m, path)
 		return
 	}
 
 	p.comments.emit(m)
 
-	k8sVersion := p.root().spec.Info.Version
-	functionName := jsonnet.RewriteAsIdentifier(k8sVersion, p.name)
-	paramName := jsonnet.RewriteAsFuncParam(k8sVersion, p.name)
-	fieldName := jsonnet.RewriteAsFieldKey(p.name)
+	functionName := p.parent.identifier(p.name)
+	paramName := p.parent.funcParam(p.name)
 	signature := fmt.Sprintf("%s(%s)::", functionName, paramName)
 
-	if p.ref != nil {
-		parsedRefPath := p.ref.Name().Parse()
+	if p.opaque {
+		mixinFunctionName := fmt.Sprintf("%sMixin", functionName)
+		replace := p.fieldObject(false, string(paramName))
+		merge := p.fieldObject(true, string(paramName))
+		if parentMixinName != nil {
+			replace = fmt.Sprintf("%s(%s)", *parentMixinName, replace)
+			merge = fmt.Sprintf("%s(%s)", *parentMixinName, merge)
+		}
+
+		m.writeLine(fmt.Sprintf("%s %s,", signature, replace))
+		m.writeLine(fmt.Sprintf(
+			"%s(%s):: %s,", mixinFunctionName, paramName, merge))
+		p.addSymbol(fmt.Sprintf("%s.%s", path, functionName), SymbolFunction, string(paramName))
+		p.addSymbol(fmt.Sprintf("%s.%s", path, mixinFunctionName), SymbolFunction, string(paramName))
+	} else if p.intOrString || p.int64String {
+		body := p.fieldObject(false, string(paramName))
+		if parentMixinName != nil {
+			body = fmt.Sprintf("%s(%s)", *parentMixinName, body)
+		}
+		m.writeLine(fmt.Sprintf(
+			"%s %s,", signature,
+			fmt.Sprintf(intOrStringValue, paramName, p.assertConstraints(string(paramName), body))))
+		p.addSymbol(fmt.Sprintf("%s.%s", path, functionName), SymbolFunction, string(paramName))
+	} else if p.ref != nil {
+		parsedRefPath := p.root().parseName(*p.root().refName(p.ref))
 		apiObject := p.root().getAPIObject(parsedRefPath)
-		apiObject.emitAsRefMixins(m, p, parentMixinName)
+		apiObject.emitAsRefMixins(m, p, parentMixinName, path)
 	} else if p.schemaType != nil {
 		paramType := *p.schemaType
+		if paramType == "boolean" {
+			signature = fmt.Sprintf("%s(%s=%s)::", functionName, paramName, booleanDefault)
+		}
 
 		var body string
 		switch paramType {
 		case "array":
-			if parentMixinName == nil {
+			if p.listType == kubespec.ListTypeAtomic {
+				body = fmt.Sprintf(
+					"if std.type(%s) == \"array\" then %s else %s",
+					paramName, p.fieldObject(false, string(paramName)),
+					p.fieldObject(false, fmt.Sprintf("[%s]", paramName)),
+				)
+				if parentMixinName != nil {
+					body = fmt.Sprintf(
+						"if std.type(%s) == \"array\" then %s(%s) else %s(%s)",
+						paramName, *parentMixinName, p.fieldObject(false, string(paramName)),
+						*parentMixinName, p.fieldObject(false, fmt.Sprintf("[%s]", paramName)),
+					)
+				}
+			} else if parentMixinName == nil {
 				body = fmt.Sprintf(
-					"if std.type(%s) == \"array\" then {%s+: %s} else {%s: [%s]}",
-					paramName, fieldName, paramName, fieldName, paramName,
+					"if std.type(%s) == \"array\" then %s else %s",
+					paramName, p.fieldObject(true, string(paramName)),
+					p.fieldObject(false, fmt.Sprintf("[%s]", paramName)),
 				)
 			} else {
 				body = fmt.Sprintf(
-					"if std.type(%s) == \"array\" then %s({%s+: %s}) else %s({%s: [%s]})",
-					paramName, *parentMixinName, fieldName, paramName, *parentMixinName,
-					fieldName, paramName,
+					"if std.type(%s) == \"array\" then %s(%s) else %s(%s)",
+					paramName, *parentMixinName, p.fieldObject(true, string(paramName)),
+					*parentMixinName, p.fieldObject(false, fmt.Sprintf("[%s]", paramName)),
 				)
 			}
-		case "integer", "string", "boolean":
+		case "integer", "number", "string", "boolean":
 			if parentMixinName == nil {
-				body = fmt.Sprintf("{%s: %s}", fieldName, paramName)
+				body = p.fieldObject(false, string(paramName))
 			} else {
-				body = fmt.Sprintf("%s({%s: %s})", *parentMixinName, fieldName, paramName)
+				body = fmt.Sprintf("%s(%s)", *parentMixinName, p.fieldObject(false, string(paramName)))
 			}
+			body = p.assertConstraints(string(paramName), body)
 		case "object":
 			if parentMixinName == nil {
-				body = fmt.Sprintf("{%s+: %s}", fieldName, paramName)
+				body = p.fieldObject(true, string(paramName))
 			} else {
-				body = fmt.Sprintf("%s({%s+: %s})", *parentMixinName, fieldName, paramName)
+				body = fmt.Sprintf("%s(%s)", *parentMixinName, p.fieldObject(true, string(paramName)))
 			}
 		default:
 			log.Panicf("Unrecognized type '%s'", paramType)
@@ -724,6 +1758,16 @@ func (p *property) emitHelper(
 
 		line := fmt.Sprintf("%s %s,", signature, body)
 		m.writeLine(line)
+		symbol := p.addSymbol(
+			fmt.Sprintf("%s.%s", path, functionName), SymbolFunction, string(paramName))
+		symbol.ListType = string(p.listType)
+		symbol.ListMapKeys = p.listMapKeys
+		if paramType == "boolean" {
+			symbol.Defaults = map[string]string{string(paramName): booleanDefault}
+		}
+		if p.stringified {
+			p.emitStringified(m, functionName, paramName, parentMixinName, path)
+		}
 	} else {
 		log.Panicf("Neither a type nor a ref")
 	}
@@ -733,16 +1777,10 @@ func (aos propertySet) sortAndFilterBlacklisted() propertySlice {
 	properties := propertySlice{}
 	for _, pm := range aos {
 		k8sVersion := pm.root().spec.Info.Version
-		var name kubespec.PropertyName
-		if pm.kind == typeAlias {
-			name = kubespec.PropertyName(strings.TrimSuffix(string(pm.name), "Type"))
-		} else {
-			name = pm.name
-		}
-		if kubeversion.IsBlacklistedProperty(k8sVersion, pm.path, name) {
+		if pm.root().kubeVersions.IsBlacklistedProperty(k8sVersion, pm.path, pm.schemaName()) {
 			continue
-		} else if pm.ref != nil {
-			if parsed := pm.ref.Name().Parse(); parsed.Version == nil {
+		} else if pm.isMixin() {
+			if parsed := pm.root().parseName(*pm.root().refName(pm.ref)); !parsed.HasVersion() {
 				continue
 			}
 		}
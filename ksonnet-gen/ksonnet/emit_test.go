@@ -0,0 +1,1351 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func emitTestSpec(t *testing.T, path string, opts Options) string {
+	text, err := Emit(context.Background(), loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	return string(text)
+}
+
+// objectText returns the text of the top-level API object named
+// `kind` (e.g., `deployment`) in `library`, or "" if there isn't one.
+func objectText(library, kind string) string {
+	start := strings.Index(library, "\n      "+kind+":: {")
+	if start == -1 {
+		return ""
+	}
+	end := strings.Index(library[start:], "\n      },")
+	return library[start : start+end]
+}
+
+// namespaceMixin returns the text of the `metadata.namespace` mixin
+// emitted for `kind`, or "" if there isn't one.
+func namespaceMixin(library, kind string) string {
+	object := objectText(library, kind)
+
+	const mixin = "namespace(namespace):: __metadataMixin({namespace: namespace}),"
+	lines := strings.Split(object, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == mixin {
+			// Include the comments preceding the mixin.
+			first := i
+			for first > 0 && strings.HasPrefix(strings.TrimSpace(lines[first-1]), "//") {
+				first--
+			}
+			return strings.Join(lines[first:i+1], "\n")
+		}
+	}
+	return ""
+}
+
+func TestClusterScopedNamespaceMixin(t *testing.T) {
+	library := emitTestSpec(t, "testdata/swagger.json", Options{})
+	if namespaceMixin(library, "service") == "" {
+		t.Errorf("Expected namespaced kind 'Service' to have a namespace mixin")
+	}
+	if namespaceMixin(library, "deployment") == "" {
+		t.Errorf("Expected namespaced kind 'Deployment' to have a namespace mixin")
+	}
+	if mixin := namespaceMixin(library, "namespace"); mixin != "" {
+		t.Errorf("Expected cluster-scoped kind 'Namespace' to omit namespace mixin, got:\n%s", mixin)
+	}
+
+	library = emitTestSpec(
+		t, "testdata/swagger.json", Options{DeprecateClusterScopedNamespace: true})
+	mixin := namespaceMixin(library, "namespace")
+	if !strings.Contains(mixin, "// DEPRECATED: 'Namespace' is cluster-scoped") {
+		t.Errorf("Expected deprecated namespace mixin for 'Namespace', got:\n%s", mixin)
+	}
+	if strings.Contains(namespaceMixin(library, "service"), "DEPRECATED") {
+		t.Errorf("Expected namespace mixin for 'Service' not to be deprecated")
+	}
+}
+
+func TestScaleHelpers(t *testing.T) {
+	library := emitTestSpec(t, "testdata/swagger.json", Options{})
+
+	const helper = "withReplicas(replicas):: {spec+: {replicas: replicas}},"
+	deployment := objectText(library, "deployment")
+	if !strings.Contains(deployment, "scale:: {") || !strings.Contains(deployment, helper) {
+		t.Errorf("Expected 'Deployment' to have scale helpers, got:\n%s", deployment)
+	}
+	// The operations of the subresource give the kind of `Scale`.
+	expected := []string{
+		"withReplicas(replicas):: {spec+: {replicas: replicas}},",
+		`new(replicas):: {apiVersion: "apps/v1beta1", kind: "Scale", spec: {replicas: replicas}},`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(deployment, line) {
+			t.Errorf("Expected 'Deployment' to contain '%s', got:\n%s", line, deployment)
+		}
+	}
+	service := objectText(library, "service")
+	if strings.Contains(service, "scale:: {") || strings.Contains(service, "withReplicas") {
+		t.Errorf("Expected 'Service' to have no scale helpers")
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	for _, kind := range index.Kinds {
+		if scales := kind.Kind == "Deployment"; kind.ScaleHelpers != scales {
+			t.Errorf("Expected '%s' to have scaleHelpers %t in the symbol index", kind.Path, scales)
+		}
+	}
+
+	// Otherwise, it's `autoscaling/v1` Scale, if the spec defines it.
+	statefulSet := objectText(emitTestSpec(t, "testdata/scale.json", Options{}), "statefulSet")
+	line := `new(replicas):: {apiVersion: "autoscaling/v1", kind: "Scale", spec: {replicas: replicas}},`
+	if !strings.Contains(statefulSet, line) {
+		t.Errorf("Expected 'StatefulSet' to contain '%s', got:\n%s", line, statefulSet)
+	}
+}
+
+func TestBooleanSetterDefaults(t *testing.T) {
+	library := emitTestSpec(t, "testdata/workloads.json", Options{})
+	job := objectText(library, "job")
+	if !strings.Contains(job, "manualSelector(manualSelector=true):: __specMixin({manualSelector: manualSelector}),") {
+		t.Errorf("Expected boolean setter to default to true, got:\n%s", job)
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/workloads.json"), Options{})
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	symbols := index.byPath()
+	expected := map[string]map[string]string{
+		"batch.v1.job.mixin.spec.manualSelector": {"manualSelector": "true"},
+		"apps.v1.deployment.mixin.spec.replicas": nil,
+		// Constructors record their defaults too.
+		"extensions.v1beta1.daemonSet.new": {"labels": "null"},
+	}
+	for path, defaults := range expected {
+		symbol, ok := symbols[path]
+		if !ok {
+			t.Errorf("Expected symbol '%s'", path)
+		} else if !reflect.DeepEqual(symbol.Defaults, defaults) {
+			t.Errorf("Expected '%s' to have defaults %v, got %v", path, defaults, symbol.Defaults)
+		}
+	}
+}
+
+func TestOpaqueProperties(t *testing.T) {
+	library := emitTestSpec(t, "testdata/swagger.json", Options{})
+	list := objectText(library, "list")
+	if !strings.Contains(list, `items(items):: if std.type(items) == "array" then {items+: items} else {items: [items]},`) {
+		t.Errorf("Expected array setter for 'List.items', got:\n%s", list)
+	}
+	if !strings.Contains(list, "// The elements of this array are not validated by the schema") {
+		t.Errorf("Expected 'List.items' comment to mention it is unvalidated, got:\n%s", list)
+	}
+	if strings.Contains(list, "itemsType") {
+		t.Errorf("Expected no type alias for array of 'RawExtension', got:\n%s", list)
+	}
+
+	library = emitTestSpec(t, "testdata/crd.json", Options{})
+	expected := []string{
+		"config(config):: __specMixin({config: config}),",
+		"configMixin(config):: __specMixin({config+: config}),",
+		"payload(payload):: __specMixin({payload: payload}),",
+		"payloadMixin(payload):: __specMixin({payload+: payload}),",
+		"template(template):: __specMixin({template: template}),",
+		"templateMixin(template):: __specMixin({template+: template}),",
+		"// The schema sets `x-kubernetes-preserve-unknown-fields`",
+		"// This field is not validated by the schema, and accepts arbitrary JSON. `template` replaces its value, and `templateMixin` merges into it.",
+		// Typed maps are not opaque.
+		"selector(selector):: __specMixin({selector+: selector}),",
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain '%s'", line)
+		}
+	}
+	if strings.Contains(library, "selectorMixin") || strings.Contains(library, "payload:: {") {
+		t.Errorf("Expected only opaque properties to get opaque setters:\n%s", library)
+	}
+}
+
+func TestIntOrStringProperties(t *testing.T) {
+	library := emitTestSpec(t, "testdata/intorstring.json", Options{})
+	expected := []string{
+		// `x-kubernetes-int-or-string`, with and without `anyOf`, and in
+		// inline objects.
+		`scrapePort(scrapePort):: assert std.type(scrapePort) == "number" || std.type(scrapePort) == "string" : "'scrapePort' must be an integer or a string, got " + std.type(scrapePort); __specMixin({scrapePort: scrapePort}),`,
+		`targetPort(targetPort):: assert std.type(targetPort) == "number" || std.type(targetPort) == "string" : "'targetPort' must be an integer or a string, got " + std.type(targetPort); __endpointMixin({targetPort: targetPort}),`,
+		// `anyOf` an integer and a string.
+		`maxUnavailable(maxUnavailable):: assert std.type(maxUnavailable) == "number" || std.type(maxUnavailable) == "string" : "'maxUnavailable' must be an integer or a string, got " + std.type(maxUnavailable); __specMixin({maxUnavailable: maxUnavailable}),`,
+		"// This field accepts integer or string, e.g., `8080` or `\"http\"`.",
+		// Other `anyOf`s are still opaque.
+		"selectorMixin(selector):: __specMixin({selector+: selector}),",
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain '%s'", line)
+		}
+	}
+	if strings.Contains(library, "scrapePortMixin") || strings.Contains(library, "maxUnavailableMixin") {
+		t.Errorf("Expected integer-or-string properties not to be opaque:\n%s", library)
+	}
+
+	// Built-in fields that `$ref` `intstr.IntOrString` get the same
+	// setters, rather than an empty mixin namespace.
+	library = emitTestSpec(t, "testdata/ingress.json", Options{})
+	line := `targetPort(targetPort):: assert std.type(targetPort) == "number" || std.type(targetPort) == "string" : "'targetPort' must be an integer or a string, got " + std.type(targetPort); {targetPort: targetPort},`
+	if !strings.Contains(library, line) {
+		t.Errorf("Expected library to contain '%s'", line)
+	}
+	if strings.Contains(library, "targetPort:: {") || strings.Contains(library, "targetPortType::") {
+		t.Errorf("Expected no mixin namespace or type alias for 'targetPort':\n%s", library)
+	}
+}
+
+func TestInt64Properties(t *testing.T) {
+	library := emitTestSpec(t, "testdata/int64.json", Options{CommentExamples: true})
+	expected := []string{
+		// Every `int64` property's comment mentions the caveat, and the
+		// example keeps every digit.
+		"// How long the Batch may run for.\n            //\n            // Values of this field are 64-bit integers (`int64`), but Jsonnet numbers are doubles, which only hold integers exactly up to 2^53 (9007199254740992).\n            //\n            // Example: `9007199254740993`.\n            //\n            // @type integer (int64)\n            activeDeadlineSeconds(activeDeadlineSeconds):: __specMixin({activeDeadlineSeconds: activeDeadlineSeconds}),",
+		// Known-risky ones also accept strings.
+		"// Larger values can be passed as strings, e.g., `\"12345678901234567890\"`, which this function passes through unchanged.\n",
+		`resourceVersion(resourceVersion):: assert std.type(resourceVersion) == "number" || std.type(resourceVersion) == "string" : "'resourceVersion' must be an integer or a string, got " + std.type(resourceVersion); __specMixin({resourceVersion: resourceVersion}),`,
+		"// @type integer (int32)\n            parallelism(parallelism):: __specMixin({parallelism: parallelism}),",
+	}
+	for _, text := range expected {
+		if !strings.Contains(library, text) {
+			t.Errorf("Expected library to contain '%s':\n%s", text, library)
+		}
+	}
+	// In both `stable.v1.batch.mixin.spec` and the hidden `batchSpec`.
+	if strings.Count(library, "64-bit integers") != 4 || strings.Count(library, "passed as strings") != 2 {
+		t.Errorf("Expected only the int64 properties to mention it:\n%s", library)
+	}
+}
+
+func TestPropertyTypeComments(t *testing.T) {
+	library := emitTestSpec(t, "testdata/swagger.json", Options{})
+	expected := []string{
+		"// Number of desired pods.\n            //\n            // @type integer (int32)\n            replicas(replicas)::",
+		"// @type map of string → string\n            labels(labels)::",
+		"// @type array of Container\n                containers(containers)::",
+		"// @type object (PodTemplateSpec)\n            template:: {",
+	}
+	for _, text := range expected {
+		if !strings.Contains(library, text) {
+			t.Errorf("Expected library to contain '%s'", text)
+		}
+	}
+
+	// Properties without a description get just the type.
+	library = emitTestSpec(t, "testdata/stages.json", Options{})
+	if !strings.Contains(library, "mixin:: {\n          // @type object (ObjectMeta)\n          metadata:: {") {
+		t.Errorf("Expected undescribed 'metadata' to have only its type:\n%s", library)
+	}
+
+	library = emitTestSpec(t, "testdata/intorstring.json", Options{})
+	if !strings.Contains(library, "// @type IntOrString\n") {
+		t.Errorf("Expected integer-or-string properties to have type 'IntOrString':\n%s", library)
+	}
+}
+
+func TestCommentExamples(t *testing.T) {
+	library := emitTestSpec(t, "testdata/examples.json", Options{})
+	if strings.Contains(library, "Example:") {
+		t.Errorf("Expected no examples in comments by default:\n%s", library)
+	}
+
+	library = emitTestSpec(t, "testdata/examples.json", Options{CommentExamples: true})
+	expected := []string{
+		"// Name must be unique within a namespace.\n          //\n          // Example: `\"web\"`.\n          //\n          // @type string\n",
+		"// Example: `\"my.database.example.com\"`.\n",
+		"// Example: `true`.\n",
+		"// Example: `null`.\n",
+	}
+	for _, text := range expected {
+		if !strings.Contains(library, text) {
+			t.Errorf("Expected library to contain '%s':\n%s", text, library)
+		}
+	}
+
+	// Arrays and objects are left to `INDEX.md`.
+	if strings.Contains(library, "Example: `[") {
+		t.Errorf("Expected no comment examples of arrays:\n%s", library)
+	}
+}
+
+func TestOnlyVersions(t *testing.T) {
+	opts := Options{OnlyVersions: []kubespec.GroupVersion{{Group: "apps", Version: "v1beta2"}}}
+	s := loadTestSpec(t, "testdata/versions.json")
+	text, err := Emit(context.Background(), s, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+
+	// The other versions of `Deployment` are gone, but what it uses is
+	// kept as hidden objects.
+	expected := []string{
+		"  apps:: {\n    v1beta2:: {",
+		"templateType:: hidden.core.v1.podTemplateSpec,",
+		"        podTemplateSpec:: {",
+		"containersType:: hidden.core.v1.container,",
+		"metadataType:: hidden.meta.v1.objectMeta,",
+		"        objectMeta:: {",
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain '%s':\n%s", line, library)
+		}
+	}
+	for _, unexpected := range []string{"v1beta1::", "extensions::", "      pod:: {"} {
+		if strings.Contains(library, unexpected) {
+			t.Errorf("Expected library not to contain '%s':\n%s", unexpected, library)
+		}
+	}
+
+	// The aliases only point at what's left.
+	aliases, err := EmitAliases(s, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	expectedAliases := "k8s + {\n" +
+		"  // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `Deployment`; use `apps/v1` instead.\n" +
+		"  deployment:: k8s.apps.v1beta2.deployment,\n" +
+		"  // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `StatefulSet`; use `apps/v1` instead.\n" +
+		"  statefulSet:: k8s.apps.v1beta2.statefulSet,\n}\n"
+	if !strings.HasSuffix(string(aliases), expectedAliases) {
+		t.Errorf("Expected aliases to end with:\n%s\ngot:\n%s", expectedAliases, aliases)
+	}
+
+	skipped := map[kubespec.DefinitionName]SkipReason{}
+	for _, sd := range SkippedDefinitions(s, opts) {
+		skipped[sd.Name] = sd.Reason
+	}
+	for _, name := range []kubespec.DefinitionName{
+		"io.k8s.api.apps.v1beta1.Deployment",
+		"io.k8s.api.extensions.v1beta1.DeploymentSpec",
+		"io.k8s.api.core.v1.Pod",
+	} {
+		if skipped[name] != SkipFiltered {
+			t.Errorf("Expected '%s' to be skipped as filtered, got '%s'", name, skipped[name])
+		}
+	}
+	if _, ok := skipped["io.k8s.api.core.v1.PodTemplateSpec"]; ok {
+		t.Errorf("Expected 'PodTemplateSpec' not to be skipped")
+	}
+}
+
+func TestAlphaWarnings(t *testing.T) {
+	library := emitTestSpec(t, "testdata/stages.json", Options{})
+
+	// The warning follows the description of each top-level alpha kind,
+	// but not of beta or GA kinds, or of hidden alpha sub-objects.
+	warned := "\n      //\n      // " + alphaWarning + "\n      "
+	for _, kind := range []string{"horizontalPodAutoscaler", "cronJob", "podPreset"} {
+		if !strings.Contains(library, warned+kind+":: {") {
+			t.Errorf("Expected alpha '%s' to have a warning:\n%s", kind, library)
+		}
+	}
+	if count := strings.Count(library, alphaWarning); count != 3 {
+		t.Errorf("Expected 3 alpha warnings got %d:\n%s", count, library)
+	}
+	v1 := library[strings.Index(library, "    v1:: {"):strings.Index(library, "    v2alpha1:: {")]
+	if strings.Contains(v1, alphaWarning) {
+		t.Errorf("Expected no warning in 'autoscaling.v1':\n%s", v1)
+	}
+}
+
+const expectedJobConstructor = `        new(name, containers, restartPolicy="OnFailure", labels={name: name}):: apiVersion + kind + {
+          metadata: {
+            labels: labels,
+            name: name,
+          },
+          spec: {
+            template: {
+              metadata: {
+                labels: labels,
+              },
+              spec: {
+                containers: if std.type(containers) == "array" then containers else [containers],
+                restartPolicy: restartPolicy,
+              },
+            },
+          },
+        },`
+
+func TestConstructorOverrides(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	text, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+
+	if job := objectText(library, "job"); !strings.Contains(job, expectedJobConstructor) {
+		t.Errorf("Expected 'Job' constructor:\n%s\ngot:\n%s", expectedJobConstructor, job)
+	}
+
+	cronJob := objectText(library, "cronJob")
+	for _, expected := range []string{
+		`new(name, schedule, containers, restartPolicy="OnFailure", labels={name: name}):: apiVersion + kind + {`,
+		"            jobTemplate: {\n              metadata: {\n                labels: labels,\n              },\n              spec: {\n                template: {\n                  metadata: {\n                    labels: labels,\n",
+		"            schedule: schedule,\n",
+	} {
+		if !strings.Contains(cronJob, expected) {
+			t.Errorf("Expected 'CronJob' constructor to contain:\n%s\ngot:\n%s", expected, cronJob)
+		}
+	}
+
+	if !strings.Contains(objectText(library, "service"), "new():: apiVersion + kind,") {
+		t.Errorf("Expected 'Service' to have the default constructor")
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	expected := &Symbol{
+		Path:   "batch.v2alpha1.cronJob.new",
+		Kind:   SymbolFunction,
+		Params: []string{"name", "schedule", "containers", "restartPolicy", "labels"},
+	}
+	if actual := index.byPath()[expected.Path]; actual == nil || !expected.signatureEquals(actual) {
+		t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+	}
+
+	// Versions whose schema lacks one of the fields fall back to the
+	// default constructor.
+	delete(
+		spec.Definitions["io.k8s.kubernetes.pkg.apis.batch.v2alpha1.CronJobSpec"].Properties,
+		"jobTemplate")
+	text, err = Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if !strings.Contains(objectText(string(text), "cronJob"), "new():: apiVersion + kind,") {
+		t.Errorf("Expected 'CronJob' without 'jobTemplate' to have the default constructor")
+	}
+}
+
+// The overridden constructors build the objects of the golden
+// manifests in `testdata/constructors`, whose first lines are the calls
+// that build them.
+func TestConstructorOverridesEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	manifests, err := ReadManifests("testdata/constructors")
+	if err != nil {
+		t.Fatalf("Could not read golden manifests:\n%v", err)
+	}
+	program := `local k = import 'k8s.libsonnet';
+{
+  "cronJob.yaml": k.batch.v2alpha1.cronJob.new("hello", "*/1 * * * *", [{name: "hello", image: "busybox"}], "Never", {app: "hello"}),
+  "job.yaml": k.batch.v1.job.new("pi", {name: "pi", image: "perl"}),
+}
+`
+	out := evaluateLibrary(t, jsonnet, loadTestSpec(t, "testdata/swagger.json"), Options{}, program)
+	objects := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(out), &objects); err != nil {
+		t.Fatalf("Could not read the evaluated objects:\n%v\n%s", err, out)
+	}
+	if len(manifests) != len(objects) {
+		t.Errorf("Expected %d golden manifests, got %d", len(objects), len(manifests))
+	}
+	for _, manifest := range manifests {
+		if diffs := DiffManifests(manifest.Object, objects[manifest.Name], true); len(diffs) != 0 {
+			t.Errorf("Object differs from 'testdata/constructors/%s':\n%s", manifest.Name, strings.Join(diffs, "\n"))
+		}
+	}
+}
+
+func TestServiceAndIngressConveniences(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/ingress.json")
+	library := emitTestSpec(t, "testdata/ingress.json", Options{})
+
+	// `IngressRule` builds the backend of each version's shape.
+	for _, expected := range []string{
+		"new(host, serviceName, servicePort, path=\"/\"):: {\n            host: host,\n            http: {\n              paths: [{backend: {serviceName: serviceName, servicePort: servicePort}, path: path}],",
+		`paths: [{backend: {service: {name: serviceName, port: if std.type(servicePort) == "string" then {name: servicePort} else {number: servicePort}}}, path: path, pathType: "Prefix"}],`,
+		"new(hosts, secretName):: {\n            hosts: if std.type(hosts) == \"array\" then hosts else [hosts],",
+	} {
+		if !strings.Contains(library, expected) {
+			t.Errorf("Expected library to contain:\n%s", expected)
+		}
+	}
+	if strings.Count(library, "withRulesMixin(rules):: {spec+: {rules+: ") != 2 {
+		t.Errorf("Expected both versions of 'Ingress' to have 'withRulesMixin'")
+	}
+
+	service := objectText(library, "service")
+	for _, expected := range []string{
+		`withType(type):: assert std.count(["ClusterIP", "NodePort", "LoadBalancer", "ExternalName"], type) > 0 :`,
+		`withTypeClusterIp():: self.withType("ClusterIP"),`,
+		`withTypeExternalName():: self.withType("ExternalName"),`,
+	} {
+		if !strings.Contains(service, expected) {
+			t.Errorf("Expected 'Service' to contain:\n%s\ngot:\n%s", expected, service)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, expected := range []*Symbol{
+		{
+			Path:   "hidden.networking.v1.ingressRule.new",
+			Kind:   SymbolFunction,
+			Params: []string{"host", "serviceName", "servicePort", "path"},
+		},
+		{Path: "extensions.v1beta1.ingress.withRulesMixin", Kind: SymbolFunction, Params: []string{"rules"}},
+		{Path: "core.v1.service.withTypeNodePort", Kind: SymbolFunction},
+	} {
+		if actual := index.byPath()[expected.Path]; actual == nil || !expected.signatureEquals(actual) {
+			t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+		}
+	}
+
+	// Without `spec.type`, there are no type helpers.
+	spec = loadTestSpec(t, "testdata/ingress.json")
+	delete(spec.Definitions["io.k8s.api.core.v1.ServiceSpec"].Properties, "type")
+	text, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Contains(objectText(string(text), "service"), "withType") {
+		t.Errorf("Expected 'Service' without 'spec.type' to have no type helpers")
+	}
+}
+
+func TestStorageConveniences(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/storage.json")
+	library := emitTestSpec(t, "testdata/storage.json", Options{})
+
+	claim := objectText(library, "persistentVolumeClaim")
+	for _, expected := range []string{
+		"new(name, storage, accessModes=[\"ReadWriteOnce\"]):: apiVersion + kind + {",
+		"accessModes: if std.type(accessModes) == \"array\" then accessModes else [accessModes],",
+		"requests: assert std.type(storage) == \"string\" :",
+		"withStorageClassName(name):: {spec+: {storageClassName: name}},",
+	} {
+		if !strings.Contains(claim, expected) {
+			t.Errorf("Expected 'PersistentVolumeClaim' to contain:\n%s\ngot:\n%s", expected, claim)
+		}
+	}
+
+	// Templates are stripped of `apiVersion` and `kind` when they're
+	// added, rather than when they're constructed, so that any claim
+	// can be passed.
+	statefulSet := objectText(library, "statefulSet")
+	for _, expected := range []string{
+		`withVolumeClaimTemplates(pvc):: {spec+: {volumeClaimTemplates: [{[f]: claim[f] for f in std.objectFields(claim) if f != "apiVersion" && f != "kind"} for claim in (`,
+		`withVolumeClaimTemplatesMixin(pvc):: {spec+: {volumeClaimTemplates+: [{[f]: claim[f] for f in std.objectFields(claim) if f != "apiVersion" && f != "kind"} for claim in (`,
+	} {
+		if !strings.Contains(statefulSet, expected) {
+			t.Errorf("Expected 'StatefulSet' to contain:\n%s\ngot:\n%s", expected, statefulSet)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, expected := range []*Symbol{
+		{
+			Path:   "core.v1.persistentVolumeClaim.new",
+			Kind:   SymbolFunction,
+			Params: []string{"name", "storage", "accessModes"},
+		},
+		{Path: "core.v1.persistentVolumeClaim.withStorageClassName", Kind: SymbolFunction, Params: []string{"name"}},
+		{Path: "apps.v1.statefulSet.withVolumeClaimTemplatesMixin", Kind: SymbolFunction, Params: []string{"pvc"}},
+	} {
+		if actual := index.byPath()[expected.Path]; actual == nil || !expected.signatureEquals(actual) {
+			t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+		}
+	}
+}
+
+func TestReferenceConveniences(t *testing.T) {
+	const path = "testdata/references.json"
+	spec := loadTestSpec(t, path)
+	library := emitTestSpec(t, path, Options{})
+
+	// The objects are nested, so they're emitted in the hidden group.
+	hiddenObjectText := func(kind string) string {
+		start := strings.Index(library, "\n        "+kind+":: {")
+		if start == -1 {
+			return ""
+		}
+		return library[start : start+strings.Index(library[start:], "\n        },")]
+	}
+	for object, expected := range map[string]string{
+		"secretKeySelector":     "new(name, key):: {\n            key: key,\n            name: name,\n          },",
+		"configMapKeySelector":  "new(name, key):: {\n            key: key,\n            name: name,\n          },",
+		"localObjectReference":  "new(name):: {\n            name: name,\n          },",
+		"objectReference":       "new(kind, name, namespace):: {\n            kind: kind,\n            name: name,\n            namespace: namespace,\n          },",
+		"objectFieldSelector":   "new(fieldPath):: {",
+		"resourceFieldSelector": "new(resource):: {",
+		"envVar":                "fromSecretKeyRef(name, secretKeyRef, key=null):: {name: name, valueFrom: {secretKeyRef: if std.type(secretKeyRef) == \"object\" && std.objectHas(secretKeyRef, \"key\") then",
+		"envFromSource":         "fromSecretRef(secretRef):: {secretRef: {name: if std.type(secretRef) == \"string\" then secretRef else",
+		"volume":                "fromPersistentVolumeClaim(name, claim):: {name: name, persistentVolumeClaim: {claimName: if std.type(claim) == \"string\" then claim else",
+	} {
+		if text := hiddenObjectText(object); !strings.Contains(text, expected) {
+			t.Errorf("Expected '%s' to contain:\n%s\ngot:\n%s", object, expected, text)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, expected := range []*Symbol{
+		{Path: "hidden.core.v1.secretKeySelector.new", Kind: SymbolFunction, Params: []string{"name", "key"}},
+		{Path: "hidden.core.v1.objectReference.new", Kind: SymbolFunction, Params: []string{"kind", "name", "namespace"}},
+		{
+			Path:     "hidden.core.v1.envVar.fromConfigMapKeyRef",
+			Kind:     SymbolFunction,
+			Params:   []string{"name", "configMapKeyRef", "key"},
+			Defaults: map[string]string{"key": "null"},
+		},
+		{Path: "hidden.core.v1.volume.fromSecret", Kind: SymbolFunction, Params: []string{"name", "secret"}},
+	} {
+		actual := index.byPath()[expected.Path]
+		if actual == nil || !expected.signatureEquals(actual) || !reflect.DeepEqual(expected.Defaults, actual.Defaults) {
+			t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+		}
+	}
+
+	// The smoke tests pass each helper both a plain name and an object.
+	tests, err := EmitTests(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	for _, name := range []string{
+		"core.v1.container.envType.fromSecretKeyRef",
+		"core.v1.container.envType.fromSecretKeyRef.selector",
+		"core.v1.container.envType.fromConfigMapKeyRef.selector",
+		"core.v1.container.envFromType.fromConfigMapRef.reference",
+		"core.v1.pod.mixin.spec.volumesType.fromSecret",
+		"core.v1.pod.mixin.spec.volumesType.fromSecret.reference",
+	} {
+		if !strings.Contains(string(tests["core.jsonnet"]), fmt.Sprintf("%q: (", name)) {
+			t.Errorf("Expected 'core.jsonnet' to test '%s':\n%s", name, tests["core.jsonnet"])
+		}
+	}
+}
+
+func TestReferenceConveniencesEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// Each helper makes the same object from a name as from a reference,
+	// a key selector, or the object referred to.
+	const program = `local k = import 'k8s.libsonnet';
+local spec = k.core.v1.pod.mixin.spec;
+local env = spec.containersType.envType;
+local ref = spec.imagePullSecretsType.new("web");
+[
+  std.assertEqual(env.fromSecretKeyRef("A", "web", "b"), env.fromSecretKeyRef("A", env.mixin.valueFrom.secretKeyRefType.new("web", "b"))),
+  std.assertEqual(env.fromSecretKeyRef("A", "web", "b"), env.fromSecretKeyRef("A", ref, "b")),
+  std.assertEqual(env.fromConfigMapKeyRef("A", "web", "b"), env.fromConfigMapKeyRef("A", {metadata: {name: "web"}}, "b")),
+  std.assertEqual(env.fromConfigMapKeyRef("A", "web", "c"), env.fromConfigMapKeyRef("A", env.mixin.valueFrom.configMapKeyRefType.new("web", "b"), "c")),
+  std.assertEqual(spec.containersType.envFromType.fromSecretRef("web"), spec.containersType.envFromType.fromSecretRef(ref)),
+  std.assertEqual(spec.volumesType.fromConfigMap("v", "web"), spec.volumesType.fromConfigMap("v", ref)),
+  std.assertEqual(spec.volumesType.fromPersistentVolumeClaim("v", "web"), spec.volumesType.fromPersistentVolumeClaim("v", {metadata: {name: "web"}})),
+]
+`
+	spec := loadTestSpec(t, "testdata/references.json")
+	if out := evaluateLibrary(t, jsonnet, spec, Options{}, program); strings.Contains(out, "false") {
+		t.Errorf("Expected every form to evaluate the same, got:\n%s", out)
+	}
+}
+
+const expectedSubjectAccessReviewConstructor = `        new(user, verb, resource, namespace):: apiVersion + kind + {
+          spec: {
+            resourceAttributes: {
+              namespace: namespace,
+              resource: resource,
+              verb: verb,
+            },
+            user: user,
+          },
+        },`
+
+// The constructors of the review kinds set fields two objects deep in
+// their specs, in every version that has them. (The beta versions are
+// identical to the GA ones, so they're emitted in full to see them.)
+func TestReviewConstructors(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/reviews.json")
+	library := emitTestSpec(t, "testdata/reviews.json", Options{FullBetaDuplicates: true})
+
+	for _, version := range []string{"v1", "v1beta1"} {
+		start := strings.Index(library, "\n    "+version+":: {\n      local apiVersion = {apiVersion: \"authorization.k8s.io/")
+		if start == -1 {
+			t.Fatalf("Expected 'authorization.%s' in:\n%s", version, library)
+		}
+		namespace := library[start:]
+		if !strings.Contains(objectText(namespace, "subjectAccessReview"), expectedSubjectAccessReviewConstructor) {
+			t.Errorf("Expected 'authorization.%s.subjectAccessReview' constructor:\n%s\ngot:\n%s",
+				version, expectedSubjectAccessReviewConstructor, objectText(namespace, "subjectAccessReview"))
+		}
+		expected := "        new(verb, resource, namespace):: apiVersion + kind + {\n          spec: {\n            resourceAttributes: {\n"
+		if !strings.Contains(objectText(namespace, "selfSubjectAccessReview"), expected) {
+			t.Errorf("Expected 'authorization.%s.selfSubjectAccessReview' constructor:\n%s\ngot:\n%s",
+				version, expected, objectText(namespace, "selfSubjectAccessReview"))
+		}
+	}
+	expected := "        new(token):: apiVersion + kind + {\n          spec: {\n            token: token,\n          },\n        },"
+	if !strings.Contains(objectText(library, "tokenReview"), expected) {
+		t.Errorf("Expected 'TokenReview' constructor:\n%s\ngot:\n%s", expected, objectText(library, "tokenReview"))
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, expected := range []*Symbol{
+		{Path: "authentication.v1.tokenReview.new", Kind: SymbolFunction, Params: []string{"token"}},
+		{
+			Path:   "authorization.v1beta1.subjectAccessReview.new",
+			Kind:   SymbolFunction,
+			Params: []string{"user", "verb", "resource", "namespace"},
+		},
+		{
+			Path:   "authorization.v1.selfSubjectAccessReview.new",
+			Kind:   SymbolFunction,
+			Params: []string{"verb", "resource", "namespace"},
+		},
+	} {
+		if actual := index.byPath()[expected.Path]; actual == nil || !expected.signatureEquals(actual) {
+			t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+		}
+	}
+
+	tests, err := EmitTests(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	for _, line := range []string{
+		`std.assertEqual(v.subjectAccessReview.new("jane", "get", "pods", "default").spec, {resourceAttributes: {namespace: "default", resource: "pods", verb: "get"}, user: "jane"})`,
+		`std.assertEqual(v.selfSubjectAccessReview.new("list", "secrets", "kube-system").spec, {resourceAttributes: {namespace: "kube-system", resource: "secrets", verb: "list"}})`,
+	} {
+		if strings.Count(string(tests["authorization.jsonnet"]), line) != 2 {
+			t.Errorf("Expected both versions' tests to contain:\n%s\ngot:\n%s", line, tests["authorization.jsonnet"])
+		}
+	}
+
+	// A version whose nested object lacks one of the fields falls back
+	// to the default constructor.
+	delete(
+		spec.Definitions["io.k8s.api.authorization.v1beta1.ResourceAttributes"].Properties,
+		"namespace")
+	text, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Count(string(text), "new(user, verb, resource, namespace):: apiVersion + kind + {") != 1 {
+		t.Errorf("Expected only 'authorization.v1.subjectAccessReview' to have the constructor override")
+	}
+}
+
+func TestMatchingLabels(t *testing.T) {
+	library := emitTestSpec(t, "testdata/workloads.json", Options{})
+
+	const mixin = "local __matchingLabels(labels) = {spec+: {selector+: {matchLabels: labels}, template+: {metadata+: {labels: labels}}}},"
+	const helper = "withMatchingLabels(labels):: __matchingLabels(labels),"
+	const assertion = "assertSelectorMatches():: {"
+	constructors := map[string]string{
+		// `spec.selector` is required in `apps/v1`.
+		"deployment": "new(labels):: apiVersion + kind + __matchingLabels(labels),",
+		"daemonSet":  "new(labels=null):: apiVersion + kind + (if labels == null then {} else __matchingLabels(labels)),",
+	}
+	for kind, constructor := range constructors {
+		object := objectText(library, kind)
+		for _, expected := range []string{mixin, constructor, helper, assertion} {
+			if !strings.Contains(object, expected) {
+				t.Errorf("Expected '%s' to contain:\n%s\ngot:\n%s", kind, expected, object)
+			}
+		}
+	}
+
+	// The server generates the selector of a `Job`, unless
+	// `spec.manualSelector` is set.
+	if job := objectText(library, "job"); strings.Contains(job, helper) {
+		t.Errorf("Expected 'Job' to have no matching labels helpers, got:\n%s", job)
+	}
+
+	const check = `assert std.length([l for l in std.objectFields(selector) if !std.objectHas(labels, l) || labels[l] != selector[l]]) == 0 : "'spec.selector.matchLabels' must match 'spec.template.metadata.labels'",`
+	if !strings.Contains(objectText(library, "deployment"), check) {
+		t.Errorf("Expected 'Deployment' selector assertion:\n%s", check)
+	}
+}
+
+type recordingLogger struct {
+	events map[string][]map[string]interface{}
+}
+
+func (l *recordingLogger) Log(msg string, keysAndValues ...interface{}) {
+	event := map[string]interface{}{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		event[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	l.events[msg] = append(l.events[msg], event)
+}
+
+func TestEmitCancelled(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Emit(ctx, spec, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to wrap 'context.Canceled', got %v", err)
+	}
+
+	// Cancelling during emission stops before the next group.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	emitted := 0
+	opts := Options{Progress: func(phase string, done, total int) {
+		if phase == "emit" {
+			emitted++
+			cancel()
+		}
+	}}
+	_, err := Emit(ctx, spec, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the error to wrap 'context.Canceled', got %v", err)
+	}
+	if emitted != 1 || !strings.Contains(err.Error(), "cancelled after 1 of ") {
+		t.Errorf("Expected emission to stop after 1 group, got %d:\n%v", emitted, err)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	emitTestSpec(t, "testdata/swagger.json", Options{Logger: logger})
+
+	for _, msg := range []string{"parse", "emit group", "emit"} {
+		if len(logger.events[msg]) == 0 {
+			t.Errorf("Expected '%s' to be logged", msg)
+		}
+		for _, event := range logger.events[msg] {
+			if _, ok := event["duration"].(time.Duration); !ok {
+				t.Errorf("Expected '%s' to log a duration, got %#v", msg, event)
+			}
+		}
+	}
+
+	// `RawExtension` has no version, so it's skipped.
+	if parse := logger.events["parse"][0]; parse["skipped"] != 1 {
+		t.Errorf("Expected 1 skipped definition, got %#v", parse)
+	}
+
+	groups := map[string]interface{}{}
+	for _, event := range logger.events["emit group"] {
+		groups[event["group"].(string)] = event["objects"]
+	}
+	if groups["batch"] != 2 || groups["hidden.meta"] != 2 {
+		t.Errorf("Unexpected counts of emitted objects per group: %v", groups)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	calls := map[string][][2]int{}
+	emitTestSpec(t, "testdata/swagger.json", Options{
+		Progress: func(phase string, done, total int) {
+			calls[phase] = append(calls[phase], [2]int{done, total})
+		},
+	})
+
+	root := newRoot(spec, Options{})
+	totals := map[string]int{
+		"parse": len(spec.Definitions),
+		"emit":  len(root.groups) + len(root.hiddenGroups),
+	}
+	if len(calls) != len(totals) {
+		t.Errorf("Expected progress for phases %v, got %v", totals, calls)
+	}
+	for phase, total := range totals {
+		if len(calls[phase]) != total {
+			t.Errorf("Expected %d calls for '%s', got %d", total, phase, len(calls[phase]))
+		}
+		for i, call := range calls[phase] {
+			if call != [2]int{i + 1, total} {
+				t.Errorf("Expected call %d of '%s' to be %d/%d, got %v", i, phase, i+1, total, call)
+			}
+		}
+	}
+}
+
+func TestListTypes(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/listtypes.json")
+	text, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+
+	const atomicComment = "// This list is atomic (`x-kubernetes-list-type: atomic`)"
+	expected := []string{
+		// Atomic lists are replaced, even if they also have a merge
+		// patch strategy (as `imagePullSecrets` does).
+		atomicComment,
+		`args(args):: if std.type(args) == "array" then {args: args} else {args: [args]},`,
+		`imagePullSecrets(imagePullSecrets):: if std.type(imagePullSecrets) == "array" then {imagePullSecrets: imagePullSecrets} else {imagePullSecrets: [imagePullSecrets]},`,
+		`tolerations(tolerations):: if std.type(tolerations) == "array" then __specMixin({tolerations: tolerations}) else __specMixin({tolerations: [tolerations]}),`,
+
+		// `map` lists, and lists without a list type, are appended to.
+		`ports(ports):: if std.type(ports) == "array" then {ports+: ports} else {ports: [ports]},`,
+		`command(command):: if std.type(command) == "array" then {command+: command} else {command: [command]},`,
+		`containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain:\n%s", line)
+		}
+	}
+
+	// Specs without list types are unaffected.
+	if strings.Contains(emitTestSpec(t, "testdata/swagger.json", Options{}), atomicComment) {
+		t.Errorf("Expected no atomic lists in spec without list types")
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := index.byPath()
+	ports := symbols["hidden.core.v1.container.ports"]
+	if ports == nil || ports.ListType != "map" ||
+		strings.Join(ports.ListMapKeys, ",") != "containerPort,protocol" {
+		t.Errorf("Expected 'ports' to be a map list keyed by 'containerPort,protocol', got %#v", ports)
+	}
+	if args := symbols["hidden.core.v1.container.args"]; args == nil || args.ListType != "atomic" {
+		t.Errorf("Expected 'args' to be an atomic list, got %#v", args)
+	}
+	if command := symbols["hidden.core.v1.container.command"]; command == nil || command.ListType != "" {
+		t.Errorf("Expected 'command' to have no list type, got %#v", command)
+	}
+}
+
+// A spec outside a git repository (e.g., fetched from a cluster) emits
+// without the SHA of its repository, rather than failing.
+func TestEmitOutsideRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spec")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	if _, ok := getSHARevision(dir); ok {
+		t.Skipf("Skipping, since '%s' is in a git repository", dir)
+	}
+
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	spec.FilePath = dir
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	header := string(library[:bytes.Index(library, []byte("\n{\n"))])
+	if strings.Contains(header, "OpenAPI spec is generated from") {
+		t.Errorf("Expected no SHA of the spec's repository, got:\n%s", header)
+	}
+	if !strings.Contains(header, "// SHA of ksonnet-lib HEAD: ") {
+		t.Errorf("Expected the SHA of ksonnet-lib, got:\n%s", header)
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// withoutHeader strips the comments at the top of a library, which
+// name the SHA of HEAD.
+func withoutHeader(library string) string {
+	return library[strings.Index(library, "\n{\n")+1:]
+}
+
+func TestNamingStrategies(t *testing.T) {
+	for _, ns := range jsonnet.NamingStrategies {
+		opts := Options{NamingStrategy: ns}
+		library := withoutHeader(emitTestSpec(t, "testdata/naming.json", opts))
+
+		golden := fmt.Sprintf("testdata/naming.%s.golden", ns)
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, []byte(library), 0644); err != nil {
+				t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+			}
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+		}
+		if library != string(expected) {
+			t.Errorf(
+				"Library for naming strategy '%s' differs from '%s'; run `go test -update` and diff:\n%s",
+				ns, golden, library)
+		}
+
+		// Only identifiers are renamed, never field keys.
+		for _, field := range []string{"{externalIPs", "{loadBalancerSourceCIDRs", "{httpGet"} {
+			if !strings.Contains(library, field) {
+				t.Errorf("Expected naming strategy '%s' to keep field key '%s'", ns, field)
+			}
+		}
+
+		// The symbol index agrees with the library.
+		index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/naming.json"), opts)
+		if err != nil {
+			t.Fatalf("Failed to build symbol index:\n%v", err)
+		}
+		for _, symbol := range index.Symbols {
+			name := symbol.Path[strings.LastIndex(symbol.Path, ".")+1:]
+			if !strings.Contains(library, name+"::") && !strings.Contains(library, name+"(") {
+				t.Errorf("Symbol '%s' is not in the library for naming strategy '%s'", symbol.Path, ns)
+			}
+		}
+	}
+
+	// The default strategy is the curated one.
+	if emitTestSpec(t, "testdata/naming.json", Options{}) !=
+		emitTestSpec(t, "testdata/naming.json", Options{NamingStrategy: jsonnet.NamingStrategyCurated}) {
+		t.Errorf("Expected the zero value naming strategy to be the curated one")
+	}
+}
+
+func TestEmittedSizes(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	sizes, total, err := EmittedSizes(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to compute emitted sizes:\n%v", err)
+	}
+
+	if library := emitTestSpec(t, "testdata/swagger.json", Options{}); total != len(library) {
+		t.Errorf("Expected total size %d to be the size of the library, %d", total, len(library))
+	}
+	sum := 0
+	for name, size := range sizes {
+		sum += size
+		if _, ok := spec.Definitions[name]; !ok && name != "" {
+			t.Errorf("Size attributed to unknown definition '%s'", name)
+		}
+	}
+	if sum != total {
+		t.Errorf("Expected sizes to sum to %d, got %d", total, sum)
+	}
+
+	// `ObjectMeta` is inlined as the `metadata` mixin of every
+	// top-level object, so it outweighs the objects themselves.
+	const objectMeta = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+	const deployment = "io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment"
+	if sizes[objectMeta] <= sizes[deployment] || sizes[deployment] == 0 {
+		t.Errorf(
+			"Expected '%s' (%d bytes) to outweigh '%s' (%d bytes)",
+			objectMeta, sizes[objectMeta], deployment, sizes[deployment])
+	}
+}
+
+// Native kinds take the API group of their apiVersion from their
+// `x-kubernetes-group-version-kind`, not the short group in their
+// name, and objects other than kinds get setters of their `kind`.
+func TestNativeAPIVersions(t *testing.T) {
+	library := emitTestSpec(t, "testdata/roundtrip.json", Options{})
+	for _, line := range []string{
+		"  rbac:: {\n    v1:: {\n      local apiVersion = {apiVersion: \"rbac.authorization.k8s.io/v1\"},\n",
+		"            kind(kind):: __roleRefMixin({kind: kind}),\n",
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain:\n%s", line)
+		}
+	}
+	if roleBinding := objectText(library, "roleBinding"); !strings.Contains(roleBinding, "local kind = {kind: \"RoleBinding\"},") ||
+		strings.Contains(roleBinding, "\n        kind(kind)::") {
+		t.Errorf("Expected the kind of 'roleBinding' to be set by its constructor only:\n%s", roleBinding)
+	}
+}
+
+func TestDefinitionPrefixes(t *testing.T) {
+	opts := Options{Parser: &kubespec.Parser{Prefixes: []string{kubespec.NativePrefix, "com.github.openshift"}}}
+	spec := loadTestSpec(t, "testdata/openshift.json")
+	library := emitTestSpec(t, "testdata/openshift.json", opts)
+
+	// OpenShift's kinds are grouped by the API group in their
+	// `x-kubernetes-group-version-kind`, so OpenShift's `apps` doesn't
+	// collide with the native one.
+	expected := []string{
+		"  apps:: {",
+		"      local apiVersion = {apiVersion: \"apps/v1\"},",
+		"  appsOpenshiftIo:: {",
+		"      local apiVersion = {apiVersion: \"apps.openshift.io/v1\"},",
+		"  routeOpenshiftIo:: {",
+		"      local apiVersion = {apiVersion: \"route.openshift.io/v1\"},",
+		"          specType:: hidden.routeOpenshiftIo.v1.routeSpec,",
+		"            toType:: hidden.routeOpenshiftIo.v1.routeTargetReference,",
+		"    routeOpenshiftIo:: {",
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, "\n"+line+"\n") {
+			t.Errorf("Expected library to contain line:\n%s", line)
+		}
+	}
+
+	// They get the same constructors and mixins as native kinds.
+	for kind, mixin := range map[string]string{
+		"route":            "host(host):: __specMixin({host: host}),",
+		"deploymentConfig": "replicas(replicas):: __specMixin({replicas: replicas}),",
+		"deployment":       "replicas(replicas):: __specMixin({replicas: replicas}),",
+	} {
+		object := objectText(library, kind)
+		if !strings.Contains(object, "new():: apiVersion + kind,") {
+			t.Errorf("Expected '%s' to have a constructor:\n%s", kind, object)
+		}
+		if !strings.Contains(object, mixin) {
+			t.Errorf("Expected '%s' to have mixin '%s':\n%s", kind, mixin, object)
+		}
+	}
+
+	aliases, err := EmitAliases(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	for _, alias := range []string{
+		"deployment:: k8s.apps.v1.deployment,",
+		"deploymentConfig:: k8s.appsOpenshiftIo.v1.deploymentConfig,",
+		"route:: k8s.routeOpenshiftIo.v1.route,",
+	} {
+		if !strings.Contains(string(aliases), alias) {
+			t.Errorf("Expected alias '%s' in:\n%s", alias, aliases)
+		}
+	}
+}
+
+func TestInlineObjects(t *testing.T) {
+	library := emitTestSpec(t, "testdata/inline.json", Options{})
+	backup := objectText(library, "backup")
+
+	// Inline objects get mixins, three levels deep, exactly like
+	// objects that are defined separately.
+	expected := []string{
+		"          spec:: {",
+		"            schedule:: {",
+		"              local __scheduleMixin(schedule) = __specMixin({schedule+: schedule}),",
+		"              cron(cron):: __scheduleMixin({cron: cron}),",
+		"              window:: {",
+		"                local __windowMixin(window) = __scheduleMixin({window+: window}),",
+		"                retention:: {",
+		"                  local __retentionMixin(retention) = __windowMixin({retention+: retention}),",
+		"                  keepLast(keepLast):: __retentionMixin({keepLast: keepLast}),",
+		"                retentionType:: hidden.stable.v1.backupSpecScheduleWindowRetentionInline,",
+		"              windowType:: hidden.stable.v1.backupSpecScheduleWindowInline,",
+		"          status:: {",
+		"            phase(phase):: __statusMixin({phase: phase}),",
+		"          statusType:: hidden.stable.v1.backupStatusInline,",
+	}
+	for _, line := range expected {
+		if !strings.Contains(backup, "\n"+line+"\n") {
+			t.Errorf("Expected 'backup' to contain line:\n%s", line)
+		}
+	}
+
+	// The real `BackupSpecScheduleInline` keeps its name.
+	if !strings.Contains(backup, "scheduleType:: hidden.stable.v1.backupSpecScheduleInline2,") ||
+		!strings.Contains(library, "legacy(legacy=true):: {legacy: legacy},") {
+		t.Errorf("Expected synthesized definitions not to collide with real ones:\n%s", library)
+	}
+
+	// Beyond the depth limit, inline objects get plain setters.
+	library = emitTestSpec(t, "testdata/inline.json", Options{MaxInlineDepth: 2})
+	backup = objectText(library, "backup")
+	if !strings.Contains(backup, "retention(retention):: __windowMixin({retention+: retention}),") ||
+		strings.Contains(backup, "retention:: {") {
+		t.Errorf("Expected 'retention' to get a plain setter beyond the depth limit:\n%s", backup)
+	}
+	if !strings.Contains(backup, "windowType:: hidden.stable.v1.backupSpecScheduleWindowInline,") {
+		t.Errorf("Expected 'window' to be within the depth limit:\n%s", backup)
+	}
+}
+
+// Run with `-race`.
+func TestConcurrentFilteredEmits(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	inGroups := func(groups string) func(kubespec.DefinitionName, *kubespec.SchemaDefinition) bool {
+		return func(name kubespec.DefinitionName, def *kubespec.SchemaDefinition) bool {
+			parsed, err := kubespec.ParseName(name)
+			if err != nil || len(def.TopLevelSpecs) == 0 {
+				return false
+			}
+			group := "core"
+			if parsed.HasGroup() {
+				group = string(parsed.Group)
+			}
+			return strings.Contains(","+groups+",", ","+group+",")
+		}
+	}
+	groups := []string{"apps", "batch", "core", "apps,batch"}
+
+	// Emit each filtered library once sequentially, then all of them
+	// concurrently from the same spec, and check they agree.
+	expected := map[string]string{}
+	for _, group := range groups {
+		text, err := Emit(context.Background(), spec.Filter(inGroups(group)), Options{})
+		if err != nil {
+			t.Fatalf("Failed to emit library for '%s':\n%v", group, err)
+		}
+		expected[group] = string(text)
+	}
+
+	var wg sync.WaitGroup
+	libraries := make([]string, len(groups))
+	for i, group := range groups {
+		wg.Add(1)
+		go func(i int, group string) {
+			defer wg.Done()
+			text, err := Emit(context.Background(), spec.Filter(inGroups(group)), Options{})
+			if err != nil {
+				t.Errorf("Failed to emit library for '%s':\n%v", group, err)
+			}
+			libraries[i] = string(text)
+		}(i, group)
+	}
+	wg.Wait()
+
+	for i, group := range groups {
+		if libraries[i] != expected[group] {
+			t.Errorf("Expected concurrent emit for '%s' to match sequential emit", group)
+		}
+		for _, name := range strings.Split(group, ",") {
+			if !strings.Contains(libraries[i], "\n  "+name+":: {\n") {
+				t.Errorf("Expected library for '%s' to contain '%s'", group, name)
+			}
+		}
+	}
+	if strings.Contains(expected["apps"], "\n  batch:: {\n") {
+		t.Errorf("Expected library for 'apps' to exclude other groups")
+	}
+}
+
+func TestGenerateFromSubset(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	groups := []kubespec.GroupName{"apps", "core"}
+	filtered := spec.Filter(kubespec.InGroups(groups))
+
+	text, err := filtered.MarshalSubset(true)
+	if err != nil {
+		t.Fatalf("Failed to write subset:\n%v", err)
+	}
+	subset := kubespec.APISpec{}
+	if err := json.Unmarshal(text, &subset); err != nil {
+		t.Fatalf("Failed to load subset:\n%v", err)
+	}
+	subset.Text = text
+	subset.FilePath = "."
+	if len(subset.Definitions) >= len(spec.Definitions) {
+		t.Errorf("Expected the subset to have fewer than %d definitions, got %d",
+			len(spec.Definitions), len(subset.Definitions))
+	}
+
+	// Generating from the subset, with the same groups, produces the
+	// same library, byte for byte.
+	for name, emit := range map[string]func(*kubespec.APISpec, Options) ([]byte, error){
+		"Emit": func(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+			return Emit(context.Background(), spec, opts)
+		},
+		"EmitAliases": EmitAliases,
+	} {
+		expected, err := emit(filtered, Options{})
+		if err != nil {
+			t.Fatalf("%s failed on the filtered spec:\n%v", name, err)
+		}
+		actual, err := emit(subset.Filter(kubespec.InGroups(groups)), Options{})
+		if err != nil {
+			t.Fatalf("%s failed on the subset:\n%v", name, err)
+		}
+		if string(actual) != string(expected) {
+			t.Errorf("Expected %s of the subset to match the filtered spec:\n%s\ngot:\n%s",
+				name, expected, actual)
+		}
+	}
+}
+
+func TestYAMLSpecs(t *testing.T) {
+	// The YAML fixtures are the JSON ones, rewritten.
+	for _, name := range []string{"testdata/swagger", "testdata/crd"} {
+		fromJSON := emitTestSpec(t, name+".json", Options{})
+		fromYAML := emitTestSpec(t, name+".yaml", Options{})
+		if fromYAML != fromJSON {
+			t.Errorf("Expected '%s.yaml' to emit the same library as '%s.json'", name, name)
+		}
+	}
+}
+
+func TestOmitComments(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+
+	// Each file is the one with comments, without the comment lines
+	// after its header.
+	withoutComments := func(text string) string {
+		lines := strings.Split(text, "\n")
+		kept := []string{}
+		for i, line := range lines {
+			header := i == len(kept) && strings.HasPrefix(line, "//")
+			if header || !strings.HasPrefix(strings.TrimSpace(line), "//") {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Join(kept, "\n")
+	}
+	for name, emit := range map[string]func(*kubespec.APISpec, Options) ([]byte, error){
+		"Emit": func(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+			return Emit(context.Background(), spec, opts)
+		},
+		"EmitAliases": EmitAliases,
+		"EmitLabels":  EmitLabels,
+	} {
+		commented, err := emit(spec, Options{})
+		if err != nil {
+			t.Fatalf("%s failed:\n%v", name, err)
+		}
+		omitted, err := emit(spec, Options{OmitComments: true})
+		if err != nil {
+			t.Fatalf("%s failed with OmitComments:\n%v", name, err)
+		}
+		if expected := withoutComments(string(commented)); string(omitted) != expected {
+			t.Errorf("Expected %s with OmitComments to be:\n%s\ngot:\n%s", name, expected, omitted)
+		}
+		if !strings.HasPrefix(string(omitted), "// AUTOGENERATED") {
+			t.Errorf("Expected %s with OmitComments to keep its header:\n%s", name, omitted)
+		}
+	}
+
+	_, total, err := EmittedSizes(spec, Options{OmitComments: true})
+	if err != nil {
+		t.Fatalf("Failed to compute emitted sizes:\n%v", err)
+	}
+	if library := emitTestSpec(t, "testdata/swagger.json", Options{OmitComments: true}); total != len(library) {
+		t.Errorf("Expected total size %d to be the size of the library, %d", total, len(library))
+	}
+}
@@ -0,0 +1,20 @@
+package ksonnet
+
+import "github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+
+// The categories of the errors of the emitter, which are those of
+// kubespec (see `kubespec.ErrSpecLoad`), so that `errors.Is` tells
+// them apart whichever package's names they're checked against:
+// `ErrUnparsableName` for definition names that don't parse (which,
+// without `Options.Lenient`, `Emit` panics with), `ErrUnsupportedSchema`
+// for definitions that conflict in the library, `ErrDanglingRef` for
+// references that no library of `Options.ExternalRefs` resolves, and
+// `ErrVerifyFailed` for `VerifyImports` and
+// `ProvenanceManifest.Verify`. Errors about options are of none.
+var (
+	ErrSpecLoad          = kubespec.ErrSpecLoad
+	ErrUnparsableName    = kubespec.ErrUnparsableName
+	ErrUnsupportedSchema = kubespec.ErrUnsupportedSchema
+	ErrDanglingRef       = kubespec.ErrDanglingRef
+	ErrVerifyFailed      = kubespec.ErrVerifyFailed
+)
@@ -0,0 +1,66 @@
+package ksonnet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// checkCategory checks that `err` is of `category` alone, of the
+// categories `ErrSpecLoad` and the others are.
+func checkCategory(t *testing.T, what string, err error, category error) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s: Expected an error of %v, got none", what, category)
+		return
+	}
+	for _, other := range []error{ErrSpecLoad, ErrUnparsableName, ErrUnsupportedSchema, ErrDanglingRef, ErrVerifyFailed} {
+		if is := errors.Is(err, other); is != (other == category) {
+			t.Errorf("%s: Expected errors.Is(err, %v) to be %t, got %t for:\n%v", what, other, !is, is, err)
+		}
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	// The categories are kubespec's, so they're told apart the same
+	// whichever package an error comes from.
+	_, err := kubespec.ReadSpec("swagger.json", strings.NewReader("{"))
+	checkCategory(t, "a truncated spec", err, ErrSpecLoad)
+
+	spec, opts := externalSpec(t)
+	opts.ExternalIndexes = map[string]*SymbolIndex{"core": {}}
+	_, err = Emit(context.Background(), spec, opts)
+	checkCategory(t, "a ref to a missing external definition", err, ErrDanglingRef)
+
+	spec, opts = databasesSpec(t)
+	opts.KindCollisions = CollisionsError
+	_, err = Emit(context.Background(), spec, opts)
+	checkCategory(t, "colliding kinds", err, ErrUnsupportedSchema)
+
+	// Without `Lenient`, emitting panics with an error of its category,
+	// and emitting variants reports it.
+	malformed := loadTestSpec(t, "testdata/malformed.json")
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			checkCategory(t, "a panic for a malformed name", err, ErrUnparsableName)
+		}()
+		Emit(context.Background(), malformed, Options{})
+	}()
+	_, err = EmitMultiple(context.Background(), malformed, []VariantOptions{{Name: "strict"}})
+	checkCategory(t, "a variant with a malformed name", err, ErrUnparsableName)
+	var errs VariantErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("Expected the variant's error, got %v", err)
+	}
+
+	err = VerifyImports(Artifacts{LibraryFile: NewArtifact([]byte("import \"missing.libsonnet\"\n"))}, "")
+	checkCategory(t, "an import that doesn't resolve", err, ErrVerifyFailed)
+	artifacts := Artifacts{LibraryFile: NewArtifact([]byte("{}\n"))}
+	_, err = NewProvenanceManifest(artifacts).Verify(fstest.MapFS{LibraryFile: {Data: []byte("{tampered: true}\n")}})
+	checkCategory(t, "a tampered file", err, ErrVerifyFailed)
+}
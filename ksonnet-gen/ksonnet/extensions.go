@@ -0,0 +1,382 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// CoreExtensionsFile is the overlay that fills the extension points of
+// the library of `Options.Extends` with the kinds of
+// `Options.Extensions`; see `EmitCoreExtensions`.
+const CoreExtensionsFile = "coreExtensions.libsonnet"
+
+// Extension fills an extension point (see `kubeversion.ExtensionPoint`)
+// of the library of `Options.Extends` with a kind of this library: its
+// custom resources manage an object of their own name, of a kind the
+// hook has a source for, e.g., `volumeSourcesExt=SealedSecret:Secret`
+// for volumes of the secrets that `SealedSecret`s decrypt to. The kind
+// is either its name, or, if more than one group has it, its
+// `apiVersion` and name, e.g., `mysql.example.com/v1/Database`.
+type Extension struct {
+	ExtensionPoint string
+	Kind           string
+	Manages        string
+}
+
+// ParseExtension parses an `Extension` from `hook=Kind:Manages`.
+func ParseExtension(text string) (Extension, error) {
+	split := strings.SplitN(text, "=", 2)
+	i := -1
+	if len(split) == 2 {
+		i = strings.LastIndex(split[1], ":")
+	}
+	if i <= 0 || split[0] == "" || i == len(split[1])-1 {
+		return Extension{}, fmt.Errorf(
+			"Could not parse extension '%s'; expected e.g. 'volumeSourcesExt=SealedSecret:Secret'", text)
+	}
+	return Extension{ExtensionPoint: split[0], Kind: split[1][:i], Manages: split[1][i+1:]}, nil
+}
+
+func (e Extension) String() string {
+	return fmt.Sprintf("%s=%s:%s", e.ExtensionPoint, e.Kind, e.Manages)
+}
+
+// MarshalText writes the extension as e.g.
+// `volumeSourcesExt=SealedSecret:Secret`.
+func (e Extension) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText parses the extension with `ParseExtension`.
+func (e *Extension) UnmarshalText(text []byte) error {
+	parsed, err := ParseExtension(string(text))
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+// `emitExtensionPoints` emits, with `Options.EmitExtensionPoints`, the
+// extension points of the API object (see
+// `kubeversion.ExtensionPoints`) in its namespace at `path`, as empty
+// hidden objects, e.g., `volumeSourcesExt:: {},`, which are indexed as
+// `Symbol.ExtensionPoint`. One whose name a property of the object has
+// is skipped.
+func (ao *apiObject) emitExtensionPoints(m *indentWriter, path string) {
+	root := ao.root()
+	if !root.opts.EmitExtensionPoints {
+		return
+	}
+	definition := ao.parsedName.Unparse()
+	for _, point := range root.kubeVersions.ExtensionPoints(root.spec.Info.Version) {
+		if point.Definition != definition {
+			continue
+		}
+		if root.index.lookup(path+"."+point.Name) != nil {
+			root.logger().Log(
+				"skip extension point", "name", point.Name, "path", path,
+				"reason", "the namespace has a symbol of its name")
+			continue
+		}
+		m.writeLine(fmt.Sprintf("// %s", point.Description))
+		m.writeLine(fmt.Sprintf("%s:: {},", point.Name))
+		root.index.add(path+"."+point.Name, SymbolNamespace).ExtensionPoint = true
+	}
+}
+
+// `extensionPointPaths` returns the paths that reach each extension
+// point of the library the index was built for, by name, in order:
+// those of the points that aren't hidden, and those through the type
+// aliases that point at a namespace a point is in, or, in turn, at one
+// an alias to it is in, e.g.,
+// `core.v1.pod.mixin.spec.containersType.envType.envSourcesExt` for
+// `hidden.core.v1.envVar.envSourcesExt`, through
+// `hidden.core.v1.container.envType`. No alias is followed twice on
+// the way to a path, so recursive definitions reach it once.
+func (si *SymbolIndex) extensionPointPaths() map[string][]string {
+	aliases := []*Symbol{}
+	for _, symbol := range si.Symbols {
+		if symbol.Kind == SymbolAlias && symbol.Target != "" {
+			aliases = append(aliases, symbol)
+		}
+	}
+	paths := map[string][]string{}
+	var reach func(name, path string, followed map[*Symbol]bool)
+	reach = func(name, path string, followed map[*Symbol]bool) {
+		if !strings.HasPrefix(path, hiddenNamespace+".") {
+			paths[name] = append(paths[name], path)
+		}
+		for _, alias := range aliases {
+			if followed[alias] || !strings.HasPrefix(path, alias.Target+".") {
+				continue
+			}
+			followed[alias] = true
+			reach(name, alias.Path+strings.TrimPrefix(path, alias.Target), followed)
+			delete(followed, alias)
+		}
+	}
+	for _, symbol := range si.Symbols {
+		if symbol.ExtensionPoint {
+			reach(symbol.Path[strings.LastIndex(symbol.Path, ".")+1:], symbol.Path, map[*Symbol]bool{})
+		}
+	}
+	for _, reached := range paths {
+		sort.Strings(reached)
+	}
+	return paths
+}
+
+// `extensionPoint` returns the extension point `name` of the library
+// of `Options.Extends`, from the table of its Kubernetes version.
+func (opts *Options) extensionPoint(name string) (kubeversion.ExtensionPoint, bool) {
+	for _, point := range opts.kubeVersions().ExtensionPoints(opts.Extends.KubernetesVersion) {
+		if point.Name == name {
+			return point, true
+		}
+	}
+	return kubeversion.ExtensionPoint{}, false
+}
+
+// `validateExtensions` checks that `Options.Extensions` and
+// `Options.Extends` are set together, and that each extension is of an
+// extension point that the library of `Options.Extends` has, manages a
+// kind the point has a source for, and is the only one of its point and
+// kind.
+func (opts *Options) validateExtensions() []string {
+	problems := []string{}
+	if opts.Extends == nil {
+		if len(opts.Extensions) > 0 {
+			problems = append(problems, "Extensions requires Extends, the symbol index of the library they extend")
+		}
+		return problems
+	}
+	if len(opts.Extensions) == 0 {
+		problems = append(problems, "Extends requires Extensions, the kinds that fill its extension points")
+	}
+
+	reached := opts.Extends.extensionPointPaths()
+	available := []string{}
+	for name := range reached {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	seen := map[Extension]bool{}
+	for _, e := range opts.Extensions {
+		point, ok := opts.extensionPoint(e.ExtensionPoint)
+		if !ok || len(reached[e.ExtensionPoint]) == 0 {
+			problems = append(problems, fmt.Sprintf(
+				"Extension '%s' is of extension point '%s', which the library of Extends doesn't have (it has: %s); generate it with EmitExtensionPoints",
+				e, e.ExtensionPoint, strings.Join(available, ", ")))
+			continue
+		}
+		if _, ok := point.Sources[e.Manages]; !ok {
+			problems = append(problems, fmt.Sprintf(
+				"Extension '%s' manages '%s', which extension point '%s' has no source for (it has: %s)",
+				e, e.Manages, e.ExtensionPoint, strings.Join(sortedKeys(point.Sources), ", ")))
+		}
+		key := Extension{ExtensionPoint: e.ExtensionPoint, Kind: e.Kind}
+		if seen[key] {
+			problems = append(problems, fmt.Sprintf(
+				"Extensions fill extension point '%s' with kind '%s' more than once", e.ExtensionPoint, e.Kind))
+		}
+		seen[key] = true
+	}
+	return problems
+}
+
+// EmitCoreExtensions takes a swagger API specification, and returns the
+// text of `coreExtensions.libsonnet`, an overlay of the library of
+// `Options.Extends` (e.g., a core library generated with
+// `Options.EmitExtensionPoints`) that fills its extension points with
+// the kinds of `Options.Extensions` of the library `Emit` generates
+// (e.g., of CRDs). Each hook gets, for each kind, a function named
+// after it that takes the hook's parameters, with the custom resource,
+// or its name, for `resource`, e.g., `fromSealedSecret(name,
+// sealedSecret)`, and returns the object whose source is what the
+// resource manages, along with a field of the kind's namespace. The
+// overlay patches every path that reaches the hook, so it's merged
+// into the other library, e.g.:
+//
+//	local k = (import "k8s/k8s.libsonnet") + (import "crds/coreExtensions.libsonnet");
+//	k.core.v1.pod.mixin.spec.volumesType.volumeSourcesExt.fromSealedSecret("creds", "db-creds")
+//
+// The overlay imports this library, which imports neither. It fails if
+// the options are invalid (see `Options.Validate`), or if a kind isn't
+// a top-level kind of exactly one group.
+func EmitCoreExtensions(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	root := newRoot(spec, opts)
+	for _, problem := range opts.validateExtensions() {
+		root.errors = append(root.errors, fmt.Errorf("%s", problem))
+	}
+	if err := joinErrors(root.errors); err != nil {
+		return nil, err
+	}
+
+	m := root.newLibraryWriter(newIndentWriter())
+	if err := root.emitCoreExtensions(m); err != nil {
+		return nil, err
+	}
+	return m.bytes()
+}
+
+// `extensionKind` returns the top-level API object of the kind of an
+// extension: the only one whose kind is `kind`, or, if it's qualified,
+// whose `apiVersion` and kind are, e.g., `mysql.example.com/v1/Database`.
+func (root *root) extensionKind(kind string) (*apiObject, error) {
+	found := []*apiObject{}
+	apiVersions := []string{}
+	for _, group := range root.groups.toSortedSlice() {
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.emitOrder() {
+				if !ao.isTopLevel {
+					continue
+				}
+				for _, gvk := range ao.gvks {
+					if kind == string(gvk.Kind) || kind == gvk.APIVersion()+"/"+string(gvk.Kind) {
+						found = append(found, ao)
+						apiVersions = append(apiVersions, gvk.APIVersion()+"/"+string(gvk.Kind))
+						break
+					}
+				}
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("The library has no top-level kind '%s'", kind)
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf(
+		"More than one group has kind '%s'; qualify it with its apiVersion, as one of: %s",
+		kind, strings.Join(apiVersions, ", "))
+}
+
+// `emitCoreExtensions` emits `coreExtensions.libsonnet`; see
+// `EmitCoreExtensions`.
+func (root *root) emitCoreExtensions(m *indentWriter) error {
+	opts := root.opts
+	k8sVersion := root.spec.Info.Version
+	emitTemplatedHeader(m, root.spec, opts, CoreExtensionsFile, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", k8sVersion))
+		m.writeLine(fmt.Sprintf(
+			"// Fills the extension points of a library for Kubernetes %s with the kinds of `%s`; merge it into that library.",
+			opts.Extends.KubernetesVersion, LibraryFile))
+	})
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf("local crds = import %q;", root.importPath(LibraryFile)))
+
+	byPoint := map[string][]Extension{}
+	for _, e := range opts.Extensions {
+		byPoint[e.ExtensionPoint] = append(byPoint[e.ExtensionPoint], e)
+	}
+	points := []string{}
+	for name := range byPoint {
+		points = append(points, name)
+	}
+	sort.Strings(points)
+
+	errors := []error{}
+	for _, name := range points {
+		point, _ := opts.extensionPoint(name)
+		m.writeLine("")
+		m.writeLine(fmt.Sprintf("local %s = {", name))
+		m.indent()
+		for _, e := range byPoint[name] {
+			ao, err := root.extensionKind(e.Kind)
+			if err != nil {
+				errors = append(errors, fmt.Errorf("Extension '%s':\n%v", e, err))
+				continue
+			}
+			kindName := string(root.naming().RewriteAsIdentifier(k8sVersion, ao.name))
+			if decided := root.kindNameOf(ao); decided != nil {
+				kindName = decided.name
+			}
+
+			taken := map[string]bool{"ref": true}
+			for _, param := range point.Params {
+				taken[param] = param != "resource"
+			}
+			resource := string(root.naming().ParamName(k8sVersion, kubespec.PropertyName(kindName), taken))
+			params := []string{}
+			for _, param := range point.Params {
+				if param == "resource" {
+					param = resource
+				}
+				params = append(params, param)
+			}
+
+			kind := fmt.Sprintf("`%s` `%s`", ao.gvks[0].APIVersion(), ao.name)
+			m.writeLine(fmt.Sprintf(
+				"// `%s` of the `%s` that a %s manages, of its name; `%s` is the resource or its name.",
+				name, e.Manages, kind, resource))
+			m.writeLine(fmt.Sprintf(
+				"from%s(%s):: local ref = %s; %s,",
+				strings.ToUpper(kindName[:1])+kindName[1:], strings.Join(params, ", "),
+				nameValue(resource), point.Sources[e.Manages]))
+			m.writeLine(fmt.Sprintf("// The namespace of %s in `%s`.", kind, LibraryFile))
+			m.writeLine(fmt.Sprintf("%s:: crds.%s,", kindName, root.libraryPath(ao.path())))
+		}
+		m.dedent()
+		m.writeLine("};")
+	}
+	if err := joinErrors(errors); err != nil {
+		return err
+	}
+
+	// Patch every path that reaches each point, nested by namespace.
+	reached := opts.Extends.extensionPointPaths()
+	patches := map[string]string{}
+	for _, name := range points {
+		for _, path := range reached[name] {
+			patches[path] = name
+		}
+	}
+	m.writeLine("")
+	m.writeLine("{")
+	m.indent()
+	emitPatchTree(m, "", patches)
+	m.dedent()
+	m.writeLine("}")
+	return nil
+}
+
+// `emitPatchTree` emits the fields of the namespace at `prefix` (or the
+// top, if it's empty) that lead to the paths of `patches`, in order,
+// extending each with `+::`, and each path with the local it maps to.
+func emitPatchTree(m *indentWriter, prefix string, patches map[string]string) {
+	children := map[string]bool{}
+	for path := range patches {
+		rest := path
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+".") {
+				continue
+			}
+			rest = strings.TrimPrefix(path, prefix+".")
+		}
+		children[strings.SplitN(rest, ".", 2)[0]] = true
+	}
+	names := []string{}
+	for name := range children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := joinPath(prefix, name)
+		if local, ok := patches[path]; ok {
+			m.writeLine(fmt.Sprintf("%s+:: %s,", name, local))
+			continue
+		}
+		m.writeLine(fmt.Sprintf("%s+:: {", name))
+		m.indent()
+		emitPatchTree(m, path, patches)
+		m.dedent()
+		m.writeLine("},")
+	}
+}
@@ -0,0 +1,224 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// extensionsSpec returns the spec of the `Database`s of
+// `databases.yaml`, with options that fill the hooks of the library of
+// `references.json`, generated with `Options.EmitExtensionPoints`, with
+// the MySQL one, and its volumes with the claims of the PostgreSQL one,
+// whose name `Options.KindCollisions` qualifies.
+func extensionsSpec(t *testing.T) (*SymbolIndex, Options) {
+	core, err := BuildSymbolIndex(
+		loadTestSpec(t, "testdata/references.json"), Options{EmitExtensionPoints: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	_, opts := databasesSpec(t)
+	opts.Extends = core
+	opts.Extensions = []Extension{
+		{ExtensionPoint: "volumeSourcesExt", Kind: "mysql.example.com/v1/Database", Manages: "Secret"},
+		{ExtensionPoint: "envSourcesExt", Kind: "mysql.example.com/v1/Database", Manages: "Secret"},
+		{ExtensionPoint: "volumeSourcesExt", Kind: "postgres.example.com/v1/Database", Manages: "PersistentVolumeClaim"},
+	}
+	return core, opts
+}
+
+func TestParseExtension(t *testing.T) {
+	for text, expected := range map[string]Extension{
+		"volumeSourcesExt=SealedSecret:Secret":         {"volumeSourcesExt", "SealedSecret", "Secret"},
+		"envSourcesExt=example.com/v1/Vault:ConfigMap": {"envSourcesExt", "example.com/v1/Vault", "ConfigMap"},
+	} {
+		e, err := ParseExtension(text)
+		if err != nil || e != expected || e.String() != text {
+			t.Errorf("Expected '%s' to parse as %+v, got %+v (%v)", text, expected, e, err)
+		}
+	}
+	for _, text := range []string{"", "volumeSourcesExt", "=Kind:Secret", "hook=Kind", "hook=:Secret", "hook=Kind:"} {
+		if _, err := ParseExtension(text); err == nil {
+			t.Errorf("Expected '%s' not to parse", text)
+		}
+	}
+}
+
+func TestExtensionPoints(t *testing.T) {
+	core, _ := extensionsSpec(t)
+	paths := core.extensionPointPaths()
+	// Each is reached through the mixin of the pod's spec, and through
+	// the type alias of the spec, which points at the hidden `podSpec`.
+	for name, path := range map[string]string{
+		"volumeSourcesExt":  "volumesType.volumeSourcesExt",
+		"envSourcesExt":     "containersType.envType.envSourcesExt",
+		"envFromSourcesExt": "containersType.envFromType.envFromSourcesExt",
+	} {
+		expected := "core.v1.pod.mixin.spec." + path + ", core.v1.pod.mixin.specType." + path
+		if strings.Join(paths[name], ", ") != expected {
+			t.Errorf("Expected '%s' to be reached at '%s', got %v", name, expected, paths[name])
+		}
+	}
+
+	library := emitTestSpec(t, "testdata/references.json", Options{EmitExtensionPoints: true})
+	if !strings.Contains(library, "\n          volumeSourcesExt:: {},\n") {
+		t.Errorf("Expected the namespace of 'Volume' to have an empty hook, got:\n%s", library)
+	}
+
+	// Without the option, the library has no hooks.
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/references.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if paths := index.extensionPointPaths(); len(paths) != 0 {
+		t.Errorf("Expected no extension points, got %v", paths)
+	}
+}
+
+func TestCoreExtensions(t *testing.T) {
+	spec, _ := databasesSpec(t)
+	_, opts := extensionsSpec(t)
+	text, err := EmitCoreExtensions(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit overlay:\n%v", err)
+	}
+	overlay := string(text)
+
+	golden := "testdata/coreExtensions.golden"
+	if *updateGolden {
+		if err := ioutil.WriteFile(golden, text, 0644); err != nil {
+			t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+	}
+	if overlay != string(expected) {
+		t.Errorf("Overlay differs from '%s'; run `go test -update` and diff:\n%s", golden, overlay)
+	}
+
+	artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if artifacts[CoreExtensionsFile] == nil || string(artifacts[CoreExtensionsFile].Text) != overlay {
+		t.Errorf("Expected the artifacts to have the overlay")
+	}
+}
+
+func TestCoreExtensionsErrors(t *testing.T) {
+	spec, _ := databasesSpec(t)
+	core, opts := extensionsSpec(t)
+	for _, test := range []struct {
+		extensions []Extension
+		extends    *SymbolIndex
+		problem    string
+	}{
+		{
+			[]Extension{{"volumeSourcesExt", "mysql.example.com/v1/Database", "Secret"}}, nil,
+			"Extensions requires Extends",
+		},
+		{nil, core, "Extends requires Extensions"},
+		{
+			[]Extension{{"volumeSourceExt", "Database", "Secret"}}, core,
+			"Extension 'volumeSourceExt=Database:Secret' is of extension point 'volumeSourceExt', which the library of Extends doesn't have (it has: envFromSourcesExt, envSourcesExt, volumeSourcesExt)",
+		},
+		{
+			[]Extension{{"volumeSourcesExt", "Database", "Secret"}}, &SymbolIndex{KubernetesVersion: "v1.9.0"},
+			"which the library of Extends doesn't have (it has: ); generate it with EmitExtensionPoints",
+		},
+		{
+			[]Extension{{"envFromSourcesExt", "mysql.example.com/v1/Database", "PersistentVolumeClaim"}}, core,
+			"manages 'PersistentVolumeClaim', which extension point 'envFromSourcesExt' has no source for (it has: ConfigMap, Secret)",
+		},
+		{
+			[]Extension{
+				{"volumeSourcesExt", "mysql.example.com/v1/Database", "Secret"},
+				{"volumeSourcesExt", "mysql.example.com/v1/Database", "ConfigMap"},
+			}, core,
+			"Extensions fill extension point 'volumeSourcesExt' with kind 'mysql.example.com/v1/Database' more than once",
+		},
+		{
+			[]Extension{{"volumeSourcesExt", "Database", "Secret"}}, core,
+			"More than one group has kind 'Database'; qualify it with its apiVersion, as one of: mysql.example.com/v1/Database, postgres.example.com/v1/Database",
+		},
+		{
+			[]Extension{{"volumeSourcesExt", "Table", "Secret"}}, core,
+			"The library has no top-level kind 'Table'",
+		},
+	} {
+		opts.Extensions, opts.Extends = test.extensions, test.extends
+		_, err := EmitCoreExtensions(spec, opts)
+		if err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("Expected extensions %v to fail with:\n%s\ngot %v", test.extensions, test.problem, err)
+		}
+	}
+}
+
+// A pod of the core library mounts the secret of a MySQL database of
+// the CRD library, through the overlay, though neither library imports
+// the other.
+func TestCoreExtensionsEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "extensions")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	spec, _ := databasesSpec(t)
+	_, opts := extensionsSpec(t)
+	core, err := EmitArtifacts(
+		context.Background(), loadTestSpec(t, "testdata/references.json"), Options{EmitExtensionPoints: true})
+	if err != nil {
+		t.Fatalf("Failed to emit the core library:\n%v", err)
+	}
+	crds, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit the CRD library:\n%v", err)
+	}
+	for lib, artifacts := range map[string]Artifacts{"k8s": core, "crds": crds} {
+		if err := os.Mkdir(filepath.Join(dir, lib), 0755); err != nil {
+			t.Fatalf("Could not create directory:\n%v", err)
+		}
+		for name, artifact := range artifacts {
+			if strings.Contains(string(artifact.Text), "import \"../") {
+				t.Errorf("Expected '%s' of '%s' not to import the other library", name, lib)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, lib, name), artifact.Text, 0644); err != nil {
+				t.Fatalf("Could not write '%s' of '%s':\n%v", name, lib, err)
+			}
+		}
+	}
+
+	program := `local k = (import 'k8s/k8s.libsonnet') + (import 'crds/coreExtensions.libsonnet');
+local spec = k.core.v1.pod.mixin.spec;
+local volumes = spec.volumesType.volumeSourcesExt;
+local db = volumes.database.new() + volumes.database.mixin.metadata.name('orders');
+spec.volumes([volumes.fromDatabase('data', db), volumes.fromPostgresDatabase('wal', 'events')]) +
+spec.containers([{name: 'app', env: [spec.containersType.envType.envSourcesExt.fromDatabase('PASSWORD', 'orders', 'password')]}])
+`
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Could not evaluate the libraries:\n%v\n%s", err, out)
+	}
+	for _, field := range []string{
+		`"secretName": "orders"`, `"name": "data"`, `"claimName": "events"`, `"secretKeyRef": {`, `"key": "password"`,
+	} {
+		if !strings.Contains(string(out), field) {
+			t.Errorf("Expected the pod spec to have %s, got:\n%s", field, out)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package ksonnet
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `externalRef` is where a definition that isn't in the spec is in an
+// external library (see `Options.ExternalRefs`): the name of the
+// library, and the path of the definition's namespace in it that
+// other libraries can reach, e.g., `core` and
+// `apps.v1.deployment.mixin.metadataType` for `ObjectMeta`.
+type externalRef struct {
+	library string
+	path    string
+}
+
+// `local` is the local that the library's files bind the external
+// library to, e.g., `__external_core`. It's prefixed so that it can't
+// shadow the library's own locals (e.g., `kind`).
+func (ref *externalRef) local() string {
+	return externalLocal(ref.library)
+}
+
+func externalLocal(library string) string {
+	return "__external_" + library
+}
+
+// `externalImport` is what the library's files import the library of
+// `Options.ExternalRefs` named `library` as: `k8s.libsonnet` in its
+// directory, e.g., `k8s/k8s.libsonnet` for `./k8s`, which Jsonnet
+// resolves through its library path (`-J`).
+func (opts *Options) externalImport(library string) string {
+	return path.Join(opts.ExternalRefs[library], LibraryFile)
+}
+
+// `externalImports` returns what the library's files import the
+// libraries of `Options.ExternalRefs` as, in order of name.
+func (opts *Options) externalImports() []string {
+	imports := []string{}
+	for _, library := range sortedKeys(opts.ExternalRefs) {
+		imports = append(imports, opts.externalImport(library))
+	}
+	return imports
+}
+
+// DefinitionPath returns the path of the namespace of the definition
+// `name` in the library the index was built for, that another library
+// importing it can reach, and whether there is one. It's the
+// namespace `Emit` generated for the definition, unless that's hidden
+// (e.g., `hidden.meta.v1.objectMeta`), in which case it's the first,
+// in order of path, of the type aliases that point at it (e.g.,
+// `apps.v1.deployment.mixin.metadataType`).
+func (si *SymbolIndex) DefinitionPath(name kubespec.DefinitionName) (string, bool) {
+	hidden := map[string]bool{}
+	for _, symbol := range si.Symbols {
+		if !isDefinitionNamespace(symbol) || symbol.Definition != name {
+			continue
+		}
+		if !strings.HasPrefix(symbol.Path, hiddenNamespace+".") {
+			return symbol.Path, true
+		}
+		hidden[symbol.Path] = true
+	}
+
+	aliases := []string{}
+	for _, symbol := range si.Symbols {
+		if symbol.Kind == SymbolAlias && hidden[symbol.Target] &&
+			!strings.HasPrefix(symbol.Path, hiddenNamespace+".") {
+			aliases = append(aliases, symbol.Path)
+		}
+	}
+	if len(aliases) == 0 {
+		return "", false
+	}
+	sort.Strings(aliases)
+	return aliases[0], true
+}
+
+// `validateExternalRefs` checks that each library of
+// `Options.ExternalRefs` is named by a lowerCamelCase identifier, is
+// imported from a relative path, and has an index in
+// `Options.ExternalIndexes`, and that each index is of one of them.
+func (opts *Options) validateExternalRefs() []string {
+	problems := []string{}
+	for _, library := range sortedKeys(opts.ExternalRefs) {
+		dir := opts.ExternalRefs[library]
+		if !namespacePattern.MatchString(library) {
+			problems = append(problems, fmt.Sprintf(
+				"ExternalRefs names a library '%s', which isn't a lowerCamelCase identifier", library))
+		}
+		if err := validateImportBase(path.Clean(dir)); err != nil || dir == "" {
+			problems = append(problems, fmt.Sprintf(
+				"ExternalRefs imports library '%s' from '%s', which isn't a relative path without '..' segments, e.g., './k8s'",
+				library, dir))
+		}
+		if opts.ExternalIndexes[library] == nil {
+			problems = append(problems, fmt.Sprintf(
+				"ExternalRefs has library '%s', which ExternalIndexes has no symbol index of", library))
+		}
+	}
+	unimported := []string{}
+	for library := range opts.ExternalIndexes {
+		if _, ok := opts.ExternalRefs[library]; !ok {
+			unimported = append(unimported, fmt.Sprintf(
+				"ExternalIndexes has a symbol index of library '%s', which ExternalRefs doesn't import", library))
+		}
+	}
+	sort.Strings(unimported)
+	return append(problems, unimported...)
+}
+
+// `newExternalRefs` finds each definition that the spec refers to but
+// doesn't have in the indexes of `Options.ExternalRefs`, trying the
+// libraries in order of name, and returns where each is, along with
+// an error listing the references to the definitions none of them
+// has, or has where other libraries can reach them.
+func (root *root) newExternalRefs() (map[kubespec.DefinitionName]*externalRef, []error) {
+	if len(root.opts.ExternalRefs) == 0 {
+		return nil, nil
+	}
+	refs := map[kubespec.DefinitionName]*externalRef{}
+	missing := []string{}
+	for _, dangling := range root.spec.DanglingRefs() {
+		ref, ok := refs[dangling.To]
+		if !ok {
+			for _, library := range sortedKeys(root.opts.ExternalRefs) {
+				if path, found := root.opts.ExternalIndexes[library].DefinitionPath(dangling.To); found {
+					ref = &externalRef{library: library, path: path}
+					break
+				}
+			}
+		}
+		if ref == nil {
+			missing = append(missing, dangling.String())
+			continue
+		}
+		refs[dangling.To] = ref
+		root.logger().Log(
+			"external ref", "definition", dangling.To, "library", ref.library, "path", ref.path)
+	}
+	if len(missing) == 0 {
+		return refs, nil
+	}
+	return refs, []error{kubespec.Categorize(ErrDanglingRef, fmt.Errorf(
+		"No external library (%s) has the definitions these references refer to, where other libraries can reach them:\n%s",
+		strings.Join(sortedKeys(root.opts.ExternalRefs), ", "), strings.Join(missing, "\n")))}
+}
+
+// `externalRef` returns where the definition `ref` refers to is in an
+// external library, or nil if it isn't in one (including if it's in
+// the spec).
+func (root *root) externalRef(ref *kubespec.ObjectRef) *externalRef {
+	if ref == nil || root.externals == nil {
+		return nil
+	}
+	return root.externals[*root.refName(ref)]
+}
+
+// `emitExternalImports` binds each external library the library refers
+// to to its local, e.g., `local __external_core = import
+// "k8s/k8s.libsonnet";`, reporting whether there were any.
+func (root *root) emitExternalImports(m *indentWriter) bool {
+	used := map[string]bool{}
+	for _, ref := range root.externals {
+		used[ref.library] = true
+	}
+	for _, library := range sortedKeys(root.opts.ExternalRefs) {
+		if used[library] {
+			m.writeLine(fmt.Sprintf(
+				"local %s = import %q;", externalLocal(library), root.opts.externalImport(library)))
+		}
+	}
+	return len(used) > 0
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// externalSpec returns the spec of the `Database`s of `databases.yaml`
+// alone, whose `metadata` refers to `ObjectMeta`, which it doesn't
+// have, with options linking it to the library of `collisions.json`,
+// which does, as the external library `core`.
+func externalSpec(t *testing.T) (*kubespec.APISpec, Options) {
+	spec, opts := databasesSpec(t)
+	spec = spec.Exclude(func(name kubespec.DefinitionName, def *kubespec.SchemaDefinition) bool {
+		return !strings.HasPrefix(string(name), kubespec.CRDPrefix)
+	})
+	core, err := BuildSymbolIndex(loadTestSpec(t, "testdata/collisions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	opts.ExternalRefs = map[string]string{"core": "./k8s"}
+	opts.ExternalIndexes = map[string]*SymbolIndex{"core": core}
+	return spec, opts
+}
+
+func TestDefinitionPath(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/collisions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, test := range []struct {
+		name  kubespec.DefinitionName
+		path  string
+		found bool
+	}{
+		{name: "io.k8s.api.apps.v1.Deployment", path: "apps.v1.deployment", found: true},
+		// Hidden, so reached through the first of its type aliases.
+		{name: kubespec.ObjectMetaName, path: "apps.v1.deployment.mixin.metadataType", found: true},
+		{name: "io.k8s.api.core.v1.PodSpec"},
+	} {
+		if path, found := index.DefinitionPath(test.name); path != test.path || found != test.found {
+			t.Errorf("Expected the path of '%s' to be '%s' (%v), got '%s' (%v)",
+				test.name, test.path, test.found, path, found)
+		}
+	}
+}
+
+func TestExternalRefs(t *testing.T) {
+	spec, opts := externalSpec(t)
+	text, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+	for _, line := range []string{
+		`local __external_core = import "k8s/k8s.libsonnet";`,
+		"metadataType:: __external_core.apps.v1.deployment.mixin.metadataType,",
+		"// Its type is in the external library `core`, at `apps.v1.deployment.mixin.metadataType`, which `metadataType` points at.",
+		"metadata(metadata):: {metadata: metadata},",
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected the library to have:\n%s\ngot:\n%s", line, library)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	target := ""
+	for _, symbol := range index.Symbols {
+		if symbol.Path == "mysqlExampleCom.v1.database.mixin.metadataType" {
+			target = symbol.Target
+		}
+	}
+	if target != "__external_core.apps.v1.deployment.mixin.metadataType" {
+		t.Errorf("Expected the type alias to point into the external library, got '%s'", target)
+	}
+
+	// The external library is imported by every file that refers to it,
+	// and its import is accepted as resolving.
+	for _, split := range []bool{false, true} {
+		opts.SplitByGroup = split
+		artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+		if err != nil {
+			t.Fatalf("Failed to emit artifacts with SplitByGroup %v:\n%v", split, err)
+		}
+		if !split {
+			continue
+		}
+		referring := 0
+		for name, artifact := range artifacts {
+			text := string(artifact.Text)
+			if !strings.Contains(text, "__external_core.") {
+				continue
+			}
+			referring++
+			if !strings.Contains(text, `local __external_core = import "k8s/k8s.libsonnet";`) {
+				t.Errorf("Expected '%s', which refers to the external library, to import it", name)
+			}
+		}
+		if referring != 2 {
+			t.Errorf("Expected the files of both groups to refer to the external library, got %d", referring)
+		}
+	}
+}
+
+func TestExternalRefsMissing(t *testing.T) {
+	spec, opts := externalSpec(t)
+	opts.ExternalIndexes["core"] = &SymbolIndex{}
+	_, err := Emit(context.Background(), spec, opts)
+	if err == nil {
+		t.Fatalf("Expected references to definitions no index has to fail")
+	}
+	for _, problem := range []string{
+		"No external library (core) has the definitions these references refer to",
+		"'io.k8s.crd.api.mysqlExampleCom.v1.Database' property 'metadata' refers to 'io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta'",
+		"'io.k8s.crd.api.postgresExampleCom.v1.Database' property 'metadata' refers to 'io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta'",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the error to include '%s', got:\n%v", problem, err)
+		}
+	}
+}
+
+// The team library evaluates with the core library next to it in the
+// library path, as `k8s`.
+func TestExternalRefsEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	spec, opts := externalSpec(t)
+	for lib, emit := range map[string]func() ([]byte, error){
+		"k8s": func() ([]byte, error) {
+			return Emit(context.Background(), loadTestSpec(t, "testdata/collisions.json"), Options{})
+		},
+		"team": func() ([]byte, error) { return Emit(context.Background(), spec, opts) },
+	} {
+		text, err := emit()
+		if err != nil {
+			t.Fatalf("Failed to emit library '%s':\n%v", lib, err)
+		}
+		if err := os.Mkdir(filepath.Join(dir, lib), 0755); err != nil {
+			t.Fatalf("Could not create directory:\n%v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, lib, LibraryFile), text, 0644); err != nil {
+			t.Fatalf("Could not write library '%s':\n%v", lib, err)
+		}
+	}
+
+	program := `local team = import 'team/k8s.libsonnet';
+local db = team.mysqlExampleCom.v1.database;
+db.new() + db.metadata(db.mixin.metadataType.name('orders'))
+`
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Could not evaluate the library:\n%v\n%s", err, out)
+	}
+	for _, field := range []string{`"kind": "Database"`, `"metadata": {`, `"name": "orders"`} {
+		if !strings.Contains(string(out), field) {
+			t.Errorf("Expected the object to have %s, got:\n%s", field, out)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// fieldOrderName is the hidden array of the field order of each
+// top-level kind, which `Options.EmitFieldOrder` adds.
+const fieldOrderName = "fieldOrder"
+
+// leadingFields are the fields a top-level kind's `fieldOrder` starts
+// with, if it has them, as hand-written manifests do. Every kind has
+// an `apiVersion` and a `kind`, which its constructor sets.
+var leadingFields = []kubespec.PropertyName{"apiVersion", "kind", "metadata", "spec"}
+
+// `fieldOrder` returns the names of the fields of a top-level kind in
+// the order they read best: `leadingFields`, then the rest in the
+// order the spec declares them (see
+// `kubespec.SchemaDefinition.DeclaredPropertyNames`). It's the same
+// however often the spec is loaded.
+func (ao *apiObject) fieldOrder() []kubespec.PropertyName {
+	declared := map[kubespec.PropertyName]bool{"apiVersion": true, "kind": true}
+	for _, name := range ao.declared {
+		declared[name] = true
+	}
+	order := []kubespec.PropertyName{}
+	seen := map[kubespec.PropertyName]bool{}
+	for _, name := range append(append([]kubespec.PropertyName{}, leadingFields...), ao.declared...) {
+		if declared[name] && !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// `emitFieldOrder` emits the hidden `fieldOrder` array of a top-level
+// kind, e.g., `fieldOrder:: ["apiVersion", "kind", "metadata", "spec", "status"],`,
+// with `Options.EmitFieldOrder`.
+func (ao *apiObject) emitFieldOrder(m *indentWriter) {
+	if !ao.root().opts.EmitFieldOrder || !ao.isTopLevel {
+		return
+	}
+	if dm, ok := ao.properties[fieldOrderName]; ok {
+		log.Panicf(
+			"Attempted to create helper '%s', but the property already existed at '%s'",
+			fieldOrderName, dm.path)
+	}
+	names := []string{}
+	for _, name := range ao.fieldOrder() {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	m.writeLine(fmt.Sprintf(
+		"// The order of the kind's fields that reads best, for `%s.reorder`.", utilName))
+	m.writeLine(fmt.Sprintf("%s:: [%s],", fieldOrderName, strings.Join(names, ", ")))
+}
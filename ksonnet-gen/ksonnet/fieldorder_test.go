@@ -0,0 +1,81 @@
+package ksonnet
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestFieldOrder(t *testing.T) {
+	const path = "testdata/fieldorder.json"
+	if library := emitTestSpec(t, path, Options{}); strings.Contains(library, "fieldOrder") || strings.Contains(library, "util::") {
+		t.Errorf("Expected no field order hints by default:\n%s", library)
+	}
+
+	opts := Options{EmitFieldOrder: true}
+	library := emitTestSpec(t, path, opts)
+	for _, hint := range []string{
+		// `spec` is declared after `status`, but comes first.
+		`fieldOrder:: ["apiVersion", "kind", "metadata", "spec", "status"],`,
+		// The rest are in the order they're declared, not by name.
+		`fieldOrder:: ["apiVersion", "kind", "metadata", "data", "binaryData"],`,
+		"reorder(obj, order):: std.foldl(",
+	} {
+		if !strings.Contains(library, hint) {
+			t.Errorf("Expected the library to have '%s':\n%s", hint, library)
+		}
+	}
+	// Hidden objects have no hints.
+	if strings.Count(library, "fieldOrder:: [") != 2 {
+		t.Errorf("Expected a field order for each of the 2 top-level kinds:\n%s", library)
+	}
+	// The hints are the same however often the spec is loaded.
+	for i := 0; i < 5; i++ {
+		if again := emitTestSpec(t, path, opts); again != library {
+			t.Fatalf("Expected the library to be the same each time it's emitted")
+		}
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	if symbol := symbols["util.reorder"]; symbol == nil || symbol.Kind != SymbolFunction || strings.Join(symbol.Params, ", ") != "obj, order" {
+		t.Errorf("Expected 'util.reorder(obj, order)' to be indexed, got %#v", symbol)
+	}
+
+	// With a file per group, `util` is in the file that imports them.
+	opts.SplitByGroup = true
+	files := emitSplitTestSpec(t, path, opts)
+	if !strings.Contains(files[LibraryFile], "\n  util:: {\n") {
+		t.Errorf("Expected '%s' to have the 'util' namespace:\n%s", LibraryFile, files[LibraryFile])
+	}
+}
+
+func TestFieldOrderEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// A reordered object evaluates the same as the object.
+	const program = `local k = import 'k8s.libsonnet';
+local deployment = k.apps.v1.deployment;
+local configMap = k.core.v1.configMap;
+local d = deployment.new() + deployment.mixin.metadata.name("web") + deployment.mixin.spec.replicas(2) + {extra: true};
+local c = configMap.new() + configMap.data({a: "b"});
+[
+  std.assertEqual(k.util.reorder(d, deployment.fieldOrder), d),
+  std.assertEqual(k.util.reorder(c, configMap.fieldOrder), c),
+  std.assertEqual(k.util.reorder({}, deployment.fieldOrder), {}),
+]
+`
+	spec := loadTestSpec(t, "testdata/fieldorder.json")
+	if out := evaluateLibrary(t, jsonnet, spec, Options{EmitFieldOrder: true}, program); strings.Contains(out, "false") {
+		t.Errorf("Expected every reordered object to evaluate the same, got:\n%s", out)
+	}
+}
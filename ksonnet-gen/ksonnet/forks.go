@@ -0,0 +1,71 @@
+package ksonnet
+
+import (
+	"sort"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// DefinitionFork is a definition that
+// `kubespec.APISpec.WithCRDSources` forked: `Forked` is the name of
+// the fork, and `Definition` that of the definition in `Source`, the
+// label of the source of CRDs it came from. Conflicting is set if the
+// spec had another schema under that name, and unset if it was forked
+// because it refers to one that did. Path is the namespace of the
+// fork in the library (e.g., `mysqlExampleCom_v2.v1.database`), if
+// it's emitted.
+type DefinitionFork struct {
+	Definition  string `json:"definition"`
+	Forked      string `json:"forked"`
+	Source      string `json:"source"`
+	Conflicting bool   `json:"conflicting,omitempty"`
+	Path        string `json:"path,omitempty"`
+}
+
+// `definitionForks` maps the package of every forked definition to the
+// label of its source, so that `groupName` groups it apart from the
+// definition it was forked from.
+func definitionForks(spec *kubespec.APISpec) map[string]string {
+	forks := map[string]string{}
+	for name, def := range spec.Definitions {
+		if def.Fork != nil {
+			forks[definitionPackage(name)] = def.Fork.Source
+		}
+	}
+	return forks
+}
+
+// `newForks` returns the forked definitions of the spec, as the symbol
+// index records them, in order of name, logging each.
+func (root *root) newForks() []*DefinitionFork {
+	forks := []*DefinitionFork{}
+	for name, def := range root.spec.Definitions {
+		if def.Fork == nil {
+			continue
+		}
+		fork := &DefinitionFork{
+			Definition:  string(def.Fork.Definition),
+			Forked:      string(name),
+			Source:      def.Fork.Source,
+			Conflicting: def.Fork.Conflicting,
+		}
+		if parsed, err := root.parser.ParseName(name); err == nil && parsed.HasVersion() {
+			for _, hidden := range []bool{false, true} {
+				if ao, err := root.getAPIObjectHelper(parsed, hidden); err == nil {
+					fork.Path = ao.path()
+					break
+				}
+			}
+		}
+		forks = append(forks, fork)
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		return forks[i].Forked < forks[j].Forked
+	})
+	for _, fork := range forks {
+		root.logger().Log(
+			"definition fork", "definition", fork.Definition, "forked", fork.Forked,
+			"source", fork.Source, "conflicting", fork.Conflicting, "path", fork.Path)
+	}
+	return forks
+}
@@ -0,0 +1,102 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// forkedSpec returns the spec of `crd.json` with the CRDs of
+// `databases.yaml` and `databases_v2.yaml`, each labelled by its file,
+// merged with `kubespec.ConflictsFork`.
+func forkedSpec(t *testing.T) (*kubespec.APISpec, Options) {
+	sources := []*kubespec.CRDSource{}
+	for _, label := range []string{"databases", "databases_v2"} {
+		text, err := ioutil.ReadFile("testdata/" + label + ".yaml")
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		crds, err := kubespec.ReadCRDs(label+".yaml", text)
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		sources = append(sources, &kubespec.CRDSource{Label: label, CRDs: crds})
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDSources(sources, false, kubespec.ConflictsFork)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	return spec, Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+}
+
+func TestForks(t *testing.T) {
+	spec, opts := forkedSpec(t)
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	opts.Logger = logger
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+
+	// The MySQL databases of the second source are a group of their
+	// own, of the same API group, and only they have its collation; the
+	// PostgreSQL databases, which are identical, are emitted once.
+	group := "\n  mysqlExampleCom_databases_v2:: {\n    v1:: {\n      local apiVersion = {apiVersion: \"mysql.example.com/v1\"},\n"
+	if !strings.Contains(string(library), group) {
+		t.Fatalf("Expected the fork's group to have the original's apiVersion:\n%s", library)
+	}
+	forked := objectText(string(library[strings.Index(string(library), group):]), "database")
+	original := objectText(string(library[strings.Index(string(library), "\n  mysqlExampleCom:: {\n"):]), "database")
+	if !strings.Contains(forked, "collation(collation)::") {
+		t.Errorf("Expected the fork to have the second source's schema:\n%s", forked)
+	}
+	if !strings.Contains(original, "charset(charset)::") || strings.Contains(original, "collation") {
+		t.Errorf("Expected the original to keep the first source's schema:\n%s", original)
+	}
+	if count := strings.Count(string(library), "\n  postgresExampleCom"); count != 1 {
+		t.Errorf("Expected the identical definition's group to be emitted once, got %d", count)
+	}
+
+	// Exactly one definition is forked, which the symbol index and the
+	// log record, along with the collision of its kind.
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	expected := []*DefinitionFork{{
+		Definition:  "io.k8s.crd.api.mysqlExampleCom.v1.Database",
+		Forked:      "io.k8s.crd.api.mysqlExampleCom_databases_v2.v1.Database",
+		Source:      "databases_v2",
+		Conflicting: true,
+		Path:        "mysqlExampleCom_databases_v2.v1.database",
+	}}
+	if !reflect.DeepEqual(index.Forks, expected) {
+		t.Errorf("Expected the index to record the fork %+v, got %+v", *expected[0], index.Forks)
+	}
+	events := logger.events["definition fork"]
+	if len(events) == 0 {
+		t.Errorf("Expected the fork to be logged")
+	}
+	for _, event := range events {
+		if event["forked"] != expected[0].Forked || event["path"] != expected[0].Path {
+			t.Errorf("Expected only the fork to be logged, got %v", event)
+		}
+	}
+	qualified := ""
+	for _, event := range logger.events["alias collision"] {
+		qualified += event["qualified"].(string) + ","
+	}
+	if !strings.Contains(qualified, "=mysqlExampleCom_databases_v2.v1.database,") {
+		t.Errorf("Expected the fork's kind to be qualified, got %s", qualified)
+	}
+
+	// Without forks, nothing is recorded.
+	spec, opts = databasesSpec(t)
+	if index, err := BuildSymbolIndex(spec, opts); err != nil || len(index.Forks) != 0 {
+		t.Errorf("Expected no forks, got %v, %v", index, err)
+	}
+}
@@ -0,0 +1,135 @@
+package ksonnet
+
+import (
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// PropertyIDMap maps, for each definition that has a property whose
+// identifier isn't its name (e.g., `hostIpc` for `hostIPC` with
+// `jsonnet.NamingStrategyInitialisms`, `std_` for `std`, or
+// `replicas2` for `Replicas`), the identifier back to the property.
+// The identifiers of the other properties are their names.
+type PropertyIDMap map[kubespec.DefinitionName]map[string]kubespec.PropertyName
+
+// `newGroupIDs` returns the identifiers of the groups of the library,
+// which `defaultGroupName` names groups by, mapped to the API groups
+// they were sanitized from; see `kubespec.GroupIDMap`. Native groups
+// are named in definition names already (e.g., `rbac`), and map to the
+// API group of their kinds (e.g., `rbac.authorization.k8s.io`, or `""`
+// for `core`), or, if they have none, or several, to themselves. CRDs
+// keep the identifiers `kubespec.APISpec.WithCRDs` gave their API
+// groups, and the API groups of other definitions with non-native
+// prefixes are given theirs after those, so that two that sanitize to
+// the same identifier (e.g., `foo-bar.io` and `foo.bar.io`) are
+// grouped apart in the library. Forks map to the API group of the
+// definition they were forked from.
+func (root *root) newGroupIDs(spec *kubespec.APISpec) kubespec.GroupIDMap {
+	native := map[string]map[string]bool{}
+	crds := map[string]string{}
+	origins := []string{}
+	forks := map[string]string{}
+	for name, def := range spec.Definitions {
+		parsed, err := root.parser.ParseName(name)
+		if err != nil {
+			continue
+		}
+		if parsed.Prefix == "" {
+			id := "core"
+			if parsed.HasGroup() {
+				id = string(parsed.Group)
+			}
+			if native[id] == nil {
+				native[id] = map[string]bool{}
+			}
+			for _, gvk := range def.TopLevelSpecs {
+				native[id][string(gvk.Group)] = true
+			}
+			continue
+		}
+
+		pkg := definitionPackage(name)
+		origin := root.groupOrigin(parsed)
+		if label, ok := root.packageForks[pkg]; ok {
+			forks[origin] = label
+			continue
+		}
+		if _, ok := root.packageGroups[pkg]; ok && parsed.Prefix == kubespec.CRDPrefix && parsed.HasGroup() {
+			crds[string(parsed.Group)] = origin
+			continue
+		}
+		origins = append(origins, origin)
+	}
+
+	ids := kubespec.GroupIDMap{}
+	for id, groups := range native {
+		ids[id] = id
+		if len(groups) == 1 {
+			for group := range groups {
+				ids[id] = group
+			}
+		}
+	}
+	taken := map[string]bool{}
+	for id := range ids {
+		taken[strings.ToLower(id)] = true
+	}
+	for id, origin := range crds {
+		if !taken[strings.ToLower(id)] {
+			ids[id] = origin
+		} else {
+			origins = append(origins, origin)
+		}
+	}
+	ids.Add(origins...)
+	forked := []string{}
+	for origin := range forks {
+		forked = append(forked, origin)
+	}
+	ids.Add(forked...)
+	for origin, label := range forks {
+		ids[ids.ID(origin)+"_"+label] = origin
+	}
+	return ids
+}
+
+// `groupOrigin` returns the name `defaultGroupName` sanitizes to name
+// the group of a definition with a non-native prefix: the API group of
+// its package, or, if it has none, the group in its name, qualified by
+// the last label of its prefix (e.g., `apps.openshift`).
+func (root *root) groupOrigin(parsedName kubespec.ParsedName) string {
+	pkg := definitionPackage(parsedName.Unparse())
+	if apiGroup, ok := root.packageGroups[pkg]; ok {
+		return string(apiGroup)
+	}
+	group := "core"
+	if parsedName.HasGroup() {
+		group = string(parsedName.Group)
+		if label, ok := root.packageForks[pkg]; ok {
+			group = strings.TrimSuffix(group, "_"+label)
+		}
+	}
+	labels := strings.Split(parsedName.Prefix, ".")
+	return group + "." + labels[len(labels)-1]
+}
+
+// `addPropertyIDs` records the identifiers of the properties of a
+// definition that aren't their names; see `PropertyIDMap`.
+func (root *root) addPropertyIDs(
+	path kubespec.DefinitionName, def *kubespec.SchemaDefinition, ao *apiObject,
+) {
+	for name := range def.Properties {
+		id := string(ao.identifier(name))
+		if id == string(name) {
+			continue
+		}
+		if root.index.PropertyIDs == nil {
+			root.index.PropertyIDs = PropertyIDMap{}
+		}
+		if root.index.PropertyIDs[path] == nil {
+			root.index.PropertyIDs[path] = map[string]kubespec.PropertyName{}
+		}
+		root.index.PropertyIDs[path][id] = name
+	}
+}
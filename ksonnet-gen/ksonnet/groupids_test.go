@@ -0,0 +1,153 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// groupIDsCRDs are CRDs of `Widget`s in two API groups with the same
+// identifier, one with a property whose identifier is reserved.
+const groupIDsCRDs = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.foo.bar.example.com
+spec:
+  group: foo.bar.example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        properties:
+          std:
+            type: string
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.foo-bar.example.com
+spec:
+  group: foo-bar.example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        properties:
+          color:
+            type: string
+`
+
+// groupIDsSpec returns the spec of `crd.json` with `groupIDsCRDs`, and
+// copies of their `Widget`s under the prefix `io.example`, whose API
+// groups, `baz-qux.example.com` and `baz.qux.example.com`, also have
+// the same identifier.
+func groupIDsSpec(t *testing.T) (*kubespec.APISpec, Options) {
+	crds, err := kubespec.ReadCRDs("widgets.yaml", []byte(groupIDsCRDs))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	for name, group := range map[kubespec.DefinitionName]kubespec.GroupName{
+		"io.example.api.bazQux.v1.Widget": "baz-qux.example.com",
+		"io.example.api.baz.v1.Widget":    "baz.qux.example.com",
+	} {
+		def := *spec.Definitions["io.k8s.crd.api.fooBarExampleCom.v1.Widget"]
+		def.TopLevelSpecs = kubespec.TopLevelSpecs{{Group: group, Version: "v1", Kind: "Widget"}}
+		spec.Definitions[name] = &def
+	}
+	return spec, Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix, "io.example"}}}
+}
+
+func TestGroupIDs(t *testing.T) {
+	expected := kubespec.GroupIDMap{
+		"fooBarExampleCom":  "foo-bar.example.com",
+		"fooBarExampleCom2": "foo.bar.example.com",
+		"bazQuxExampleCom":  "baz-qux.example.com",
+		"bazQuxExampleCom2": "baz.qux.example.com",
+	}
+	kinds := map[string]string{
+		"fooBarExampleCom.v1.widget":  "foo-bar.example.com",
+		"fooBarExampleCom2.v1.widget": "foo.bar.example.com",
+		"bazQuxExampleCom.v1.widget":  "baz-qux.example.com",
+		"bazQuxExampleCom2.v1.widget": "baz.qux.example.com",
+	}
+
+	// Each run gives each API group the same identifier, however the
+	// definitions are iterated, and no two the same one.
+	var first []byte
+	for run := 0; run < 5; run++ {
+		spec, opts := groupIDsSpec(t)
+		library, err := Emit(context.Background(), spec, opts)
+		if err != nil {
+			t.Fatalf("Failed to emit library:\n%v", err)
+		}
+		if first == nil {
+			first = library
+		} else if !bytes.Equal(library, first) {
+			t.Fatalf("Expected run %d to emit the same library as the first", run)
+		}
+		index, err := BuildSymbolIndex(spec, opts)
+		if err != nil {
+			t.Fatalf("Failed to build symbol index:\n%v", err)
+		}
+		for id, group := range expected {
+			if index.GroupIDs[id] != group {
+				t.Errorf("Expected '%s' to map back to '%s', got %v", id, group, index.GroupIDs)
+			}
+		}
+		found := map[string]string{}
+		for _, kind := range index.Kinds {
+			if kind.Kind == "Widget" {
+				found[kind.Path] = kind.Group
+			}
+		}
+		if !reflect.DeepEqual(found, kinds) {
+			t.Errorf("Expected the Widgets %v, got %v", kinds, found)
+		}
+
+		// Properties whose identifiers aren't their names map back to
+		// them.
+		props := index.PropertyIDs["io.k8s.crd.api.fooBarExampleCom2.v1.Widget"]
+		if props["std_"] != "std" {
+			t.Errorf("Expected 'std_' to map back to 'std', got %v", props)
+		}
+		if _, ok := index.PropertyIDs["io.k8s.crd.api.fooBarExampleCom.v1.Widget"]; ok {
+			t.Errorf("Expected no identifiers for properties named by their names, got %v", index.PropertyIDs)
+		}
+	}
+	if index := string(first); !strings.Contains(index, "\n  fooBarExampleCom2:: {") ||
+		!strings.Contains(index, "\n  bazQuxExampleCom2:: {") {
+		t.Errorf("Expected a group for each API group:\n%s", first)
+	}
+
+	// A native group without kinds maps back to itself.
+	index, err := BuildSymbolIndex(groupIDsSpec(t))
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if group := index.GroupIDs["meta"]; group != "meta" {
+		t.Errorf("Expected 'meta' to map back to itself, got %v", index.GroupIDs)
+	}
+
+	// Aliases qualified by a label of their group are sanitized too.
+	root := newRoot(loadTestSpec(t, "testdata/crd.json"), Options{})
+	group := &group{name: "myAppIo", apiGroup: "my-app.io"}
+	if alias := root.shortQualifiedAlias(group, "widget"); alias != "myAppWidget" {
+		t.Errorf("Expected the alias 'myAppWidget', got '%s'", alias)
+	}
+}
@@ -0,0 +1,146 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// GVKIndexFile is the file that maps the group/version/kind of each
+// top-level kind to its namespace, which `Options.EmitGVKIndex` adds;
+// see `EmitGVKIndex`.
+const GVKIndexFile = "gvkIndex.libsonnet"
+
+// EmitGVKIndex takes a swagger API specification, and returns the text
+// of `gvkIndex.libsonnet`, an object whose fields are keyed by the
+// `apiVersion` and `kind` of each top-level kind of the library `Emit`
+// generates (e.g., `apps/v1beta2:Deployment`), from its
+// `x-kubernetes-group-version-kind`, and are functions that take the
+// library and return the kind's namespace, e.g.,
+// `function(k) k.apps.v1beta2.deployment`, or, under
+// `Options.NamespacePrefix`, `function(k) k.lib.k8s.apps.v1beta2.deployment`.
+// It doesn't import the library, which `util.forManifest(obj)` looks
+// kinds up in it with.
+//
+// The entries of kinds whose names `Options.KindCollisions` qualified
+// are led by a comment with the name, e.g., `postgresDatabase`.
+//
+// A key that more than one namespace has (e.g., with
+// `Options.Namespaces`) is left out, as are the kinds of definitions
+// the library skips (see `SkippedDefinitions`), each with a comment
+// saying why.
+func EmitGVKIndex(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	root := newRoot(spec, opts)
+	if err := joinErrors(root.errors); err != nil {
+		return nil, err
+	}
+
+	m := root.newLibraryWriter(newIndentWriter())
+	root.emitGVKIndex(m, spec)
+	return m.bytes()
+}
+
+// `gvkKey` is the key of a kind in `gvkIndex.libsonnet`, e.g.,
+// `apps/v1beta2:Deployment`.
+func gvkKey(gvk *kubespec.TopLevelSpec) string {
+	return fmt.Sprintf("%s:%s", gvk.APIVersion(), gvk.Kind)
+}
+
+// `gvkPaths` returns the paths of the namespaces of the library's
+// top-level kinds, from the top of `k8s.libsonnet` (see
+// `Options.NamespacePrefix`), keyed by `gvkKey`, in order of path.
+func (root *root) gvkPaths() map[string][]string {
+	paths := map[string][]string{}
+	for _, group := range root.groups.toSortedSlice() {
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.emitOrder() {
+				if !ao.isTopLevel {
+					continue
+				}
+				for _, gvk := range ao.gvks {
+					key := gvkKey(gvk)
+					paths[key] = append(paths[key], root.libraryPath(ao.path()))
+				}
+			}
+		}
+	}
+	for _, keyed := range paths {
+		sort.Strings(keyed)
+	}
+	return paths
+}
+
+// `emitGVKIndex` emits `gvkIndex.libsonnet`, sorted by key; see
+// `EmitGVKIndex`. `original` is the spec before the definitions the
+// library skips are removed.
+func (root *root) emitGVKIndex(m *indentWriter, original *kubespec.APISpec) {
+	emitTemplatedHeader(m, root.spec, root.opts, GVKIndexFile, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", root.spec.Info.Version))
+		m.writeLine(fmt.Sprintf(
+			"// The namespace of `%s` of each apiVersion and kind, as a function of the library.",
+			LibraryFile))
+	})
+	m.writeLine("")
+	m.writeLine("{")
+	m.indent()
+
+	// The kinds whose names are qualified (see `Options.KindCollisions`)
+	// are named in a comment, so that they can be found by their names.
+	qualified := map[string]string{}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				if name := root.kindNameOf(ao); name != nil && name.qualified {
+					qualified[root.libraryPath(ao.path())] = name.name
+				}
+			}
+		}
+	}
+
+	lines := map[string]string{}
+	paths := root.gvkPaths()
+	for key, keyed := range paths {
+		if len(keyed) > 1 {
+			lines[key] = fmt.Sprintf(
+				"// Omitted %q, since more than one namespace has it: %s.", key, strings.Join(keyed, ", "))
+			continue
+		}
+		lines[key] = fmt.Sprintf("%q: function(k) k.%s,", key, keyed[0])
+		if name, ok := qualified[keyed[0]]; ok {
+			lines[key] = fmt.Sprintf(
+				"// Named `%s` in `%s`, `%s`, and the samples.\n%s",
+				name, AliasesFile, IndexDocFile, lines[key])
+		}
+	}
+	for _, skipped := range root.skipped {
+		def, ok := original.Definitions[skipped.Name]
+		if !ok {
+			continue
+		}
+		for _, gvk := range def.TopLevelSpecs {
+			key := gvkKey(gvk)
+			if _, ok := lines[key]; ok {
+				continue
+			}
+			lines[key] = fmt.Sprintf(
+				"// Omitted %q, since the library skips '%s' (%s).", key, skipped.Name, skipped.Reason)
+		}
+	}
+
+	keys := []string{}
+	for key := range lines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, line := range strings.Split(lines[key], "\n") {
+			m.writeLine(line)
+		}
+	}
+
+	m.dedent()
+	m.writeLine("}")
+}
@@ -0,0 +1,116 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmitGVKIndex(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/gvkindex.json")
+	index, err := EmitGVKIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit '%s':\n%v", GVKIndexFile, err)
+	}
+	const expected = `{
+  "apps/v1:Deployment": function(k) k.apps.v1.deployment,
+  // Omitted "extensions/v1beta1:Ingress", since more than one namespace has it: extensions.v1beta1.ingress, networking.v1beta1.ingress.
+  "v1:Service": function(k) k.core.v1.service,
+}
+`
+	if !strings.HasSuffix(string(index), expected) {
+		t.Errorf("Expected '%s' to end with:\n%s\ngot:\n%s", GVKIndexFile, expected, index)
+	}
+
+	// Kinds the library skips are left out too.
+	opts := Options{OnlyVersions: []kubespec.GroupVersion{{Group: "apps", Version: "v1"}}}
+	index, err = EmitGVKIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit '%s':\n%v", GVKIndexFile, err)
+	}
+	if comment := `// Omitted "v1:Service", since the library skips 'io.k8s.api.core.v1.Service' (filtered).`; !strings.Contains(string(index), comment) {
+		t.Errorf("Expected '%s' to have:\n%s\ngot:\n%s", GVKIndexFile, comment, index)
+	}
+	if !strings.Contains(string(index), `"apps/v1:Deployment": function(k) k.apps.v1.deployment,`) {
+		t.Errorf("Expected '%s' to keep 'apps/v1:Deployment':\n%s", GVKIndexFile, index)
+	}
+
+	// It's only written, and `util.forManifest` only emitted, with
+	// `Options.EmitGVKIndex`.
+	artifacts, err := EmitArtifacts(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if _, ok := artifacts[GVKIndexFile]; ok || strings.Contains(string(artifacts[LibraryFile].Text), "forManifest") {
+		t.Errorf("Expected no GVK index by default")
+	}
+	artifacts, err = EmitArtifacts(context.Background(), spec, Options{EmitGVKIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if _, ok := artifacts[GVKIndexFile]; !ok {
+		t.Errorf("Expected '%s' to be written with EmitGVKIndex", GVKIndexFile)
+	}
+	if !strings.Contains(string(artifacts[LibraryFile].Text), `forManifest(obj):: local gvks = import "gvkIndex.libsonnet";`) {
+		t.Errorf("Expected the library to have 'util.forManifest':\n%s", artifacts[LibraryFile].Text)
+	}
+}
+
+func TestEmitGVKIndexEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "gvkindex")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/gvkindex.json"), Options{EmitGVKIndex: true})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	for name, artifact := range artifacts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), artifact.Text, 0644); err != nil {
+			t.Fatalf("Could not write '%s':\n%v", name, err)
+		}
+	}
+
+	// A mixin applied to whatever the manifest is, through the
+	// namespace of its kind.
+	const program = `local k = import 'k8s.libsonnet';
+local labeled(obj) = obj + k.util.forManifest(obj).mixin.metadata.labels({team: "web"});
+local deployment = k.apps.v1.deployment.new() + k.apps.v1.deployment.mixin.spec.replicas(2);
+local service = k.core.v1.service.new() + k.core.v1.service.mixin.spec.type("ClusterIP");
+[
+  std.assertEqual(labeled(deployment), deployment + k.apps.v1.deployment.mixin.metadata.labels({team: "web"})),
+  std.assertEqual(labeled(service), service + k.core.v1.service.mixin.metadata.labels({team: "web"})),
+  std.assertEqual(k.util.forManifest({apiVersion: "v1", kind: "Service"}).mixin.spec.type("NodePort"), k.core.v1.service.mixin.spec.type("NodePort")),
+]
+`
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	if out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput(); err != nil || strings.Contains(string(out), "false") {
+		t.Errorf("Expected Deployments and Services to dispatch to their kinds, got %v:\n%s", err, out)
+	}
+
+	// Unknown kinds, and kinds left out of the index, fail with their
+	// apiVersion and kind.
+	for _, manifest := range []string{`{apiVersion: "apps/v2", kind: "Deployment"}`, `{apiVersion: "extensions/v1beta1", kind: "Ingress"}`} {
+		if err := ioutil.WriteFile(main, []byte("(import 'k8s.libsonnet').util.forManifest("+manifest+")\n"), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+		if err == nil || !strings.Contains(string(out), "The library has no kind for") {
+			t.Errorf("Expected dispatching %s to fail, got:\n%s", manifest, out)
+		}
+	}
+}
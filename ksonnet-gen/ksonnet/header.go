@@ -0,0 +1,95 @@
+package ksonnet
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// HeaderData is what `Options.HeaderTemplate` is executed with, once
+// for each `.libsonnet` file of the library.
+type HeaderData struct {
+	// File is the name of the file the header starts, e.g.,
+	// `k8s.libsonnet`, or `apps.libsonnet` in a library split by group.
+	File string
+
+	// KubernetesVersion is the spec's `info.version`, e.g., `v1.9.0`.
+	KubernetesVersion string
+
+	// SpecSHA256 is the digest of the bytes the spec was read from (see
+	// `kubespec.APISpec.SourceSHA256`), or empty for specs that weren't
+	// read from a file or URL.
+	SpecSHA256 string
+
+	// Options are those the library is generated with.
+	Options Options
+
+	// Default is the header the file has without a template, without
+	// its trailing newline, e.g., to keep it under a license.
+	Default string
+}
+
+// headerTemplateName is the name `Options.HeaderTemplate` is parsed
+// with, which its errors are reported with, along with the line, e.g.,
+// `template: header:3: unexpected "}" in operand`.
+const headerTemplateName = "header"
+
+// `parseHeaderTemplate` parses `Options.HeaderTemplate`, returning nil
+// if it's empty.
+func parseHeaderTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(headerTemplateName).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("HeaderTemplate doesn't parse: %v", err)
+	}
+	return tmpl, nil
+}
+
+// `emitTemplatedHeader` emits the comments that start `file`, a file of
+// the library generated from `spec`: those `defaults` writes, or, with
+// `Options.HeaderTemplate`, the template's output. Either is kept with
+// `Options.OmitComments`. An error parsing or executing the template
+// is the writer's, so that `bytes` returns it and generation fails.
+func emitTemplatedHeader(
+	m *indentWriter, spec *kubespec.APISpec, opts Options, file string,
+	defaults func(m *indentWriter),
+) {
+	tmpl, err := parseHeaderTemplate(opts.HeaderTemplate)
+	if err == nil && tmpl == nil {
+		m.keepComments(func() { defaults(m) })
+		return
+	}
+
+	var header bytes.Buffer
+	if err == nil {
+		d := newIndentWriter()
+		defaults(d)
+		err = tmpl.Execute(&header, &HeaderData{
+			File:              file,
+			KubernetesVersion: spec.Info.Version,
+			SpecSHA256:        spec.SourceSHA256,
+			Options:           opts,
+			Default:           strings.TrimSuffix(d.buffer.String(), "\n"),
+		})
+	}
+	if err != nil {
+		if m.err == nil {
+			m.err = fmt.Errorf("Could not write the header of '%s':\n%v", file, err)
+		}
+		return
+	}
+	text := strings.TrimRight(header.String(), "\n")
+	if text == "" {
+		return
+	}
+	m.keepComments(func() {
+		for _, line := range strings.Split(text, "\n") {
+			m.writeLine(line)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// licenseHeader is a header template of the kind an organization would
+// start its files with, which leaves out the default header, and so
+// the SHA of HEAD.
+const licenseHeader = `// Copyright 2026 ACME Corp. Licensed under the Apache License, Version 2.0.
+// {{.File}}, generated from Kubernetes {{.KubernetesVersion}} (sha256:{{.SpecSHA256}}){{with .Options.NamespacePrefix}} under '{{.}}'{{end}}.
+// Owners: #platform-k8s
+`
+
+func TestHeaderTemplate(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/gvkindex.json")
+	spec.SourceSHA256 = "5f2b6c1a"
+	opts := Options{HeaderTemplate: licenseHeader, NamespacePrefix: "lib.k8s"}
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	aliases, err := EmitAliases(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	output := string(library) + "\n// k.libsonnet\n" + string(aliases)
+
+	const golden = "testdata/header.custom.golden"
+	if *updateGolden {
+		if err := ioutil.WriteFile(golden, []byte(output), 0644); err != nil {
+			t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+	}
+	if output != string(expected) {
+		t.Errorf(
+			"Library with a custom header differs from '%s'; run `go test -update` and diff:\n%s",
+			golden, output)
+	}
+
+	// Every `.libsonnet` file gets the header, named after itself, and
+	// keeps it without comments.
+	opts = Options{HeaderTemplate: licenseHeader, SplitByGroup: true, EmitGVKIndex: true, OmitComments: true}
+	artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	for name, artifact := range artifacts {
+		if !strings.HasSuffix(name, ".libsonnet") {
+			continue
+		}
+		header := "// Copyright 2026 ACME Corp. Licensed under the Apache License, Version 2.0.\n// " +
+			name + ", generated from Kubernetes v1.9.0 (sha256:5f2b6c1a).\n// Owners: #platform-k8s\n\n"
+		if !strings.HasPrefix(string(artifact.Text), header) {
+			t.Errorf("Expected '%s' to start with:\n%s\ngot:\n%s", name, header, artifact.Text)
+		}
+	}
+
+	// `{{.Default}}` is the header the file has otherwise.
+	for _, file := range []string{LibraryFile, AliasesFile} {
+		emit := Emit
+		if file == AliasesFile {
+			emit = func(_ context.Context, spec *kubespec.APISpec, opts Options) ([]byte, error) {
+				return EmitAliases(spec, opts)
+			}
+		}
+		plain, err := emit(context.Background(), spec, Options{})
+		if err != nil {
+			t.Fatalf("Failed to emit '%s':\n%v", file, err)
+		}
+		templated, err := emit(context.Background(), spec, Options{HeaderTemplate: "// Licensed.\n{{.Default}}\n"})
+		if err != nil {
+			t.Fatalf("Failed to emit '%s':\n%v", file, err)
+		}
+		if string(templated) != "// Licensed.\n"+string(plain) {
+			t.Errorf("Expected '%s' to be the default with a line before it, got:\n%s", file, templated)
+		}
+	}
+	// `LibraryAPIVersions` reads the header the template wrote.
+	if versions, ok := LibraryAPIVersions(library); ok {
+		t.Errorf("Expected a header without the apiVersions to list none, got %v", versions)
+	}
+}
+
+func TestHeaderTemplateErrors(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/gvkindex.json")
+
+	// Errors in the template fail generation, with its line.
+	for template, expected := range map[string]string{
+		"// {{.File}}\n// {{.Owner}}\n":  "template: header:2:5: executing \"header\" at <.Owner>: can't evaluate field Owner",
+		"// {{.File}}\n\n// {{end}}\n":   "template: header:3: unexpected {{end}}",
+		"// {{.File}}\n// {{.File | x}}": "template: header:2: function \"x\" not defined",
+	} {
+		opts := Options{HeaderTemplate: template}
+		if _, err := Emit(context.Background(), spec, opts); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected emitting with template %q to fail with '%s', got %v", template, expected, err)
+		}
+		if _, err := EmitAliases(spec, opts); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected emitting '%s' with template %q to fail with '%s', got %v", AliasesFile, template, expected, err)
+		}
+	}
+
+	opts := Options{HeaderTemplate: "// {{.Options.Nope}}\n", SplitByGroup: true}
+	_, err := EmitArtifacts(context.Background(), spec, opts)
+	if err == nil || !strings.Contains(err.Error(), "Could not write the header of 'apps.libsonnet'") ||
+		!strings.Contains(err.Error(), "template: header:1:13:") {
+		t.Errorf("Expected the header of the first group's file to fail with its line, got %v", err)
+	}
+}
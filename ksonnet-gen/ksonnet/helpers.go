@@ -0,0 +1,441 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+//-----------------------------------------------------------------------------
+// Object helpers.
+//-----------------------------------------------------------------------------
+
+// `objectHelper` is a convenience function emitted in the namespace of
+// an API object, next to its constructor, for a field that's awkward
+// to set with the generated mixins (e.g., because the field is nested
+// in an array, or takes one of a few values).
+type objectHelper struct {
+	comment  string
+	name     string
+	params   []string
+	defaults map[string]string // Jsonnet expressions of the optional params, by name.
+	body     string            // Jsonnet expression.
+	fields   []string          // Dotted paths that must exist for the helper to be emitted.
+	tests    []helperTest
+
+	// properties are the properties that params take, by param, where
+	// the param isn't named after its property (e.g., `claim` for
+	// `persistentVolumeClaim`); `jsonnet.NamingStrategyUniformParams`
+	// names it after the property instead. See `helperParams`.
+	properties map[string]kubespec.PropertyName
+}
+
+// `helperTest` is a smoke test of a constructor override or an object
+// helper, which asserts that `expression` evaluates to `expected`. The
+// expression refers to the namespace of the test's version (e.g.,
+// `k.extensions.v1beta1`) as `v`.
+type helperTest struct {
+	name       string
+	expression string
+	expected   string
+}
+
+// `objectHelpers` is keyed by group and kind, like
+// `constructorOverrides`, so that helpers apply to every version of a
+// kind whose schema has the fields they set.
+var objectHelpers = map[kubespec.GroupName]map[kubespec.ObjectKind][]*objectHelper{
+	"apps": {
+		"StatefulSet": statefulSetHelpers,
+	},
+	"core": {
+		"EnvFromSource":         envFromSourceHelpers,
+		"EnvVar":                envVarHelpers,
+		"PersistentVolumeClaim": persistentVolumeClaimHelpers,
+		"Service":               serviceTypeHelpers(),
+		"Volume":                volumeHelpers,
+	},
+	"extensions": {
+		"Ingress": ingressHelpers,
+	},
+	"networking": {
+		"Ingress": ingressHelpers,
+	},
+}
+
+// arrayValue accepts either a single element or an array of them.
+const arrayValue = `if std.type(%[1]s) == "array" then %[1]s else [%[1]s]`
+
+var ingressHelpers = []*objectHelper{
+	{
+		comment: "Sets `spec.rules` to `rules`, a rule or an array of them (e.g., from `mixin.spec.rulesType.new`).",
+		name:    "withRules",
+		params:  []string{"rules"},
+		body:    fmt.Sprintf("{spec+: {rules: %s}}", fmt.Sprintf(arrayValue, "rules")),
+		fields:  []string{"spec.rules"},
+	},
+	{
+		comment: "Appends `rules`, a rule or an array of them, to `spec.rules`.",
+		name:    "withRulesMixin",
+		params:  []string{"rules"},
+		body:    fmt.Sprintf("{spec+: {rules+: %s}}", fmt.Sprintf(arrayValue, "rules")),
+		fields:  []string{"spec.rules"},
+		tests: []helperTest{{
+			name:       "ingress.withRulesMixin",
+			expression: `(v.ingress.withRules({host: "a.example.com"}) + v.ingress.withRulesMixin([{host: "b.example.com"}])).spec.rules`,
+			expected:   `[{host: "a.example.com"}, {host: "b.example.com"}]`,
+		}},
+	},
+	{
+		comment: "Sets `spec.tls` to `tls`, a TLS configuration or an array of them (e.g., from `mixin.spec.tlsType.new`).",
+		name:    "withTls",
+		params:  []string{"tls"},
+		body:    fmt.Sprintf("{spec+: {tls: %s}}", fmt.Sprintf(arrayValue, "tls")),
+		fields:  []string{"spec.tls"},
+	},
+	{
+		comment: "Appends `tls`, a TLS configuration or an array of them, to `spec.tls`.",
+		name:    "withTlsMixin",
+		params:  []string{"tls"},
+		body:    fmt.Sprintf("{spec+: {tls+: %s}}", fmt.Sprintf(arrayValue, "tls")),
+		fields:  []string{"spec.tls"},
+	},
+}
+
+var persistentVolumeClaimHelpers = []*objectHelper{
+	{
+		comment:    "Sets `spec.storageClassName`, the class of storage the claim is provisioned from.",
+		name:       "withStorageClassName",
+		params:     []string{"name"},
+		body:       "{spec+: {storageClassName: name}}",
+		fields:     []string{"spec.storageClassName"},
+		properties: map[string]kubespec.PropertyName{"name": "storageClassName"},
+	},
+}
+
+// claimTemplatesValue accepts either a single claim or an array of
+// them, and removes their `apiVersion` and `kind`, which the server
+// rejects in `spec.volumeClaimTemplates`, so that claims made with
+// `persistentVolumeClaim.new` can be used as templates.
+var claimTemplatesValue = fmt.Sprintf(
+	`[{[f]: claim[f] for f in std.objectFields(claim) if f != "apiVersion" && f != "kind"} for claim in (%s)]`,
+	fmt.Sprintf(arrayValue, "pvc"))
+
+var statefulSetHelpers = []*objectHelper{
+	{
+		comment:    "Sets `spec.volumeClaimTemplates` to `pvc`, a claim or an array of them (e.g., from `persistentVolumeClaim.new`), without their `apiVersion` and `kind`.",
+		name:       "withVolumeClaimTemplates",
+		params:     []string{"pvc"},
+		body:       fmt.Sprintf("{spec+: {volumeClaimTemplates: %s}}", claimTemplatesValue),
+		fields:     []string{"spec.volumeClaimTemplates"},
+		properties: map[string]kubespec.PropertyName{"pvc": "volumeClaimTemplates"},
+	},
+	{
+		comment:    "Appends `pvc`, a claim or an array of them, to `spec.volumeClaimTemplates`, without their `apiVersion` and `kind`.",
+		name:       "withVolumeClaimTemplatesMixin",
+		params:     []string{"pvc"},
+		body:       fmt.Sprintf("{spec+: {volumeClaimTemplates+: %s}}", claimTemplatesValue),
+		fields:     []string{"spec.volumeClaimTemplates"},
+		properties: map[string]kubespec.PropertyName{"pvc": "volumeClaimTemplates"},
+		tests: []helperTest{
+			{
+				name:       "statefulSet.withVolumeClaimTemplatesMixin",
+				expression: `(v.statefulSet.withVolumeClaimTemplates({apiVersion: "v1", kind: "PersistentVolumeClaim", metadata: {name: "a"}}) + v.statefulSet.withVolumeClaimTemplatesMixin([{apiVersion: "v1", kind: "PersistentVolumeClaim", metadata: {name: "b"}}])).spec.volumeClaimTemplates`,
+				expected:   `[{metadata: {name: "a"}}, {metadata: {name: "b"}}]`,
+			},
+			{
+				name:       "statefulSet.withVolumeClaimTemplatesMixin.fields",
+				expression: `[std.objectFields(claim) for claim in v.statefulSet.withVolumeClaimTemplatesMixin({apiVersion: "v1", kind: "PersistentVolumeClaim", metadata: {name: "data"}, spec: {}}).spec.volumeClaimTemplates]`,
+				expected:   `[["metadata", "spec"]]`,
+			},
+		},
+	},
+}
+
+// `nameValue` returns a Jsonnet expression for the name of the object
+// the param `param` refers to, which is either the name itself, an
+// object with `metadata` (e.g., from `secret.new`), or a reference
+// (e.g., from `localObjectReference.new`).
+func nameValue(param string) string {
+	return fmt.Sprintf(
+		`if std.type(%[1]s) == "string" then %[1]s else if std.type(%[1]s) != "object" then error "'%[1]s' must be a name or an object, got " + std.type(%[1]s) else if std.objectHas(%[1]s, "metadata") then %[1]s.metadata.name else %[1]s.name`,
+		param)
+}
+
+// `keySelectorValue` returns a Jsonnet expression for a key selector
+// (e.g., a `SecretKeySelector`) from the param `param`, which is
+// either a key selector, whose key the param `key` replaces unless it's
+// null, or anything `nameValue` accepts, along with `key`.
+func keySelectorValue(param string) string {
+	return fmt.Sprintf(
+		`if std.type(%[1]s) == "object" && std.objectHas(%[1]s, "key") then %[1]s + (if key == null then {} else {key: key}) else assert key != null : "'key' is required unless '%[1]s' is a key selector"; {key: key, name: %[2]s}`,
+		param, nameValue(param))
+}
+
+var envVarHelpers = []*objectHelper{
+	{
+		comment:  "Creates a variable named `name` from a key of a secret: `secretKeyRef` is a key selector (e.g., from `mixin.valueFrom.secretKeyRefType.new`), or the secret, or its name, with `key`.",
+		name:     "fromSecretKeyRef",
+		params:   []string{"name", "secretKeyRef", "key"},
+		defaults: map[string]string{"key": "null"},
+		body:     fmt.Sprintf("{name: name, valueFrom: {secretKeyRef: %s}}", keySelectorValue("secretKeyRef")),
+		fields:   []string{"name", "valueFrom.secretKeyRef"},
+		tests: []helperTest{
+			{
+				name:       "container.envType.fromSecretKeyRef",
+				expression: `v.pod.mixin.spec.containersType.envType.fromSecretKeyRef("PASSWORD", "db", "password")`,
+				expected:   `{name: "PASSWORD", valueFrom: {secretKeyRef: {key: "password", name: "db"}}}`,
+			},
+			{
+				name:       "container.envType.fromSecretKeyRef.selector",
+				expression: `v.pod.mixin.spec.containersType.envType.fromSecretKeyRef("PASSWORD", v.pod.mixin.spec.containersType.envType.mixin.valueFrom.secretKeyRefType.new("db", "password"))`,
+				expected:   `{name: "PASSWORD", valueFrom: {secretKeyRef: {key: "password", name: "db"}}}`,
+			},
+		},
+	},
+	{
+		comment:  "Creates a variable named `name` from a key of a config map: `configMapKeyRef` is a key selector (e.g., from `mixin.valueFrom.configMapKeyRefType.new`), or the config map, or its name, with `key`.",
+		name:     "fromConfigMapKeyRef",
+		params:   []string{"name", "configMapKeyRef", "key"},
+		defaults: map[string]string{"key": "null"},
+		body:     fmt.Sprintf("{name: name, valueFrom: {configMapKeyRef: %s}}", keySelectorValue("configMapKeyRef")),
+		fields:   []string{"name", "valueFrom.configMapKeyRef"},
+		tests: []helperTest{
+			{
+				name:       "container.envType.fromConfigMapKeyRef",
+				expression: `v.pod.mixin.spec.containersType.envType.fromConfigMapKeyRef("LEVEL", {metadata: {name: "settings"}}, "level")`,
+				expected:   `{name: "LEVEL", valueFrom: {configMapKeyRef: {key: "level", name: "settings"}}}`,
+			},
+			{
+				name:       "container.envType.fromConfigMapKeyRef.selector",
+				expression: `v.pod.mixin.spec.containersType.envType.fromConfigMapKeyRef("LEVEL", v.pod.mixin.spec.containersType.envType.mixin.valueFrom.configMapKeyRefType.new("settings", "level") + {optional: true}, "verbosity")`,
+				expected:   `{name: "LEVEL", valueFrom: {configMapKeyRef: {key: "verbosity", name: "settings", optional: true}}}`,
+			},
+		},
+	},
+}
+
+var envFromSourceHelpers = []*objectHelper{
+	{
+		comment: "Creates a source of every key of the secret `secretRef` (the secret, its name, or a reference to it) as a variable.",
+		name:    "fromSecretRef",
+		params:  []string{"secretRef"},
+		body:    fmt.Sprintf("{secretRef: {name: %s}}", nameValue("secretRef")),
+		fields:  []string{"secretRef.name"},
+	},
+	{
+		comment: "Creates a source of every key of the config map `configMapRef` (the config map, its name, or a reference to it) as a variable.",
+		name:    "fromConfigMapRef",
+		params:  []string{"configMapRef"},
+		body:    fmt.Sprintf("{configMapRef: {name: %s}}", nameValue("configMapRef")),
+		fields:  []string{"configMapRef.name"},
+		tests: []helperTest{
+			{
+				name:       "container.envFromType.fromConfigMapRef",
+				expression: `v.pod.mixin.spec.containersType.envFromType.fromConfigMapRef("settings")`,
+				expected:   `{configMapRef: {name: "settings"}}`,
+			},
+			{
+				name:       "container.envFromType.fromConfigMapRef.reference",
+				expression: `v.pod.mixin.spec.containersType.envFromType.fromConfigMapRef(v.pod.mixin.spec.imagePullSecretsType.new("settings"))`,
+				expected:   `{configMapRef: {name: "settings"}}`,
+			},
+		},
+	},
+}
+
+var volumeHelpers = []*objectHelper{
+	{
+		comment: "Creates a volume named `name` with the keys of the secret `secret` (the secret, its name, or a reference to it) as files.",
+		name:    "fromSecret",
+		params:  []string{"name", "secret"},
+		body:    fmt.Sprintf("{name: name, secret: {secretName: %s}}", nameValue("secret")),
+		fields:  []string{"name", "secret.secretName"},
+		tests: []helperTest{
+			{
+				name:       "pod.mixin.spec.volumesType.fromSecret",
+				expression: `v.pod.mixin.spec.volumesType.fromSecret("certs", "web-tls")`,
+				expected:   `{name: "certs", secret: {secretName: "web-tls"}}`,
+			},
+			{
+				name:       "pod.mixin.spec.volumesType.fromSecret.reference",
+				expression: `v.pod.mixin.spec.volumesType.fromSecret("certs", v.pod.mixin.spec.imagePullSecretsType.new("web-tls"))`,
+				expected:   `{name: "certs", secret: {secretName: "web-tls"}}`,
+			},
+		},
+	},
+	{
+		comment: "Creates a volume named `name` with the keys of the config map `configMap` (the config map, its name, or a reference to it) as files.",
+		name:    "fromConfigMap",
+		params:  []string{"name", "configMap"},
+		body:    fmt.Sprintf("{name: name, configMap: {name: %s}}", nameValue("configMap")),
+		fields:  []string{"name", "configMap.name"},
+	},
+	{
+		comment:    "Creates a volume named `name` of the claim `claim` (e.g., from `persistentVolumeClaim.new`, or its name).",
+		name:       "fromPersistentVolumeClaim",
+		params:     []string{"name", "claim"},
+		body:       fmt.Sprintf("{name: name, persistentVolumeClaim: {claimName: %s}}", nameValue("claim")),
+		fields:     []string{"name", "persistentVolumeClaim.claimName"},
+		properties: map[string]kubespec.PropertyName{"claim": "persistentVolumeClaim"},
+		tests: []helperTest{{
+			name:       "pod.mixin.spec.volumesType.fromPersistentVolumeClaim",
+			expression: `v.pod.mixin.spec.volumesType.fromPersistentVolumeClaim("data", {apiVersion: "v1", kind: "PersistentVolumeClaim", metadata: {name: "data-claim"}})`,
+			expected:   `{name: "data", persistentVolumeClaim: {claimName: "data-claim"}}`,
+		}},
+	},
+}
+
+// serviceTypes are the values of a `Service`'s `spec.type`.
+var serviceTypes = []string{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"}
+
+// `serviceTypeHelpers` returns `withType`, which checks that its
+// argument is one of `serviceTypes`, and a helper per type (e.g.,
+// `withTypeNodePort`), so that typos are caught where they're made
+// rather than by the server.
+func serviceTypeHelpers() []*objectHelper {
+	quoted := []string{}
+	for _, serviceType := range serviceTypes {
+		quoted = append(quoted, fmt.Sprintf("%q", serviceType))
+	}
+	helpers := []*objectHelper{{
+		comment: fmt.Sprintf(
+			"Sets `spec.type`, which determines how the service is exposed, to one of %s.",
+			strings.Join(quoted, ", ")),
+		name:   "withType",
+		params: []string{"type"},
+		body: fmt.Sprintf(
+			`assert std.count([%s], type) > 0 : "'type' must be one of %s, got '" + type + "'"; {spec+: {type: type}}`,
+			strings.Join(quoted, ", "), strings.Join(serviceTypes, ", ")),
+		fields: []string{"spec.type"},
+		tests: []helperTest{{
+			name:       "service.withType",
+			expression: `v.service.withType("NodePort")`,
+			expected:   `{spec: {type: "NodePort"}}`,
+		}},
+	}}
+	for _, serviceType := range serviceTypes {
+		helpers = append(helpers, &objectHelper{
+			comment: fmt.Sprintf("Sets `spec.type` to %q.", serviceType),
+			name:    fmt.Sprintf("withType%s", serviceTypeSuffix(serviceType)),
+			body:    fmt.Sprintf("self.withType(%q)", serviceType),
+			fields:  []string{"spec.type"},
+		})
+	}
+	return helpers
+}
+
+// `serviceTypeSuffix` spells initialisms like the rest of the library,
+// e.g., `ClusterIp` for `ClusterIP`.
+func serviceTypeSuffix(serviceType string) string {
+	return strings.Replace(serviceType, "IP", "Ip", -1)
+}
+
+// `objectHelpers` returns the helpers of the API object whose fields
+// all exist in its schema.
+func (ao *apiObject) objectHelpers() []*objectHelper {
+	helpers := []*objectHelper{}
+	for _, helper := range objectHelpers[ao.parent.parent.name][ao.name] {
+		ok := true
+		for _, field := range helper.fields {
+			ok = ok && ao.hasField(strings.Split(field, "."))
+		}
+		if ok {
+			helpers = append(helpers, helper)
+		}
+	}
+	return helpers
+}
+
+func (ao *apiObject) emitObjectHelpers(m *indentWriter, path string) {
+	for _, helper := range ao.objectHelpers() {
+		if dm, ok := ao.properties[kubespec.PropertyName(helper.name)]; ok {
+			log.Panicf(
+				"Attempted to create helper '%s', but the property already existed at '%s'",
+				helper.name, dm.path)
+		}
+
+		params, defaults, bindings := ao.helperParams(helper)
+		signature := []string{}
+		for _, param := range params {
+			if value, ok := defaults[param]; ok {
+				param = fmt.Sprintf("%s=%s", param, value)
+			}
+			signature = append(signature, param)
+		}
+		m.writeLine("// " + helper.comment)
+		m.writeLine(fmt.Sprintf(
+			"%s(%s):: %s%s,", helper.name, strings.Join(signature, ", "), bindings, helper.body))
+		symbol := ao.root().index.add(
+			fmt.Sprintf("%s.%s", path, helper.name), SymbolFunction, params...)
+		if len(defaults) > 0 {
+			symbol.Defaults = defaults
+		}
+	}
+}
+
+// `helperParams` returns the parameters of `helper` as it's emitted,
+// with their defaults, and the locals its body is prefixed with. With
+// `jsonnet.NamingStrategyUniformParams`, each parameter is named by
+// `jsonnet.Naming.ParamName` after the property it takes (e.g.,
+// `withStorageClassName(storageClassName)`), and bound to the name the
+// body uses, if that's another (e.g., `local name = storageClassName;
+// `); otherwise, they're the helper's own.
+func (ao *apiObject) helperParams(helper *objectHelper) ([]string, map[string]string, string) {
+	naming := ao.root().naming()
+	if !naming.UniformParams() {
+		return helper.params, helper.defaults, ""
+	}
+
+	params := []string{}
+	defaults := map[string]string{}
+	bindings := ""
+	taken := map[string]bool{}
+	for _, param := range helper.params {
+		property, ok := helper.properties[param]
+		if !ok {
+			property = kubespec.PropertyName(param)
+		}
+		name := string(naming.ParamName(ao.root().spec.Info.Version, property, taken))
+		taken[name] = true
+		params = append(params, name)
+		if value, ok := helper.defaults[param]; ok {
+			defaults[name] = value
+		}
+		if name != param {
+			bindings += fmt.Sprintf("local %s = %s; ", param, name)
+		}
+	}
+	if len(defaults) == 0 {
+		defaults = nil
+	}
+	return params, defaults, bindings
+}
+
+// `helperTests` returns the smoke tests of the API object's constructor
+// override and helpers.
+func (ao *apiObject) helperTests() []helperTest {
+	tests := []helperTest{}
+	if override := ao.constructorOverride(); override != nil {
+		tests = append(tests, override.tests...)
+	}
+	for _, helper := range ao.objectHelpers() {
+		tests = append(tests, helper.tests...)
+	}
+	return tests
+}
+
+func (va *versionedAPI) emitHelperTests(m *indentWriter, objects apiObjectSlice) {
+	for _, ao := range objects {
+		for _, test := range ao.helperTests() {
+			m.writeLine(fmt.Sprintf("\"%s.%s\": (", va.path(), test.name))
+			m.indent()
+			m.writeLine(fmt.Sprintf("local v = k.%s;", va.path()))
+			m.writeLine(fmt.Sprintf(
+				"std.assertEqual(%s, %s)", test.expression, test.expected))
+			m.dedent()
+			m.writeLine("),")
+		}
+	}
+}
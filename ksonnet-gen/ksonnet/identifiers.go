@@ -0,0 +1,139 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `propertyIdentifiers` returns the Jsonnet identifier of each property
+// of a definition, which names its setter or mixin namespace, and
+// (with `Type`) its type alias. Identifiers must be unique within the
+// definition's namespace, but two properties can map to the same one
+// (e.g., `replicas` and `Replicas`, which some CRD controllers both
+// write); we also treat identifiers that differ only by case as
+// colliding, since they'd be too easy to confuse.
+//
+// Collisions are resolved deterministically. Properties whose names
+// are already identifiers keep them, then the rest are considered in
+// sorted order, and whichever come later get the lowest numeric
+// suffix that's free (e.g., `replicas2`). Each rename is logged.
+//
+// Identifiers that are reserved in Jsonnet (see
+// `jsonnet.IsReservedIdentifier`) get a trailing underscore first
+// (e.g., `std_` for the property `std`; see
+// `jsonnet.Naming.SanitizePropertyID`), so that a parameter never
+// shadows `std`, and no setter is named after a keyword; this is logged
+// too, and `SymbolIndex` records it, along with every identifier that
+// isn't its property's name (see `PropertyIDMap`).
+func (root *root) propertyIdentifiers(
+	path kubespec.DefinitionName, def *kubespec.SchemaDefinition,
+) map[kubespec.PropertyName]jsonnet.Identifier {
+	k8sVersion := root.spec.Info.Version
+	names := []kubespec.PropertyName{}
+	preferred := map[kubespec.PropertyName]jsonnet.Identifier{}
+	for name := range def.Properties {
+		names = append(names, name)
+		preferred[name] = root.naming().SanitizePropertyID(k8sVersion, name)
+		if reserved := root.naming().RewriteAsIdentifier(k8sVersion, name); reserved != preferred[name] {
+			root.logger().Log(
+				"reserved identifier", "definition", path, "property", name,
+				"identifier", reserved, "renamed", fmt.Sprintf("%s=%s", name, preferred[name]))
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iExact := string(preferred[names[i]]) == string(names[i])
+		jExact := string(preferred[names[j]]) == string(names[j])
+		if iExact != jExact {
+			return iExact
+		}
+		return names[i] < names[j]
+	})
+
+	// Give out the preferred identifiers first, so that a suffixed one
+	// never takes another property's preferred identifier.
+	ids := map[kubespec.PropertyName]jsonnet.Identifier{}
+	owners := map[string]kubespec.PropertyName{}
+	for _, name := range names {
+		key := strings.ToLower(string(preferred[name]))
+		if _, taken := owners[key]; !taken {
+			owners[key] = name
+			ids[name] = preferred[name]
+		}
+	}
+	for _, name := range names {
+		if _, ok := ids[name]; ok {
+			continue
+		}
+		owner := owners[strings.ToLower(string(preferred[name]))]
+		for n := 2; ; n++ {
+			id := jsonnet.Identifier(fmt.Sprintf("%s%d", preferred[name], n))
+			key := strings.ToLower(string(id))
+			if _, taken := owners[key]; !taken {
+				owners[key] = name
+				ids[name] = id
+				break
+			}
+		}
+		root.logger().Log(
+			"identifier collision", "definition", path,
+			"properties", fmt.Sprintf("%s,%s", owner, name),
+			"identifier", preferred[owner], "renamed", fmt.Sprintf("%s=%s", name, ids[name]))
+	}
+
+	// Type aliases are named after their property's identifier, so
+	// that they're unique too.
+	for _, name := range names {
+		alias := name + "Type"
+		if _, ok := def.Properties[alias]; !ok {
+			ids[alias] = ids[name] + "Type"
+		}
+	}
+	return ids
+}
+
+// `identifier` returns the Jsonnet identifier of the API object's
+// property `name`; see `propertyIdentifiers`.
+func (ao *apiObject) identifier(name kubespec.PropertyName) jsonnet.Identifier {
+	if id, ok := ao.identifiers[name]; ok {
+		return id
+	}
+	return ao.root().naming().RewriteAsIdentifier(ao.root().spec.Info.Version, name)
+}
+
+// `renamedFrom` returns the identifier the API object's property
+// `name` would have had, if `propertyIdentifiers` renamed it, to avoid
+// a collision or a reserved identifier (e.g., `std` for `std_`).
+func (ao *apiObject) renamedFrom(name kubespec.PropertyName) (jsonnet.Identifier, bool) {
+	preferred := ao.root().naming().RewriteAsIdentifier(ao.root().spec.Info.Version, name)
+	if id := ao.identifier(name); id != preferred {
+		return preferred, true
+	}
+	return "", false
+}
+
+// `funcParam` returns the name of the parameter of the setter of the
+// API object's property `name`, which matches its identifier (e.g.,
+// `replicas2(replicas2)`), unless that's a Jsonnet keyword. With
+// `jsonnet.NamingStrategyUniformParams`, it's the property's
+// `jsonnet.Naming.ParamName` instead, with another trailing underscore
+// if its identifier was renamed for colliding with another's (e.g.,
+// `replicas2(replicas_)`).
+func (ao *apiObject) funcParam(name kubespec.PropertyName) jsonnet.FuncParam {
+	k8sVersion := ao.root().spec.Info.Version
+	id := ao.identifier(name)
+	if naming := ao.root().naming(); naming.UniformParams() {
+		taken := map[string]bool{}
+		if sanitized := naming.SanitizePropertyID(k8sVersion, name); id != sanitized {
+			taken[string(sanitized)] = true
+		}
+		return naming.ParamName(k8sVersion, name, taken)
+	}
+	if id == ao.root().naming().RewriteAsIdentifier(k8sVersion, name) {
+		return ao.root().naming().RewriteAsFuncParam(k8sVersion, name)
+	}
+	return ao.root().naming().RewriteAsFuncParam(k8sVersion, kubespec.PropertyName(id))
+}
@@ -0,0 +1,153 @@
+package ksonnet
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestPropertyIdentifiers(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/duplicates.json")
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	root := newRoot(spec, Options{Logger: logger})
+
+	// Properties that are already identifiers keep them; the others
+	// get the lowest free suffix, skipping `replicas2`, which is taken.
+	ids := root.propertyIdentifiers(
+		"io.k8s.kubernetes.pkg.apis.stable.v1.ScalerSpec",
+		spec.Definitions["io.k8s.kubernetes.pkg.apis.stable.v1.ScalerSpec"])
+	expected := map[kubespec.PropertyName]jsonnet.Identifier{
+		"replicas":  "replicas",
+		"Replicas":  "replicas3",
+		"replicas2": "replicas2",
+		"targetRef": "targetRef",
+		"TargetRef": "targetRef2",
+	}
+	for name, id := range expected {
+		if ids[name] != id {
+			t.Errorf("Expected '%s' to be named '%s', got '%s'", name, id, ids[name])
+		}
+		if ids[name+"Type"] != id+"Type" {
+			t.Errorf("Expected the type alias of '%s' to be named '%sType', got '%s'", name, id, ids[name+"Type"])
+		}
+	}
+
+	renamed := []string{}
+	for _, event := range logger.events["identifier collision"] {
+		renamed = append(renamed, event["properties"].(string)+" "+event["renamed"].(string))
+	}
+	sort.Strings(renamed)
+	if !reflect.DeepEqual(renamed, []string{
+		"replicas,Replicas Replicas=replicas3",
+		"replicas,Replicas Replicas=replicas3",
+		"spec,Spec Spec=spec2",
+		"targetRef,TargetRef TargetRef=targetRef2",
+		"targetRef,TargetRef TargetRef=targetRef2",
+	}) {
+		t.Errorf("Unexpected collisions logged: %v", renamed)
+	}
+
+	// Setters, their parameters, mixin namespaces, and type aliases all
+	// use the suffixed identifiers, while the fields keep their names.
+	library := emitTestSpec(t, "testdata/duplicates.json", Options{})
+	for _, line := range []string{
+		"replicas3(replicas3):: {Replicas: replicas3},",
+		"replicas(replicas):: {replicas: replicas},",
+		"replicas2(replicas2):: {replicas2: replicas2},",
+		"spec2:: {\n            local __spec2Mixin(spec2) = {Spec+: spec2},",
+		"replicas3(replicas3):: __spec2Mixin({Replicas: replicas3}),",
+		"spec2Type:: hidden.stable.v1.scalerSpec,",
+		"specType:: hidden.stable.v1.scalerSpec,",
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain:\n%s", line)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbol := index.byPath()["stable.v1.scaler.mixin.spec2.replicas3"]
+	if symbol == nil || !reflect.DeepEqual(symbol.Params, []string{"replicas3"}) {
+		t.Errorf("Expected a 'replicas3' setter in the symbol index, got %#v", symbol)
+	}
+}
+
+func TestReservedPropertyIdentifiers(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/reserved.json")
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	root := newRoot(spec, Options{Logger: logger})
+
+	// Each rename is warned about, once per definition: `std` is a
+	// property of both `Widget` and `WidgetSpec`.
+	renamed := []string{}
+	for _, event := range logger.events["reserved identifier"] {
+		renamed = append(renamed, event["renamed"].(string))
+	}
+	sort.Strings(renamed)
+	if !reflect.DeepEqual(renamed, []string{"local=local_", "self=self_", "std=std_", "std=std_"}) {
+		t.Errorf("Unexpected reserved identifiers logged: %v", renamed)
+	}
+
+	// Reserved identifiers get a trailing underscore, which can collide
+	// with a property that's already named that way.
+	ids := root.propertyIdentifiers(
+		"io.k8s.kubernetes.pkg.apis.stable.v1.WidgetSpec",
+		spec.Definitions["io.k8s.kubernetes.pkg.apis.stable.v1.WidgetSpec"])
+	expected := map[kubespec.PropertyName]jsonnet.Identifier{
+		"local": "local_",
+		"self":  "self_",
+		"std":   "std_2",
+		"std_":  "std_",
+	}
+	for name, id := range expected {
+		if ids[name] != id {
+			t.Errorf("Expected '%s' to be named '%s', got '%s'", name, id, ids[name])
+		}
+	}
+
+	// No parameter shadows `std`, and the fields keep their names.
+	library := emitTestSpec(t, "testdata/reserved.json", Options{})
+	for _, line := range []string{
+		`local_(local_=true):: __specMixin({"local": local_}),`,
+		`self_(self_):: __specMixin({"self": self_}),`,
+		`std_2(std_2):: if std.type(std_2) == "array" then __specMixin({std+: std_2}) else __specMixin({std: [std_2]}),`,
+		`std_(std_):: __specMixin({std_: std_}),`,
+		"std_:: {\n            local __std_Mixin(std_) = {std+: std_},",
+		"std_Type:: hidden.stable.v1.widgetStd,",
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected library to contain:\n%s", line)
+		}
+	}
+	if strings.Contains(library, "(std)") || strings.Contains(library, "(std=") {
+		t.Errorf("Expected no parameter named 'std':\n%s", library)
+	}
+
+	// The symbol index maps renamed symbols back to the identifiers
+	// they'd have had.
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := index.byPath()
+	for path, from := range map[string]string{
+		"stable.v1.widget.mixin.spec.std_2":  "std",
+		"stable.v1.widget.mixin.spec.local_": "local",
+		"stable.v1.widget.mixin.std_":        "std",
+		"stable.v1.widget.mixin.std_Type":    "stdType",
+		"stable.v1.widget.mixin.spec.std_":   "",
+	} {
+		symbol := symbols[path]
+		if symbol == nil {
+			t.Errorf("Expected symbol '%s' in the index", path)
+		} else if symbol.RenamedFrom != from {
+			t.Errorf("Expected '%s' to be renamed from '%s', got '%s'", path, from, symbol.RenamedFrom)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ksonnet
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `importPath` is what the library's files import `file`, another of
+// them, as: `file`, relative to the importing file, or, with
+// `Options.ImportBase`, `file` under it, e.g.,
+// `github.com/ourorg/k8s-libsonnet/apps.libsonnet`.
+func (root *root) importPath(file string) string {
+	if root.opts.ImportBase == "" {
+		return file
+	}
+	return path.Join(root.opts.ImportBase, file)
+}
+
+// `validateImportBase` checks that `base` is a path Jsonnet can find
+// the library under in a library path (`-J`), e.g.,
+// `github.com/ourorg/k8s-libsonnet`.
+func validateImportBase(base string) error {
+	if base == "" {
+		return nil
+	}
+	if strings.HasPrefix(base, "/") || strings.HasSuffix(base, "/") ||
+		strings.ContainsAny(base, "\\\"'\n") {
+		return fmt.Errorf(
+			"Import base '%s' isn't a relative path, e.g., 'github.com/ourorg/k8s-libsonnet'", base)
+	}
+	for _, segment := range strings.Split(base, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf(
+				"Import base '%s' has an empty, '.', or '..' segment", base)
+		}
+	}
+	return nil
+}
+
+// VerifyImports checks that every import in the library's Jsonnet
+// `artifacts` (those `EmitArtifacts` returns, keyed by their path
+// relative to the library's directory) resolves to another of them,
+// the way Jsonnet resolves it: relative to the importing file, or, if
+// `importBase` is set (see `Options.ImportBase`), in a library path
+// (`-J`) with the library's directory at `importBase` under it, e.g.,
+// `vendor/github.com/ourorg/k8s-libsonnet`. It returns an error naming
+// each import that doesn't. Files that aren't Jsonnet (e.g., `INDEX.md`)
+// aren't checked. The error is of `ErrVerifyFailed`. Imports of `external` (e.g., the external libraries
+// of `Options.ExternalRefs`, e.g., `k8s/k8s.libsonnet`) are of other
+// libraries in the library path, so they resolve if those are there.
+func VerifyImports(artifacts Artifacts, importBase string, external ...string) error {
+	names := []string{}
+	for name := range artifacts {
+		if strings.HasSuffix(name, ".libsonnet") || strings.HasSuffix(name, ".jsonnet") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// With an import base, the library is at `importBase` of the
+	// library path, so relative imports are resolved from there too.
+	resolves := func(importer, imported string) bool {
+		for _, other := range external {
+			if imported == other {
+				return true
+			}
+		}
+		dir := path.Join(importBase, path.Dir(importer))
+		candidates := []string{path.Join(dir, imported)}
+		if importBase != "" {
+			candidates = append(candidates, path.Clean(imported))
+		}
+		for _, candidate := range candidates {
+			if importBase != "" {
+				if !strings.HasPrefix(candidate, importBase+"/") {
+					continue
+				}
+				candidate = strings.TrimPrefix(candidate, importBase+"/")
+			}
+			if _, ok := artifacts[candidate]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	problems := []string{}
+	for _, name := range names {
+		text := string(artifacts[name].Text)
+		code := stripCommentsAndStrings(text)
+		for _, match := range importStatementPattern.FindAllStringIndex(code, -1) {
+			end := match[1]
+			for end < len(text) && strings.ContainsRune(" \t\r\n", rune(text[end])) {
+				end++
+			}
+			literal, ok := importLiteral(text, end)
+			if !ok {
+				continue
+			}
+			imported := literal[1 : len(literal)-1]
+			if !resolves(name, imported) {
+				problems = append(problems, fmt.Sprintf("'%s' imports %s", name, literal))
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	where := "relative to the importing file"
+	if importBase != "" {
+		where = fmt.Sprintf("with the library at '%s' of the library path", importBase)
+	}
+	return kubespec.Categorize(ErrVerifyFailed, fmt.Errorf(
+		"Imports of the library don't resolve %s:\n%s", where, strings.Join(problems, "\n")))
+}
@@ -0,0 +1,125 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testImportBase = "github.com/ourorg/k8s-libsonnet"
+
+func TestImportBase(t *testing.T) {
+	opts := Options{
+		SplitByGroup: true, EmitPresets: true, EmitGVKIndex: true, ImportBase: testImportBase,
+	}
+	artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/collisions.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	for _, expected := range []struct{ name, line string }{
+		{LibraryFile, `apps:: import "github.com/ourorg/k8s-libsonnet/apps.libsonnet",`},
+		{LibraryFile, `local hidden = import "github.com/ourorg/k8s-libsonnet/hidden.libsonnet",`},
+		{LibraryFile, `local gvks = import "github.com/ourorg/k8s-libsonnet/gvkIndex.libsonnet";`},
+		{"apps.libsonnet", `local hidden = import "github.com/ourorg/k8s-libsonnet/hidden.libsonnet";`},
+		{AliasesFile, `local k8s = import "github.com/ourorg/k8s-libsonnet/k8s.libsonnet";`},
+	} {
+		if text := string(artifacts[expected.name].Text); !strings.Contains(text, expected.line) {
+			t.Errorf("Expected '%s' to have:\n%s\ngot:\n%s", expected.name, expected.line, text)
+		}
+	}
+
+	// By default, the imports are relative.
+	artifacts, err = EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/collisions.json"), Options{SplitByGroup: true})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if !strings.Contains(string(artifacts[LibraryFile].Text), `apps:: import "apps.libsonnet",`) ||
+		!strings.Contains(string(artifacts[AliasesFile].Text), `local k8s = import "k8s.libsonnet";`) {
+		t.Errorf("Expected relative imports by default")
+	}
+}
+
+func TestVerifyImports(t *testing.T) {
+	artifacts := Artifacts{
+		LibraryFile:      NewArtifact([]byte("{\n  apps:: import \"apps.libsonnet\",\n  // import \"commented.libsonnet\"\n}\n")),
+		"apps.libsonnet": NewArtifact([]byte("local hidden = import 'hidden.libsonnet';\n{}\n")),
+		HiddenFile:       NewArtifact([]byte("{}\n")),
+		IndexDocFile:     NewArtifact([]byte("local k = import \"missing.libsonnet\";\n")),
+	}
+	if err := VerifyImports(artifacts, ""); err != nil {
+		t.Errorf("Expected relative imports to resolve, got:\n%v", err)
+	}
+
+	// Relative imports resolve with an import base too, but a base
+	// other than the library's doesn't.
+	if err := VerifyImports(artifacts, testImportBase); err != nil {
+		t.Errorf("Expected relative imports to resolve under an import base, got:\n%v", err)
+	}
+	artifacts["apps.libsonnet"] = NewArtifact([]byte(
+		"local hidden = import \"github.com/ourorg/k8s-libsonnet/hidden.libsonnet\";\nlocal other = import \"github.com/other/hidden.libsonnet\";\n{}\n"))
+	err := VerifyImports(artifacts, testImportBase)
+	if err == nil || !strings.Contains(err.Error(), `'apps.libsonnet' imports "github.com/other/hidden.libsonnet"`) ||
+		strings.Contains(err.Error(), `"github.com/ourorg/k8s-libsonnet/hidden.libsonnet"`) {
+		t.Errorf("Expected only the import outside the library to fail, got:\n%v", err)
+	}
+
+	// Without the import base, absolute imports don't resolve.
+	err = VerifyImports(artifacts, "")
+	if err == nil || !strings.Contains(err.Error(), "relative to the importing file") ||
+		!strings.Contains(err.Error(), `"github.com/ourorg/k8s-libsonnet/hidden.libsonnet"`) {
+		t.Errorf("Expected absolute imports to fail without an import base, got:\n%v", err)
+	}
+}
+
+func TestImportBaseEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "importbase")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The library is vendored at its import base, and the program is
+	// outside it, so the imports only resolve through `-J vendor`.
+	opts := Options{SplitByGroup: true, EmitGVKIndex: true, ImportBase: testImportBase}
+	artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/collisions.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	vendor := filepath.Join(dir, "vendor")
+	library := filepath.Join(vendor, filepath.FromSlash(testImportBase))
+	if err := os.MkdirAll(library, 0755); err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	for name, artifact := range artifacts {
+		if err := ioutil.WriteFile(filepath.Join(library, name), artifact.Text, 0644); err != nil {
+			t.Fatalf("Could not write '%s':\n%v", name, err)
+		}
+	}
+
+	// `k.libsonnet` imports `k8s.libsonnet`, which imports each group's
+	// file, which imports `hidden.libsonnet`, and `util.forManifest`
+	// imports `gvkIndex.libsonnet`.
+	const program = `local k = import "github.com/ourorg/k8s-libsonnet/k.libsonnet";
+local deployment = k.deployment.new() + k.deployment.mixin.metadata.name("web");
+[
+  std.assertEqual(deployment, {apiVersion: "apps/v1", kind: "Deployment", metadata: {name: "web"}}),
+  std.assertEqual(k.util.forManifest(deployment).mixin.metadata.name("api").metadata.name, "api"),
+  std.assertEqual(std.objectHasAll(k.apps.v1.deployment.mixin.metadataType, "name"), true),
+]
+`
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	if out, err := exec.Command(jsonnet, "-J", vendor, main).CombinedOutput(); err != nil || strings.Contains(string(out), "false") {
+		t.Errorf("Expected the vendored library to evaluate, got %v:\n%s", err, out)
+	}
+}
@@ -0,0 +1,160 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// IndexDocFile is the name of the file `EmitIndexDoc` generates.
+const IndexDocFile = "INDEX.md"
+
+// EmitIndexDoc takes a swagger API specification, and returns the text
+// of `INDEX.md`, a Markdown table of contents for the library `Emit`
+// generates: the line that imports it, and, for each API group, its
+// versions and their top-level kinds, with their paths, flattened
+// aliases (see `EmitAliases`), and the first sentence of their
+// descriptions, led by when they're removed if they're scheduled for
+// removal (see `kubeversion.ScheduledRemoval`), followed by the example values the spec gives them and
+// their fields, fenced as JSON (and truncated if they're large;
+// see `kubespec.FormatExample`). It's built from the same model as the library, and in
+// the same order, so it matches the library and is identical from run
+// to run.
+func EmitIndexDoc(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	root := newRoot(spec, opts)
+	if err := joinErrors(root.errors); err != nil {
+		return nil, err
+	}
+
+	m := newIndentWriter()
+	root.emitIndexDoc(m)
+	return m.bytes()
+}
+
+func (root *root) emitIndexDoc(m *indentWriter) {
+	aliases := map[*apiObject]string{}
+	for _, alias := range root.aliases() {
+		aliases[alias.object] = alias.name
+	}
+
+	m.writeLine(fmt.Sprintf("# Kubernetes %s", root.spec.Info.Version))
+	m.writeLine("")
+	m.writeLine("<!-- AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY. -->")
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf(
+		"Import `%s`, which has the whole library along with a flattened alias for each kind:", AliasesFile))
+	m.writeLine("")
+	m.writeLine("```jsonnet")
+	m.writeLine(fmt.Sprintf("local k = %s;", root.libraryImport(AliasesFile)))
+	m.writeLine("```")
+
+	for _, group := range root.groups.toSortedSlice() {
+		versions := []string{}
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			versions = append(versions, fmt.Sprintf("`%s`", va.apiVersion()))
+		}
+
+		m.writeLine("")
+		m.writeLine(fmt.Sprintf("## %s", group.path()))
+		m.writeLine("")
+		m.writeLine(fmt.Sprintf("Versions: %s", strings.Join(versions, ", ")))
+		m.writeLine("")
+		m.writeLine("| Kind | Path | Alias | Description |")
+		m.writeLine("| --- | --- | --- | --- |")
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.apiObjects.toSortedSlice() {
+				alias := ""
+				if name, ok := aliases[ao]; ok {
+					alias = fmt.Sprintf("`k.%s`", name)
+				}
+				description := summarize(ao.description)
+				if warning := ao.removalWarning(); warning != "" {
+					description = fmt.Sprintf("**%s** %s", warning, description)
+				}
+				m.writeLine(fmt.Sprintf(
+					"| %s | `k.%s` | %s | %s |",
+					ao.name, ao.path(), alias, strings.TrimSpace(description)))
+			}
+		}
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.apiObjects.toSortedSlice() {
+				ao.emitIndexDocExamples(m)
+			}
+		}
+	}
+}
+
+// `emitIndexDocExamples` writes the examples of an API object and of
+// its fields, if it has any, under a heading of its own. Fields of
+// nested objects are included, by their path (e.g., `spec.replicas`).
+func (ao *apiObject) emitIndexDocExamples(m *indentWriter) {
+	fields := []exampleField{}
+	ao.collectExamples(nil, map[*apiObject]bool{}, &fields)
+	if !ao.hasExample && len(fields) == 0 {
+		return
+	}
+
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf("### %s examples (`k.%s`)", ao.displayName(), ao.path()))
+	if ao.hasExample {
+		m.writeLine("")
+		writeIndexDocExample(m, ao.example)
+	}
+	for _, field := range fields {
+		m.writeLine("")
+		m.writeLine(fmt.Sprintf("`%s`:", field.path))
+		m.writeLine("")
+		writeIndexDocExample(m, field.property.example)
+	}
+}
+
+// exampleField is a field with an example, at `path` in a top-level
+// API object.
+type exampleField struct {
+	path     string
+	property *property
+}
+
+// `collectExamples` appends the fields of an API object that have
+// examples to `fields`, recursing into the objects they `$ref`, but not
+// into those already being visited.
+func (ao *apiObject) collectExamples(
+	path []string, visiting map[*apiObject]bool, fields *[]exampleField,
+) {
+	visiting[ao] = true
+	defer delete(visiting, ao)
+
+	for _, pm := range ao.properties.sortAndFilterBlacklisted() {
+		if pm.kind != method {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), string(pm.name))
+		if pm.hasExample {
+			*fields = append(*fields, exampleField{strings.Join(fieldPath, "."), pm})
+		}
+		if child := pm.refObject(); child != nil && !visiting[child] {
+			child.collectExamples(fieldPath, visiting, fields)
+		}
+	}
+}
+
+func writeIndexDocExample(m *indentWriter, example interface{}) {
+	text, truncated := kubespec.FormatExample(example)
+	m.writeLine("```json")
+	for _, line := range strings.Split(text, "\n") {
+		m.writeLine(line)
+	}
+	m.writeLine("```")
+	if truncated {
+		m.writeLine("")
+		m.writeLine("_Truncated; see the spec for the whole example._")
+	}
+}
+
+// `summarize` returns the first sentence of a description (see
+// `firstSentence`), escaped for a Markdown table cell.
+func summarize(description comments) string {
+	text := firstSentence(strings.Join(description, "\n"))
+	return strings.Replace(text, "|", `\|`, -1)
+}
@@ -0,0 +1,93 @@
+package ksonnet
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEmitIndexDoc(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	text, err := EmitIndexDoc(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit index doc:\n%v", err)
+	}
+	doc := string(text)
+
+	expected := []string{
+		`local k = import "k.libsonnet";`,
+		"## batch\n\nVersions: `batch/v1`, `batch/v2alpha1`\n",
+		"| Job | `k.batch.v1.job` | `k.job` | Job represents the configuration of a single job. |",
+		"| Deployment | `k.apps.v1beta1.deployment` | `k.deployment` | **REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta1` `Deployment`; use `apps/v1` instead.** Deployment enables declarative updates for Pods and ReplicaSets. |",
+	}
+	for _, line := range expected {
+		if !strings.Contains(doc, line) {
+			t.Errorf("Expected index doc to contain '%s':\n%s", line, doc)
+		}
+	}
+
+	// Only top-level kinds are listed, and each is in the library.
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	kinds := map[string]bool{}
+	for _, kind := range index.Kinds {
+		kinds[kind.Path] = true
+	}
+	paths := regexp.MustCompile("\n\\| [A-Za-z]+ \\| `k\\.([^`]+)`").FindAllStringSubmatch(doc, -1)
+	if len(paths) != len(kinds) {
+		t.Errorf("Expected %d kinds in index doc, got %d", len(kinds), len(paths))
+	}
+	for _, path := range paths {
+		if !kinds[path[1]] {
+			t.Errorf("Expected '%s' in index doc to be a kind in the library", path[1])
+		}
+	}
+
+	again, err := EmitIndexDoc(spec, Options{})
+	if err != nil || string(again) != doc {
+		t.Errorf("Expected index doc to be the same from run to run")
+	}
+}
+
+func TestEmitIndexDocExamples(t *testing.T) {
+	text, err := EmitIndexDoc(loadTestSpec(t, "testdata/examples.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit index doc:\n%v", err)
+	}
+	doc := string(text)
+
+	expected := []string{
+		// Deeply nested examples are elided.
+		"### Service examples (`k.core.v1.service`)\n\n```json\n{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"Service\",\n  \"metadata\": {\n    \"labels\": {\n      \"app\": {…}\n    },\n    \"name\": \"web\"\n  }\n}\n```\n\n_Truncated; see the spec for the whole example._\n",
+		// Fields of nested objects are listed by their path.
+		"`spec.ports`:\n\n```json\n[\n  80,\n  443\n]\n```\n",
+		"`spec.clusterIP`:\n\n```json\nnull\n```\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(doc, line) {
+			t.Errorf("Expected index doc to contain '%s':\n%s", line, doc)
+		}
+	}
+
+	// Kinds without examples get no heading.
+	if strings.Count(doc, "examples (`k.") != 1 {
+		t.Errorf("Expected examples of one kind:\n%s", doc)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := map[string]string{
+		"Pod is a collection of containers. It runs on a host.": "Pod is a collection of containers.",
+		"A | B":                     `A \| B`,
+		"Spans\nlines. And more.":   "Spans lines.",
+		"Version 1.2 of something.": "Version 1.2 of something.",
+		"":                          "",
+	}
+	for description, expected := range tests {
+		if actual := summarize(newComments(description)); actual != expected {
+			t.Errorf("Expected summary of '%s' to be '%s', got '%s'", description, expected, actual)
+		}
+	}
+}
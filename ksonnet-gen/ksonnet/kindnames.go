@@ -0,0 +1,243 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// CollisionPolicy decides how the outputs name a kind that more than
+// one API group has (e.g., `Database` in `mysql.example.com` and
+// `postgres.example.com`): its flattened alias in `k.libsonnet`, the
+// headings of its examples in `INDEX.md`, the file name of its sample,
+// and the comment of its entry in `gvkIndex.libsonnet`; see
+// `Options.KindCollisions`.
+type CollisionPolicy string
+
+const (
+	// CollisionsQualifyOnConflict gives one group of a kind the
+	// unqualified name (the group `kubeversion.PreferredGroup` names,
+	// or else the first in alphabetical order), and qualifies the
+	// others with their group's short name, e.g., `postgresDatabase`.
+	// The zero value of `CollisionPolicy` is the same.
+	CollisionsQualifyOnConflict CollisionPolicy = "qualify-on-conflict"
+
+	// CollisionsQualifyAlways qualifies the name of every kind with its
+	// group's short name, e.g., `mysqlDatabase` and `appsDeployment`,
+	// so that no name changes when another group adds the same kind.
+	CollisionsQualifyAlways CollisionPolicy = "qualify-always"
+
+	// CollisionsError fails generation if any kind is in more than one
+	// group, naming each of them.
+	CollisionsError CollisionPolicy = "error"
+)
+
+// CollisionPolicies lists the valid collision policies.
+var CollisionPolicies = []CollisionPolicy{
+	CollisionsQualifyOnConflict, CollisionsQualifyAlways, CollisionsError,
+}
+
+// ParseCollisionPolicy returns the collision policy called `name`.
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	names := []string{}
+	for _, policy := range CollisionPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+		names = append(names, string(policy))
+	}
+	return "", fmt.Errorf(
+		"Unknown collision policy '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// `kindName` is the name a top-level kind of a group goes by in every
+// output, as `Options.KindCollisions` decided it: `name` is its
+// flattened alias (e.g., `postgresDatabase`), which points at
+// `object`, the most stable version of the kind in the group, and
+// `qualified` is set if the name has the group's short name.
+type kindName struct {
+	kind      kubespec.ObjectKind
+	group     *group
+	name      string
+	qualified bool
+	object    *apiObject
+}
+
+// `kindNameKey` identifies a kind of a group in `root.kindNames`.
+type kindNameKey struct {
+	group kubespec.GroupName
+	kind  kubespec.ObjectKind
+}
+
+// `kindNameOf` returns the name of the kind of a top-level API object
+// in its group, or nil if it isn't top-level.
+func (root *root) kindNameOf(ao *apiObject) *kindName {
+	if !ao.isTopLevel {
+		return nil
+	}
+	return root.kindNames[kindNameKey{ao.parent.parent.name, ao.name}]
+}
+
+// `displayName` is what `INDEX.md` and the samples call an API object:
+// its kind (e.g., `Database`), or, if `Options.KindCollisions`
+// qualified its name, that (e.g., `postgresDatabase`).
+func (ao *apiObject) displayName() string {
+	if name := ao.root().kindNameOf(ao); name != nil && name.qualified {
+		return name.name
+	}
+	return string(ao.name)
+}
+
+// `newKindNames` decides the name of every top-level kind of every
+// group, once, against the whole set of kinds, so that every output
+// agrees on them; see `CollisionPolicy`. With `CollisionsError`, it
+// returns an error for each kind that's in more than one group. Each
+// collision is logged with what was chosen. Kinds are decided in
+// sorted order, so the names, and the log, only depend on the spec.
+func (root *root) newKindNames() (map[kindNameKey]*kindName, []error) {
+	k8sVersion := root.spec.Info.Version
+
+	// For each kind, the most stable version of it in each group.
+	byKind := map[kubespec.ObjectKind]map[kubespec.GroupName]*apiObject{}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				if !ao.isTopLevel {
+					continue
+				}
+				groups, ok := byKind[ao.name]
+				if !ok {
+					groups = map[kubespec.GroupName]*apiObject{}
+					byKind[ao.name] = groups
+				}
+				current, ok := groups[group.name]
+				if !ok || moreStableVersion(va.version, current.parent.version) {
+					groups[group.name] = ao
+				}
+			}
+		}
+	}
+
+	kinds := []string{}
+	for kind := range byKind {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	names, errors := map[kindNameKey]*kindName{}, []error{}
+	for _, k := range kinds {
+		kind := kubespec.ObjectKind(k)
+		groups := byKind[kind]
+		groupNames := []string{}
+		for name := range groups {
+			groupNames = append(groupNames, string(name))
+		}
+		sort.Strings(groupNames)
+
+		if root.opts.KindCollisions == CollisionsError && len(groupNames) > 1 {
+			apiGroups := []string{}
+			for _, name := range groupNames {
+				apiGroup := string(groups[kubespec.GroupName(name)].parent.parent.apiGroup)
+				if apiGroup == "" {
+					apiGroup = "core"
+				}
+				apiGroups = append(apiGroups, apiGroup)
+			}
+			errors = append(errors, kubespec.Categorize(ErrUnsupportedSchema, fmt.Errorf(
+				"Kind '%s' is in more than one API group (%s), which KindCollisions '%s' doesn't allow",
+				kind, strings.Join(apiGroups, ", "), CollisionsError)))
+			continue
+		}
+
+		chosen := kubespec.GroupName(groupNames[0])
+		preferred, ok := root.kubeVersions.PreferredGroup(k8sVersion, kind)
+		if _, exists := groups[preferred]; ok && exists {
+			chosen = preferred
+		}
+
+		// Qualified names are the group's short name and the kind, unless
+		// two groups have the same short name, whose names are qualified
+		// with their whole namespace.
+		id := string(root.naming().RewriteAsIdentifier(k8sVersion, kind))
+		decided := []*kindName{}
+		short := map[string]int{}
+		for _, name := range groupNames {
+			group := kubespec.GroupName(name)
+			qualified := root.opts.KindCollisions == CollisionsQualifyAlways || group != chosen
+			decision := &kindName{
+				kind: kind, group: groups[group].parent.parent,
+				name: id, qualified: qualified, object: groups[group],
+			}
+			if qualified {
+				decision.name = root.shortQualifiedAlias(decision.group, id)
+				short[decision.name]++
+			}
+			decided = append(decided, decision)
+		}
+		qualified := []string{}
+		for _, decision := range decided {
+			if decision.qualified && short[decision.name] > 1 {
+				decision.name = qualifiedAlias(decision.group, id)
+			}
+			names[kindNameKey{decision.group.name, kind}] = decision
+			if decision.qualified && len(groupNames) > 1 {
+				qualified = append(qualified, fmt.Sprintf("%s=%s", decision.name, decision.object.path()))
+			}
+		}
+
+		if len(groupNames) > 1 {
+			root.logger().Log(
+				"alias collision", "kind", kind, "alias", id,
+				"chosen", groups[chosen].path(), "preferred", ok,
+				"policy", root.collisionPolicy(),
+				"qualified", strings.Join(qualified, ","))
+		}
+	}
+	return names, errors
+}
+
+// `collisionPolicy` is `Options.KindCollisions`, or its default.
+func (root *root) collisionPolicy() CollisionPolicy {
+	if root.opts.KindCollisions == "" {
+		return CollisionsQualifyOnConflict
+	}
+	return root.opts.KindCollisions
+}
+
+// `shortQualifiedAlias` returns the name of the kind `id` qualified
+// with the short name of its group, the first label of its API group
+// (or its name, for `core`), e.g., `postgresDatabase` for `database`
+// in `postgres.example.com`, or `eventsEvent` for `event` in
+// `events.k8s.io`. The label is sanitized like the rest of the API
+// group, e.g., `myAppWidget` for `widget` in `my-app.io`.
+func (root *root) shortQualifiedAlias(group *group, id string) string {
+	k8sVersion := root.spec.Info.Version
+	label := string(group.name)
+	if group.apiGroup != "" {
+		label = strings.SplitN(string(group.apiGroup), ".", 2)[0]
+	}
+	short := string(root.naming().RewriteAsIdentifier(k8sVersion, kubespec.GroupName(kubespec.SanitizeGroupID(label))))
+	return short + strings.ToUpper(id[:1]) + id[1:]
+}
+
+// `kindNameIndex` returns the names `newKindNames` decided, as the
+// symbol index records them, in order of name.
+func (root *root) kindNameIndex() []*KindName {
+	index := []*KindName{}
+	for _, decision := range root.kindNames {
+		index = append(index, &KindName{
+			Kind:      string(decision.kind),
+			Group:     string(decision.group.apiGroup),
+			Name:      decision.name,
+			Qualified: decision.qualified,
+			Path:      decision.object.path(),
+		})
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].Name < index[j].Name
+	})
+	return index
+}
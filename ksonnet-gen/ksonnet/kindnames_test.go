@@ -0,0 +1,221 @@
+package ksonnet
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// databasesSpec returns the spec of `crd.json` with the `Database`s of
+// `databases.yaml`, one in `mysql.example.com`, and one in
+// `postgres.example.com`.
+func databasesSpec(t *testing.T) (*kubespec.APISpec, Options) {
+	text, err := ioutil.ReadFile("testdata/databases.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	crds, err := kubespec.ReadCRDs("databases.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	return spec, Options{Parser: &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}}}
+}
+
+// kindNameOutputs are the outputs that name kinds, as
+// `TestKindCollisions` checks them.
+type kindNameOutputs struct {
+	aliases, indexDoc, gvkIndex string
+	samples                     []*Sample
+	index                       *SymbolIndex
+}
+
+func emitKindNameOutputs(t *testing.T, spec *kubespec.APISpec, opts Options) *kindNameOutputs {
+	aliases, err := EmitAliases(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	indexDoc, err := EmitIndexDoc(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit index doc:\n%v", err)
+	}
+	gvkIndex, err := EmitGVKIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit gvk index:\n%v", err)
+	}
+	samples, _, err := EmitSamples(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit samples:\n%v", err)
+	}
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	return &kindNameOutputs{string(aliases), string(indexDoc), string(gvkIndex), samples, index}
+}
+
+func TestKindCollisions(t *testing.T) {
+	spec, opts := databasesSpec(t)
+	for _, test := range []struct {
+		policy  CollisionPolicy
+		names   []KindName
+		lines   []string
+		missing []string
+		samples []string
+	}{
+		{
+			policy: "",
+			names: []KindName{
+				{Kind: "CronTab", Group: "", Name: "cronTab", Path: "stable.v1.cronTab"},
+				{Kind: "Database", Group: "mysql.example.com", Name: "database", Path: "mysqlExampleCom.v1.database"},
+				{Kind: "Database", Group: "postgres.example.com", Name: "postgresDatabase", Qualified: true, Path: "postgresExampleCom.v1.database"},
+			},
+			lines: []string{
+				"database:: k8s.mysqlExampleCom.v1.database,",
+				"postgresDatabase:: k8s.postgresExampleCom.v1.database,",
+				"### Database examples (`k.mysqlExampleCom.v1.database`)",
+				"### postgresDatabase examples (`k.postgresExampleCom.v1.database`)",
+				"  // Named `postgresDatabase` in `k.libsonnet`, `INDEX.md`, and the samples.\n  \"postgres.example.com/v1:Database\"",
+			},
+			missing: []string{"mysqlDatabase", "Named `database`"},
+			samples: []string{
+				"mysqlExampleCom/v1/database",
+				"postgresExampleCom/v1/postgresDatabase",
+				"stable/v1/cronTab",
+			},
+		},
+		{
+			policy: CollisionsQualifyAlways,
+			names: []KindName{
+				{Kind: "Database", Group: "mysql.example.com", Name: "mysqlDatabase", Qualified: true, Path: "mysqlExampleCom.v1.database"},
+				{Kind: "Database", Group: "postgres.example.com", Name: "postgresDatabase", Qualified: true, Path: "postgresExampleCom.v1.database"},
+				{Kind: "CronTab", Group: "", Name: "stableCronTab", Qualified: true, Path: "stable.v1.cronTab"},
+			},
+			lines: []string{
+				"mysqlDatabase:: k8s.mysqlExampleCom.v1.database,",
+				"postgresDatabase:: k8s.postgresExampleCom.v1.database,",
+				"stableCronTab:: k8s.stable.v1.cronTab,",
+				"### mysqlDatabase examples (`k.mysqlExampleCom.v1.database`)",
+				"### postgresDatabase examples (`k.postgresExampleCom.v1.database`)",
+				"  // Named `mysqlDatabase` in `k.libsonnet`, `INDEX.md`, and the samples.\n  \"mysql.example.com/v1:Database\"",
+				"  // Named `postgresDatabase` in `k.libsonnet`, `INDEX.md`, and the samples.\n  \"postgres.example.com/v1:Database\"",
+			},
+			missing: []string{" database:: ", "### Database examples"},
+			samples: []string{
+				"mysqlExampleCom/v1/mysqlDatabase",
+				"postgresExampleCom/v1/postgresDatabase",
+				"stable/v1/stableCronTab",
+			},
+		},
+	} {
+		opts.KindCollisions = test.policy
+		outputs := emitKindNameOutputs(t, spec, opts)
+		all := outputs.aliases + outputs.indexDoc + outputs.gvkIndex
+		for _, line := range test.lines {
+			if !strings.Contains(all, line) {
+				t.Errorf("Expected the outputs with '%s' to have:\n%s\ngot:\n%s", test.policy, line, all)
+			}
+		}
+		for _, text := range test.missing {
+			if strings.Contains(all, text) {
+				t.Errorf("Expected the outputs with '%s' not to have '%s', got:\n%s", test.policy, text, all)
+			}
+		}
+		samples := []string{}
+		for _, sample := range outputs.samples {
+			samples = append(samples, sample.Path)
+		}
+		if !reflect.DeepEqual(samples, test.samples) {
+			t.Errorf("Expected the samples with '%s' to be %v, got %v", test.policy, test.samples, samples)
+		}
+
+		// The symbol index records the same names, and aliases.
+		expected := map[string]KindName{}
+		for _, name := range test.names {
+			expected[name.Name] = name
+		}
+		if len(outputs.index.KindNames) != len(test.names) {
+			t.Errorf("Expected %d kind names with '%s', got %d", len(test.names), test.policy, len(outputs.index.KindNames))
+		}
+		for _, name := range outputs.index.KindNames {
+			if *name != expected[name.Name] {
+				t.Errorf("Expected kind name %#v with '%s', got %#v", expected[name.Name], test.policy, *name)
+			}
+			if outputs.index.Aliases[name.Name] != name.Path {
+				t.Errorf("Expected alias '%s' to be '%s', got '%s'", name.Name, name.Path, outputs.index.Aliases[name.Name])
+			}
+		}
+		policy := test.policy
+		if policy == "" {
+			policy = CollisionsQualifyOnConflict
+		}
+		if outputs.index.KindCollisions != policy {
+			t.Errorf("Expected the symbol index to record '%s', got '%s'", policy, outputs.index.KindCollisions)
+		}
+	}
+}
+
+func TestKindCollisionsError(t *testing.T) {
+	spec, opts := databasesSpec(t)
+	opts.KindCollisions = CollisionsError
+	const expected = "Kind 'Database' is in more than one API group (mysql.example.com, postgres.example.com), which KindCollisions 'error' doesn't allow"
+
+	_, aliasesErr := EmitAliases(spec, opts)
+	_, indexDocErr := EmitIndexDoc(spec, opts)
+	_, gvkIndexErr := EmitGVKIndex(spec, opts)
+	_, _, samplesErr := EmitSamples(spec, opts)
+	_, indexErr := BuildSymbolIndex(spec, opts)
+	for name, err := range map[string]error{
+		AliasesFile: aliasesErr, IndexDocFile: indexDocErr, GVKIndexFile: gvkIndexErr,
+		"samples": samplesErr, "symbol index": indexErr,
+	} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %s to fail with:\n%s\ngot:\n%v", name, expected, err)
+		}
+	}
+
+	// Kinds in one group each are fine.
+	if _, err := EmitAliases(loadTestSpec(t, "testdata/crd.json"), opts); err != nil {
+		t.Errorf("Expected no collisions, got:\n%v", err)
+	}
+}
+
+func TestShortQualifiedAliasCollision(t *testing.T) {
+	// `db.mysql.io` and `db.postgres.io` have the same short name, so
+	// their `Database`s are qualified with their namespaces.
+	text, err := ioutil.ReadFile("testdata/databases.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	text = []byte(strings.NewReplacer(
+		"mysql.example.com", "db.mysql.io", "postgres.example.com", "db.postgres.io").Replace(string(text)))
+	crds, err := kubespec.ReadCRDs("databases.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/crd.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	_, opts := databasesSpec(t)
+	opts.KindCollisions = CollisionsQualifyAlways
+	aliases, err := EmitAliases(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	for _, line := range []string{
+		"dbMysqlIoDatabase:: k8s.dbMysqlIo.v1.database,",
+		"dbPostgresIoDatabase:: k8s.dbPostgresIo.v1.database,",
+		"stableCronTab:: k8s.stable.v1.cronTab,",
+	} {
+		if !strings.Contains(string(aliases), line) {
+			t.Errorf("Expected the aliases to have:\n%s\ngot:\n%s", line, aliases)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// EmitLabels takes a swagger API specification, and returns the text
+// of `labels.libsonnet`, which exposes the well-known label and
+// annotation keys of its version of Kubernetes (see
+// `kubeversion.WellKnownLabels`) as constants, e.g.,
+// `labels.appName`, along with helpers that build sets of labels,
+// e.g., `recommendedLabels(name, version="1.0")`. It doesn't depend on
+// `k8s.libsonnet`.
+func EmitLabels(spec *kubespec.APISpec, opts Options) ([]byte, error) {
+	k8sVersion := spec.Info.Version
+	labels := opts.kubeVersions().WellKnownLabels(k8sVersion)
+	annotations := opts.kubeVersions().WellKnownAnnotations(k8sVersion)
+	if labels == nil && annotations == nil {
+		return nil, fmt.Errorf(
+			"No well-known labels for Kubernetes version '%s'", k8sVersion)
+	}
+
+	m := newIndentWriter()
+	m.omitComments = opts.OmitComments
+	emitTemplatedHeader(m, spec, opts, LabelsFile, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", k8sVersion))
+	})
+	m.writeLine("")
+	m.writeLine("{")
+	m.indent()
+
+	m.writeLine("// Well-known label keys, e.g., for `metadata.labels` and node selectors.")
+	emitKeys(m, "labels", labels)
+	m.writeLine("// Well-known annotation keys, e.g., for `metadata.annotations`.")
+	emitKeys(m, "annotations", annotations)
+
+	recommended := recommendedLabels(labels)
+	if len(recommended) > 0 {
+		m.writeLine("")
+		m.writeLine("// `recommendedLabels` returns the labels Kubernetes recommends")
+		m.writeLine("// giving every object, leaving out those whose values are null.")
+		emitLabelsHelper(m, recommendedLabelsName, recommended)
+
+		selector := []kubeversion.WellKnownKey{}
+		for _, key := range recommended {
+			if key.Selector {
+				selector = append(selector, key)
+			}
+		}
+		m.writeLine("// `selectorLabels` returns the subset of the recommended labels")
+		m.writeLine("// that don't change over the life of an application, and so are")
+		m.writeLine("// safe to use in selectors, leaving out those whose values are")
+		m.writeLine("// null.")
+		emitLabelsHelper(m, selectorLabelsName, selector)
+	}
+
+	m.dedent()
+	m.writeLine("}")
+	return m.bytes()
+}
+
+const (
+	recommendedLabelsName = "recommendedLabels"
+	selectorLabelsName    = "selectorLabels"
+)
+
+// `emitKeys` emits a namespace of constants for `keys`.
+func emitKeys(m *indentWriter, namespace string, keys []kubeversion.WellKnownKey) {
+	m.writeLine(fmt.Sprintf("%s:: {", namespace))
+	m.indent()
+	for _, key := range keys {
+		if key.Description != "" {
+			m.writeLine("// " + key.Description)
+		}
+		m.writeLine(fmt.Sprintf("%s:: \"%s\",", key.Name, key.Key))
+	}
+	m.dedent()
+	m.writeLine("},")
+}
+
+// `recommendedLabels` returns the labels that `recommendedLabels`
+// sets, in the order of its parameters.
+func recommendedLabels(labels []kubeversion.WellKnownKey) []kubeversion.WellKnownKey {
+	recommended := []kubeversion.WellKnownKey{}
+	for _, key := range labels {
+		if key.Param != "" {
+			recommended = append(recommended, key)
+		}
+	}
+	return recommended
+}
+
+// `emitLabelsHelper` emits a function that takes a parameter for each
+// of `keys`, and returns the labels whose values aren't null.
+func emitLabelsHelper(m *indentWriter, name string, keys []kubeversion.WellKnownKey) {
+	m.writeLine(fmt.Sprintf("%s(%s):: std.prune({", name, strings.Join(labelParams(keys), ", ")))
+	m.indent()
+	for _, key := range keys {
+		m.writeLine(fmt.Sprintf("\"%s\": %s,", key.Key, key.Param))
+	}
+	m.dedent()
+	m.writeLine("}),")
+}
+
+// `labelParams` returns the parameters of a labels helper, with
+// optional parameters defaulting to null.
+func labelParams(keys []kubeversion.WellKnownKey) []string {
+	params := []string{}
+	for _, key := range keys {
+		if key.Required {
+			params = append(params, key.Param)
+		} else {
+			params = append(params, key.Param+"=null")
+		}
+	}
+	return params
+}
+
+// `emitLabelsTest` emits a Jsonnet test of `labels.libsonnet`, which
+// checks every constant, and evaluates the helpers with only their
+// required parameters and with all of them. The test expects to live
+// in a directory next to `labels.libsonnet`. The keys are those of
+// `data` for the version.
+func emitLabelsTest(m *indentWriter, data *kubeversion.Data, k8sVersion string) {
+	m.writeLine("// AUTOGENERATED tests for `labels.libsonnet`. DO NOT MODIFY.")
+	m.writeLine("local l = import \"../labels.libsonnet\";")
+	m.writeLine("")
+	m.writeLine("{")
+	m.indent()
+
+	labels := data.WellKnownLabels(k8sVersion)
+	for _, namespace := range []struct {
+		name string
+		keys []kubeversion.WellKnownKey
+	}{
+		{"labels", labels},
+		{"annotations", data.WellKnownAnnotations(k8sVersion)},
+	} {
+		for _, key := range namespace.keys {
+			m.writeLine(fmt.Sprintf(
+				"\"%s.%s\": std.assertEqual(l.%s.%s, \"%s\"),",
+				namespace.name, key.Name, namespace.name, key.Name, key.Key))
+		}
+	}
+
+	recommended := recommendedLabels(labels)
+	selector := []kubeversion.WellKnownKey{}
+	for _, key := range recommended {
+		if key.Selector {
+			selector = append(selector, key)
+		}
+	}
+	for _, helper := range []struct {
+		name string
+		keys []kubeversion.WellKnownKey
+	}{
+		{recommendedLabelsName, recommended},
+		{selectorLabelsName, selector},
+	} {
+		if len(helper.keys) == 0 {
+			continue
+		}
+		required, requiredLabels := []string{}, []string{}
+		all, allLabels := []string{}, []string{}
+		for _, key := range helper.keys {
+			if key.Required {
+				required = append(required, fmt.Sprintf("\"%s\"", key.Param))
+				requiredLabels = append(requiredLabels, fmt.Sprintf("\"%s\": \"%s\"", key.Key, key.Param))
+			}
+			all = append(all, fmt.Sprintf("%s=\"%s\"", key.Param, key.Param))
+			allLabels = append(allLabels, fmt.Sprintf("\"%s\": \"%s\"", key.Key, key.Param))
+		}
+		m.writeLine(fmt.Sprintf(
+			"%s: std.assertEqual(l.%s(%s), {%s}),",
+			helper.name, helper.name, strings.Join(required, ", "), strings.Join(requiredLabels, ", ")))
+		m.writeLine(fmt.Sprintf(
+			"%sAll: std.assertEqual(l.%s(%s), {%s}),",
+			helper.name, helper.name, strings.Join(all, ", "), strings.Join(allLabels, ", ")))
+	}
+
+	m.dedent()
+	m.writeLine("}")
+}
@@ -0,0 +1,78 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmitLabels(t *testing.T) {
+	text, err := EmitLabels(loadTestSpec(t, "testdata/collisions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit labels:\n%v", err)
+	}
+	labels := string(text)
+
+	for _, expected := range []string{
+		"  labels:: {\n",
+		`    appName:: "app.kubernetes.io/name",`,
+		`    hostname:: "kubernetes.io/hostname",`,
+		`    betaOs:: "beta.kubernetes.io/os",`,
+		"  annotations:: {\n",
+		`    lastAppliedConfiguration:: "kubectl.kubernetes.io/last-applied-configuration",`,
+		"  recommendedLabels(name, instance=null, version=null, component=null, partOf=null, managedBy=null):: std.prune({",
+		`    "app.kubernetes.io/part-of": partOf,`,
+		"  selectorLabels(name, instance=null):: std.prune({",
+	} {
+		if !strings.Contains(labels, expected) {
+			t.Errorf("Expected labels to contain:\n%s\ngot:\n%s", expected, labels)
+		}
+	}
+	if strings.Contains(selectorLabelsText(labels), "version") {
+		t.Errorf("Expected 'selectorLabels' to exclude the version:\n%s", labels)
+	}
+
+	// The keys come from `kubeversion`, and vary by version.
+	const initContainers = `betaInitContainers:: "pod.beta.kubernetes.io/init-containers",`
+	if strings.Contains(labels, initContainers) {
+		t.Errorf("Expected no init containers annotation in v1.9.0")
+	}
+	text, err = EmitLabels(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit labels:\n%v", err)
+	}
+	if !strings.Contains(string(text), initContainers) {
+		t.Errorf("Expected init containers annotation in v1.7.0")
+	}
+
+	spec := &kubespec.APISpec{Info: &kubespec.SchemaInfo{Version: "v0.1.0"}}
+	if _, err := EmitLabels(spec, Options{}); err == nil {
+		t.Errorf("Expected an error for an unrecognized version")
+	}
+}
+
+func selectorLabelsText(labels string) string {
+	start := strings.Index(labels, "selectorLabels(")
+	return labels[start : start+strings.Index(labels[start:], "}),")]
+}
+
+func TestEmitLabelsTest(t *testing.T) {
+	tests, err := EmitTests(loadTestSpec(t, "testdata/collisions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	test := string(tests["labels.jsonnet"])
+
+	for _, expected := range []string{
+		`local l = import "../labels.libsonnet";`,
+		`"labels.appName": std.assertEqual(l.labels.appName, "app.kubernetes.io/name"),`,
+		`"annotations.changeCause": std.assertEqual(l.annotations.changeCause, "kubernetes.io/change-cause"),`,
+		`recommendedLabels: std.assertEqual(l.recommendedLabels("name"), {"app.kubernetes.io/name": "name"}),`,
+		`selectorLabelsAll: std.assertEqual(l.selectorLabels(name="name", instance="instance"), {"app.kubernetes.io/name": "name", "app.kubernetes.io/instance": "instance"}),`,
+	} {
+		if !strings.Contains(test, expected) {
+			t.Errorf("Expected 'labels.jsonnet' to contain:\n%s\ngot:\n%s", expected, test)
+		}
+	}
+}
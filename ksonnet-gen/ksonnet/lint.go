@@ -0,0 +1,509 @@
+package ksonnet
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// LintSeverity is how badly a `LintFinding` affects the library
+// generated from a spec: `LintInfo` findings are worth knowing about,
+// `LintWarning` ones make for weak bindings (e.g., a setter that takes
+// arbitrary JSON), and `LintError` ones for broken ones (e.g., a setter
+// whose name doesn't parse).
+type LintSeverity string
+
+const (
+	LintInfo    LintSeverity = "info"
+	LintWarning LintSeverity = "warning"
+	LintError   LintSeverity = "error"
+)
+
+var lintSeverityRanks = map[LintSeverity]int{LintInfo: 1, LintWarning: 2, LintError: 3}
+
+// ParseLintSeverity returns the severity named `name`: `info`,
+// `warning`, or `error`.
+func ParseLintSeverity(name string) (LintSeverity, error) {
+	if _, ok := lintSeverityRanks[LintSeverity(name)]; !ok {
+		return "", fmt.Errorf("Unknown lint severity '%s'; must be 'info', 'warning', or 'error'", name)
+	}
+	return LintSeverity(name), nil
+}
+
+// AtLeast reports whether the severity is `other` or worse, e.g., an
+// error is at least a warning.
+func (ls LintSeverity) AtLeast(other LintSeverity) bool {
+	return lintSeverityRanks[ls] >= lintSeverityRanks[other]
+}
+
+// LintFinding is a pattern in a spec that `LintRule` `Rule` found: in
+// the definition `Definition`, at the property `Path` of it (e.g.,
+// `spec.template`, for a property of an inline object; empty for the
+// definition itself), with a `Message` that says what's wrong with it.
+type LintFinding struct {
+	Rule       string                  `json:"rule"`
+	Severity   LintSeverity            `json:"severity"`
+	Definition kubespec.DefinitionName `json:"definition"`
+	Path       string                  `json:"path,omitempty"`
+	Message    string                  `json:"message"`
+}
+
+// LintRule is a check of a spec for a pattern that makes for poor
+// bindings: `Check` returns what it finds, whose `Rule` and `Severity`
+// `Lint` fills in with the rule's `ID` (e.g., `untyped-property`) and
+// `Severity`.
+type LintRule struct {
+	ID          string
+	Severity    LintSeverity
+	Description string
+	Check       func(s *kubespec.APISpec) []*LintFinding
+}
+
+// LintRules are the built-in rules of `Lint`, in order of ID. Callers
+// can pass their own alongside (or instead of) them.
+var LintRules = []*LintRule{
+	{
+		ID:          "array-without-items",
+		Severity:    LintWarning,
+		Description: "An array doesn't declare the schema of its items, so its setter and appender take arbitrary JSON.",
+		Check:       lintArraysWithoutItems,
+	},
+	{
+		ID:          "dangling-ref",
+		Severity:    LintError,
+		Description: "A property refers to a definition that isn't in the spec, so it gets an opaque setter, or, with --strict-refs, fails generation.",
+		Check:       lintDanglingRefs,
+	},
+	{
+		ID:          "deep-inline-schema",
+		Severity:    LintWarning,
+		Description: "An inline object is nested deeper than --max-inline-depth, so it gets a plain setter rather than a mixin.",
+		Check:       lintDeepInlineSchemas,
+	},
+	{
+		ID:          "identifier-collision",
+		Severity:    LintWarning,
+		Description: "Properties of an object have identifiers that differ only by case, so all but one get a numeric suffix (e.g., `replicas2`).",
+		Check:       lintIdentifierCollisions,
+	},
+	{
+		ID:          "invalid-identifier",
+		Severity:    LintError,
+		Description: "A property's name isn't a Jsonnet identifier (e.g., `rolling-update`), so its setter doesn't parse.",
+		Check:       lintInvalidIdentifiers,
+	},
+	{
+		ID:          "large-inline-schema",
+		Severity:    LintWarning,
+		Description: "An inline object has so many properties, nested or not, that its mixins dwarf the rest of its definition.",
+		Check:       lintLargeInlineSchemas,
+	},
+	{
+		ID:          "missing-description",
+		Severity:    LintWarning,
+		Description: "A definition has no description, so its namespace has no comment.",
+		Check:       lintMissingDescriptions,
+	},
+	{
+		ID:          "missing-property-description",
+		Severity:    LintInfo,
+		Description: "A property has no description, so its setter has no comment.",
+		Check:       lintMissingPropertyDescriptions,
+	},
+	{
+		ID:          "no-required-fields",
+		Severity:    LintInfo,
+		Description: "An object with properties requires none of them, so nothing says which setters a valid object needs.",
+		Check:       lintNoRequiredFields,
+	},
+	{
+		ID:          "required-not-declared",
+		Severity:    LintWarning,
+		Description: "An object requires a field that it doesn't declare, so the library has no setter for it.",
+		Check:       lintRequiredNotDeclared,
+	},
+	{
+		ID:          "reserved-identifier",
+		Severity:    LintWarning,
+		Description: "A property's identifier is reserved in Jsonnet (e.g., `std`, or `local`), so its setter gets a trailing underscore.",
+		Check:       lintReservedIdentifiers,
+	},
+	{
+		ID:          "untyped-property",
+		Severity:    LintWarning,
+		Description: "A property has no type, or is an object with neither properties nor additional properties, so its setter takes arbitrary JSON.",
+		Check:       lintUntypedProperties,
+	},
+}
+
+// lintLargeInlineSchema is the number of properties at every depth of
+// an inline object from which `large-inline-schema` reports it.
+const lintLargeInlineSchema = 100
+
+// lintIdentifierPattern matches Jsonnet identifiers.
+var lintIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Lint checks `s` with each of `rules`, and returns what they find,
+// sorted by definition, then path, then rule.
+func Lint(s *kubespec.APISpec, rules []*LintRule) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, rule := range rules {
+		for _, finding := range rule.Check(s) {
+			finding.Rule = rule.ID
+			finding.Severity = rule.Severity
+			findings = append(findings, finding)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Definition != findings[j].Definition {
+			return findings[i].Definition < findings[j].Definition
+		} else if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return findings
+}
+
+// `lintObject` is an object whose schema a spec declares, which rules
+// check: a definition, whose `path` is empty, or an inline object of
+// one, at the property `path` of it, nested `depth` levels deep
+// (counting the properties of the definition as the first level, as
+// `kubespec.APISpec.WithInlineDefinitions` does). It's `embedded` if
+// it's a Kubernetes object, i.e., a kind or an embedded resource.
+type lintObject struct {
+	definition  kubespec.DefinitionName
+	path        string
+	depth       int
+	embedded    bool
+	description string
+	required    []string
+	properties  kubespec.Properties
+}
+
+// `propertyPath` returns the path of the property `name` of the
+// object, e.g., `spec.replicas` for `replicas` of `spec`.
+func (lo *lintObject) propertyPath(name kubespec.PropertyName) string {
+	if lo.path == "" {
+		return string(name)
+	}
+	return lo.path + "." + string(name)
+}
+
+// `sortedNames` returns the names of the object's properties, sorted.
+func (lo *lintObject) sortedNames() []kubespec.PropertyName {
+	names := []kubespec.PropertyName{}
+	for name := range lo.properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// `lintObjects` returns every definition of `s` and every inline
+// object nested in one, in order of definition name, each followed by
+// the inline objects of its properties, in order of name.
+func lintObjects(s *kubespec.APISpec) []*lintObject {
+	names := []kubespec.DefinitionName{}
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	objects := []*lintObject{}
+	var walk func(object *lintObject)
+	walk = func(object *lintObject) {
+		objects = append(objects, object)
+		for _, name := range object.sortedNames() {
+			prop := object.properties[name]
+			if prop == nil || prop.Ref != nil || len(prop.Properties) == 0 {
+				continue
+			}
+			walk(&lintObject{
+				definition:  object.definition,
+				path:        object.propertyPath(name),
+				depth:       object.depth + 1,
+				embedded:    prop.EmbeddedResource,
+				description: prop.Description,
+				required:    prop.Required,
+				properties:  prop.Properties,
+			})
+		}
+	}
+	for _, name := range names {
+		def := s.Definitions[name]
+		walk(&lintObject{
+			definition:  name,
+			embedded:    s.Classify(name).IsTopLevel() || def.EmbeddedResource,
+			description: def.Description,
+			required:    def.Required,
+			properties:  def.Properties,
+		})
+	}
+	return objects
+}
+
+// `lintProperties` calls `check` with each property of each object of
+// `lintObjects`, in order of name, and returns the findings it returns.
+func lintProperties(
+	s *kubespec.APISpec,
+	check func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding,
+) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		for _, name := range object.sortedNames() {
+			if prop := object.properties[name]; prop != nil {
+				if finding := check(object, name, prop); finding != nil {
+					findings = append(findings, finding)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// `lintK8sVersion` returns the version of Kubernetes the spec is of,
+// whose naming rules identifiers follow.
+func lintK8sVersion(s *kubespec.APISpec) string {
+	if s.Info == nil {
+		return ""
+	}
+	return s.Info.Version
+}
+
+// `isResourceField` reports whether a property of a kind, or of an
+// embedded resource (see `kubespec.Property.EmbeddedResource`), is one
+// of the fields every Kubernetes object has, whose schemas don't vary.
+func (lo *lintObject) isResourceField(name kubespec.PropertyName) bool {
+	return lo.embedded && (name == "apiVersion" || name == "kind" || name == "metadata")
+}
+
+func lintMissingDescriptions(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		if object.path == "" && strings.TrimSpace(object.description) == "" {
+			findings = append(findings, &LintFinding{
+				Definition: object.definition,
+				Message:    "The definition has no description",
+			})
+		}
+	}
+	return findings
+}
+
+func lintMissingPropertyDescriptions(s *kubespec.APISpec) []*LintFinding {
+	return lintProperties(s, func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding {
+		if object.isResourceField(name) || strings.TrimSpace(prop.Description) != "" {
+			return nil
+		}
+		return &LintFinding{
+			Definition: object.definition,
+			Path:       object.propertyPath(name),
+			Message:    fmt.Sprintf("'%s' has no description", name),
+		}
+	})
+}
+
+func lintNoRequiredFields(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		if len(object.properties) == 0 || len(object.required) > 0 {
+			continue
+		}
+		message := "The definition requires none of its properties"
+		if object.path != "" {
+			message = fmt.Sprintf("The inline object '%s' requires none of its properties", object.path)
+		}
+		findings = append(findings, &LintFinding{
+			Definition: object.definition,
+			Path:       object.path,
+			Message:    message,
+		})
+	}
+	return findings
+}
+
+func lintRequiredNotDeclared(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		for _, name := range object.required {
+			if _, ok := object.properties[kubespec.PropertyName(name)]; ok {
+				continue
+			}
+			findings = append(findings, &LintFinding{
+				Definition: object.definition,
+				Path:       object.propertyPath(kubespec.PropertyName(name)),
+				Message:    fmt.Sprintf("'%s' is required, but isn't a declared property", name),
+			})
+		}
+	}
+	return findings
+}
+
+// `lintUntypedProperties` finds untyped properties (see
+// `kubespec.Property.IsUntyped`), but for those that preserve unknown
+// fields, which say that they're meant to take arbitrary JSON.
+func lintUntypedProperties(s *kubespec.APISpec) []*LintFinding {
+	return lintProperties(s, func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding {
+		if !prop.IsUntyped() || prop.PreserveUnknownFields || object.isResourceField(name) {
+			return nil
+		}
+		message := fmt.Sprintf("'%s' has no type, so its setter takes arbitrary JSON", name)
+		if prop.Type != nil {
+			message = fmt.Sprintf(
+				"'%s' is an object with neither properties nor additional properties, so its setter takes arbitrary JSON; set 'x-kubernetes-preserve-unknown-fields' if that's intended", name)
+		}
+		return &LintFinding{
+			Definition: object.definition,
+			Path:       object.propertyPath(name),
+			Message:    message,
+		}
+	})
+}
+
+func lintArraysWithoutItems(s *kubespec.APISpec) []*LintFinding {
+	return lintProperties(s, func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding {
+		if prop.Type == nil || *prop.Type != "array" || prop.Items.Ref != nil || prop.Items.Type != nil {
+			return nil
+		}
+		return &LintFinding{
+			Definition: object.definition,
+			Path:       object.propertyPath(name),
+			Message:    fmt.Sprintf("'%s' is an array that doesn't declare the schema of its items", name),
+		}
+	})
+}
+
+// `lintLargeInlineSchemas` reports the inline objects of definitions'
+// own properties that have at least `lintLargeInlineSchema` properties,
+// counting those of the objects nested in them; the objects nested in
+// them aren't reported again.
+func lintLargeInlineSchemas(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		if object.depth != 1 {
+			continue
+		}
+		if count := countInlineProperties(object.properties); count >= lintLargeInlineSchema {
+			findings = append(findings, &LintFinding{
+				Definition: object.definition,
+				Path:       object.path,
+				Message: fmt.Sprintf(
+					"The inline object '%s' has %d properties, nested or not; consider a definition of its own to refer to", object.path, count),
+			})
+		}
+	}
+	return findings
+}
+
+// `countInlineProperties` returns the number of `properties`, and of
+// the properties of the inline objects nested in them.
+func countInlineProperties(properties kubespec.Properties) int {
+	count := 0
+	for _, prop := range properties {
+		count++
+		if prop != nil && prop.Ref == nil {
+			count += countInlineProperties(prop.Properties)
+		}
+	}
+	return count
+}
+
+// `lintDeepInlineSchemas` reports the inline objects nested more than
+// `DefaultMaxInlineDepth` levels deep, which get no mixins of their own
+// by default; the objects nested in them aren't reported again.
+func lintDeepInlineSchemas(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		if object.depth != DefaultMaxInlineDepth+1 {
+			continue
+		}
+		findings = append(findings, &LintFinding{
+			Definition: object.definition,
+			Path:       object.path,
+			Message: fmt.Sprintf(
+				"The inline object '%s' is nested %d levels deep, deeper than the %d that get mixins by default, so it gets a plain setter",
+				object.path, object.depth, DefaultMaxInlineDepth),
+		})
+	}
+	return findings
+}
+
+func lintInvalidIdentifiers(s *kubespec.APISpec) []*LintFinding {
+	k8sVersion := lintK8sVersion(s)
+	return lintProperties(s, func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding {
+		if name != "" && lintIdentifierPattern.MatchString(string(jsonnet.Naming{}.RewriteAsIdentifier(k8sVersion, name))) {
+			return nil
+		}
+		return &LintFinding{
+			Definition: object.definition,
+			Path:       object.propertyPath(name),
+			Message:    fmt.Sprintf("'%s' isn't a Jsonnet identifier, so its setter won't parse", name),
+		}
+	})
+}
+
+func lintReservedIdentifiers(s *kubespec.APISpec) []*LintFinding {
+	k8sVersion := lintK8sVersion(s)
+	return lintProperties(s, func(object *lintObject, name kubespec.PropertyName, prop *kubespec.Property) *LintFinding {
+		if name == "" {
+			return nil
+		}
+		id := jsonnet.Naming{}.RewriteAsIdentifier(k8sVersion, name)
+		if !jsonnet.IsReservedIdentifier(id) {
+			return nil
+		}
+		return &LintFinding{
+			Definition: object.definition,
+			Path:       object.propertyPath(name),
+			Message: fmt.Sprintf(
+				"'%s' is reserved in Jsonnet, so its setter is named '%s'", id,
+				jsonnet.Naming{}.SanitizePropertyID(k8sVersion, name)),
+		}
+	})
+}
+
+// `lintIdentifierCollisions` reports the properties of each object
+// whose identifiers are the same as an earlier one's, ignoring case,
+// as `propertyIdentifiers` would suffix them.
+func lintIdentifierCollisions(s *kubespec.APISpec) []*LintFinding {
+	k8sVersion := lintK8sVersion(s)
+	findings := []*LintFinding{}
+	for _, object := range lintObjects(s) {
+		owners := map[string]kubespec.PropertyName{}
+		for _, name := range object.sortedNames() {
+			if name == "" {
+				continue
+			}
+			key := strings.ToLower(string(jsonnet.Naming{}.SanitizePropertyID(k8sVersion, name)))
+			owner, taken := owners[key]
+			if !taken {
+				owners[key] = name
+				continue
+			}
+			findings = append(findings, &LintFinding{
+				Definition: object.definition,
+				Path:       object.propertyPath(name),
+				Message: fmt.Sprintf(
+					"'%s' and '%s' have the same identifier, ignoring case, so one of their setters gets a numeric suffix", owner, name),
+			})
+		}
+	}
+	return findings
+}
+
+func lintDanglingRefs(s *kubespec.APISpec) []*LintFinding {
+	findings := []*LintFinding{}
+	for _, ref := range s.DanglingRefs() {
+		findings = append(findings, &LintFinding{
+			Definition: ref.From,
+			Path:       string(ref.Property),
+			Message:    fmt.Sprintf("'%s' refers to '%s', which isn't in the spec", ref.Property, ref.To),
+		})
+	}
+	return findings
+}
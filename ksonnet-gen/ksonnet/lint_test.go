@@ -0,0 +1,299 @@
+package ksonnet
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// lintSpec returns a spec of `definitions`, the JSON of its
+// `definitions` object.
+func lintSpec(t *testing.T, definitions string) *kubespec.APISpec {
+	text := fmt.Sprintf(`{"info": {"version": "v1.9.0"}, "definitions": {%s}}`, definitions)
+	s, err := kubespec.UnmarshalSpec("lint.json", []byte(text))
+	if err != nil {
+		t.Fatalf("Could not deserialize lint spec:\n%v\n%s", err, text)
+	}
+	return s
+}
+
+func lintRule(t *testing.T, id string) *LintRule {
+	for _, rule := range LintRules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	t.Fatalf("No lint rule '%s'", id)
+	return nil
+}
+
+// nestedInline returns the JSON of an inline object nested `depth`
+// levels deep in properties named `a`.
+func nestedInline(depth int) string {
+	schema := `{"type": "string", "description": "Leaf."}`
+	for i := 0; i < depth; i++ {
+		schema = fmt.Sprintf(`{"type": "object", "description": "Level.", "required": ["a"], "properties": {"a": %s}}`, schema)
+	}
+	return schema
+}
+
+// wideInline returns the JSON of an inline object with `n` string
+// properties.
+func wideInline(n int) string {
+	properties := []string{}
+	for i := 0; i < n; i++ {
+		properties = append(properties, fmt.Sprintf(`"field%d": {"type": "string", "description": "A field."}`, i))
+	}
+	return fmt.Sprintf(`{"type": "object", "description": "Wide.", "required": ["field0"], "properties": {%s}}`, strings.Join(properties, ", "))
+}
+
+// widgetKind declares the definition it's in the kind `v1` `Widget`.
+const widgetKind = `"x-kubernetes-group-version-kind": [{"Group": "", "Version": "v1", "Kind": "Widget"}]`
+
+var lintRuleTests = []struct {
+	rule        string
+	definitions string
+
+	// Each finding, as its path in its definition, e.g.,
+	// `io.k8s.api.core.v1.Widget spec.replicas`.
+	expected []string
+}{
+	{
+		rule: "missing-description",
+		definitions: `
+			"io.k8s.api.core.v1.Described": {"description": "Described.", "properties": {}},
+			"io.k8s.api.core.v1.Blank": {"description": "  ", "properties": {}},
+			"io.k8s.api.core.v1.Undescribed": {"properties": {"spec": {"type": "object", "properties": {"a": {"type": "string"}}}}}`,
+		expected: []string{"io.k8s.api.core.v1.Blank", "io.k8s.api.core.v1.Undescribed"},
+	},
+	{
+		rule: "missing-property-description",
+		definitions: fmt.Sprintf(`
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", %s, "properties": {
+				"apiVersion": {"type": "string"},
+				"kind": {"type": "string"},
+				"described": {"type": "string", "description": "Described."},
+				"bare": {"type": "string"},
+				"spec": {"type": "object", "description": "Spec.", "properties": {"kind": {"type": "string"}}},
+				"template": {"type": "object", "description": "Template.", "x-kubernetes-embedded-resource": true, "properties": {"kind": {"type": "string"}}}
+			}},
+			"io.k8s.api.core.v1.WidgetSpec": {"description": "Spec.", "properties": {"kind": {"type": "string"}}}`, widgetKind),
+		expected: []string{
+			"io.k8s.api.core.v1.Widget bare",
+			"io.k8s.api.core.v1.Widget spec.kind",
+			"io.k8s.api.core.v1.WidgetSpec kind",
+		},
+	},
+	{
+		rule: "no-required-fields",
+		definitions: `
+			"io.k8s.api.core.v1.Empty": {"description": "Empty."},
+			"io.k8s.api.core.v1.Optional": {"description": "Optional.", "properties": {"a": {"type": "string"}}},
+			"io.k8s.api.core.v1.Required": {"description": "Required.", "required": ["spec"], "properties": {
+				"spec": {"type": "object", "properties": {"a": {"type": "string"}}}
+			}}`,
+		expected: []string{"io.k8s.api.core.v1.Optional", "io.k8s.api.core.v1.Required spec"},
+	},
+	{
+		rule: "required-not-declared",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "required": ["spec", "size"], "properties": {
+				"spec": {"type": "object", "required": ["a", "b"], "properties": {"a": {"type": "string"}}}
+			}}`,
+		expected: []string{"io.k8s.api.core.v1.Widget size", "io.k8s.api.core.v1.Widget spec.b"},
+	},
+	{
+		rule: "untyped-property",
+		definitions: fmt.Sprintf(`
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", %s, "properties": {
+				"metadata": {},
+				"anything": {"description": "Anything."},
+				"object": {"type": "object"},
+				"preserved": {"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+				"map": {"type": "object", "additionalProperties": {"type": "string"}},
+				"port": {"x-kubernetes-int-or-string": true},
+				"ref": {"$ref": "#/definitions/io.k8s.api.core.v1.Widget"},
+				"spec": {"type": "object", "properties": {"config": {"type": "object"}}}
+			}}`, widgetKind),
+		expected: []string{
+			"io.k8s.api.core.v1.Widget anything",
+			"io.k8s.api.core.v1.Widget object",
+			"io.k8s.api.core.v1.Widget spec.config",
+		},
+	},
+	{
+		rule: "array-without-items",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"names": {"type": "array", "items": {"type": "string"}},
+				"refs": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.core.v1.Widget"}},
+				"things": {"type": "array"},
+				"spec": {"type": "object", "properties": {"args": {"type": "array", "items": {}}}}
+			}}`,
+		expected: []string{"io.k8s.api.core.v1.Widget spec.args", "io.k8s.api.core.v1.Widget things"},
+	},
+	{
+		rule: "large-inline-schema",
+		definitions: fmt.Sprintf(`
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"small": %s,
+				"wide": %s,
+				"nested": {"type": "object", "properties": {"wide": %s}}
+			}}`, wideInline(lintLargeInlineSchema-1), wideInline(lintLargeInlineSchema), wideInline(lintLargeInlineSchema)),
+		expected: []string{"io.k8s.api.core.v1.Widget nested", "io.k8s.api.core.v1.Widget wide"},
+	},
+	{
+		rule: "deep-inline-schema",
+		definitions: fmt.Sprintf(`
+			"io.k8s.api.core.v1.Shallow": {"description": "Shallow.", "properties": {"a": %s}},
+			"io.k8s.api.core.v1.Deep": {"description": "Deep.", "properties": {"a": %s}}`,
+			nestedInline(DefaultMaxInlineDepth), nestedInline(DefaultMaxInlineDepth+2)),
+		expected: []string{"io.k8s.api.core.v1.Deep a.a.a.a.a.a"},
+	},
+	{
+		rule: "reserved-identifier",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"std": {"type": "string"},
+				"local": {"type": "string"},
+				"standard": {"type": "string"},
+				"spec": {"type": "object", "properties": {"self": {"type": "string"}}}
+			}}`,
+		expected: []string{
+			"io.k8s.api.core.v1.Widget local",
+			"io.k8s.api.core.v1.Widget spec.self",
+			"io.k8s.api.core.v1.Widget std",
+		},
+	},
+	{
+		rule: "identifier-collision",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"Replicas": {"type": "integer"},
+				"replicas": {"type": "integer"},
+				"size": {"type": "integer"},
+				"spec": {"type": "object", "properties": {"Name": {"type": "string"}, "name": {"type": "string"}}}
+			}}`,
+		expected: []string{"io.k8s.api.core.v1.Widget replicas", "io.k8s.api.core.v1.Widget spec.name"},
+	},
+	{
+		rule: "invalid-identifier",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"rolling-update": {"type": "string"},
+				"9lives": {"type": "string"},
+				"_private": {"type": "string"},
+				"spec": {"type": "object", "properties": {"a.b": {"type": "string"}}}
+			}}`,
+		expected: []string{
+			"io.k8s.api.core.v1.Widget 9lives",
+			"io.k8s.api.core.v1.Widget rolling-update",
+			"io.k8s.api.core.v1.Widget spec.a.b",
+		},
+	},
+	{
+		rule: "dangling-ref",
+		definitions: `
+			"io.k8s.api.core.v1.Widget": {"description": "Widget.", "properties": {
+				"self": {"$ref": "#/definitions/io.k8s.api.core.v1.Widget"},
+				"missing": {"$ref": "#/definitions/io.k8s.api.core.v1.Missing"},
+				"items": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.core.v1.Gone"}}
+			}}`,
+		expected: []string{"io.k8s.api.core.v1.Widget items", "io.k8s.api.core.v1.Widget missing"},
+	},
+}
+
+func TestLintRules(t *testing.T) {
+	tested := map[string]bool{}
+	for _, test := range lintRuleTests {
+		tested[test.rule] = true
+		rule := lintRule(t, test.rule)
+		findings := Lint(lintSpec(t, test.definitions), []*LintRule{rule})
+
+		actual := []string{}
+		for _, finding := range findings {
+			actual = append(actual, strings.TrimSpace(fmt.Sprintf("%s %s", finding.Definition, finding.Path)))
+			if finding.Rule != rule.ID || finding.Severity != rule.Severity || finding.Message == "" {
+				t.Errorf("Expected a finding of '%s', of severity %s, with a message, got %#v", rule.ID, rule.Severity, finding)
+			}
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected '%s' to find:\n%s\ngot:\n%s", test.rule, strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+		}
+	}
+
+	for _, rule := range LintRules {
+		if !tested[rule.ID] {
+			t.Errorf("Expected a test of lint rule '%s'", rule.ID)
+		}
+		if _, err := ParseLintSeverity(string(rule.Severity)); err != nil || rule.Description == "" || rule.Check == nil {
+			t.Errorf("Expected lint rule '%s' to have a severity, a description, and a check, got %#v", rule.ID, rule)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	s := lintSpec(t, `
+		"io.k8s.api.core.v1.Widget": {"description": "Widget.", "required": ["spec"], "properties": {
+			"spec": {"type": "object", "description": "Spec.", "required": ["rolling-update"], "properties": {
+				"rolling-update": {"type": "string", "description": "Update."},
+				"things": {"type": "array", "description": "Things."}
+			}}
+		}},
+		"io.k8s.api.core.v1.Gadget": {"properties": {"std": {"type": "string", "description": "Std."}}}`)
+
+	// Findings of every rule are sorted by definition, then path, then
+	// rule.
+	actual := []string{}
+	for _, finding := range Lint(s, LintRules) {
+		actual = append(actual, fmt.Sprintf("%s %s %s %s", finding.Severity, finding.Definition, finding.Path, finding.Rule))
+	}
+	expected := []string{
+		"warning io.k8s.api.core.v1.Gadget  missing-description",
+		"info io.k8s.api.core.v1.Gadget  no-required-fields",
+		"warning io.k8s.api.core.v1.Gadget std reserved-identifier",
+		"error io.k8s.api.core.v1.Widget spec.rolling-update invalid-identifier",
+		"warning io.k8s.api.core.v1.Widget spec.things array-without-items",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected the findings:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+
+	// Rules are extensible: any func of the spec with an ID will do.
+	custom := &LintRule{
+		ID:       "no-gadgets",
+		Severity: LintError,
+		Check: func(s *kubespec.APISpec) []*LintFinding {
+			if _, ok := s.Definitions["io.k8s.api.core.v1.Gadget"]; !ok {
+				return nil
+			}
+			return []*LintFinding{{Definition: "io.k8s.api.core.v1.Gadget", Message: "Gadgets aren't allowed"}}
+		},
+	}
+	findings := Lint(s, []*LintRule{custom})
+	if len(findings) != 1 || findings[0].Rule != "no-gadgets" || findings[0].Severity != LintError {
+		t.Errorf("Expected a finding of the custom rule, got %#v", findings)
+	}
+}
+
+func TestLintSeverity(t *testing.T) {
+	for _, test := range []struct {
+		severity, other LintSeverity
+		atLeast         bool
+	}{
+		{LintError, LintWarning, true},
+		{LintWarning, LintWarning, true},
+		{LintInfo, LintWarning, false},
+		{LintWarning, LintError, false},
+	} {
+		if atLeast := test.severity.AtLeast(test.other); atLeast != test.atLeast {
+			t.Errorf("Expected %s.AtLeast(%s) to be %v", test.severity, test.other, test.atLeast)
+		}
+	}
+	if _, err := ParseLintSeverity("fatal"); err == nil {
+		t.Errorf("Expected an unknown severity to fail")
+	}
+}
@@ -0,0 +1,17 @@
+package ksonnet
+
+// Logger receives structured progress events as the library is
+// generated, e.g., how long each API group took to emit. Each event
+// has a message (e.g., `emit group`) and alternating key/value pairs
+// (e.g., `"group", "apps", "duration", d`), so that it's easy to adapt
+// to structured loggers such as zap's `SugaredLogger.Infow`.
+//
+// Durations are always logged under the key `duration`, as a
+// `time.Duration`.
+type Logger interface {
+	Log(msg string, keysAndValues ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(string, ...interface{}) {}
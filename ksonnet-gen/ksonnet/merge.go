@@ -0,0 +1,285 @@
+package ksonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// mergeName is the mixin that `Options.EmitMerge` adds to each
+// top-level kind, and the function it adds to `util`.
+const mergeName = "merge"
+
+// mergeLocal is the local of the library that has the merge strategy
+// tables and the functions that interpret them; see `emitMergeLocal`.
+const mergeLocal = "__merge"
+
+// mergeFunctions are the fields of `mergeLocal` after its tables:
+// `values` merges `b` into `a` by the strategy table `table` (or, if
+// it's null, field by field), `field` merges the values `a` and `b` of
+// the field `name` of an object with the table `table`, and `mixin`
+// returns the mixin that merges `other` into the object it's added to.
+// Lists with `k` are merged item by item, matched by their `k` fields,
+// and their items with the table `i`; lists with `s` by value; objects
+// field by field, with the table `o`; and anything else is replaced by
+// the right-hand value.
+var mergeFunctions = []string{
+	"local merge = self,",
+	"table(id):: if id == null then null else merge.tables[id],",
+	"local strategy(table, name) = if table != null && std.objectHas(table, name) then table[name] else {},",
+	"local tableOf(s, field) = if std.objectHas(s, field) then merge.tables[s[field]] else null,",
+	"local keyOf(keys, item) = [if std.type(item) == \"object\" && std.objectHas(item, key) then item[key] else null for key in keys],",
+	"local list(s, left, right) =",
+	"  local items = tableOf(s, \"i\");",
+	"  [std.foldl(function(merged, b) merge.values(items, merged, b), [b for b in right if keyOf(s.k, b) == keyOf(s.k, a)], a) for a in left] +",
+	"  [b for b in right if std.length([a for a in left if keyOf(s.k, a) == keyOf(s.k, b)]) == 0],",
+	"field(table, name, a, b)::",
+	"  local s = strategy(table, name);",
+	"  if std.type(a) == \"array\" && std.type(b) == \"array\" && std.objectHas(s, \"k\") then list(s, a, b)",
+	"  else if std.type(a) == \"array\" && std.type(b) == \"array\" && std.objectHas(s, \"s\") then a + [x for x in b if std.count(a, x) == 0]",
+	"  else merge.values(tableOf(s, \"o\"), a, b),",
+	"values(table, a, b)::",
+	"  if std.type(a) == \"object\" && std.type(b) == \"object\" then",
+	"    a + {[name]: if std.objectHas(a, name) then merge.field(table, name, a[name], b[name]) else b[name] for name in std.objectFields(b)}",
+	"  else b,",
+	"mixin(table, other)::",
+	"  assert std.type(other) == \"object\" : \"'merge' takes an object, got \" + std.type(other);",
+	"  std.foldl(function(mixin, name) mixin + {[name]: if name in super then merge.field(table, name, super[name], other[name]) else other[name]}, std.objectFields(other), {}),",
+}
+
+// `mergeStrategies` are the merge strategy tables of the library, which
+// `Options.EmitMerge` compiles from the schemas of its definitions:
+// `tables` has a table for each structure (see
+// `kubespec.APISpec.StructuralHashes`) of the definitions that have
+// a field that isn't merged field by field or replaced, or an object
+// field whose definition has one, and `ids` maps the name of each of
+// those definitions to the index of its table.
+type mergeStrategies struct {
+	tables []map[string]interface{}
+	ids    map[kubespec.DefinitionName]int
+}
+
+// `id` returns the Jsonnet expression of the index of the table of the
+// definition `name`, or `null` if it has none.
+func (s *mergeStrategies) id(name kubespec.DefinitionName) string {
+	if id, ok := s.ids[name]; ok {
+		return strconv.Itoa(id)
+	}
+	return "null"
+}
+
+// `newMergeStrategies` compiles the merge strategy tables of the
+// definitions of the library with `Options.EmitMerge`, or returns nil
+// if it isn't set. A field's strategy comes from its schema: a list
+// whose `x-kubernetes-list-type` is `atomic` is replaced, whatever its
+// strategic merge patch metadata; one with `x-kubernetes-list-map-keys`
+// (or else `x-kubernetes-patch-merge-key`) is merged by those keys; a
+// `set` is merged by value; and an object field is merged by the
+// table of its definition.
+func (root *root) newMergeStrategies() *mergeStrategies {
+	if !root.opts.EmitMerge {
+		return nil
+	}
+	objects := map[kubespec.DefinitionName]*apiObject{}
+	for _, groups := range []groupSet{root.groups, root.hiddenGroups} {
+		for _, group := range groups {
+			for _, va := range group.versionedAPIs {
+				for _, ao := range va.apiObjects {
+					objects[ao.parsedName.Unparse()] = ao
+				}
+			}
+		}
+	}
+	known := func(ref *kubespec.ObjectRef) (kubespec.DefinitionName, bool) {
+		if ref == nil {
+			return "", false
+		}
+		name := *root.refName(ref)
+		_, ok := objects[name]
+		return name, ok
+	}
+
+	// Each definition's own strategies, and the definitions its object
+	// fields and the items of its keyed lists refer to.
+	type strategy struct {
+		entry map[string]interface{}
+		ref   kubespec.DefinitionName
+		refs  string // "o" or "i", if `ref` is set.
+	}
+	strategies := map[kubespec.DefinitionName]map[string]*strategy{}
+	for name, ao := range objects {
+		fields := map[string]*strategy{}
+		for _, pm := range ao.properties {
+			if pm.kind == typeAlias || pm.opaque {
+				continue
+			}
+			if pm.schemaType != nil && *pm.schemaType == "array" {
+				keys := pm.listMapKeys
+				if pm.listType != kubespec.ListTypeMap || len(keys) == 0 {
+					keys = nil
+					if pm.patchMergeKey != "" {
+						keys = []string{pm.patchMergeKey}
+					}
+				}
+				switch {
+				case pm.listType == kubespec.ListTypeAtomic:
+				case pm.listType == kubespec.ListTypeSet:
+					fields[string(pm.name)] = &strategy{entry: map[string]interface{}{"s": true}}
+				case len(keys) > 0:
+					k := []interface{}{}
+					for _, key := range keys {
+						k = append(k, key)
+					}
+					s := &strategy{entry: map[string]interface{}{"k": k}}
+					if ref, ok := known(pm.itemTypes.Ref); ok {
+						s.ref, s.refs = ref, "i"
+					}
+					fields[string(pm.name)] = s
+				}
+				continue
+			}
+			if ref, ok := known(pm.ref); ok && pm.isMixin() {
+				fields[string(pm.name)] = &strategy{entry: map[string]interface{}{}, ref: ref, refs: "o"}
+			}
+		}
+		strategies[name] = fields
+	}
+
+	// A definition needs a table if it has a list that's merged, or an
+	// object field, or keyed list, whose definition needs one.
+	needed := map[kubespec.DefinitionName]bool{}
+	for changed := true; changed; {
+		changed = false
+		for name, fields := range strategies {
+			if needed[name] {
+				continue
+			}
+			for _, s := range fields {
+				if len(s.entry) > 0 || needed[s.ref] {
+					needed[name], changed = true, true
+					break
+				}
+			}
+		}
+	}
+
+	// Definitions of the same structure have the same table, which is
+	// numbered in order of the first of their names.
+	hashes := root.spec.StructuralHashes()
+	names := []string{}
+	for name := range needed {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	result := &mergeStrategies{ids: map[kubespec.DefinitionName]int{}}
+	byHash := map[string]int{}
+	first := []kubespec.DefinitionName{}
+	for _, name := range names {
+		dn := kubespec.DefinitionName(name)
+		hash := hashes[dn]
+		id, ok := byHash[hash]
+		if !ok || hash == "" {
+			id = len(first)
+			byHash[hash] = id
+			first = append(first, dn)
+		}
+		result.ids[dn] = id
+	}
+	for _, dn := range first {
+		table := map[string]interface{}{}
+		for field, s := range strategies[dn] {
+			entry := map[string]interface{}{}
+			for key, value := range s.entry {
+				entry[key] = value
+			}
+			if s.ref != "" && needed[s.ref] {
+				entry[s.refs] = json.Number(strconv.Itoa(result.ids[s.ref]))
+			}
+			if len(entry) > 0 {
+				table[field] = entry
+			}
+		}
+		result.tables = append(result.tables, table)
+	}
+	root.logger().Log("merge strategies", "tables", len(result.tables), "definitions", len(result.ids))
+	return result
+}
+
+// `emitMergeLocal` emits `mergeLocal`, with `Options.EmitMerge`: the
+// tables of `newMergeStrategies`, one per line, `kinds`, which maps the
+// path of each top-level kind to the index of its table, or null, and
+// `mergeFunctions`.
+func (root *root) emitMergeLocal(m *indentWriter) {
+	s := root.mergeStrategies
+	if s == nil {
+		return
+	}
+	kinds := map[string]interface{}{}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				if !ao.isTopLevel {
+					continue
+				}
+				if id, ok := s.ids[ao.parsedName.Unparse()]; ok {
+					kinds[ao.path()] = json.Number(strconv.Itoa(id))
+				} else {
+					kinds[ao.path()] = nil
+				}
+			}
+		}
+	}
+	kindsText, err := jsonnet.Literal(kinds)
+	if err != nil {
+		log.Panicf("Could not write the merge strategies of the kinds:\n%v", err)
+	}
+
+	m.writeLine(fmt.Sprintf(
+		"// The merge strategies of `%s.%s` and the kinds' `%s` mixins: each table maps the fields of a definition that aren't replaced, or merged field by field, to how they're merged.",
+		utilName, mergeName, mergeName))
+	m.writeLine(fmt.Sprintf("local %s = {", mergeLocal))
+	m.indent()
+	m.writeLine(fmt.Sprintf("kinds:: %s,", kindsText))
+	m.writeLine("tables:: [")
+	m.indent()
+	for id, table := range s.tables {
+		text, err := jsonnet.Literal(table)
+		if err != nil {
+			log.Panicf("Could not write merge strategy table %d:\n%v", id, err)
+		}
+		m.writeLine(text + ",")
+	}
+	m.dedent()
+	m.writeLine("],")
+	for _, line := range mergeFunctions {
+		m.writeLine(line)
+	}
+	m.dedent()
+	m.writeLine("},")
+}
+
+// `emitMergeMixin` emits the `merge(other)` mixin of a top-level kind,
+// with `Options.EmitMerge`, which merges `other` into the object it's
+// added to by the kind's schema (see `newMergeStrategies`), e.g.,
+// `deployment.new() + deployment.merge(other)`.
+func (ao *apiObject) emitMergeMixin(m *indentWriter, path string) {
+	s := ao.root().mergeStrategies
+	if s == nil || !ao.isTopLevel {
+		return
+	}
+	if dm, ok := ao.properties[mergeName]; ok {
+		log.Panicf(
+			"Attempted to create helper '%s', but the property already existed at '%s'",
+			mergeName, dm.path)
+	}
+	m.writeLine("// Merges the object `other` into this one by the kind's schema, rather than as `+` does: lists with a merge key are merged item by item, sets by value, objects field by field, and other lists and scalars are replaced.")
+	m.writeLine(fmt.Sprintf(
+		"%s(other):: %s.mixin(%s.table(%s), other),",
+		mergeName, mergeLocal, mergeLocal, s.id(ao.parsedName.Unparse())))
+	ao.root().index.add(fmt.Sprintf("%s.%s", path, mergeName), SymbolFunction, "other")
+}
@@ -0,0 +1,112 @@
+package ksonnet
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	const path = "testdata/listtypes.json"
+	if library := emitTestSpec(t, path, Options{}); strings.Contains(library, mergeLocal) || strings.Contains(library, "merge(") {
+		t.Errorf("Expected no merge functions by default:\n%s", library)
+	}
+
+	opts := Options{EmitMerge: true}
+	library := emitTestSpec(t, path, opts)
+	for _, line := range []string{
+		`kinds:: {"core.v1.pod": 1},`,
+		// `ports` is a map keyed by both of its list-map-keys, rather than
+		// its patch merge key.
+		`{ports: {k: ["containerPort", "protocol"]}},`,
+		`{spec: {o: 2}},`,
+		`{containers: {i: 0, k: ["name"]}},`,
+		"merge(other):: __merge.mixin(__merge.table(1), other),",
+		`merge(kindPath, a, b):: if std.objectHas(__merge.kinds, kindPath) then __merge.values(__merge.table(__merge.kinds[kindPath]), a, b)`,
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected the library to have '%s':\n%s", line, library)
+		}
+	}
+	// Atomic lists are replaced, so they have no entry, even with a
+	// patch merge key (`imagePullSecrets`).
+	tables := library[strings.Index(library, "tables:: ["):strings.Index(library, "local merge = self,")]
+	for _, field := range []string{"args", "imagePullSecrets", "tolerations", "command"} {
+		if strings.Contains(tables, field+":") {
+			t.Errorf("Expected no strategy for '%s':\n%s", field, tables)
+		}
+	}
+	// Only top-level kinds have the mixin.
+	if count := strings.Count(library, "merge(other)::"); count != 1 {
+		t.Errorf("Expected a 'merge' mixin for the 1 top-level kind, got %d:\n%s", count, library)
+	}
+	for i := 0; i < 5; i++ {
+		if again := emitTestSpec(t, path, opts); again != library {
+			t.Fatalf("Expected the library to be the same each time it's emitted")
+		}
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	if symbol := symbols["util.merge"]; symbol == nil || symbol.Kind != SymbolFunction || strings.Join(symbol.Params, ", ") != "kindPath, a, b" {
+		t.Errorf("Expected 'util.merge(kindPath, a, b)' to be indexed, got %#v", symbol)
+	}
+	if symbol := symbols["core.v1.pod.merge"]; symbol == nil || symbol.Kind != SymbolFunction || strings.Join(symbol.Params, ", ") != "other" {
+		t.Errorf("Expected 'core.v1.pod.merge(other)' to be indexed, got %#v", symbol)
+	}
+
+	// Kinds that share their namespace have the same mixin.
+	emitTestSpec(t, path, Options{EmitMerge: true, ShareIdenticalKinds: true, Compact: true})
+}
+
+func TestMergeEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// Containers are merged by name, their ports by port and protocol,
+	// and atomic lists are replaced.
+	const program = `local k = import 'k8s.libsonnet';
+local pod = k.core.v1.pod;
+local a = pod.new() +
+  pod.mixin.spec.containers([
+    {name: "web", image: "web:1", args: ["--a"], ports: [{containerPort: 80, protocol: "TCP"}, {containerPort: 53, protocol: "UDP"}]},
+    {name: "sidecar", image: "sidecar:1"},
+  ]) +
+  pod.mixin.spec.tolerations([{key: "a"}]) +
+  pod.mixin.spec.imagePullSecrets([{name: "a"}]);
+local b = {spec: {
+  containers: [
+    {name: "web", image: "web:2", args: ["--b"], ports: [{containerPort: 80, protocol: "TCP", name: "http"}, {containerPort: 53, protocol: "TCP"}]},
+    {name: "logs", image: "logs:1"},
+  ],
+  tolerations: [{key: "b"}],
+  imagePullSecrets: [{name: "b"}],
+}};
+local expected = {apiVersion: "v1", kind: "Pod", spec: {
+  containers: [
+    {name: "web", image: "web:2", args: ["--b"], ports: [{containerPort: 80, protocol: "TCP", name: "http"}, {containerPort: 53, protocol: "UDP"}, {containerPort: 53, protocol: "TCP"}]},
+    {name: "sidecar", image: "sidecar:1"},
+    {name: "logs", image: "logs:1"},
+  ],
+  tolerations: [{key: "b"}],
+  imagePullSecrets: [{name: "b"}],
+}};
+[
+  std.assertEqual(a + pod.merge(b), expected),
+  std.assertEqual(k.util.merge("core.v1.pod", a, b), expected),
+  std.assertEqual(a + pod.merge({}), a),
+]
+`
+	spec := loadTestSpec(t, "testdata/listtypes.json")
+	if out := evaluateLibrary(t, jsonnet, spec, Options{EmitMerge: true}, program); strings.Contains(out, "false") {
+		t.Errorf("Expected every merge to evaluate as expected, got:\n%s", out)
+	}
+}
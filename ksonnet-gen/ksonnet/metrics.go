@@ -0,0 +1,199 @@
+package ksonnet
+
+import "time"
+
+// Metrics receives operational metrics as the library is generated,
+// e.g., by a service that regenerates libraries whenever a cluster's
+// spec changes. Each metric has a name (one of the `Metric...`
+// constants), a value, and labels (see `MetricDefinitions` for which
+// labels each has). `Counter` adds the value to a counter, `Gauge` sets
+// a gauge to it, and `Observe` adds it to a histogram or summary.
+//
+// The methods are called from the goroutine generating the library, at
+// the same points as `Options.Progress` and at the end of each phase,
+// so they should return quickly. `EmitArtifacts` reports only those of
+// the library. `MetricsFuncs` adapts functions, e.g., ones that look
+// up prometheus client_golang collectors by name.
+type Metrics interface {
+	Counter(name string, value float64, labels map[string]string)
+	Gauge(name string, value float64, labels map[string]string)
+	Observe(name string, value float64, labels map[string]string)
+}
+
+// The names of the metrics reported to `Metrics`.
+const (
+	// MetricPhaseDuration observes how long each phase of generation
+	// took, in seconds.
+	MetricPhaseDuration = "ksonnet_gen_phase_duration_seconds"
+	// MetricPhaseProgress is the fraction of each phase's units of
+	// work that are done, as reported to `Options.Progress`.
+	MetricPhaseProgress = "ksonnet_gen_phase_progress_ratio"
+	// MetricGroupDuration observes how long each API group took to
+	// emit, in seconds.
+	MetricGroupDuration = "ksonnet_gen_group_duration_seconds"
+	// MetricDefinitionsParsed counts the definitions parsed into the
+	// library.
+	MetricDefinitionsParsed = "ksonnet_gen_definitions_parsed_total"
+	// MetricDefinitionsSkipped counts the definitions the library
+	// skips (see `SkippedDefinitions`).
+	MetricDefinitionsSkipped = "ksonnet_gen_definitions_skipped_total"
+	// MetricOutputBytes is the size of the last library emitted, in
+	// bytes, across all of its files.
+	MetricOutputBytes = "ksonnet_gen_output_bytes"
+	// MetricParserCacheHits counts the definition names that
+	// `Options.Parser` answered from its cache.
+	MetricParserCacheHits = "ksonnet_gen_parser_cache_hits_total"
+	// MetricParserCacheMisses counts the definition names that
+	// `Options.Parser` had to parse.
+	MetricParserCacheMisses = "ksonnet_gen_parser_cache_misses_total"
+)
+
+// The labels of the metrics reported to `Metrics`.
+const (
+	// MetricLabelPhase is the phase of generation: `synthesize`,
+	// `parse`, or `emit`.
+	MetricLabelPhase = "phase"
+	// MetricLabelGroup is the path of an API group, e.g., `apps` or
+	// `hidden.meta`.
+	MetricLabelGroup = "group"
+	// MetricLabelK8sVersion is the Kubernetes version of the spec,
+	// e.g., `v1.9.0`.
+	MetricLabelK8sVersion = "k8s_version"
+)
+
+// MetricType is how a metric is reported: by `Metrics.Counter`,
+// `Metrics.Gauge`, or `Metrics.Observe`.
+type MetricType string
+
+const (
+	MetricCounter MetricType = "counter"
+	MetricGauge   MetricType = "gauge"
+	MetricObserve MetricType = "observe"
+)
+
+// MetricDefinition describes a metric reported to `Metrics`, e.g., to
+// register a collector for it.
+type MetricDefinition struct {
+	Name   string
+	Type   MetricType
+	Help   string
+	Labels []string
+}
+
+// MetricDefinitions are the metrics reported to `Metrics`, each always
+// with exactly its `Labels`.
+var MetricDefinitions = []MetricDefinition{
+	{MetricPhaseDuration, MetricObserve, "How long each phase of generation took, in seconds.",
+		[]string{MetricLabelPhase, MetricLabelK8sVersion}},
+	{MetricPhaseProgress, MetricGauge, "The fraction of each phase's units of work that are done.",
+		[]string{MetricLabelPhase, MetricLabelK8sVersion}},
+	{MetricGroupDuration, MetricObserve, "How long each API group took to emit, in seconds.",
+		[]string{MetricLabelGroup, MetricLabelK8sVersion}},
+	{MetricDefinitionsParsed, MetricCounter, "Definitions parsed into the library.",
+		[]string{MetricLabelK8sVersion}},
+	{MetricDefinitionsSkipped, MetricCounter, "Definitions the library skips.",
+		[]string{MetricLabelK8sVersion}},
+	{MetricOutputBytes, MetricGauge, "The size of the last library emitted, in bytes.",
+		[]string{MetricLabelK8sVersion}},
+	{MetricParserCacheHits, MetricCounter, "Definition names answered from the parser's cache.",
+		[]string{MetricLabelK8sVersion}},
+	{MetricParserCacheMisses, MetricCounter, "Definition names the parser had to parse.",
+		[]string{MetricLabelK8sVersion}},
+}
+
+// MetricsFuncs adapts functions to `Metrics`, so that the library
+// doesn't depend on a metrics client. A nil function drops its
+// metrics. E.g., with prometheus client_golang, register a
+// `CounterVec`, `GaugeVec`, or `HistogramVec` for each of
+// `MetricDefinitions`, keyed by name, and set:
+//
+//	CounterFunc: func(name string, value float64, labels map[string]string) {
+//		counters[name].With(prometheus.Labels(labels)).Add(value)
+//	},
+type MetricsFuncs struct {
+	CounterFunc func(name string, value float64, labels map[string]string)
+	GaugeFunc   func(name string, value float64, labels map[string]string)
+	ObserveFunc func(name string, value float64, labels map[string]string)
+}
+
+// Counter calls `CounterFunc`, if it's set.
+func (f MetricsFuncs) Counter(name string, value float64, labels map[string]string) {
+	if f.CounterFunc != nil {
+		f.CounterFunc(name, value, labels)
+	}
+}
+
+// Gauge calls `GaugeFunc`, if it's set.
+func (f MetricsFuncs) Gauge(name string, value float64, labels map[string]string) {
+	if f.GaugeFunc != nil {
+		f.GaugeFunc(name, value, labels)
+	}
+}
+
+// Observe calls `ObserveFunc`, if it's set.
+func (f MetricsFuncs) Observe(name string, value float64, labels map[string]string) {
+	if f.ObserveFunc != nil {
+		f.ObserveFunc(name, value, labels)
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Counter(string, float64, map[string]string) {}
+func (nopMetrics) Gauge(string, float64, map[string]string)   {}
+func (nopMetrics) Observe(string, float64, map[string]string) {}
+
+func (root *root) metrics() Metrics {
+	if root.opts.Metrics == nil {
+		return nopMetrics{}
+	}
+	return root.opts.Metrics
+}
+
+// `metricLabels` returns the labels of a metric of the root's spec:
+// its Kubernetes version, and alternating label names and values.
+func (root *root) metricLabels(namesAndValues ...string) map[string]string {
+	labels := map[string]string{MetricLabelK8sVersion: root.spec.Info.Version}
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		labels[namesAndValues[i]] = namesAndValues[i+1]
+	}
+	return labels
+}
+
+// `observePhase` reports how long a phase of generation took, since
+// `start`.
+func (root *root) observePhase(phase string, start time.Time) {
+	root.metrics().Observe(
+		MetricPhaseDuration, time.Since(start).Seconds(),
+		root.metricLabels(MetricLabelPhase, phase))
+}
+
+// `countParses` reports how many definition names the root's parser
+// answered from its cache, and how many it had to parse, since the last
+// call (or since the root was created). If `Options.Parser` is shared
+// between generations running at once, some of them may count each
+// other's.
+func (root *root) countParses() {
+	hits, misses, _ := root.parser.Stats()
+	labels := root.metricLabels()
+	root.metrics().Counter(MetricParserCacheHits, float64(hits-root.parserHits), labels)
+	root.metrics().Counter(MetricParserCacheMisses, float64(misses-root.parserMisses), labels)
+	root.parserHits, root.parserMisses = hits, misses
+}
+
+// `reportOutput` reports the size of the library emitted, and the
+// duration of the `emit` phase, since `start`.
+func (root *root) reportOutput(bytes int, start time.Time) {
+	root.observePhase("emit", start)
+	root.metrics().Gauge(MetricOutputBytes, float64(bytes), root.metricLabels())
+	root.countParses()
+}
+
+// `progressRatio` is `done` out of `total`, as a fraction, or 1 if
+// there's nothing to do.
+func progressRatio(done, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(done) / float64(total)
+}
@@ -0,0 +1,121 @@
+package ksonnet
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type recordedMetric struct {
+	typ    MetricType
+	value  float64
+	labels map[string]string
+}
+
+type recordingMetrics struct {
+	metrics map[string][]recordedMetric
+}
+
+func (r *recordingMetrics) record(typ MetricType, name string, value float64, labels map[string]string) {
+	r.metrics[name] = append(r.metrics[name], recordedMetric{typ, value, labels})
+}
+
+func TestMetrics(t *testing.T) {
+	r := &recordingMetrics{metrics: map[string][]recordedMetric{}}
+	metrics := MetricsFuncs{
+		CounterFunc: func(name string, value float64, labels map[string]string) {
+			r.record(MetricCounter, name, value, labels)
+		},
+		GaugeFunc: func(name string, value float64, labels map[string]string) {
+			r.record(MetricGauge, name, value, labels)
+		},
+		ObserveFunc: func(name string, value float64, labels map[string]string) {
+			r.record(MetricObserve, name, value, labels)
+		},
+	}
+	progress := 0
+	emitTestSpec(t, "testdata/swagger.json", Options{
+		Metrics:  metrics,
+		Progress: func(string, int, int) { progress++ },
+	})
+
+	// Each metric is reported as documented, with exactly its labels.
+	for _, definition := range MetricDefinitions {
+		recorded := r.metrics[definition.Name]
+		if len(recorded) == 0 {
+			t.Errorf("Expected '%s' to be reported", definition.Name)
+		}
+		for _, metric := range recorded {
+			names := []string{}
+			for name := range metric.labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			expected := append([]string{}, definition.Labels...)
+			sort.Strings(expected)
+			if metric.typ != definition.Type || !reflect.DeepEqual(names, expected) {
+				t.Errorf("Expected '%s' to be a %s labeled %v, got %#v", definition.Name, definition.Type, expected, metric)
+			}
+			if metric.labels[MetricLabelK8sVersion] != "v1.7.0" {
+				t.Errorf("Expected '%s' to be labeled with the spec's version, got %v", definition.Name, metric.labels)
+			}
+		}
+	}
+
+	// The progress gauge is set wherever `Options.Progress` is called,
+	// ending at 1.
+	gauges := r.metrics[MetricPhaseProgress]
+	if len(gauges) != progress {
+		t.Errorf("Expected %d progress gauges, one per progress callback, got %d", progress, len(gauges))
+	}
+	if last := gauges[len(gauges)-1]; last.value != 1 || last.labels[MetricLabelPhase] != "emit" {
+		t.Errorf("Expected emission to end at 1, got %#v", last)
+	}
+
+	phases := map[string]bool{}
+	for _, metric := range r.metrics[MetricPhaseDuration] {
+		phases[metric.labels[MetricLabelPhase]] = true
+	}
+	if !reflect.DeepEqual(phases, map[string]bool{"synthesize": true, "parse": true, "emit": true}) {
+		t.Errorf("Unexpected phases: %v", phases)
+	}
+	groups := map[string]bool{}
+	for _, metric := range r.metrics[MetricGroupDuration] {
+		groups[metric.labels[MetricLabelGroup]] = true
+	}
+	if !groups["batch"] || !groups["hidden.meta"] {
+		t.Errorf("Expected the duration of each group, got %v", groups)
+	}
+
+	// `RawExtension` has no version, so it's skipped.
+	if skipped := r.metrics[MetricDefinitionsSkipped]; skipped[0].value != 1 {
+		t.Errorf("Expected 1 skipped definition, got %#v", skipped)
+	}
+	if bytes := r.metrics[MetricOutputBytes]; bytes[0].value == 0 {
+		t.Errorf("Expected the size of the output, got %#v", bytes)
+	}
+	if misses := r.metrics[MetricParserCacheMisses]; misses[0].value == 0 {
+		t.Errorf("Expected a new parser to miss, got %#v", misses)
+	}
+}
+
+func TestMetricsArtifacts(t *testing.T) {
+	// The other artifacts don't count the library's definitions again.
+	counted := 0
+	metrics := MetricsFuncs{CounterFunc: func(name string, value float64, labels map[string]string) {
+		if name == MetricDefinitionsParsed {
+			counted++
+		}
+	}}
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	if _, err := EmitArtifacts(context.Background(), spec, Options{Metrics: metrics}); err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if counted != 1 {
+		t.Errorf("Expected the definitions to be counted once, got %d", counted)
+	}
+
+	// A nil function drops its metrics.
+	emitTestSpec(t, "testdata/swagger.json", Options{Metrics: MetricsFuncs{}})
+}
@@ -0,0 +1,519 @@
+package ksonnet
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// ImportMigration is what `MigrateImports` found in a tree of Jsonnet
+// files: the edits that migrate them to another layout of the library,
+// and the references it couldn't migrate.
+type ImportMigration struct {
+	// Edits are the rewrites of the files, sorted by file and offset.
+	Edits []*ImportEdit
+
+	// Unresolved are references into the library that don't name
+	// anything in the old one (e.g., `k8s.apps.v1.deploymnet`), or
+	// whose symbols have no counterpart in the new one (e.g., a kind
+	// that was removed), sorted by file and line. They're left as they
+	// are, for someone to migrate by hand.
+	Unresolved []*UnresolvedReference
+
+	files, original map[string][]byte
+}
+
+// ImportEdit is one rewrite of a Jsonnet file: `Old`, the text at byte
+// `Offset` of the file (which is on line `Line`), is replaced by `New`,
+// e.g., `.rbac.v1.role` by `.rbacAuthorization.v1.role`.
+type ImportEdit struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Offset int    `json:"offset"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// UnresolvedReference is a reference that `MigrateImports` can't
+// migrate, and why, e.g., `isn't in the new library`.
+type UnresolvedReference struct {
+	UsageReference
+	Problem string `json:"problem"`
+}
+
+func (ur *UnresolvedReference) String() string {
+	return fmt.Sprintf("%s: %s", ur.UsageReference.String(), ur.Problem)
+}
+
+var (
+	// importStatementPattern matches an import (the literal it imports
+	// follows it), and the local it's bound to, if any, in Jsonnet code
+	// whose strings are blanked by `stripCommentsAndStrings`.
+	importStatementPattern = regexp.MustCompile(
+		`(?:\blocal\s+([A-Za-z_][A-Za-z0-9_]*)\s*=\s*)?\bimport\b`)
+)
+
+// MigrateImports rewrites the Jsonnet `files`, which map the path of
+// each file to its text, from using the library whose symbols are
+// `from` to using the library whose symbols are `to`, e.g., when it's
+// split by group (see `Options.SplitByGroup`), its files are named
+// differently, a group is moved to another namespace (see
+// `Options.Namespaces`), its identifiers are named by another strategy,
+// or the aliases of `k.libsonnet` are removed. Files are returned with
+// the edits by `Files`; `files` isn't modified.
+//
+// Like `ScanUsage`, it matches tokens rather than parsing: imports of
+// the library's files (e.g., `import "vendor/apps.libsonnet"`), and
+// references to the library through the locals they're bound to, `k8s`
+// and `k` (e.g., `k8s.rbac.v1.role.new`). Only the literal of an import,
+// or the fields of a reference, are rewritten, so the rest of each file
+// is left exactly as it was. A symbol is found in `to` at the same
+// path; or, if the namespace of its definition moved, or its property
+// was renamed (see `Symbol.RenamedFrom`), by the definition and
+// property it's for; or, if its alias of `k.libsonnet` was removed, at
+// the path the alias pointed at. References to the shims of renamed
+// properties (see `Symbol.Deprecated`) are rewritten as the symbols
+// that replace them. Fields after the symbols `from` has
+// (e.g., those of customizations) are kept as they are. The files of
+// the library itself (e.g., `k8s.libsonnet`) are skipped.
+func MigrateImports(from, to *SymbolIndex, files map[string][]byte) *ImportMigration {
+	migration := &ImportMigration{files: map[string][]byte{}, original: map[string][]byte{}}
+	m := newPathMigrator(from, to)
+
+	libraryFiles := map[string]bool{
+		LibraryFile: true, AliasesFile: true, LabelsFile: true, HiddenFile: true, GVKIndexFile: true,
+	}
+	groupFiles := map[string]string{} // The group of each file of `from`.
+	for group, name := range from.Files {
+		libraryFiles[name] = true
+		groupFiles[name] = group
+	}
+	for _, name := range to.Files {
+		libraryFiles[name] = true
+	}
+
+	names := []string{}
+	for name := range files {
+		if !libraryFiles[path.Base(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		text := string(files[name])
+		f := &migratingFile{
+			name:      name,
+			text:      text,
+			code:      stripCommentsAndStrings(text),
+			migration: migration,
+			bindings: map[string]*libraryBinding{
+				"k8s": {},
+				"k":   {aliases: true},
+			},
+		}
+		f.migrateImports(m, groupFiles)
+		f.migrateReferences(m)
+		if len(f.edits) > 0 {
+			migration.files[name] = []byte(f.apply())
+			migration.original[name] = files[name]
+			migration.Edits = append(migration.Edits, f.edits...)
+		}
+	}
+	sort.SliceStable(migration.Unresolved, func(i, j int) bool {
+		a, b := migration.Unresolved[i], migration.Unresolved[j]
+		return a.File < b.File || (a.File == b.File && a.Line < b.Line)
+	})
+	return migration
+}
+
+// Files returns the text of each file the migration rewrites, with its
+// edits, keyed by its path.
+func (migration *ImportMigration) Files() map[string][]byte {
+	return migration.files
+}
+
+// Diff returns each line the migration rewrites, as a unified diff
+// without context (edits never add or remove lines), e.g.:
+//
+//	--- environments/prod/main.jsonnet
+//	+++ environments/prod/main.jsonnet
+//	@@ -3 +3 @@
+//	-local role = k8s.rbac.v1.role;
+//	+local role = k8s.rbacAuthorization.v1.role;
+func (migration *ImportMigration) Diff() string {
+	names := []string{}
+	for name := range migration.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diff strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&diff, "--- %s\n+++ %s\n", name, name)
+		oldLines := strings.Split(string(migration.original[name]), "\n")
+		newLines := strings.Split(string(migration.files[name]), "\n")
+		for i, line := range newLines {
+			if line != oldLines[i] {
+				fmt.Fprintf(&diff, "@@ -%d +%d @@\n-%s\n+%s\n", i+1, i+1, oldLines[i], line)
+			}
+		}
+	}
+	return diff.String()
+}
+
+// `libraryBinding` is what a local of a Jsonnet file is bound to: the
+// library, or a group's file of it (in which case `prefix` is the path
+// of the group, e.g., `rbac`, and `newPrefix` is its path in the new
+// library), and whether it has the aliases of `k.libsonnet`.
+type libraryBinding struct {
+	prefix, newPrefix string
+	aliases           bool
+}
+
+// `migratingFile` is a Jsonnet file being migrated: its text, and the
+// text with its comments and strings blanked, whose offsets are the
+// same.
+type migratingFile struct {
+	name, text, code string
+	bindings         map[string]*libraryBinding
+	edits            []*ImportEdit
+	migration        *ImportMigration
+}
+
+func (f *migratingFile) line(offset int) int {
+	return strings.Count(f.text[:offset], "\n") + 1
+}
+
+func (f *migratingFile) edit(offset int, old, new string) {
+	f.edits = append(f.edits, &ImportEdit{
+		File: f.name, Line: f.line(offset), Offset: offset, Old: old, New: new,
+	})
+}
+
+func (f *migratingFile) unresolved(offset int, text, problem string) {
+	f.migration.Unresolved = append(f.migration.Unresolved, &UnresolvedReference{
+		UsageReference: UsageReference{File: f.name, Line: f.line(offset), Text: text},
+		Problem:        problem,
+	})
+}
+
+// `migrateImports` rewrites the imports of the files of a library split
+// by group to the files of the same groups in the new library, which,
+// if it isn't split, are the groups' fields of `k8s.libsonnet`, e.g.,
+// `(import "vendor/k8s.libsonnet").rbac`. It binds the locals the
+// imports are bound to.
+func (f *migratingFile) migrateImports(m *pathMigrator, groupFiles map[string]string) {
+	for _, match := range importStatementPattern.FindAllStringSubmatchIndex(f.code, -1) {
+		start, end := match[0], match[1]
+		if match[2] != -1 {
+			start = strings.LastIndex(f.code[start:end], "import") + start
+		}
+		for end < len(f.text) && strings.ContainsRune(" \t\r\n", rune(f.text[end])) {
+			end++
+		}
+		literal, ok := importLiteral(f.text, end)
+		if !ok {
+			continue
+		}
+		quote, imported := literal[:1], literal[1:len(literal)-1]
+		local := ""
+		if match[2] != -1 {
+			local = f.code[match[2]:match[3]]
+		}
+
+		base := path.Base(imported)
+		binding := &libraryBinding{aliases: base == AliasesFile}
+		group, isGroupFile := groupFiles[base]
+		switch {
+		case base == LibraryFile || base == AliasesFile:
+		case isGroupFile:
+			statement := f.text[start : end+len(literal)]
+			newGroup, ok := m.migrate(group)
+			if !ok {
+				f.unresolved(start, statement, fmt.Sprintf("the new library has no `%s`", group))
+				continue
+			}
+			binding.prefix, binding.newPrefix = group, newGroup
+			if len(m.to.Files) > 0 {
+				newFile, ok := m.to.Files[newGroup]
+				if !ok {
+					f.unresolved(start, statement, fmt.Sprintf("the new library has no file for `%s`", newGroup))
+					continue
+				}
+				if newFile != base {
+					f.edit(end, literal, quote+path.Join(path.Dir(imported), newFile)+quote)
+				}
+			} else if group == hiddenNamespace {
+				f.unresolved(start, statement, "the hidden objects of the new library can't be imported")
+				continue
+			} else {
+				library := quote + path.Join(path.Dir(imported), LibraryFile) + quote
+				f.edit(start, statement, fmt.Sprintf("(import %s).%s", library, newGroup))
+			}
+		default:
+			continue
+		}
+		if local != "" {
+			f.bindings[local] = binding
+		}
+	}
+}
+
+// `importLiteral` returns the string literal at `offset` of `text`,
+// with its quotes, if there is one there.
+func importLiteral(text string, offset int) (string, bool) {
+	if offset >= len(text) || (text[offset] != '"' && text[offset] != '\'') {
+		return "", false
+	}
+	end := strings.IndexAny(text[offset+1:], string(text[offset])+"\n\\")
+	if end == -1 || text[offset+1+end] != text[offset] {
+		return "", false
+	}
+	return text[offset : offset+end+2], true
+}
+
+// `migrateReferences` rewrites the fields of each reference to the
+// library through a bound local whose path is different in the new
+// library.
+func (f *migratingFile) migrateReferences(m *pathMigrator) {
+	for _, match := range referencePattern.FindAllStringSubmatchIndex(f.code, -1) {
+		binding, ok := f.bindings[f.code[match[2]:match[3]]]
+		if !ok || match[4] == match[5] {
+			continue
+		}
+		old := f.code[match[4]:match[5]]
+		fields := strings.Split(old[1:], ".")
+		text := strings.TrimSpace(f.code[match[2]:match[1]])
+
+		oldPath := joinPath(binding.prefix, strings.Join(fields, "."))
+		alias := ""
+		if binding.aliases {
+			if target, ok := m.from.Aliases[fields[0]]; ok {
+				alias = fields[0]
+				oldPath = joinPath(target, strings.Join(fields[1:], "."))
+			}
+		}
+
+		// Fields after a symbol are kept (e.g., those of a customization
+		// of a kind), but not after a group or version, whose members
+		// are all in the index.
+		known := m.known(oldPath)
+		if symbol := m.oldSymbols[known]; known == "" || (known != oldPath && symbol.Kind == SymbolNamespace && symbol.Definition == "") {
+			f.unresolved(match[2], text, "doesn't refer to anything in the old library")
+			continue
+		}
+		newKnown, ok := m.migrate(known)
+		if !ok {
+			f.unresolved(match[2], text, m.missing(known))
+			continue
+		}
+		newPath := newKnown + strings.TrimPrefix(oldPath, known)
+
+		var newFields string
+		if target, ok := m.to.Aliases[alias]; ok && strings.HasPrefix(newPath+".", target+".") {
+			newFields = alias + strings.TrimPrefix(newPath, target)
+		} else if binding.newPrefix == "" {
+			newFields = newPath
+		} else if strings.HasPrefix(newPath, binding.newPrefix+".") {
+			newFields = strings.TrimPrefix(newPath, binding.newPrefix+".")
+		} else {
+			f.unresolved(match[2], text, fmt.Sprintf(
+				"is `%s` in the new library, which isn't in the file of `%s`", newPath, binding.newPrefix))
+			continue
+		}
+		if "."+newFields != old {
+			f.edit(match[4], old, "."+newFields)
+		}
+	}
+}
+
+// `apply` returns the text of the file with its edits, which are
+// sorted by offset.
+func (f *migratingFile) apply() string {
+	sort.SliceStable(f.edits, func(i, j int) bool {
+		return f.edits[i].Offset < f.edits[j].Offset
+	})
+	var text strings.Builder
+	last := 0
+	for _, edit := range f.edits {
+		text.WriteString(f.text[last:edit.Offset])
+		text.WriteString(edit.New)
+		last = edit.Offset + len(edit.Old)
+	}
+	text.WriteString(f.text[last:])
+	return text.String()
+}
+
+func joinPath(prefix, path string) string {
+	if prefix == "" {
+		return path
+	} else if path == "" {
+		return prefix
+	}
+	return prefix + "." + path
+}
+
+// `pathMigrator` maps the paths of the symbols of one symbol index,
+// `from`, to the paths of the same symbols in another, `to`.
+type pathMigrator struct {
+	from, to      *SymbolIndex
+	oldSymbols    map[string]*Symbol
+	newSymbols    map[string]*Symbol
+	newChildren   map[string][]*Symbol // By the path of their namespace.
+	movedSymbols  map[string]string
+	removedByKind map[string]*KindSymbol
+}
+
+// `newPathMigrator` returns a migrator from `from` to `to`. The
+// namespace of each definition is found in `to` by its definition, and
+// the namespaces it's in move with it, e.g., `rbac` to
+// `rbacAuthorization` if `rbac.v1.role` moved to
+// `rbacAuthorization.v1.role`, unless their definitions disagree.
+func newPathMigrator(from, to *SymbolIndex) *pathMigrator {
+	m := &pathMigrator{
+		from:          from,
+		to:            to,
+		oldSymbols:    from.byPath(),
+		newSymbols:    to.byPath(),
+		newChildren:   map[string][]*Symbol{},
+		movedSymbols:  map[string]string{},
+		removedByKind: map[string]*KindSymbol{},
+	}
+	namespaces := map[kubespec.DefinitionName][]string{}
+	for _, symbol := range to.Symbols {
+		if end := strings.LastIndex(symbol.Path, "."); end != -1 {
+			m.newChildren[symbol.Path[:end]] = append(m.newChildren[symbol.Path[:end]], symbol)
+		}
+		if isDefinitionNamespace(symbol) {
+			namespaces[symbol.Definition] = append(namespaces[symbol.Definition], symbol.Path)
+		}
+	}
+
+	disagree := map[string]bool{}
+	for _, symbol := range from.Symbols {
+		if paths := namespaces[symbol.Definition]; isDefinitionNamespace(symbol) && len(paths) == 1 {
+			m.movedSymbols[symbol.Path] = paths[0]
+			oldParts, newParts := strings.Split(symbol.Path, "."), strings.Split(paths[0], ".")
+			if len(oldParts) != len(newParts) {
+				continue
+			}
+			for i := 1; i < len(oldParts); i++ {
+				oldPath, newPath := strings.Join(oldParts[:i], "."), strings.Join(newParts[:i], ".")
+				if moved, ok := m.movedSymbols[oldPath]; ok && moved != newPath {
+					disagree[oldPath] = true
+				}
+				m.movedSymbols[oldPath] = newPath
+			}
+		}
+	}
+	for path := range disagree {
+		delete(m.movedSymbols, path)
+	}
+
+	for _, kind := range from.Kinds {
+		if kind.ReplacementPath != "" {
+			m.removedByKind[kind.Path] = kind
+		}
+	}
+	return m
+}
+
+// `isDefinitionNamespace` reports whether `symbol` is the namespace of
+// a definition, e.g., `apps.v1.deployment`, or
+// `hidden.apps.v1.deploymentSpec`, rather than of one of its properties.
+func isDefinitionNamespace(symbol *Symbol) bool {
+	return symbol.Kind == SymbolNamespace && symbol.Definition != "" && symbol.Property == ""
+}
+
+// `known` returns the longest prefix of `path` that's the path of a
+// symbol in `from`, or the empty string if none is.
+func (m *pathMigrator) known(path string) string {
+	for prefix := path; prefix != ""; {
+		if _, ok := m.oldSymbols[prefix]; ok {
+			return prefix
+		}
+		end := strings.LastIndex(prefix, ".")
+		if end == -1 {
+			break
+		}
+		prefix = prefix[:end]
+	}
+	return ""
+}
+
+// `migrate` returns the path in `to` of the symbol at `path` in `from`
+// (or, for a group namespace, the namespace that `path` is in `from`),
+// if it has one.
+func (m *pathMigrator) migrate(path string) (string, bool) {
+	if moved, ok := m.movedSymbols[path]; ok {
+		return moved, true
+	}
+	end := strings.LastIndex(path, ".")
+	if end == -1 {
+		_, ok := m.newSymbols[path]
+		return path, ok || len(m.newChildren[path]) > 0
+	}
+	parent, ok := m.migrate(path[:end])
+	if !ok {
+		return "", false
+	}
+	name := path[end+1:]
+	old := m.oldSymbols[path]
+	if symbol, ok := m.newSymbols[parent+"."+name]; ok && (old == nil || sameProperty(old, symbol)) {
+		if symbol.Deprecated && symbol.ReplacedBy != "" {
+			return symbol.ReplacedBy, true
+		}
+		return symbol.Path, true
+	}
+	if old == nil || old.Property == "" {
+		return "", false
+	}
+
+	// A renamed property (e.g., `externalIPs`, which another naming
+	// strategy names `externalIps`) is found by the property it's for.
+	found := ""
+	for _, symbol := range m.newChildren[parent] {
+		newName := symbol.Path[len(parent)+1:]
+		if sameProperty(old, symbol) && (symbol.RenamedFrom == name || sameIdentifier(name, newName)) {
+			if found != "" {
+				return "", false
+			}
+			found = symbol.Path
+		}
+	}
+	return found, found != ""
+}
+
+// `missing` describes why the symbol at `path` of `from` isn't in
+// `to`, naming the replacement of its kind, if it has one.
+func (m *pathMigrator) missing(path string) string {
+	for kindPath, kind := range m.removedByKind {
+		if path == kindPath || strings.HasPrefix(path, kindPath+".") {
+			return fmt.Sprintf(
+				"isn't in the new library; Kubernetes %s replaces it with `%s` (`%s`)",
+				kind.RemovedIn, kind.ReplacementPath, kind.Replacement)
+		}
+	}
+	return "isn't in the new library"
+}
+
+// `sameProperty` reports whether two symbols are the same sort of
+// symbol for the same property of the same definition, e.g., its
+// setter.
+func sameProperty(a, b *Symbol) bool {
+	return a.Kind == b.Kind && a.Definition == b.Definition &&
+		a.Property == b.Property && a.Items == b.Items
+}
+
+// `sameIdentifier` reports whether two names of the same symbol are
+// the same but for case and a trailing underscore, as when they're
+// named by different naming strategies (e.g., `withExternalIPs` and
+// `withExternalIps`), or one is renamed to avoid a reserved identifier.
+func sameIdentifier(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "_"), strings.TrimRight(b, "_"))
+}
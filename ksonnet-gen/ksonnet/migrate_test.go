@@ -0,0 +1,130 @@
+package ksonnet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `migrationIndexes` returns the symbol indexes of the libraries that
+// `testdata/migrate` is migrated between; see its README.
+func migrationIndexes(t *testing.T) (from, to *SymbolIndex) {
+	spec := loadTestSpec(t, "testdata/migrate/swagger.json")
+	from, err := BuildSymbolIndex(spec, Options{SplitByGroup: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	spec = spec.Filter(func(name kubespec.DefinitionName, _ *kubespec.SchemaDefinition) bool {
+		return name != "io.k8s.api.apps.v1beta1.Deployment"
+	})
+	to, err = BuildSymbolIndex(spec, Options{
+		NamingStrategy: jsonnet.NamingStrategyInitialisms,
+		Namespaces:     map[string]string{"api/rbac": "rbacAuthorization"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	delete(to.Aliases, "deployment")
+	return from, to
+}
+
+func TestMigrateImports(t *testing.T) {
+	from, to := migrationIndexes(t)
+	before := readDir(t, "testdata/migrate/before")
+	migration := MigrateImports(from, to, before)
+
+	after := map[string]string{}
+	for name, text := range before {
+		after[name] = string(text)
+	}
+	for name, text := range migration.Files() {
+		after[name] = string(text)
+	}
+	if *updateGolden {
+		writeGoldenDir(t, "testdata/migrate/after", after)
+	}
+	compareGoldenDir(t, "testdata/migrate/after", after)
+
+	// The files passed in are left as they are.
+	if text := string(before["lib/web.libsonnet"]); !strings.Contains(text, "k8s.rbac.v1.role.mixin") {
+		t.Errorf("Expected the files passed in not to be modified, got:\n%s", text)
+	}
+	if _, ok := migration.Files()["vendor/k.libsonnet"]; ok {
+		t.Errorf("Expected the library's own files not to be migrated")
+	}
+
+	unresolved := []string{}
+	for _, ref := range migration.Unresolved {
+		unresolved = append(unresolved, ref.String())
+	}
+	expected := []string{
+		"environments/prod/main.jsonnet:11: k.apps.v1beta1.deployment.new: isn't in the new library; Kubernetes v1.16 replaces it with `apps.v1.deployment` (`apps/v1`)",
+		"environments/prod/main.jsonnet:11: k.apps.v1beta1.deployment.paused: isn't in the new library; Kubernetes v1.16 replaces it with `apps.v1.deployment` (`apps/v1`)",
+		"environments/prod/main.jsonnet:12: k.core.v1.servce.new: doesn't refer to anything in the old library",
+	}
+	if !reflect.DeepEqual(unresolved, expected) {
+		t.Errorf("Expected the unresolved references:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(unresolved, "\n"))
+	}
+
+	diff := migration.Diff()
+	for _, expected := range []string{
+		"--- lib/web.libsonnet\n+++ lib/web.libsonnet\n",
+		"--- environments/prod/main.jsonnet\n+++ environments/prod/main.jsonnet\n@@ -3 +3 @@\n-local rbac = import '../../vendor/rbac-authorization-k8s-io.libsonnet';\n+local rbac = (import '../../vendor/k8s.libsonnet').rbacAuthorization;\n",
+	} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expected the diff to have:\n%s\ngot:\n%s", expected, diff)
+		}
+	}
+	if strings.Count(diff, "@@ -") != changedLines(before, after) {
+		t.Errorf("Expected the diff to have a hunk for each line that changed, got:\n%s", diff)
+	}
+}
+
+// `changedLines` counts the lines of `after` that differ from those of
+// `before`, which it has as many of.
+func changedLines(before map[string][]byte, after map[string]string) int {
+	changed := 0
+	for name, text := range before {
+		oldLines, newLines := strings.Split(string(text), "\n"), strings.Split(after[name], "\n")
+		for i := range oldLines {
+			if oldLines[i] != newLines[i] {
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+// Between two libraries split by group, imports of a group's file are
+// rewritten to the file it's in now.
+func TestMigrateImportsFileNaming(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/migrate/swagger.json")
+	from, err := BuildSymbolIndex(spec, Options{SplitByGroup: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	to, err := BuildSymbolIndex(spec, Options{SplitByGroup: true, FileNaming: FileNamingNamespace})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	files := map[string][]byte{
+		"a.jsonnet": []byte("local rbac = import \"vendor/rbac-authorization-k8s-io.libsonnet\";\nrbac.v1.role.new()\n"),
+		"b.jsonnet": []byte("local k8s = import \"vendor/k8s.libsonnet\";\nk8s.rbac.v1.role.new()\n"),
+	}
+	migration := MigrateImports(from, to, files)
+	expected := map[string][]byte{
+		"a.jsonnet": []byte("local rbac = import \"vendor/rbac.libsonnet\";\nrbac.v1.role.new()\n"),
+	}
+	if !reflect.DeepEqual(migration.Files(), expected) || len(migration.Unresolved) != 0 {
+		t.Errorf("Expected only the import to be rewritten, got %q and %v", migration.Files(), migration.Unresolved)
+	}
+
+	// Migrating to the same library changes nothing.
+	if migration := MigrateImports(from, from, files); len(migration.Edits) != 0 || migration.Diff() != "" {
+		t.Errorf("Expected no edits between the same libraries, got %v", migration.Edits)
+	}
+}
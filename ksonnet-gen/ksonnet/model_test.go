@@ -0,0 +1,98 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// saveAndReload writes `s` as a model and reads it back.
+func saveAndReload(t testing.TB, s *kubespec.APISpec) *kubespec.APISpec {
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("Failed to write model:\n%v", err)
+	}
+	reloaded, err := kubespec.ReadSpecFrom(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read model:\n%v", err)
+	}
+	return reloaded
+}
+
+// TestModelRoundTrip checks that every file generated from a spec
+// reloaded from its model is identical to the file generated from the
+// spec, for each spec of the corpus, and the fixtures whose schemas
+// have examples, extensions, and constraints, with each option of
+// the corpus.
+func TestModelRoundTrip(t *testing.T) {
+	paths := []string{"testdata/examples.json", "testdata/validation.json", "testdata/unions.json", "testdata/listtypes.json"}
+	for _, spec := range corpusSpecs {
+		paths = append(paths, filepath.Join(corpusDir, spec.name+".json"))
+	}
+	for _, path := range paths {
+		original := loadTestSpec(t, path)
+		reloaded := saveAndReload(t, original)
+		for _, c := range corpusOptions {
+			expected, err := EmitArtifacts(context.Background(), original, c.opts)
+			if err != nil {
+				t.Fatalf("Failed to emit library from '%s':\n%v", path, err)
+			}
+			actual, err := EmitArtifacts(context.Background(), reloaded, c.opts)
+			if err != nil {
+				t.Fatalf("Failed to emit library from the model of '%s':\n%v", path, err)
+			}
+			if len(actual) != len(expected) {
+				t.Errorf("%s/%s: Expected %d files from the model, got %d", path, c.name, len(expected), len(actual))
+			}
+			for name, artifact := range expected {
+				if other, ok := actual[name]; !ok || !bytes.Equal(other.Text, artifact.Text) {
+					t.Errorf("%s/%s: Expected '%s' from the model to be identical to the one from the spec", path, c.name, name)
+				}
+			}
+		}
+	}
+}
+
+// The benchmarks compare loading the largest spec of the corpus by
+// parsing it with loading it from its model.
+const benchmarkSpec = corpusDir + "/v1.9.0.json"
+
+func BenchmarkLoadSpec(b *testing.B) {
+	text, err := ioutil.ReadFile(benchmarkSpec)
+	if err != nil {
+		b.Fatalf("Could not read '%s':\n%v", benchmarkSpec, err)
+	}
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kubespec.ReadSpec(benchmarkSpec, bytes.NewReader(text)); err != nil {
+			b.Fatalf("Failed to read spec:\n%v", err)
+		}
+	}
+}
+
+func BenchmarkLoadModel(b *testing.B) {
+	text, err := ioutil.ReadFile(benchmarkSpec)
+	if err != nil {
+		b.Fatalf("Could not read '%s':\n%v", benchmarkSpec, err)
+	}
+	s, err := kubespec.ReadSpec(benchmarkSpec, bytes.NewReader(text))
+	if err != nil {
+		b.Fatalf("Failed to read spec:\n%v", err)
+	}
+	var model bytes.Buffer
+	if _, err := s.WriteTo(&model); err != nil {
+		b.Fatalf("Failed to write model:\n%v", err)
+	}
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := kubespec.ReadSpecFrom(bytes.NewReader(model.Bytes())); err != nil {
+			b.Fatalf("Failed to read model:\n%v", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package ksonnet
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// namespacePattern is what the values of `Options.Namespaces` must
+// look like: a lowerCamelCase identifier, which the naming strategies
+// leave as it is.
+var namespacePattern = regexp.MustCompile(`^[a-z][A-Za-z0-9_]*$`)
+
+// `validateNamespace` reports whether `key` and `namespace` are a
+// valid entry of `Options.Namespaces`.
+func validateNamespace(key, namespace string) error {
+	parts := strings.Split(key, "/")
+	for _, part := range parts {
+		if part == "" || len(parts) > 2 {
+			return fmt.Errorf(
+				"Namespaces must be keyed by 'codebase' or 'codebase/group', got '%s'", key)
+		}
+	}
+	if !namespacePattern.MatchString(namespace) ||
+		jsonnet.IsReservedIdentifier(jsonnet.Identifier(namespace)) {
+		return fmt.Errorf(
+			"Namespaces maps '%s' to '%s', which isn't a lowerCamelCase identifier", key, namespace)
+	}
+	return nil
+}
+
+// `namespaceKeys` returns the keys of `Options.Namespaces` that apply
+// to a definition, most specific first, e.g., `api/apps` and `api` for
+// `io.k8s.api.apps.v1.Deployment`, or `kubernetes/core` and
+// `kubernetes` for `io.k8s.kubernetes.pkg.api.v1.Pod`.
+func namespaceKeys(parsedName kubespec.ParsedName) []string {
+	codebase := parsedName.QualifiedCodebase()
+	group := "core"
+	if parsedName.HasGroup() {
+		group = string(parsedName.Group)
+	}
+	return []string{codebase + "/" + group, codebase}
+}
+
+// `mappedNamespace` returns the namespace `Options.Namespaces` puts a
+// definition in, if any.
+func (root *root) mappedNamespace(parsedName kubespec.ParsedName) (kubespec.GroupName, bool) {
+	for _, key := range namespaceKeys(parsedName) {
+		if namespace, ok := root.opts.Namespaces[key]; ok {
+			return kubespec.GroupName(namespace), true
+		}
+	}
+	return "", false
+}
+
+// `namespaceMappingErrors` checks that each key of `Options.Namespaces`
+// names a codebase of `spec`, and, if it has a group, one of that
+// codebase's groups (see `kubespec.Parser.Codebases`), since a mapping
+// that matches no definition is most likely misspelled.
+func (root *root) namespaceMappingErrors(spec *kubespec.APISpec) []error {
+	errors := []error{}
+	if len(root.opts.Namespaces) == 0 {
+		return errors
+	}
+	codebases := root.parser.Codebases(spec)
+	names := []string{}
+	for codebase := range codebases {
+		names = append(names, codebase)
+	}
+	sort.Strings(names)
+
+	keys := []string{}
+	for key := range root.opts.Namespaces {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 2)
+		gvs, ok := codebases[parts[0]]
+		if !ok {
+			errors = append(errors, fmt.Errorf(
+				"Namespaces maps '%s', but the spec has no codebase '%s'; its codebases are %s",
+				key, parts[0], strings.Join(names, ", ")))
+			continue
+		}
+		if len(parts) == 1 {
+			continue
+		}
+		groups := []string{}
+		found := false
+		for _, gv := range gvs {
+			if len(groups) == 0 || groups[len(groups)-1] != string(gv.Group) {
+				groups = append(groups, string(gv.Group))
+			}
+			found = found || string(gv.Group) == parts[1]
+		}
+		if !found {
+			errors = append(errors, fmt.Errorf(
+				"Namespaces maps '%s', but codebase '%s' has no group '%s'; its groups are %s",
+				key, parts[0], parts[1], strings.Join(groups, ", ")))
+		}
+	}
+	return errors
+}
+
+// `namespaceCollisions` checks that `Options.Namespaces` keeps every
+// definition at a path of its own, and that each top-level group only
+// has kinds of one API group, since they share its `apiVersion`. It
+// returns the problems, and the definitions to leave out so that the
+// rest of the library can still be built: every one but the first, in
+// order of name, of those that collide. Without a mapping, there's
+// nothing to check.
+func (root *root) namespaceCollisions() ([]error, map[kubespec.DefinitionName]bool) {
+	errors := []error{}
+	collided := map[kubespec.DefinitionName]bool{}
+	if len(root.opts.Namespaces) == 0 {
+		return errors, collided
+	}
+
+	names := []string{}
+	for name := range root.spec.Definitions {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	type location struct {
+		hidden  bool
+		group   kubespec.GroupName
+		version kubespec.VersionString
+		kind    kubespec.ObjectKind
+	}
+	type groupOwner struct {
+		name     kubespec.DefinitionName
+		apiGroup kubespec.GroupName
+	}
+	paths := map[location]kubespec.DefinitionName{}
+	owners := map[kubespec.GroupName]groupOwner{}
+	for _, n := range names {
+		name := kubespec.DefinitionName(n)
+		parsedName := root.parseName(name)
+		if !parsedName.HasVersion() {
+			continue
+		}
+		hidden := !root.spec.Classify(name).IsTopLevel()
+		group, apiGroup := root.groupName(parsedName)
+
+		at := location{hidden, group, parsedName.Version, parsedName.Kind}
+		if other, ok := paths[at]; ok {
+			errors = append(errors, kubespec.Categorize(ErrUnsupportedSchema, fmt.Errorf(
+				"Namespaces puts both '%s' and '%s' at '%s.%s.%s'",
+				other, name, group, parsedName.Version, parsedName.Kind)))
+			collided[name] = true
+			continue
+		}
+		paths[at] = name
+		if hidden {
+			continue
+		}
+
+		if apiGroup == "" {
+			apiGroup = group
+		}
+		if owner, ok := owners[group]; !ok {
+			owners[group] = groupOwner{name, apiGroup}
+		} else if owner.apiGroup != apiGroup {
+			errors = append(errors, kubespec.Categorize(ErrUnsupportedSchema, fmt.Errorf(
+				"Namespaces puts '%s' (API group '%s') and '%s' (API group '%s') in the same namespace '%s', whose kinds share one apiVersion",
+				owner.name, owner.apiGroup, name, apiGroup, group)))
+			collided[name] = true
+		}
+	}
+	return errors, collided
+}
@@ -0,0 +1,144 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// aggregatorNamespaces moves the aggregator into a namespace of its
+// own, and the CRD kinds into a group that's named after them.
+var aggregatorNamespaces = map[string]string{
+	"kube-aggregator":                       "aggregator",
+	"apiextensions-apiserver/apiextensions": "crds",
+}
+
+func TestNamespaces(t *testing.T) {
+	for name, namespaces := range map[string]map[string]string{
+		"default": nil,
+		"custom":  aggregatorNamespaces,
+	} {
+		opts := Options{Namespaces: namespaces}
+		library := withoutHeader(emitTestSpec(t, "testdata/namespaces.json", opts))
+
+		aliases, err := EmitAliases(loadTestSpec(t, "testdata/namespaces.json"), opts)
+		if err != nil {
+			t.Fatalf("Failed to emit aliases with the %s namespaces:\n%v", name, err)
+		}
+		output := library + "\n// k.libsonnet\n" + string(aliases)
+
+		golden := fmt.Sprintf("testdata/namespaces.%s.golden", name)
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, []byte(output), 0644); err != nil {
+				t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+			}
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+		}
+		if output != string(expected) {
+			t.Errorf(
+				"Library for the %s namespaces differs from '%s'; run `go test -update` and diff:\n%s",
+				name, golden, output)
+		}
+	}
+
+	// The default mapping is the layout without one.
+	if actual, expected := emitTestSpec(t, "testdata/namespaces.json", Options{Namespaces: map[string]string{}}),
+		emitTestSpec(t, "testdata/namespaces.json", Options{}); actual != expected {
+		t.Errorf("Expected an empty mapping to change nothing, got:\n%s", actual)
+	}
+
+	// Moved kinds keep their apiVersion, and the index and aliases
+	// follow them.
+	library := emitTestSpec(t, "testdata/namespaces.json", Options{Namespaces: aggregatorNamespaces})
+	if !strings.Contains(library, "  aggregator:: {\n    v1beta1:: {\n      local apiVersion = {apiVersion: \"apiregistration.k8s.io/v1beta1\"},") {
+		t.Errorf("Expected the aggregator namespace to keep the apiregistration apiVersion, got:\n%s", library)
+	}
+	if strings.Contains(library, "apiregistration::") || strings.Contains(library, "apiextensions::") {
+		t.Errorf("Expected the mapped groups not to be emitted under their own names, got:\n%s", library)
+	}
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/namespaces.json"), Options{Namespaces: aggregatorNamespaces})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]bool{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = true
+	}
+	for _, path := range []string{
+		"aggregator.v1beta1.aPIService.new",
+		"hidden.aggregator.v1beta1.serviceReference.name",
+		"crds.v1beta1.customResourceDefinition.mixin.spec.group",
+	} {
+		if !symbols[path] {
+			t.Errorf("Expected the symbol index to have '%s'", path)
+		}
+	}
+	if target := index.Aliases["aPIService"]; target != "aggregator.v1beta1.aPIService" {
+		t.Errorf("Expected the 'aPIService' alias to point at the aggregator namespace, got '%s'", target)
+	}
+
+	// The index says which codebase contributed which groups, whatever
+	// namespaces they're mapped to.
+	codebases := map[string][]kubespec.GroupVersion{
+		"api": {
+			{Group: "admissionregistration", Version: "v1beta1"},
+			{Group: "core", Version: "v1"},
+		},
+		"apiextensions-apiserver": {{Group: "apiextensions", Version: "v1beta1"}},
+		"apimachinery":            {{Group: "meta", Version: "v1"}},
+		"kube-aggregator":         {{Group: "apiregistration", Version: "v1beta1"}},
+	}
+	if !reflect.DeepEqual(index.Codebases, codebases) {
+		t.Errorf("Expected the symbol index to have codebases %v, got %v", codebases, index.Codebases)
+	}
+}
+
+func TestNamespaceCollisions(t *testing.T) {
+	tests := map[string]map[string]string{
+		// The aggregator's `ServiceReference` is hidden where
+		// admissionregistration's is.
+		"Namespaces puts both 'io.k8s.api.admissionregistration.v1beta1.ServiceReference' and 'io.k8s.kube-aggregator.pkg.apis.apiregistration.v1beta1.ServiceReference' at 'admissionregistration.v1beta1.ServiceReference'": {
+			"kube-aggregator/apiregistration": "admissionregistration",
+		},
+		// `APIService` and `ConfigMap` would share `apiVersion: v1`.
+		"Namespaces puts 'io.k8s.api.core.v1.ConfigMap' (API group 'core') and 'io.k8s.kube-aggregator.pkg.apis.apiregistration.v1beta1.APIService' (API group 'apiregistration.k8s.io') in the same namespace 'core', whose kinds share one apiVersion": {
+			"kube-aggregator": "core",
+		},
+	}
+	for expected, namespaces := range tests {
+		spec := loadTestSpec(t, "testdata/namespaces.json")
+		opts := Options{Namespaces: namespaces}
+		if _, err := Emit(context.Background(), spec, opts); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected emitting with %v to fail with:\n%s\ngot:\n%v", namespaces, expected, err)
+		}
+		if _, err := EmitAliases(spec, opts); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected the aliases with %v to fail with:\n%s\ngot:\n%v", namespaces, expected, err)
+		}
+	}
+}
+
+func TestNamespaceMappingErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"Namespaces maps 'kube-agregator', but the spec has no codebase 'kube-agregator'; its codebases are api, apiextensions-apiserver, apimachinery, kube-aggregator": {
+			"kube-agregator": "aggregator",
+		},
+		"Namespaces maps 'api/apps', but codebase 'api' has no group 'apps'; its groups are admissionregistration, core": {
+			"api/apps": "workloads",
+		},
+	}
+	for expected, namespaces := range tests {
+		spec := loadTestSpec(t, "testdata/namespaces.json")
+		opts := Options{Namespaces: namespaces}
+		if _, err := Emit(context.Background(), spec, opts); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected emitting with %v to fail with:\n%s\ngot:\n%v", namespaces, expected, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ksonnet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// forbiddenCalls are the functions that only package main may call,
+// keyed by import path, since they exit without running defers, and
+// can't be recovered by programs that embed the library.
+var forbiddenCalls = map[string]map[string]bool{
+	"log": {"Fatal": true, "Fatalf": true, "Fatalln": true},
+	"os":  {"Exit": true},
+}
+
+// Every package of the module but package main reports errors, or
+// panics, rather than exiting.
+func TestNoExitOutsideMain(t *testing.T) {
+	fset := token.NewFileSet()
+	checked := 0
+	err := filepath.Walk("..", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "testdata" || (strings.HasPrefix(info.Name(), ".") && path != "..") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		if file.Name.Name == "main" {
+			return nil
+		}
+		checked++
+
+		// The names the file imports the packages of `forbiddenCalls` as.
+		imported := map[string]string{}
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			if _, ok := forbiddenCalls[importPath]; !ok {
+				continue
+			}
+			name := importPath
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imported[name] = importPath
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := selector.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if importPath, ok := imported[pkg.Name]; ok && forbiddenCalls[importPath][selector.Sel.Name] {
+				t.Errorf("%s: calls %s.%s outside package main; return an error, or panic, instead",
+					fset.Position(call.Pos()), importPath, selector.Sel.Name)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Could not scan the module's packages:\n%v", err)
+	}
+	if checked == 0 {
+		t.Fatalf("Expected to find the module's packages")
+	}
+}
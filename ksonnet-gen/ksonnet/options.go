@@ -0,0 +1,535 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// Options customizes the library generated by `Emit`. The zero value
+// generates the default library. The `yaml` tags name the options in
+// generation profiles (see package `profile`); the callbacks can't be
+// set by one.
+type Options struct {
+	// DeprecateClusterScopedNamespace, when set, emits the
+	// `metadata.namespace` mixin for cluster-scoped kinds (e.g.,
+	// `Namespace`, `Node`) with a deprecation comment, rather than
+	// omitting it.
+	DeprecateClusterScopedNamespace bool `yaml:"deprecateClusterScopedNamespace"`
+
+	// NamingStrategy decides how identifiers from the spec become the
+	// names of namespaces, setters, and mixins (and so the paths in the
+	// symbol index), e.g., whether `externalIPs` gets the setter
+	// `externalIPs` or `externalIps`, and, with
+	// `jsonnet.NamingStrategyUniformParams`, the names of parameters.
+	// Field keys are never renamed. The zero value is
+	// `jsonnet.NamingStrategyCurated`.
+	NamingStrategy jsonnet.NamingStrategy `yaml:"namingStrategy"`
+
+	// Customizations maps the path of a namespace in the library
+	// (e.g., `apps.v1beta1.deployment`) to Jsonnet fields to add to it
+	// (e.g., `withRolloutAnnotation(reason):: ...,`), which are merged
+	// into the generated namespace verbatim. It's an error to customize
+	// a namespace that isn't generated, or, unless AllowOverrides is
+	// set, to define a field that's already generated.
+	Customizations map[string]string `yaml:"customizations"`
+	AllowOverrides bool              `yaml:"allowOverrides"`
+
+	// Namespaces overrides the top-level namespace of the library that
+	// the definitions of a codebase, or of one group of it, are emitted
+	// in (e.g., `kube-aggregator/apiregistration: core`, to fold the
+	// aggregator's kinds into the main tree). Codebases are named as in
+	// definition names (e.g., `kubernetes`, `api`, `kube-aggregator`),
+	// qualified by their prefix if it isn't `io.k8s` (e.g.,
+	// `com.github.openshift.api`), and the group without one is `core`.
+	// A `codebase/group` key wins over a `codebase` one. Each value
+	// must be a lowerCamelCase identifier. The `apiVersion` of moved
+	// kinds is unchanged. It's an error for the mapping to put two
+	// definitions at the same path, or kinds with different API groups
+	// in the same top-level namespace. By default, every definition
+	// keeps its namespace; see `groupName`.
+	Namespaces map[string]string `yaml:"namespaces"`
+
+	// NamespacePrefix, if set, is a dotted path of lowerCamelCase
+	// identifiers (e.g., `lib.k8s`) under which the whole tree of the
+	// library is nested, so that `apps.v1.deployment` is
+	// `lib.k8s.apps.v1.deployment` from the top of `k8s.libsonnet`.
+	// The aliases of `k.libsonnet` are nested with it (e.g.,
+	// `lib.k8s.deployment`), as are the paths of the symbol index (but
+	// for those of hidden objects), `gvkIndex.libsonnet`, `INDEX.md`,
+	// and the examples of the library's comments. The keys of
+	// `Customizations` are still paths in the tree, without the prefix.
+	NamespacePrefix string `yaml:"namespacePrefix"`
+
+	// HeaderTemplate, if set, is a Go `text/template` whose output
+	// replaces the comments that start each `.libsonnet` file of the
+	// library, e.g., to add a license header. It's executed with a
+	// `HeaderData`, whose `Default` is the header the file would have
+	// otherwise; since `LibraryAPIVersions` reads the apiVersions from
+	// the header of `k8s.libsonnet`, templates that leave out
+	// `{{.Default}}` should keep that line. Its output should be
+	// Jsonnet comments; it's written as is, even with `OmitComments`.
+	// Errors parsing or executing it fail generation, with the line of
+	// the template.
+	HeaderTemplate string `yaml:"headerTemplate"`
+
+	// MaxInlineDepth limits how deeply nested inline object schemas
+	// (e.g., in CRDs) are given definitions, and so mixins, of their
+	// own; see `kubespec.APISpec.WithInlineDefinitions`. Deeper objects
+	// get plain setters. The zero value is `DefaultMaxInlineDepth`.
+	MaxInlineDepth int `yaml:"maxInlineDepth"`
+
+	// CommentExamples, when set, adds the example value the spec gives
+	// a property to its comment, if the example is a short scalar (see
+	// `kubespec.ScalarExample`), e.g., "Example: `8080`.". Other
+	// examples only appear in `INDEX.md`.
+	CommentExamples bool `yaml:"commentExamples"`
+
+	// EmbedRawSchemas, when set, adds the hidden `rawSchemas` namespace
+	// to the library, with the original JSON schema of each definition
+	// that's skipped because the library can't model it (i.e.,
+	// unparsable, malformed, and unsupported definitions; see
+	// `SkipReason`), for tools of their own to introspect. Definitions
+	// that are filtered out or blacklisted aren't embedded.
+	EmbedRawSchemas bool `yaml:"embedRawSchemas"`
+
+	// EmitPatchHelpers, when set, adds a `patch` namespace to each
+	// top-level kind, with helpers that return JSON Patch operations
+	// and strategic-merge patches of its fields, e.g.,
+	// `deployment.patch.spec.replace("replicas", 3)`. It's opt-in,
+	// since it nests a namespace for every object field of the kind.
+	EmitPatchHelpers bool `yaml:"emitPatchHelpers"`
+
+	// EmitPresets, when set, adds the `presets` namespace to the
+	// library, with a function for each of the best-practice presets of
+	// its version of Kubernetes (see `kubeversion.Presets`) that
+	// returns a mixin of them, e.g., `presets.securityHardened()` for a
+	// `PodSpec`. The mixins are made of the library's own setters, and
+	// leave out the fields the spec doesn't have.
+	EmitPresets bool `yaml:"emitPresets"`
+
+	// EmitValidation, when set, makes the setters of properties whose
+	// schemas bound their values (`minimum`, `maximum`, `minLength`,
+	// and `maxLength`, as CRD schemas often do) assert the bounds, e.g.,
+	// "'replicas' must be at least 0, got -1". Values of other types
+	// than the bound's aren't checked. Like any Jsonnet assertion, they
+	// fail when the object is evaluated (e.g., manifested), not when
+	// the setter is called. `pattern` is only documented, since Jsonnet
+	// has no regular expressions. Whether or not it's set, the bounds
+	// are in the setters' comments.
+	EmitValidation bool `yaml:"emitValidation"`
+
+	// EmitFieldOrder, when set, adds a hidden `fieldOrder` array to the
+	// namespace of each top-level kind, listing the order its fields
+	// read best in, e.g., for rendering YAML to review: `apiVersion`,
+	// `kind`, `metadata`, and `spec` first, then the rest in the order
+	// the spec declares them. It also adds `util.reorder(obj, order)`,
+	// which rebuilds an object with its fields in that order; see
+	// `emitUtil`.
+	EmitFieldOrder bool `yaml:"emitFieldOrder"`
+
+	// EmitGVKIndex, when set, makes `EmitArtifacts` also write
+	// `gvkIndex.libsonnet`, which maps the `apiVersion` and `kind` of
+	// each top-level kind to its namespace (see `EmitGVKIndex`), and
+	// adds `util.forManifest(obj)`, which returns the namespace of the
+	// kind of a manifest, e.g., to apply a mixin to whatever workload it
+	// is.
+	EmitGVKIndex bool `yaml:"emitGVKIndex"`
+
+	// EmitMerge, when set, adds `util.merge(kindPath, a, b)`, which
+	// merges the object `b` into `a`, both of the kind at `kindPath`
+	// (e.g., `apps.v1.deployment`), by the kind's schema, rather than as
+	// `+` does, which replaces lists, and a `merge(other)` mixin to each
+	// top-level kind, which does the same for the object it's added to:
+	// lists with `x-kubernetes-list-map-keys` or
+	// `x-kubernetes-patch-merge-key` are merged item by item, by their
+	// keys, lists whose `x-kubernetes-list-type` is `set` by value, and
+	// objects and maps field by field, while atomic lists, other lists,
+	// and scalars take the right-hand value. The strategies are compiled
+	// into tables, which the library's `__merge` interprets. It can't
+	// be combined with SplitByGroup.
+	EmitMerge bool `yaml:"emitMerge"`
+
+	// EmitStringifiedSetters, when set, gives the setters of the maps of
+	// strings whose values are most often passed as numbers or booleans,
+	// which the server rejects (a ConfigMap's `data`, a Secret's
+	// `stringData`, and annotations; see
+	// `kubeversion.IsStringifiedProperty`), a `Stringified` variant,
+	// e.g., `withDataStringified(data)`, which converts the values with
+	// `util.toString`. The plain setters still pass their values through
+	// unchanged. It can't be combined with SplitByGroup.
+	EmitStringifiedSetters bool `yaml:"emitStringifiedSetters"`
+
+	// EmitQuantity, when set, adds `util.quantity`, whose functions
+	// compute with quantities such as resource requests and limits
+	// (e.g., `"500m"` and `"1.5Gi"`): `parse(q)` returns a quantity's
+	// value in base units, `format(n, suffix)` writes a number with a
+	// suffix, and `add(a, b)` and `scale(q, factor)` return quantities.
+	// The suffixes are those Kubernetes accepts, binary, decimal, and
+	// exponents, and an unknown one fails. The namespace is the same for
+	// every spec.
+	EmitQuantity bool `yaml:"emitQuantity"`
+
+	// EmitOptionsHelpers, when set, adds helpers to `util` for the
+	// parameters of operations, from the paths of the spec:
+	// `deleteOptions.new(...)`, with a named parameter for each field of
+	// the body of deletes (e.g., `new(propagationPolicy="Foreground",
+	// gracePeriodSeconds=0)`), `listOptions.new(...)`, with one for each
+	// query parameter of lists, and `listSelectors(labelMap, fieldMap)`,
+	// which renders maps as selector strings, escaping commas and equals
+	// signs in values. Arguments are checked against the types of their
+	// fields.
+	EmitOptionsHelpers bool `yaml:"emitOptionsHelpers"`
+
+	// SplitByGroup, when set, makes `EmitArtifacts` write the library
+	// as a file per group, which `k8s.libsonnet` imports, rather than
+	// as one file; see `EmitSplit`. FileNaming decides how the files
+	// are named after their groups; the zero value is
+	// `FileNamingDashes`.
+	SplitByGroup bool       `yaml:"splitByGroup"`
+	FileNaming   FileNaming `yaml:"fileNaming"`
+
+	// SplitHidden, with SplitByGroup, writes the hidden objects of each
+	// group to a file of their own too (e.g., `hidden.core.libsonnet`),
+	// which `hidden.libsonnet` imports as the group's namespace. Jsonnet
+	// evaluates fields, imports included, only when they're accessed,
+	// but parses each file it imports whole, so a program that uses a
+	// few kinds then loads only the hidden groups they refer to, rather
+	// than every group's.
+	SplitHidden bool `yaml:"splitHidden"`
+
+	// ImportBase, when set, makes the library's files import each other
+	// by absolute paths under it (e.g.,
+	// `github.com/ourorg/k8s-libsonnet/apps.libsonnet`), which Jsonnet
+	// resolves through its library path (`-J`, e.g., a `vendor`
+	// directory the library is vendored into at that path), rather than
+	// by paths relative to the importing file, which is the default.
+	// `EmitArtifacts` checks that the imports resolve with the library
+	// there; see `VerifyImports`.
+	ImportBase string `yaml:"importBase"`
+
+	// ExternalRefs, when set, links the library to other libraries
+	// generated by ksonnet-gen (e.g., a core library, for a library of
+	// CRDs generated on its own): it maps the name of each (e.g.,
+	// `core`) to the directory of its `k8s.libsonnet` in the Jsonnet
+	// library path (e.g., `./k8s`), and ExternalIndexes maps the name to
+	// the library's symbol index (see `BuildSymbolIndex`). A property
+	// that refers to a definition the spec doesn't have, and an
+	// external library does (see `SymbolIndex.DefinitionPath`), gets a
+	// type alias that points into that library, e.g., `metadataType::
+	// __external_core.apps.v1.deployment.mixin.metadataType`, along
+	// with its plain setters. It's an error for such a definition to be
+	// in no external library.
+	ExternalRefs    map[string]string       `yaml:"externalRefs"`
+	ExternalIndexes map[string]*SymbolIndex `yaml:"-"`
+
+	// EmitExtensionPoints, when set, emits the hooks of
+	// `kubeversion.ExtensionPoints` (e.g., `volumeSourcesExt`) as empty
+	// hidden objects, so that a library generated on its own (e.g., of
+	// CRDs) can fill them without either library importing the other.
+	EmitExtensionPoints bool `yaml:"emitExtensionPoints"`
+
+	// Extends, when set, is the symbol index of a library generated
+	// with `EmitExtensionPoints` (e.g., a core library), whose hooks
+	// Extensions fill with kinds of this library, in the overlay
+	// `coreExtensions.libsonnet` that `EmitArtifacts` adds; see
+	// `EmitCoreExtensions`. Each requires the other.
+	Extends    *SymbolIndex `yaml:"-"`
+	Extensions []Extension  `yaml:"extensions"`
+
+	// KindCollisions decides how the outputs name a kind that more than
+	// one API group has: its alias in `k.libsonnet`, the headings of its
+	// examples in `INDEX.md`, the file name of its sample, and its entry
+	// in `gvkIndex.libsonnet`, which are decided once, and recorded in
+	// the symbol index (see `SymbolIndex.KindNames`). The zero value is
+	// `CollisionsQualifyOnConflict`.
+	KindCollisions CollisionPolicy `yaml:"kindCollisions"`
+
+	// DiffFriendly, when set, emits the library so that regenerating
+	// it from a newer spec changes as few lines as it can: each
+	// definition's namespace is headed by a banner comment with its
+	// name, the namespaces of a version are ordered by definition name,
+	// and the header doesn't list the library's group/versions, which
+	// `EmitArtifacts` writes to `apiVersions.json` instead; see
+	// `EmitAPIVersions`.
+	DiffFriendly bool `yaml:"diffFriendly"`
+
+	// OmitComments, when set, leaves the comments out of the library
+	// (the descriptions of namespaces and functions, their `@type`,
+	// the examples of top-level kinds, and so on), but for the header of each file, for a smaller
+	// library to deploy. The library evaluates the same either way.
+	OmitComments bool `yaml:"omitComments"`
+
+	// CommentBudgets bound how much of the spec's descriptions the
+	// library's comments keep, to keep its size down, keyed by section
+	// (e.g., `CommentSectionProperties`); a section with no budget of
+	// its own has `CommentSectionDefault`'s, if any, and otherwise keeps
+	// them whole. `INDEX.md`, `explain` and the symbol index are never
+	// trimmed. See `CommentBudget`.
+	CommentBudgets map[CommentSection]CommentBudget `yaml:"commentBudgets"`
+
+	// Compact, when set, shares the mixins of definitions that more
+	// than CompactThreshold properties in the spec refer to (e.g.,
+	// `ObjectMeta`), as locals of the library that the namespaces of
+	// those properties call, rather than expanding them under each.
+	// Every namespace evaluates to the same functions either way, and
+	// the symbol index is the same. Namespaces with customizations in
+	// them are still expanded. It can't be combined with
+	// `SplitByGroup`. The zero value of CompactThreshold is
+	// `DefaultCompactThreshold`.
+	Compact          bool `yaml:"compact"`
+	CompactThreshold int  `yaml:"compactThreshold"`
+
+	// ShareIdenticalKinds, when set, emits the body of top-level kinds
+	// whose schemas are identical in several groups or versions (e.g.,
+	// the `Scale` of `apps/v1beta1` and `extensions/v1beta1`) once, as
+	// a local of the library that each of their namespaces calls with
+	// its `apiVersion`. Each namespace evaluates to the same object
+	// either way, and the symbol index is the same. Kinds whose
+	// namespaces have customizations in them, or would be emitted
+	// differently (e.g., with helpers of one group), aren't shared. It
+	// can't be combined with `SplitByGroup`.
+	ShareIdenticalKinds bool `yaml:"shareIdenticalKinds"`
+
+	// FullBetaDuplicates, when set, emits top-level kinds of beta
+	// versions in full, even if they're identical to the same kind in a
+	// GA version of their group (as they often are right after it's
+	// promoted). By default such a kind's namespace is the GA kind's,
+	// whose constructor sets the beta `apiVersion` instead, with a
+	// comment that recommends the GA kind; see `newBetaAliases`. Either
+	// way, each namespace evaluates to the same object, and the symbol
+	// index is the same.
+	FullBetaDuplicates bool `yaml:"fullBetaDuplicates"`
+
+	// ProvenanceFields, if set, records these fields of the spec's
+	// `info` and `securityDefinitions` (see `kubespec.Provenance`) in
+	// the header of `k8s.libsonnet` and in the symbol index, e.g., to
+	// record which cluster, and which auth mode, the library was
+	// generated from. It's opt-in, and only the fields listed are
+	// recorded, so that nothing sensitive (e.g., a contact's email) is
+	// published by default.
+	ProvenanceFields []ProvenanceField `yaml:"provenanceFields"`
+
+	// Compat, if set, keeps the library compatible with apps written
+	// against the libraries of a legacy generator; e.g.,
+	// `CompatKsonnet0` also emits the hidden helpers of ksonnet 0.x's
+	// mixin namespaces, as deprecated wrappers. It can't be combined
+	// with `Compact`.
+	Compat Compat `yaml:"compat"`
+
+	// WarnDeprecatedShims, when set, makes the shims of renamed
+	// properties (e.g., `serviceAccount`, a wrapper of the setter of
+	// `serviceAccountName`; see `kubeversion.DeprecatedShims`) warn,
+	// with `std.trace`, each time they're evaluated, naming their
+	// replacement. The shims are emitted either way; without it, they
+	// are silent.
+	WarnDeprecatedShims bool `yaml:"warnDeprecatedShims"`
+
+	// OnlyVersions, if set, restricts the library to the top-level
+	// kinds of these group/versions, along with the definitions they
+	// use (e.g., `core.v1.PodTemplateSpec` for `apps/v1beta2`), which
+	// are emitted as hidden objects. The aliases of `k.libsonnet` only
+	// point at the kinds that remain. Definitions removed this way are
+	// reported by `SkippedDefinitions` as `SkipFiltered`.
+	OnlyVersions []kubespec.GroupVersion `yaml:"onlyVersions"`
+
+	// Lenient, when set, skips definitions whose names don't parse,
+	// rather than failing, and emits the properties that refer to them
+	// as though they were untyped. See `SkippedDefinitions`.
+	Lenient bool `yaml:"lenient"`
+
+	// FailOnRemovedIn, if set to a version of Kubernetes (e.g.,
+	// `v1.16`), makes generation fail if the library has a top-level
+	// kind that the version no longer serves, listing each one; see
+	// `kubeversion.ScheduledRemoval`. Whether or not it's set, such
+	// kinds and their aliases are emitted with a comment saying when
+	// they're removed, and what replaces them.
+	FailOnRemovedIn string `yaml:"failOnRemovedIn"`
+
+	// KubeVersions is the version-specific data the library is
+	// generated with, e.g., which identifiers are renamed, and which
+	// properties aren't emitted; nil means `kubeversion.Default()`.
+	// Generations with different data may run at once.
+	KubeVersions *kubeversion.Data `yaml:"-"`
+
+	// Parser parses the names of the spec's definitions, caching the
+	// results, e.g., to share them between generations from one spec.
+	// Its `Prefixes` are the prefixes of the definition names that are
+	// parsed, and must be set; nil means a new parser for each
+	// generation that accepts only `kubespec.NativePrefix`.
+	Parser *kubespec.Parser `yaml:"-"`
+
+	// Logger, if set, receives structured progress events (e.g., the
+	// duration of each phase of generation). By default nothing is
+	// logged.
+	Logger Logger `yaml:"-"`
+
+	// Progress, if set, is called as the library is generated, with the
+	// name of the phase (`parse` or `emit`), how many units of work it
+	// has done, and how many there are in total: definitions while
+	// parsing, and API groups (including hidden ones) while emitting.
+	// `done` increases by one with each call, ending at `total`. It's
+	// called from the goroutine generating the library, so it should
+	// return quickly.
+	Progress func(phase string, done, total int) `yaml:"-"`
+
+	// Metrics, if set, receives operational metrics (e.g., the
+	// duration of each phase, and the size of the output) at the same
+	// points as `Progress`, and at the end of each phase; see
+	// `MetricDefinitions`. By default none are reported.
+	Metrics Metrics `yaml:"-"`
+}
+
+// `kubeVersions` returns `KubeVersions`, or the bundled data if it's
+// nil.
+func (opts *Options) kubeVersions() *kubeversion.Data {
+	if opts.KubeVersions == nil {
+		return kubeversion.Default()
+	}
+	return opts.KubeVersions
+}
+
+// DefaultMaxInlineDepth is the default `Options.MaxInlineDepth`.
+const DefaultMaxInlineDepth = 5
+
+// Validate reports the options that are invalid, or that contradict
+// each other, e.g., as read from a profile: an unknown naming or file
+// naming strategy or collision policy, a negative `MaxInlineDepth` or
+// `CompactThreshold`, `Compact`, `ShareIdenticalKinds`, `EmitMerge`, or
+// `EmitStringifiedSetters` with `SplitByGroup`, `SplitHidden` without
+// it, an unknown `Compat`, or one with `Compact`, an
+// unknown field in `ProvenanceFields`, a field of it or a
+// group/version in `OnlyVersions` twice, an external library without
+// an index, extensions without `Extends`, or of a hook its library
+// doesn't have, a `FailOnRemovedIn` that isn't a version, customizations
+// of a namespace with no path, a `Namespaces` entry or segment of
+// `NamespacePrefix` that isn't an identifier, or a `HeaderTemplate`
+// that doesn't parse.
+func (opts *Options) Validate() error {
+	problems := []string{}
+	if opts.NamingStrategy != "" {
+		if _, err := jsonnet.ParseNamingStrategy(string(opts.NamingStrategy)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if opts.FileNaming != "" {
+		if _, err := ParseFileNaming(string(opts.FileNaming)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if opts.CompactThreshold < 0 {
+		problems = append(problems, fmt.Sprintf(
+			"CompactThreshold must be at least 0 (meaning the default, %d), got %d",
+			DefaultCompactThreshold, opts.CompactThreshold))
+	}
+	if opts.Compact && opts.SplitByGroup {
+		problems = append(problems, "Compact can't be combined with SplitByGroup, since the shared mixins are locals of one file")
+	}
+	if opts.Compat != "" {
+		if _, err := ParseCompat(string(opts.Compat)); err != nil {
+			problems = append(problems, err.Error())
+		} else if opts.Compact {
+			problems = append(problems, "Compat can't be combined with Compact, since the shared mixins don't depend on the kinds that use them")
+		}
+	}
+	if opts.EmitMerge && opts.SplitByGroup {
+		problems = append(problems, "EmitMerge can't be combined with SplitByGroup, since the merge strategies are a local of one file")
+	}
+	if opts.EmitStringifiedSetters && opts.SplitByGroup {
+		problems = append(problems, "EmitStringifiedSetters can't be combined with SplitByGroup, since the setters call the util namespace of one file")
+	}
+	if opts.SplitHidden && !opts.SplitByGroup {
+		problems = append(problems, "SplitHidden requires SplitByGroup, since the library has no hidden file to split otherwise")
+	}
+	if opts.ShareIdenticalKinds && opts.SplitByGroup {
+		problems = append(problems, "ShareIdenticalKinds can't be combined with SplitByGroup, since the shared kinds are locals of one file")
+	}
+	if opts.KindCollisions != "" {
+		if _, err := ParseCollisionPolicy(string(opts.KindCollisions)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if err := validateImportBase(opts.ImportBase); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if opts.Parser != nil && len(opts.Parser.Prefixes) == 0 {
+		problems = append(problems, "Parser must have at least one of the Prefixes of the definition names it parses")
+	}
+	problems = append(problems, opts.validateExternalRefs()...)
+	problems = append(problems, opts.validateExtensions()...)
+	if opts.MaxInlineDepth < 0 {
+		problems = append(problems, fmt.Sprintf(
+			"MaxInlineDepth must be at least 0 (meaning the default, %d), got %d",
+			DefaultMaxInlineDepth, opts.MaxInlineDepth))
+	}
+	for _, section := range CommentSections {
+		budget, ok := opts.CommentBudgets[section]
+		if !ok {
+			continue
+		}
+		if budget.Mode != "" {
+			if _, err := ParseCommentMode(string(budget.Mode)); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if budget.MaxLines < 0 {
+			problems = append(problems, fmt.Sprintf(
+				"The MaxLines of the '%s' comment budget must be at least 0 (meaning no limit), got %d",
+				section, budget.MaxLines))
+		}
+	}
+	for section := range opts.CommentBudgets {
+		if _, err := ParseCommentSection(string(section)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	seenFields := map[ProvenanceField]bool{}
+	for _, field := range opts.ProvenanceFields {
+		if _, err := ParseProvenanceField(string(field)); err != nil {
+			problems = append(problems, err.Error())
+		} else if seenFields[field] {
+			problems = append(problems, fmt.Sprintf("ProvenanceFields lists '%s' more than once", field))
+		}
+		seenFields[field] = true
+	}
+	seen := map[kubespec.GroupVersion]bool{}
+	for _, gv := range opts.OnlyVersions {
+		if seen[gv] {
+			problems = append(problems, fmt.Sprintf("OnlyVersions lists '%s' more than once", gv))
+		}
+		seen[gv] = true
+	}
+	if opts.FailOnRemovedIn != "" && !kubeversion.IsVersion(opts.FailOnRemovedIn) {
+		problems = append(problems, fmt.Sprintf(
+			"FailOnRemovedIn must be a version of Kubernetes, e.g., 'v1.16', got '%s'",
+			opts.FailOnRemovedIn))
+	}
+	if _, ok := opts.Customizations[""]; ok {
+		problems = append(problems, "Customizations must name the path of the namespace they customize")
+	}
+	keys := []string{}
+	for key := range opts.Namespaces {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := validateNamespace(key, opts.Namespaces[key]); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if err := validateNamespacePrefix(opts.NamespacePrefix); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := parseHeaderTemplate(opts.HeaderTemplate); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid options:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package ksonnet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/profile"
+)
+
+func TestValidateOptions(t *testing.T) {
+	valid := []Options{
+		{},
+		{
+			NamingStrategy: jsonnet.NamingStrategyInitialisms,
+			MaxInlineDepth: 2,
+			OnlyVersions:   []kubespec.GroupVersion{{Group: "apps", Version: "v1"}, {Group: "batch", Version: "v1"}},
+			Customizations: map[string]string{"apps.v1.deployment": "foo:: 1,"},
+			Namespaces:     map[string]string{"kube-aggregator/apiregistration": "core", "kubernetes": "legacy"},
+		},
+		{FailOnRemovedIn: "v1.16"},
+		{FailOnRemovedIn: "1.22.3"},
+		{Compact: true, CompactThreshold: 5},
+		{Compact: true, ShareIdenticalKinds: true},
+		{ProvenanceFields: []ProvenanceField{ProvenanceTitle, ProvenanceAuthModes}},
+		{Compat: CompatKsonnet0, ShareIdenticalKinds: true},
+		{SplitByGroup: true, ImportBase: "github.com/ourorg/k8s-libsonnet"},
+		{KindCollisions: CollisionsQualifyAlways},
+		{EmitQuantity: true, SplitByGroup: true},
+		{EmitOptionsHelpers: true, SplitByGroup: true},
+		{SplitByGroup: true, SplitHidden: true},
+		{WarnDeprecatedShims: true, Compact: true},
+		{NamespacePrefix: "lib.k8s", HeaderTemplate: "// Copyright {{.Options.NamespacePrefix}}\n{{.Default}}\n"},
+		{
+			ExternalRefs:    map[string]string{"core": "./k8s", "certManager": "vendor/cert-manager"},
+			ExternalIndexes: map[string]*SymbolIndex{"core": {}, "certManager": {}},
+		},
+		{CommentBudgets: map[CommentSection]CommentBudget{
+			CommentSectionDefault: {MaxLines: 5},
+			CommentSectionKinds:   {Mode: CommentsFull},
+		}},
+	}
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Expected %#v to be valid, got:\n%v", opts, err)
+		}
+	}
+
+	opts := Options{
+		NamingStrategy:         "camel",
+		FileNaming:             "flat",
+		MaxInlineDepth:         -1,
+		OnlyVersions:           []kubespec.GroupVersion{{Group: "apps", Version: "v1"}, {Group: "apps", Version: "v1"}},
+		Customizations:         map[string]string{"": "foo:: 1,"},
+		Namespaces:             map[string]string{"a/b/c": "x", "api/apps": "Apps", "api": "local"},
+		NamespacePrefix:        "lib..k8s",
+		HeaderTemplate:         "// {{.File}}\n// {{if}}\n",
+		FailOnRemovedIn:        "next",
+		SplitByGroup:           true,
+		Compact:                true,
+		CompactThreshold:       -1,
+		ShareIdenticalKinds:    true,
+		EmitMerge:              true,
+		EmitStringifiedSetters: true,
+		ProvenanceFields:       []ProvenanceField{"secrets", ProvenanceTitle, ProvenanceTitle},
+		Compat:                 "ksonnet-1.x",
+		ImportBase:             "/github.com/ourorg/",
+		KindCollisions:         "qualify-never",
+		CommentBudgets: map[CommentSection]CommentBudget{
+			CommentSectionProperties: {Mode: "last-sentence", MaxLines: -1},
+			"examples":               {},
+		},
+	}
+	err := opts.Validate()
+	if err == nil {
+		t.Fatalf("Expected %#v to be invalid", opts)
+	}
+	for _, problem := range []string{
+		"Unknown naming strategy 'camel'",
+		"Unknown file naming strategy 'flat'; expected one of: dashes, underscores, namespace",
+		"MaxInlineDepth must be at least 0",
+		"CompactThreshold must be at least 0",
+		"Compact can't be combined with SplitByGroup",
+		"ShareIdenticalKinds can't be combined with SplitByGroup",
+		"EmitMerge can't be combined with SplitByGroup",
+		"EmitStringifiedSetters can't be combined with SplitByGroup",
+		"OnlyVersions lists 'apps/v1' more than once",
+		"Unknown provenance field 'secrets'; expected one of: title, version, contact, authModes",
+		"ProvenanceFields lists 'title' more than once",
+		"Unknown compatibility mode 'ksonnet-1.x'; expected one of: ksonnet-0.x",
+		"Import base '/github.com/ourorg/' isn't a relative path",
+		"Unknown collision policy 'qualify-never'; expected one of: qualify-on-conflict, qualify-always, error",
+		"Unknown comment mode 'last-sentence'; expected one of: full, first-sentence",
+		"The MaxLines of the 'properties' comment budget must be at least 0",
+		"Unknown comment section 'examples'; expected one of: default, kinds, properties",
+		"FailOnRemovedIn must be a version of Kubernetes, e.g., 'v1.16', got 'next'",
+		"Customizations must name the path",
+		"Namespaces must be keyed by 'codebase' or 'codebase/group', got 'a/b/c'",
+		"Namespaces maps 'api/apps' to 'Apps', which isn't a lowerCamelCase identifier",
+		"Namespaces maps 'api' to 'local', which isn't a lowerCamelCase identifier",
+		"NamespacePrefix must be a dotted path of lowerCamelCase identifiers, e.g., 'lib.k8s', got 'lib..k8s'",
+		"HeaderTemplate doesn't parse: template: header:2: missing value for if",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problems to include '%s', got:\n%v", problem, err)
+		}
+	}
+
+	opts = Options{SplitHidden: true}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "SplitHidden requires SplitByGroup") {
+		t.Errorf("Expected SplitHidden without SplitByGroup to be invalid, got %v", err)
+	}
+
+	opts = Options{Parser: &kubespec.Parser{}}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "Parser must have at least one of the Prefixes") {
+		t.Errorf("Expected a parser without prefixes to be invalid, got %v", err)
+	}
+
+	opts = Options{ImportBase: "github.com/../k8s-libsonnet"}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "has an empty, '.', or '..' segment") {
+		t.Errorf("Expected an import base with '..' to be invalid, got %v", err)
+	}
+
+	opts = Options{
+		ExternalRefs:    map[string]string{"Core": "./k8s", "meta": "../meta", "crds": ""},
+		ExternalIndexes: map[string]*SymbolIndex{"Core": {}, "apps": {}},
+	}
+	err = opts.Validate()
+	if err == nil {
+		t.Fatalf("Expected %#v to be invalid", opts)
+	}
+	for _, problem := range []string{
+		"ExternalRefs names a library 'Core', which isn't a lowerCamelCase identifier",
+		"ExternalRefs imports library 'meta' from '../meta', which isn't a relative path without '..' segments",
+		"ExternalRefs imports library 'crds' from '', which isn't a relative path",
+		"ExternalRefs has library 'meta', which ExternalIndexes has no symbol index of",
+		"ExternalIndexes has a symbol index of library 'apps', which ExternalRefs doesn't import",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problems to include '%s', got:\n%v", problem, err)
+		}
+	}
+
+	for _, prefix := range []string{"Lib", "lib.local", "hidden.k8s", "lib.k8s."} {
+		opts = Options{NamespacePrefix: prefix}
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected namespace prefix '%s' to be invalid", prefix)
+		}
+	}
+
+	opts = Options{Compat: CompatKsonnet0, Compact: true}
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "Compat can't be combined with Compact") {
+		t.Errorf("Expected Compat with Compact to be invalid, got %v", err)
+	}
+}
+
+// Every option but the callbacks and the data passed in (e.g.,
+// `KubeVersions`) can be set by a profile.
+func TestOptionsProfile(t *testing.T) {
+	text := `deprecateClusterScopedNamespace: true
+namingStrategy: go-initialisms
+customizations:
+  apps.v1.deployment: |
+    foo:: 1,
+allowOverrides: true
+namespaces:
+  kube-aggregator: aggregator
+namespacePrefix: lib.k8s
+headerTemplate: |
+  // Copyright ACME. {{.Default}}
+maxInlineDepth: 2
+commentExamples: true
+embedRawSchemas: true
+emitPatchHelpers: true
+emitPresets: true
+emitValidation: true
+emitFieldOrder: true
+emitGVKIndex: true
+emitMerge: true
+emitStringifiedSetters: true
+emitQuantity: true
+emitOptionsHelpers: true
+splitByGroup: true
+fileNaming: underscores
+splitHidden: true
+importBase: github.com/ourorg/k8s-libsonnet
+externalRefs:
+  core: "./k8s"
+emitExtensionPoints: true
+extensions:
+  - "volumeSourcesExt=mysql.example.com/v1/Database:Secret"
+kindCollisions: qualify-always
+diffFriendly: true
+omitComments: true
+commentBudgets:
+  kinds:
+    mode: full
+    maxLines: 0
+  properties:
+    mode: first-sentence
+    maxLines: 3
+compact: true
+compactThreshold: 3
+shareIdenticalKinds: true
+fullBetaDuplicates: true
+provenanceFields:
+  - title
+  - authModes
+compat: ksonnet-0.x
+warnDeprecatedShims: true
+onlyVersions: [apps/v1]
+lenient: true
+failOnRemovedIn: v1.16
+`
+	opts := Options{}
+	if err := profile.Unmarshal([]byte(text), &opts); err != nil {
+		t.Fatalf("Failed to read options from profile:\n%v", err)
+	}
+	expected := Options{
+		DeprecateClusterScopedNamespace: true,
+		NamingStrategy:                  jsonnet.NamingStrategyInitialisms,
+		Customizations:                  map[string]string{"apps.v1.deployment": "foo:: 1,\n"},
+		AllowOverrides:                  true,
+		Namespaces:                      map[string]string{"kube-aggregator": "aggregator"},
+		NamespacePrefix:                 "lib.k8s",
+		HeaderTemplate:                  "// Copyright ACME. {{.Default}}\n",
+		MaxInlineDepth:                  2,
+		CommentExamples:                 true,
+		EmbedRawSchemas:                 true,
+		EmitPatchHelpers:                true,
+		EmitPresets:                     true,
+		EmitValidation:                  true,
+		EmitFieldOrder:                  true,
+		EmitGVKIndex:                    true,
+		EmitMerge:                       true,
+		EmitStringifiedSetters:          true,
+		EmitQuantity:                    true,
+		EmitOptionsHelpers:              true,
+		SplitByGroup:                    true,
+		FileNaming:                      FileNamingUnderscores,
+		SplitHidden:                     true,
+		ImportBase:                      "github.com/ourorg/k8s-libsonnet",
+		ExternalRefs:                    map[string]string{"core": "./k8s"},
+		EmitExtensionPoints:             true,
+		Extensions: []Extension{
+			{ExtensionPoint: "volumeSourcesExt", Kind: "mysql.example.com/v1/Database", Manages: "Secret"},
+		},
+		KindCollisions: CollisionsQualifyAlways,
+		DiffFriendly:   true,
+		OmitComments:   true,
+		CommentBudgets: map[CommentSection]CommentBudget{
+			CommentSectionKinds:      {Mode: CommentsFull},
+			CommentSectionProperties: {Mode: CommentsFirstSentence, MaxLines: 3},
+		},
+		Compact:             true,
+		CompactThreshold:    3,
+		ShareIdenticalKinds: true,
+		FullBetaDuplicates:  true,
+		ProvenanceFields:    []ProvenanceField{ProvenanceTitle, ProvenanceAuthModes},
+		Compat:              CompatKsonnet0,
+		WarnDeprecatedShims: true,
+		OnlyVersions:        []kubespec.GroupVersion{{Group: "apps", Version: "v1"}},
+		Lenient:             true,
+		FailOnRemovedIn:     "v1.16",
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("Expected:\n%#v\ngot:\n%#v", expected, opts)
+	}
+
+	written, err := profile.Marshal(opts)
+	if err != nil {
+		t.Fatalf("Failed to write options as a profile:\n%v", err)
+	}
+	if string(written) != strings.Replace(text, " [apps/v1]", "\n  - apps/v1", 1) {
+		t.Errorf("Expected options to be written as:\n%s\ngot:\n%s", text, written)
+	}
+
+	if err := profile.Unmarshal([]byte("logger: x\nprogress: y"), &Options{}); err == nil {
+		t.Errorf("Expected the callbacks not to be settable by a profile")
+	}
+	if err := profile.Unmarshal([]byte("kubeVersions: x\nparser: y"), &Options{}); err == nil {
+		t.Errorf("Expected the kubeversion data and parser not to be settable by a profile")
+	}
+	if err := profile.Unmarshal([]byte("externalIndexes:\n  core: x"), &Options{}); err == nil {
+		t.Errorf("Expected the external libraries' indexes not to be settable by a profile")
+	}
+	if err := profile.Unmarshal([]byte("extends: x"), &Options{}); err == nil {
+		t.Errorf("Expected the index of the extended library not to be settable by a profile")
+	}
+}
@@ -0,0 +1,186 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// The helpers of `util` that `Options.EmitOptionsHelpers` adds.
+const (
+	deleteOptionsName = "deleteOptions"
+	listOptionsName   = "listOptions"
+	listSelectorsName = "listSelectors"
+)
+
+// optionsParam is a parameter of the `new` of `deleteOptionsName` or
+// `listOptionsName`: the field it sets, and the Jsonnet type it's
+// checked to be, if it has one.
+type optionsParam struct {
+	field       string
+	param       string
+	jsonnetType string
+}
+
+// listSelectorsBody is the lines of the body of `listSelectorsName`,
+// which renders maps of labels and fields as the `labelSelector` and
+// `fieldSelector` of a list, e.g., `{app: "web", tier: "a,b"}` as
+// `app=web,tier=a\,b`: terms are joined by commas in order of key, and
+// backslashes, commas and equals signs in values are escaped with a
+// backslash, as Kubernetes' field selectors unescape them. Numbers and
+// booleans are written as `std.toString` does. A null or empty map
+// sets no selector, and keys with a comma, an equals sign or a `!`,
+// which can't be escaped, fail.
+var listSelectorsBody = []string{
+	`local escape(value) = std.join("", [if c == "\\" || c == "," || c == "=" then "\\" + c else c for c in std.stringChars(value)]);`,
+	`local term(param, key, value) =`,
+	`  if std.length([c for c in std.stringChars(key) if c == "," || c == "=" || c == "!"]) > 0 then error "'%s' can't select by '%s', since keys can't have a comma, an equals sign or a '!'" % [param, key]`,
+	`  else if std.type(value) == "string" then key + "=" + escape(value)`,
+	`  else if std.type(value) == "number" || std.type(value) == "boolean" then key + "=" + std.toString(value)`,
+	`  else error "The value of '%s' in '%s' must be a string, a number or a boolean, got %s" % [key, param, std.type(value)];`,
+	`local selector(field, param, selectors) =`,
+	`  if selectors == null then {}`,
+	`  else if std.type(selectors) != "object" then error "'%s' must be an object of keys and values, got %s" % [param, std.type(selectors)]`,
+	`  else if std.length(selectors) == 0 then {}`,
+	`  else {[field]: std.join(",", [term(param, key, selectors[key]) for key in std.objectFields(selectors)])};`,
+	`selector("labelSelector", "labelMap", labelMap) + selector("fieldSelector", "fieldMap", fieldMap),`,
+}
+
+// `emitOptionsHelpers` emits, with `Options.EmitOptionsHelpers`, the
+// helpers of `util` for the parameters of operations, from the paths
+// of the spec: `deleteOptions.new(...)`, with a parameter for each
+// field of the definition the bodies of deletes refer to (see
+// `kubespec.APISpec.BodyDefinition`), `listOptions.new(...)`, with one
+// for each query parameter of lists (see
+// `kubespec.APISpec.QueryParameters`), and `listSelectors(labelMap,
+// fieldMap)`; see `listSelectorsBody`. A spec without deletes that take
+// a body, or lists with query parameters, gets a comment in place of
+// that helper.
+func (root *root) emitOptionsHelpers(m *indentWriter) {
+	if name, ok := root.spec.BodyDefinition("delete"); ok {
+		params := root.deleteOptionsParams(root.spec.Definitions[name])
+		root.emitOptionsNew(m, deleteOptionsName, fmt.Sprintf(
+			"// Makes the body of a delete, a `%s`, e.g., `new(propagationPolicy=\"Foreground\", gracePeriodSeconds=0)`: each argument that isn't null sets the field of its name, and is checked to be of the field's type.",
+			name[strings.LastIndex(string(name), ".")+1:]), params)
+	} else {
+		m.writeLine(fmt.Sprintf("// Omitted `%s`, since no delete of the spec takes a body.", deleteOptionsName))
+	}
+
+	params := []optionsParam{}
+	for _, param := range root.spec.QueryParameters("list") {
+		params = append(params, optionsParam{
+			field:       param.Name,
+			param:       string(root.naming().RewriteAsFuncParam(root.spec.Info.Version, kubespec.PropertyName(param.Name))),
+			jsonnetType: jsonnetTypeOf(param.Type),
+		})
+	}
+	if len(params) > 0 {
+		root.emitOptionsNew(m, listOptionsName,
+			"// Makes the query parameters of a list, e.g., `new(limit=100) + util.listSelectors({app: \"web\"})`: each argument that isn't null sets the parameter of its name, and is checked to be of the parameter's type.",
+			params)
+	} else {
+		m.writeLine(fmt.Sprintf("// Omitted `%s`, since no list of the spec has query parameters.", listOptionsName))
+	}
+
+	m.writeLine("// Renders maps of labels and fields as the `labelSelector` and `fieldSelector` of a list, e.g., `listSelectors({app: \"web\"}, {\"status.phase\": \"Running\"})` is `{labelSelector: \"app=web\", fieldSelector: \"status.phase=Running\"}`. Terms are in order of key, and backslashes, commas and equals signs in values are escaped with a backslash; a null or empty map sets no selector.")
+	m.writeLine(fmt.Sprintf("%s(labelMap={}, fieldMap={})::", listSelectorsName))
+	m.indent()
+	for _, line := range listSelectorsBody {
+		m.writeLine(line)
+	}
+	m.dedent()
+	symbol := root.index.add(utilName+"."+listSelectorsName, SymbolFunction, "labelMap", "fieldMap")
+	symbol.Defaults = map[string]string{"labelMap": "{}", "fieldMap": "{}"}
+}
+
+// `deleteOptionsParams` returns the parameters of `deleteOptions.new`,
+// a parameter for each field of `def` but `apiVersion` and `kind`, in
+// order of name.
+func (root *root) deleteOptionsParams(def *kubespec.SchemaDefinition) []optionsParam {
+	names := []string{}
+	for name := range def.Properties {
+		if name != "apiVersion" && name != "kind" {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+
+	params := []optionsParam{}
+	for _, name := range names {
+		prop := def.Properties[kubespec.PropertyName(name)]
+		jsonnetType := ""
+		switch {
+		case prop.IsIntOrString():
+		case prop.Ref != nil:
+			if ref, ok := root.spec.Definitions[*root.refName(prop.Ref)]; ok && ref.Type != nil {
+				jsonnetType = jsonnetTypeOf(*ref.Type)
+			} else if ok && len(ref.Properties) > 0 {
+				jsonnetType = "object"
+			}
+		case prop.Type != nil:
+			jsonnetType = jsonnetTypeOf(*prop.Type)
+		}
+		params = append(params, optionsParam{
+			field:       name,
+			param:       string(root.naming().RewriteAsFuncParam(root.spec.Info.Version, kubespec.PropertyName(name))),
+			jsonnetType: jsonnetType,
+		})
+	}
+	return params
+}
+
+// `emitOptionsNew` emits the namespace `name` of `util`, with a `new`
+// whose parameters, defaulting to null, set the fields of `params`.
+func (root *root) emitOptionsNew(m *indentWriter, name, comment string, params []optionsParam) {
+	m.writeLine(comment)
+	m.writeLine(name + ":: {")
+	m.indent()
+	signature := []string{}
+	names := []string{}
+	fields := []string{}
+	defaults := map[string]string{}
+	for _, param := range params {
+		signature = append(signature, param.param+"=null")
+		names = append(names, param.param)
+		fields = append(fields, fmt.Sprintf("[%q, %s]", param.field, param.param))
+		defaults[param.param] = "null"
+	}
+	m.writeLine(fmt.Sprintf("new(%s)::", strings.Join(signature, ", ")))
+	m.indent()
+	for _, param := range params {
+		if param.jsonnetType == "" {
+			continue
+		}
+		m.writeLine(fmt.Sprintf(
+			`assert %[1]s == null || std.type(%[1]s) == %[2]q : "'%[1]s' must be %[3]s, got " + std.type(%[1]s);`,
+			param.param, param.jsonnetType, jsonnetTypeArticle(param.jsonnetType)))
+	}
+	m.writeLine(fmt.Sprintf("{[field[0]]: field[1] for field in [%s] if field[1] != null},", strings.Join(fields, ", ")))
+	m.dedent()
+	m.dedent()
+	m.writeLine("},")
+	root.index.add(utilName+"."+name, SymbolNamespace)
+	symbol := root.index.add(utilName+"."+name+".new", SymbolFunction, names...)
+	symbol.Defaults = defaults
+}
+
+// `jsonnetTypeOf` returns the type `std.type` gives values of the
+// schema type `t`, or "" if it can't tell.
+func jsonnetTypeOf(t kubespec.SchemaType) string {
+	switch t {
+	case "integer", "number":
+		return "number"
+	case "string", "boolean", "array", "object":
+		return string(t)
+	}
+	return ""
+}
+
+func jsonnetTypeArticle(jsonnetType string) string {
+	if jsonnetType == "array" || jsonnetType == "object" {
+		return "an " + jsonnetType
+	}
+	return "a " + jsonnetType
+}
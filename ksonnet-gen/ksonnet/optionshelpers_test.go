@@ -0,0 +1,183 @@
+package ksonnet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEmitOptionsHelpers(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/optionshelpers.json")
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Contains(string(library), listSelectorsName) {
+		t.Errorf("Expected no options helpers by default:\n%s", library)
+	}
+
+	opts := Options{EmitOptionsHelpers: true}
+	library, err = Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+
+	// The body of deletes has a parameter for each of its fields, and
+	// lists one for each query parameter, of their path's too, each
+	// checked against its type.
+	for _, line := range []string{
+		"\n    deleteOptions:: {\n      new(gracePeriodSeconds=null, orphanDependents=null, preconditions=null, propagationPolicy=null)::\n",
+		`        assert gracePeriodSeconds == null || std.type(gracePeriodSeconds) == "number" : "'gracePeriodSeconds' must be a number, got " + std.type(gracePeriodSeconds);`,
+		`        assert preconditions == null || std.type(preconditions) == "object" : "'preconditions' must be an object, got " + std.type(preconditions);`,
+		"\n      new(continue=null, fieldSelector=null, includeUninitialized=null, labelSelector=null, limit=null, pretty=null, resourceVersion=null, timeoutSeconds=null, watch=null)::\n",
+		`        assert limit == null || std.type(limit) == "number" : "'limit' must be a number, got " + std.type(limit);`,
+		"\n    listSelectors(labelMap={}, fieldMap={})::\n",
+	} {
+		if !strings.Contains(string(library), line) {
+			t.Errorf("Expected the library to have:\n%s\ngot:\n%s", line, library)
+		}
+	}
+
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	for path, params := range map[string][]string{
+		"util.deleteOptions.new": {"gracePeriodSeconds", "orphanDependents", "preconditions", "propagationPolicy"},
+		"util.listSelectors":     {"labelMap", "fieldMap"},
+	} {
+		if symbol := symbols[path]; symbol == nil || symbol.Kind != SymbolFunction || !reflect.DeepEqual(symbol.Params, params) {
+			t.Errorf("Expected '%s(%s)' to be indexed, got %#v", path, strings.Join(params, ", "), symbol)
+		}
+	}
+	if symbol := symbols["util.listOptions"]; symbol == nil || symbol.Kind != SymbolNamespace {
+		t.Errorf("Expected 'util.listOptions' to be indexed, got %#v", symbol)
+	}
+	if defaults := symbols["util.listOptions.new"].Defaults; defaults["limit"] != "null" {
+		t.Errorf("Expected the parameters of 'listOptions.new' to default to null, got %v", defaults)
+	}
+
+	// Specs without deletes or lists say why they have no helpers for
+	// them.
+	library, err = Emit(context.Background(), loadTestSpec(t, "testdata/workloads.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	for _, comment := range []string{
+		"// Omitted `deleteOptions`, since no delete of the spec takes a body.",
+		"// Omitted `listOptions`, since no list of the spec has query parameters.",
+	} {
+		if !strings.Contains(string(library), comment) {
+			t.Errorf("Expected the library to have:\n%s\ngot:\n%s", comment, library)
+		}
+	}
+	if !strings.Contains(string(library), "\n    listSelectors(labelMap={}, fieldMap={})::\n") {
+		t.Errorf("Expected 'listSelectors' whatever the spec:\n%s", library)
+	}
+
+	if err := (&Options{EmitOptionsHelpers: true, SplitByGroup: true}).Validate(); err != nil {
+		t.Errorf("Expected EmitOptionsHelpers with SplitByGroup to be valid, got:\n%v", err)
+	}
+}
+
+func TestEmitOptionsHelpersEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	spec := loadTestSpec(t, "testdata/optionshelpers.json")
+	opts := Options{EmitOptionsHelpers: true}
+
+	// Selectors are sorted by key, and backslashes, commas and equals
+	// signs in values are escaped, in that order, so that an escaped
+	// backslash isn't read as escaping what follows it.
+	evaluated := map[string]string{
+		`util.listSelectors({app: "web"})`:                                              `{"labelSelector": "app=web"}`,
+		`util.listSelectors({tier: "front", app: "web"})`:                               `{"labelSelector": "app=web,tier=front"}`,
+		`util.listSelectors({}, {"status.phase": "Running"})`:                           `{"fieldSelector": "status.phase=Running"}`,
+		`util.listSelectors(fieldMap={"metadata.name": "a,b"})`:                         `{"fieldSelector": "metadata.name=a\\,b"}`,
+		`util.listSelectors(null, {"spec.nodeName": "x=y"})`:                            `{"fieldSelector": "spec.nodeName=x\\=y"}`,
+		`util.listSelectors({}, {"metadata.name": "a\\b"})`:                             `{"fieldSelector": "metadata.name=a\\\\b"}`,
+		`util.listSelectors({}, {"metadata.name": "a\\,b=c"})`:                          `{"fieldSelector": "metadata.name=a\\\\\\,b\\=c"}`,
+		`util.listSelectors({}, {"metadata.name": ",,=="})`:                             `{"fieldSelector": "metadata.name=\\,\\,\\=\\="}`,
+		`util.listSelectors({ready: true, replicas: 3}, {"spec.unschedulable": false})`: `{"labelSelector": "ready=true,replicas=3", "fieldSelector": "spec.unschedulable=false"}`,
+		`util.listSelectors({app: ""})`:                                                 `{"labelSelector": "app="}`,
+		`util.listSelectors()`:                                                          `{}`,
+		`util.listOptions.new(limit=10) + util.listSelectors({app: "web"})`:             `{"limit": 10, "labelSelector": "app=web"}`,
+		`util.deleteOptions.new(propagationPolicy="Foreground", gracePeriodSeconds=0)`:  `{"propagationPolicy": "Foreground", "gracePeriodSeconds": 0}`,
+		`util.deleteOptions.new(preconditions={uid: "123"})`:                            `{"preconditions": {"uid": "123"}}`,
+		`util.deleteOptions.new()`:                                                      `{}`,
+	}
+	expressions := []string{}
+	for expression := range evaluated {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+	checks := []string{}
+	for _, expression := range expressions {
+		checks = append(checks, fmt.Sprintf("%q: %s,", expression, expression))
+	}
+	program := "local util = (import 'k8s.libsonnet').util;\n{\n" + strings.Join(checks, "\n") + "\n}\n"
+	out := evaluateLibrary(t, jsonnet, spec, opts, program)
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(out), &values); err != nil {
+		t.Fatalf("Could not read the evaluated checks:\n%v\n%s", err, out)
+	}
+	for expression, expected := range evaluated {
+		var actual, want interface{}
+		json.Unmarshal(values[expression], &actual)
+		if err := json.Unmarshal([]byte(expected), &want); err != nil {
+			t.Fatalf("Could not read the expected value of %s:\n%v", expression, err)
+		}
+		if !reflect.DeepEqual(actual, want) {
+			t.Errorf("Expected %s to be %s, got %s", expression, expected, values[expression])
+		}
+	}
+
+	// Keys that can't be escaped, values that aren't scalars, and
+	// arguments of the wrong type fail.
+	dir, err := ioutil.TempDir("", "optionshelpers")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+		t.Fatalf("Could not write library:\n%v", err)
+	}
+	for expression, message := range map[string]string{
+		`util.listSelectors({"a,b": "c"})`:               "'labelMap' can't select by 'a,b'",
+		`util.listSelectors({}, {"a=b": "c"})`:           "'fieldMap' can't select by 'a=b'",
+		`util.listSelectors({"!app": "web"})`:            "'labelMap' can't select by '!app'",
+		`util.listSelectors({app: ["web"]})`:             "The value of 'app' in 'labelMap' must be a string, a number or a boolean, got array",
+		`util.listSelectors("app=web")`:                  "'labelMap' must be an object of keys and values, got string",
+		`util.deleteOptions.new(gracePeriodSeconds="0")`: "'gracePeriodSeconds' must be a number, got string",
+		`util.deleteOptions.new(propagationPolicy=true)`: "'propagationPolicy' must be a string, got boolean",
+		`util.listOptions.new(watch="true")`:             "'watch' must be a boolean, got string",
+	} {
+		main := filepath.Join(dir, "main.jsonnet")
+		program := "local util = (import 'k8s.libsonnet').util;\n" + expression + "\n"
+		if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+		if err == nil || !strings.Contains(string(out), message) {
+			t.Errorf("Expected %s to fail with \"%s\", got:\n%s", expression, message, out)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+)
+
+// signaturePattern matches the start of the signature of a function of
+// the library, e.g., `withReplicas(` in `withReplicas(replicas)::`.
+var signaturePattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\(`)
+
+// `librarySignatures` returns the parameter lists of the functions of
+// `library`, by function name, without their defaults.
+func librarySignatures(library string) map[string][]string {
+	signatures := map[string][]string{}
+	for _, line := range strings.Split(library, "\n") {
+		for _, match := range signaturePattern.FindAllStringSubmatchIndex(line, -1) {
+			depth, end := 1, match[1]
+			for ; end < len(line) && depth > 0; end++ {
+				switch line[end] {
+				case '(', '[', '{':
+					depth++
+				case ')', ']', '}':
+					depth--
+				}
+			}
+			if depth > 0 || !strings.HasPrefix(line[end:], "::") {
+				continue
+			}
+			params, depth, current := []string{}, 0, ""
+			for _, c := range line[match[1] : end-1] {
+				switch {
+				case strings.ContainsRune("([{", c):
+					depth++
+				case strings.ContainsRune(")]}", c):
+					depth--
+				}
+				if c == ',' && depth == 0 {
+					params, current = append(params, current), ""
+				} else {
+					current += string(c)
+				}
+			}
+			if strings.TrimSpace(current) != "" {
+				params = append(params, current)
+			}
+			for i, param := range params {
+				params[i] = strings.TrimSpace(strings.SplitN(param, "=", 2)[0])
+			}
+			name := line[match[2]:match[3]]
+			signatures[name] = append(signatures[name], strings.Join(params, ", "))
+		}
+	}
+	return signatures
+}
+
+func TestSymbolParams(t *testing.T) {
+	specs := []string{
+		"testdata/storage.json", "testdata/optionshelpers.json", "testdata/reserved.json",
+		"testdata/deprecatedshims.json", "testdata/stringified.json", "testdata/collisions.json",
+	}
+	for _, ns := range jsonnet.NamingStrategies {
+		for _, path := range specs {
+			spec := loadTestSpec(t, path)
+			opts := Options{
+				NamingStrategy: ns, EmitPatchHelpers: true, EmitPresets: true, EmitValidation: true,
+				EmitFieldOrder: true, EmitGVKIndex: true, EmitMerge: true, EmitStringifiedSetters: true,
+				EmitQuantity: true, EmitOptionsHelpers: true,
+			}
+			library, err := Emit(context.Background(), spec, opts)
+			if err != nil {
+				t.Fatalf("Failed to emit '%s':\n%v", path, err)
+			}
+			index, err := BuildSymbolIndex(spec, opts)
+			if err != nil {
+				t.Fatalf("Failed to build symbol index of '%s':\n%v", path, err)
+			}
+
+			// Each function of the index has the parameters it's
+			// emitted with, for signature help.
+			signatures := librarySignatures(string(library))
+			for _, symbol := range index.Symbols {
+				if symbol.Kind != SymbolFunction {
+					continue
+				}
+				name := symbol.Path[strings.LastIndex(symbol.Path, ".")+1:]
+				params := strings.Join(symbol.Params, ", ")
+				found := false
+				for _, signature := range signatures[name] {
+					found = found || signature == params
+				}
+				if !found {
+					t.Errorf("Expected '%s' (%s, naming '%s') to take (%s), got %q",
+						symbol.Path, path, ns, params, signatures[name])
+				}
+			}
+		}
+	}
+}
+
+// TestOverrideParamNames checks that the names the overrides give
+// their parameters, which `jsonnet.NamingStrategyUniformParams` keeps,
+// follow its rule anyway: none is reserved, and none is taken twice.
+func TestOverrideParamNames(t *testing.T) {
+	check := func(what string, params []string) {
+		seen := map[string]bool{}
+		for _, param := range params {
+			if jsonnet.IsReservedIdentifier(jsonnet.Identifier(param)) || seen[param] {
+				t.Errorf("Expected the parameters of %s to be distinct and not reserved, got %v", what, params)
+			}
+			seen[param] = true
+		}
+	}
+	overrides := map[string]*constructorOverride{"ingressTLS": ingressTLSOverride}
+	for group, kinds := range constructorOverrides {
+		for kind, override := range kinds {
+			overrides[fmt.Sprintf("%s.%s", group, kind)] = override
+		}
+	}
+	for version, kinds := range versionedConstructorOverrides {
+		for kind, override := range kinds {
+			overrides[fmt.Sprintf("%s.%s", version, kind)] = override
+		}
+	}
+	for name, override := range overrides {
+		params := []string{}
+		for _, param := range override.params {
+			params = append(params, param.name)
+		}
+		check(fmt.Sprintf("the constructor of '%s'", name), params)
+	}
+}
+
+// TestUniformParams compares the libraries of the specs whose helpers
+// and setters `jsonnet.NamingStrategyUniformParams` renames the
+// parameters of to the goldens; the corpus has none.
+func TestUniformParams(t *testing.T) {
+	opts := Options{NamingStrategy: jsonnet.NamingStrategyUniformParams}
+	for _, name := range []string{"storage", "reserved"} {
+		library := withoutHeader(emitTestSpec(t, fmt.Sprintf("testdata/%s.json", name), opts))
+
+		golden := fmt.Sprintf("testdata/params.%s.golden", name)
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, []byte(library), 0644); err != nil {
+				t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+			}
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+		}
+		if library != string(expected) {
+			t.Errorf(
+				"Library of '%s' differs from '%s'; run `go test -update` and diff:\n%s",
+				name, golden, library)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// patchNamespace is the namespace of the patch helpers of a top-level
+// kind; see `emitPatchHelpers`.
+const patchNamespace = "patch"
+
+// pointerFunction is the local function of the `patch` namespace that
+// appends `path`, a field name or an array of field names and list
+// indices, to the JSON Pointer (RFC 6901) `prefix`, escaping `~` and
+// `/` in each segment.
+const pointerFunction = `local pointer(prefix, path) = prefix + std.join("", ["/" + std.strReplace(std.strReplace(std.toString(segment), "~", "~0"), "/", "~1") for segment in (if std.type(path) == "array" then path else [path])]),`
+
+// pointerEscaper escapes a field name as a segment of a JSON Pointer.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// `emitPatchHelpers` emits the `patch` namespace of a top-level kind,
+// if `Options.EmitPatchHelpers` is set, with helpers that return patch
+// documents rather than objects: `replace(path, value)`, a JSON Patch
+// (RFC 6902) operation, and, for objects with lists that have a patch
+// merge key (`x-kubernetes-patch-merge-key`), `mergeListItem(field,
+// keyValue, partial)`, a strategic-merge patch. Object fields get
+// nested namespaces with the same helpers, which are named like their
+// mixins, but build paths from the fields' names in the spec. Lists
+// without a merge key can only be patched by index, with `replace`.
+func (ao *apiObject) emitPatchHelpers(m *indentWriter, path string) {
+	if !ao.isTopLevel || !ao.root().opts.EmitPatchHelpers {
+		return
+	}
+	if dm, ok := ao.properties[patchNamespace]; ok {
+		log.Panicf(
+			"Attempted to create patch helpers, but '%s' property already existed at '%s'",
+			patchNamespace, dm.path)
+	}
+
+	m.writeLine("// Helpers that return patches of this kind, rather than objects: `replace` returns a JSON Patch operation, and `mergeListItem` a strategic-merge patch. Each object field has the same helpers, for the paths under it.")
+	m.writeLine(fmt.Sprintf("%s:: {", patchNamespace))
+	m.indent()
+	patchPath := fmt.Sprintf("%s.%s", path, patchNamespace)
+	ao.root().index.add(patchPath, SymbolNamespace)
+	m.writeLine(pointerFunction)
+
+	visited := map[kubespec.DefinitionName]bool{ao.parsedName.Unparse(): true}
+	ao.emitPatchFields(m, patchPath, nil, visited)
+	ao.root().closeNamespace(m, patchPath)
+}
+
+// `emitPatchFields` emits the patch helpers of the object at `fields`,
+// the names in the spec of the fields that lead to it from the kind,
+// and recurses into its object fields. Definitions in `visited`, which
+// are those already on the way from the kind, aren't recursed into,
+// so that recursive schemas (e.g., `JSONSchemaProps`) end.
+func (ao *apiObject) emitPatchFields(
+	m *indentWriter, path string, fields []kubespec.PropertyName,
+	visited map[kubespec.DefinitionName]bool,
+) {
+	pointer := ""
+	for _, field := range fields {
+		pointer += "/" + pointerEscaper.Replace(string(field))
+	}
+	if pointer == "" {
+		m.writeLine("// Returns a JSON Patch operation that replaces the value at `path`, a field name or an array of field names and list indices (e.g., `[\"spec\", \"containers\", 0]`), with `value`.")
+	} else {
+		m.writeLine(fmt.Sprintf(
+			"// Returns a JSON Patch operation that replaces the value at `path` under `%s` with `value`.", pointer))
+	}
+	m.writeLine(fmt.Sprintf(
+		"replace(path, value):: {op: \"replace\", path: pointer(%q, path), value: value},", pointer))
+	ao.root().index.add(fmt.Sprintf("%s.replace", path), SymbolFunction, "path", "value")
+
+	properties := ao.properties.sortAndFilterBlacklisted()
+	keys, lists := map[string]interface{}{}, []string{}
+	for _, pm := range properties {
+		if pm.kind == typeAlias || pm.patchMergeKey == "" ||
+			pm.schemaType == nil || *pm.schemaType != "array" {
+			continue
+		}
+		keys[string(pm.name)] = pm.patchMergeKey
+		lists = append(lists, fmt.Sprintf("`%s` (by `%s`)", pm.name, pm.patchMergeKey))
+	}
+	if len(keys) > 0 {
+		keysText, err := jsonnet.Literal(keys)
+		if err != nil {
+			log.Panicf("Could not write the patch merge keys of '%s':\n%v", ao.name, err)
+		}
+		fragment := "{[field]: [{[keys[field]]: keyValue} + partial]}"
+		for i := len(fields) - 1; i >= 0; i-- {
+			fragment = fmt.Sprintf("{%s: %s}", jsonnet.RewriteAsFieldKey(fields[i]), fragment)
+		}
+
+		m.writeLine(fmt.Sprintf(
+			"// Returns a strategic-merge patch that merges `partial` into the item of the list `field` whose merge key is `keyValue`, for %s.",
+			joinAlternatives(lists)))
+		m.writeLine("mergeListItem(field, keyValue, partial)::")
+		m.indent()
+		m.writeLine(fmt.Sprintf("local keys = %s;", keysText))
+		m.writeLine(`assert std.objectHas(keys, field) : "'" + field + "' isn't a list with a patch merge key; replace its items by index instead";`)
+		m.writeLine(fragment + ",")
+		m.dedent()
+		ao.root().index.add(
+			fmt.Sprintf("%s.mergeListItem", path), SymbolFunction, "field", "keyValue", "partial")
+	}
+
+	for _, pm := range properties {
+		if pm.kind == typeAlias || !pm.isMixin() || isSpecialProperty(pm.name) {
+			continue
+		}
+		// The helpers of the object itself win; the field can still
+		// be patched through `replace`.
+		id := ao.identifier(pm.name)
+		if id == "replace" || id == "mergeListItem" {
+			continue
+		}
+		refName := *ao.root().refName(pm.ref)
+		if visited[refName] {
+			continue
+		}
+		child := ao.root().getAPIObject(ao.root().parseName(refName))
+
+		m.push(refName)
+		m.writeLine(fmt.Sprintf("%s:: {", id))
+		m.indent()
+		childPath := fmt.Sprintf("%s.%s", path, id)
+		pm.addSymbol(childPath, SymbolNamespace)
+		visited[refName] = true
+		child.emitPatchFields(m, childPath, append(fields[:len(fields):len(fields)], pm.name), visited)
+		delete(visited, refName)
+		ao.root().closeNamespace(m, childPath)
+		m.pop()
+	}
+}
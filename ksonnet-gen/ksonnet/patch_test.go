@@ -0,0 +1,56 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatchHelpers(t *testing.T) {
+	opts := Options{EmitPatchHelpers: true}
+	deployment := objectText(emitTestSpec(t, "testdata/workloads.json", opts), "deployment")
+	expected := []string{
+		`replace(path, value):: {op: "replace", path: pointer("", path), value: value},`,
+		"spec:: {\n            // Returns a JSON Patch operation that replaces the value at `path` under `/spec` with `value`.\n" +
+			`            replace(path, value):: {op: "replace", path: pointer("/spec", path), value: value},`,
+		`replace(path, value):: {op: "replace", path: pointer("/spec/template/spec", path), value: value},`,
+		// Only lists with a merge key can be merged into.
+		"mergeListItem(field, keyValue, partial)::\n" +
+			`                  local keys = {containers: "name"};` + "\n" +
+			`                  assert std.objectHas(keys, field) : "'" + field + "' isn't a list with a patch merge key; replace its items by index instead";` + "\n" +
+			`                  {spec: {template: {spec: {[field]: [{[keys[field]]: keyValue} + partial]}}}},`,
+	}
+	for _, text := range expected {
+		if !strings.Contains(deployment, text) {
+			t.Errorf("Expected the deployment to contain:\n%s\ngot:\n%s", text, deployment)
+		}
+	}
+	if strings.Count(deployment, "mergeListItem(") != 1 {
+		t.Errorf("Expected only the pod spec to get 'mergeListItem':\n%s", deployment)
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/workloads.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := index.byPath()
+	for _, path := range []string{
+		"apps.v1.deployment.patch.replace",
+		"apps.v1.deployment.patch.spec.template.spec.mergeListItem",
+	} {
+		if symbol, ok := symbols[path]; !ok || symbol.Kind != SymbolFunction {
+			t.Errorf("Expected a function symbol for '%s', got %#v", path, symbol)
+		}
+	}
+
+	// Paths are built from the names of fields in the spec, not from
+	// their identifiers.
+	widget := objectText(emitTestSpec(t, "testdata/reserved.json", opts), "widget")
+	if !strings.Contains(widget, "std_:: {\n") ||
+		!strings.Contains(widget, `replace(path, value):: {op: "replace", path: pointer("/std", path), value: value},`) {
+		t.Errorf("Expected the 'std' field to be patched at '/std', got:\n%s", widget)
+	}
+
+	if library := emitTestSpec(t, "testdata/workloads.json", Options{}); strings.Contains(library, "patch::") {
+		t.Errorf("Expected no patch helpers by default:\n%s", library)
+	}
+}
@@ -0,0 +1,131 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+)
+
+// `validateNamespacePrefix` reports whether `prefix` is a valid
+// `Options.NamespacePrefix`: empty, or a dotted path of lowerCamelCase
+// identifiers that doesn't start with `hidden`, which paths of the
+// symbol index start with for hidden objects.
+func validateNamespacePrefix(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	segments := strings.Split(prefix, ".")
+	for _, segment := range segments {
+		if !namespacePattern.MatchString(segment) ||
+			jsonnet.IsReservedIdentifier(jsonnet.Identifier(segment)) {
+			return fmt.Errorf(
+				"NamespacePrefix must be a dotted path of lowerCamelCase identifiers, e.g., 'lib.k8s', got '%s'", prefix)
+		}
+	}
+	if segments[0] == hiddenNamespace {
+		return fmt.Errorf(
+			"NamespacePrefix can't start with '%s', which the paths of hidden objects start with, got '%s'",
+			hiddenNamespace, prefix)
+	}
+	return nil
+}
+
+// `prefixSegments` returns the namespaces of `Options.NamespacePrefix`,
+// outermost first, e.g., `lib` and `k8s` for `lib.k8s`.
+func (root *root) prefixSegments() []string {
+	if root.opts.NamespacePrefix == "" {
+		return nil
+	}
+	return strings.Split(root.opts.NamespacePrefix, ".")
+}
+
+// `libraryPath` returns the path in the tree `path` (e.g.,
+// `apps.v1.deployment`) as it is from the top of `k8s.libsonnet`,
+// under `Options.NamespacePrefix`, e.g., `lib.k8s.apps.v1.deployment`.
+func (root *root) libraryPath(path string) string {
+	return joinPath(root.opts.NamespacePrefix, path)
+}
+
+// `rootRef` returns the Jsonnet expression that refers to the top of
+// the tree from anywhere in `k8s.libsonnet`: `$`, or, under
+// `Options.NamespacePrefix`, e.g., `$.lib.k8s`.
+func (root *root) rootRef() string {
+	return joinPath("$", root.opts.NamespacePrefix)
+}
+
+// `libraryImport` returns the Jsonnet expression that imports the tree
+// of the library from `file` (e.g., `../k8s.libsonnet`), for programs
+// that refer to it as `k`, e.g., `(import "../k8s.libsonnet").lib.k8s`
+// under `Options.NamespacePrefix`.
+func (root *root) libraryImport(file string) string {
+	if root.opts.NamespacePrefix == "" {
+		return fmt.Sprintf("import %q", file)
+	}
+	return fmt.Sprintf("(import %q).%s", file, root.opts.NamespacePrefix)
+}
+
+// `openPrefix` opens the namespaces of `Options.NamespacePrefix`, with
+// `separator` (e.g., `::`, or `+::` to extend the library's), and
+// `closePrefix` closes them.
+func (root *root) openPrefix(m *indentWriter, separator string) {
+	for _, segment := range root.prefixSegments() {
+		m.writeLine(fmt.Sprintf("%s%s {", segment, separator))
+		m.indent()
+	}
+}
+
+func (root *root) closePrefix(m *indentWriter) {
+	for range root.prefixSegments() {
+		m.dedent()
+		m.writeLine("},")
+	}
+}
+
+// `nest` moves the paths of the index under `prefix`, an
+// `Options.NamespacePrefix`, adding the namespaces of the prefix. The
+// paths of hidden objects are kept, since they're in a local of the
+// library rather than in its tree, as are the targets of type aliases,
+// which are hidden objects or objects of external libraries.
+func (si *SymbolIndex) nest(prefix string) {
+	if prefix == "" {
+		return
+	}
+	nested := func(path string) string {
+		if path == "" || path == hiddenNamespace || strings.HasPrefix(path, hiddenNamespace+".") {
+			return path
+		}
+		return prefix + "." + path
+	}
+
+	for _, symbol := range si.Symbols {
+		symbol.Path = nested(symbol.Path)
+		symbol.ReplacedBy = nested(symbol.ReplacedBy)
+	}
+	segments := strings.Split(prefix, ".")
+	for i := range segments {
+		si.add(strings.Join(segments[:i+1], "."), SymbolNamespace)
+	}
+	for _, kind := range si.Kinds {
+		kind.Path = nested(kind.Path)
+		kind.ReplacementPath = nested(kind.ReplacementPath)
+	}
+	for _, name := range si.KindNames {
+		name.Path = nested(name.Path)
+	}
+	if si.Aliases != nil {
+		aliases := map[string]string{}
+		for alias, path := range si.Aliases {
+			aliases[nested(alias)] = nested(path)
+		}
+		si.Aliases = aliases
+	}
+	if si.Files != nil {
+		files := map[string]string{}
+		for path, file := range si.Files {
+			files[nested(path)] = file
+		}
+		si.Files = files
+	}
+	si.NamespacePrefix = prefix
+}
@@ -0,0 +1,164 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNamespacePrefix(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/gvkindex.json")
+	opts := Options{NamespacePrefix: "lib.k8s", EmitGVKIndex: true}
+	library := withoutHeader(emitTestSpec(t, "testdata/gvkindex.json", opts))
+	aliases, err := EmitAliases(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	gvkIndex, err := EmitGVKIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit '%s':\n%v", GVKIndexFile, err)
+	}
+	output := library + "\n// k.libsonnet\n" + string(aliases) + "\n// gvkIndex.libsonnet\n" + string(gvkIndex)
+
+	const golden = "testdata/prefix.two-level.golden"
+	if *updateGolden {
+		if err := ioutil.WriteFile(golden, []byte(output), 0644); err != nil {
+			t.Fatalf("Could not write golden file '%s':\n%v", golden, err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Could not read golden file '%s':\n%v", golden, err)
+	}
+	if output != string(expected) {
+		t.Errorf(
+			"Library under 'lib.k8s' differs from '%s'; run `go test -update` and diff:\n%s",
+			golden, output)
+	}
+
+	// The tree is the library's without a prefix, indented twice more.
+	unprefixed := withoutHeader(emitTestSpec(t, "testdata/gvkindex.json", Options{EmitGVKIndex: true}))
+	for _, line := range []string{
+		"      apps:: {",
+		"          deployment:: {",
+		"      local hidden = {",
+	} {
+		if !strings.Contains(library, "\n"+line+"\n") {
+			t.Errorf("Expected the prefixed library to have line '%s':\n%s", line, library)
+		}
+	}
+	if strings.Count(library, "\n") != strings.Count(unprefixed, "\n")+4 {
+		t.Errorf("Expected the prefix to add 4 lines to the library, got:\n%s", library)
+	}
+
+	// The paths of the symbol index are nested, but for hidden ones.
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if index.NamespacePrefix != "lib.k8s" {
+		t.Errorf("Expected the index to record the prefix, got '%s'", index.NamespacePrefix)
+	}
+	symbols := index.byPath()
+	for _, path := range []string{"lib", "lib.k8s", "lib.k8s.apps.v1.deployment.new", "hidden.meta.v1.objectMeta"} {
+		if symbols[path] == nil {
+			t.Errorf("Expected the index to have '%s'", path)
+		}
+	}
+	for _, symbol := range index.Symbols {
+		if !strings.HasPrefix(symbol.Path, "lib") && !strings.HasPrefix(symbol.Path, "hidden") {
+			t.Errorf("Expected '%s' to be under the prefix", symbol.Path)
+		}
+	}
+	if path := index.Aliases["lib.k8s.deployment"]; path != "lib.k8s.apps.v1.deployment" {
+		t.Errorf("Expected 'lib.k8s.deployment' to alias 'lib.k8s.apps.v1.deployment', got '%s'", path)
+	}
+	for _, kind := range index.Kinds {
+		if !strings.HasPrefix(kind.Path, "lib.k8s.") {
+			t.Errorf("Expected kind '%s' to be under the prefix", kind.Path)
+		}
+	}
+
+	// Programs that bind the library to `k` take it from under the
+	// prefix, and keep referring to kinds by their paths in the tree.
+	doc, err := EmitIndexDoc(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit '%s':\n%v", IndexDocFile, err)
+	}
+	if line := `local k = (import "k.libsonnet").lib.k8s;`; !strings.Contains(string(doc), line) {
+		t.Errorf("Expected '%s' to have:\n%s\ngot:\n%s", IndexDocFile, line, doc)
+	}
+	samples, _, err := EmitSamples(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit samples:\n%v", err)
+	}
+	if sample := sampleProgram(samples, "apps/v1/deployment"); !strings.Contains(sample, `local k = (import "../../k8s.libsonnet").lib.k8s;`) ||
+		!strings.Contains(sample, "k.apps.v1.deployment.new(") {
+		t.Errorf("Expected the sample to import the library under the prefix, got:\n%s", sample)
+	}
+
+	// Split by group, `k8s.libsonnet` nests the groups' imports, and
+	// the files' imports still resolve.
+	opts.SplitByGroup = true
+	artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	if line := "\n  lib:: {\n    k8s:: {\n      apps:: import \"apps.libsonnet\",\n"; !strings.Contains(string(artifacts[LibraryFile].Text), line) {
+		t.Errorf("Expected '%s' to nest its imports, got:\n%s", LibraryFile, artifacts[LibraryFile].Text)
+	}
+	index, err = BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if file := index.Files["lib.k8s.apps"]; file != "apps.libsonnet" {
+		t.Errorf("Expected 'lib.k8s.apps' to be in 'apps.libsonnet', got '%s'", file)
+	}
+	if file := index.Files[hiddenNamespace]; file != HiddenFile {
+		t.Errorf("Expected '%s' to be in '%s', got '%s'", hiddenNamespace, HiddenFile, file)
+	}
+}
+
+func TestNamespacePrefixEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	for _, split := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "prefix")
+		if err != nil {
+			t.Fatalf("Could not create directory:\n%v", err)
+		}
+		defer os.RemoveAll(dir)
+		opts := Options{NamespacePrefix: "lib.k8s", EmitGVKIndex: true, SplitByGroup: split}
+		artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/gvkindex.json"), opts)
+		if err != nil {
+			t.Fatalf("Failed to emit artifacts:\n%v", err)
+		}
+		for name, artifact := range artifacts {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), artifact.Text, 0644); err != nil {
+				t.Fatalf("Could not write '%s':\n%v", name, err)
+			}
+		}
+
+		const program = `local k = (import 'k.libsonnet').lib.k8s;
+local deployment = k.apps.v1.deployment.new() + k.apps.v1.deployment.mixin.spec.replicas(2);
+[
+  std.assertEqual(k.deployment.new() + k.deployment.mixin.spec.replicas(2), deployment),
+  std.assertEqual(k.util.forManifest(deployment).mixin.spec.replicas(3).spec.replicas, 3),
+  std.assertEqual(std.objectFieldsAll(import 'k8s.libsonnet'), ["lib"]),
+]
+`
+		main := filepath.Join(dir, "main.jsonnet")
+		if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		if out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput(); err != nil || strings.Contains(string(out), "false") {
+			t.Errorf("Expected the library under 'lib.k8s' (split: %v) to evaluate, got %v:\n%s", split, err, out)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// presetsName is the namespace of the library that
+// `Options.EmitPresets` adds.
+const presetsName = "presets"
+
+// `presetMixin` is a preset of `kubeversion.Presets` as the library
+// emits it: the Jsonnet expressions whose sum is its mixin.
+type presetMixin struct {
+	preset kubeversion.Preset
+	terms  []string
+}
+
+// `presetMixins` returns the presets of the spec's version whose
+// definitions are in the library, each made of the setters of the
+// fields the library has, so that they follow the spec (e.g., they
+// leave out a field of another version). Presets with none of their
+// fields are left out, as are fields that apply a preset that's left
+// out, or whose items aren't of its definition.
+func (root *root) presetMixins() []presetMixin {
+	presets := root.kubeVersions.Presets(root.spec.Info.Version)
+	definitions := map[string]kubespec.DefinitionName{}
+	for _, preset := range presets {
+		definitions[preset.Name] = preset.Definition
+	}
+
+	mixins := []presetMixin{}
+	emitted := map[string]bool{}
+	// Presets that apply others are emitted only if those are, and
+	// those may come later, so they're resolved until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		mixins = mixins[:0]
+		for _, preset := range presets {
+			ao := root.definitionObject(preset.Definition)
+			if ao == nil {
+				continue
+			}
+			mixin := presetMixin{preset: preset}
+			for _, field := range preset.Fields {
+				path := strings.Split(field.Path, ".")
+				p := ao.field(path)
+				if p == nil {
+					continue
+				}
+				if field.Each == "" {
+					mixin.terms = append(mixin.terms, fmt.Sprintf(
+						"%s(%s)", ao.setterPath(path), field.Value))
+				} else if p.itemTypes.Ref != nil && len(path) == 1 && emitted[field.Each] &&
+					*root.refName(p.itemTypes.Ref) == definitions[field.Each] &&
+					!jsonnet.IsReservedIdentifier(jsonnet.Identifier(p.name)) {
+					mixin.terms = append(mixin.terms, fmt.Sprintf(
+						"{%[1]s: [item + %[4]s.%[2]s.%[3]s() for item in super.%[1]s]}",
+						p.name, presetsName, field.Each, root.rootRef()))
+				}
+			}
+			if len(mixin.terms) == 0 {
+				continue
+			}
+			if !emitted[preset.Name] {
+				emitted[preset.Name], changed = true, true
+			}
+			mixins = append(mixins, mixin)
+		}
+	}
+	return mixins
+}
+
+// `definitionObject` returns the API object of a definition, whether
+// it's hidden or not, or nil if the library doesn't have it.
+func (root *root) definitionObject(name kubespec.DefinitionName) *apiObject {
+	parsedName := root.parseName(name)
+	if !parsedName.HasVersion() {
+		return nil
+	}
+	for _, hidden := range []bool{false, true} {
+		if ao, err := root.getAPIObjectHelper(parsedName, hidden); err == nil {
+			return ao
+		}
+	}
+	return nil
+}
+
+// `setterPath` returns the Jsonnet expression of the setter of the
+// field at `path` of the API object, which `field` has, from anywhere
+// in the library, e.g., `hidden.core.v1.podSpec.mixin.securityContext.runAsNonRoot`
+// for `securityContext.runAsNonRoot` of `PodSpec`, or
+// `$.core.v1.serviceAccount.automountServiceAccountToken`.
+func (ao *apiObject) setterPath(path []string) string {
+	segments := []string{ao.path()}
+	if ao.isTopLevel {
+		segments[0] = ao.root().rootRef() + "." + segments[0]
+	}
+	if len(path) > 1 {
+		segments = append(segments, "mixin")
+	}
+	owner := ao
+	for i, name := range path {
+		segments = append(segments, string(owner.identifier(kubespec.PropertyName(name))))
+		if i < len(path)-1 {
+			owner = owner.properties[kubespec.PropertyName(name)].refObject()
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// `emitPresets` emits the `presets` namespace, with a function for
+// each of `presetMixins` that returns its mixin, e.g.,
+// `presets.securityHardened()`. If the hidden objects aren't locals of
+// the file the namespace is in, it imports them from `HiddenFile`.
+func (root *root) emitPresets(m *indentWriter, importHidden bool) {
+	m.writeLine("// Mixins of best-practice defaults, made of the setters of the fields they set.")
+	m.writeLine(fmt.Sprintf("%s:: {", presetsName))
+	m.indent()
+	root.index.add(presetsName, SymbolNamespace)
+	if importHidden {
+		m.writeLine(fmt.Sprintf("local %s = import %q,", hiddenNamespace, root.importPath(HiddenFile)))
+	}
+	for _, mixin := range root.presetMixins() {
+		kind := root.parseName(mixin.preset.Definition).Kind
+		m.writeLine(fmt.Sprintf("// %s", mixin.preset.Description))
+		m.writeLine("//")
+		m.writeLine(fmt.Sprintf("// Apply it to a `%s`.", kind))
+		m.writeLine(fmt.Sprintf(
+			"%s():: %s,", mixin.preset.Name, strings.Join(mixin.terms, " + ")))
+		symbol := root.index.add(
+			fmt.Sprintf("%s.%s", presetsName, mixin.preset.Name), SymbolFunction)
+		symbol.Definition = mixin.preset.Definition
+	}
+	m.dedent()
+	m.writeLine("},")
+}
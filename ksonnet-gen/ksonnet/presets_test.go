@@ -0,0 +1,140 @@
+package ksonnet
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestPresets(t *testing.T) {
+	library := emitTestSpec(t, "testdata/presets.json", Options{EmitPresets: true})
+	expected := []string{
+		"  presets:: {",
+		"    // Apply it to a `PodSpec`.",
+		"    securityHardened():: hidden.core.v1.podSpec.mixin.securityContext.runAsNonRoot(true) + {containers: [item + $.presets.securityHardenedContainer() for item in super.containers]},",
+		`    securityHardenedContainer():: hidden.core.v1.container.mixin.securityContext.runAsNonRoot(true) + hidden.core.v1.container.mixin.securityContext.readOnlyRootFilesystem(true) + hidden.core.v1.container.mixin.securityContext.allowPrivilegeEscalation(false) + hidden.core.v1.container.mixin.securityContext.privileged(false) + hidden.core.v1.container.mixin.securityContext.capabilities.drop(["ALL"]),`,
+		`    seccompRuntimeDefault():: hidden.core.v1.podTemplateSpec.mixin.metadata.annotations({"seccomp.security.alpha.kubernetes.io/pod": "runtime/default"}),`,
+		"    minimalServiceAccount():: $.core.v1.serviceAccount.automountServiceAccountToken(false),",
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, "\n"+line+"\n") {
+			t.Errorf("Expected library to contain line:\n%s", line)
+		}
+	}
+	if strings.Contains(emitTestSpec(t, "testdata/presets.json", Options{}), "presets::") {
+		t.Errorf("Expected no presets unless they're asked for")
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/presets.json"), Options{EmitPresets: true})
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	symbols := index.byPath()
+	if symbol, ok := symbols[presetsName]; !ok || symbol.Kind != SymbolNamespace {
+		t.Errorf("Expected the symbol index to have the '%s' namespace", presetsName)
+	}
+	if symbol, ok := symbols["presets.securityHardened"]; !ok || symbol.Kind != SymbolFunction ||
+		symbol.Definition != "io.k8s.api.core.v1.PodSpec" {
+		t.Errorf("Expected the symbol index to have 'presets.securityHardened' for 'PodSpec', got %+v", symbol)
+	}
+
+	// In a library split by group, the hidden objects are imported.
+	files, err := EmitSplit(context.Background(), loadTestSpec(t, "testdata/presets.json"), Options{EmitPresets: true})
+	if err != nil {
+		t.Fatalf("Failed to emit split library:\n%v", err)
+	}
+	if line := "\n    local hidden = import \"hidden.libsonnet\",\n"; !strings.Contains(string(files[LibraryFile]), line) {
+		t.Errorf("Expected the presets of '%s' to import the hidden objects:\n%s", LibraryFile, files[LibraryFile])
+	}
+}
+
+// Presets follow the spec: fields it doesn't have are left out, as
+// are presets of definitions it doesn't have, and the fields that
+// apply them.
+func TestPresetsFollowSpec(t *testing.T) {
+	emit := func(spec *kubespec.APISpec) string {
+		library, err := Emit(context.Background(), spec, Options{EmitPresets: true})
+		if err != nil {
+			t.Fatalf("Failed to emit library:\n%v", err)
+		}
+		return string(library)
+	}
+
+	spec := loadTestSpec(t, "testdata/presets.json")
+	delete(spec.Definitions["io.k8s.api.core.v1.SecurityContext"].Properties, "allowPrivilegeEscalation")
+	delete(spec.Definitions, "io.k8s.api.core.v1.ServiceAccount")
+	library := emit(spec)
+	if !strings.Contains(library, "securityHardenedContainer():: hidden.core.v1.container.mixin.securityContext.runAsNonRoot(true) + hidden.core.v1.container.mixin.securityContext.readOnlyRootFilesystem(true) + hidden.core.v1.container.mixin.securityContext.privileged(false) +") {
+		t.Errorf("Expected 'securityHardenedContainer' to leave out 'allowPrivilegeEscalation':\n%s", library)
+	}
+	if strings.Contains(library, "minimalServiceAccount") {
+		t.Errorf("Expected no 'minimalServiceAccount' without 'ServiceAccount':\n%s", library)
+	}
+
+	spec = loadTestSpec(t, "testdata/presets.json")
+	delete(spec.Definitions["io.k8s.api.core.v1.Container"].Properties, "securityContext")
+	library = emit(spec)
+	if !strings.Contains(library, "\n    securityHardened():: hidden.core.v1.podSpec.mixin.securityContext.runAsNonRoot(true),\n") ||
+		strings.Contains(library, "securityHardenedContainer()") {
+		t.Errorf("Expected 'securityHardened' to leave out the containers without 'securityHardenedContainer':\n%s", library)
+	}
+}
+
+// The mixins apply over a `Deployment` built with its constructor, to
+// the containers it already has, and over a `ServiceAccount`.
+func TestPresetsEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("jsonnet isn't installed")
+	}
+	program := `local k = import "k8s.libsonnet";
+local deployment = k.apps.v1.deployment;
+local container = deployment.mixin.spec.template.spec.containersType;
+local hardened =
+  deployment.new({app: "web"}) +
+  deployment.mixin.spec.template.spec.containers([
+    container.name("web") + container.image("nginx"),
+    container.name("sidecar") + container.mixin.securityContext.capabilities.add("NET_ADMIN"),
+  ]) +
+  {spec+: {template+: k.presets.seccompRuntimeDefault() + {spec+: k.presets.securityHardened()}}};
+local containerSecurity(capabilities) = {
+  runAsNonRoot: true,
+  readOnlyRootFilesystem: true,
+  allowPrivilegeEscalation: false,
+  privileged: false,
+  capabilities: capabilities {drop: ["ALL"]},
+};
+std.assertEqual(hardened, {
+  apiVersion: "apps/v1",
+  kind: "Deployment",
+  spec: {
+    selector: {matchLabels: {app: "web"}},
+    template: {
+      metadata: {
+        labels: {app: "web"},
+        annotations: {"seccomp.security.alpha.kubernetes.io/pod": "runtime/default"},
+      },
+      spec: {
+        securityContext: {runAsNonRoot: true},
+        containers: [
+          {name: "web", image: "nginx", securityContext: containerSecurity({})},
+          {name: "sidecar", securityContext: containerSecurity({add: ["NET_ADMIN"]})},
+        ],
+      },
+    },
+  },
+}) &&
+std.assertEqual(
+  k.core.v1.serviceAccount.new() + k.presets.minimalServiceAccount(),
+  {apiVersion: "v1", kind: "ServiceAccount", automountServiceAccountToken: false})
+`
+	spec := loadTestSpec(t, "testdata/presets.json")
+	for _, opts := range []Options{{EmitPresets: true}, {EmitPresets: true, Compact: true}} {
+		if out := evaluateLibrary(t, jsonnet, spec, opts, program); strings.TrimSpace(out) != "true" {
+			t.Errorf("Expected the presets to apply with %+v, got:\n%s", opts, out)
+		}
+	}
+}
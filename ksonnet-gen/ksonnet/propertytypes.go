@@ -0,0 +1,57 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// `forcePropertyTypes` returns `spec` with the properties whose types
+// `kubeversion.PropertyTypes` forces for its version replaced by ones
+// of those types, so that they're modeled (and emitted) as though the
+// spec had declared them so. Overrides of definitions the spec doesn't
+// have (e.g., because they're filtered out) are ignored; those of
+// properties a definition doesn't have are recorded in `root.errors`.
+func (root *root) forcePropertyTypes(spec *kubespec.APISpec) *kubespec.APISpec {
+	for _, override := range root.kubeVersions.PropertyTypes(spec.Info.Version) {
+		if _, ok := spec.Definitions[override.Definition]; !ok {
+			continue
+		}
+		path := []kubespec.PropertyName{}
+		for _, name := range strings.Split(override.Path, ".") {
+			path = append(path, kubespec.PropertyName(name))
+		}
+		forced, err := spec.ReplaceProperty(override.Definition, path, func(prop *kubespec.Property) *kubespec.Property {
+			return forcedProperty(prop, override.Type)
+		})
+		if err != nil {
+			root.errors = append(root.errors, fmt.Errorf(
+				"Can't force property '%s' of '%s' to '%s', because the definition has no such property",
+				override.Path, override.Definition, override.Type))
+			continue
+		}
+		spec = forced
+	}
+	return spec
+}
+
+// `forcedProperty` returns a property with the description of `prop`,
+// and the type `t`, or nil for `kubeversion.PropertySkip`.
+func forcedProperty(prop *kubespec.Property, t kubeversion.PropertyType) *kubespec.Property {
+	forced := &kubespec.Property{Description: prop.Description}
+	switch t {
+	case kubeversion.PropertySkip:
+		return nil
+	case kubeversion.PropertyIntOrString:
+		forced.IntOrString = true
+	case kubeversion.PropertyOpaque:
+		schemaType := kubespec.SchemaType("object")
+		forced.Type = &schemaType
+	default:
+		schemaType := kubespec.SchemaType(t)
+		forced.Type = &schemaType
+	}
+	return forced
+}
@@ -0,0 +1,74 @@
+package ksonnet
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+func TestForcedPropertyTypes(t *testing.T) {
+	const path = "testdata/propertytypes.json"
+
+	// The bundled entries: `Secret.data` is opaque, unlike
+	// `stringData`, whose schema is the same, and `HTTPGetAction.port`
+	// is an `IntOrString`, though the spec doesn't define it.
+	library := emitTestSpec(t, path, Options{})
+	for _, expected := range []string{
+		"\n        data(data):: {data: data},\n        dataMixin(data):: {data+: data},\n",
+		"\n        stringData(stringData):: {stringData+: stringData},\n",
+		`port(port):: assert std.type(port) == "number" || std.type(port) == "string" : "'port' must be an integer or a string, got " + std.type(port); {port: port},`,
+		"\n          // @type IntOrString\n",
+	} {
+		if !strings.Contains(library, expected) {
+			t.Errorf("Expected the library to contain:\n%s\ngot:\n%s", expected, library)
+		}
+	}
+	if strings.Contains(library, "portMixin") {
+		t.Errorf("Expected 'port' not to be opaque:\n%s", library)
+	}
+
+	// Overrides take precedence over the spec, including for properties
+	// of inline objects.
+	data, err := kubeversion.Default().WithOverrides("v1.9.0", kubeversion.Overrides{
+		PropertyTypes: map[string]map[string]kubeversion.PropertyType{
+			"io.k8s.api.core.v1.Secret":        {"type": kubeversion.PropertySkip},
+			"io.k8s.api.core.v1.HTTPGetAction": {"host": kubeversion.PropertyOpaque},
+			"io.k8s.api.core.v1.Probe":         {"tcpSocket.port": kubeversion.PropertyIntOrString},
+			"io.k8s.api.apps.v1.Deployment":    {"spec": kubeversion.PropertyOpaque},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not override the data:\n%v", err)
+	}
+	library = emitTestSpec(t, path, Options{KubeVersions: data})
+	for _, expected := range []string{
+		"\n          host(host):: {host: host},\n          hostMixin(host):: {host+: host},\n",
+		`port(port):: assert std.type(port) == "number" || std.type(port) == "string" : "'port' must be an integer or a string, got " + std.type(port); __tcpSocketMixin({port: port}),`,
+	} {
+		if !strings.Contains(library, expected) {
+			t.Errorf("Expected the library to contain:\n%s\ngot:\n%s", expected, library)
+		}
+	}
+	if strings.Contains(library, "type(type)") || strings.Contains(library, "Used to facilitate") {
+		t.Errorf("Expected 'Secret.type' to be skipped:\n%s", library)
+	}
+
+	// A property that isn't in its definition fails generation.
+	data, err = kubeversion.Default().WithOverrides("v1.9.0", kubeversion.Overrides{
+		PropertyTypes: map[string]map[string]kubeversion.PropertyType{
+			"io.k8s.api.core.v1.Secret": {"immutable": kubeversion.PropertyBoolean},
+			"io.k8s.api.core.v1.Probe":  {"tcpSocket.host": kubeversion.PropertyString},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not override the data:\n%v", err)
+	}
+	_, err = Emit(context.Background(), loadTestSpec(t, path), Options{KubeVersions: data})
+	if err == nil ||
+		!strings.Contains(err.Error(), "Can't force property 'tcpSocket.host' of 'io.k8s.api.core.v1.Probe' to 'string'") ||
+		!strings.Contains(err.Error(), "Can't force property 'immutable' of 'io.k8s.api.core.v1.Secret' to 'boolean'") {
+		t.Errorf("Expected forcing the types of missing properties to fail, got %v", err)
+	}
+}
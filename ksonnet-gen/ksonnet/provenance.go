@@ -0,0 +1,105 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// ProvenanceField is a field of a spec's `kubespec.Provenance` that
+// `Options.ProvenanceFields` records in the library.
+type ProvenanceField string
+
+const (
+	// ProvenanceTitle is the spec's `info.title`, e.g., `Kubernetes`.
+	ProvenanceTitle ProvenanceField = "title"
+
+	// ProvenanceVersion is the spec's `info.version`, e.g., `v1.7.0`.
+	ProvenanceVersion ProvenanceField = "version"
+
+	// ProvenanceContact is the spec's `info.contact`, whose email may
+	// be personal.
+	ProvenanceContact ProvenanceField = "contact"
+
+	// ProvenanceAuthModes are the names and types of the spec's
+	// `securityDefinitions`, without their descriptions or OAuth URLs;
+	// see `kubespec.AuthMode`.
+	ProvenanceAuthModes ProvenanceField = "authModes"
+)
+
+// ProvenanceFields lists the valid provenance fields.
+var ProvenanceFields = []ProvenanceField{
+	ProvenanceTitle, ProvenanceVersion, ProvenanceContact, ProvenanceAuthModes,
+}
+
+// ParseProvenanceField returns the provenance field called `name`.
+func ParseProvenanceField(name string) (ProvenanceField, error) {
+	names := []string{}
+	for _, field := range ProvenanceFields {
+		if string(field) == name {
+			return field, nil
+		}
+		names = append(names, string(field))
+	}
+	return "", fmt.Errorf(
+		"Unknown provenance field '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// `provenance` returns the fields of the spec's provenance that
+// `Options.ProvenanceFields` selects, or nil if it selects none.
+func (root *root) provenance() *kubespec.Provenance {
+	if len(root.opts.ProvenanceFields) == 0 {
+		return nil
+	}
+	full := root.spec.Provenance()
+	p := &kubespec.Provenance{}
+	for _, field := range root.opts.ProvenanceFields {
+		switch field {
+		case ProvenanceTitle:
+			p.Title = full.Title
+		case ProvenanceVersion:
+			p.Version = full.Version
+		case ProvenanceContact:
+			p.Contact = full.Contact
+		case ProvenanceAuthModes:
+			p.AuthModes = full.AuthModes
+		}
+	}
+	return p
+}
+
+// `emitProvenance` emits a header line for each field of the spec's
+// provenance that `Options.ProvenanceFields` selects and the spec has,
+// e.g., `// Spec auth modes: BearerToken (apiKey in header 'authorization')`.
+func (root *root) emitProvenance(m *indentWriter) {
+	p := root.provenance()
+	if p == nil {
+		return
+	}
+	if p.Title != "" {
+		m.writeLine("// Spec title: " + p.Title)
+	}
+	if p.Version != "" {
+		m.writeLine("// Spec version: " + p.Version)
+	}
+	if p.Contact != nil {
+		contact := []string{}
+		for _, part := range []string{p.Contact.Name, p.Contact.Email, p.Contact.URL} {
+			if part != "" {
+				contact = append(contact, part)
+			}
+		}
+		if len(contact) > 0 {
+			m.writeLine("// Spec contact: " + strings.Join(contact, ", "))
+		}
+	}
+	if len(p.AuthModes) > 0 {
+		modes := []string{}
+		for _, mode := range p.AuthModes {
+			modes = append(modes, mode.String())
+		}
+		m.writeLine("// Spec auth modes: " + strings.Join(modes, ", "))
+	}
+}
@@ -0,0 +1,64 @@
+package ksonnet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestProvenance(t *testing.T) {
+	const path = "testdata/provenance.json"
+
+	// Nothing is recorded by default.
+	library := emitTestSpec(t, path, Options{})
+	if strings.Contains(library, "// Spec ") {
+		t.Errorf("Expected no provenance in the header by default:\n%s", library)
+	}
+	index, err := BuildSymbolIndex(loadTestSpec(t, path), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if index.Provenance != nil {
+		t.Errorf("Expected no provenance in the symbol index by default, got %#v", index.Provenance)
+	}
+
+	opts := Options{ProvenanceFields: []ProvenanceField{ProvenanceTitle, ProvenanceAuthModes}}
+	library = emitTestSpec(t, path, opts)
+	const header = `// Kubernetes version: v1.9.0
+// Spec title: Kubernetes
+// Spec auth modes: BearerToken (apiKey in header 'authorization'), SSO (oauth2 with flow 'accessCode')
+`
+	if !strings.Contains(library, header) {
+		t.Errorf("Expected the header to have:\n%s\ngot:\n%s", header, library)
+	}
+	// Neither the contact, nor the descriptions and URLs of the
+	// schemes, are recorded.
+	for _, secret := range []string{"platform@example.com", "sso.internal", "Single sign-on"} {
+		if strings.Contains(library, secret) {
+			t.Errorf("Expected '%s' not to be in the library:\n%s", secret, library)
+		}
+	}
+
+	index, err = BuildSymbolIndex(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	expected := &kubespec.Provenance{
+		Title: "Kubernetes",
+		AuthModes: []*kubespec.AuthMode{
+			{Name: "BearerToken", Type: "apiKey", In: "header", Parameter: "authorization"},
+			{Name: "SSO", Type: "oauth2", Flow: "accessCode"},
+		},
+	}
+	if !reflect.DeepEqual(index.Provenance, expected) {
+		t.Errorf("Expected the symbol index to have the provenance %#v, got %#v", expected, index.Provenance)
+	}
+
+	// The contact is opt-in.
+	library = emitTestSpec(t, path, Options{ProvenanceFields: []ProvenanceField{ProvenanceContact, ProvenanceVersion}})
+	if !strings.Contains(library, "// Spec version: v1.9.0\n// Spec contact: Platform team, platform@example.com\n") {
+		t.Errorf("Expected the header to have the version and contact:\n%s", library)
+	}
+}
@@ -0,0 +1,321 @@
+package ksonnet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// ProvenanceManifestFile is the name of the manifest that records what a
+// library was generated from; see `ProvenanceManifest`.
+const ProvenanceManifestFile = "provenance.json"
+
+// ProvenanceManifestVersion is the version of the format of
+// `ProvenanceManifest`, which `ReadProvenanceManifest` checks.
+const ProvenanceManifestVersion = 1
+
+// ProvenanceManifest records where a generated library came from, so
+// that it can be checked, e.g., before it's published, that the files
+// are the ones a given spec and build of the generator produce: the
+// digests of the inputs, the options the library was generated with,
+// the build of the generator, and the digest of each file, as
+// `Checksums` lists them. It's written as `CanonicalJSON`, so that a
+// detached signature of the file can be checked against any copy of
+// it; see `Canonical`.
+type ProvenanceManifest struct {
+	ManifestVersion int                `json:"manifestVersion"`
+	Generator       GeneratorBuild     `json:"generator"`
+	Inputs          []*ProvenanceInput `json:"inputs"`
+
+	// Options are the effective settings of the generation, keyed as a
+	// profile is, e.g., `{"excludeAlpha": true}`.
+	Options map[string]interface{} `json:"options"`
+
+	// Files maps the name of each file of the library, relative to the
+	// directory it's written to (e.g., `tests/apps.jsonnet`), to its
+	// hex-encoded SHA-256 digest.
+	Files map[string]string `json:"files"`
+}
+
+// GeneratorBuild identifies the build of `ksonnet-gen` that generated
+// a library, as far as the Go toolchain recorded it: the version of
+// its module (`(devel)` for a build of a checkout), the commit it was
+// built from, whether that checkout had changes, and the version of
+// Go. Fields the build didn't record are empty.
+type GeneratorBuild struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"goVersion"`
+}
+
+// ReadGeneratorBuild returns the build of the running binary.
+func ReadGeneratorBuild() GeneratorBuild {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return GeneratorBuild{}
+	}
+	build := GeneratorBuild{Version: info.Main.Version, GoVersion: info.GoVersion}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Commit = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// ProvenanceInput is a file or document a library was generated from:
+// its kind (e.g., `spec`, `crd`, or `externalIndex`), the name it was
+// given by (a path, URL, or `stdin`), and the hex-encoded SHA-256
+// digest of its bytes as they were read.
+type ProvenanceInput struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewProvenanceManifest returns the manifest of `artifacts`, with the
+// build of the running binary, and no inputs or options, which the
+// caller adds. The digests of the files are the ones `NewArtifact`
+// computed, as `Checksums` lists them.
+func NewProvenanceManifest(artifacts map[string]*Artifact) *ProvenanceManifest {
+	files := map[string]string{}
+	for name, artifact := range artifacts {
+		files[name] = artifact.SHA256
+	}
+	return &ProvenanceManifest{
+		ManifestVersion: ProvenanceManifestVersion,
+		Generator:       ReadGeneratorBuild(),
+		Inputs:          []*ProvenanceInput{},
+		Options:         map[string]interface{}{},
+		Files:           files,
+	}
+}
+
+// Canonical returns the text of the manifest as written to
+// `ProvenanceManifestFile`: its `CanonicalJSON`, with its inputs in
+// order of kind and name.
+func (m *ProvenanceManifest) Canonical() ([]byte, error) {
+	sorted := *m
+	sorted.Inputs = append([]*ProvenanceInput{}, m.Inputs...)
+	sort.SliceStable(sorted.Inputs, func(i, j int) bool {
+		if sorted.Inputs[i].Kind != sorted.Inputs[j].Kind {
+			return sorted.Inputs[i].Kind < sorted.Inputs[j].Kind
+		}
+		return sorted.Inputs[i].Name < sorted.Inputs[j].Name
+	})
+	return CanonicalJSON(&sorted)
+}
+
+// ReadProvenanceManifest deserializes the text of a
+// `ProvenanceManifestFile`. It fails if the manifest is of another
+// version, has fields it doesn't know, or isn't in canonical form,
+// since a signature of the file would then cover text other than what
+// `Canonical` writes.
+func ReadProvenanceManifest(text []byte) (*ProvenanceManifest, error) {
+	d := json.NewDecoder(bytes.NewReader(text))
+	d.DisallowUnknownFields()
+	d.UseNumber()
+	m := &ProvenanceManifest{}
+	if err := d.Decode(m); err != nil {
+		return nil, fmt.Errorf("Could not deserialize provenance manifest:\n%v", err)
+	}
+	if d.More() {
+		return nil, fmt.Errorf("Could not deserialize provenance manifest: it has text after its object")
+	}
+	if m.ManifestVersion != ProvenanceManifestVersion {
+		return nil, fmt.Errorf(
+			"Provenance manifest is of version %d; expected version %d",
+			m.ManifestVersion, ProvenanceManifestVersion)
+	}
+	canonical, err := m.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(canonical, text) {
+		return nil, kubespec.Categorize(ErrVerifyFailed, fmt.Errorf("Provenance manifest isn't in canonical form (sorted keys, no whitespace, integers only), so a signature of it can't be checked"))
+	}
+	return m, nil
+}
+
+// Verify recomputes the digest of each file of the manifest in `dir`,
+// returning how many matched, and an error listing each file that's
+// missing, whose digest doesn't match, or that's in `dir` but not in
+// the manifest (but for `ProvenanceManifestFile` and `ChecksumsFile`,
+// which are written alongside the library), of `ErrVerifyFailed`.
+func (m *ProvenanceManifest) Verify(dir fs.FS) (int, error) {
+	names := []string{}
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems, verified := []string{}, 0
+	for _, name := range names {
+		text, err := fs.ReadFile(dir, name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("'%s' can't be read: %v", name, err))
+			continue
+		}
+		digest := sha256.Sum256(text)
+		if actual := hex.EncodeToString(digest[:]); actual != m.Files[name] {
+			problems = append(problems, fmt.Sprintf(
+				"'%s' has digest %s; the manifest has %s", name, actual, m.Files[name]))
+			continue
+		}
+		verified++
+	}
+
+	err := fs.WalkDir(dir, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || name == ProvenanceManifestFile || name == ChecksumsFile {
+			return nil
+		}
+		if _, ok := m.Files[name]; !ok {
+			problems = append(problems, fmt.Sprintf("'%s' isn't in the manifest", name))
+		}
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("The directory can't be listed: %v", err))
+	}
+
+	if len(problems) > 0 {
+		return verified, kubespec.Categorize(ErrVerifyFailed, fmt.Errorf(
+			"The files don't match the provenance manifest:\n%s", strings.Join(problems, "\n")))
+	}
+	return verified, nil
+}
+
+// `maxSafeInteger` is the largest integer that every JSON parser reads
+// exactly, 2^53 - 1, since many read numbers as doubles.
+const maxSafeInteger = 1<<53 - 1
+
+// CanonicalJSON serializes `v` as `json.Marshal` does, and then in a
+// canonical form, so that equal values always have the same bytes,
+// e.g., to sign them: objects with their keys sorted by their bytes,
+// no whitespace, strings escaped as little as JSON allows (`"`, `\`,
+// and control characters, the common ones as `\n` and so on, and the
+// rest as `\u00XX`), and otherwise in UTF-8. Numbers must be integers
+// whose magnitude is at most 2^53 - 1, written without exponents or
+// leading zeros, since other numbers read back differently from one
+// parser to the next; it's an error if any isn't. (`json.Marshal`
+// has already replaced invalid UTF-8 in strings.)
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	text, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(text))
+	d.UseNumber()
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, value, ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// `writeCanonical` writes `value`, as decoded with `UseNumber`, to
+// `buf`, as `CanonicalJSON` does. `path` names it in errors, e.g.,
+// `options.maxInlineDepth`.
+func writeCanonical(buf *bytes.Buffer, value interface{}, path string) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case json.Number:
+		i, err := strconv.ParseInt(value.String(), 10, 64)
+		if err != nil || i > maxSafeInteger || i < -maxSafeInteger {
+			return fmt.Errorf(
+				"Can't serialize '%s' canonically: %s isn't an integer between -(2^53 - 1) and 2^53 - 1",
+				path, value)
+		}
+		buf.WriteString(strconv.FormatInt(i, 10))
+	case string:
+		writeCanonicalString(buf, value)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := writeCanonical(buf, value[key], child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("Can't serialize '%s' canonically: unexpected %T", path, value)
+	}
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
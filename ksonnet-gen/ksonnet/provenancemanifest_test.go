@@ -0,0 +1,124 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	value := map[string]interface{}{
+		"b": []interface{}{int64(-3), 0, true, nil, "x"},
+		"a": struct {
+			Z string `json:"z"`
+			Y int    `json:"y"`
+		}{Z: "quote \" backslash \\ newline \n tab \t bell \a <html> & é ✓", Y: 1<<53 - 1},
+		"": map[string]string{},
+	}
+	text, err := CanonicalJSON(value)
+	if err != nil {
+		t.Fatalf("Failed to serialize:\n%v", err)
+	}
+	expected := `{"":{},"a":{"y":9007199254740991,"z":"quote \" backslash \\ newline \n tab \t bell \u0007 <html> & é ✓"},"b":[-3,0,true,null,"x"]}`
+	if string(text) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, text)
+	}
+
+	// Numbers that parsers may read differently are rejected.
+	for _, value := range []interface{}{
+		map[string]interface{}{"ratio": 1.5},
+		map[string]interface{}{"big": int64(1 << 53)},
+		map[string]interface{}{"list": []interface{}{-(int64(1) << 53)}},
+	} {
+		if _, err := CanonicalJSON(value); err == nil || !strings.Contains(err.Error(), "isn't an integer between") {
+			t.Errorf("Expected %v to be rejected, got %v", value, err)
+		}
+	}
+	if _, err := CanonicalJSON(map[string]interface{}{"options": map[string]interface{}{"ratio": 0.5}}); err == nil ||
+		!strings.Contains(err.Error(), "Can't serialize 'options.ratio' canonically") {
+		t.Errorf("Expected the error to name the field, got %v", err)
+	}
+}
+
+func TestProvenanceManifest(t *testing.T) {
+	artifacts := map[string]*Artifact{
+		LibraryFile:          NewArtifact([]byte("{}\n")),
+		"tests/apps.jsonnet": NewArtifact([]byte("[]\n")),
+	}
+	m := NewProvenanceManifest(artifacts)
+	m.Inputs = append(m.Inputs,
+		&ProvenanceInput{Kind: "spec", Name: "swagger.json", SHA256: "bb"},
+		&ProvenanceInput{Kind: "crd", Name: "z.yaml", SHA256: "cc"},
+		&ProvenanceInput{Kind: "crd", Name: "a.yaml", SHA256: "aa"})
+	m.Options["maxInlineDepth"] = int64(5)
+	m.Options["includeGroups"] = []interface{}{"apps"}
+	text, err := m.Canonical()
+	if err != nil {
+		t.Fatalf("Failed to serialize manifest:\n%v", err)
+	}
+	for _, fragment := range []string{
+		`{"files":{"k8s.libsonnet":"` + artifacts[LibraryFile].SHA256 + `","tests/apps.jsonnet":"` + artifacts["tests/apps.jsonnet"].SHA256 + `"},"generator":{`,
+		`"inputs":[{"kind":"crd","name":"a.yaml","sha256":"aa"},{"kind":"crd","name":"z.yaml","sha256":"cc"},{"kind":"spec","name":"swagger.json","sha256":"bb"}]`,
+		`"manifestVersion":1,"options":{"includeGroups":["apps"],"maxInlineDepth":5}}`,
+	} {
+		if !strings.Contains(string(text), fragment) {
+			t.Errorf("Expected the manifest to have '%s', got:\n%s", fragment, text)
+		}
+	}
+	if m.Inputs[0].Name != "swagger.json" {
+		t.Errorf("Expected serializing not to reorder the manifest's inputs")
+	}
+
+	// It reads back as the same manifest, in the same form.
+	read, err := ReadProvenanceManifest(text)
+	if err != nil {
+		t.Fatalf("Failed to read manifest:\n%v", err)
+	}
+	if again, err := read.Canonical(); err != nil || string(again) != string(text) {
+		t.Errorf("Expected the manifest to round-trip, got:\n%s (%v)", again, err)
+	}
+
+	for _, test := range []struct {
+		text     string
+		expected string
+	}{
+		{strings.Replace(string(text), `"files":{`, `"files": {`, 1), "isn't in canonical form"},
+		{string(text) + "\n", "isn't in canonical form"},
+		{strings.Replace(string(text), `"maxInlineDepth":5`, `"maxInlineDepth":5.0`, 1), "isn't an integer"},
+		{strings.Replace(string(text), `"manifestVersion":1`, `"manifestVersion":2`, 1), "is of version 2; expected version 1"},
+		{strings.Replace(string(text), `"files"`, `"signature":"x","files"`, 1), `unknown field "signature"`},
+		{string(text) + "{}", "it has text after its object"},
+	} {
+		if _, err := ReadProvenanceManifest([]byte(test.text)); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected reading %s to fail with '%s', got %v", test.text, test.expected, err)
+		}
+	}
+
+	// Verifying recomputes the digest of each file.
+	dir := fstest.MapFS{
+		LibraryFile:            {Data: []byte("{}\n")},
+		"tests/apps.jsonnet":   {Data: []byte("[]\n")},
+		ProvenanceManifestFile: {Data: text},
+		ChecksumsFile:          {Data: Checksums(artifacts)},
+	}
+	if verified, err := m.Verify(dir); err != nil || verified != 2 {
+		t.Errorf("Expected both files to verify, got %d (%v)", verified, err)
+	}
+	dir[LibraryFile] = &fstest.MapFile{Data: []byte("{tampered: true}\n")}
+	delete(dir, "tests/apps.jsonnet")
+	dir["extra.libsonnet"] = &fstest.MapFile{Data: []byte("{}\n")}
+	verified, err := m.Verify(dir)
+	if verified != 0 || err == nil {
+		t.Fatalf("Expected no files to verify, got %d (%v)", verified, err)
+	}
+	for _, problem := range []string{
+		"'k8s.libsonnet' has digest ",
+		"; the manifest has " + artifacts[LibraryFile].SHA256,
+		"'tests/apps.jsonnet' can't be read",
+		"'extra.libsonnet' isn't in the manifest",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problems to include '%s', got:\n%v", problem, err)
+		}
+	}
+}
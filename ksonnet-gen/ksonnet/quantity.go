@@ -0,0 +1,90 @@
+package ksonnet
+
+// quantityName is the namespace of `util` that `Options.EmitQuantity`
+// adds, with the functions of `quantityFunctions`.
+const quantityName = "quantity"
+
+// quantitySymbols are the functions of `quantityName`, as the symbol
+// index records them, in the order they're emitted.
+var quantitySymbols = []struct {
+	name     string
+	params   []string
+	defaults map[string]string
+}{
+	{"parse", []string{"q"}, nil},
+	{"format", []string{"n", "suffix"}, map[string]string{"suffix": `""`}},
+	{"add", []string{"a", "b"}, nil},
+	{"scale", []string{"q", "factor"}, nil},
+}
+
+// quantitySuffixes are the suffixes of the quantities Kubernetes
+// accepts, other than exponents (e.g., `e3`), as the numerator and
+// denominator of their multipliers, so that the decimal fractions are
+// divided by, rather than multiplied by an inexact `0.001`.
+const quantitySuffixes = `{n: [1, 1e9], u: [1, 1e6], m: [1, 1e3], "": [1, 1], k: [1e3, 1], M: [1e6, 1], G: [1e9, 1], T: [1e12, 1], P: [1e15, 1], E: [1e18, 1], Ki: [std.pow(2, 10), 1], Mi: [std.pow(2, 20), 1], Gi: [std.pow(2, 30), 1], Ti: [std.pow(2, 40), 1], Pi: [std.pow(2, 50), 1], Ei: [std.pow(2, 60), 1]}`
+
+// quantityFunctions are the lines of `quantityName`, after its
+// comment: `parse(q)` returns the value of a quantity (e.g., `"500m"`
+// or `"1.5Gi"`) in base units, reading its sign, its digits (with a
+// fraction, if it has one), and its suffix, binary (`Ki` to `Ei`),
+// decimal (`n` to `E`), or an exponent (`e3`, `E-2`); `format(n,
+// suffix)` writes a number of base units in the units of a suffix,
+// without an exponent or trailing zeros, rounded to nine decimal
+// places, which is as precise as Kubernetes is; and `add(a, b)` and
+// `scale(q, factor)` compute with quantities, writing the result in
+// the suffix of the first (or, if it's a number, the second) quantity,
+// or as a number if neither is a string. Each fails for an unknown
+// suffix, or a string that isn't a quantity.
+var quantityFunctions = []string{
+	"local quantity = self,",
+	"local suffixes = " + quantitySuffixes + ",",
+	`local digits(s) = std.length(s) > 0 && std.length([c for c in std.stringChars(s) if std.count(std.stringChars("0123456789"), c) == 0]) == 0,`,
+	`local tail(s, i) = std.substr(s, i, std.length(s) - i),`,
+	`local multiplier(suffix, context) =`,
+	`  local exponent = tail(suffix, 1);`,
+	`  local unsigned = if std.length(exponent) > 0 && (exponent[0] == "-" || exponent[0] == "+") then tail(exponent, 1) else exponent;`,
+	`  if std.objectHas(suffixes, suffix) then suffixes[suffix]`,
+	`  else if (suffix[0] == "e" || suffix[0] == "E") && digits(unsigned) then (if exponent[0] == "-" then [1, std.pow(10, std.parseInt(unsigned))] else [std.pow(10, std.parseInt(unsigned)), 1])`,
+	`  else error "Unknown quantity suffix '%s'%s; expected one of: Ki, Mi, Gi, Ti, Pi, Ei, n, u, m, k, M, G, T, P, E, or an exponent, e.g., e3" % [suffix, context],`,
+	`local split(q) =`,
+	`  local chars = std.stringChars(q);`,
+	`  local end = std.foldl(function(n, i) if n == i && std.count(std.stringChars(if i == 0 then "+-.0123456789" else ".0123456789"), chars[i]) > 0 then i + 1 else n, std.range(0, std.length(chars) - 1), 0);`,
+	`  [std.substr(q, 0, end), tail(q, end)],`,
+	`local mantissa(m, q) =`,
+	`  local unsigned = if std.length(m) > 0 && (m[0] == "-" || m[0] == "+") then tail(m, 1) else m;`,
+	`  local parts = std.split(unsigned, ".");`,
+	`  local whole = parts[0];`,
+	`  local fraction = if std.length(parts) == 2 then parts[1] else "";`,
+	`  if std.length(parts) > 2 || (whole == "" && fraction == "") || (whole != "" && !digits(whole)) || (fraction != "" && !digits(fraction)) then error "'%s' isn't a quantity" % q`,
+	`  else (if m[0] == "-" then -1 else 1) * ((if whole == "" then 0 else std.parseInt(whole)) + (if fraction == "" then 0 else std.parseInt(fraction) / std.pow(10, std.length(fraction)))),`,
+	`local suffixOf(q) = if std.type(q) == "string" then split(q)[1] else "",`,
+	`local trimmed(s) = if std.length(s) > 0 && s[std.length(s) - 1] == "0" then trimmed(std.substr(s, 0, std.length(s) - 1)) else s,`,
+	`parse(q):: if std.type(q) == "number" then q else if std.type(q) != "string" then error "'parse' takes a quantity, e.g., \"500m\" or \"1.5Gi\", got " + std.type(q) else local parts = split(q); local m = mantissa(parts[0], q); local multiple = multiplier(parts[1], " in '%s'" % q); m * multiple[0] / multiple[1],`,
+	`format(n, suffix=""):: if std.type(n) != "number" then error "'format' takes a number, got " + std.type(n) else`,
+	`  local multiple = multiplier(suffix, "");`,
+	`  local x = std.abs(n) * multiple[1] / multiple[0];`,
+	`  local nanos = std.floor((x - std.floor(x)) * 1e9 + 0.5);`,
+	`  local whole = std.floor(x) + (if nanos == 1e9 then 1 else 0);`,
+	`  local fraction = trimmed("%09d" % (if nanos == 1e9 then 0 else nanos));`,
+	`  (if n < 0 && (whole > 0 || fraction != "") then "-" else "") + ("%d" % whole) + (if fraction == "" then "" else "." + fraction) + suffix,`,
+	`add(a, b):: local sum = quantity.parse(a) + quantity.parse(b); if std.type(a) != "string" && std.type(b) != "string" then sum else quantity.format(sum, if std.type(a) == "string" then suffixOf(a) else suffixOf(b)),`,
+	`scale(q, factor):: if std.type(factor) != "number" then error "'scale' takes a number to scale by, got " + std.type(factor) else local scaled = quantity.parse(q) * factor; if std.type(q) != "string" then scaled else quantity.format(scaled, suffixOf(q)),`,
+}
+
+// `emitQuantity` emits `quantityName`, with `Options.EmitQuantity`; see
+// `quantityFunctions`.
+func (root *root) emitQuantity(m *indentWriter) {
+	m.writeLine("// Computes with quantities, e.g., the resource requests and limits `\"500m\"` and `\"1.5Gi\"`: `parse(q)` returns the value of a quantity in base units (a number is its own value), `format(n, suffix)` writes a number in the units of a suffix (e.g., `format(1610612736, \"Gi\")` is `\"1.5Gi\"`), and `add(a, b)` and `scale(q, factor)` return the sum or the product as a quantity of the first's suffix (e.g., `add(\"1Gi\", \"512Mi\")` is `\"1.5Gi\"`). Suffixes are binary (`Ki`, `Mi`, `Gi`, `Ti`, `Pi`, `Ei`), decimal (`n`, `u`, `m`, `k`, `M`, `G`, `T`, `P`, `E`), or exponents (e.g., `e3`), and an unknown one fails.")
+	m.writeLine(quantityName + ":: {")
+	m.indent()
+	for _, line := range quantityFunctions {
+		m.writeLine(line)
+	}
+	m.dedent()
+	m.writeLine("},")
+	root.index.add(utilName+"."+quantityName, SymbolNamespace)
+	for _, function := range quantitySymbols {
+		symbol := root.index.add(utilName+"."+quantityName+"."+function.name, SymbolFunction, function.params...)
+		symbol.Defaults = function.defaults
+	}
+}
@@ -0,0 +1,187 @@
+package ksonnet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEmitQuantity(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Contains(string(library), quantityName+":: {") {
+		t.Errorf("Expected no quantity helpers by default:\n%s", library)
+	}
+
+	opts := Options{EmitQuantity: true}
+	library, err = Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if !strings.Contains(string(library), "\n  util:: {\n    // Computes with quantities") ||
+		!strings.Contains(string(library), "\n    quantity:: {\n      local quantity = self,\n") {
+		t.Errorf("Expected util to have the quantity helpers:\n%s", library)
+	}
+
+	index, err := BuildSymbolIndex(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	if symbol := symbols["util.quantity"]; symbol == nil || symbol.Kind != SymbolNamespace {
+		t.Errorf("Expected 'util.quantity' to be indexed, got %#v", symbol)
+	}
+	for path, params := range map[string][]string{
+		"util.quantity.parse":  {"q"},
+		"util.quantity.format": {"n", "suffix"},
+		"util.quantity.add":    {"a", "b"},
+		"util.quantity.scale":  {"q", "factor"},
+	} {
+		if symbol := symbols[path]; symbol == nil || symbol.Kind != SymbolFunction || !reflect.DeepEqual(symbol.Params, params) {
+			t.Errorf("Expected '%s(%s)' to be indexed, got %#v", path, strings.Join(params, ", "), symbol)
+		}
+	}
+	if defaults := symbols["util.quantity.format"].Defaults; defaults["suffix"] != `""` {
+		t.Errorf("Expected 'format' to default its suffix, got %v", defaults)
+	}
+
+	// The helpers are self-contained, so a library split by group has
+	// them too.
+	if err := (&Options{EmitQuantity: true, SplitByGroup: true}).Validate(); err != nil {
+		t.Errorf("Expected EmitQuantity with SplitByGroup to be valid, got:\n%v", err)
+	}
+}
+
+func TestEmitQuantityEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	opts := Options{EmitQuantity: true}
+
+	// The quantities of Kubernetes' own examples, in each form it
+	// accepts: millicores, binary and decimal suffixes, exponents, signs,
+	// and fractions without a whole part.
+	parsed := map[string]float64{
+		"500m": 0.5, "100m": 0.1, "1": 1, "0.5": 0.5, ".5": 0.5, "1.": 1, "+2": 2, "-1.5k": -1500,
+		"1Ki": 1024, "128Mi": 134217728, "1.5Gi": 1610612736, "2Ti": 2199023255552, "1Pi": 1125899906842624, "1Ei": 1152921504606846976,
+		"5n": 5e-9, "100u": 1e-4, "1k": 1000, "1.5M": 1500000, "2G": 2e9, "3T": 3e12, "4P": 4e15, "1E": 1e18,
+		"1e3": 1000, "1E3": 1000, "12e-3": 0.012, "5e+2": 500, "1.5e6": 1500000,
+	}
+	quantities := []string{}
+	for q := range parsed {
+		quantities = append(quantities, q)
+	}
+	sort.Strings(quantities)
+	checks := []string{}
+	for _, q := range quantities {
+		checks = append(checks, fmt.Sprintf("%q: quantity.parse(%q),", "parse "+q, q))
+	}
+
+	// Formatting writes the digits without an exponent or trailing
+	// zeros, and arithmetic keeps the suffix it's given, rounding off
+	// what doubles can't represent.
+	formatted := map[string]string{
+		`quantity.parse(3)`:                                                "3",
+		`quantity.format(0.5, "m")`:                                        `"500m"`,
+		`quantity.format(1610612736, "Gi")`:                                `"1.5Gi"`,
+		`quantity.format(1000)`:                                            `"1000"`,
+		`quantity.format(1500, "k")`:                                       `"1.5k"`,
+		`quantity.format(-0.1, "m")`:                                       `"-100m"`,
+		`quantity.format(1024, "Ki")`:                                      `"1Ki"`,
+		`quantity.format(1e-9, "n")`:                                       `"1n"`,
+		`quantity.format(2500, "e3")`:                                      `"2.5e3"`,
+		`quantity.format(1 / 3)`:                                           `"0.333333333"`,
+		`quantity.format(0, "Gi")`:                                         `"0Gi"`,
+		`quantity.format(quantity.parse("1.5Gi"), "Mi")`:                   `"1536Mi"`,
+		`quantity.add("1Gi", "512Mi")`:                                     `"1.5Gi"`,
+		`quantity.add("250m", "250m")`:                                     `"500m"`,
+		`quantity.add("100m", 0.2)`:                                        `"300m"`,
+		`quantity.add(1, "500m")`:                                          `"1500m"`,
+		`quantity.add(1, 2)`:                                               "3",
+		`quantity.scale("500m", 2)`:                                        `"1000m"`,
+		`quantity.scale("768Mi", 2)`:                                       `"1536Mi"`,
+		`quantity.scale("1Gi", 0.5)`:                                       `"0.5Gi"`,
+		`quantity.scale(2, 1.5)`:                                           "3",
+		`std.foldl(quantity.add, ["100m", "200m", "300m"], "0m")`:          `"600m"`,
+		`quantity.format(quantity.parse("1e3") + quantity.parse("1"), "")`: `"1001"`,
+	}
+	expressions := []string{}
+	for expression := range formatted {
+		expressions = append(expressions, expression)
+	}
+	sort.Strings(expressions)
+	for _, expression := range expressions {
+		checks = append(checks, fmt.Sprintf("%q: %s,", expression, expression))
+	}
+	program := "local quantity = (import 'k8s.libsonnet').util.quantity;\n{\n" + strings.Join(checks, "\n") + "\n}\n"
+	out := evaluateLibrary(t, jsonnet, spec, opts, program)
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(out), &values); err != nil {
+		t.Fatalf("Could not read the evaluated checks:\n%v\n%s", err, out)
+	}
+	for q, expected := range parsed {
+		var actual float64
+		if err := json.Unmarshal(values["parse "+q], &actual); err != nil || actual != expected {
+			t.Errorf("Expected '%s' to parse as %v, got %s", q, expected, values["parse "+q])
+		}
+	}
+	for expression, expected := range formatted {
+		var actual, want interface{}
+		json.Unmarshal(values[expression], &actual)
+		json.Unmarshal([]byte(expected), &want)
+		if !reflect.DeepEqual(actual, want) {
+			t.Errorf("Expected %s to be %s, got %s", expression, expected, values[expression])
+		}
+	}
+
+	// Unknown suffixes, and strings that aren't quantities, fail.
+	dir, err := ioutil.TempDir("", "quantity")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+		t.Fatalf("Could not write library:\n%v", err)
+	}
+	for expression, message := range map[string]string{
+		`quantity.parse("1Xi")`:          "Unknown quantity suffix 'Xi' in '1Xi'",
+		`quantity.parse("1e")`:           "Unknown quantity suffix 'e' in '1e'",
+		`quantity.parse("1 Gi")`:         "Unknown quantity suffix ' Gi' in '1 Gi'",
+		`quantity.parse("1.2.3")`:        "'1.2.3' isn't a quantity",
+		`quantity.parse("Gi")`:           "'Gi' isn't a quantity",
+		`quantity.parse(null)`:           "'parse' takes a quantity",
+		`quantity.format(1, "Q")`:        "Unknown quantity suffix 'Q'; expected one of:",
+		`quantity.scale("1Gi", "2")`:     "'scale' takes a number to scale by, got string",
+		`quantity.add("500m", "1 core")`: "Unknown quantity suffix ' core' in '1 core'",
+	} {
+		main := filepath.Join(dir, "main.jsonnet")
+		program := "local quantity = (import 'k8s.libsonnet').util.quantity;\n" + expression + "\n"
+		if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+		if err == nil || !strings.Contains(string(out), message) {
+			t.Errorf("Expected %s to fail with \"%s\", got:\n%s", expression, message, out)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// rawSchemasName is the namespace of the library that
+// `Options.EmbedRawSchemas` adds.
+const rawSchemasName = "rawSchemas"
+
+// rawSchemaReasons are the reasons for skipping a definition that get
+// its schema embedded: the library can't model it. Filtered and
+// blacklisted definitions are left out on purpose, so they aren't.
+var rawSchemaReasons = map[SkipReason]bool{
+	SkipUnparsable:  true,
+	SkipMalformed:   true,
+	SkipUnsupported: true,
+}
+
+// `emitRawSchemas` emits the `rawSchemas` namespace, which maps the
+// name of each definition skipped for one of `rawSchemaReasons` to an
+// object whose hidden `schema` field is the definition's JSON schema
+// as a Jsonnet literal (see `jsonnet.Literal`), e.g.,
+// `rawSchemas["io.k8s.apimachinery.pkg.runtime.RawExtension"].schema`.
+func (root *root) emitRawSchemas(m *indentWriter) error {
+	skipped := append([]*SkippedDefinition{}, root.skipped...)
+	SortSkippedDefinitions(skipped)
+	names := []kubespec.DefinitionName{}
+	reasons := map[kubespec.DefinitionName]*SkippedDefinition{}
+	for _, sd := range skipped {
+		if _, ok := reasons[sd.Name]; !ok && rawSchemaReasons[sd.Reason] {
+			names = append(names, sd.Name)
+			reasons[sd.Name] = sd
+		}
+	}
+	raw, err := root.spec.RawDefinitions(names)
+	if err != nil {
+		return fmt.Errorf("Could not embed raw schemas:\n%v", err)
+	}
+
+	m.writeLine("// The JSON schemas of the definitions the library can't model, keyed by name.")
+	m.writeLine(fmt.Sprintf("%s:: {", rawSchemasName))
+	m.indent()
+	root.index.add(rawSchemasName, SymbolNamespace)
+	for _, name := range names {
+		schema, ok := raw[name]
+		if !ok {
+			continue
+		}
+		literal, err := jsonnet.Literal(schema)
+		if err != nil {
+			return fmt.Errorf("Could not embed raw schema of '%s':\n%v", name, err)
+		}
+		key, _ := jsonnet.Literal(string(name))
+		detail := strings.Join(strings.Fields(reasons[name].Detail), " ")
+		m.writeLine(fmt.Sprintf("%s:: {", key))
+		m.indent()
+		m.writeLine(fmt.Sprintf("// Skipped (%s): %s", reasons[name].Reason, detail))
+		m.writeLine(fmt.Sprintf("schema:: %s,", literal))
+		m.dedent()
+		m.writeLine("},")
+	}
+	m.dedent()
+	m.writeLine("},")
+	return nil
+}
@@ -0,0 +1,74 @@
+package ksonnet
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmbedRawSchemas(t *testing.T) {
+	// Unsupported definitions are embedded.
+	library := emitTestSpec(t, "testdata/swagger.json", Options{EmbedRawSchemas: true})
+	expected := []string{
+		"  rawSchemas:: {",
+		`    "io.k8s.apimachinery.pkg.runtime.RawExtension":: {`,
+		"      // Skipped (unsupported): has no API version, so it has no namespace in the library",
+		`      schema:: {description: "RawExtension is used to hold extensions in external versions.", properties: {Raw: {description: "Raw is the underlying serialization of this object.", format: "byte", type: "string"}}, required: ["Raw"]},`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(library, "\n"+line+"\n") {
+			t.Errorf("Expected library to contain line:\n%s", line)
+		}
+	}
+	if strings.Contains(emitTestSpec(t, "testdata/swagger.json", Options{}), "rawSchemas") {
+		t.Errorf("Expected no raw schemas unless they're asked for")
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{EmbedRawSchemas: true})
+	if err != nil {
+		t.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	if symbol, ok := index.byPath()[rawSchemasName]; !ok || symbol.Kind != SymbolNamespace {
+		t.Errorf("Expected the symbol index to have the '%s' namespace", rawSchemasName)
+	}
+
+	// So are malformed definitions, in lenient mode.
+	library = emitTestSpec(t, "testdata/malformed.json", Options{EmbedRawSchemas: true, Lenient: true})
+	for _, line := range []string{
+		`    "io.k8s.api..v1.Widget":: {`,
+		"      schema:: {properties: {size: {type: \"integer\"}}},",
+		`    "io.k8s.api.example.v1.Gadget-Spec":: {`,
+	} {
+		if !strings.Contains(library, "\n"+line+"\n") {
+			t.Errorf("Expected library to contain line:\n%s", line)
+		}
+	}
+
+	// Filtered definitions aren't.
+	opts := Options{
+		EmbedRawSchemas: true,
+		OnlyVersions:    []kubespec.GroupVersion{{Group: "apps", Version: "v1beta1"}},
+	}
+	library = emitTestSpec(t, "testdata/swagger.json", opts)
+	if !strings.Contains(library, "\n  rawSchemas:: {\n  },\n") {
+		t.Errorf("Expected no raw schemas of filtered definitions:\n%s", library)
+	}
+
+	// The schema comes from the spec's text.
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	spec.Text = nil
+	if _, err := Emit(context.Background(), spec, Options{EmbedRawSchemas: true}); err == nil {
+		t.Errorf("Expected an error embedding raw schemas from a spec without its text")
+	}
+	if _, err := BuildSymbolIndex(spec, Options{EmbedRawSchemas: true}); err == nil {
+		t.Errorf("Expected an error indexing raw schemas from a spec without its text")
+	}
+	if _, _, err := EmittedSizes(spec, Options{EmbedRawSchemas: true}); err == nil {
+		t.Errorf("Expected an error sizing raw schemas from a spec without its text")
+	}
+	if _, err := ScanUsage(spec, Options{EmbedRawSchemas: true}, nil); err == nil {
+		t.Errorf("Expected an error scanning usage with raw schemas from a spec without its text")
+	}
+}
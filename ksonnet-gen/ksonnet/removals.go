@@ -0,0 +1,114 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// `removal` returns when Kubernetes stops serving a top-level API
+// object, and what replaces it, if it's scheduled for removal; see
+// `kubeversion.ScheduledRemoval`.
+func (ao *apiObject) removal() (kubeversion.Removal, bool) {
+	k8sVersion := ao.root().spec.Info.Version
+	for _, gvk := range ao.gvks {
+		removal, ok := ao.root().kubeVersions.ScheduledRemoval(
+			k8sVersion, gvkAPIVersion(gvk), string(gvk.Kind))
+		if ok {
+			return removal, true
+		}
+	}
+	return kubeversion.Removal{}, false
+}
+
+// `gvkAPIVersion` returns the `apiVersion` of a group/version/kind,
+// e.g., `apps/v1`, or just `v1` for the core group.
+func gvkAPIVersion(gvk *kubespec.TopLevelSpec) string {
+	if gvk.Group == "" {
+		return string(gvk.Version)
+	}
+	return fmt.Sprintf("%s/%s", gvk.Group, gvk.Version)
+}
+
+// `replacementPath` returns the path of the kind that replaces a
+// removed one, e.g., `apps.v1.deployment` for `extensions/v1beta1`
+// `Deployment`, if the library has it.
+func (root *root) replacementPath(removal kubeversion.Removal) (string, bool) {
+	if removal.Replacement == "" {
+		return "", false
+	}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				for _, gvk := range ao.gvks {
+					if gvkAPIVersion(gvk) == removal.Replacement && string(gvk.Kind) == removal.Kind {
+						return ao.path(), true
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// `removalWarning` returns the comment that heads the namespace and
+// alias of a kind that's scheduled for removal, e.g., "REMOVED IN
+// v1.16: ... use `apps.v1.deployment` (`apps/v1`) instead.", or "" if
+// it isn't.
+func (ao *apiObject) removalWarning() string {
+	removal, ok := ao.removal()
+	if !ok {
+		return ""
+	}
+	warning := fmt.Sprintf(
+		"REMOVED IN %s: Kubernetes %s stops serving `%s` `%s`",
+		removal.RemovedIn, removal.RemovedIn, removal.APIVersion, removal.Kind)
+	if path, ok := ao.root().replacementPath(removal); ok {
+		return fmt.Sprintf("%s; use `%s` (`%s`) instead.", warning, path, removal.Replacement)
+	} else if removal.Replacement != "" {
+		return fmt.Sprintf("%s; use `%s` instead.", warning, removal.Replacement)
+	}
+	return warning + ", and nothing replaces it."
+}
+
+// `removalErrors` reports each top-level kind of the library that's
+// removed by `Options.FailOnRemovedIn`, sorted by path, as one error.
+func (root *root) removalErrors() []error {
+	if root.opts.FailOnRemovedIn == "" {
+		return nil
+	}
+	removed := []string{}
+	for _, group := range root.groups.toSortedSlice() {
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.apiObjects.toSortedSlice() {
+				if removal, ok := ao.removal(); ok && removal.RemovedBy(root.opts.FailOnRemovedIn) {
+					removed = append(removed, fmt.Sprintf("'%s' (%s)", ao.path(), ao.removalWarning()))
+				}
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	return []error{fmt.Errorf(
+		"Library has %d kinds that Kubernetes %s doesn't serve:\n%s",
+		len(removed), root.opts.FailOnRemovedIn, strings.Join(removed, "\n"))}
+}
+
+// `addRemoval` records in the symbol index when a kind is removed, and
+// what replaces it, if it's scheduled for removal.
+func (root *root) addRemoval(kind *KindSymbol) {
+	apiVersion := kind.Version
+	if kind.Group != "" {
+		apiVersion = kind.Group + "/" + kind.Version
+	}
+	removal, ok := root.kubeVersions.ScheduledRemoval(root.spec.Info.Version, apiVersion, kind.Kind)
+	if !ok {
+		return
+	}
+	kind.RemovedIn = removal.RemovedIn
+	kind.Replacement = removal.Replacement
+	kind.ReplacementPath, _ = root.replacementPath(removal)
+}
@@ -0,0 +1,86 @@
+package ksonnet
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRemovals(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/collisions.json")
+	library := emitTestSpec(t, "testdata/collisions.json", Options{})
+	expected := []string{
+		// The replacement is named by its path, if the library has it.
+		"      // REMOVED IN v1.16: Kubernetes v1.16 stops serving `extensions/v1beta1` `Deployment`; use `apps.v1.deployment` (`apps/v1`) instead.\n      //\n",
+		"      // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `Deployment`; use `apps.v1.deployment` (`apps/v1`) instead.\n",
+		"      // REMOVED IN v1.16: Kubernetes v1.16 stops serving `extensions/v1beta1` `NetworkPolicy`; use `networking.v1.networkPolicy` (`networking.k8s.io/v1`) instead.\n",
+		// ... and otherwise by its apiVersion.
+		"      // REMOVED IN v1.22: Kubernetes v1.22 stops serving `extensions/v1beta1` `Ingress`; use `networking.k8s.io/v1` instead.\n",
+	}
+	for _, text := range expected {
+		if !strings.Contains(library, text) {
+			t.Errorf("Expected the library to contain:\n%s", text)
+		}
+	}
+	if count := strings.Count(library, "// REMOVED IN"); count != 5 {
+		t.Errorf("Expected 5 kinds to be scheduled for removal, got %d:\n%s", count, library)
+	}
+
+	text, err := EmitAliases(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit aliases:\n%v", err)
+	}
+	aliases := string(text)
+	if !strings.Contains(aliases, "  // REMOVED IN v1.22: Kubernetes v1.22 stops serving `extensions/v1beta1` `Ingress`; use `networking.k8s.io/v1` instead.\n  ingress:: k8s.extensions.v1beta1.ingress,\n") ||
+		!strings.Contains(aliases, "  eventsEvent:: k8s.events.v1beta1.event,\n  // REMOVED IN v1.16") ||
+		!strings.Contains(aliases, "{\n  deployment:: k8s.apps.v1.deployment,\n") {
+		t.Errorf("Expected only the aliases of removed kinds to be commented, got:\n%s", aliases)
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	kinds := map[string]*KindSymbol{}
+	for _, kind := range index.Kinds {
+		kinds[kind.Path] = kind
+	}
+	if kind := kinds["extensions.v1beta1.deployment"]; kind.RemovedIn != "v1.16" ||
+		kind.Replacement != "apps/v1" || kind.ReplacementPath != "apps.v1.deployment" {
+		t.Errorf("Expected the index to say that 'extensions.v1beta1.deployment' is removed, got %#v", kind)
+	}
+	if kind := kinds["extensions.v1beta1.ingress"]; kind.RemovedIn != "v1.22" ||
+		kind.Replacement != "networking.k8s.io/v1" || kind.ReplacementPath != "" {
+		t.Errorf("Expected the index to say that 'extensions.v1beta1.ingress' is removed, got %#v", kind)
+	}
+	if kind := kinds["apps.v1.deployment"]; kind.RemovedIn != "" || kind.Replacement != "" {
+		t.Errorf("Expected 'apps.v1.deployment' not to be removed, got %#v", kind)
+	}
+}
+
+func TestFailOnRemovedIn(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/collisions.json")
+	for _, version := range []string{"", "v1.15.3"} {
+		if _, err := Emit(context.Background(), spec, Options{FailOnRemovedIn: version}); err != nil {
+			t.Errorf("Expected nothing to be removed by '%s', got:\n%v", version, err)
+		}
+	}
+
+	tests := map[string]string{
+		"v1.16": "Library has 4 kinds that Kubernetes v1.16 doesn't serve:\n" +
+			"'apps.v1beta1.deployment' (REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta1` `Deployment`; use `apps.v1.deployment` (`apps/v1`) instead.)\n" +
+			"'apps.v1beta2.deployment' (REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `Deployment`; use `apps.v1.deployment` (`apps/v1`) instead.)\n" +
+			"'extensions.v1beta1.deployment' (REMOVED IN v1.16: Kubernetes v1.16 stops serving `extensions/v1beta1` `Deployment`; use `apps.v1.deployment` (`apps/v1`) instead.)\n" +
+			"'extensions.v1beta1.networkPolicy' (REMOVED IN v1.16: Kubernetes v1.16 stops serving `extensions/v1beta1` `NetworkPolicy`; use `networking.v1.networkPolicy` (`networking.k8s.io/v1`) instead.)",
+		"1.22": "Library has 5 kinds that Kubernetes 1.22 doesn't serve:\n",
+	}
+	for version, expected := range tests {
+		opts := Options{FailOnRemovedIn: version}
+		if _, err := Emit(context.Background(), spec, opts); err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("Expected emitting with '%s' to fail with:\n%s\ngot:\n%v", version, expected, err)
+		}
+		if _, err := BuildSymbolIndex(spec, opts); err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("Expected the index with '%s' to fail with:\n%s\ngot:\n%v", version, expected, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// SchemaStep is a property that a path in the library traverses:
+// `Property` of the definition `Definition`. Items is set if the path
+// goes on into the elements of the property, an array, through its
+// type alias (e.g., `containersType`).
+type SchemaStep struct {
+	Definition kubespec.ParsedName
+	Property   kubespec.PropertyName
+	Items      bool
+}
+
+// hiddenNamespace is the local of the library that holds the hidden
+// API objects, e.g., `hidden.core.v1.container`.
+const hiddenNamespace = "hidden"
+
+// ResolveLibraryPath maps a path in the library `index` describes
+// (e.g., `apps.v1beta2.deployment.mixin.spec.template.spec.containers`)
+// back to the properties of the spec it traverses, e.g., `spec` of
+// `Deployment`, `template` of `DeploymentSpec`, `spec` of
+// `PodTemplateSpec`, and `containers` of `PodSpec`. Paths may begin
+// with `k.`, and with an alias of `k.libsonnet` (e.g.,
+// `k.deployment.mixin.spec`), and may go through type aliases into
+// the objects they point at (e.g., `...spec.containersType.image`).
+// Namespaces that aren't properties (e.g., `mixin`) add no step.
+// Definition names are parsed accepting only `kubespec.NativePrefix`.
+//
+// It's an error if a segment of the path isn't in the library (e.g.,
+// because its definition was pruned, or filtered out with
+// `Options.OnlyVersions`); the error names the first such segment and
+// its position, counting from 1.
+func ResolveLibraryPath(index *SymbolIndex, path string) ([]SchemaStep, error) {
+	symbols := index.byPath()
+	segments := strings.Split(path, ".")
+	first := 0
+	if len(segments) > 1 && segments[0] == "k" {
+		first = 1
+	}
+
+	parser := &kubespec.Parser{Prefixes: []string{kubespec.NativePrefix}}
+	steps := []SchemaStep{}
+	current := ""
+	for i := first; i < len(segments); i++ {
+		segment := segments[i]
+		next := segment
+		if current != "" {
+			next = current + "." + segment
+		}
+		symbol, ok := symbols[next]
+		if target, isAlias := index.Aliases[segment]; !ok && i == first && isAlias {
+			next = target
+			symbol, ok = symbols[next]
+		}
+		if !ok && next == hiddenNamespace {
+			// The hidden objects are in a local, rather than a
+			// namespace of their own, but type aliases point into it.
+			current = next
+			continue
+		} else if !ok {
+			if current == "" {
+				return nil, fmt.Errorf(
+					"Could not resolve library path '%s': segment %d ('%s') is not in the library",
+					path, i+1, segment)
+			}
+			return nil, fmt.Errorf(
+				"Could not resolve library path '%s': segment %d ('%s') is not in '%s'",
+				path, i+1, segment, current)
+		}
+
+		if symbol.Property != "" {
+			parsed, err := parser.ParseName(symbol.Definition)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"Could not resolve library path '%s' at segment %d ('%s'):\n%v",
+					path, i+1, segment, err)
+			}
+			steps = append(steps, SchemaStep{
+				Definition: parsed,
+				Property:   symbol.Property,
+				Items:      symbol.Items,
+			})
+		} else if symbol.Type != "" {
+			// Symbols of properties have had a type for longer than
+			// they've recorded their property.
+			return nil, fmt.Errorf(
+				"Could not resolve library path '%s': segment %d ('%s') has no definition in the index; regenerate it",
+				path, i+1, segment)
+		}
+
+		if symbol.Kind == SymbolAlias {
+			next = symbol.Target
+		}
+		current = next
+	}
+	return steps, nil
+}
@@ -0,0 +1,122 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `stepStrings` renders steps as `Kind.property`, with `[]` for
+// descents into the elements of arrays.
+func stepStrings(steps []SchemaStep) string {
+	strs := []string{}
+	for _, step := range steps {
+		text := fmt.Sprintf("%s.%s", step.Definition.Kind, step.Property)
+		if step.Items {
+			text += "[]"
+		}
+		strs = append(strs, text)
+	}
+	return strings.Join(strs, " ")
+}
+
+var resolveTests = map[string]string{
+	// Mixins, and the setters in them.
+	"apps.v1beta1.deployment.mixin.spec.template.spec.containers": "Deployment.spec DeploymentSpec.template PodTemplateSpec.spec PodSpec.containers",
+	"apps.v1beta1.deployment.mixin.spec.replicas":                 "Deployment.spec DeploymentSpec.replicas",
+	// Namespaces that aren't properties add no steps.
+	"apps.v1beta1.deployment":       "",
+	"apps.v1beta1.deployment.mixin": "",
+	"apps.v1beta1.deployment.new":   "",
+	// Type aliases descend into the elements of arrays, and into the
+	// objects of other properties.
+	"apps.v1beta1.deployment.mixin.spec.template.spec.containersType.image": "Deployment.spec DeploymentSpec.template PodTemplateSpec.spec PodSpec.containers[] Container.image",
+	"apps.v1beta1.deployment.mixin.specType.replicas":                       "Deployment.spec DeploymentSpec.replicas",
+	"hidden.core.v1.container.image":                                        "Container.image",
+	// Paths may begin with `k.`, and with an alias.
+	"k.apps.v1beta1.deployment.mixin.spec.replicas": "Deployment.spec DeploymentSpec.replicas",
+	"k.job.mixin.spec.completions":                  "Job.spec JobSpec.completions",
+	"job.mixin.spec.completions":                    "Job.spec JobSpec.completions",
+}
+
+func TestResolveLibraryPath(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for path, expected := range resolveTests {
+		steps, err := ResolveLibraryPath(index, path)
+		if err != nil {
+			t.Errorf("Failed to resolve '%s':\n%v", path, err)
+		} else if actual := stepStrings(steps); actual != expected {
+			t.Errorf("Expected '%s' to resolve to '%s', got '%s'", path, expected, actual)
+		}
+	}
+
+	steps, err := ResolveLibraryPath(index, "apps.v1beta1.deployment.mixin.spec")
+	if err != nil || len(steps) != 1 {
+		t.Fatalf("Failed to resolve 'spec':\n%v", err)
+	}
+	expected := kubespec.DefinitionName("io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment")
+	if step := steps[0]; step.Definition.Unparse() != expected || step.Definition.Group != "apps" {
+		t.Errorf("Expected step from '%s', got %#v", expected, step)
+	}
+
+	// Errors name the first segment that isn't in the library.
+	errorTests := map[string]string{
+		"apps.v1beta1.deployment.mixin.spec.template.spek.containers": "segment 7 ('spek') is not in 'apps.v1beta1.deployment.mixin.spec.template'",
+		"apps.v1beta1.deployment.mixin.spec.replicas.foo":             "segment 7 ('foo') is not in 'apps.v1beta1.deployment.mixin.spec.replicas'",
+		"nope.deployment":         "segment 1 ('nope') is not in the library",
+		"k.deployment.mixin.nope": "segment 4 ('nope') is not in",
+	}
+	for path, message := range errorTests {
+		if _, err := ResolveLibraryPath(index, path); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Expected resolving '%s' to fail with '%s', got %v", path, message, err)
+		}
+	}
+}
+
+func TestResolveLibraryPathPruned(t *testing.T) {
+	opts := Options{OnlyVersions: []kubespec.GroupVersion{{Group: "apps", Version: "v1beta2"}}}
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/versions.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// The alias points at the version that remains, and the
+	// definitions it uses are still resolved, though they're hidden.
+	steps, err := ResolveLibraryPath(index, "k.deployment.mixin.spec.template.spec.containersType.image")
+	if err != nil {
+		t.Fatalf("Failed to resolve path:\n%v", err)
+	}
+	const expected = "Deployment.spec DeploymentSpec.template PodTemplateSpec.spec PodSpec.containers[] Container.image"
+	if actual := stepStrings(steps); actual != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+	if steps[0].Definition.Version != "v1beta2" {
+		t.Errorf("Expected the alias to resolve to 'apps/v1beta2', got %#v", steps[0].Definition)
+	}
+
+	// Kinds that were filtered out can't be resolved.
+	for _, path := range []string{"extensions.v1beta1.deployment.mixin.spec", "apps.v1beta1.deployment"} {
+		if _, err := ResolveLibraryPath(index, path); err == nil {
+			t.Errorf("Expected resolving pruned path '%s' to fail", path)
+		}
+	}
+	if _, err := ResolveLibraryPath(index, "apps.v1beta1.deployment"); err == nil ||
+		!strings.Contains(err.Error(), "segment 2 ('v1beta1') is not in 'apps'") {
+		t.Errorf("Expected error to name the pruned version, got %v", err)
+	}
+
+	// Indexes from before symbols recorded their properties can't be
+	// resolved through properties.
+	for _, symbol := range index.Symbols {
+		symbol.Definition, symbol.Property = "", ""
+	}
+	if _, err := ResolveLibraryPath(index, "k.deployment.mixin.spec"); err == nil ||
+		!strings.Contains(err.Error(), "regenerate") {
+		t.Errorf("Expected resolving with an old index to fail, got %v", err)
+	}
+}
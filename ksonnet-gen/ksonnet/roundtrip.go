@@ -0,0 +1,251 @@
+package ksonnet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// Manifest is a Kubernetes object of a corpus read by `ReadManifests`.
+type Manifest struct {
+	// Name is the path of the file the object was read from, relative
+	// to the corpus, followed by `#` and the number of its document,
+	// from 1, if the file has several (e.g., `rbac.yaml#2`).
+	Name string
+	// Object is the object, as `encoding/json` decodes it.
+	Object interface{}
+}
+
+// ReadManifests reads the objects of each `.yaml`, `.yml` and `.json`
+// file in `dir`, and its subdirectories, in order of path; YAML files
+// may have several documents. Documents that are empty, or null, are
+// skipped.
+func ReadManifests(dir string) ([]Manifest, error) {
+	manifests := []Manifest{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		documents := [][]byte{text}
+		if filepath.Ext(path) != ".json" {
+			if documents, err = yamljson.Documents(text); err != nil {
+				return fmt.Errorf("Could not read manifests of '%s':\n%v", name, err)
+			}
+		}
+		for i, document := range documents {
+			var obj interface{}
+			if err := json.Unmarshal(document, &obj); err != nil {
+				return fmt.Errorf("Could not read manifest %d of '%s':\n%v", i+1, name, err)
+			}
+			if obj == nil {
+				continue
+			}
+			manifest := Manifest{Name: filepath.ToSlash(name), Object: obj}
+			if len(documents) > 1 {
+				manifest.Name = fmt.Sprintf("%s#%d", manifest.Name, i+1)
+			}
+			manifests = append(manifests, manifest)
+		}
+		return nil
+	})
+	return manifests, err
+}
+
+// roundTripBuilder is the part of the programs of `RoundTripProgram`
+// that rebuilds `manifest`: `build` walks its fields, calling the
+// setter of each that the namespaces at its path have (the kind's own,
+// and its `mixin`, at the top), descending into the mixin namespace of
+// each object that has one, and merging the rest in as they are, which
+// it lists in `merged`. The kind's `new()` starts the object, if it
+// takes no parameters, so that its `apiVersion` and `kind` are the
+// library's; otherwise the manifest's are used.
+var roundTripBuilder = []string{
+	`local kind = k.util.forManifest(manifest);`,
+	`local nest(path, value) = std.foldr(function(field, nested) {[field]+: nested}, path, value);`,
+	`local build(namespaces, obj, path) = std.foldl(function(built, field)`,
+	`  local value = obj[field];`,
+	`  local members = [ns[field] for ns in namespaces if std.objectHasAll(ns, field)];`,
+	`  local setters = [member for member in members if std.isFunction(member)];`,
+	`  local mixins = [member for member in members if std.isObject(member)];`,
+	`  if std.length(setters) > 0 then {object: built.object + setters[0](value), merged: built.merged}`,
+	`  else if std.length(mixins) > 0 && std.isObject(value) && std.length(value) > 0 then`,
+	`    local nested = build(mixins, value, path + [field]);`,
+	`    {object: built.object + nested.object, merged: built.merged + nested.merged}`,
+	`  else {object: built.object + nest(path, {[field]: value}), merged: built.merged + (if std.isObject(value) && std.length(value) == 0 then [] else [std.join(".", path + [field])])},`,
+	`  std.objectFields(obj), {object: {}, merged: []});`,
+	`local constructed = std.objectHasAll(kind, "new") && std.isFunction(kind.new) && std.length(kind.new) == 0;`,
+	`local base = if constructed then kind.new() else {apiVersion: manifest.apiVersion, kind: manifest.kind};`,
+	`local fields = {[field]: manifest[field] for field in std.objectFields(manifest) if field != "apiVersion" && field != "kind"};`,
+	`local built = build([kind] + (if std.objectHasAll(kind, "mixin") then [kind.mixin] else []), fields, []);`,
+	`{object: base + built.object, merged: built.merged, constructed: constructed}`,
+}
+
+// RoundTripProgram returns a Jsonnet program that rebuilds `manifest`,
+// a Kubernetes object as `encoding/json` decodes it, with the library
+// `k8s.libsonnet`, which must be generated with `Options.EmitGVKIndex`:
+// it looks the manifest's kind up with `util.forManifest`, starts from
+// the kind's `new()`, if it takes no parameters, and sets each field
+// with the setters of the kind and its mixins. Fields that have no
+// setter (e.g., `status`, or ones that aren't in the schema) are merged
+// in as they are.
+//
+// The program evaluates to an object with the rebuilt manifest as
+// `object`, the dotted paths of the fields that were merged as `merged`,
+// and whether `new()` was called as `constructed`; comparing `object`
+// to `manifest` (see `DiffManifests`) checks that the constructor and
+// setters set exactly what the manifest has, and that none of the
+// library's hidden fields leak into it. It's an error if `manifest`
+// isn't an object with a string `apiVersion` and `kind`.
+func RoundTripProgram(manifest interface{}) (string, error) {
+	obj, ok := manifest.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("Can't round-trip a manifest that isn't an object")
+	}
+	for _, field := range []string{"apiVersion", "kind"} {
+		if _, ok := obj[field].(string); !ok {
+			return "", fmt.Errorf("Can't round-trip a manifest without a string '%s'", field)
+		}
+	}
+	literal, err := jsonnet.Literal(manifest)
+	if err != nil {
+		return "", err
+	}
+	lines := append([]string{
+		fmt.Sprintf("local k = import %q;", LibraryFile),
+		"local manifest = " + literal + ";",
+	}, roundTripBuilder...)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// DiffManifests returns how `actual` differs from `expected`, both
+// decoded by `encoding/json`: a line for each value that differs,
+// e.g., `spec.replicas: expected 3, got 2`, each field that's
+// missing, or unexpected, and each list whose length differs, in
+// order of path. Key order doesn't matter. Unless `strictNulls` is
+// set, a field that's `null` is the same as one that's absent, as it
+// is to the API server. Keys that aren't identifiers are quoted in
+// paths, e.g., `metadata.annotations["app.kubernetes.io/name"]`.
+func DiffManifests(expected, actual interface{}, strictNulls bool) []string {
+	diffs := []string{}
+	diffValues(&diffs, "", expected, actual, strictNulls)
+	return diffs
+}
+
+func diffValues(diffs *[]string, path string, expected, actual interface{}, strictNulls bool) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		if a, ok := actual.(map[string]interface{}); ok {
+			diffObjects(diffs, path, e, a, strictNulls)
+			return
+		}
+	case []interface{}:
+		if a, ok := actual.([]interface{}); ok {
+			if len(a) != len(e) {
+				*diffs = append(*diffs, fmt.Sprintf(
+					"%s: expected %d items, got %d: %s", diffPath(path), len(e), len(a), diffValue(a)))
+				return
+			}
+			for i := range e {
+				diffValues(diffs, fmt.Sprintf("%s[%d]", path, i), e[i], a[i], strictNulls)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		*diffs = append(*diffs, fmt.Sprintf(
+			"%s: expected %s, got %s", diffPath(path), diffValue(expected), diffValue(actual)))
+	}
+}
+
+func diffObjects(diffs *[]string, path string, expected, actual map[string]interface{}, strictNulls bool) {
+	keys := []string{}
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := diffKey(path, key)
+		e, eok := expected[key]
+		a, aok := actual[key]
+		if !strictNulls {
+			eok = eok && e != nil
+			aok = aok && a != nil
+		}
+		switch {
+		case eok && !aok:
+			*diffs = append(*diffs, fmt.Sprintf("%s: missing, expected %s", field, diffValue(e)))
+		case aok && !eok:
+			*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", field, diffValue(a)))
+		case eok && aok:
+			diffValues(diffs, field, e, a, strictNulls)
+		}
+	}
+}
+
+// `diffKey` returns the path of the field `key` of the object at
+// `path`; see `DiffManifests`.
+func diffKey(path, key string) string {
+	if jsonnetIdentifier(key) {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	quoted, _ := json.Marshal(key)
+	return fmt.Sprintf("%s[%s]", path, quoted)
+}
+
+func diffPath(path string) string {
+	if path == "" {
+		return "(manifest)"
+	}
+	return path
+}
+
+// `diffValue` writes a value of `DiffManifests` as compact JSON.
+func diffValue(value interface{}) string {
+	text, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(text)
+}
+
+// `jsonnetIdentifier` reports whether `key` can be written as it is
+// in a dotted path, i.e., it has only letters, digits and underscores,
+// and doesn't start with a digit.
+func jsonnetIdentifier(key string) bool {
+	for i, r := range key {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return key != ""
+}
@@ -0,0 +1,189 @@
+package ksonnet
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestReadManifests(t *testing.T) {
+	manifests, err := ReadManifests("testdata/manifests")
+	if err != nil {
+		t.Fatalf("Failed to read manifests:\n%v", err)
+	}
+	names := []string{}
+	for _, manifest := range manifests {
+		names = append(names, manifest.Name)
+	}
+	expected := []string{
+		"configmap.yaml", "database.yaml", "deployment.yaml", "rbac.yaml#1", "rbac.yaml#2", "service.yaml",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected the manifests %v, got %v", expected, names)
+	}
+}
+
+func TestRoundTripProgram(t *testing.T) {
+	program, err := RoundTripProgram(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]interface{}{"workers": "4"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write program:\n%v", err)
+	}
+	for _, line := range []string{
+		`local k = import "k8s.libsonnet";`,
+		`local manifest = {apiVersion: "v1", data: {workers: "4"}, kind: "ConfigMap"};`,
+		`local kind = k.util.forManifest(manifest);`,
+	} {
+		if !strings.Contains(program, line+"\n") {
+			t.Errorf("Expected the program to have:\n%s\ngot:\n%s", line, program)
+		}
+	}
+
+	for _, manifest := range []interface{}{
+		[]interface{}{},
+		map[string]interface{}{"kind": "ConfigMap"},
+		map[string]interface{}{"apiVersion": "v1", "kind": 1.0},
+	} {
+		if _, err := RoundTripProgram(manifest); err == nil {
+			t.Errorf("Expected round-tripping %v to fail", manifest)
+		}
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	decode := func(text string) interface{} {
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			t.Fatalf("Could not decode '%s':\n%v", text, err)
+		}
+		return value
+	}
+	expected := decode(`{
+  "kind": "Deployment",
+  "metadata": {"name": "web", "annotations": {"deployment.kubernetes.io/revision": "3"}, "labels": null},
+  "spec": {"replicas": 3, "template": {"spec": {"containers": [{"name": "web", "image": "nginx"}]}}}
+}`)
+
+	// Key order, and nulls that are absent, don't matter.
+	same := decode(`{
+  "spec": {"template": {"spec": {"containers": [{"image": "nginx", "name": "web"}]}}, "replicas": 3},
+  "metadata": {"annotations": {"deployment.kubernetes.io/revision": "3"}, "name": "web"},
+  "kind": "Deployment"
+}`)
+	if diffs := DiffManifests(expected, same, false); len(diffs) != 0 {
+		t.Errorf("Expected no differences, got:\n%s", strings.Join(diffs, "\n"))
+	}
+	if diffs := DiffManifests(expected, same, true); !reflect.DeepEqual(diffs, []string{`metadata.labels: missing, expected null`}) {
+		t.Errorf("Expected the null to differ with strict nulls, got:\n%s", strings.Join(diffs, "\n"))
+	}
+
+	different := decode(`{
+  "kind": "Deployment",
+  "metadata": {"name": "web", "annotations": {"deployment.kubernetes.io/revision": 3}, "namespace": "shop"},
+  "spec": {"replicas": 2, "template": {"spec": {"containers": [{"name": "web"}]}}, "paused": false}
+}`)
+	expectedDiffs := []string{
+		`metadata.annotations["deployment.kubernetes.io/revision"]: expected "3", got 3`,
+		`metadata.namespace: unexpected "shop"`,
+		`spec.paused: unexpected false`,
+		`spec.replicas: expected 3, got 2`,
+		`spec.template.spec.containers[0].image: missing, expected "nginx"`,
+	}
+	if diffs := DiffManifests(expected, different, false); !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected the differences:\n%s\ngot:\n%s", strings.Join(expectedDiffs, "\n"), strings.Join(diffs, "\n"))
+	}
+
+	// Lists of different lengths differ as a whole.
+	longer := decode(`{"kind": "Deployment", "metadata": {"name": "web", "annotations": {"deployment.kubernetes.io/revision": "3"}},
+  "spec": {"replicas": 3, "template": {"spec": {"containers": [{"name": "web", "image": "nginx"}, {"name": "log"}]}}}}`)
+	expectedDiffs = []string{
+		`spec.template.spec.containers: expected 1 items, got 2: [{"image":"nginx","name":"web"},{"name":"log"}]`,
+	}
+	if diffs := DiffManifests(expected, longer, false); !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected the differences:\n%s\ngot:\n%s", strings.Join(expectedDiffs, "\n"), strings.Join(diffs, "\n"))
+	}
+}
+
+func TestRoundTripCorpus(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "roundtrip")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	text, err := ioutil.ReadFile("testdata/databases.yaml")
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	crds, err := kubespec.ReadCRDs("databases.yaml", text)
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := loadTestSpec(t, "testdata/roundtrip.json").WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	opts := Options{
+		EmitGVKIndex: true,
+		Parser:       &kubespec.Parser{Prefixes: []string{"io.k8s", kubespec.CRDPrefix}},
+	}
+	artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	for name, artifact := range artifacts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), artifact.Text, 0644); err != nil {
+			t.Fatalf("Could not write '%s':\n%v", name, err)
+		}
+	}
+
+	// Each manifest of the corpus is rebuilt exactly by the library's
+	// setters.
+	manifests, err := ReadManifests("testdata/manifests")
+	if err != nil {
+		t.Fatalf("Failed to read manifests:\n%v", err)
+	}
+	main := filepath.Join(dir, "main.jsonnet")
+	for _, manifest := range manifests {
+		program, err := RoundTripProgram(manifest.Object)
+		if err != nil {
+			t.Fatalf("Failed to write the program of '%s':\n%v", manifest.Name, err)
+		}
+		if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-J", dir, main).Output()
+		if err != nil {
+			t.Errorf("Failed to evaluate the program of '%s':\n%v", manifest.Name, err)
+			continue
+		}
+		var result struct {
+			Object interface{} `json:"object"`
+			Merged []string    `json:"merged"`
+		}
+		if err := json.Unmarshal(out, &result); err != nil {
+			t.Fatalf("Could not decode the result of '%s':\n%v", manifest.Name, err)
+		}
+		if diffs := DiffManifests(manifest.Object, result.Object, false); len(diffs) != 0 {
+			t.Errorf("Expected '%s' to round-trip, got:\n%s", manifest.Name, strings.Join(diffs, "\n"))
+		}
+		if len(result.Merged) != 0 {
+			t.Errorf("Expected each field of '%s' to have a setter, got %v", manifest.Name, result.Merged)
+		}
+	}
+}
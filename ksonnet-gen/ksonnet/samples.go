@@ -0,0 +1,97 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// SkippedSample is a top-level kind that `EmitSamples` has no sample
+// for, and why.
+type SkippedSample struct {
+	Kind   string // Its apiVersion and kind, e.g., `apps/v1beta1/Deployment`.
+	Reason string
+}
+
+// Sample is the program of a sample manifest of a top-level kind.
+type Sample struct {
+	Kind string // As `SkippedSample.Kind`.
+	// Path is where the sample goes, without an extension, e.g.,
+	// `apps/v1beta1/deployment`: its program is `.jsonnet`, and the
+	// manifest it renders `.yaml`.
+	Path    string
+	Program []byte
+}
+
+// EmitSamples takes a swagger API specification, and returns the
+// program of a sample manifest for each top-level kind of the library
+// `Emit` generates for it, in order. Each builds the kind with its
+// constructor, passing the dummy values the smoke tests do (see
+// `EmitTests`), and renders the result as YAML, which `jsonnet -S`
+// prints. So evaluating the samples also tests that the constructors
+// build valid objects. The programs expect `k8s.libsonnet` to be two
+// directories up, next to the group directories.
+//
+// Kinds whose constructors take arguments that dummy values can't be
+// derived for are skipped, and returned in order, with why.
+func EmitSamples(
+	spec *kubespec.APISpec, opts Options,
+) ([]*Sample, []*SkippedSample, error) {
+	root := newRoot(spec, opts)
+	if err := joinErrors(root.errors); err != nil {
+		return nil, nil, err
+	}
+
+	samples, skipped := []*Sample{}, []*SkippedSample{}
+	for _, group := range root.groups.toSortedSlice() {
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.apiObjects.toSortedSlice() {
+				if !ao.isTopLevel {
+					continue
+				}
+				kind := fmt.Sprintf("%s/%s", va.apiVersion(), ao.name)
+				args, unsupported := ao.dummyArgs()
+				if unsupported != "" {
+					skipped = append(skipped, &SkippedSample{Kind: kind, Reason: unsupported})
+					continue
+				}
+
+				m := newIndentWriter()
+				ao.emitSample(m, kind, args)
+				text, err := m.bytes()
+				if err != nil {
+					return nil, nil, err
+				}
+				samples = append(samples, &Sample{Kind: kind, Path: ao.samplePath(), Program: text})
+			}
+		}
+	}
+	return samples, skipped, nil
+}
+
+// `samplePath` is the path of the sample of a top-level API object:
+// its path, as directories, ending with the name
+// `Options.KindCollisions` gave its kind if it's qualified, e.g.,
+// `apps/v1beta1/deployment`, or
+// `postgresExampleCom/v1/postgresDatabase`.
+func (ao *apiObject) samplePath() string {
+	path := ao.path()
+	if name := ao.root().kindNameOf(ao); name != nil && name.qualified {
+		path = ao.parent.path() + "." + name.name
+	}
+	return strings.Replace(path, ".", "/", -1)
+}
+
+// `emitSample` emits a program that renders the API object, built by
+// its constructor from `args`, as YAML.
+func (ao *apiObject) emitSample(m *indentWriter, kind string, args []string) {
+	m.writeLine(fmt.Sprintf(
+		"// AUTOGENERATED sample `%s` manifest, built with placeholder values. Render it with `jsonnet -S`. DO NOT MODIFY.",
+		kind))
+	m.writeLine(fmt.Sprintf("local k = %s;", ao.root().libraryImport("../../"+LibraryFile)))
+	m.writeLine("")
+	m.writeLine(fmt.Sprintf(
+		"std.manifestYamlDoc(k.%s.%s(%s))",
+		ao.path(), constructorName, strings.Join(args, ", ")))
+}
@@ -0,0 +1,129 @@
+package ksonnet
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// sampleProgram returns the program of the sample at `path`.
+func sampleProgram(samples []*Sample, path string) string {
+	for _, sample := range samples {
+		if sample.Path == path {
+			return string(sample.Program)
+		}
+	}
+	return ""
+}
+
+func TestEmitSamples(t *testing.T) {
+	samples, skipped, err := EmitSamples(loadTestSpec(t, "testdata/workloads.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit samples:\n%v", err)
+	}
+	names := []string{}
+	for _, sample := range samples {
+		names = append(names, sample.Kind+" "+sample.Path)
+	}
+	expected := []string{
+		"apps/v1/Deployment apps/v1/deployment",
+		"batch/v1/Job batch/v1/job",
+		"extensions/v1beta1/DaemonSet extensions/v1beta1/daemonSet",
+	}
+	if !reflect.DeepEqual(names, expected) || len(skipped) != 0 {
+		t.Errorf("Expected samples %v, got %v, skipping %v", expected, names, skipped)
+	}
+
+	// Each sample renders its kind's constructor, called with the smoke
+	// tests' dummy arguments, as YAML.
+	job := sampleProgram(samples, "batch/v1/job")
+	for _, line := range []string{
+		"// AUTOGENERATED sample `batch/v1/Job` manifest, built with placeholder values. Render it with `jsonnet -S`. DO NOT MODIFY.\n",
+		"local k = import \"../../k8s.libsonnet\";\n",
+		"\nstd.manifestYamlDoc(k.batch.v1.job.new(\"x\", [{name: \"x\"}]))\n",
+	} {
+		if !strings.Contains(job, line) {
+			t.Errorf("Expected the job sample to contain:\n%s\ngot:\n%s", line, job)
+		}
+	}
+}
+
+func TestEmitSamplesSkipped(t *testing.T) {
+	// A parameter that sets no field has no type to derive a dummy
+	// value from.
+	constructorOverrides["stable"] = map[kubespec.ObjectKind]*constructorOverride{
+		"CronTab": {params: []constructorParam{{name: "schedule"}}},
+	}
+	defer delete(constructorOverrides, "stable")
+
+	samples, skipped, err := EmitSamples(loadTestSpec(t, "testdata/crd.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit samples:\n%v", err)
+	}
+	expected := []*SkippedSample{{
+		Kind:   "stable/v1/CronTab",
+		Reason: "the constructor's 'schedule' parameter sets no field, so its type is unknown",
+	}}
+	if len(samples) != 0 || !reflect.DeepEqual(skipped, expected) {
+		t.Errorf("Expected the cron tab to be skipped, got %d samples, skipping %v", len(samples), skipped)
+	}
+}
+
+// Each sample renders a manifest of its kind.
+func TestEmitSamplesEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	dir, err := ioutil.TempDir("", "samples")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+		t.Fatalf("Could not write library:\n%v", err)
+	}
+	samples, _, err := EmitSamples(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit samples:\n%v", err)
+	}
+	for _, sample := range samples {
+		path := filepath.Join(dir, filepath.FromSlash(sample.Path)+".jsonnet")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Could not create directory:\n%v", err)
+		}
+		if err := ioutil.WriteFile(path, sample.Program, 0644); err != nil {
+			t.Fatalf("Could not write sample:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-S", path).Output()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			t.Errorf("Could not evaluate the sample of '%s':\n%v\n%s", sample.Kind, err, exitErr.Stderr)
+			continue
+		} else if err != nil {
+			t.Fatalf("Could not run `jsonnet`:\n%v", err)
+		}
+		manifest, err := yamljson.ToJSON(out)
+		var obj struct{ APIVersion, Kind string }
+		if err == nil {
+			err = json.Unmarshal(manifest, &obj)
+		}
+		if err != nil || obj.APIVersion+"/"+obj.Kind != sample.Kind {
+			t.Errorf("Expected the sample of '%s' to be a manifest of it, got:\n%s", sample.Kind, out)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package ksonnet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `kindSharing` is the state of `Options.ShareIdenticalKinds`: which
+// top-level kinds share the body of another, identical one.
+type kindSharing struct {
+	// canonical maps the definition name of each kind that's shared to
+	// the kind whose body it shares, which is the first of them to be
+	// emitted; shared are those kinds, in the order they're emitted.
+	canonical map[kubespec.DefinitionName]*apiObject
+	shared    []*apiObject
+}
+
+// `hiddenRefPattern` matches what the fields of a namespace refer to
+// in the hidden objects: type aliases (e.g.,
+// `hidden.apps.v1beta1.scaleSpec`), and, with `Options.Compact`, shared
+// mixins (e.g., `__hidden_apps_v1beta1_scaleSpecMixins`).
+var hiddenRefPattern = regexp.MustCompile(`\bhidden(\.[A-Za-z0-9_]+)+|\b__hidden_[A-Za-z0-9_]+Mixins\b`)
+
+// `newKindSharing` chooses the top-level kinds that
+// `Options.ShareIdenticalKinds` shares, or returns nil if the option
+// isn't set. Candidates are the kinds of the same structural hash (see
+// `kubespec.APISpec.StructuralHashes`), whose namespaces aren't
+// customized; of those, the kinds share whose fields are emitted the
+// same, but for the hidden objects they refer to, which are identical
+// since their hashes are. That leaves out, e.g., kinds with helpers
+// only one group has, or with properties blacklisted in only one.
+func (root *root) newKindSharing() *kindSharing {
+	if !root.opts.ShareIdenticalKinds {
+		return nil
+	}
+	hashes := root.spec.StructuralHashes()
+
+	candidates := map[string][]*apiObject{}
+	order := []string{}
+	for _, group := range root.groups.toSortedSlice() {
+		for _, va := range group.versionedAPIs.toSortedSlice() {
+			for _, ao := range va.emitOrder() {
+				if _, aliased := root.betaAliasOf(ao); !ao.isTopLevel || aliased || root.customizes(ao.path()) {
+					continue
+				}
+				hash := hashes[ao.parsedName.Unparse()]
+				if _, ok := candidates[hash]; !ok {
+					order = append(order, hash)
+				}
+				candidates[hash] = append(candidates[hash], ao)
+			}
+		}
+	}
+
+	s := &kindSharing{canonical: map[kubespec.DefinitionName]*apiObject{}}
+	for _, hash := range order {
+		if len(candidates[hash]) < 2 {
+			continue
+		}
+		byText := map[string][]*apiObject{}
+		texts := []string{}
+		for _, ao := range candidates[hash] {
+			text := hiddenRefPattern.ReplaceAllString(root.fieldsText(ao), "hidden")
+			if _, ok := byText[text]; !ok {
+				texts = append(texts, text)
+			}
+			byText[text] = append(byText[text], ao)
+		}
+		for _, text := range texts {
+			identical := byText[text]
+			if len(identical) < 2 {
+				continue
+			}
+			s.shared = append(s.shared, identical[0])
+			for _, ao := range identical {
+				s.canonical[ao.parsedName.Unparse()] = identical[0]
+			}
+		}
+	}
+	root.logger().Log("share", "kinds", len(s.shared), "namespaces", len(s.canonical))
+	return s
+}
+
+// `customizes` reports whether `Options.Customizations` customizes the
+// namespace at `path`, or one in it.
+func (root *root) customizes(path string) bool {
+	for customized := range root.opts.Customizations {
+		if customized == path || strings.HasPrefix(customized, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// `fieldsText` returns the fields of the namespace of the API object
+// `ao`, as they're emitted, without indexing their symbols.
+func (root *root) fieldsText(ao *apiObject) string {
+	m := newIndentWriter()
+	root.withoutCounting(func() {
+		root.withoutIndex(func() { ao.emitFields(m, ao.path()) })
+	})
+	text, _ := m.bytes()
+	return string(text)
+}
+
+// `withoutIndex` calls `emit` with a scratch symbol index, for emitting
+// text whose symbols are indexed elsewhere, or not at all.
+func (root *root) withoutIndex(emit func()) {
+	index := root.index
+	root.index = newSymbolIndex(root.spec.Info.Version)
+	defer func() { root.index = index }()
+	emit()
+}
+
+// `sharesKind` reports whether the top-level API object `ao` shares the
+// body of an identical kind with `Options.ShareIdenticalKinds`.
+func (root *root) sharesKind(ao *apiObject) bool {
+	if s := root.kindSharing; s != nil {
+		_, ok := s.canonical[ao.parsedName.Unparse()]
+		return ok
+	}
+	return false
+}
+
+// `sharedKindName` is the name of the local function that returns the
+// shared body of a top-level kind, given its `apiVersion`, e.g.,
+// `__apps_v1beta1_scaleKind`.
+func (ao *apiObject) sharedKindName() string {
+	return fmt.Sprintf("__%sKind", strings.Replace(ao.path(), ".", "_", -1))
+}
+
+// `emitSharedKindRef` emits the namespace of the top-level API object
+// `ao`, named `jsonnetName`, as a call of the shared body of the kinds
+// identical to it with its version's `apiVersion`, e.g.,
+// `scale:: __apps_v1beta1_scaleKind(apiVersion),`, which evaluates to
+// the namespace `emitNamespace` would emit. That's also emitted, to a
+// scratch writer, so that it's indexed as though it were.
+func (ao *apiObject) emitSharedKindRef(m *indentWriter, jsonnetName kubespec.ObjectKind) {
+	scratch := newIndentWriter()
+	scratch.depth = m.depth
+	ao.root().withoutCounting(func() { ao.emitNamespace(scratch, jsonnetName) })
+
+	canonical := ao.root().kindSharing.canonical[ao.parsedName.Unparse()]
+	m.writeLine(fmt.Sprintf("%s:: %s(apiVersion),", jsonnetName, canonical.sharedKindName()))
+}
+
+// `emitSharedKinds` emits the shared body of each set of identical
+// top-level kinds, as a local function of the library, whose parameter
+// is the `apiVersion` each of the kinds' namespaces passes.
+func (root *root) emitSharedKinds(m *indentWriter) {
+	s := root.kindSharing
+	if s == nil {
+		return
+	}
+	for _, ao := range s.shared {
+		m.push(ao.parsedName.Unparse())
+		m.writeLine(fmt.Sprintf(
+			"// The body of `%s`, which the namespaces of the kinds identical to it share.",
+			ao.parsedName.Unparse()))
+		m.writeLine(fmt.Sprintf("local %s(apiVersion) = {", ao.sharedKindName()))
+		m.indent()
+		root.withoutIndex(func() { ao.emitFields(m, ao.path()) })
+		m.dedent()
+		m.writeLine("},")
+		m.pop()
+	}
+}
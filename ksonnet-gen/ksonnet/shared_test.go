@@ -0,0 +1,173 @@
+package ksonnet
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
+)
+
+// `versionText` returns the text of the namespace of the version named
+// `version` of the group named `group` in `library`.
+func versionText(library, group, version string) string {
+	start := strings.Index(library, "\n  "+group+":: {\n")
+	if start == -1 {
+		return ""
+	}
+	text := library[start:]
+	start = strings.Index(text, "\n    "+version+":: {\n")
+	if start == -1 {
+		return ""
+	}
+	text = text[start:]
+	return text[:strings.Index(text, "\n    },\n")]
+}
+
+func TestShareIdenticalKinds(t *testing.T) {
+	const path = "testdata/polymorphic.json"
+	library := emitTestSpec(t, path, Options{ShareIdenticalKinds: true})
+
+	// The `Scale` of `apps` and `extensions` share a body, whose status
+	// has a map as its selector; that of `autoscaling` has a string.
+	const ref = "\n      scale:: __apps_v1beta1_scaleKind(apiVersion),"
+	for _, gv := range [][]string{{"apps", "v1beta1"}, {"apps", "v1beta2"}, {"extensions", "v1beta1"}} {
+		if text := versionText(library, gv[0], gv[1]); !strings.Contains(text, ref) || strings.Contains(text, "scale:: {") {
+			t.Errorf("Expected '%s.%s' to refer to the shared body:\n%s", gv[0], gv[1], text)
+		}
+	}
+	if text := versionText(library, "autoscaling", "v1"); !strings.Contains(text, "\n      scale:: {\n") {
+		t.Errorf("Expected 'autoscaling.v1.scale' to be expanded:\n%s", text)
+	}
+	const local = "\n  local __apps_v1beta1_scaleKind(apiVersion) = {\n"
+	if strings.Count(library, local) != 1 || strings.Count(library, "Kind(apiVersion) = {") != 1 {
+		t.Fatalf("Expected one shared body:\n%s", library)
+	}
+
+	// The shared body is the namespace each kind would have, but for
+	// the hidden objects it refers to, which are identical.
+	body := library[strings.Index(library, local)+len(local):]
+	body = body[:strings.Index(body, "\n  },\n")]
+	expanded := emitTestSpec(t, path, Options{})
+	dedent := func(text string, depth int) string {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, strings.Repeat("  ", depth))
+		}
+		return strings.Join(lines, "\n")
+	}
+	for _, group := range []string{"apps", "extensions"} {
+		object := objectText(versionText(expanded, group, "v1beta1"), "scale")
+		fields := strings.Replace(object[strings.Index(object, "{\n")+2:], "hidden."+group+".", "hidden.apps.", -1)
+		// The shared body has no example, since it names the kind.
+		for strings.HasPrefix(strings.TrimSpace(fields), "//") {
+			fields = fields[strings.Index(fields, "\n")+1:]
+		}
+		if dedent(fields, 4) != dedent(body, 2) {
+			t.Errorf("Expected the shared body to be that of '%s.v1beta1.scale':\n%s\ngot:\n%s", group, fields, body)
+		}
+	}
+
+	// The symbols (and so the aliases) are those of the expanded kinds.
+	spec := loadTestSpec(t, path)
+	shared, err := BuildSymbolIndex(spec, Options{ShareIdenticalKinds: true})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	independent, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if !reflect.DeepEqual(shared, independent) {
+		t.Errorf("Expected sharing not to change the symbol index")
+	}
+
+	sizes, total, err := EmittedSizes(spec, Options{ShareIdenticalKinds: true})
+	if err != nil {
+		t.Fatalf("Failed to measure library:\n%v", err)
+	}
+	if total != len(library) || sizes["io.k8s.api.apps.v1beta1.Scale"] <= sizes["io.k8s.api.apps.v1beta2.Scale"] {
+		t.Errorf("Expected the shared body to count against its definition, got %d of %d bytes: %v", total, len(library), sizes)
+	}
+
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	emitTestSpec(t, path, Options{ShareIdenticalKinds: true, Logger: logger})
+	if events := logger.events["share"]; len(events) != 1 || events[0]["kinds"] != 1 || events[0]["namespaces"] != 3 {
+		t.Errorf("Expected one kind to be shared by three namespaces, got %v", events)
+	}
+}
+
+func TestShareIdenticalKindsExclusions(t *testing.T) {
+	const path = "testdata/polymorphic.json"
+
+	// A property blacklisted in only one group emits that group's kind
+	// differently; its hash is the same, since the schema is.
+	data, err := kubeversion.Default().WithOverrides("v1.9.0", kubeversion.Overrides{
+		BlacklistedProperties: map[string][]string{
+			"io.k8s.api.extensions.v1beta1.ScaleStatus": {"targetSelector"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Could not override the data:\n%v", err)
+	}
+	for _, c := range []struct {
+		opts     Options
+		expanded string
+	}{
+		{Options{KubeVersions: data}, "extensions.v1beta1"},
+		{Options{Customizations: map[string]string{"apps.v1beta1.scale.mixin.spec": "foo:: 1,"}}, "apps.v1beta1"},
+	} {
+		c.opts.ShareIdenticalKinds = true
+		library := emitTestSpec(t, path, c.opts)
+		for _, gv := range []string{"apps.v1beta1", "apps.v1beta2", "extensions.v1beta1"} {
+			parts := strings.Split(gv, ".")
+			text := versionText(library, parts[0], parts[1])
+			if expanded := strings.Contains(text, "scale:: {"); expanded != (gv == c.expanded) {
+				t.Errorf("Expected '%s.scale' to be expanded: %v, with %+v:\n%s", gv, gv == c.expanded, c.opts, text)
+			}
+		}
+		if !strings.Contains(library, "(apiVersion) = {") || strings.Contains(library, fmt.Sprintf("__%sKind", strings.Replace(c.expanded+".scale", ".", "_", -1))) {
+			t.Errorf("Expected the other kinds to share the body of another with %+v:\n%s", c.opts, library)
+		}
+	}
+
+	// The option can't be combined with `SplitByGroup`.
+	if err := (&Options{ShareIdenticalKinds: true, SplitByGroup: true}).Validate(); err == nil {
+		t.Errorf("Expected ShareIdenticalKinds and SplitByGroup to be invalid")
+	}
+}
+
+func TestShareIdenticalKindsEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	spec := loadTestSpec(t, "testdata/polymorphic.json")
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// Each group's kind, made by its constructor, with every one of its
+	// one-parameter functions applied.
+	calls := []string{}
+	for _, kind := range []string{"apps.v1beta1.scale", "apps.v1beta2.scale", "autoscaling.v1.scale", "extensions.v1beta1.scale"} {
+		calls = append(calls, fmt.Sprintf("  %q: k.%s.new(),", kind, kind))
+		for _, symbol := range index.Symbols {
+			if symbol.Kind == SymbolFunction && len(symbol.Params) == 1 && strings.HasPrefix(symbol.Path, kind+".") {
+				calls = append(calls, fmt.Sprintf("  %q: k.%s.new() + k.%s(1),", symbol.Path, kind, symbol.Path))
+			}
+		}
+	}
+	program := "local k = import 'k8s.libsonnet';\n{\n" + strings.Join(calls, "\n") + "\n}\n"
+
+	independent := evaluateLibrary(t, jsonnet, spec, Options{}, program)
+	for _, opts := range []Options{{ShareIdenticalKinds: true}, {ShareIdenticalKinds: true, Compact: true}} {
+		if shared := evaluateLibrary(t, jsonnet, spec, opts, program); shared != independent {
+			t.Errorf("Expected the shared kinds to evaluate with %+v to:\n%s\ngot:\n%s", opts, independent, shared)
+		}
+	}
+}
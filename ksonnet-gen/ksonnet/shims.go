@@ -0,0 +1,129 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `emitDeprecatedShims` emits, in the namespace at `path`, the shims of
+// the API object's renamed properties (see
+// `kubeversion.DeprecatedShims`), after the symbols of the properties
+// that replaced them: for each symbol of a replacement in the
+// namespace, a wrapper of it by the old name, e.g.,
+// `serviceAccount(serviceAccount):: self.serviceAccountName(serviceAccount)`,
+// or, for type aliases and mixin namespaces, a field that points at it,
+// e.g., `externalAdmissionHooksType:: self.webhooksType`. With
+// `Options.WarnDeprecatedShims`, each also `std.trace`s a warning that
+// names its replacement when it's evaluated. Shims are indexed as
+// `Symbol.Deprecated`.
+//
+// A shim isn't emitted while the object still has a property of its
+// old name, whose own setter still works, or if it has no replacement,
+// or if another of its symbols has the shim's identifier.
+func (ao *apiObject) emitDeprecatedShims(m *indentWriter, path string) {
+	root := ao.root()
+	k8sVersion := root.spec.Info.Version
+	definition := ao.parsedName.Unparse()
+	shims := root.kubeVersions.DeprecatedShims(k8sVersion, definition)
+	if len(shims) == 0 {
+		return
+	}
+
+	taken := map[string]bool{}
+	for name := range ao.properties {
+		taken[string(ao.identifier(name))] = true
+	}
+	for _, shim := range shims {
+		if _, ok := ao.properties[shim.Name]; ok {
+			continue
+		}
+		if _, ok := ao.properties[shim.Replacement]; !ok {
+			continue
+		}
+		oldID := string(root.naming().RewriteAsIdentifier(k8sVersion, shim.Name))
+		newID := string(ao.identifier(shim.Replacement))
+		param := string(root.naming().RewriteAsFuncParam(k8sVersion, shim.Name))
+		for _, symbol := range root.index.replacementSymbols(path, definition, shim.Replacement) {
+			name := strings.TrimPrefix(symbol.Path, path+".")
+			if !strings.HasPrefix(name, newID) {
+				continue
+			}
+			shimName := oldID + strings.TrimPrefix(name, newID)
+			if taken[shimName] {
+				continue
+			}
+			taken[shimName] = true
+			ao.emitDeprecatedShim(m, path, shimName, name, param, symbol, shim.Name)
+		}
+	}
+}
+
+// `emitDeprecatedShim` emits the shim `shimName` of the namespace at
+// `path`, which wraps `symbol`, whose identifier is `name`, and indexes
+// it for the property `property` that it had. Functions of a single
+// parameter (i.e., setters) take it as `param`, the parameter of the
+// old setter, so that calls that name it keep working.
+func (ao *apiObject) emitDeprecatedShim(
+	m *indentWriter, path, shimName, name, param string, symbol *Symbol, property kubespec.PropertyName,
+) {
+	root := ao.root()
+	shimSymbol := *symbol
+	shimSymbol.Path = path + "." + shimName
+	shimSymbol.Property = property
+	shimSymbol.RenamedFrom = ""
+	shimSymbol.Deprecated = true
+	shimSymbol.ReplacedBy = symbol.Path
+
+	field := shimName
+	body := "self." + name
+	if symbol.Kind == SymbolFunction {
+		params := symbol.Params
+		signature := []string{}
+		if len(params) == 1 {
+			params = []string{param}
+			shimSymbol.Defaults = nil
+			if value, ok := symbol.Defaults[symbol.Params[0]]; ok {
+				shimSymbol.Defaults = map[string]string{param: value}
+			}
+		}
+		for _, p := range params {
+			if value, ok := shimSymbol.Defaults[p]; ok {
+				signature = append(signature, p+"="+value)
+			} else {
+				signature = append(signature, p)
+			}
+		}
+		shimSymbol.Params = params
+		field = fmt.Sprintf("%s(%s)", shimName, strings.Join(signature, ", "))
+		body = fmt.Sprintf("self.%s(%s)", name, strings.Join(params, ", "))
+	}
+	if root.opts.WarnDeprecatedShims {
+		body = fmt.Sprintf("std.trace(%q, %s)", fmt.Sprintf(
+			"DEPRECATED: `%s` of `%s` is replaced by `%s`", shimName, ao.name, name), body)
+	}
+
+	m.writeLine(fmt.Sprintf("// DEPRECATED: `%[1]s` is replaced by `%[2]s`; use `%[2]s` instead.", shimName, name))
+	m.writeLine(fmt.Sprintf("%s:: %s,", field, body))
+	root.index.Symbols = append(root.index.Symbols, &shimSymbol)
+}
+
+// `replacementSymbols` returns the symbols directly in the namespace at
+// `path` for the property `property` of `definition`, e.g., the setter
+// `webhooks` and the type alias `webhooksType`, in the order they were
+// added.
+func (si *SymbolIndex) replacementSymbols(
+	path string, definition kubespec.DefinitionName, property kubespec.PropertyName,
+) []*Symbol {
+	symbols := []*Symbol{}
+	prefix := path + "."
+	for _, symbol := range si.Symbols {
+		name := strings.TrimPrefix(symbol.Path, prefix)
+		if name != symbol.Path && !strings.Contains(name, ".") && !symbol.Deprecated &&
+			symbol.Definition == definition && symbol.Property == property {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,200 @@
+package ksonnet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `withServiceAccount` returns the spec of `testdata/deprecatedshims.json`
+// with the deprecated `serviceAccount` of its `PodSpec`, as the specs
+// of Kubernetes still have it.
+func withServiceAccount(t *testing.T) *kubespec.APISpec {
+	spec := loadTestSpec(t, "testdata/deprecatedshims.json")
+	stringType := kubespec.SchemaType("string")
+	spec.Definitions["io.k8s.api.core.v1.PodSpec"].Properties["serviceAccount"] = &kubespec.Property{
+		Description: "DeprecatedServiceAccount is a depreciated alias for ServiceAccountName.",
+		Type:        &stringType,
+	}
+	return spec
+}
+
+func TestEmitDeprecatedShims(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/deprecatedshims.json")
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+
+	// Each symbol of a replacement gets a shim by the old name: setters,
+	// type aliases, and mixin namespaces.
+	for _, line := range []string{
+		"\n          // DEPRECATED: `serviceAccount` is replaced by `serviceAccountName`; use `serviceAccountName` instead.\n          serviceAccount(serviceAccount):: self.serviceAccountName(serviceAccount),\n",
+		"\n        externalAdmissionHooks(externalAdmissionHooks):: self.webhooks(externalAdmissionHooks),\n",
+		"\n        externalAdmissionHooksType:: self.webhooksType,\n",
+		"\n        count(count):: self.deprecatedCount(count),\n",
+		"\n          source:: self.deprecatedSource,\n",
+	} {
+		if !strings.Contains(string(library), line) {
+			t.Errorf("Expected the library to have:\n%s\ngot:\n%s", line, library)
+		}
+	}
+	if strings.Contains(string(library), "std.trace") {
+		t.Errorf("Expected the shims to be silent by default:\n%s", library)
+	}
+
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	for path, replacement := range map[string]string{
+		"core.v1.pod.mixin.spec.serviceAccount":                                                   "core.v1.pod.mixin.spec.serviceAccountName",
+		"admissionregistration.v1beta1.validatingWebhookConfiguration.externalAdmissionHooksType": "admissionregistration.v1beta1.validatingWebhookConfiguration.webhooksType",
+		"events.v1beta1.event.mixin.source":                                                       "events.v1beta1.event.mixin.deprecatedSource",
+	} {
+		symbol := symbols[path]
+		if symbol == nil || !symbol.Deprecated || symbol.ReplacedBy != replacement {
+			t.Errorf("Expected '%s' to be indexed as deprecated, replaced by '%s', got %#v", path, replacement, symbol)
+			continue
+		}
+		if symbol.Kind != symbols[replacement].Kind {
+			t.Errorf("Expected '%s' to be a %s like '%s', got a %s", path, symbols[replacement].Kind, replacement, symbol.Kind)
+		}
+	}
+	if symbol := symbols["core.v1.pod.mixin.spec.serviceAccount"]; symbol != nil &&
+		(symbol.Property != "serviceAccount" || !reflect.DeepEqual(symbol.Params, []string{"serviceAccount"})) {
+		t.Errorf("Expected the shim to be for the property 'serviceAccount', with its parameter, got %#v", symbol)
+	}
+	if symbol := symbols["core.v1.pod.mixin.spec.serviceAccountName"]; symbol.Deprecated {
+		t.Errorf("Expected the replacement not to be deprecated, got %#v", symbol)
+	}
+
+	// With warnings, each shim traces one.
+	library, err = Emit(context.Background(), spec, Options{WarnDeprecatedShims: true})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	line := "serviceAccount(serviceAccount):: std.trace(\"DEPRECATED: `serviceAccount` of `PodSpec` is replaced by `serviceAccountName`\", self.serviceAccountName(serviceAccount)),"
+	if !strings.Contains(string(library), line) {
+		t.Errorf("Expected the library to have:\n%s\ngot:\n%s", line, library)
+	}
+
+	// While the definition has the old property, its own setter is kept.
+	library, err = Emit(context.Background(), withServiceAccount(t), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if strings.Contains(string(library), "self.serviceAccountName(serviceAccount)") ||
+		!strings.Contains(string(library), "serviceAccount(serviceAccount):: __specMixin({serviceAccount: serviceAccount}),") {
+		t.Errorf("Expected no shim of a property the definition has:\n%s", library)
+	}
+}
+
+func TestMigrateDeprecatedShims(t *testing.T) {
+	from, err := BuildSymbolIndex(withServiceAccount(t), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	to, err := BuildSymbolIndex(loadTestSpec(t, "testdata/deprecatedshims.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// References to shims are rewritten as their replacements.
+	migration := MigrateImports(from, to, map[string][]byte{
+		"main.jsonnet": []byte("local k = import \"k.libsonnet\";\nk.core.v1.pod.mixin.spec.serviceAccount(\"web\")\n"),
+	})
+	expected := "local k = import \"k.libsonnet\";\nk.core.v1.pod.mixin.spec.serviceAccountName(\"web\")\n"
+	if text := string(migration.Files()["main.jsonnet"]); text != expected {
+		t.Errorf("Expected the migrated file:\n%s\ngot:\n%s", expected, text)
+	}
+	if len(migration.Unresolved) != 0 {
+		t.Errorf("Expected every reference to resolve, got %v", migration.Unresolved)
+	}
+}
+
+func TestDeprecatedShimsEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+	spec := loadTestSpec(t, "testdata/deprecatedshims.json")
+	program := strings.Join([]string{
+		"local k = import 'k8s.libsonnet';",
+		"local pod = k.core.v1.pod, event = k.events.v1beta1.event, hooks = k.admissionregistration.v1beta1.validatingWebhookConfiguration;",
+		"{",
+		"  pod: pod.mixin.spec.serviceAccount('web') == pod.mixin.spec.serviceAccountName('web'),",
+		"  named: pod.mixin.spec.serviceAccount(serviceAccount='web') == pod.mixin.spec.serviceAccountName('web'),",
+		"  event: event.count(3) + event.mixin.source.component('kubelet') == event.deprecatedCount(3) + event.mixin.deprecatedSource.component('kubelet'),",
+		"  hooks: hooks.externalAdmissionHooks(hooks.externalAdmissionHooksType.name('a')) == hooks.webhooks(hooks.webhooksType.name('a')),",
+		"}",
+	}, "\n")
+
+	dir, err := ioutil.TempDir("", "deprecatedshims")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+
+	// Shims set the same fields as their replacements; silently, unless
+	// they're made to warn.
+	for _, warn := range []bool{false, true} {
+		library, err := Emit(context.Background(), spec, Options{WarnDeprecatedShims: warn})
+		if err != nil {
+			t.Fatalf("Failed to emit library:\n%v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+			t.Fatalf("Could not write library:\n%v", err)
+		}
+		cmd := exec.Command(jsonnet, "-J", dir, main)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Could not evaluate the library with warnings %v:\n%v\n%s", warn, err, stderr.String())
+		}
+		matches := map[string]bool{}
+		if err := json.Unmarshal(out, &matches); err != nil {
+			t.Fatalf("Could not read the evaluated checks:\n%v\n%s", err, out)
+		}
+		if expected := map[string]bool{"event": true, "hooks": true, "named": true, "pod": true}; !reflect.DeepEqual(matches, expected) {
+			t.Errorf("Expected the shims to match their replacements with warnings %v, got:\n%s", warn, out)
+		}
+
+		warnings := stderr.String()
+		if !warn {
+			if warnings != "" {
+				t.Errorf("Expected silent shims not to warn, got:\n%s", warnings)
+			}
+			continue
+		}
+		for _, warning := range []string{
+			"DEPRECATED: `serviceAccount` of `PodSpec` is replaced by `serviceAccountName`",
+			"DEPRECATED: `count` of `Event` is replaced by `deprecatedCount`",
+			"DEPRECATED: `source` of `Event` is replaced by `deprecatedSource`",
+			"DEPRECATED: `externalAdmissionHooks` of `ValidatingWebhookConfiguration` is replaced by `webhooks`",
+			"DEPRECATED: `externalAdmissionHooksType` of `ValidatingWebhookConfiguration` is replaced by `webhooksType`",
+		} {
+			if !strings.Contains(warnings, warning) {
+				t.Errorf("Expected the shims to warn:\n%s\ngot:\n%s", warning, warnings)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package ksonnet
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Sink is where the files of a library are written, e.g., a directory
+// (see `NewDirSink`), or an archive (see `NewTarSink` and
+// `NewZipSink`), so that a library can be published as a single
+// artifact without writing it to a directory first.
+type Sink interface {
+	// CreateFile returns a writer for the file `name`, a path relative
+	// to the root of the library, separated by slashes (e.g.,
+	// `tests/apps.jsonnet`). The file is written when the writer is
+	// closed, which must be before the next file is created.
+	CreateFile(name string) (io.WriteCloser, error)
+
+	// Finalize finishes writing the library, once every file is
+	// written.
+	Finalize() error
+}
+
+// WriteFile writes the file `name`, whose contents are `text`, to
+// `sink`.
+func WriteFile(sink Sink, name string, text []byte) error {
+	w, err := sink.CreateFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(text); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// WriteArtifacts writes each of `artifacts` to `sink`, in order of
+// name, and finalizes it.
+func WriteArtifacts(sink Sink, artifacts map[string]*Artifact) error {
+	names := []string{}
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := WriteFile(sink, name, artifacts[name].Text); err != nil {
+			return fmt.Errorf("Could not write `%s`:\n%v", name, err)
+		}
+	}
+	return sink.Finalize()
+}
+
+// NewDirSink returns a sink that writes each file to `dir`, creating
+// it, and the directories files are in, as needed. Each file is
+// written to a temporary file next to it, which replaces it once it's
+// closed, so that a file is never left half-written.
+func NewDirSink(dir string) Sink {
+	return &dirSink{dir: dir}
+}
+
+type dirSink struct {
+	dir string
+}
+
+func (s *dirSink) CreateFile(name string) (io.WriteCloser, error) {
+	target := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{File: f, target: target}, nil
+}
+
+func (s *dirSink) Finalize() error {
+	return nil
+}
+
+// `dirFile` is a file of a `dirSink`, which replaces its target when
+// it's closed.
+type dirFile struct {
+	*os.File
+	target string
+}
+
+func (f *dirFile) Close() error {
+	err := f.File.Close()
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.target)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// archiveModTime is the modification time of every file in an archive,
+// so that a library's archive is the same whenever it's generated.
+var archiveModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewTarSink returns a sink that writes the library to `w` as a
+// gzipped tar archive, whose files have the names they're created with
+// and are in the order they're created. An archive is written whole or
+// not at all, since it's unusable until `Finalize` ends it.
+func NewTarSink(w io.Writer) Sink {
+	gz := gzip.NewWriter(w)
+	return &tarSink{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+type tarSink struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (s *tarSink) CreateFile(name string) (io.WriteCloser, error) {
+	return &bufferedFile{write: func(text []byte) error {
+		header := &tar.Header{
+			Name:     path.Clean(name),
+			Mode:     0644,
+			Size:     int64(len(text)),
+			ModTime:  archiveModTime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := s.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := s.tw.Write(text)
+		return err
+	}}, nil
+}
+
+func (s *tarSink) Finalize() error {
+	if err := s.tw.Close(); err != nil {
+		return err
+	}
+	return s.gz.Close()
+}
+
+// NewZipSink returns a sink that writes the library to `w` as a zip
+// archive, like `NewTarSink`.
+func NewZipSink(w io.Writer) Sink {
+	return &zipSink{zw: zip.NewWriter(w)}
+}
+
+type zipSink struct {
+	zw *zip.Writer
+}
+
+func (s *zipSink) CreateFile(name string) (io.WriteCloser, error) {
+	header := &zip.FileHeader{Name: path.Clean(name), Method: zip.Deflate, Modified: archiveModTime}
+	header.SetMode(0644)
+	w, err := s.zw.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{w}, nil
+}
+
+func (s *zipSink) Finalize() error {
+	return s.zw.Close()
+}
+
+// `bufferedFile` buffers a file of an archive whose header needs its
+// size, and calls `write` with its contents when it's closed.
+type bufferedFile struct {
+	bytes.Buffer
+	write func(text []byte) error
+}
+
+func (f *bufferedFile) Close() error {
+	return f.write(f.Bytes())
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,144 @@
+package ksonnet
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// sinkArtifacts returns the files of a library split by group, with
+// a test in a directory of its own and checksums, as a command that
+// writes them all would.
+func sinkArtifacts(t *testing.T) Artifacts {
+	opts := Options{SplitByGroup: true}
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	artifacts, err := EmitArtifacts(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	artifacts["tests/apps.jsonnet"] = NewArtifact([]byte("local k = import \"../k.libsonnet\";\ntrue\n"))
+	artifacts[ChecksumsFile] = NewArtifact(Checksums(artifacts))
+	return artifacts
+}
+
+// readDir returns the contents of every file under `dir`, keyed by
+// their slash-separated paths relative to it.
+func readDir(t *testing.T, dir string) map[string][]byte {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = text
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Could not read '%s':\n%v", dir, err)
+	}
+	return files
+}
+
+// readTar returns the contents of every file of a gzipped tar, keyed
+// by name, and the names in the order they're archived.
+func readTar(t *testing.T, archive []byte) (map[string][]byte, []string) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Could not decompress archive:\n%v", err)
+	}
+	files, names := map[string][]byte{}, []string{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Could not read archive:\n%v", err)
+		}
+		text, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Could not read '%s' from archive:\n%v", header.Name, err)
+		}
+		files[header.Name] = text
+		names = append(names, header.Name)
+	}
+	return files, names
+}
+
+func TestSinks(t *testing.T) {
+	artifacts := sinkArtifacts(t)
+
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatalf("Could not create temporary directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	// The directory, and the `tests` directory in it, are created.
+	dir = filepath.Join(dir, "lib")
+	if err := WriteArtifacts(NewDirSink(dir), artifacts); err != nil {
+		t.Fatalf("Could not write to directory:\n%v", err)
+	}
+	written := readDir(t, dir)
+	if len(written) != len(artifacts) {
+		t.Errorf("Expected the directory to have only the %d files of the library, got %d", len(artifacts), len(written))
+	}
+
+	var archive bytes.Buffer
+	if err := WriteArtifacts(NewTarSink(&archive), artifacts); err != nil {
+		t.Fatalf("Could not write to tar:\n%v", err)
+	}
+	archived, names := readTar(t, archive.Bytes())
+	if !reflect.DeepEqual(archived, written) {
+		t.Errorf("Expected the tar to have the same files as the directory, byte for byte")
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Expected the files to be archived in order of name, got %v", names)
+	}
+
+	// Archives are the same however often they're written.
+	var again bytes.Buffer
+	if err := WriteArtifacts(NewTarSink(&again), artifacts); err != nil {
+		t.Fatalf("Could not write to tar:\n%v", err)
+	}
+	if !bytes.Equal(archive.Bytes(), again.Bytes()) {
+		t.Errorf("Expected the same tar each time it's written")
+	}
+
+	var zipped bytes.Buffer
+	if err := WriteArtifacts(NewZipSink(&zipped), artifacts); err != nil {
+		t.Fatalf("Could not write to zip:\n%v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatalf("Could not open zip:\n%v", err)
+	}
+	unzipped := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Could not open '%s' in zip:\n%v", f.Name, err)
+		}
+		text, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("Could not read '%s' from zip:\n%v", f.Name, err)
+		}
+		unzipped[f.Name] = text
+	}
+	if !reflect.DeepEqual(unzipped, written) {
+		t.Errorf("Expected the zip to have the same files as the directory, byte for byte")
+	}
+}
@@ -0,0 +1,152 @@
+package ksonnet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// SkipReason is why a definition of the spec isn't emitted. The
+// values are part of the API, so that reports of skipped definitions
+// can be compared over time: they won't change, though reasons may be
+// added.
+type SkipReason string
+
+const (
+	// SkipUnparsable is for definitions whose names don't parse (see
+	// `kubespec.Parser`), e.g., because their prefix isn't one of the
+	// `Prefixes` of `Options.Parser`. They're only skipped if
+	// `Options.Lenient` is set; otherwise they fail generation.
+	SkipUnparsable SkipReason = "unparsable"
+
+	// SkipMalformed is for definitions whose names have a malformed
+	// segment (see `kubespec.MalformedNameError`), e.g., the empty
+	// group of `io.k8s.api..v1.Foo`, as hand-edited specs sometimes do.
+	// Like unparsable definitions, they're only skipped if
+	// `Options.Lenient` is set.
+	SkipMalformed SkipReason = "malformed"
+
+	// SkipUnsupported is for definitions the library has no namespace
+	// for, i.e., those with no API version (e.g.,
+	// `io.k8s.apimachinery.pkg.runtime.RawExtension`).
+	SkipUnsupported SkipReason = "unsupported"
+
+	// SkipFiltered is for definitions removed from the spec before it's
+	// emitted (e.g., by `--include-group`), or by `Options.OnlyVersions`;
+	// see `FilteredDefinitions`.
+	SkipFiltered SkipReason = "filtered"
+
+	// SkipBlacklisted is for definitions that `kubeversion` blacklists
+	// for the spec's version of Kubernetes; see
+	// `kubeversion.IsBlacklistedDefinition`.
+	SkipBlacklisted SkipReason = "blacklisted"
+)
+
+// SkippedDefinition is a definition of the spec that isn't emitted,
+// and why.
+type SkippedDefinition struct {
+	Name   kubespec.DefinitionName `json:"name"`
+	Reason SkipReason              `json:"reason"`
+	Detail string                  `json:"detail"`
+
+	// Source is the spec file the definition is from. It's left empty
+	// here, since an `APISpec` doesn't know its file, for the caller to
+	// fill in.
+	Source string `json:"source"`
+}
+
+// SkippedDefinitions returns the definitions of `spec` that `Emit`
+// skips with `opts`, sorted by name. Properties that refer to an
+// unparsable or blacklisted definition are emitted as though they
+// were untyped.
+func SkippedDefinitions(spec *kubespec.APISpec, opts Options) []*SkippedDefinition {
+	skipped := newRoot(spec, opts).skipped
+	SortSkippedDefinitions(skipped)
+	return skipped
+}
+
+// FilteredDefinitions returns the definitions of `original` that
+// aren't in `filtered` (e.g., the result of `original.Filter`), as
+// skipped for `SkipFiltered`, with `detail`, sorted by name.
+func FilteredDefinitions(
+	original, filtered *kubespec.APISpec, detail string,
+) []*SkippedDefinition {
+	skipped := []*SkippedDefinition{}
+	for name := range original.Definitions {
+		if _, ok := filtered.Definitions[name]; !ok {
+			skipped = append(skipped, &SkippedDefinition{
+				Name:   name,
+				Reason: SkipFiltered,
+				Detail: detail,
+			})
+		}
+	}
+	SortSkippedDefinitions(skipped)
+	return skipped
+}
+
+// SortSkippedDefinitions sorts `skipped` by name, and then by reason,
+// so that reports can be diffed.
+func SortSkippedDefinitions(skipped []*SkippedDefinition) {
+	sort.Slice(skipped, func(i, j int) bool {
+		if skipped[i].Name != skipped[j].Name {
+			return skipped[i].Name < skipped[j].Name
+		}
+		return skipped[i].Reason < skipped[j].Reason
+	})
+}
+
+// `excludeSkipped` returns `spec` without the definitions that are
+// skipped before parsing (see `SkipUnparsable`, `SkipMalformed`, and
+// `SkipBlacklisted`), recording them in `root.skipped`.
+func (root *root) excludeSkipped(spec *kubespec.APISpec) *kubespec.APISpec {
+	k8sVersion := spec.Info.Version
+	return spec.Exclude(func(name kubespec.DefinitionName, _ *kubespec.SchemaDefinition) bool {
+		if _, err := root.parser.ParseName(name); err != nil && root.opts.Lenient {
+			reason := SkipUnparsable
+			if errors.As(err, new(*kubespec.MalformedNameError)) {
+				reason = SkipMalformed
+			}
+			root.skip(name, reason, err.Error())
+		} else if root.kubeVersions.IsBlacklistedDefinition(k8sVersion, name) {
+			root.skip(name, SkipBlacklisted, fmt.Sprintf(
+				"blacklisted for Kubernetes version '%s'", k8sVersion))
+		} else {
+			return false
+		}
+		return true
+	})
+}
+
+// `filterVersions` restricts `spec` to `Options.OnlyVersions`, if
+// any, and the definitions they use, recording the rest in
+// `root.skipped` as `SkipFiltered`.
+func (root *root) filterVersions(spec *kubespec.APISpec) *kubespec.APISpec {
+	if len(root.opts.OnlyVersions) == 0 {
+		return spec
+	}
+	versions := []string{}
+	for _, gv := range root.opts.OnlyVersions {
+		versions = append(versions, gv.String())
+	}
+	detail := fmt.Sprintf("not used by the kinds of %s", strings.Join(versions, ", "))
+
+	filtered := spec.Filter(root.parser.InGroupVersions(root.opts.OnlyVersions))
+	for _, sd := range FilteredDefinitions(spec, filtered, detail) {
+		root.skip(sd.Name, sd.Reason, sd.Detail)
+	}
+	return filtered
+}
+
+func (root *root) skip(name kubespec.DefinitionName, reason SkipReason, detail string) {
+	root.skipped = append(root.skipped, &SkippedDefinition{
+		Name:   name,
+		Reason: reason,
+		Detail: detail,
+	})
+	root.logger().Log(
+		"skip", "definition", name, "reason", reason, "detail", detail)
+}
@@ -0,0 +1,134 @@
+package ksonnet
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func skippedStrings(skipped []*SkippedDefinition) []string {
+	strs := []string{}
+	for _, sd := range skipped {
+		strs = append(strs, string(sd.Name)+" "+string(sd.Reason))
+	}
+	return strs
+}
+
+func TestSkippedDefinitions(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/openshift.json")
+
+	skipped := SkippedDefinitions(spec, Options{Lenient: true})
+	expected := []string{
+		"com.github.openshift.api.apps.v1.DeploymentConfig unparsable",
+		"com.github.openshift.api.apps.v1.DeploymentConfigSpec unparsable",
+		"com.github.openshift.api.route.v1.Route unparsable",
+		"com.github.openshift.api.route.v1.RouteSpec unparsable",
+		"com.github.openshift.api.route.v1.RouteTargetReference unparsable",
+	}
+	if actual := skippedStrings(skipped); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected skipped definitions:\n%v\ngot:\n%v", expected, actual)
+	}
+	for _, sd := range skipped {
+		if !strings.Contains(sd.Detail, string(sd.Name)) {
+			t.Errorf("Expected detail of '%s' to name it, got '%s'", sd.Name, sd.Detail)
+		}
+	}
+
+	// The rest of the spec is still emitted.
+	text, err := Emit(context.Background(), spec, Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	library := string(text)
+	if objectText(library, "deployment") == "" {
+		t.Errorf("Expected library to have 'deployment'")
+	}
+	if strings.Contains(library, "openshift") || strings.Contains(library, "route::") {
+		t.Errorf("Expected library to have no skipped definitions")
+	}
+
+	// Names with malformed segments are told from unparsable ones, and
+	// properties that refer to them accept arbitrary JSON.
+	malformed := loadTestSpec(t, "testdata/malformed.json")
+	skipped = SkippedDefinitions(malformed, Options{Lenient: true})
+	expected = []string{
+		"io.k8s.api..v1.Widget malformed",
+		"io.k8s.api.example.v1. malformed",
+		"io.k8s.api.example.v1.Gadget-Spec malformed",
+	}
+	if actual := skippedStrings(skipped); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected skipped definitions:\n%v\ngot:\n%v", expected, actual)
+	}
+	if detail := skipped[0].Detail; !strings.Contains(detail, "segment 4 ('') is empty") {
+		t.Errorf("Expected detail to name the empty segment, got '%s'", detail)
+	}
+	text, err = Emit(context.Background(), malformed, Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if !strings.Contains(string(text), "widgetMixin(widget)::") {
+		t.Errorf("Expected 'widget' to be emitted as untyped:\n%s", text)
+	}
+
+	unsupported := SkippedDefinitions(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	expected = []string{"io.k8s.apimachinery.pkg.runtime.RawExtension unsupported"}
+	if actual := skippedStrings(unsupported); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected skipped definitions:\n%v\ngot:\n%v", expected, actual)
+	}
+}
+
+func TestFilteredDefinitions(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/openshift.json")
+	filtered := spec.Filter(func(name kubespec.DefinitionName, _ *kubespec.SchemaDefinition) bool {
+		return strings.HasPrefix(string(name), "com.github.openshift.api.route.")
+	})
+
+	// `ObjectMeta` is kept, since routes refer to it.
+	skipped := FilteredDefinitions(spec, filtered, "not a route")
+	expected := []string{
+		"com.github.openshift.api.apps.v1.DeploymentConfig filtered",
+		"com.github.openshift.api.apps.v1.DeploymentConfigSpec filtered",
+		"io.k8s.api.apps.v1.Deployment filtered",
+		"io.k8s.api.apps.v1.DeploymentSpec filtered",
+	}
+	if actual := skippedStrings(skipped); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected skipped definitions:\n%v\ngot:\n%v", expected, actual)
+	}
+
+	// With nothing filtered, the report is an empty array, not `null`.
+	none, err := json.Marshal(FilteredDefinitions(spec, spec, ""))
+	if err != nil {
+		t.Fatalf("Failed to marshal skipped definitions:\n%v", err)
+	}
+	if string(none) != "[]" {
+		t.Errorf("Expected '[]', got '%s'", none)
+	}
+}
+
+func TestSortSkippedDefinitions(t *testing.T) {
+	skipped := []*SkippedDefinition{
+		{Name: "b", Reason: SkipFiltered},
+		{Name: "a", Reason: SkipUnsupported},
+		{Name: "a", Reason: SkipBlacklisted},
+	}
+	SortSkippedDefinitions(skipped)
+	expected := []string{"a blacklisted", "a unsupported", "b filtered"}
+	if actual := skippedStrings(skipped); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected order %v, got %v", expected, actual)
+	}
+
+	// The field names are part of the report's format.
+	text, err := json.Marshal(&SkippedDefinition{
+		Name: "a", Reason: SkipUnparsable, Detail: "d", Source: "swagger.json"})
+	if err != nil {
+		t.Fatalf("Failed to marshal skipped definition:\n%v", err)
+	}
+	const expectedJSON = `{"name":"a","reason":"unparsable","detail":"d","source":"swagger.json"}`
+	if string(text) != expectedJSON {
+		t.Errorf("Expected '%s', got '%s'", expectedJSON, text)
+	}
+}
@@ -0,0 +1,294 @@
+package ksonnet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// EmitTests takes a swagger API specification, and returns a Jsonnet
+// smoke test for each API group of the library `Emit` generates for
+// it, keyed by file name (e.g., `apps.jsonnet`). Each test constructs
+// every top-level API object in its group, passing dummy values for
+// the constructor's required parameters, and asserts the object's
+// `apiVersion` and `kind`, and evaluates the conveniences that have
+// tests of their own (e.g., `ingress.withRulesMixin`). There's also a
+// test of `labels.libsonnet`, `labels.jsonnet`. The tests expect to
+// live in a directory next to `k8s.libsonnet`.
+func EmitTests(
+	spec *kubespec.APISpec, opts Options,
+) (map[string][]byte, error) {
+	root := newRoot(spec, opts)
+
+	tests := map[string][]byte{}
+	for _, group := range root.groups.toSortedSlice() {
+		m := newIndentWriter()
+		group.emitTests(m)
+		text, err := m.bytes()
+		if err != nil {
+			return nil, err
+		}
+		tests[group.path()+".jsonnet"] = text
+	}
+
+	if root.kubeVersions.WellKnownLabels(spec.Info.Version) != nil {
+		m := newIndentWriter()
+		emitLabelsTest(m, root.kubeVersions, spec.Info.Version)
+		text, err := m.bytes()
+		if err != nil {
+			return nil, err
+		}
+		tests["labels.jsonnet"] = text
+	}
+	return tests, nil
+}
+
+func (group *group) emitTests(m *indentWriter) {
+	m.writeLine(fmt.Sprintf(
+		"// AUTOGENERATED smoke tests for the `%s` group of `k8s.libsonnet`. DO NOT MODIFY.",
+		group.path()))
+	m.writeLine(fmt.Sprintf("local k = %s;", group.parent.libraryImport("../"+LibraryFile)))
+	m.writeLine("")
+	m.writeLine("{")
+	m.indent()
+
+	// Emit in sorted order so that we can diff the output.
+	for _, va := range group.versionedAPIs.toSortedSlice() {
+		for _, ao := range va.apiObjects.toSortedSlice() {
+			if ao.isTopLevel {
+				ao.emitTest(m)
+				ao.emitBooleanDefaultTest(m)
+				ao.emitReservedIdentifierTests(m)
+			}
+		}
+
+		// Nested objects (e.g., `IngressRule`) are emitted in the hidden
+		// group, but reachable from this version's top-level objects.
+		objects := va.apiObjects.toSortedSlice()
+		if hidden, ok := group.parent.hiddenGroups[group.name]; ok {
+			if hiddenVA, ok := hidden.versionedAPIs[va.version]; ok {
+				objects = append(objects, hiddenVA.apiObjects.toSortedSlice()...)
+			}
+		}
+		va.emitHelperTests(m, objects)
+	}
+
+	m.dedent()
+	m.writeLine("}")
+}
+
+// `emitTest` emits a test that the API object's constructor produces
+// an object with the right `apiVersion` and `kind`.
+func (ao *apiObject) emitTest(m *indentWriter) {
+	args, _ := ao.dummyArgs()
+	path := ao.path()
+	m.writeLine(fmt.Sprintf("\"%s\": (", path))
+	m.indent()
+	m.writeLine(fmt.Sprintf(
+		"local object = k.%s.%s(%s);",
+		path, constructorName, strings.Join(args, ", ")))
+	m.writeLine(fmt.Sprintf(
+		"std.assertEqual({apiVersion: object.apiVersion, kind: object.kind}, {apiVersion: \"%s\", kind: \"%s\"})",
+		ao.parent.apiVersion(), ao.name))
+	m.dedent()
+	m.writeLine("),")
+}
+
+// `emitBooleanDefaultTest` emits a test that the setter of the first
+// boolean property of the API object's `spec` (e.g.,
+// `pod.mixin.spec.hostNetwork`) sets it to `true` when called with no
+// arguments, and still accepts an explicit `false`.
+func (ao *apiObject) emitBooleanDefaultTest(m *indentWriter) {
+	for _, spec := range ao.properties.sortAndFilterBlacklisted() {
+		if spec.name != "spec" || spec.kind == typeAlias || spec.ref == nil {
+			continue
+		}
+		specObject := ao.root().getAPIObject(ao.root().parseName(*ao.root().refName(spec.ref)))
+		for _, pm := range specObject.properties.sortAndFilterBlacklisted() {
+			if pm.kind == typeAlias || pm.opaque || pm.schemaType == nil ||
+				*pm.schemaType != "boolean" {
+				continue
+			}
+			setter := fmt.Sprintf(
+				"%s.mixin.%s.%s", ao.path(), ao.identifier(spec.name), specObject.identifier(pm.name))
+			specField := jsonnet.RewriteAsFieldKey(spec.name)
+			field := jsonnet.RewriteAsFieldKey(pm.name)
+			m.writeLine(fmt.Sprintf("\"%s\": (", setter))
+			m.indent()
+			m.writeLine(fmt.Sprintf(
+				"std.assertEqual([k.%s(), k.%s(false)], [{%s: {%s: true}}, {%s: {%s: false}}])",
+				setter, setter, specField, field, specField, field))
+			m.dedent()
+			m.writeLine("),")
+			return
+		}
+	}
+}
+
+// jsonnetTypes maps the swagger types of scalars and arrays to what
+// `std.type` returns for their values.
+var jsonnetTypes = map[kubespec.SchemaType]string{
+	"string":  "string",
+	"integer": "number",
+	"number":  "number",
+	"boolean": "boolean",
+	"array":   "array",
+}
+
+// `emitReservedIdentifierTests` emits a test for each property of the
+// API object's `spec` whose setter was renamed because its identifier
+// is reserved (e.g., `mixin.spec.std_` for `std`; see
+// `propertyIdentifiers`), that the setter still works, and that `std`
+// is still the standard library in an object that overrides the
+// field.
+func (ao *apiObject) emitReservedIdentifierTests(m *indentWriter) {
+	for _, spec := range ao.properties.sortAndFilterBlacklisted() {
+		if spec.name != "spec" || spec.kind == typeAlias || spec.ref == nil {
+			continue
+		}
+		specObject := ao.root().getAPIObject(ao.root().parseName(*ao.root().refName(spec.ref)))
+		for _, pm := range specObject.properties.sortAndFilterBlacklisted() {
+			if pm.kind == typeAlias || pm.opaque || pm.intOrString || pm.schemaType == nil {
+				continue
+			}
+			preferred, renamed := specObject.renamedFrom(pm.name)
+			if !renamed || !jsonnet.IsReservedIdentifier(preferred) {
+				continue
+			}
+			jsonnetType, ok := jsonnetTypes[*pm.schemaType]
+			if !ok {
+				continue
+			}
+			setter := fmt.Sprintf(
+				"%s.mixin.%s.%s", ao.path(), ao.identifier(spec.name), specObject.identifier(pm.name))
+			specField := jsonnet.RewriteAsFieldKey(spec.name)
+			field := jsonnet.RewriteAsFieldKey(pm.name)
+			// Keyed apart from `emitBooleanDefaultTest`'s test of the
+			// same setter.
+			m.writeLine(fmt.Sprintf("\"%s (reserved)\": (", setter))
+			m.indent()
+			m.writeLine(fmt.Sprintf(
+				"local object = k.%s(%s) + {%s+: {%s: std.type(super[%q])}};",
+				setter, ao.root().dummyValue(pm.schema(), dummyValueDepth), specField, field, pm.name))
+			m.writeLine(fmt.Sprintf(
+				"std.assertEqual(object, {%s: {%s: \"%s\"}})", specField, field, jsonnetType))
+			m.dedent()
+			m.writeLine("),")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+// Dummy values.
+//-----------------------------------------------------------------------------
+
+// dummyValueDepth caps how deeply `dummyValue` recurses into the
+// required fields of nested objects.
+const dummyValueDepth = 5
+
+// `dummyArgs` returns the arguments that the smoke tests and samples
+// pass to the API object's constructor: a dummy value for each of its
+// required parameters, of the type of the first field it sets. If an
+// argument is a guess, because neither the parameter nor the schema
+// says what it should be, `unsupported` says why.
+func (ao *apiObject) dummyArgs() (args []string, unsupported string) {
+	for _, param := range ao.constructorParams() {
+		if param.defaultValue != "" {
+			continue
+		}
+		if param.dummy != "" {
+			args = append(args, param.dummy)
+			continue
+		} else if len(param.fields) == 0 {
+			args = append(args, `"x"`)
+			unsupported = fmt.Sprintf(
+				"the constructor's '%s' parameter sets no field, so its type is unknown", param.name)
+			continue
+		}
+		field := ao.field(strings.Split(param.fields[0], "."))
+		if field == nil {
+			args = append(args, `"x"`)
+			unsupported = fmt.Sprintf(
+				"the constructor's '%s' parameter sets '%s', which the schema doesn't have",
+				param.name, param.fields[0])
+			continue
+		}
+		args = append(args, ao.root().dummyValue(field.schema(), dummyValueDepth))
+	}
+	return args, unsupported
+}
+
+// `schema` returns the swagger schema of the property.
+func (p *property) schema() *kubespec.Property {
+	return p.root().spec.Definitions[p.path].Properties[p.name]
+}
+
+// `dummyValue` returns a Jsonnet expression for an arbitrary value of
+// the type described by `prop`: strings are `"x"`, numbers are `1`,
+// arrays have one element, maps have one key, and objects have their
+// required fields set, recursively, up to `depth` levels deep.
+func (root *root) dummyValue(prop *kubespec.Property, depth int) string {
+	if prop.IsUntyped() {
+		return "{}"
+	} else if prop.Ref != nil {
+		return root.dummyObject(*root.refName(prop.Ref), depth)
+	} else if prop.IsIntOrString() {
+		return "1"
+	}
+
+	switch *prop.Type {
+	case "string":
+		return `"x"`
+	case "integer", "number":
+		return "1"
+	case "boolean":
+		return "true"
+	case "array":
+		if prop.Items.Ref != nil {
+			return fmt.Sprintf("[%s]", root.dummyObject(*root.refName(prop.Items.Ref), depth))
+		} else if prop.Items.Type != nil {
+			item := &kubespec.Property{Type: prop.Items.Type, Format: prop.Items.Format}
+			return fmt.Sprintf("[%s]", root.dummyValue(item, depth))
+		}
+		return "[{}]"
+	case "object":
+		if ap := prop.AdditionalProperties; ap != nil && ap.Schema != nil {
+			return fmt.Sprintf("{x: %s}", root.dummyValue(ap.Schema, depth))
+		}
+	}
+	return "{}"
+}
+
+// `dummyObject` returns a Jsonnet expression for an arbitrary value of
+// the definition named `name`. For object definitions, that's an
+// object with only the required fields set.
+func (root *root) dummyObject(name kubespec.DefinitionName, depth int) string {
+	def, ok := root.spec.Definitions[name]
+	if !ok || def.IsUntyped() {
+		return "{}"
+	} else if def.Type != nil && *def.Type != "object" {
+		// E.g., `Quantity`, which is a string.
+		return root.dummyValue(&kubespec.Property{Type: def.Type}, depth)
+	} else if depth == 0 {
+		return "{}"
+	}
+
+	required := append([]string{}, def.Required...)
+	sort.Strings(required)
+
+	fields := []string{}
+	for _, propName := range required {
+		prop, ok := def.Properties[kubespec.PropertyName(propName)]
+		if !ok {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf(
+			"%s: %s",
+			jsonnet.RewriteAsFieldKey(kubespec.PropertyName(propName)),
+			root.dummyValue(prop, depth-1)))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
@@ -0,0 +1,175 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmitTests(t *testing.T) {
+	tests, err := EmitTests(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+
+	for _, name := range []string{"apps.jsonnet", "batch.jsonnet", "core.jsonnet"} {
+		if _, ok := tests[name]; !ok {
+			t.Errorf("Expected test file '%s'", name)
+		}
+	}
+	if _, ok := tests["hidden.core.jsonnet"]; ok {
+		t.Errorf("Expected no tests for hidden groups")
+	}
+
+	batch := string(tests["batch.jsonnet"])
+	for _, expected := range []string{
+		`local k = import "../k8s.libsonnet";`,
+		`local object = k.batch.v2alpha1.cronJob.new("x", "x", [{name: "x"}]);`,
+		`std.assertEqual({apiVersion: object.apiVersion, kind: object.kind}, {apiVersion: "batch/v1", kind: "Job"})`,
+	} {
+		if !strings.Contains(batch, expected) {
+			t.Errorf("Expected 'batch.jsonnet' to contain:\n%s\ngot:\n%s", expected, batch)
+		}
+	}
+
+	// Optional parameters are left out.
+	apps := string(tests["apps.jsonnet"])
+	if !strings.Contains(apps, "local object = k.apps.v1beta1.deployment.new();") {
+		t.Errorf("Expected 'Deployment' to be constructed without arguments, got:\n%s", apps)
+	}
+
+	tests, err = EmitTests(loadTestSpec(t, "testdata/workloads.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	apps = string(tests["apps.jsonnet"])
+	if !strings.Contains(apps, `local object = k.apps.v1.deployment.new({x: "x"});`) {
+		t.Errorf("Expected 'Deployment' to be constructed with dummy labels, got:\n%s", apps)
+	}
+
+	// Boolean setters are evaluated without arguments, and with `false`.
+	batch = string(tests["batch.jsonnet"])
+	if !strings.Contains(batch, `std.assertEqual([k.batch.v1.job.mixin.spec.manualSelector(), k.batch.v1.job.mixin.spec.manualSelector(false)], [{spec: {manualSelector: true}}, {spec: {manualSelector: false}}])`) {
+		t.Errorf("Expected a test of the default of a boolean setter, got:\n%s", batch)
+	}
+
+	// Setters renamed from reserved identifiers are evaluated, overriding
+	// their field with an expression that uses `std`.
+	tests, err = EmitTests(loadTestSpec(t, "testdata/reserved.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	stable := string(tests["stable.jsonnet"])
+	for _, expected := range []string{
+		`"stable.v1.widget.mixin.spec.std_2 (reserved)": (`,
+		`local object = k.stable.v1.widget.mixin.spec.std_2(["x"]) + {spec+: {std: std.type(super["std"])}};`,
+		`std.assertEqual(object, {spec: {std: "array"}})`,
+		`local object = k.stable.v1.widget.mixin.spec.self_("x") + {spec+: {"self": std.type(super["self"])}};`,
+		`"stable.v1.widget.mixin.spec.local_ (reserved)": (`,
+	} {
+		if !strings.Contains(stable, expected) {
+			t.Errorf("Expected 'stable.jsonnet' to contain:\n%s\ngot:\n%s", expected, stable)
+		}
+	}
+	if strings.Contains(stable, "spec.std_ (reserved)") {
+		t.Errorf("Expected no reserved identifier test of a property named 'std_', got:\n%s", stable)
+	}
+
+	// Conveniences are evaluated against the shape of each version,
+	// including those of nested objects.
+	tests, err = EmitTests(loadTestSpec(t, "testdata/ingress.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	for name, expected := range map[string][]string{
+		"core.jsonnet": {
+			`std.assertEqual(v.service.withType("NodePort"), {spec: {type: "NodePort"}})`,
+		},
+		"extensions.jsonnet": {
+			`"extensions.v1beta1.ingress.withRulesMixin": (`,
+			`std.assertEqual(v.ingress.mixin.spec.rulesType.new("example.com", "web", 80), {host: "example.com", http: {paths: [{backend: {serviceName: "web", servicePort: 80}, path: "/"}]}})`,
+		},
+		"networking.jsonnet": {
+			`local v = k.networking.v1;`,
+			`{backend: {service: {name: "web", port: {number: 80}}}, path: "/", pathType: "Prefix"}`,
+			`v.ingress.mixin.spec.tlsType.new("example.com", "example-tls")`,
+		},
+	} {
+		for _, line := range expected {
+			if !strings.Contains(string(tests[name]), line) {
+				t.Errorf("Expected '%s' to contain:\n%s\ngot:\n%s", name, line, tests[name])
+			}
+		}
+	}
+
+	// Volume claim templates are evaluated without their `apiVersion`
+	// and `kind`.
+	tests, err = EmitTests(loadTestSpec(t, "testdata/storage.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit tests:\n%v", err)
+	}
+	for name, expected := range map[string][]string{
+		"apps.jsonnet": {
+			`"apps.v1.statefulSet.withVolumeClaimTemplatesMixin.fields": (`,
+			`.spec.volumeClaimTemplates], [["metadata", "spec"]])`,
+		},
+		"core.jsonnet": {
+			`local object = k.core.v1.persistentVolumeClaim.new("x", "1Gi");`,
+			`std.assertEqual(v.persistentVolumeClaim.new("data", "10Gi", "ReadOnlyMany").spec, {accessModes: ["ReadOnlyMany"], resources: {requests: {storage: "10Gi"}}})`,
+		},
+	} {
+		for _, line := range expected {
+			if !strings.Contains(string(tests[name]), line) {
+				t.Errorf("Expected '%s' to contain:\n%s\ngot:\n%s", name, line, tests[name])
+			}
+		}
+	}
+}
+
+func schemaType(t string) *kubespec.SchemaType {
+	st := kubespec.SchemaType(t)
+	return &st
+}
+
+func TestDummyValue(t *testing.T) {
+	root := newRoot(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	podSpec := kubespec.DefinitionName("io.k8s.kubernetes.pkg.api.v1.PodSpec")
+
+	tests := []struct {
+		prop     *kubespec.Property
+		depth    int
+		expected string
+	}{
+		{&kubespec.Property{Type: schemaType("string")}, 1, `"x"`},
+		{&kubespec.Property{Type: schemaType("integer")}, 1, "1"},
+		{&kubespec.Property{Type: schemaType("boolean")}, 1, "true"},
+		{&kubespec.Property{}, 1, "{}"},
+		{
+			&kubespec.Property{
+				Type:  schemaType("array"),
+				Items: kubespec.Items{Type: schemaType("string")},
+			},
+			1, `["x"]`,
+		},
+		{
+			&kubespec.Property{
+				Type: schemaType("object"),
+				AdditionalProperties: &kubespec.AdditionalProperties{
+					Allowed: true,
+					Schema:  &kubespec.Property{Type: schemaType("integer")},
+				},
+			},
+			1, "{x: 1}",
+		},
+		{&kubespec.Property{Ref: podSpec.AsObjectRef()}, 5, `{containers: [{name: "x"}]}`},
+		// Recursion into required fields stops at the depth cap.
+		{&kubespec.Property{Ref: podSpec.AsObjectRef()}, 1, "{containers: [{}]}"},
+		{&kubespec.Property{Ref: podSpec.AsObjectRef()}, 0, "{}"},
+	}
+	for _, test := range tests {
+		if actual := root.dummyValue(test.prop, test.depth); actual != test.expected {
+			t.Errorf("Expected dummy value '%s' got '%s' for %#v", test.expected, actual, test.prop)
+		}
+	}
+}
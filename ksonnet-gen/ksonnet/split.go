@@ -0,0 +1,351 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// HiddenFile is the file of a library split by group (see
+// `Options.SplitByGroup`) that has the hidden objects, which the type
+// aliases of every group point at.
+const HiddenFile = "hidden.libsonnet"
+
+// FileNaming decides how the files of a library split by group are
+// named after their groups; see `Options.SplitByGroup`.
+type FileNaming string
+
+const (
+	// FileNamingDashes names each group's file after the API group of
+	// its kinds, as it's named in their apiVersion (e.g.,
+	// `rbac.authorization.k8s.io`, or `core`), lowercased, with each
+	// run of characters other than letters and digits replaced by a
+	// dash, e.g., `rbac-authorization-k8s-io.libsonnet`. Groups without
+	// kinds are named after their namespace. The zero value of
+	// `FileNaming` is the same.
+	FileNamingDashes FileNaming = "dashes"
+
+	// FileNamingUnderscores is `FileNamingDashes` with underscores,
+	// e.g., `rbac_authorization_k8s_io.libsonnet`.
+	FileNamingUnderscores FileNaming = "underscores"
+
+	// FileNamingNamespace names each group's file after its namespace
+	// in the library, as it is, e.g., `rbac.libsonnet`, or
+	// `routeOpenshiftIo.libsonnet`.
+	FileNamingNamespace FileNaming = "namespace"
+)
+
+// FileNamings lists the valid file naming strategies.
+var FileNamings = []FileNaming{
+	FileNamingDashes, FileNamingUnderscores, FileNamingNamespace,
+}
+
+// ParseFileNaming returns the file naming strategy called `name`.
+func ParseFileNaming(name string) (FileNaming, error) {
+	names := []string{}
+	for _, fn := range FileNamings {
+		if string(fn) == name {
+			return fn, nil
+		}
+		names = append(names, string(fn))
+	}
+	return "", fmt.Errorf(
+		"Unknown file naming strategy '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// `separator` is what replaces the characters of API group names that
+// aren't letters or digits, and what precedes the number that
+// disambiguates colliding names.
+func (fn FileNaming) separator() string {
+	if fn == FileNamingUnderscores {
+		return "_"
+	}
+	return "-"
+}
+
+// `baseName` returns the name of a group's file, before collisions are
+// resolved and without its extension.
+func (fn FileNaming) baseName(group *group) string {
+	if fn == FileNamingNamespace {
+		return group.path()
+	}
+
+	var name strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(group.apiGroupName()) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			pending = true
+			continue
+		}
+		if pending && name.Len() > 0 {
+			name.WriteString(fn.separator())
+		}
+		pending = false
+		name.WriteRune(r)
+	}
+	if name.Len() == 0 {
+		return group.path()
+	}
+	return name.String()
+}
+
+// `apiGroupName` returns the API group of a group's kinds, as it's
+// named in their apiVersion (e.g., `rbac.authorization.k8s.io`, or
+// `core`), or, if the group has no kinds, or kinds of more than one API
+// group, its name.
+func (group *group) apiGroupName() string {
+	names := map[kubespec.GroupName]bool{}
+	for _, va := range group.versionedAPIs {
+		for _, ao := range va.apiObjects {
+			for _, gvk := range ao.gvks {
+				names[gvk.Group] = true
+			}
+		}
+	}
+	if len(names) == 1 {
+		for name := range names {
+			if name == "" {
+				return "core"
+			}
+			return string(name)
+		}
+	}
+	return string(group.name)
+}
+
+// `groupFiles` names the file of each group of a library split by
+// group, keyed by the group's path, and, with `Options.SplitHidden`,
+// that of each hidden group after it, which is named after its path
+// (e.g., `hidden.core`); see `Options.FileNaming`. Names are
+// given in order of path, and compared case-insensitively, since some
+// filesystems (e.g., macOS's, by default) can't tell `Events` from
+// `events`: a group whose name is taken, by an earlier group or by one
+// of the library's other files, gets the first free name with `-2`,
+// `-3`, and so on appended. Each collision is logged.
+func (root *root) groupFiles() map[string]string {
+	taken := map[string]string{}
+	for _, name := range []string{LibraryFile, AliasesFile, LabelsFile, HiddenFile, GVKIndexFile} {
+		taken[strings.ToLower(name)] = name
+	}
+
+	groups := root.groups.toSortedSlice()
+	if root.opts.SplitHidden {
+		groups = append(groups, root.hiddenGroups.toSortedSlice()...)
+	}
+	files := map[string]string{}
+	for _, group := range groups {
+		base := root.opts.FileNaming.baseName(group)
+		if group.hidden {
+			base = group.path()
+		}
+		name := base + ".libsonnet"
+		for n := 2; taken[strings.ToLower(name)] != ""; n++ {
+			name = fmt.Sprintf("%s%s%d.libsonnet", base, root.opts.FileNaming.separator(), n)
+		}
+		if name != base+".libsonnet" {
+			root.logger().Log(
+				"file name collision", "group", group.path(), "file", name,
+				"collides", taken[strings.ToLower(base+".libsonnet")])
+		}
+		taken[strings.ToLower(name)] = group.path()
+		files[group.path()] = name
+	}
+	return files
+}
+
+// EmitSplit takes a swagger API specification, and returns the files of
+// the library `Emit` generates, split by group, keyed by file name:
+// `k8s.libsonnet`, which imports each group's file as the group's
+// namespace (see `Options.FileNaming`), the file of each group, and
+// `hidden.libsonnet`, with the hidden objects the groups' type aliases
+// point at, or, with `Options.SplitHidden`, imports the file of each
+// hidden group. Importing `k8s.libsonnet` gives the same library as
+// `Emit`'s. If `ctx` is cancelled, it stops before the next group.
+func EmitSplit(
+	ctx context.Context, spec *kubespec.APISpec, opts Options,
+) (map[string][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("Could not emit library: %w", err)
+	}
+	root := newRoot(spec, opts)
+	start := time.Now()
+	files, err := root.emitSplit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := root.customizationErrors(); err != nil {
+		return nil, err
+	}
+	bytes := 0
+	for _, text := range files {
+		bytes += len(text)
+	}
+	root.reportOutput(bytes, start)
+	return files, nil
+}
+
+func (root *root) emitSplit(ctx context.Context) (map[string][]byte, error) {
+	names := root.groupFiles()
+	files := map[string][]byte{}
+	write := func(name string, m *indentWriter) error {
+		text, err := m.bytes()
+		if err != nil {
+			return err
+		}
+		files[name] = text
+		return nil
+	}
+
+	index := root.newLibraryWriter(newIndentWriter())
+	root.emitHeader(index)
+	index.writeLine("{")
+	index.indent()
+	root.openPrefix(index, "::")
+
+	done, total := 0, len(root.groups)+len(root.hiddenGroups)
+	cancelled := func() error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf(
+				"Could not emit library, cancelled after %d of %d groups: %w",
+				done, total, err)
+		}
+		return nil
+	}
+	for _, group := range root.groups.toSortedSlice() {
+		if err := cancelled(); err != nil {
+			return nil, err
+		}
+		name := names[group.path()]
+		index.writeLine(fmt.Sprintf("%s:: import %q,", group.path(), root.importPath(name)))
+
+		m := root.newLibraryWriter(newIndentWriter())
+		root.emitFileHeader(m, name, fmt.Sprintf(
+			"The `%s` namespace of `%s`, which imports this file.", group.path(), LibraryFile))
+		m.writeLine(fmt.Sprintf("local %s = import %q;", hiddenNamespace, root.importPath(HiddenFile)))
+		root.emitExternalImports(m)
+		m.writeLine("")
+		m.writeLine("{")
+		group.emitVersions(m)
+		root.closeObject(m, group.path(), "}")
+		if err := write(name, m); err != nil {
+			return nil, err
+		}
+		done++
+		root.progress("emit", done, total)
+	}
+
+	if root.opts.EmitPresets {
+		root.emitPresets(index, true)
+	}
+	root.emitUtil(index)
+	if root.opts.EmbedRawSchemas {
+		if err := root.emitRawSchemas(index); err != nil {
+			return nil, err
+		}
+	}
+	root.closePrefix(index)
+	index.dedent()
+	index.writeLine("}")
+	if err := write(LibraryFile, index); err != nil {
+		return nil, err
+	}
+
+	if root.opts.SplitHidden {
+		if err := root.emitSplitHidden(names, write, cancelled, &done, total); err != nil {
+			return nil, err
+		}
+		return files, nil
+	}
+
+	m := root.newLibraryWriter(newIndentWriter())
+	root.emitFileHeader(m, HiddenFile, fmt.Sprintf(
+		"The hidden objects of `%s`, which the type aliases of its namespaces point at.", LibraryFile))
+	if root.emitExternalImports(m) {
+		m.writeLine("")
+	}
+	m.writeLine(fmt.Sprintf("local %s = {", hiddenNamespace))
+	m.indent()
+	for _, hiddenGroup := range root.hiddenGroups.toSortedSlice() {
+		if err := cancelled(); err != nil {
+			return nil, err
+		}
+		hiddenGroup.emit(m)
+		done++
+		root.progress("emit", done, total)
+	}
+	m.dedent()
+	m.writeLine("};")
+	m.writeLine("")
+	m.writeLine(hiddenNamespace)
+	if err := write(HiddenFile, m); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// `emitSplitHidden` writes `hidden.libsonnet` as an object that imports
+// the file of each hidden group (see `Options.SplitHidden`), which it
+// writes too, with `write`. Each file binds `hidden` to
+// `hidden.libsonnet`, as the groups' files do, for the type aliases of
+// its objects; the imports are cyclic, but since neither file's value
+// needs the other's fields, Jsonnet only follows them on access.
+func (root *root) emitSplitHidden(
+	names map[string]string, write func(string, *indentWriter) error,
+	cancelled func() error, done *int, total int,
+) error {
+	index := root.newLibraryWriter(newIndentWriter())
+	root.emitFileHeader(index, HiddenFile, fmt.Sprintf(
+		"The hidden objects of `%s`, which the type aliases of its namespaces point at, a file per group.", LibraryFile))
+	index.writeLine("{")
+	index.indent()
+	for _, hiddenGroup := range root.hiddenGroups.toSortedSlice() {
+		if err := cancelled(); err != nil {
+			return err
+		}
+		name := names[hiddenGroup.path()]
+		index.writeLine(fmt.Sprintf(
+			"%s:: import %q,", strings.TrimPrefix(hiddenGroup.path(), hiddenNamespace+"."), root.importPath(name)))
+
+		m := root.newLibraryWriter(newIndentWriter())
+		root.emitFileHeader(m, name, fmt.Sprintf(
+			"The `%s` namespace of `%s`, which imports this file.", hiddenGroup.path(), HiddenFile))
+		m.writeLine(fmt.Sprintf("local %s = import %q;", hiddenNamespace, root.importPath(HiddenFile)))
+		root.emitExternalImports(m)
+		m.writeLine("")
+		m.writeLine("{")
+		hiddenGroup.emitVersions(m)
+		root.closeObject(m, hiddenGroup.path(), "}")
+		if err := write(name, m); err != nil {
+			return err
+		}
+		*done++
+		root.progress("emit", *done, total)
+	}
+	index.dedent()
+	index.writeLine("}")
+	return write(HiddenFile, index)
+}
+
+// `emitFileHeader` emits the comments that start `file`, one of the
+// files of a library split by group other than `k8s.libsonnet`, ending
+// with `description`, or what `Options.HeaderTemplate` makes of them.
+func (root *root) emitFileHeader(m *indentWriter, file, description string) {
+	emitTemplatedHeader(m, root.spec, root.opts, file, func(m *indentWriter) {
+		m.writeLine("// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.")
+		m.writeLine(fmt.Sprintf("// Kubernetes version: %s", root.spec.Info.Version))
+		m.writeLine("// " + description)
+	})
+	m.writeLine("")
+}
+
+// `fileIndex` maps the path of each group, and `hidden`, to its file in
+// a library split by group, for the symbol index.
+func (root *root) fileIndex() map[string]string {
+	files := root.groupFiles()
+	files[hiddenNamespace] = HiddenFile
+	return files
+}
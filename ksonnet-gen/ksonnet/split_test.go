@@ -0,0 +1,297 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func emitSplitTestSpec(t *testing.T, path string, opts Options) map[string]string {
+	files, err := EmitSplit(context.Background(), loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to emit split library:\n%v", err)
+	}
+	texts := map[string]string{}
+	for name, text := range files {
+		texts[name] = string(text)
+	}
+	return texts
+}
+
+func sortedFileNames(files map[string]string) []string {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestEmitSplit(t *testing.T) {
+	files := emitSplitTestSpec(t, "testdata/collisions.json", Options{SplitByGroup: true})
+
+	// Dotted API groups are named after their apiVersion, with dashes.
+	expectedNames := []string{
+		"apps.libsonnet",
+		"core.libsonnet",
+		"events-k8s-io.libsonnet",
+		"extensions.libsonnet",
+		"hidden.libsonnet",
+		"k8s.libsonnet",
+		"networking-k8s-io.libsonnet",
+	}
+	if names := sortedFileNames(files); !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("Expected files %v, got %v", expectedNames, names)
+	}
+
+	index := files[LibraryFile]
+	for _, line := range []string{
+		`apps:: import "apps.libsonnet",`,
+		`events:: import "events-k8s-io.libsonnet",`,
+		`networking:: import "networking-k8s-io.libsonnet",`,
+	} {
+		if !strings.Contains(index, "\n  "+line+"\n") {
+			t.Errorf("Expected '%s' to import:\n%s\ngot:\n%s", LibraryFile, line, index)
+		}
+	}
+
+	// Each group's file has the body of its namespace in the library
+	// `Emit` generates.
+	library := emitTestSpec(t, "testdata/collisions.json", Options{})
+	group := files["networking-k8s-io.libsonnet"]
+	if !strings.Contains(group, "local hidden = import \"hidden.libsonnet\";\n\n{\n") {
+		t.Errorf("Expected group file to import '%s':\n%s", HiddenFile, group)
+	}
+	body := group[strings.Index(group, "\n{\n")+3 : strings.LastIndex(group, "}")]
+	start := strings.Index(library, "\n  networking:: {\n") + len("\n  networking:: {\n")
+	end := strings.Index(library[start:], "\n  },\n") + 1
+	namespace := ""
+	for _, line := range strings.SplitAfter(library[start:start+end], "\n") {
+		namespace += strings.TrimPrefix(line, "  ")
+	}
+	if body != namespace {
+		t.Errorf("Expected group file body:\n%s\ngot:\n%s", namespace, body)
+	}
+
+	hidden := files[HiddenFile]
+	if !strings.Contains(hidden, "\nlocal hidden = {\n  meta:: {\n") ||
+		!strings.HasSuffix(hidden, "};\n\nhidden\n") {
+		t.Errorf("Expected '%s' to define the hidden objects:\n%s", HiddenFile, hidden)
+	}
+}
+
+func TestFileNamings(t *testing.T) {
+	cases := []struct {
+		naming   FileNaming
+		expected string
+	}{
+		{"", "networking-k8s-io.libsonnet"},
+		{FileNamingDashes, "networking-k8s-io.libsonnet"},
+		{FileNamingUnderscores, "networking_k8s_io.libsonnet"},
+		{FileNamingNamespace, "networking.libsonnet"},
+	}
+	for _, c := range cases {
+		opts := Options{SplitByGroup: true, FileNaming: c.naming}
+		files := emitSplitTestSpec(t, "testdata/collisions.json", opts)
+		if _, ok := files[c.expected]; !ok {
+			t.Errorf("Expected '%s' to give '%s', got %v", c.naming, c.expected, sortedFileNames(files))
+		}
+		if line := `networking:: import "` + c.expected + `",`; !strings.Contains(files[LibraryFile], line) {
+			t.Errorf("Expected '%s' to import:\n%s", LibraryFile, line)
+		}
+	}
+
+	if _, err := ParseFileNaming("flat"); err == nil {
+		t.Errorf("Expected unknown file naming strategy to fail")
+	}
+}
+
+func TestFileNameCollisions(t *testing.T) {
+	logger := &recordingLogger{events: map[string][]map[string]interface{}{}}
+	opts := Options{
+		SplitByGroup: true,
+		FileNaming:   FileNamingNamespace,
+		Namespaces: map[string]string{
+			"api/core":       "labels",
+			"api/events":     "eventsK8s",
+			"api/networking": "eventsk8s",
+		},
+		Logger: logger,
+	}
+	files := emitSplitTestSpec(t, "testdata/collisions.json", opts)
+
+	// Names that differ only in case collide, as do names of the
+	// library's other files; the later path gets a number.
+	for _, line := range []string{
+		`eventsK8s:: import "eventsK8s.libsonnet",`,
+		`eventsk8s:: import "eventsk8s-2.libsonnet",`,
+		`labels:: import "labels-2.libsonnet",`,
+	} {
+		if !strings.Contains(files[LibraryFile], line) {
+			t.Errorf("Expected '%s' to import:\n%s\ngot:\n%s", LibraryFile, line, files[LibraryFile])
+		}
+	}
+	for _, name := range []string{"eventsK8s.libsonnet", "eventsk8s-2.libsonnet", "labels-2.libsonnet"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected file '%s', got %v", name, sortedFileNames(files))
+		}
+	}
+
+	expected := []map[string]interface{}{
+		{"group": "eventsk8s", "file": "eventsk8s-2.libsonnet", "collides": "eventsK8s"},
+		{"group": "labels", "file": "labels-2.libsonnet", "collides": LabelsFile},
+	}
+	if events := logger.events["file name collision"]; !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected collisions %v, got %v", expected, events)
+	}
+
+	// The symbol index has the same names.
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/collisions.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for path, name := range map[string]string{
+		"eventsK8s": "eventsK8s.libsonnet",
+		"eventsk8s": "eventsk8s-2.libsonnet",
+		"labels":    "labels-2.libsonnet",
+		"hidden":    HiddenFile,
+	} {
+		if index.Files[path] != name {
+			t.Errorf("Expected symbol index to map '%s' to '%s', got '%s'", path, name, index.Files[path])
+		}
+	}
+}
+
+func TestEmitArtifactsSplit(t *testing.T) {
+	artifacts, err := EmitArtifacts(
+		context.Background(), loadTestSpec(t, "testdata/collisions.json"), Options{SplitByGroup: true})
+	if err != nil {
+		t.Fatalf("Failed to emit artifacts:\n%v", err)
+	}
+	for _, name := range []string{LibraryFile, HiddenFile, "apps.libsonnet", AliasesFile, LabelsFile} {
+		if _, ok := artifacts[name]; !ok {
+			t.Errorf("Expected artifact '%s'", name)
+		}
+	}
+}
+
+func TestSplitHidden(t *testing.T) {
+	opts := Options{SplitByGroup: true, SplitHidden: true}
+	files := emitSplitTestSpec(t, "testdata/gvkindex.json", opts)
+
+	// Each hidden group is a file of its own, named after its path,
+	// which `hidden.libsonnet` imports.
+	for _, name := range []string{"hidden.apps.libsonnet", "hidden.core.libsonnet", "hidden.meta.libsonnet"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected file '%s', got %v", name, sortedFileNames(files))
+		}
+	}
+	index := files[HiddenFile]
+	if !strings.HasSuffix(index, "\n{\n"+
+		"  apps:: import \"hidden.apps.libsonnet\",\n"+
+		"  core:: import \"hidden.core.libsonnet\",\n"+
+		"  meta:: import \"hidden.meta.libsonnet\",\n}\n") {
+		t.Errorf("Expected '%s' to import the hidden groups, got:\n%s", HiddenFile, index)
+	}
+
+	// Each file has the body of its group's namespace in the hidden
+	// file that isn't split.
+	hidden := emitSplitTestSpec(t, "testdata/gvkindex.json", Options{SplitByGroup: true})[HiddenFile]
+	group := files["hidden.meta.libsonnet"]
+	if !strings.Contains(group, "local hidden = import \"hidden.libsonnet\";\n\n{\n") {
+		t.Errorf("Expected hidden group file to import '%s':\n%s", HiddenFile, group)
+	}
+	body := group[strings.Index(group, "\n{\n")+3 : strings.LastIndex(group, "}")]
+	start := strings.Index(hidden, "\n  meta:: {\n") + len("\n  meta:: {\n")
+	end := strings.Index(hidden[start:], "\n  },\n") + 1
+	namespace := ""
+	for _, line := range strings.SplitAfter(hidden[start:start+end], "\n") {
+		namespace += strings.TrimPrefix(line, "  ")
+	}
+	if body != namespace {
+		t.Errorf("Expected hidden group file body:\n%s\ngot:\n%s", namespace, body)
+	}
+
+	// The symbol index maps the hidden groups to their files, so that
+	// each hidden path is in the file of the group it starts with.
+	symbols, err := BuildSymbolIndex(loadTestSpec(t, "testdata/gvkindex.json"), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	for _, symbol := range symbols.Symbols {
+		segments := strings.SplitN(symbol.Path, ".", 3)
+		if segments[0] != hiddenNamespace || len(segments) < 2 {
+			continue
+		}
+		group := segments[0] + "." + segments[1]
+		if file := symbols.Files[group]; files[file] == "" {
+			t.Errorf("Expected '%s' to be in a file of the library, got '%s'", symbol.Path, file)
+		}
+	}
+	if file := symbols.Files[hiddenNamespace]; file != HiddenFile {
+		t.Errorf("Expected '%s' to be in '%s', got '%s'", hiddenNamespace, HiddenFile, file)
+	}
+
+	// The imports of the library resolve.
+	if _, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/gvkindex.json"), opts); err != nil {
+		t.Errorf("Failed to emit artifacts:\n%v", err)
+	}
+}
+
+func TestSplitHiddenEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// The program uses the hidden objects of `apps` and `meta`, through
+	// type aliases, but not those of `core`.
+	const program = `local k = import 'k.libsonnet';
+local deployment = k.apps.v1.deployment;
+deployment.new() + deployment.mixin.metadata.name('web') + deployment.mixin.spec.replicas(2) +
+{spec+: deployment.mixin.specType.paused()} +
+{fields: std.objectFieldsAll(deployment.mixin.metadataType)}
+`
+	outputs := map[bool]string{}
+	for _, splitHidden := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "splithidden")
+		if err != nil {
+			t.Fatalf("Could not create directory:\n%v", err)
+		}
+		defer os.RemoveAll(dir)
+		opts := Options{SplitByGroup: true, SplitHidden: splitHidden}
+		artifacts, err := EmitArtifacts(context.Background(), loadTestSpec(t, "testdata/gvkindex.json"), opts)
+		if err != nil {
+			t.Fatalf("Failed to emit artifacts:\n%v", err)
+		}
+		for name, artifact := range artifacts {
+			// Jsonnet only loads the files of the hidden groups the
+			// program uses, so it evaluates without the others.
+			if name == "hidden.core.libsonnet" {
+				continue
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, name), artifact.Text, 0644); err != nil {
+				t.Fatalf("Could not write '%s':\n%v", name, err)
+			}
+		}
+		main := filepath.Join(dir, "main.jsonnet")
+		if err := ioutil.WriteFile(main, []byte(program), 0644); err != nil {
+			t.Fatalf("Could not write program:\n%v", err)
+		}
+		out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+		if err != nil {
+			t.Fatalf("Expected the library (split hidden: %v) to evaluate, got %v:\n%s", splitHidden, err, out)
+		}
+		outputs[splitHidden] = string(out)
+	}
+	if outputs[true] != outputs[false] {
+		t.Errorf("Expected the same object with the hidden objects split, got:\n%s\nrather than:\n%s",
+			outputs[true], outputs[false])
+	}
+}
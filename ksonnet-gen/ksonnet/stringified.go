@@ -0,0 +1,52 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// toStringName is the function of `util` that
+// `Options.EmitStringifiedSetters` adds, which the `Stringified`
+// setters convert the values of their maps with.
+const toStringName = "toString"
+
+// toStringBody is the body of `util.toString(value)`: strings are
+// returned unchanged, booleans are `"true"` or `"false"`, numbers are
+// written as `std.toString` writes them, and objects and arrays as
+// indented JSON. `null` fails, since it's more likely a mistake than a
+// value that's meant to be the string `"null"`.
+const toStringBody = `local type = std.type(value); if type == "string" then value else if type == "boolean" then (if value then "true" else "false") else if type == "number" then std.toString(value) else if type == "object" || type == "array" then std.manifestJsonEx(value, "  ") else if type == "null" then error "'toString' can't convert null to a string; leave the key out instead" else error "'toString' can't convert a " + type + " to a string"`
+
+// `emitStringified` emits the `Stringified` variant of the setter
+// `functionName` of a map of strings (see `property.stringified`),
+// which merges `paramName` into the field as the setter does, but with
+// each of its values converted by `util.toString`, e.g.,
+// `configMap.dataStringified({replicas: 3})` sets `data.replicas` to
+// `"3"`.
+func (p *property) emitStringified(
+	m *indentWriter, functionName jsonnet.Identifier, paramName jsonnet.FuncParam,
+	parentMixinName *string, path string,
+) {
+	name := fmt.Sprintf("%sStringified", functionName)
+	if pm, ok := p.parent.properties[kubespec.PropertyName(name)]; ok {
+		log.Panicf(
+			"Attempted to create helper '%s', but the property already existed at '%s'",
+			name, pm.path)
+	}
+	body := p.fieldObject(true, fmt.Sprintf(
+		"std.mapWithKey(function(key, value) %s.%s.%s(value), %s)",
+		p.root().rootRef(), utilName, toStringName, paramName))
+	if parentMixinName != nil {
+		body = fmt.Sprintf("%s(%s)", *parentMixinName, body)
+	}
+	m.writeLine(fmt.Sprintf(
+		"// Like `%s`, but converts each value that isn't a string (e.g., `3`, `true`, or an object) with `%s.%s`.",
+		functionName, utilName, toStringName))
+	m.writeLine(fmt.Sprintf(
+		"%s(%s):: %s,", name, paramName,
+		fmt.Sprintf(`assert std.type(%[1]s) == "object" : "'%[1]s' must be an object, got " + std.type(%[1]s); %[2]s`, paramName, body)))
+	p.addSymbol(fmt.Sprintf("%s.%s", path, name), SymbolFunction, string(paramName))
+}
@@ -0,0 +1,113 @@
+package ksonnet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmitStringifiedSetters(t *testing.T) {
+	const path = "testdata/stringified.json"
+	if library := emitTestSpec(t, path, Options{}); strings.Contains(library, "Stringified") || strings.Contains(library, "toString(") {
+		t.Errorf("Expected no stringified setters by default:\n%s", library)
+	}
+
+	opts := Options{EmitStringifiedSetters: true}
+	library := emitTestSpec(t, path, opts)
+	for _, line := range []string{
+		`dataStringified(data):: assert std.type(data) == "object" : "'data' must be an object, got " + std.type(data); {data+: std.mapWithKey(function(key, value) $.util.toString(value), data)},`,
+		`stringDataStringified(stringData)::`,
+		`annotationsStringified(annotations)::`,
+		`__metadataMixin({annotations+: std.mapWithKey(function(key, value) $.util.toString(value), annotations)}),`,
+		"toString(value):: ",
+	} {
+		if !strings.Contains(library, line) {
+			t.Errorf("Expected the library to have '%s':\n%s", line, library)
+		}
+	}
+	// The plain setters still pass their values through, and maps that
+	// aren't listed (labels), or whose values are base64 (a Secret's
+	// `data`), get no variant.
+	if !strings.Contains(library, "data(data):: {data+: data},") {
+		t.Errorf("Expected the plain 'data' setter to be unchanged:\n%s", library)
+	}
+	if strings.Contains(library, "labelsStringified") {
+		t.Errorf("Expected no stringified setter for 'labels':\n%s", library)
+	}
+	if count := strings.Count(library, "dataStringified(data)::"); count != 1 {
+		t.Errorf("Expected only the ConfigMap's 'data' to be stringified, got %d setters:\n%s", count, library)
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, path), opts)
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	if symbol := symbols["util.toString"]; symbol == nil || symbol.Kind != SymbolFunction || strings.Join(symbol.Params, ", ") != "value" {
+		t.Errorf("Expected 'util.toString(value)' to be indexed, got %#v", symbol)
+	}
+	if symbol := symbols["core.v1.configMap.dataStringified"]; symbol == nil || symbol.Kind != SymbolFunction || symbol.Property != "data" {
+		t.Errorf("Expected 'core.v1.configMap.dataStringified(data)' to be indexed, got %#v", symbol)
+	}
+
+	if err := (&Options{EmitStringifiedSetters: true, SplitByGroup: true}).Validate(); err == nil {
+		t.Errorf("Expected EmitStringifiedSetters with SplitByGroup to be invalid")
+	}
+}
+
+func TestEmitStringifiedSettersEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// Numbers, booleans, and nested objects are converted, strings are
+	// unchanged, and the plain setter passes values through.
+	const program = `local k = import 'k8s.libsonnet';
+local configMap = k.core.v1.configMap;
+local cm = configMap.new() +
+  configMap.dataStringified({replicas: 3, ratio: 0.5, debug: true, quiet: false, name: "web", limits: {cpu: 1, tags: ["a"]}}) +
+  configMap.mixin.metadata.annotationsStringified({"example.com/port": 8080});
+[
+  std.assertEqual(cm.data, {replicas: "3", ratio: "0.5", debug: "true", quiet: "false", name: "web", limits: std.manifestJsonEx({cpu: 1, tags: ["a"]}, "  ")}),
+  std.assertEqual(cm.metadata.annotations, {"example.com/port": "8080"}),
+  std.assertEqual((configMap.data({a: "1"}) + configMap.dataStringified({b: 2})).data, {a: "1", b: "2"}),
+  std.assertEqual(configMap.data({replicas: 3}).data, {replicas: 3}),
+  std.assertEqual(k.core.v1.secret.stringDataStringified({port: 5432}).stringData, {port: "5432"}),
+]
+`
+	spec := loadTestSpec(t, "testdata/stringified.json")
+	opts := Options{EmitStringifiedSetters: true}
+	if out := evaluateLibrary(t, jsonnet, spec, opts, program); strings.Contains(out, "false") {
+		t.Errorf("Expected every value to be converted as expected, got:\n%s", out)
+	}
+
+	// `null` fails clearly, rather than becoming the string "null".
+	dir, err := ioutil.TempDir("", "stringified")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	library, err := Emit(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, LibraryFile), library, 0644); err != nil {
+		t.Fatalf("Could not write library:\n%v", err)
+	}
+	main := filepath.Join(dir, "main.jsonnet")
+	if err := ioutil.WriteFile(main, []byte("(import 'k8s.libsonnet').core.v1.configMap.dataStringified({a: null})\n"), 0644); err != nil {
+		t.Fatalf("Could not write program:\n%v", err)
+	}
+	out, err := exec.Command(jsonnet, "-J", dir, main).CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "'toString' can't convert null to a string") {
+		t.Errorf("Expected converting null to fail, got:\n%s", out)
+	}
+}
@@ -0,0 +1,384 @@
+package ksonnet
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+//-----------------------------------------------------------------------------
+// Symbol index.
+//-----------------------------------------------------------------------------
+
+// SymbolKind describes what sort of Jsonnet value a `Symbol` refers
+// to in the generated library.
+type SymbolKind string
+
+const (
+	// SymbolNamespace is an object that groups other symbols, e.g.,
+	// `apps.v1beta1.deployment` or `apps.v1beta1.deployment.mixin`.
+	SymbolNamespace SymbolKind = "namespace"
+
+	// SymbolFunction is a function, e.g., a property method like
+	// `core.v1.container.image`, or a constructor like
+	// `apps.v1beta1.deployment.new`.
+	SymbolFunction SymbolKind = "function"
+
+	// SymbolAlias is a type alias that points at a hidden API object,
+	// e.g., `core.v1.pod.mixin.spec.containersType`.
+	SymbolAlias SymbolKind = "alias"
+)
+
+// Symbol is a single named value in the generated library. For
+// example, the property method `core.v1.container.image(image)` would
+// be represented with the path `core.v1.container.image`, the kind
+// `SymbolFunction`, and the parameter list `["image"]`. Params are
+// the names the function is emitted with, so they follow
+// `Options.NamingStrategy`.
+type Symbol struct {
+	Path   string     `json:"path"`
+	Kind   SymbolKind `json:"kind"`
+	Params []string   `json:"params,omitempty"`
+	Target string     `json:"target,omitempty"` // Only set for aliases.
+
+	// Defaults maps the parameters that may be left out to the Jsonnet
+	// expressions they default to, e.g., `{"hostNetwork": "true"}` for
+	// `core.v1.pod.mixin.spec.hostNetwork(hostNetwork=true)`.
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	// Definition is the definition of the spec an API object's
+	// namespace is emitted for (e.g.,
+	// `io.k8s.api.apps.v1beta2.Deployment` for
+	// `apps.v1beta2.deployment`). For the setters, mixin namespaces, and
+	// type aliases of a property, it's the definition that has the
+	// property, and Property is its name in the spec. Items is set for
+	// the type aliases of arrays, which point at the type of their
+	// elements (e.g., `containersType`). See `ResolveLibraryPath`.
+	Definition kubespec.DefinitionName `json:"definition,omitempty"`
+	Property   kubespec.PropertyName   `json:"property,omitempty"`
+	Items      bool                    `json:"items,omitempty"`
+
+	// Level is set for the namespaces of API objects, and is
+	// `topLevel` for those of top-level ones (e.g.,
+	// `apps.v1beta2.deployment`, but also `apps.v1beta2.deploymentList`),
+	// and `subObject` for the rest (e.g., `core.v1.podSpec` in
+	// `hidden`); see `kubespec.APISpec.Classify`.
+	Level string `json:"level,omitempty"`
+
+	// RenamedFrom is the identifier the property would have been named
+	// by, if it was renamed to avoid another property's identifier or a
+	// reserved one (e.g., `std` for `crd.v1.thing.mixin.spec.std_`); see
+	// `propertyIdentifiers`.
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+
+	// Type describes the type of the property a function sets, or a
+	// mixin namespace is for, e.g., `array of Container`; see
+	// `kubespec.APISpec.TypeString`.
+	Type string `json:"type,omitempty"`
+
+	// The `x-kubernetes-list-type` and `x-kubernetes-list-map-keys` of
+	// array properties, if the spec sets them.
+	ListType    string   `json:"listType,omitempty"`
+	ListMapKeys []string `json:"listMapKeys,omitempty"`
+
+	// Deprecated is set for the shims of renamed properties (e.g.,
+	// `core.v1.pod.mixin.spec.serviceAccount`; see
+	// `kubeversion.DeprecatedShims`), and ReplacedBy is the path of the
+	// symbol each wraps (e.g., `core.v1.pod.mixin.spec.serviceAccountName`),
+	// which `MigrateImports` rewrites references to them as.
+	Deprecated bool   `json:"deprecated,omitempty"`
+	ReplacedBy string `json:"replacedBy,omitempty"`
+
+	// ExtensionPoint is set for the hooks of
+	// `Options.EmitExtensionPoints` (e.g.,
+	// `hidden.core.v1.volume.volumeSourcesExt`), which
+	// `Options.Extends` looks up.
+	ExtensionPoint bool `json:"extensionPoint,omitempty"`
+}
+
+// signatureEquals reports whether two symbols at the same path are
+// interchangeable from the point of view of a caller. A change of list
+// type counts, since it decides whether the setter appends to the list
+// or replaces it.
+func (s *Symbol) signatureEquals(other *Symbol) bool {
+	return s.Kind == other.Kind &&
+		s.Target == other.Target &&
+		s.ListType == other.ListType &&
+		reflect.DeepEqual(s.Params, other.Params)
+}
+
+// defaultsKept reports whether `other` still defaults every parameter
+// `s` defaults, to the same value. Callers that leave a parameter out
+// break if its default goes away or changes, but not if a parameter
+// gains one.
+func (s *Symbol) defaultsKept(other *Symbol) bool {
+	for param, value := range s.Defaults {
+		if other.Defaults[param] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// KindSymbol describes a top-level API object (i.e., something that
+// can be created with `kubectl`) in the generated library. For
+// example, `apps.v1beta1.deployment` has group `apps`, version
+// `v1beta1` (whose stage is `beta`), kind `Deployment`, is namespaced,
+// and has the subresources `scale` and `status`.
+type KindSymbol struct {
+	Path         string   `json:"path"`
+	Group        string   `json:"group"`
+	Version      string   `json:"version"`
+	Stage        string   `json:"stage"` // See `kubespec.VersionStage`.
+	Kind         string   `json:"kind"`
+	Scope        string   `json:"scope"`
+	Verbs        []string `json:"verbs"`
+	Subresources []string `json:"subresources"`
+
+	// ScaleHelpers is set if the kind got the conveniences for its
+	// `/scale` subresource, `withReplicas` and the `scale` namespace.
+	ScaleHelpers bool `json:"scaleHelpers,omitempty"`
+
+	// Storage is set if the kind is a custom resource in the version
+	// its CRD stores; see `kubespec.APISpec.WithCRDs`.
+	Storage bool `json:"storage,omitempty"`
+
+	// If the kind is scheduled for removal, the release of Kubernetes
+	// that stops serving it (e.g., `v1.16`), the apiVersion that
+	// replaces it (e.g., `apps/v1`), and the path of its replacement,
+	// if the library has it (e.g., `apps.v1.deployment`); see
+	// `kubeversion.ScheduledRemoval`.
+	RemovedIn       string `json:"removedIn,omitempty"`
+	Replacement     string `json:"replacement,omitempty"`
+	ReplacementPath string `json:"replacementPath,omitempty"`
+}
+
+// KindName is the name a top-level kind of an API group goes by in
+// every output (e.g., `postgresDatabase` for the `Database` of
+// `postgres.example.com`), and the path of the most stable version of
+// it that its alias points at. Qualified is set if the name has the
+// group's short name; see `CollisionPolicy`. Group is the API group,
+// as in the kind's apiVersion, so it's empty for `core`.
+type KindName struct {
+	Kind      string `json:"kind"`
+	Group     string `json:"group"`
+	Name      string `json:"name"`
+	Qualified bool   `json:"qualified,omitempty"`
+	Path      string `json:"path"`
+}
+
+// SymbolIndex is the set of all symbols emitted into `k8s.libsonnet`
+// for some Kubernetes version, sorted by path, along with the
+// flattened aliases of `k.libsonnet`, which map each alias (e.g.,
+// `deployment`) to the path it points at. If the library is split by
+// group (see `Options.SplitByGroup`), Files maps the path of each
+// group (e.g., `rbac`), and `hidden`, to the name of its file (e.g.,
+// `rbac-authorization-k8s-io.libsonnet`), as, with
+// `Options.SplitHidden`, it does each hidden group (e.g., `hidden.core`
+// to `hidden.core.libsonnet`).
+//
+// NamespacePrefix is the `Options.NamespacePrefix` the tree of the
+// library is nested under, if any, which every path but those of
+// hidden objects starts with, e.g., `lib.k8s.apps.v1.deployment`; the
+// aliases are keyed by their paths, e.g., `lib.k8s.deployment`.
+//
+// KindNames are the names `Options.KindCollisions`, recorded as
+// KindCollisions, gave each top-level kind of each group, which
+// `k.libsonnet`, `INDEX.md`, the samples, and `gvkIndex.libsonnet` all
+// use.
+//
+// GroupIDs maps the identifier of each group of the library (e.g.,
+// `fooBarIo2`) back to the API group it's named after (e.g.,
+// `foo.bar.io`), and PropertyIDs maps the identifiers of properties
+// that aren't their names back to them, for each definition; see
+// `kubespec.GroupIDMap` and `PropertyIDMap`. Groups that
+// `Options.Namespaces` names aren't sanitized, so they're only in
+// GroupIDs under the groups they came from.
+//
+// Forks are the definitions that merging sources of CRDs forked,
+// because they conflicted, in order of name; see `DefinitionFork`.
+//
+// Codebases are the group/versions each codebase the spec was merged
+// from contributed to the library, as `kubespec.Parser.Codebases`
+// returns them. Provenance has the fields of the spec's provenance
+// that `Options.ProvenanceFields` selects, if it selects any.
+type SymbolIndex struct {
+	KubernetesVersion string                             `json:"kubernetesVersion"`
+	NamespacePrefix   string                             `json:"namespacePrefix,omitempty"`
+	Codebases         map[string][]kubespec.GroupVersion `json:"codebases,omitempty"`
+	Provenance        *kubespec.Provenance               `json:"provenance,omitempty"`
+	Symbols           []*Symbol                          `json:"symbols"`
+	Kinds             []*KindSymbol                      `json:"kinds"`
+	Aliases           map[string]string                  `json:"aliases,omitempty"`
+	KindCollisions    CollisionPolicy                    `json:"kindCollisions,omitempty"`
+	KindNames         []*KindName                        `json:"kindNames,omitempty"`
+	GroupIDs          kubespec.GroupIDMap                `json:"groupIDs,omitempty"`
+	PropertyIDs       PropertyIDMap                      `json:"propertyIDs,omitempty"`
+	Forks             []*DefinitionFork                  `json:"forks,omitempty"`
+	Files             map[string]string                  `json:"files,omitempty"`
+}
+
+func newSymbolIndex(k8sVersion string) *SymbolIndex {
+	return &SymbolIndex{
+		KubernetesVersion: k8sVersion,
+		Symbols:           []*Symbol{},
+		Kinds:             []*KindSymbol{},
+	}
+}
+
+func (si *SymbolIndex) addKind(
+	path string, gvks kubespec.TopLevelSpecs, scope kubespec.Scope,
+	resource *kubespec.Resource, scaleHelpers, storage bool,
+) []*KindSymbol {
+	verbs, subresources := []string{}, []string{}
+	if resource != nil {
+		verbs, subresources = resource.Verbs, resource.Subresources
+	}
+
+	kinds := []*KindSymbol{}
+	for _, gvk := range gvks {
+		kinds = append(kinds, &KindSymbol{
+			Path:         path,
+			Group:        string(gvk.Group),
+			Version:      string(gvk.Version),
+			Stage:        string(gvk.Version.Stage()),
+			Kind:         string(gvk.Kind),
+			Scope:        scope.String(),
+			Verbs:        verbs,
+			Subresources: subresources,
+			ScaleHelpers: scaleHelpers,
+			Storage:      storage,
+		})
+	}
+	si.Kinds = append(si.Kinds, kinds...)
+	return kinds
+}
+
+// `children` returns the names of the symbols directly inside the
+// namespace at `path`, e.g., `new` and `mixin` for
+// `apps.v1beta1.deployment`.
+func (si *SymbolIndex) children(path string) map[string]bool {
+	children := map[string]bool{}
+	prefix := path + "."
+	for _, symbol := range si.Symbols {
+		if name := strings.TrimPrefix(symbol.Path, prefix); name != symbol.Path &&
+			!strings.Contains(name, ".") {
+			children[name] = true
+		}
+	}
+	return children
+}
+
+func (si *SymbolIndex) add(path string, kind SymbolKind, params ...string) *Symbol {
+	symbol := &Symbol{
+		Path:   path,
+		Kind:   kind,
+		Params: params,
+	}
+	si.Symbols = append(si.Symbols, symbol)
+	return symbol
+}
+
+// lookup returns the symbol most recently added at `path`, or nil if
+// there isn't one.
+func (si *SymbolIndex) lookup(path string) *Symbol {
+	for i := len(si.Symbols) - 1; i >= 0; i-- {
+		if si.Symbols[i].Path == path {
+			return si.Symbols[i]
+		}
+	}
+	return nil
+}
+
+func (si *SymbolIndex) addAlias(path, target string) *Symbol {
+	symbol := &Symbol{
+		Path:   path,
+		Kind:   SymbolAlias,
+		Target: target,
+	}
+	si.Symbols = append(si.Symbols, symbol)
+	return symbol
+}
+
+func (si *SymbolIndex) sort() {
+	sortSymbols(si.Symbols)
+	sort.Slice(si.Kinds, func(i, j int) bool {
+		return si.Kinds[i].Path < si.Kinds[j].Path
+	})
+}
+
+func (si *SymbolIndex) byPath() map[string]*Symbol {
+	symbols := make(map[string]*Symbol, len(si.Symbols))
+	for _, symbol := range si.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	return symbols
+}
+
+//-----------------------------------------------------------------------------
+// Symbol diffs.
+//-----------------------------------------------------------------------------
+
+// SymbolChange records a symbol whose signature differs between a
+// baseline `SymbolIndex` and a newly-generated one.
+type SymbolChange struct {
+	Old *Symbol
+	New *Symbol
+}
+
+// SymbolDiff is the difference between a baseline `SymbolIndex` and a
+// newly-generated one. Each list is sorted by path.
+type SymbolDiff struct {
+	Added   []*Symbol
+	Removed []*Symbol
+	Changed []*SymbolChange
+}
+
+// DiffSymbolIndexes compares a baseline index against the current
+// one, reporting which symbols have been added, removed, or had their
+// signature changed.
+func DiffSymbolIndexes(baseline, current *SymbolIndex) *SymbolDiff {
+	diff := &SymbolDiff{}
+	oldSymbols := baseline.byPath()
+	newSymbols := current.byPath()
+
+	for path, oldSymbol := range oldSymbols {
+		newSymbol, ok := newSymbols[path]
+		if !ok {
+			diff.Removed = append(diff.Removed, oldSymbol)
+		} else if !oldSymbol.signatureEquals(newSymbol) || !oldSymbol.defaultsKept(newSymbol) {
+			diff.Changed = append(diff.Changed, &SymbolChange{
+				Old: oldSymbol,
+				New: newSymbol,
+			})
+		}
+	}
+
+	for path, newSymbol := range newSymbols {
+		if _, ok := oldSymbols[path]; !ok {
+			diff.Added = append(diff.Added, newSymbol)
+		}
+	}
+
+	// Sort so that reports are stable.
+	sortSymbols(diff.Added)
+	sortSymbols(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Old.Path < diff.Changed[j].Old.Path
+	})
+	return diff
+}
+
+// IsBreaking reports whether the diff removes or changes any symbol
+// that callers of the baseline library may depend on.
+func (diff *SymbolDiff) IsBreaking() bool {
+	return len(diff.Removed) > 0 || len(diff.Changed) > 0
+}
+
+func sortSymbols(symbols []*Symbol) {
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Path < symbols[j].Path
+	})
+}
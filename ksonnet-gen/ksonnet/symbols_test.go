@@ -0,0 +1,240 @@
+package ksonnet
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func loadTestSpec(t *testing.T, path string) *kubespec.APISpec {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read test spec '%s':\n%v", path, err)
+	}
+
+	s, err := kubespec.UnmarshalSpec(path, text)
+	if err != nil {
+		t.Fatalf("Could not deserialize test spec '%s':\n%v", path, err)
+	}
+	s.FilePath = "."
+	return s
+}
+
+var expectedSymbols = map[string]*Symbol{
+	"apps": {Kind: SymbolNamespace},
+	"apps.v1beta1.deployment.new": {
+		Kind:   SymbolFunction,
+		Params: []string{"labels"},
+	},
+	"apps.v1beta1.deployment.mixin.spec.replicas": {
+		Kind:   SymbolFunction,
+		Params: []string{"replicas"},
+	},
+	"apps.v1beta1.deployment.mixin.spec.template.spec.containersType": {
+		Kind:   SymbolAlias,
+		Target: "hidden.core.v1.container",
+	},
+	"apps.v1beta1.deployment.scale.withReplicas": {
+		Kind:   SymbolFunction,
+		Params: []string{"replicas"},
+	},
+	"core.v1.service.mixin.spec.clusterIp": {
+		Kind:   SymbolFunction,
+		Params: []string{"clusterIp"},
+	},
+	"hidden.core.v1.container.image": {
+		Kind:   SymbolFunction,
+		Params: []string{"image"},
+	},
+}
+
+func TestBuildSymbolIndex(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	if index.KubernetesVersion != "v1.7.0" {
+		t.Errorf("Expected version 'v1.7.0' got '%s'", index.KubernetesVersion)
+	}
+
+	symbols := index.byPath()
+	for path, expected := range expectedSymbols {
+		actual, ok := symbols[path]
+		if !ok {
+			t.Errorf("Expected symbol '%s' to be in index", path)
+			continue
+		}
+		expected.Path = path
+		if !expected.signatureEquals(actual) {
+			t.Errorf("Expected symbol '%#v' got '%#v'", expected, actual)
+		}
+	}
+
+	if _, ok := symbols["apps.v1beta1.deployment.mixin.status"]; ok {
+		t.Errorf("Blacklisted property 'status' should not be in index")
+	}
+
+	scopes := map[string]string{}
+	for _, kind := range index.Kinds {
+		scopes[kind.Path] = kind.Scope
+		if kind.Kind == "Deployment" &&
+			!reflect.DeepEqual(kind.Subresources, []string{"scale", "status"}) {
+			t.Errorf("Unexpected subresources for 'Deployment': %v", kind.Subresources)
+		}
+	}
+	expectedScopes := map[string]string{
+		"apps.v1beta1.deployment": "namespaced",
+		"core.v1.namespace":       "cluster",
+		"core.v1.service":         "namespaced",
+	}
+	for path, expected := range expectedScopes {
+		if scopes[path] != expected {
+			t.Errorf("Expected '%s' to have scope '%s' got '%s'", path, expected, scopes[path])
+		}
+	}
+
+	for i := 1; i < len(index.Symbols); i++ {
+		if index.Symbols[i-1].Path >= index.Symbols[i].Path {
+			t.Errorf(
+				"Symbols not sorted: '%s' before '%s'",
+				index.Symbols[i-1].Path, index.Symbols[i].Path)
+		}
+	}
+}
+
+func TestSymbolIndexTypes(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	symbols := index.byPath()
+	expected := map[string]string{
+		"apps.v1beta1.deployment.mixin.spec.replicas":                     "integer (int32)",
+		"apps.v1beta1.deployment.mixin.spec.template":                     "object (PodTemplateSpec)",
+		"apps.v1beta1.deployment.mixin.spec.template.spec.containers":     "array of Container",
+		"apps.v1beta1.deployment.mixin.metadata.labels":                   "map of string → string",
+		"hidden.core.v1.container.image":                                  "string",
+		"apps.v1beta1.deployment.new":                                     "",
+		"apps.v1beta1.deployment.mixin.spec.template.spec.containersType": "",
+	}
+	for path, typeString := range expected {
+		if symbol, ok := symbols[path]; !ok {
+			t.Errorf("Expected symbol '%s' to be in index", path)
+		} else if symbol.Type != typeString {
+			t.Errorf("Expected symbol '%s' to have type '%s' got '%s'", path, typeString, symbol.Type)
+		}
+	}
+}
+
+func TestSymbolIndexLevels(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/swagger.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	// The namespaces of API objects say whether they're top-level, and
+	// other symbols don't.
+	symbols := index.byPath()
+	expected := map[string]string{
+		"apps.v1beta1.deployment":            "topLevel",
+		"hidden.core.v1.podSpec":             "subObject",
+		"apps.v1beta1.deployment.mixin.spec": "",
+		"apps.v1beta1.deployment.new":        "",
+		"hidden.core.v1.container.image":     "",
+	}
+	for path, level := range expected {
+		if symbol, ok := symbols[path]; !ok {
+			t.Errorf("Expected symbol '%s' to be in index", path)
+		} else if symbol.Level != level {
+			t.Errorf("Expected symbol '%s' to have level '%s' got '%s'", path, level, symbol.Level)
+		}
+	}
+}
+
+func TestSymbolIndexStages(t *testing.T) {
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/stages.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+
+	stages := map[string]string{}
+	for _, kind := range index.Kinds {
+		stages[kind.Path] = kind.Stage
+	}
+	expected := map[string]string{
+		"autoscaling.v1.horizontalPodAutoscaler":       "ga",
+		"autoscaling.v2alpha1.horizontalPodAutoscaler": "alpha",
+		"batch.v1beta1.cronJob":                        "beta",
+		"batch.v2alpha1.cronJob":                       "alpha",
+		"settings.v1alpha1.podPreset":                  "alpha",
+	}
+	if !reflect.DeepEqual(stages, expected) {
+		t.Errorf("Expected stages %v got %v", expected, stages)
+	}
+}
+
+func TestDiffSymbolIndexes(t *testing.T) {
+	baseline := &SymbolIndex{
+		Symbols: []*Symbol{
+			{Path: "core.v1.container.image", Kind: SymbolFunction, Params: []string{"image"}},
+			{Path: "core.v1.container.name", Kind: SymbolFunction, Params: []string{"name"}},
+			{Path: "core.v1.container.new", Kind: SymbolFunction},
+		},
+	}
+	current := &SymbolIndex{
+		Symbols: []*Symbol{
+			{Path: "core.v1.container.args", Kind: SymbolFunction, Params: []string{"args"}},
+			{Path: "core.v1.container.image", Kind: SymbolNamespace},
+			{Path: "core.v1.container.new", Kind: SymbolFunction},
+		},
+	}
+
+	diff := DiffSymbolIndexes(baseline, current)
+	if !diff.IsBreaking() {
+		t.Errorf("Expected diff to be breaking")
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Path != "core.v1.container.args" {
+		t.Errorf("Unexpected additions: %#v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Path != "core.v1.container.name" {
+		t.Errorf("Unexpected removals: %#v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].New.Path != "core.v1.container.image" {
+		t.Errorf("Unexpected changes: %#v", diff.Changed)
+	}
+
+	atomic := &SymbolIndex{
+		Symbols: []*Symbol{
+			{Path: "core.v1.container.args", Kind: SymbolFunction, Params: []string{"args"}, ListType: "atomic"},
+		},
+	}
+	if diff := DiffSymbolIndexes(current, atomic); len(diff.Changed) != 1 {
+		t.Errorf("Expected a change of list type to change the symbol, got %#v", diff)
+	}
+
+	if DiffSymbolIndexes(current, current).IsBreaking() {
+		t.Errorf("Expected diff of identical indexes to be non-breaking")
+	}
+
+	// Gaining a default is compatible; losing or changing one is not.
+	setter := func(defaults map[string]string) *SymbolIndex {
+		return &SymbolIndex{Symbols: []*Symbol{{
+			Path: "core.v1.pod.mixin.spec.hostNetwork", Kind: SymbolFunction,
+			Params: []string{"hostNetwork"}, Defaults: defaults,
+		}}}
+	}
+	withDefault := setter(map[string]string{"hostNetwork": "true"})
+	if DiffSymbolIndexes(setter(nil), withDefault).IsBreaking() {
+		t.Errorf("Expected adding a default to be non-breaking")
+	}
+	if diff := DiffSymbolIndexes(withDefault, setter(nil)); len(diff.Changed) != 1 {
+		t.Errorf("Expected removing a default to change the symbol, got %#v", diff)
+	}
+	if diff := DiffSymbolIndexes(withDefault, setter(map[string]string{"hostNetwork": "false"})); len(diff.Changed) != 1 {
+		t.Errorf("Expected changing a default to change the symbol, got %#v", diff)
+	}
+}
@@ -0,0 +1,978 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.7.0
+// API versions: apps/v1beta1, batch/v1, batch/v2alpha1
+// SHA of ksonnet-lib HEAD: [SHA]
+// SHA of Kubernetes HEAD OpenAPI spec is generated from: [SHA]
+
+{
+  apps:: {
+    v1beta1:: {
+      local apiVersion = {apiVersion: "apps/v1beta1"},
+      // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta1` `Deployment`; use `apps/v1` instead.
+      //
+      // Deployment enables declarative updates for Pods and ReplicaSets.
+      deployment:: {
+        // Example, where `k` is the library:
+        //
+        //   local deployment = k.apps.v1beta1.deployment;
+        //   deployment.new()
+        //   + deployment.mixin.spec.replicas(replicas)
+        //   + deployment.mixin.spec.template.spec.containers(containers)
+        local kind = {kind: "Deployment"},
+        local __matchingLabels(labels) = {spec+: {selector+: {matchLabels: labels}, template+: {metadata+: {labels: labels}}}},
+        new(labels=null):: apiVersion + kind + (if labels == null then {} else __matchingLabels(labels)),
+        // Sets both `spec.selector.matchLabels` and `spec.template.metadata.labels` to `labels`, so that the selector matches the pods created from the template.
+        withMatchingLabels(labels):: __matchingLabels(labels),
+        // Errors unless every label in `spec.selector.matchLabels` is also in `spec.template.metadata.labels`. Since it checks the final object, it can be added before or after other mixins.
+        assertSelectorMatches():: {
+          local selector = if std.objectHas(self, "spec") && std.objectHas(self.spec, "selector") && std.objectHas(self.spec.selector, "matchLabels") then self.spec.selector.matchLabels else {},
+          local labels = if std.objectHas(self, "spec") && std.objectHas(self.spec, "template") && std.objectHas(self.spec.template, "metadata") && std.objectHas(self.spec.template.metadata, "labels") then self.spec.template.metadata.labels else {},
+          assert std.length([l for l in std.objectFields(selector) if !std.objectHas(labels, l) || labels[l] != selector[l]]) == 0 : "'spec.selector.matchLabels' must match 'spec.template.metadata.labels'",
+        },
+        // Number of desired replicas, i.e., the `spec.replicas` field read and written by the `/scale` subresource.
+        withReplicas(replicas):: {spec+: {replicas: replicas}},
+        // Conveniences for the `/scale` subresource of this object.
+        scale:: {
+          // A `Scale` object (`apps/v1beta1`) with `spec.replicas` set, to write to the `/scale` subresource.
+          new(replicas):: {apiVersion: "apps/v1beta1", kind: "Scale", spec: {replicas: replicas}},
+          // Number of desired replicas, i.e., the `spec.replicas` field read and written by the `/scale` subresource.
+          withReplicas(replicas):: {spec+: {replicas: replicas}},
+        },
+        mixin:: {
+          // Standard object metadata.
+          //
+          // @type object (ObjectMeta)
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            // Annotations is an unstructured key value map.
+            //
+            // @type map of string → string
+            annotations(annotations):: __metadataMixin({annotations+: annotations}),
+            // Must be empty before the object is deleted from the registry.
+            //
+            // @type array of string
+            finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+            // Map of string keys and values that can be used to organize and categorize objects.
+            //
+            // @type map of string → string
+            labels(labels):: __metadataMixin({labels+: labels}),
+            // Name must be unique within a namespace.
+            //
+            // @type string
+            name(name):: __metadataMixin({name: name}),
+            // Namespace defines the space within each name must be unique.
+            //
+            // @type string
+            namespace(namespace):: __metadataMixin({namespace: namespace}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          // Specification of the desired behavior of the Deployment.
+          //
+          // @type object (DeploymentSpec)
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            // Number of desired pods.
+            //
+            // @type integer (int32)
+            replicas(replicas):: __specMixin({replicas: replicas}),
+            // Label selector for pods.
+            //
+            // @type object (LabelSelector)
+            selector:: {
+              local __selectorMixin(selector) = __specMixin({selector+: selector}),
+              // matchLabels is a map of {key,value} pairs.
+              //
+              // @type map of string → string
+              matchLabels(matchLabels):: __selectorMixin({matchLabels+: matchLabels}),
+            },
+            selectorType:: hidden.meta.v1.labelSelector,
+            // Template describes the pods that will be created.
+            //
+            // @type object (PodTemplateSpec)
+            template:: {
+              local __templateMixin(template) = __specMixin({template+: template}),
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the pod.
+              //
+              // @type object (PodSpec)
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                // List of containers belonging to the pod.
+                //
+                // @type array of Container
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+                // Host networking requested for this pod.
+                //
+                // @type boolean
+                hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                // Restart policy for all containers within the pod.
+                //
+                // @type string
+                restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+          specType:: hidden.apps.v1beta1.deploymentSpec,
+        },
+      },
+    },
+  },
+  batch:: {
+    v1:: {
+      local apiVersion = {apiVersion: "batch/v1"},
+      // Job represents the configuration of a single job.
+      job:: {
+        // Example, where `k` is the library:
+        //
+        //   local job = k.batch.v1.job;
+        //   job.new(name, containers)
+        //   + job.mixin.metadata.annotations(annotations)
+        //   + job.mixin.metadata.finalizers(finalizers)
+        local kind = {kind: "Job"},
+        // Creates a `Job` that runs `containers` to completion. `labels` are set on both the job and its pod template.
+        new(name, containers, restartPolicy="OnFailure", labels={name: name}):: apiVersion + kind + {
+          metadata: {
+            labels: labels,
+            name: name,
+          },
+          spec: {
+            template: {
+              metadata: {
+                labels: labels,
+              },
+              spec: {
+                containers: if std.type(containers) == "array" then containers else [containers],
+                restartPolicy: restartPolicy,
+              },
+            },
+          },
+        },
+        mixin:: {
+          // Standard object's metadata.
+          //
+          // @type object (ObjectMeta)
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            // Annotations is an unstructured key value map.
+            //
+            // @type map of string → string
+            annotations(annotations):: __metadataMixin({annotations+: annotations}),
+            // Must be empty before the object is deleted from the registry.
+            //
+            // @type array of string
+            finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+            // Map of string keys and values that can be used to organize and categorize objects.
+            //
+            // @type map of string → string
+            labels(labels):: __metadataMixin({labels+: labels}),
+            // Name must be unique within a namespace.
+            //
+            // @type string
+            name(name):: __metadataMixin({name: name}),
+            // Namespace defines the space within each name must be unique.
+            //
+            // @type string
+            namespace(namespace):: __metadataMixin({namespace: namespace}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          // Specification of the desired behavior of the Job.
+          //
+          // @type object (JobSpec)
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            // Specifies the desired number of successfully finished pods the job should be run with.
+            //
+            // @type integer (int32)
+            completions(completions):: __specMixin({completions: completions}),
+            // Describes the pod that will be created when executing a job.
+            //
+            // @type object (PodTemplateSpec)
+            template:: {
+              local __templateMixin(template) = __specMixin({template+: template}),
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the pod.
+              //
+              // @type object (PodSpec)
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                // List of containers belonging to the pod.
+                //
+                // @type array of Container
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+                // Host networking requested for this pod.
+                //
+                // @type boolean
+                hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                // Restart policy for all containers within the pod.
+                //
+                // @type string
+                restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+          specType:: hidden.batch.v1.jobSpec,
+        },
+      },
+    },
+    v2alpha1:: {
+      local apiVersion = {apiVersion: "batch/v2alpha1"},
+      // CronJob represents the configuration of a single cron job.
+      //
+      // alpha API: not enabled by default.
+      cronJob:: {
+        // Example, where `k` is the library:
+        //
+        //   local cronJob = k.batch.v2alpha1.cronJob;
+        //   cronJob.new(name, schedule, containers)
+        //   + cronJob.mixin.metadata.annotations(annotations)
+        //   + cronJob.mixin.metadata.finalizers(finalizers)
+        local kind = {kind: "CronJob"},
+        // Creates a `CronJob` that runs `containers` to completion on `schedule`. `labels` are set on the cron job, its job template, and the job's pod template.
+        new(name, schedule, containers, restartPolicy="OnFailure", labels={name: name}):: apiVersion + kind + {
+          metadata: {
+            labels: labels,
+            name: name,
+          },
+          spec: {
+            jobTemplate: {
+              metadata: {
+                labels: labels,
+              },
+              spec: {
+                template: {
+                  metadata: {
+                    labels: labels,
+                  },
+                  spec: {
+                    containers: if std.type(containers) == "array" then containers else [containers],
+                    restartPolicy: restartPolicy,
+                  },
+                },
+              },
+            },
+            schedule: schedule,
+          },
+        },
+        mixin:: {
+          // Standard object's metadata.
+          //
+          // @type object (ObjectMeta)
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            // Annotations is an unstructured key value map.
+            //
+            // @type map of string → string
+            annotations(annotations):: __metadataMixin({annotations+: annotations}),
+            // Must be empty before the object is deleted from the registry.
+            //
+            // @type array of string
+            finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+            // Map of string keys and values that can be used to organize and categorize objects.
+            //
+            // @type map of string → string
+            labels(labels):: __metadataMixin({labels+: labels}),
+            // Name must be unique within a namespace.
+            //
+            // @type string
+            name(name):: __metadataMixin({name: name}),
+            // Namespace defines the space within each name must be unique.
+            //
+            // @type string
+            namespace(namespace):: __metadataMixin({namespace: namespace}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          // Specification of the desired behavior of the CronJob.
+          //
+          // @type object (CronJobSpec)
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            // Specifies the job that will be created when executing a CronJob.
+            //
+            // @type object (JobTemplateSpec)
+            jobTemplate:: {
+              local __jobTemplateMixin(jobTemplate) = __specMixin({jobTemplate+: jobTemplate}),
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __jobTemplateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the job.
+              //
+              // @type object (JobSpec)
+              spec:: {
+                local __specMixin(spec) = __jobTemplateMixin({spec+: spec}),
+                // Specifies the desired number of successfully finished pods the job should be run with.
+                //
+                // @type integer (int32)
+                completions(completions):: __specMixin({completions: completions}),
+                // Describes the pod that will be created when executing a job.
+                //
+                // @type object (PodTemplateSpec)
+                template:: {
+                  local __templateMixin(template) = __specMixin({template+: template}),
+                  // Standard object's metadata.
+                  //
+                  // @type object (ObjectMeta)
+                  metadata:: {
+                    local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                    // Annotations is an unstructured key value map.
+                    //
+                    // @type map of string → string
+                    annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                    // Must be empty before the object is deleted from the registry.
+                    //
+                    // @type array of string
+                    finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                    // Map of string keys and values that can be used to organize and categorize objects.
+                    //
+                    // @type map of string → string
+                    labels(labels):: __metadataMixin({labels+: labels}),
+                    // Name must be unique within a namespace.
+                    //
+                    // @type string
+                    name(name):: __metadataMixin({name: name}),
+                    // Namespace defines the space within each name must be unique.
+                    //
+                    // @type string
+                    namespace(namespace):: __metadataMixin({namespace: namespace}),
+                  },
+                  metadataType:: hidden.meta.v1.objectMeta,
+                  // Specification of the desired behavior of the pod.
+                  //
+                  // @type object (PodSpec)
+                  spec:: {
+                    local __specMixin(spec) = __templateMixin({spec+: spec}),
+                    // List of containers belonging to the pod.
+                    //
+                    // @type array of Container
+                    containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                    containersType:: hidden.core.v1.container,
+                    // Host networking requested for this pod.
+                    //
+                    // @type boolean
+                    hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                    // Restart policy for all containers within the pod.
+                    //
+                    // @type string
+                    restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+                  },
+                  specType:: hidden.core.v1.podSpec,
+                },
+                templateType:: hidden.core.v1.podTemplateSpec,
+              },
+              specType:: hidden.batch.v1.jobSpec,
+            },
+            jobTemplateType:: hidden.batch.v2alpha1.jobTemplateSpec,
+            // The schedule in Cron format.
+            //
+            // @type string
+            schedule(schedule):: __specMixin({schedule: schedule}),
+          },
+          specType:: hidden.batch.v2alpha1.cronJobSpec,
+        },
+      },
+    },
+  },
+  local hidden = {
+    apps:: {
+      v1beta1:: {
+        local apiVersion = {apiVersion: "apps/v1beta1"},
+        // DeploymentSpec is the specification of the desired behavior of the Deployment.
+        deploymentSpec:: {
+          new():: {},
+          // Number of desired pods.
+          //
+          // @type integer (int32)
+          replicas(replicas):: {replicas: replicas},
+          mixin:: {
+            // Label selector for pods.
+            //
+            // @type object (LabelSelector)
+            selector:: {
+              local __selectorMixin(selector) = {selector+: selector},
+              // matchLabels is a map of {key,value} pairs.
+              //
+              // @type map of string → string
+              matchLabels(matchLabels):: __selectorMixin({matchLabels+: matchLabels}),
+            },
+            selectorType:: hidden.meta.v1.labelSelector,
+            // Template describes the pods that will be created.
+            //
+            // @type object (PodTemplateSpec)
+            template:: {
+              local __templateMixin(template) = {template+: template},
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the pod.
+              //
+              // @type object (PodSpec)
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                // List of containers belonging to the pod.
+                //
+                // @type array of Container
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+                // Host networking requested for this pod.
+                //
+                // @type boolean
+                hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                // Restart policy for all containers within the pod.
+                //
+                // @type string
+                restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+        },
+        // DeploymentStatus is the most recently observed status of the Deployment.
+        deploymentStatus:: {
+          new():: {},
+          // Total number of non-terminated pods targeted by this deployment.
+          //
+          // @type integer (int32)
+          replicas(replicas):: {replicas: replicas},
+          mixin:: {
+          },
+        },
+      },
+    },
+    batch:: {
+      v1:: {
+        local apiVersion = {apiVersion: "batch/v1"},
+        // JobSpec describes how the job execution will look like.
+        jobSpec:: {
+          new():: {},
+          // Specifies the desired number of successfully finished pods the job should be run with.
+          //
+          // @type integer (int32)
+          completions(completions):: {completions: completions},
+          mixin:: {
+            // Describes the pod that will be created when executing a job.
+            //
+            // @type object (PodTemplateSpec)
+            template:: {
+              local __templateMixin(template) = {template+: template},
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the pod.
+              //
+              // @type object (PodSpec)
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                // List of containers belonging to the pod.
+                //
+                // @type array of Container
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+                // Host networking requested for this pod.
+                //
+                // @type boolean
+                hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                // Restart policy for all containers within the pod.
+                //
+                // @type string
+                restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+        },
+      },
+      v2alpha1:: {
+        local apiVersion = {apiVersion: "batch/v2alpha1"},
+        // CronJobSpec describes how the job execution will look like and when it will actually run.
+        cronJobSpec:: {
+          new():: {},
+          // The schedule in Cron format.
+          //
+          // @type string
+          schedule(schedule):: {schedule: schedule},
+          mixin:: {
+            // Specifies the job that will be created when executing a CronJob.
+            //
+            // @type object (JobTemplateSpec)
+            jobTemplate:: {
+              local __jobTemplateMixin(jobTemplate) = {jobTemplate+: jobTemplate},
+              // Standard object's metadata.
+              //
+              // @type object (ObjectMeta)
+              metadata:: {
+                local __metadataMixin(metadata) = __jobTemplateMixin({metadata+: metadata}),
+                // Annotations is an unstructured key value map.
+                //
+                // @type map of string → string
+                annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                // Must be empty before the object is deleted from the registry.
+                //
+                // @type array of string
+                finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                // Map of string keys and values that can be used to organize and categorize objects.
+                //
+                // @type map of string → string
+                labels(labels):: __metadataMixin({labels+: labels}),
+                // Name must be unique within a namespace.
+                //
+                // @type string
+                name(name):: __metadataMixin({name: name}),
+                // Namespace defines the space within each name must be unique.
+                //
+                // @type string
+                namespace(namespace):: __metadataMixin({namespace: namespace}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              // Specification of the desired behavior of the job.
+              //
+              // @type object (JobSpec)
+              spec:: {
+                local __specMixin(spec) = __jobTemplateMixin({spec+: spec}),
+                // Specifies the desired number of successfully finished pods the job should be run with.
+                //
+                // @type integer (int32)
+                completions(completions):: __specMixin({completions: completions}),
+                // Describes the pod that will be created when executing a job.
+                //
+                // @type object (PodTemplateSpec)
+                template:: {
+                  local __templateMixin(template) = __specMixin({template+: template}),
+                  // Standard object's metadata.
+                  //
+                  // @type object (ObjectMeta)
+                  metadata:: {
+                    local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                    // Annotations is an unstructured key value map.
+                    //
+                    // @type map of string → string
+                    annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                    // Must be empty before the object is deleted from the registry.
+                    //
+                    // @type array of string
+                    finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                    // Map of string keys and values that can be used to organize and categorize objects.
+                    //
+                    // @type map of string → string
+                    labels(labels):: __metadataMixin({labels+: labels}),
+                    // Name must be unique within a namespace.
+                    //
+                    // @type string
+                    name(name):: __metadataMixin({name: name}),
+                    // Namespace defines the space within each name must be unique.
+                    //
+                    // @type string
+                    namespace(namespace):: __metadataMixin({namespace: namespace}),
+                  },
+                  metadataType:: hidden.meta.v1.objectMeta,
+                  // Specification of the desired behavior of the pod.
+                  //
+                  // @type object (PodSpec)
+                  spec:: {
+                    local __specMixin(spec) = __templateMixin({spec+: spec}),
+                    // List of containers belonging to the pod.
+                    //
+                    // @type array of Container
+                    containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                    containersType:: hidden.core.v1.container,
+                    // Host networking requested for this pod.
+                    //
+                    // @type boolean
+                    hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                    // Restart policy for all containers within the pod.
+                    //
+                    // @type string
+                    restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+                  },
+                  specType:: hidden.core.v1.podSpec,
+                },
+                templateType:: hidden.core.v1.podTemplateSpec,
+              },
+              specType:: hidden.batch.v1.jobSpec,
+            },
+            jobTemplateType:: hidden.batch.v2alpha1.jobTemplateSpec,
+          },
+        },
+        // JobTemplateSpec describes the data a Job should have when created from a template
+        jobTemplateSpec:: {
+          new():: {},
+          mixin:: {
+            // Standard object's metadata.
+            //
+            // @type object (ObjectMeta)
+            metadata:: {
+              local __metadataMixin(metadata) = {metadata+: metadata},
+              // Annotations is an unstructured key value map.
+              //
+              // @type map of string → string
+              annotations(annotations):: __metadataMixin({annotations+: annotations}),
+              // Must be empty before the object is deleted from the registry.
+              //
+              // @type array of string
+              finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+              // Map of string keys and values that can be used to organize and categorize objects.
+              //
+              // @type map of string → string
+              labels(labels):: __metadataMixin({labels+: labels}),
+              // Name must be unique within a namespace.
+              //
+              // @type string
+              name(name):: __metadataMixin({name: name}),
+              // Namespace defines the space within each name must be unique.
+              //
+              // @type string
+              namespace(namespace):: __metadataMixin({namespace: namespace}),
+            },
+            metadataType:: hidden.meta.v1.objectMeta,
+            // Specification of the desired behavior of the job.
+            //
+            // @type object (JobSpec)
+            spec:: {
+              local __specMixin(spec) = {spec+: spec},
+              // Specifies the desired number of successfully finished pods the job should be run with.
+              //
+              // @type integer (int32)
+              completions(completions):: __specMixin({completions: completions}),
+              // Describes the pod that will be created when executing a job.
+              //
+              // @type object (PodTemplateSpec)
+              template:: {
+                local __templateMixin(template) = __specMixin({template+: template}),
+                // Standard object's metadata.
+                //
+                // @type object (ObjectMeta)
+                metadata:: {
+                  local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                  // Annotations is an unstructured key value map.
+                  //
+                  // @type map of string → string
+                  annotations(annotations):: __metadataMixin({annotations+: annotations}),
+                  // Must be empty before the object is deleted from the registry.
+                  //
+                  // @type array of string
+                  finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+                  // Map of string keys and values that can be used to organize and categorize objects.
+                  //
+                  // @type map of string → string
+                  labels(labels):: __metadataMixin({labels+: labels}),
+                  // Name must be unique within a namespace.
+                  //
+                  // @type string
+                  name(name):: __metadataMixin({name: name}),
+                  // Namespace defines the space within each name must be unique.
+                  //
+                  // @type string
+                  namespace(namespace):: __metadataMixin({namespace: namespace}),
+                },
+                metadataType:: hidden.meta.v1.objectMeta,
+                // Specification of the desired behavior of the pod.
+                //
+                // @type object (PodSpec)
+                spec:: {
+                  local __specMixin(spec) = __templateMixin({spec+: spec}),
+                  // List of containers belonging to the pod.
+                  //
+                  // @type array of Container
+                  containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                  containersType:: hidden.core.v1.container,
+                  // Host networking requested for this pod.
+                  //
+                  // @type boolean
+                  hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+                  // Restart policy for all containers within the pod.
+                  //
+                  // @type string
+                  restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+                },
+                specType:: hidden.core.v1.podSpec,
+              },
+              templateType:: hidden.core.v1.podTemplateSpec,
+            },
+            specType:: hidden.batch.v1.jobSpec,
+          },
+        },
+      },
+    },
+    core:: {
+      v1:: {
+        local apiVersion = {apiVersion: "v1"},
+        // A single application container that you want to run within a pod.
+        container:: {
+          new():: {},
+          // Docker image name.
+          //
+          // @type string
+          image(image):: {image: image},
+          // Name of the container specified as a DNS_LABEL.
+          //
+          // @type string
+          name(name):: {name: name},
+          // List of ports to expose from the container.
+          //
+          // @type array of ContainerPort
+          ports(ports):: if std.type(ports) == "array" then {ports+: ports} else {ports: [ports]},
+          portsType:: hidden.core.v1.containerPort,
+          mixin:: {
+          },
+        },
+        // ContainerPort represents a network port in a single container.
+        containerPort:: {
+          new():: {},
+          // Number of port to expose on the pod's IP address.
+          //
+          // @type integer (int32)
+          containerPort(containerPort):: {containerPort: containerPort},
+          // Protocol for port. Must be UDP or TCP.
+          //
+          // @type string
+          protocol(protocol):: {protocol: protocol},
+          mixin:: {
+          },
+        },
+        // PodSpec is a description of a pod.
+        podSpec:: {
+          new():: {},
+          // List of containers belonging to the pod.
+          //
+          // @type array of Container
+          containers(containers):: if std.type(containers) == "array" then {containers+: containers} else {containers: [containers]},
+          containersType:: hidden.core.v1.container,
+          // Host networking requested for this pod.
+          //
+          // @type boolean
+          hostNetwork(hostNetwork=true):: {hostNetwork: hostNetwork},
+          // Restart policy for all containers within the pod.
+          //
+          // @type string
+          restartPolicy(restartPolicy):: {restartPolicy: restartPolicy},
+          mixin:: {
+          },
+        },
+        // PodTemplateSpec describes the data a pod should have when created from a template
+        podTemplateSpec:: {
+          new():: {},
+          mixin:: {
+            // Standard object's metadata.
+            //
+            // @type object (ObjectMeta)
+            metadata:: {
+              local __metadataMixin(metadata) = {metadata+: metadata},
+              // Annotations is an unstructured key value map.
+              //
+              // @type map of string → string
+              annotations(annotations):: __metadataMixin({annotations+: annotations}),
+              // Must be empty before the object is deleted from the registry.
+              //
+              // @type array of string
+              finalizers(finalizers):: if std.type(finalizers) == "array" then __metadataMixin({finalizers+: finalizers}) else __metadataMixin({finalizers: [finalizers]}),
+              // Map of string keys and values that can be used to organize and categorize objects.
+              //
+              // @type map of string → string
+              labels(labels):: __metadataMixin({labels+: labels}),
+              // Name must be unique within a namespace.
+              //
+              // @type string
+              name(name):: __metadataMixin({name: name}),
+              // Namespace defines the space within each name must be unique.
+              //
+              // @type string
+              namespace(namespace):: __metadataMixin({namespace: namespace}),
+            },
+            metadataType:: hidden.meta.v1.objectMeta,
+            // Specification of the desired behavior of the pod.
+            //
+            // @type object (PodSpec)
+            spec:: {
+              local __specMixin(spec) = {spec+: spec},
+              // List of containers belonging to the pod.
+              //
+              // @type array of Container
+              containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+              containersType:: hidden.core.v1.container,
+              // Host networking requested for this pod.
+              //
+              // @type boolean
+              hostNetwork(hostNetwork=true):: __specMixin({hostNetwork: hostNetwork}),
+              // Restart policy for all containers within the pod.
+              //
+              // @type string
+              restartPolicy(restartPolicy):: __specMixin({restartPolicy: restartPolicy}),
+            },
+            specType:: hidden.core.v1.podSpec,
+          },
+        },
+      },
+    },
+    meta:: {
+      v1:: {
+        local apiVersion = {apiVersion: "meta/v1"},
+        // A label selector is a label query over a set of resources.
+        labelSelector:: {
+          new():: {},
+          // matchLabels is a map of {key,value} pairs.
+          //
+          // @type map of string → string
+          matchLabels(matchLabels):: {matchLabels+: matchLabels},
+          mixin:: {
+          },
+        },
+        // ObjectMeta is metadata that all persisted resources must have.
+        objectMeta:: {
+          new():: {},
+          // Annotations is an unstructured key value map.
+          //
+          // @type map of string → string
+          annotations(annotations):: {annotations+: annotations},
+          // Must be empty before the object is deleted from the registry.
+          //
+          // @type array of string
+          finalizers(finalizers):: if std.type(finalizers) == "array" then {finalizers+: finalizers} else {finalizers: [finalizers]},
+          // Map of string keys and values that can be used to organize and categorize objects.
+          //
+          // @type map of string → string
+          labels(labels):: {labels+: labels},
+          // Name must be unique within a namespace.
+          //
+          // @type string
+          name(name):: {name: name},
+          // Namespace defines the space within each name must be unique.
+          //
+          // @type string
+          namespace(namespace):: {namespace: namespace},
+          mixin:: {
+          },
+        },
+      },
+    },
+  },
+}
@@ -0,0 +1,355 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.9.0
+// API versions: networking.k8s.io/v1
+// SHA of ksonnet-lib HEAD: [SHA]
+// SHA of Kubernetes HEAD OpenAPI spec is generated from: [SHA]
+
+{
+  networking:: {
+    v1:: {
+      local apiVersion = {apiVersion: "networking.k8s.io/v1"},
+      // Ingress is a collection of rules that allow inbound connections to reach the endpoints defined by a backend.
+      ingress:: {
+        // Example, where `k` is the library:
+        //
+        //   local ingress = k.networking.v1.ingress;
+        //   ingress.new()
+        //   + ingress.mixin.spec.rules(rules)
+        //   + ingress.mixin.spec.tls(tls)
+        local kind = {kind: "Ingress"},
+        new():: apiVersion + kind,
+        // Sets `spec.rules` to `rules`, a rule or an array of them (e.g., from `mixin.spec.rulesType.new`).
+        withRules(rules):: {spec+: {rules: if std.type(rules) == "array" then rules else [rules]}},
+        // Appends `rules`, a rule or an array of them, to `spec.rules`.
+        withRulesMixin(rules):: {spec+: {rules+: if std.type(rules) == "array" then rules else [rules]}},
+        // Sets `spec.tls` to `tls`, a TLS configuration or an array of them (e.g., from `mixin.spec.tlsType.new`).
+        withTls(tls):: {spec+: {tls: if std.type(tls) == "array" then tls else [tls]}},
+        // Appends `tls`, a TLS configuration or an array of them, to `spec.tls`.
+        withTlsMixin(tls):: {spec+: {tls+: if std.type(tls) == "array" then tls else [tls]}},
+        mixin:: {
+          // Standard object's metadata.
+          //
+          // @type object (ObjectMeta)
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            // Map of string keys and values that can be used to organize and categorize objects.
+            //
+            // @type map of string → string
+            labels(labels):: __metadataMixin({labels+: labels}),
+            // Name must be unique within a namespace.
+            //
+            // @type string
+            name(name):: __metadataMixin({name: name}),
+            // Namespace defines the space within which each name must be unique.
+            //
+            // @type string
+            namespace(namespace):: __metadataMixin({namespace: namespace}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          // Spec is the desired state of the Ingress.
+          //
+          // @type object (IngressSpec)
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            // DefaultBackend is the backend that should handle requests that don't match any rule.
+            //
+            // @type object (IngressBackend)
+            defaultBackend:: {
+              local __defaultBackendMixin(defaultBackend) = __specMixin({defaultBackend+: defaultBackend}),
+              // Service references a Service as a Backend.
+              //
+              // @type object (IngressServiceBackend)
+              service:: {
+                local __serviceMixin(service) = __defaultBackendMixin({service+: service}),
+                // Name is the referenced service.
+                //
+                // @type string
+                name(name):: __serviceMixin({name: name}),
+                // Port of the referenced service.
+                //
+                // @type object (ServiceBackendPort)
+                port:: {
+                  local __portMixin(port) = __serviceMixin({port+: port}),
+                  // Name is the name of the port on the Service.
+                  //
+                  // @type string
+                  name(name):: __portMixin({name: name}),
+                  // Number is the numerical port number (e.g. 80) on the Service.
+                  //
+                  // @type integer (int32)
+                  number(number):: __portMixin({number: number}),
+                },
+                portType:: hidden.networking.v1.serviceBackendPort,
+              },
+              serviceType:: hidden.networking.v1.ingressServiceBackend,
+            },
+            defaultBackendType:: hidden.networking.v1.ingressBackend,
+            // A list of host rules used to configure the Ingress.
+            //
+            // @type array of IngressRule
+            rules(rules):: if std.type(rules) == "array" then __specMixin({rules+: rules}) else __specMixin({rules: [rules]}),
+            rulesType:: hidden.networking.v1.ingressRule,
+            // TLS configuration.
+            //
+            // @type array of IngressTLS
+            tls(tls):: if std.type(tls) == "array" then __specMixin({tls+: tls}) else __specMixin({tls: [tls]}),
+            tlsType:: hidden.networking.v1.ingressTLS,
+          },
+          specType:: hidden.networking.v1.ingressSpec,
+        },
+      },
+    },
+  },
+  local hidden = {
+    meta:: {
+      v1:: {
+        local apiVersion = {apiVersion: "meta/v1"},
+        // ObjectMeta is metadata that all persisted resources must have.
+        objectMeta:: {
+          new():: {},
+          // Map of string keys and values that can be used to organize and categorize objects.
+          //
+          // @type map of string → string
+          labels(labels):: {labels+: labels},
+          // Name must be unique within a namespace.
+          //
+          // @type string
+          name(name):: {name: name},
+          // Namespace defines the space within which each name must be unique.
+          //
+          // @type string
+          namespace(namespace):: {namespace: namespace},
+          mixin:: {
+          },
+        },
+      },
+    },
+    networking:: {
+      v1:: {
+        local apiVersion = {apiVersion: "networking.k8s.io/v1"},
+        // HTTPIngressPath associates a path with a backend.
+        hTTPIngressPath:: {
+          new():: {},
+          // Path is matched against the path of an incoming request.
+          //
+          // @type string
+          path(path):: {path: path},
+          // PathType determines the interpretation of the Path matching.
+          //
+          // @type string
+          pathType(pathType):: {pathType: pathType},
+          mixin:: {
+            // Backend defines the referenced service endpoint to which the traffic will be forwarded to.
+            //
+            // @type object (IngressBackend)
+            backend:: {
+              local __backendMixin(backend) = {backend+: backend},
+              // Service references a Service as a Backend.
+              //
+              // @type object (IngressServiceBackend)
+              service:: {
+                local __serviceMixin(service) = __backendMixin({service+: service}),
+                // Name is the referenced service.
+                //
+                // @type string
+                name(name):: __serviceMixin({name: name}),
+                // Port of the referenced service.
+                //
+                // @type object (ServiceBackendPort)
+                port:: {
+                  local __portMixin(port) = __serviceMixin({port+: port}),
+                  // Name is the name of the port on the Service.
+                  //
+                  // @type string
+                  name(name):: __portMixin({name: name}),
+                  // Number is the numerical port number (e.g. 80) on the Service.
+                  //
+                  // @type integer (int32)
+                  number(number):: __portMixin({number: number}),
+                },
+                portType:: hidden.networking.v1.serviceBackendPort,
+              },
+              serviceType:: hidden.networking.v1.ingressServiceBackend,
+            },
+            backendType:: hidden.networking.v1.ingressBackend,
+          },
+        },
+        // HTTPIngressRuleValue is a list of http selectors pointing to backends.
+        hTTPIngressRuleValue:: {
+          new():: {},
+          // A collection of paths that map requests to backends.
+          //
+          // @type array of HTTPIngressPath
+          paths(paths):: if std.type(paths) == "array" then {paths+: paths} else {paths: [paths]},
+          pathsType:: hidden.networking.v1.hTTPIngressPath,
+          mixin:: {
+          },
+        },
+        // IngressBackend describes all endpoints for a given service and port.
+        ingressBackend:: {
+          new():: {},
+          mixin:: {
+            // Service references a Service as a Backend.
+            //
+            // @type object (IngressServiceBackend)
+            service:: {
+              local __serviceMixin(service) = {service+: service},
+              // Name is the referenced service.
+              //
+              // @type string
+              name(name):: __serviceMixin({name: name}),
+              // Port of the referenced service.
+              //
+              // @type object (ServiceBackendPort)
+              port:: {
+                local __portMixin(port) = __serviceMixin({port+: port}),
+                // Name is the name of the port on the Service.
+                //
+                // @type string
+                name(name):: __portMixin({name: name}),
+                // Number is the numerical port number (e.g. 80) on the Service.
+                //
+                // @type integer (int32)
+                number(number):: __portMixin({number: number}),
+              },
+              portType:: hidden.networking.v1.serviceBackendPort,
+            },
+            serviceType:: hidden.networking.v1.ingressServiceBackend,
+          },
+        },
+        // IngressRule represents the rules mapping the paths under a specified host to the related backend services.
+        ingressRule:: {
+          // Creates a rule that routes requests for `host` under `path` to port `servicePort` (a name or a number) of the service `serviceName`.
+          new(host, serviceName, servicePort, path="/"):: {
+            host: host,
+            http: {
+              paths: [{backend: {service: {name: serviceName, port: if std.type(servicePort) == "string" then {name: servicePort} else {number: servicePort}}}, path: path, pathType: "Prefix"}],
+            },
+          },
+          // Host is the fully qualified domain name of a network host.
+          //
+          // @type string
+          host(host):: {host: host},
+          mixin:: {
+            // @type object (HTTPIngressRuleValue)
+            http:: {
+              local __httpMixin(http) = {http+: http},
+              // A collection of paths that map requests to backends.
+              //
+              // @type array of HTTPIngressPath
+              paths(paths):: if std.type(paths) == "array" then __httpMixin({paths+: paths}) else __httpMixin({paths: [paths]}),
+              pathsType:: hidden.networking.v1.hTTPIngressPath,
+            },
+            httpType:: hidden.networking.v1.hTTPIngressRuleValue,
+          },
+        },
+        // IngressServiceBackend references a Kubernetes Service as a Backend.
+        ingressServiceBackend:: {
+          new():: {},
+          // Name is the referenced service.
+          //
+          // @type string
+          name(name):: {name: name},
+          mixin:: {
+            // Port of the referenced service.
+            //
+            // @type object (ServiceBackendPort)
+            port:: {
+              local __portMixin(port) = {port+: port},
+              // Name is the name of the port on the Service.
+              //
+              // @type string
+              name(name):: __portMixin({name: name}),
+              // Number is the numerical port number (e.g. 80) on the Service.
+              //
+              // @type integer (int32)
+              number(number):: __portMixin({number: number}),
+            },
+            portType:: hidden.networking.v1.serviceBackendPort,
+          },
+        },
+        // IngressSpec describes the Ingress the user wishes to exist.
+        ingressSpec:: {
+          new():: {},
+          // A list of host rules used to configure the Ingress.
+          //
+          // @type array of IngressRule
+          rules(rules):: if std.type(rules) == "array" then {rules+: rules} else {rules: [rules]},
+          rulesType:: hidden.networking.v1.ingressRule,
+          // TLS configuration.
+          //
+          // @type array of IngressTLS
+          tls(tls):: if std.type(tls) == "array" then {tls+: tls} else {tls: [tls]},
+          tlsType:: hidden.networking.v1.ingressTLS,
+          mixin:: {
+            // DefaultBackend is the backend that should handle requests that don't match any rule.
+            //
+            // @type object (IngressBackend)
+            defaultBackend:: {
+              local __defaultBackendMixin(defaultBackend) = {defaultBackend+: defaultBackend},
+              // Service references a Service as a Backend.
+              //
+              // @type object (IngressServiceBackend)
+              service:: {
+                local __serviceMixin(service) = __defaultBackendMixin({service+: service}),
+                // Name is the referenced service.
+                //
+                // @type string
+                name(name):: __serviceMixin({name: name}),
+                // Port of the referenced service.
+                //
+                // @type object (ServiceBackendPort)
+                port:: {
+                  local __portMixin(port) = __serviceMixin({port+: port}),
+                  // Name is the name of the port on the Service.
+                  //
+                  // @type string
+                  name(name):: __portMixin({name: name}),
+                  // Number is the numerical port number (e.g. 80) on the Service.
+                  //
+                  // @type integer (int32)
+                  number(number):: __portMixin({number: number}),
+                },
+                portType:: hidden.networking.v1.serviceBackendPort,
+              },
+              serviceType:: hidden.networking.v1.ingressServiceBackend,
+            },
+            defaultBackendType:: hidden.networking.v1.ingressBackend,
+          },
+        },
+        // IngressTLS describes the transport layer security associated with an Ingress.
+        ingressTLS:: {
+          // Creates a TLS configuration that terminates TLS for `hosts` (a host, or an array of them) with the certificate in the secret `secretName`.
+          new(hosts, secretName):: {
+            hosts: if std.type(hosts) == "array" then hosts else [hosts],
+            secretName: secretName,
+          },
+          // Hosts are a list of hosts included in the TLS certificate.
+          //
+          // @type array of string
+          hosts(hosts):: if std.type(hosts) == "array" then {hosts+: hosts} else {hosts: [hosts]},
+          // SecretName is the name of the secret used to terminate TLS traffic on port 443.
+          //
+          // @type string
+          secretName(secretName):: {secretName: secretName},
+          mixin:: {
+          },
+        },
+        // ServiceBackendPort is the service port being referenced.
+        serviceBackendPort:: {
+          new():: {},
+          // Name is the name of the port on the Service.
+          //
+          // @type string
+          name(name):: {name: name},
+          // Number is the numerical port number (e.g. 80) on the Service.
+          //
+          // @type integer (int32)
+          number(number):: {number: number},
+          mixin:: {
+          },
+        },
+      },
+    },
+  },
+}
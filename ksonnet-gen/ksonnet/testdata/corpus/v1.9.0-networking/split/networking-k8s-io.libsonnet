@@ -0,0 +1,101 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.9.0
+// The `networking` namespace of `k8s.libsonnet`, which imports this file.
+
+local hidden = import "hidden.libsonnet";
+
+{
+  v1:: {
+    local apiVersion = {apiVersion: "networking.k8s.io/v1"},
+    // Ingress is a collection of rules that allow inbound connections to reach the endpoints defined by a backend.
+    ingress:: {
+      // Example, where `k` is the library:
+      //
+      //   local ingress = k.networking.v1.ingress;
+      //   ingress.new()
+      //   + ingress.mixin.spec.rules(rules)
+      //   + ingress.mixin.spec.tls(tls)
+      local kind = {kind: "Ingress"},
+      new():: apiVersion + kind,
+      // Sets `spec.rules` to `rules`, a rule or an array of them (e.g., from `mixin.spec.rulesType.new`).
+      withRules(rules):: {spec+: {rules: if std.type(rules) == "array" then rules else [rules]}},
+      // Appends `rules`, a rule or an array of them, to `spec.rules`.
+      withRulesMixin(rules):: {spec+: {rules+: if std.type(rules) == "array" then rules else [rules]}},
+      // Sets `spec.tls` to `tls`, a TLS configuration or an array of them (e.g., from `mixin.spec.tlsType.new`).
+      withTls(tls):: {spec+: {tls: if std.type(tls) == "array" then tls else [tls]}},
+      // Appends `tls`, a TLS configuration or an array of them, to `spec.tls`.
+      withTlsMixin(tls):: {spec+: {tls+: if std.type(tls) == "array" then tls else [tls]}},
+      mixin:: {
+        // Standard object's metadata.
+        //
+        // @type object (ObjectMeta)
+        metadata:: {
+          local __metadataMixin(metadata) = {metadata+: metadata},
+          // Map of string keys and values that can be used to organize and categorize objects.
+          //
+          // @type map of string → string
+          labels(labels):: __metadataMixin({labels+: labels}),
+          // Name must be unique within a namespace.
+          //
+          // @type string
+          name(name):: __metadataMixin({name: name}),
+          // Namespace defines the space within which each name must be unique.
+          //
+          // @type string
+          namespace(namespace):: __metadataMixin({namespace: namespace}),
+        },
+        metadataType:: hidden.meta.v1.objectMeta,
+        // Spec is the desired state of the Ingress.
+        //
+        // @type object (IngressSpec)
+        spec:: {
+          local __specMixin(spec) = {spec+: spec},
+          // DefaultBackend is the backend that should handle requests that don't match any rule.
+          //
+          // @type object (IngressBackend)
+          defaultBackend:: {
+            local __defaultBackendMixin(defaultBackend) = __specMixin({defaultBackend+: defaultBackend}),
+            // Service references a Service as a Backend.
+            //
+            // @type object (IngressServiceBackend)
+            service:: {
+              local __serviceMixin(service) = __defaultBackendMixin({service+: service}),
+              // Name is the referenced service.
+              //
+              // @type string
+              name(name):: __serviceMixin({name: name}),
+              // Port of the referenced service.
+              //
+              // @type object (ServiceBackendPort)
+              port:: {
+                local __portMixin(port) = __serviceMixin({port+: port}),
+                // Name is the name of the port on the Service.
+                //
+                // @type string
+                name(name):: __portMixin({name: name}),
+                // Number is the numerical port number (e.g. 80) on the Service.
+                //
+                // @type integer (int32)
+                number(number):: __portMixin({number: number}),
+              },
+              portType:: hidden.networking.v1.serviceBackendPort,
+            },
+            serviceType:: hidden.networking.v1.ingressServiceBackend,
+          },
+          defaultBackendType:: hidden.networking.v1.ingressBackend,
+          // A list of host rules used to configure the Ingress.
+          //
+          // @type array of IngressRule
+          rules(rules):: if std.type(rules) == "array" then __specMixin({rules+: rules}) else __specMixin({rules: [rules]}),
+          rulesType:: hidden.networking.v1.ingressRule,
+          // TLS configuration.
+          //
+          // @type array of IngressTLS
+          tls(tls):: if std.type(tls) == "array" then __specMixin({tls+: tls}) else __specMixin({tls: [tls]}),
+          tlsType:: hidden.networking.v1.ingressTLS,
+        },
+        specType:: hidden.networking.v1.ingressSpec,
+      },
+    },
+  },
+}
@@ -0,0 +1,219 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.9.0
+// API versions: apps/v1beta1, apps/v1beta2, v1
+// SHA of ksonnet-lib HEAD: [SHA]
+// SHA of Kubernetes HEAD OpenAPI spec is generated from: [SHA]
+
+{
+  apps:: {
+    v1beta1:: {
+      local apiVersion = {apiVersion: "apps/v1beta1"},
+      deployment:: {
+        local kind = {kind: "Deployment"},
+        new():: apiVersion + kind,
+        mixin:: {
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            labels(labels):: __metadataMixin({labels+: labels}),
+            name(name):: __metadataMixin({name: name}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            replicas(replicas):: __specMixin({replicas: replicas}),
+            template:: {
+              local __templateMixin(template) = __specMixin({template+: template}),
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                labels(labels):: __metadataMixin({labels+: labels}),
+                name(name):: __metadataMixin({name: name}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+          specType:: hidden.apps.v1beta1.deploymentSpec,
+        },
+      },
+    },
+    v1beta2:: {
+      local apiVersion = {apiVersion: "apps/v1beta2"},
+      deployment:: {
+        local kind = {kind: "Deployment"},
+        new():: apiVersion + kind,
+        mixin:: {
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            labels(labels):: __metadataMixin({labels+: labels}),
+            name(name):: __metadataMixin({name: name}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            replicas(replicas):: __specMixin({replicas: replicas}),
+            template:: {
+              local __templateMixin(template) = __specMixin({template+: template}),
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                labels(labels):: __metadataMixin({labels+: labels}),
+                name(name):: __metadataMixin({name: name}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+          specType:: hidden.apps.v1beta2.deploymentSpec,
+        },
+      },
+      statefulSet:: {
+        local kind = {kind: "StatefulSet"},
+        new():: apiVersion + kind,
+        mixin:: {
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            labels(labels):: __metadataMixin({labels+: labels}),
+            name(name):: __metadataMixin({name: name}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+        },
+      },
+    },
+  },
+  core:: {
+    v1:: {
+      local apiVersion = {apiVersion: "v1"},
+      pod:: {
+        local kind = {kind: "Pod"},
+        new():: apiVersion + kind,
+        mixin:: {
+          metadata:: {
+            local __metadataMixin(metadata) = {metadata+: metadata},
+            labels(labels):: __metadataMixin({labels+: labels}),
+            name(name):: __metadataMixin({name: name}),
+          },
+          metadataType:: hidden.meta.v1.objectMeta,
+          spec:: {
+            local __specMixin(spec) = {spec+: spec},
+            containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+            containersType:: hidden.core.v1.container,
+          },
+          specType:: hidden.core.v1.podSpec,
+        },
+      },
+    },
+  },
+  local hidden = {
+    apps:: {
+      v1beta1:: {
+        local apiVersion = {apiVersion: "apps/v1beta1"},
+        deploymentSpec:: {
+          new():: {},
+          replicas(replicas):: {replicas: replicas},
+          mixin:: {
+            template:: {
+              local __templateMixin(template) = {template+: template},
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                labels(labels):: __metadataMixin({labels+: labels}),
+                name(name):: __metadataMixin({name: name}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+        },
+      },
+      v1beta2:: {
+        local apiVersion = {apiVersion: "apps/v1beta2"},
+        deploymentSpec:: {
+          new():: {},
+          replicas(replicas):: {replicas: replicas},
+          mixin:: {
+            template:: {
+              local __templateMixin(template) = {template+: template},
+              metadata:: {
+                local __metadataMixin(metadata) = __templateMixin({metadata+: metadata}),
+                labels(labels):: __metadataMixin({labels+: labels}),
+                name(name):: __metadataMixin({name: name}),
+              },
+              metadataType:: hidden.meta.v1.objectMeta,
+              spec:: {
+                local __specMixin(spec) = __templateMixin({spec+: spec}),
+                containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+                containersType:: hidden.core.v1.container,
+              },
+              specType:: hidden.core.v1.podSpec,
+            },
+            templateType:: hidden.core.v1.podTemplateSpec,
+          },
+        },
+      },
+    },
+    core:: {
+      v1:: {
+        local apiVersion = {apiVersion: "v1"},
+        container:: {
+          new():: {},
+          image(image):: {image: image},
+          name(name):: {name: name},
+          mixin:: {
+          },
+        },
+        podSpec:: {
+          new():: {},
+          containers(containers):: if std.type(containers) == "array" then {containers+: containers} else {containers: [containers]},
+          containersType:: hidden.core.v1.container,
+          mixin:: {
+          },
+        },
+        podTemplateSpec:: {
+          new():: {},
+          mixin:: {
+            metadata:: {
+              local __metadataMixin(metadata) = {metadata+: metadata},
+              labels(labels):: __metadataMixin({labels+: labels}),
+              name(name):: __metadataMixin({name: name}),
+            },
+            metadataType:: hidden.meta.v1.objectMeta,
+            spec:: {
+              local __specMixin(spec) = {spec+: spec},
+              containers(containers):: if std.type(containers) == "array" then __specMixin({containers+: containers}) else __specMixin({containers: [containers]}),
+              containersType:: hidden.core.v1.container,
+            },
+            specType:: hidden.core.v1.podSpec,
+          },
+        },
+      },
+    },
+    meta:: {
+      v1:: {
+        local apiVersion = {apiVersion: "meta/v1"},
+        objectMeta:: {
+          new():: {},
+          labels(labels):: {labels+: labels},
+          name(name):: {name: name},
+          mixin:: {
+          },
+        },
+      },
+    },
+  },
+}
@@ -0,0 +1,39 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.9.0
+
+{
+  labels:: {
+    appName:: "app.kubernetes.io/name",
+    appInstance:: "app.kubernetes.io/instance",
+    appVersion:: "app.kubernetes.io/version",
+    appComponent:: "app.kubernetes.io/component",
+    appPartOf:: "app.kubernetes.io/part-of",
+    appManagedBy:: "app.kubernetes.io/managed-by",
+    hostname:: "kubernetes.io/hostname",
+    betaOs:: "beta.kubernetes.io/os",
+    betaArch:: "beta.kubernetes.io/arch",
+    betaInstanceType:: "beta.kubernetes.io/instance-type",
+    failureDomainZone:: "failure-domain.beta.kubernetes.io/zone",
+    failureDomainRegion:: "failure-domain.beta.kubernetes.io/region",
+  },
+  annotations:: {
+    lastAppliedConfiguration:: "kubectl.kubernetes.io/last-applied-configuration",
+    changeCause:: "kubernetes.io/change-cause",
+    deploymentRevision:: "deployment.kubernetes.io/revision",
+    criticalPod:: "scheduler.alpha.kubernetes.io/critical-pod",
+    isDefaultStorageClass:: "storageclass.kubernetes.io/is-default-class",
+  },
+
+  recommendedLabels(name, instance=null, version=null, component=null, partOf=null, managedBy=null):: std.prune({
+    "app.kubernetes.io/name": name,
+    "app.kubernetes.io/instance": instance,
+    "app.kubernetes.io/version": version,
+    "app.kubernetes.io/component": component,
+    "app.kubernetes.io/part-of": partOf,
+    "app.kubernetes.io/managed-by": managedBy,
+  }),
+  selectorLabels(name, instance=null):: std.prune({
+    "app.kubernetes.io/name": name,
+    "app.kubernetes.io/instance": instance,
+  }),
+}
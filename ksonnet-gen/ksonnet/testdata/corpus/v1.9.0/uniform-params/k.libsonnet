@@ -0,0 +1,12 @@
+// AUTOGENERATED from the Kubernetes OpenAPI specification. DO NOT MODIFY.
+// Kubernetes version: v1.9.0
+
+local k8s = import "k8s.libsonnet";
+
+k8s + {
+  // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `Deployment`; use `apps/v1` instead.
+  deployment:: k8s.apps.v1beta2.deployment,
+  pod:: k8s.core.v1.pod,
+  // REMOVED IN v1.16: Kubernetes v1.16 stops serving `apps/v1beta2` `StatefulSet`; use `apps/v1` instead.
+  statefulSet:: k8s.apps.v1beta2.statefulSet,
+}
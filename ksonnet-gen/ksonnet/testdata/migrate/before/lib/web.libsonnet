@@ -0,0 +1,12 @@
+local k8s = import "k8s.libsonnet";
+local service = k8s.core.v1.service;
+
+{
+  service:
+    service.new() +
+    k8s.core.v1.service.mixin.spec.clusterIPs(["10.0.0.1"]) +  # Not k8s.core.v1.service.clusterIPs.
+    k8s.core.v1.service.mixin.spec.clusterIp("10.0.0.1"),
+  roleName:: "k8s.rbac.v1.role",
+  roles(version):: k8s.rbac[version],
+  readers:: k8s.rbac.v1.role.mixin.metadata.name("readers") + k8s.rbac.v1.role.withAggregation(true),
+}
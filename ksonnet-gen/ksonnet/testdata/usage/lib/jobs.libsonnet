@@ -0,0 +1,12 @@
+local kube = import "../vendor/k8s.libsonnet";
+local job = kube.batch.v1.job;
+
+{
+  # Runs a migration once, before the rollout.
+  migration(name, image):: job.new() + job.mixin.metadata.name(name + "-migrate") + {
+    spec+: {template+: {spec+: {
+      containers: [{name: "migrate", image: image, args: ["k8s.io/migrate", "--all"]}],
+      restartPolicy: "Never",
+    }}},
+  },
+}
@@ -0,0 +1,25 @@
+// A web server: a deployment and the service in front of it.
+//
+// NOTE: Uses `k.libsonnet`, so kinds can be referred to by their
+// flattened aliases, e.g., `k.deployment` rather than
+// `k.apps.v1beta1.deployment`.
+local k = import "../vendor/k.libsonnet";
+
+{
+  new(name, image, port=80):: {
+    local labels = {app: name},
+
+    deployment:
+      k.deployment.new(labels) +
+      k.deployment.mixin.metadata.name(name) +
+      k.deployment.mixin.spec.template.spec.containers([
+        {name: name, image: image, ports: [{containerPort: port}]},
+      ]),
+
+    service:
+      k.service.new() +
+      k.service.mixin.metadata.name(name) +
+      k.service.mixin.spec.selector(labels) +
+      k.service.mixin.spec.ports({port: port, targetPort: port}),
+  },
+}
@@ -0,0 +1,98 @@
+package ksonnet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+)
+
+// assertOneOfName is the name of the helper that checks an object
+// against the discriminated unions of its type; see `emitUnionHelpers`.
+const assertOneOfName = "assertOneOf"
+
+// oneOfFunction is the local function of `assertOneOf` that checks one
+// union: at most one of `members` is set, and the `discriminator`
+// field, if the union has one and it's set, holds the value `values`
+// maps that member to.
+var oneOfFunction = []string{
+	"local oneOf(members, discriminator, values) =",
+	"  local set = [field for field in members if std.objectHas(object, field)];",
+	`  assert std.length(set) <= 1 : "At most one of " + std.join(", ", members) + " may be set, got " + std.join(", ", set);`,
+	`  assert discriminator == null || std.length(set) == 0 || !std.objectHas(object, discriminator) || object[discriminator] == values[set[0]] : "'" + discriminator + "' must be '" + values[set[0]] + "', since '" + set[0] + "' is set, got " + std.toString(object[discriminator]);`,
+	"  true;",
+}
+
+// `emitUnionHelpers` emits `assertOneOf(object)` for API objects whose
+// definitions declare discriminated unions (`x-kubernetes-unions`; see
+// `kubespec.Union`), which asserts that `object` sets at most one
+// field of each union, and that each discriminator that's set names
+// the field that is, and returns `object`. The setters of the members
+// set the discriminator themselves; see `property.fieldObject`.
+func (ao *apiObject) emitUnionHelpers(m *indentWriter, path string) {
+	checks, descriptions := []string{}, []string{}
+	for _, union := range ao.unions {
+		if len(union.Fields) == 0 {
+			continue
+		}
+		members, values, quoted := []interface{}{}, map[string]interface{}{}, []string{}
+		for _, field := range union.SortedFields() {
+			members = append(members, string(field))
+			values[string(field)] = union.Fields[field]
+			quoted = append(quoted, fmt.Sprintf("`%s`", field))
+		}
+		discriminator := "null"
+		description := joinAlternatives(quoted)
+		if union.Discriminator != "" {
+			discriminator = fmt.Sprintf("%q", union.Discriminator)
+			description += fmt.Sprintf(", named by `%s`", union.Discriminator)
+		}
+
+		membersText, err := jsonnet.Literal(members)
+		if err != nil {
+			log.Panicf("Could not write the members of a union of '%s':\n%v", ao.name, err)
+		}
+		valuesText, err := jsonnet.Literal(values)
+		if err != nil {
+			log.Panicf("Could not write the discriminator values of a union of '%s':\n%v", ao.name, err)
+		}
+		checks = append(checks, fmt.Sprintf(
+			"assert oneOf(%s, %s, %s);", membersText, discriminator, valuesText))
+		descriptions = append(descriptions, description)
+	}
+	if len(checks) == 0 {
+		return
+	}
+	if dm, ok := ao.properties[assertOneOfName]; ok {
+		log.Panicf(
+			"Attempted to create union helper, but '%s' property already existed at '%s'",
+			assertOneOfName, dm.path)
+	}
+
+	m.writeLine(fmt.Sprintf(
+		"// Asserts that `object` sets at most one field of each union of this type (%s), and that the discriminator, if it's set, names the field that is. Returns `object`.",
+		strings.Join(descriptions, "; ")))
+	m.writeLine(fmt.Sprintf("%s(object)::", assertOneOfName))
+	m.indent()
+	for _, line := range oneOfFunction {
+		m.writeLine(line)
+	}
+	for _, check := range checks {
+		m.writeLine(check)
+	}
+	m.writeLine("object,")
+	m.dedent()
+	ao.root().index.add(fmt.Sprintf("%s.%s", path, assertOneOfName), SymbolFunction, "object")
+}
+
+// joinAlternatives joins `items` for a sentence, e.g., "a, b, or c".
+func joinAlternatives(items []string) string {
+	switch len(items) {
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " or " + items[1]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + ", or " + items[len(items)-1]
+}
@@ -0,0 +1,51 @@
+package ksonnet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnions(t *testing.T) {
+	library := emitTestSpec(t, "testdata/unions.json", Options{})
+	expected := []string{
+		// The setters of the members of a union with a discriminator set
+		// it, whether they're plain setters, array setters, or mixin
+		// namespaces, and in the kinds that refer to the type as well as
+		// in the type itself.
+		`tcpPort(tcpPort):: __specMixin({tcpPort: tcpPort, type: "TCPSocket"}),`,
+		`tcpPort(tcpPort):: {tcpPort: tcpPort, type: "TCPSocket"},`,
+		`exec(exec):: if std.type(exec) == "array" then __specMixin({exec+: exec, type: "Exec"}) else __specMixin({exec: [exec], type: "Exec"}),`,
+		`local __httpGetMixin(httpGet) = __specMixin({httpGet+: httpGet, type: "HTTPGet"}),`,
+		`local __httpGetMixin(httpGet) = {httpGet+: httpGet, type: "HTTPGet"},`,
+		// The discriminator, and the members of a union without one, are
+		// set as usual.
+		"type(type):: __specMixin({type: type}),",
+		"quiet(quiet=true):: __specMixin({quiet: quiet}),",
+		// The union's type gets `assertOneOf`, which checks every union.
+		"// Asserts that `object` sets at most one field of each union of this type (`exec`, `httpGet`, or `tcpPort`, named by `type`; `quiet` or `verbose`), and that the discriminator, if it's set, names the field that is. Returns `object`.\n          assertOneOf(object)::\n",
+		`assert oneOf(["exec", "httpGet", "tcpPort"], "type", {exec: "Exec", httpGet: "HTTPGet", tcpPort: "TCPSocket"});`,
+		`assert oneOf(["quiet", "verbose"], null, {quiet: "Quiet", verbose: "Verbose"});` + "\n            object,\n",
+	}
+	for _, text := range expected {
+		if !strings.Contains(library, text) {
+			t.Errorf("Expected library to contain '%s':\n%s", text, library)
+		}
+	}
+	if strings.Count(library, "assertOneOf(object)::") != 1 {
+		t.Errorf("Expected only the union's type to get 'assertOneOf':\n%s", library)
+	}
+
+	index, err := BuildSymbolIndex(loadTestSpec(t, "testdata/unions.json"), Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	if symbol, ok := index.byPath()["hidden.stable.v1.handler.assertOneOf"]; !ok || symbol.Kind != SymbolFunction {
+		t.Errorf("Expected a symbol for 'assertOneOf', got %#v", symbol)
+	}
+
+	// Definitions without `x-kubernetes-unions` are unchanged.
+	library = emitTestSpec(t, "testdata/swagger.json", Options{})
+	if strings.Contains(library, "assertOneOf") || strings.Contains(library, `, type: "`) {
+		t.Errorf("Expected no union helpers without 'x-kubernetes-unions':\n%s", library)
+	}
+}
@@ -0,0 +1,239 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// UsageScan is what `ScanUsage` found in a tree of Jsonnet files: the
+// top-level kinds they use, and the references it couldn't resolve.
+type UsageScan struct {
+	// Definitions are the definitions of the kinds that are used,
+	// sorted by name.
+	Definitions []kubespec.DefinitionName
+
+	// Unresolved are references that don't name a kind in the library
+	// (e.g., `k8s.apps.v1.deploymnet`, or a local variable that happens
+	// to be called `k`).
+	Unresolved []*UsageReference
+
+	// Computed are references whose kind can't be known without
+	// evaluating the Jsonnet, e.g., `k8s.apps[version]`, or
+	// `local apps = k8s.apps.v1beta1;`. Any of them could refer to any
+	// kind, so pruning is only safe if there are none.
+	Computed []*UsageReference
+}
+
+// UsageReference is a reference into the library at some line of a
+// Jsonnet file, e.g., `k8s.apps.v1beta1.deployment.new`.
+type UsageReference struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func (ur *UsageReference) String() string {
+	return fmt.Sprintf("%s:%d: %s", ur.File, ur.Line, ur.Text)
+}
+
+var (
+	// referencePattern matches a variable (e.g., `k8s`) and the fields
+	// accessed on it (e.g., `.apps.v1beta1.deployment`), followed by
+	// `[` if the next field is computed.
+	referencePattern = regexp.MustCompile(
+		`(?:^|[^A-Za-z0-9_.$])([A-Za-z_][A-Za-z0-9_]*)((?:\.[A-Za-z_][A-Za-z0-9_]*)*)(\s*\[)?`)
+
+	// importPattern matches a local bound to the library or its
+	// aliases, e.g., `local kube = import "../vendor/k.libsonnet"`.
+	importPattern = regexp.MustCompile(
+		`\blocal\s+([A-Za-z_][A-Za-z0-9_]*)\s*=\s*import\s+["'](?:[^"']*/)?(k8s|k)\.libsonnet["']`)
+)
+
+// ScanUsage finds the top-level kinds of the library `Emit` generates
+// that are referred to by `files`, which map the path of each Jsonnet
+// file to its text. References are found by matching tokens rather
+// than by parsing: `k8s.<group>.<version>.<kind>` (the library, as
+// imported from `k8s.libsonnet`), and `k.<alias>` (the flattened
+// aliases of `k.libsonnet`, which also has the rest of the library),
+// along with any other local bound to an import of either file.
+// Comments and string literals are skipped.
+func ScanUsage(
+	spec *kubespec.APISpec, opts Options, files map[string][]byte,
+) (*UsageScan, error) {
+	root := newRoot(spec, opts)
+
+	// Emitting populates the symbol index, which locates the kinds.
+	m := newIndentWriter()
+	if err := root.emitContext(context.Background(), m); err != nil {
+		return nil, err
+	}
+	if _, err := m.bytes(); err != nil {
+		return nil, err
+	}
+	if err := root.customizationErrors(); err != nil {
+		return nil, err
+	}
+	kinds := map[string]kubespec.DefinitionName{}
+	namespaces := map[string]bool{}
+	for _, kind := range root.index.Kinds {
+		kinds[kind.Path] = ""
+		for path := kind.Path; strings.Contains(path, "."); {
+			path = path[:strings.LastIndex(path, ".")]
+			namespaces[path] = true
+		}
+	}
+	for _, group := range root.groups {
+		for _, va := range group.versionedAPIs {
+			for _, ao := range va.apiObjects {
+				if _, ok := kinds[ao.path()]; ok {
+					kinds[ao.path()] = ao.parsedName.Unparse()
+				}
+			}
+		}
+	}
+	aliases := map[string]string{}
+	for _, alias := range root.aliases() {
+		aliases[alias.name] = alias.object.path()
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scan := &UsageScan{}
+	used := map[kubespec.DefinitionName]bool{}
+	for _, name := range names {
+		text := string(files[name])
+		bindings := map[string]bool{"k8s": false, "k": true} // Whether it has the aliases.
+		for _, match := range importPattern.FindAllStringSubmatch(text, -1) {
+			bindings[match[1]] = match[2] == "k"
+		}
+
+		code := stripCommentsAndStrings(text)
+		for _, match := range referencePattern.FindAllStringSubmatchIndex(code, -1) {
+			variable := code[match[2]:match[3]]
+			hasAliases, ok := bindings[variable]
+			if !ok {
+				continue
+			}
+			fields := []string{}
+			if match[4] != match[5] {
+				fields = strings.Split(code[match[4]+1:match[5]], ".")
+			}
+			computed := match[6] != -1
+
+			ref := &UsageReference{
+				File: name,
+				Line: strings.Count(code[:match[2]], "\n") + 1,
+				Text: strings.TrimSpace(code[match[2]:match[1]]),
+			}
+			if len(fields) == 0 && !computed {
+				// E.g., the binding itself, or `k8s + {...}`.
+				continue
+			}
+
+			path := strings.Join(fields, ".")
+			if hasAliases && len(fields) > 0 {
+				if target, ok := aliases[fields[0]]; ok {
+					path = strings.Join(append([]string{target}, fields[1:]...), ".")
+				}
+			}
+			if definition, ok := kindAt(kinds, path); ok {
+				used[definition] = true
+			} else if computed || path == "" || namespaces[path] {
+				scan.Computed = append(scan.Computed, ref)
+			} else {
+				scan.Unresolved = append(scan.Unresolved, ref)
+			}
+		}
+	}
+
+	for definition := range used {
+		scan.Definitions = append(scan.Definitions, definition)
+	}
+	sort.Slice(scan.Definitions, func(i, j int) bool {
+		return scan.Definitions[i] < scan.Definitions[j]
+	})
+	return scan, nil
+}
+
+// `kindAt` returns the definition of the kind whose namespace is
+// `path`, or contains it (e.g., `apps.v1beta1.deployment.new`).
+func kindAt(
+	kinds map[string]kubespec.DefinitionName, path string,
+) (kubespec.DefinitionName, bool) {
+	for prefix := path; prefix != ""; {
+		if definition, ok := kinds[prefix]; ok {
+			return definition, true
+		}
+		end := strings.LastIndex(prefix, ".")
+		if end == -1 {
+			break
+		}
+		prefix = prefix[:end]
+	}
+	return "", false
+}
+
+// Prune returns `spec` restricted to the used kinds and the
+// definitions they depend on, and whether it was restricted. If any
+// references were computed, it returns `spec` as is, since pruning
+// might remove kinds that are used, unless `force` is set.
+func (scan *UsageScan) Prune(spec *kubespec.APISpec, force bool) (*kubespec.APISpec, bool) {
+	if len(scan.Computed) > 0 && !force {
+		return spec, false
+	}
+	used := map[kubespec.DefinitionName]bool{}
+	for _, definition := range scan.Definitions {
+		used[definition] = true
+	}
+	return spec.Filter(func(name kubespec.DefinitionName, _ *kubespec.SchemaDefinition) bool {
+		return used[name]
+	}), true
+}
+
+// `stripCommentsAndStrings` replaces the comments and string literals
+// in Jsonnet code with spaces, keeping newlines, so that the offsets
+// of the rest of the code don't change.
+func stripCommentsAndStrings(code string) string {
+	stripped := []byte(code)
+	blank := func(start, end int) {
+		for i := start; i < end && i < len(stripped); i++ {
+			if stripped[i] != '\n' {
+				stripped[i] = ' '
+			}
+		}
+	}
+
+	s := &scanner{text: code}
+	for !s.done() {
+		start := s.pos
+		switch {
+		case s.hasPrefix("//") || s.hasPrefix("#") || s.hasPrefix("/*"):
+			s.skipSpace()
+			blank(start, s.pos)
+		case s.hasPrefix("|||"):
+			if end := strings.Index(code[s.pos+3:], "|||"); end != -1 {
+				s.pos += end + 6
+			} else {
+				s.pos = len(code)
+			}
+			blank(start, s.pos)
+		case s.text[s.pos] == '"' || s.text[s.pos] == '\'':
+			if err := s.skipString(); err != nil {
+				s.pos = len(code)
+			}
+			blank(start, s.pos)
+		default:
+			s.pos++
+		}
+	}
+	return string(stripped)
+}
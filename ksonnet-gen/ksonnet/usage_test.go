@@ -0,0 +1,122 @@
+package ksonnet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func loadUsageFiles(t *testing.T, dir string) map[string][]byte {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext != ".jsonnet" && ext != ".libsonnet" {
+			return nil
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = text
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read '%s':\n%v", dir, err)
+	}
+	return files
+}
+
+func referenceStrings(refs []*UsageReference) []string {
+	strs := []string{}
+	for _, ref := range refs {
+		strs = append(strs, ref.String())
+	}
+	return strs
+}
+
+func TestScanUsage(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/swagger.json")
+	files := loadUsageFiles(t, "testdata/usage")
+
+	scan, err := ScanUsage(spec, Options{}, files)
+	if err != nil {
+		t.Fatalf("Failed to scan usage:\n%v", err)
+	}
+
+	// Kinds mentioned only in comments or strings aren't used.
+	expected := []kubespec.DefinitionName{
+		"io.k8s.kubernetes.pkg.api.v1.Namespace",
+		"io.k8s.kubernetes.pkg.api.v1.Service",
+		"io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment",
+		"io.k8s.kubernetes.pkg.apis.batch.v1.Job",
+	}
+	if !reflect.DeepEqual(scan.Definitions, expected) {
+		t.Errorf("Expected definitions %v, got %v", expected, scan.Definitions)
+	}
+
+	unresolved := []string{
+		"environments/prod/main.jsonnet:15: k.name",
+		"environments/staging/main.jsonnet:11: k8s.core.v1.resourceQuota.new",
+	}
+	if actual := referenceStrings(scan.Unresolved); !reflect.DeepEqual(actual, unresolved) {
+		t.Errorf("Expected unresolved references %v, got %v", unresolved, actual)
+	}
+	computed := []string{"environments/staging/main.jsonnet:6: k8s.apps["}
+	if actual := referenceStrings(scan.Computed); !reflect.DeepEqual(actual, computed) {
+		t.Errorf("Expected computed references %v, got %v", computed, actual)
+	}
+
+	// Computed references could be to any kind, so they prevent pruning
+	// unless it's forced.
+	if pruned, ok := scan.Prune(spec, false); ok || pruned != spec {
+		t.Errorf("Expected spec with computed references not to be pruned")
+	}
+	pruned, ok := scan.Prune(spec, true)
+	if !ok {
+		t.Fatalf("Expected forced prune to succeed")
+	}
+	for _, name := range expected {
+		if _, ok := pruned.Definitions[name]; !ok {
+			t.Errorf("Expected pruned spec to keep used definition '%s'", name)
+		}
+	}
+	if _, ok := pruned.Definitions["io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"]; !ok {
+		t.Errorf("Expected pruned spec to keep the dependencies of used definitions")
+	}
+	for _, name := range []kubespec.DefinitionName{
+		"io.k8s.kubernetes.pkg.api.v1.List",
+		"io.k8s.kubernetes.pkg.apis.batch.v2alpha1.CronJob",
+	} {
+		if _, ok := pruned.Definitions[name]; ok {
+			t.Errorf("Expected pruned spec to drop unused definition '%s'", name)
+		}
+	}
+
+	// Without the computed reference, pruning is safe.
+	delete(files, "environments/staging/main.jsonnet")
+	scan, err = ScanUsage(spec, Options{}, files)
+	if err != nil {
+		t.Fatalf("Failed to scan usage:\n%v", err)
+	}
+	if len(scan.Computed) != 0 {
+		t.Errorf("Expected no computed references, got %v", referenceStrings(scan.Computed))
+	}
+	if _, ok := scan.Prune(spec, false); !ok {
+		t.Errorf("Expected spec without computed references to be pruned")
+	}
+}
+
+func TestStripCommentsAndStrings(t *testing.T) {
+	code := "local a = \"k8s.x\"; // k8s.y\n# k8s.z\n/* k8s\n.w */ 'it\\'s' + |||\n  k8s.v\n||| + k8s.u"
+	expected := "local a =        ;         \n       \n      \n              +    \n       \n    + k8s.u"
+	if actual := stripCommentsAndStrings(code); actual != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, actual)
+	}
+}
@@ -0,0 +1,133 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exampleSetters is how many setters an example calls when its kind
+// has no fields of its own in `kubeversion.Data.ExampleFields`.
+const exampleSetters = 2
+
+// `emitFieldsWithExample` emits the fields of the namespace of a
+// top-level API object, whose path is `path`, as `emitFields` does,
+// headed by a comment with an example of building the object (see
+// `usageExample`). The fields are emitted first, to a fork of `m`, so
+// that the example is made of the symbols they're indexed as, and so
+// can't call what the namespace doesn't have.
+func (ao *apiObject) emitFieldsWithExample(m *indentWriter, path string) {
+	index := ao.root().index
+	start := len(index.Symbols)
+	fields := m.fork()
+	ao.emitFields(fields, path)
+
+	if example := ao.usageExample(path, index.Symbols[start:]); len(example) > 0 {
+		m.writeLine("// Example, where `k` is the library:")
+		m.writeLine("//")
+		for _, line := range example {
+			m.writeLine("//   " + line)
+		}
+	}
+	m.join(fields)
+}
+
+// `usageExample` returns the lines of an example of building the
+// top-level API object whose path is `path`, from `symbols`, those of
+// its namespace: it calls the constructor, and then the setters of the
+// fields `kubeversion.Data.ExampleFields` has for the kind, or, if it
+// has none the namespace has setters for, the first `exampleSetters`
+// setters the namespace has. Each function is passed the names of its
+// parameters (leaving out those with defaults) as placeholders, e.g.,
+//
+//	local deployment = k.apps.v1.deployment;
+//	deployment.new(labels)
+//	+ deployment.mixin.spec.replicas(replicas)
+//
+// It returns nil if the namespace has no constructor.
+func (ao *apiObject) usageExample(path string, symbols []*Symbol) []string {
+	byPath := map[string]*Symbol{}
+	for _, symbol := range symbols {
+		if _, ok := byPath[symbol.Path]; !ok {
+			byPath[symbol.Path] = symbol
+		}
+	}
+	constructor, ok := byPath[fmt.Sprintf("%s.%s", path, constructorName)]
+	if !ok || constructor.Kind != SymbolFunction {
+		return nil
+	}
+
+	// The setters, by the dotted path of the field they set, in the
+	// order they're emitted. Variants of a setter (e.g., the
+	// `Stringified` ones) follow it, so the first of a field's is kept.
+	setters, byField := []*Symbol{}, map[string]*Symbol{}
+	for _, symbol := range symbols {
+		if symbol.Kind != SymbolFunction || symbol.Property == "" {
+			continue
+		}
+		field, ok := setterField(path, symbol.Path, byPath)
+		if !ok {
+			continue
+		} else if _, ok := byField[field]; ok {
+			continue
+		}
+		byField[field] = symbol
+		setters = append(setters, symbol)
+	}
+	chosen := []*Symbol{}
+	root := ao.root()
+	for _, field := range root.kubeVersions.ExampleFields(root.spec.Info.Version, string(ao.name)) {
+		if setter, ok := byField[field]; ok {
+			chosen = append(chosen, setter)
+		}
+	}
+	if len(chosen) == 0 {
+		if len(setters) > exampleSetters {
+			setters = setters[:exampleSetters]
+		}
+		chosen = setters
+	}
+
+	name := path[strings.LastIndex(path, ".")+1:]
+	lines := []string{
+		fmt.Sprintf("local %s = k.%s;", name, root.libraryPath(path)),
+		name + exampleCall(path, constructor),
+	}
+	for _, setter := range chosen {
+		lines = append(lines, fmt.Sprintf("+ %s%s", name, exampleCall(path, setter)))
+	}
+	return lines
+}
+
+// `exampleCall` returns the call of `symbol`, relative to the
+// namespace at `path`, that `usageExample` writes, e.g.,
+// `.mixin.spec.replicas(replicas)`.
+func exampleCall(path string, symbol *Symbol) string {
+	args := []string{}
+	for _, param := range symbol.Params {
+		if _, ok := symbol.Defaults[param]; !ok {
+			args = append(args, param)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", strings.TrimPrefix(symbol.Path, path), strings.Join(args, ", "))
+}
+
+// `setterField` returns the dotted path of the field that the setter
+// at `setterPath`, in the namespace at `path`, sets, as the names in
+// the spec of the properties of the namespaces it's in, e.g.,
+// `spec.template.spec.containers` for
+// `apps.v1.deployment.mixin.spec.template.spec.containers`. It reports
+// false if one of them isn't a property's (other than `mixin`).
+func setterField(path, setterPath string, byPath map[string]*Symbol) (string, bool) {
+	field := []string{}
+	prefix := path
+	for _, segment := range strings.Split(strings.TrimPrefix(setterPath, path+"."), ".") {
+		prefix += "." + segment
+		symbol, ok := byPath[prefix]
+		if ok && symbol.Property != "" {
+			field = append(field, string(symbol.Property))
+		} else if segment != "mixin" {
+			return "", false
+		}
+	}
+	return strings.Join(field, "."), true
+}
@@ -0,0 +1,145 @@
+package ksonnet
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// exampleLines match the lines of the examples `usageExample` writes.
+var exampleLines = regexp.MustCompile("(?m)^ *// Example, where `k` is the library:\n *//\n((?: *//   .*\n)+)")
+
+// usageExamples returns the examples in `library`, each as Jsonnet.
+func usageExamples(library string) []string {
+	examples := []string{}
+	for _, match := range exampleLines.FindAllStringSubmatch(library, -1) {
+		lines := strings.Split(strings.TrimSuffix(match[1], "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)[len("//   "):]
+		}
+		examples = append(examples, strings.Join(lines, "\n"))
+	}
+	return examples
+}
+
+// exampleCallPattern matches a call of an example, e.g.,
+// `+ deployment.mixin.spec.replicas(replicas)`.
+var exampleCallPattern = regexp.MustCompile(`^(?:\+ )?([a-zA-Z]+)((?:\.[a-zA-Z_]+)+)\(([a-zA-Z_, ]*)\)$`)
+
+func TestUsageExamples(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/workloads.json")
+	library, err := Emit(context.Background(), spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+
+	// The example of a kind with fields of its own sets them; that of
+	// one without (the `Job` of the spec has neither `backoffLimit`
+	// nor `parallelism`) sets the first two fields it has setters for.
+	for kind, expected := range map[string]string{
+		"deployment": "local deployment = k.apps.v1.deployment;\ndeployment.new(labels)\n+ deployment.mixin.spec.replicas(replicas)\n+ deployment.mixin.spec.template.spec.containers(containers)",
+		"job":        "local job = k.batch.v1.job;\njob.new(name, containers)\n+ job.mixin.metadata.annotations(annotations)\n+ job.mixin.metadata.finalizers(finalizers)",
+	} {
+		examples := usageExamples(objectText(string(library), kind))
+		if len(examples) != 1 || examples[0] != expected {
+			t.Errorf("Expected the example of '%s' to be:\n%s\ngot:\n%v", kind, expected, examples)
+		}
+	}
+
+	// Each example calls functions of the library, passing each of the
+	// parameters that have no default, by name.
+	index, err := BuildSymbolIndex(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build symbol index:\n%v", err)
+	}
+	symbols := map[string]*Symbol{}
+	for _, symbol := range index.Symbols {
+		symbols[symbol.Path] = symbol
+	}
+	examples := usageExamples(string(library))
+	if len(examples) != len(index.Kinds) {
+		t.Errorf("Expected an example for each of the %d kinds, got %d", len(index.Kinds), len(examples))
+	}
+	for _, example := range examples {
+		lines := strings.Split(example, "\n")
+		var name, path string
+		if _, err := fmt.Sscanf(lines[0], "local %s = k.%s", &name, &path); err != nil || !strings.HasSuffix(path, ";") {
+			t.Errorf("Expected the example to start with a local of the kind, got:\n%s", example)
+			continue
+		}
+		path = strings.TrimSuffix(path, ";")
+		for _, line := range lines[1:] {
+			match := exampleCallPattern.FindStringSubmatch(line)
+			if match == nil || match[1] != name {
+				t.Errorf("Expected '%s' to call a function of '%s'", line, name)
+				continue
+			}
+			symbol := symbols[path+match[2]]
+			if symbol == nil || symbol.Kind != SymbolFunction {
+				t.Errorf("Expected '%s' to call a function of the library", line)
+				continue
+			}
+			required := []string{}
+			for _, param := range symbol.Params {
+				if _, ok := symbol.Defaults[param]; !ok {
+					required = append(required, param)
+				}
+			}
+			if args := strings.Join(required, ", "); args != match[3] {
+				t.Errorf("Expected '%s' to pass '%s'", line, args)
+			}
+		}
+	}
+
+	// Examples are comments, so they're omitted with the others.
+	library, err = Emit(context.Background(), spec, Options{OmitComments: true})
+	if err != nil {
+		t.Fatalf("Failed to emit library:\n%v", err)
+	}
+	if examples := usageExamples(string(library)); len(examples) != 0 {
+		t.Errorf("Expected no examples without comments, got:\n%v", examples)
+	}
+}
+
+func TestUsageExamplesEvaluation(t *testing.T) {
+	jsonnet, err := exec.LookPath("jsonnet")
+	if err != nil {
+		t.Skip("Skipping, since `jsonnet` isn't installed")
+	}
+
+	// Each example is a valid expression of the library, once its
+	// placeholders are bound, which a function's parameters do.
+	for _, path := range []string{"testdata/workloads.json", "testdata/swagger.json"} {
+		spec := loadTestSpec(t, path)
+		library, err := Emit(context.Background(), spec, Options{})
+		if err != nil {
+			t.Fatalf("Failed to emit library:\n%v", err)
+		}
+		checks := []string{}
+		for i, example := range usageExamples(string(library)) {
+			params := map[string]bool{}
+			for _, line := range strings.Split(example, "\n")[1:] {
+				if match := exampleCallPattern.FindStringSubmatch(line); match != nil && match[3] != "" {
+					for _, param := range strings.Split(match[3], ", ") {
+						params[param] = true
+					}
+				}
+			}
+			names := []string{}
+			for param := range params {
+				names = append(names, param)
+			}
+			sort.Strings(names)
+			checks = append(checks, fmt.Sprintf(
+				"  local example%d(%s) = (%s);\n  std.type(example%d) == \"function\",", i, strings.Join(names, ", "), example, i))
+		}
+		program := "local k = import 'k8s.libsonnet';\n[\n" + strings.Join(checks, "\n") + "\n]\n"
+		if out := evaluateLibrary(t, jsonnet, spec, Options{}, program); strings.Contains(out, "false") {
+			t.Errorf("Expected the examples of '%s' to evaluate, got:\n%s", path, out)
+		}
+	}
+}
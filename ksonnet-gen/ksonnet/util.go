@@ -1,15 +1,24 @@
 package ksonnet
 
 import (
-	"log"
-	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
 )
 
-const constructorName = "new"
+const (
+	constructorName = "new"
+	scaleName       = "scale"
+
+	withReplicasName = "withReplicas"
+
+	// booleanDefault is what the setters of boolean properties set
+	// them to when called with no arguments (e.g.,
+	// `hostNetwork(hostNetwork=true)`), which is almost always what
+	// callers want.
+	booleanDefault = "true"
+)
 
 var specialProperties = map[kubespec.PropertyName]kubespec.PropertyName{
 	"apiVersion": "apiVersion",
@@ -21,26 +30,26 @@ func isSpecialProperty(pn kubespec.PropertyName) bool {
 	return ok
 }
 
-func getSHARevision(dir string) string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		log.Fatalf("Could get working directory:\n%v", err)
-	}
-
-	err = os.Chdir(dir)
-	if err != nil {
-		log.Fatalf("Could cd to directory of repository at '%s':\n%v", dir, err)
-	}
-
-	sha, err := exec.Command("sh", "-c", "git rev-parse HEAD").Output()
-	if err != nil {
-		log.Fatalf("Could not find SHA of HEAD:\n%v", err)
-	}
+// `isHandledSpecially` reports whether a property of an API object is
+// a special property that gets no setter of its own, since the
+// object's constructor sets it. Only top-level objects have one, so
+// the `apiVersion` and `kind` of any other object (e.g., the `kind` of
+// a `RoleRef`), and of the objects CRD schemas embed, get setters like
+// any other property.
+func (ao *apiObject) isHandledSpecially(pn kubespec.PropertyName) bool {
+	return isSpecialProperty(pn) && ao.isTopLevel && !ao.isEmbeddedResource
+}
 
-	err = os.Chdir(cwd)
+// `getSHARevision` returns the SHA of the HEAD of the git repository
+// at `dir`, or false if `dir` isn't in one (or git isn't installed).
+// git runs in `dir`, rather than in the working directory, which is
+// the process's, and so shared by concurrent generations.
+func getSHARevision(dir string) (string, bool) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	sha, err := cmd.Output()
 	if err != nil {
-		log.Fatalf("Could cd back to current directory '%s':\n%v", cwd, err)
+		return "", false
 	}
-
-	return strings.TrimSpace(string(sha))
+	return strings.TrimSpace(string(sha)), true
 }
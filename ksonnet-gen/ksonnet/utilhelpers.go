@@ -0,0 +1,80 @@
+package ksonnet
+
+import (
+	"fmt"
+)
+
+// utilName is the namespace of the library with helpers for the
+// objects it makes, which `Options.EmitFieldOrder`,
+// `Options.EmitGVKIndex`, `Options.EmitMerge`,
+// `Options.EmitStringifiedSetters`, `Options.EmitQuantity`, and
+// `Options.EmitOptionsHelpers` add.
+const utilName = "util"
+
+// `emitUtil` emits the `util` namespace, if any of its helpers are
+// emitted:
+//
+//   - With `Options.EmitFieldOrder`, `reorder(obj, order)` rebuilds
+//     `obj` adding its fields in `order` (e.g., a kind's `fieldOrder`),
+//     then the rest by name. Jsonnet objects evaluate the same whatever
+//     order their fields are added in, but rendering pipelines that keep
+//     that order (e.g., `--preserve-order` of some Jsonnet
+//     implementations) write them in it.
+//   - With `Options.EmitGVKIndex`, `forManifest(obj)` returns the
+//     namespace of the kind of a manifest, looked up by its `apiVersion`
+//     and `kind` in `gvkIndex.libsonnet`, or fails with them.
+//   - With `Options.EmitMerge`, `merge(kindPath, a, b)` merges `b` into
+//     `a`, objects of the kind at `kindPath`, by the kind's schema; see
+//     `newMergeStrategies`.
+//   - With `Options.EmitStringifiedSetters`, `toString(value)` converts
+//     a value to the string the `Stringified` setters set; see
+//     `toStringBody`.
+//   - With `Options.EmitQuantity`, the namespace `quantity` parses,
+//     formats, and computes with quantities, e.g., `"500m"`; see
+//     `quantityFunctions`.
+//   - With `Options.EmitOptionsHelpers`, `deleteOptions.new(...)`,
+//     `listOptions.new(...)`, and `listSelectors(labelMap, fieldMap)`
+//     make the bodies and query parameters of deletes and lists; see
+//     `emitOptionsHelpers`.
+func (root *root) emitUtil(m *indentWriter) {
+	if !root.opts.EmitFieldOrder && !root.opts.EmitGVKIndex && !root.opts.EmitMerge &&
+		!root.opts.EmitStringifiedSetters && !root.opts.EmitQuantity && !root.opts.EmitOptionsHelpers {
+		return
+	}
+	m.writeLine("// Helpers for the objects the library makes.")
+	m.writeLine(fmt.Sprintf("%s:: {", utilName))
+	m.indent()
+	root.index.add(utilName, SymbolNamespace)
+	if root.opts.EmitGVKIndex {
+		m.writeLine(fmt.Sprintf("// Returns the namespace of the kind of the manifest `obj`, e.g., `apps.v1.deployment` for one whose `apiVersion` is `apps/v1` and whose `kind` is `Deployment`, as `%s` maps them. It fails if the library doesn't have the kind.", GVKIndexFile))
+		m.writeLine(fmt.Sprintf(
+			`forManifest(obj):: local gvks = import %q; if std.type(obj) != "object" || !std.objectHas(obj, "apiVersion") || !std.objectHas(obj, "kind") then error "'forManifest' takes a manifest with an apiVersion and a kind" else local key = "%%s:%%s" %% [obj.apiVersion, obj.kind]; if std.objectHas(gvks, key) then gvks[key]($) else error "The library has no kind for '%%s'" %% key,`,
+			root.importPath(GVKIndexFile)))
+		root.index.add(utilName+".forManifest", SymbolFunction, "obj")
+	}
+	if root.opts.EmitFieldOrder {
+		m.writeLine("// Rebuilds `obj` with the fields in `order` (e.g., a kind's `fieldOrder`) that it has first, in that order, then the rest by name, for rendering pipelines that keep the order fields are added in. It evaluates the same as `obj`.")
+		m.writeLine("reorder(obj, order):: std.foldl(function(ordered, name) ordered + {[name]: obj[name]}, [name for name in order if std.objectHas(obj, name)] + [name for name in std.objectFields(obj) if std.count(order, name) == 0], {}),")
+		root.index.add(utilName+".reorder", SymbolFunction, "obj", "order")
+	}
+	if root.opts.EmitMerge {
+		m.writeLine("// Merges the object `b` into `a`, both of the kind at `kindPath` (e.g., `\"apps.v1.deployment\"`), by the kind's schema, rather than as `+` does: lists with a merge key (e.g., a pod's `containers`, by `name`) are merged item by item, sets by value, objects and maps field by field, and atomic lists, other lists, and scalars are replaced by `b`'s.")
+		m.writeLine(fmt.Sprintf(
+			`merge(kindPath, a, b):: if std.objectHas(%[1]s.kinds, kindPath) then %[1]s.values(%[1]s.table(%[1]s.kinds[kindPath]), a, b) else error "The library has no kind at '" + kindPath + "'",`,
+			mergeLocal))
+		root.index.add(utilName+"."+mergeName, SymbolFunction, "kindPath", "a", "b")
+	}
+	if root.opts.EmitStringifiedSetters {
+		m.writeLine("// Converts `value` to a string, for a map of strings such as a ConfigMap's `data`: strings are unchanged, booleans are `\"true\"` or `\"false\"`, numbers are written as `std.toString` does, and objects and arrays as indented JSON. It fails for `null`.")
+		m.writeLine(fmt.Sprintf("%s(value):: %s,", toStringName, toStringBody))
+		root.index.add(utilName+"."+toStringName, SymbolFunction, "value")
+	}
+	if root.opts.EmitQuantity {
+		root.emitQuantity(m)
+	}
+	if root.opts.EmitOptionsHelpers {
+		root.emitOptionsHelpers(m)
+	}
+	m.dedent()
+	m.writeLine("},")
+}
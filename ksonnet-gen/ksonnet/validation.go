@@ -0,0 +1,128 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// `constraint` is a bound the schema of a property sets on its values,
+// e.g., `minimum: 1`, which `Options.EmitValidation` asserts in the
+// property's setter.
+type constraint struct {
+	// valueType is the Jsonnet type of the values the bound applies to,
+	// `number` or `string`; values of other types (e.g., a string
+	// passed to an `intOrString` setter with a `minimum`) pass.
+	valueType string
+
+	// check is the Jsonnet condition values must meet, with `%[1]s`
+	// for the setter's parameter, and actual the Jsonnet expression of
+	// what's compared, for the error message.
+	check  string
+	actual string
+
+	// relation and limit describe the bound, e.g., "at least" and `1`.
+	relation string
+	limit    string
+}
+
+// `bound` describes a constraint, e.g., "at least 1", or "at most 63
+// characters long".
+func (c constraint) bound() string {
+	bound := fmt.Sprintf("%s %s", c.relation, c.limit)
+	if c.valueType == "string" {
+		bound += " characters long"
+	}
+	return bound
+}
+
+// `propertyConstraints` returns the bounds the schema of a property
+// sets on its values: its numeric range, then its length.
+func propertyConstraints(prop *kubespec.Property) []constraint {
+	constraints := []constraint{}
+	number := func(limit string, exclusive bool, op, exclusiveOp, relation, exclusiveRelation string) {
+		if exclusive {
+			op, relation = exclusiveOp, exclusiveRelation
+		}
+		constraints = append(constraints, constraint{
+			valueType: "number",
+			check:     fmt.Sprintf("%%[1]s %s %s", op, limit),
+			actual:    "std.toString(%[1]s)",
+			relation:  relation,
+			limit:     limit,
+		})
+	}
+	if prop.Minimum != "" {
+		number(string(prop.Minimum), prop.ExclusiveMinimum, ">=", ">", "at least", "greater than")
+	}
+	if prop.Maximum != "" {
+		number(string(prop.Maximum), prop.ExclusiveMaximum, "<=", "<", "at most", "less than")
+	}
+	length := func(limit int64, op, relation string) {
+		constraints = append(constraints, constraint{
+			valueType: "string",
+			check:     fmt.Sprintf("std.length(%%[1]s) %s %d", op, limit),
+			actual:    `std.toString(std.length(%[1]s)) + " characters"`,
+			relation:  relation,
+			limit:     fmt.Sprint(limit),
+		})
+	}
+	if prop.MinLength != nil {
+		length(*prop.MinLength, ">=", "at least")
+	}
+	if prop.MaxLength != nil {
+		length(*prop.MaxLength, "<=", "at most")
+	}
+	return constraints
+}
+
+// `constraintComments` returns the sentences of a property's comment
+// that describe the bounds its schema sets on its values, e.g.,
+// "Values must be at least `1` and at most `65535`.", and whether
+// its setter asserts them.
+func (root *root) constraintComments(prop *kubespec.Property, constraints []constraint) []string {
+	comments := []string{}
+	for _, valueType := range []string{"number", "string"} {
+		bounds := []string{}
+		for _, c := range constraints {
+			if c.valueType == valueType {
+				bounds = append(bounds, fmt.Sprintf("%s `%s`", c.relation, c.limit))
+			}
+		}
+		if len(bounds) == 0 {
+			continue
+		}
+		sentence := "Values must be " + strings.Join(bounds, " and ")
+		if valueType == "string" {
+			sentence += " characters long"
+		}
+		comments = append(comments, sentence+".")
+	}
+	if prop.Pattern != "" {
+		comments = append(comments, fmt.Sprintf(
+			"Values must match the pattern `%s`, which isn't checked, since Jsonnet has no regular expressions.",
+			prop.Pattern))
+	}
+	if root.opts.EmitValidation && len(constraints) > 0 {
+		comments = append(comments,
+			"This function asserts the bounds, which fails when the object is evaluated (e.g., manifested), rather than when it's called.")
+	}
+	return comments
+}
+
+// `assertConstraints` prefixes `body`, the value of a setter whose
+// parameter is `param`, with an assertion of each bound the schema
+// sets on the property's values, if `Options.EmitValidation` is set.
+func (p *property) assertConstraints(param, body string) string {
+	if !p.root().opts.EmitValidation {
+		return body
+	}
+	asserts := ""
+	for _, c := range p.constraints {
+		asserts += fmt.Sprintf(
+			"assert std.type(%[1]s) != %[2]q || "+c.check+" : \"'%[3]s' must be %[4]s, got \" + "+c.actual+"; ",
+			param, c.valueType, p.name, c.bound())
+	}
+	return asserts + body
+}
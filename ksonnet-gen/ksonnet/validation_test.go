@@ -0,0 +1,127 @@
+package ksonnet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestEmitValidation(t *testing.T) {
+	library := emitTestSpec(t, "testdata/validation.json", Options{EmitValidation: true})
+	object := objectText(library, "autoscaler")
+
+	expected := []string{
+		`maxReplicas(maxReplicas):: assert std.type(maxReplicas) != "number" || maxReplicas >= 0 : "'maxReplicas' must be at least 0, got " + std.toString(maxReplicas); assert std.type(maxReplicas) != "number" || maxReplicas <= 100 : "'maxReplicas' must be at most 100, got " + std.toString(maxReplicas); __specMixin({maxReplicas: maxReplicas}),`,
+		`targetUtilization(targetUtilization):: assert std.type(targetUtilization) != "number" || targetUtilization > 0 : "'targetUtilization' must be greater than 0, got " + std.toString(targetUtilization); assert std.type(targetUtilization) != "number" || targetUtilization < 1 : "'targetUtilization' must be less than 1, got " + std.toString(targetUtilization); __specMixin({targetUtilization: targetUtilization}),`,
+		`workload(workload):: assert std.type(workload) != "string" || std.length(workload) >= 1 : "'workload' must be at least 1 characters long, got " + std.toString(std.length(workload)) + " characters"; assert std.type(workload) != "string" || std.length(workload) <= 63 : "'workload' must be at most 63 characters long, got " + std.toString(std.length(workload)) + " characters"; __specMixin({workload: workload}),`,
+		// The type check of an `intOrString` comes first, and strings
+		// aren't range checked.
+		`metricsPort(metricsPort):: assert std.type(metricsPort) == "number" || std.type(metricsPort) == "string" : "'metricsPort' must be an integer or a string, got " + std.type(metricsPort); assert std.type(metricsPort) != "number" || metricsPort >= 1 : "'metricsPort' must be at least 1, got " + std.toString(metricsPort); assert std.type(metricsPort) != "number" || metricsPort <= 65535 : "'metricsPort' must be at most 65535, got " + std.toString(metricsPort); __specMixin({metricsPort: metricsPort}),`,
+		`mode(mode):: __specMixin({mode: mode}),`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(object, "\n"+strings.Repeat("  ", 6)+line+"\n") {
+			t.Errorf("Expected setter:\n%s\ngot:\n%s", line, object)
+		}
+	}
+
+	comments := []string{
+		"// Values must be at least `0` and at most `100`.\n",
+		"// Values must be greater than `0` and less than `1`.\n",
+		"// Values must be at least `1` and at most `63` characters long.\n" +
+			strings.Repeat("  ", 6) + "// Values must match the pattern `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`, which isn't checked, since Jsonnet has no regular expressions.\n" +
+			strings.Repeat("  ", 6) + "// This function asserts the bounds, which fails when the object is evaluated (e.g., manifested), rather than when it's called.\n",
+	}
+	for _, comment := range comments {
+		if !strings.Contains(object, comment) {
+			t.Errorf("Expected comment:\n%s\ngot:\n%s", comment, object)
+		}
+	}
+
+	// Without the option, the bounds are documented, but not asserted.
+	object = objectText(emitTestSpec(t, "testdata/validation.json", Options{}), "autoscaler")
+	if strings.Contains(object, `!= "number" ||`) || strings.Contains(object, `!= "string" ||`) || strings.Contains(object, "This function asserts the bounds") {
+		t.Errorf("Expected no assertions without 'EmitValidation', got:\n%s", object)
+	}
+	if !strings.Contains(object, "// Values must be at least `0` and at most `100`.\n") ||
+		!strings.Contains(object, "maxReplicas(maxReplicas):: __specMixin({maxReplicas: maxReplicas}),") {
+		t.Errorf("Expected 'maxReplicas' to be documented and set without assertions, got:\n%s", object)
+	}
+}
+
+// `evalConstraint` evaluates the check of a constraint, which compares
+// the parameter or its length with a number, for `value`.
+func evalConstraint(t *testing.T, c constraint, value interface{}) bool {
+	fields := strings.Fields(fmt.Sprintf(c.check, "x"))
+	if len(fields) != 3 {
+		t.Fatalf("Unexpected check '%s'", c.check)
+	}
+	var actual float64
+	switch v := value.(type) {
+	case float64:
+		if c.valueType != "number" {
+			return true
+		}
+		actual = v
+	case string:
+		if c.valueType != "string" {
+			return true
+		}
+		actual = float64(len(v))
+	}
+	limit, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		t.Fatalf("Could not parse the limit of '%s':\n%v", c.check, err)
+	}
+	switch fields[1] {
+	case ">=":
+		return actual >= limit
+	case ">":
+		return actual > limit
+	case "<=":
+		return actual <= limit
+	case "<":
+		return actual < limit
+	}
+	t.Fatalf("Unexpected operator in '%s'", c.check)
+	return false
+}
+
+func TestConstraintBoundaries(t *testing.T) {
+	spec := loadTestSpec(t, "testdata/validation.json")
+	props := spec.Definitions["io.k8s.kubernetes.pkg.apis.scaling.v1.AutoscalerSpec"].Properties
+
+	cases := []struct {
+		property string
+		value    interface{}
+		valid    bool
+	}{
+		{"maxReplicas", -1.0, false},
+		{"maxReplicas", 0.0, true},
+		{"maxReplicas", 100.0, true},
+		{"maxReplicas", 101.0, false},
+		{"targetUtilization", 0.0, false},
+		{"targetUtilization", 0.5, true},
+		{"targetUtilization", 1.0, false},
+		{"workload", "", false},
+		{"workload", "a", true},
+		{"workload", strings.Repeat("a", 63), true},
+		{"workload", strings.Repeat("a", 64), false},
+		// Values of other types than the bound's aren't checked.
+		{"metricsPort", 0.0, false},
+		{"metricsPort", 65535.0, true},
+		{"metricsPort", "metrics", true},
+	}
+	for _, c := range cases {
+		valid := true
+		for _, constraint := range propertyConstraints(props[kubespec.PropertyName(c.property)]) {
+			valid = valid && evalConstraint(t, constraint, c.value)
+		}
+		if valid != c.valid {
+			t.Errorf("Expected '%s' of %#v to be valid: %v, got %v", c.property, c.value, c.valid, valid)
+		}
+	}
+}
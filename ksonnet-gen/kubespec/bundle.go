@@ -0,0 +1,297 @@
+package kubespec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// Bundle lists the sources of the CRDs to generate a library from, in
+// the order `WithCRDSources` adds them, and how definitions that more
+// than one of them adds are merged. It's read from JSON or YAML by
+// `ReadBundle`, e.g.:
+//
+//	onConflict: fork
+//	sources:
+//	- path: crds/databases.yaml
+//	- url: https://example.com/operator/v2/crds.yaml
+//	  sha256: 0f343b0931126a20f133d67c2b018a3b5c6a1f3b1c0f0a2a8e1e8d1b8c0a4d2e
+//	  label: v2
+//	- inline: |
+//	    apiVersion: apiextensions.k8s.io/v1
+//	    kind: CustomResourceDefinition
+//	    ...
+type Bundle struct {
+	// OnConflict is the `ConflictPolicy` the sources are merged with;
+	// empty is `ConflictsError`.
+	OnConflict ConflictPolicy `json:"onConflict,omitempty"`
+
+	Sources []*BundleSource `json:"sources"`
+}
+
+// BundleSource is a source of CRDs in a `Bundle`: exactly one of a
+// local file, a URL, or manifests inline in the bundle.
+type BundleSource struct {
+	// Path is a JSON or YAML file of CRDs, relative to the bundle's
+	// directory unless it's absolute.
+	Path string `json:"path,omitempty"`
+
+	// URL is an `http` or `https` URL of a JSON or YAML file of CRDs.
+	URL string `json:"url,omitempty"`
+
+	// Inline is a CRD manifest, written as an object, or JSON or YAML
+	// text of one or more, written as a string.
+	Inline json.RawMessage `json:"inline,omitempty"`
+
+	// SHA256 is the digest, in lowercase hex, that the bytes of the
+	// path or URL must have; `ResolveBundle` fails if they don't. It's
+	// optional, and inline sources can't have one, since the bundle
+	// pins them already.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Label is the label of the source's forks (see `CRDSource`); it
+	// defaults to `SourceLabel` of the path or URL, and to `inline_N`
+	// for the Nth source, if it's inline.
+	Label string `json:"label,omitempty"`
+}
+
+// ResolvedSource is a `BundleSource` that `ResolveBundle` has read:
+// the CRDs it has, under its label, where they came from (its path,
+// URL, or `inline`), and the digest of the bytes they were read from.
+type ResolvedSource struct {
+	*CRDSource
+	Location string
+	SHA256   string
+}
+
+// sha256Pattern is what the pinned digests of sources must match.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ReadBundle deserializes and validates (see `Bundle.Validate`) the
+// bundle in `text`, which is JSON or YAML; `name` is where it came
+// from, and decides the format as for `UnmarshalSpec`. Fields the
+// bundle doesn't have are errors, which catches typos. Errors are of
+// `ErrSpecLoad`.
+func ReadBundle(name string, text []byte) (_ *Bundle, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	if isYAML(name, text) {
+		if text, err = yamljson.ToJSON(text); err != nil {
+			return nil, fmt.Errorf("Could not read bundle '%s':\n%v", name, err)
+		}
+	}
+	b := &Bundle{}
+	d := json.NewDecoder(bytes.NewReader(text))
+	d.DisallowUnknownFields()
+	if err := d.Decode(b); err != nil {
+		return nil, fmt.Errorf("Could not read bundle '%s':\n%v", name, err)
+	}
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("Bundle '%s' is invalid:\n%v", name, err)
+	}
+	return b, nil
+}
+
+// Validate returns an error listing every problem of the bundle, each
+// on a line of its own and naming the source it's of, or nil if it has
+// none: it must have a source, each source exactly one of a path, an
+// http or https URL, and inline manifests, pinned digests must be
+// SHA-256 digests in lowercase hex, labels must be letters, digits
+// and underscores, and distinct, and its conflict policy must be one of
+// `ConflictPolicies`.
+func (b *Bundle) Validate() error {
+	problems := []string{}
+	if b.OnConflict != "" {
+		if _, err := ParseConflictPolicy(string(b.OnConflict)); err != nil {
+			problems = append(problems, fmt.Sprintf("onConflict: %v", err))
+		}
+	}
+	if len(b.Sources) == 0 {
+		problems = append(problems, "sources: the bundle has no sources")
+	}
+
+	labels := map[string]int{}
+	for i, source := range b.Sources {
+		if source == nil {
+			problems = append(problems, fmt.Sprintf("sources[%d]: expected a path, url, or inline manifests, got null", i))
+			continue
+		}
+		problem := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("sources[%d]: ", i)+fmt.Sprintf(format, args...))
+		}
+
+		switch kinds := source.kinds(); len(kinds) {
+		case 0:
+			problem("expected one of path, url, or inline")
+		case 1:
+		default:
+			problem("expected one of path, url, or inline, got %d: %v", len(kinds), kinds)
+		}
+		if source.URL != "" {
+			if u, err := url.Parse(source.URL); err != nil {
+				problem("url '%s' doesn't parse: %v", source.URL, err)
+			} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				problem("url '%s' isn't an http or https URL", source.URL)
+			}
+		}
+		if source.SHA256 != "" {
+			if source.Inline != nil {
+				problem("inline manifests can't pin a sha256, since the bundle has them already")
+			} else if !sha256Pattern.MatchString(source.SHA256) {
+				problem("sha256 '%s' isn't a SHA-256 digest, i.e., 64 lowercase hex digits", source.SHA256)
+			}
+		}
+
+		label := source.label(i)
+		if !sourceLabelPattern.MatchString(label) {
+			problem("label '%s' isn't letters, digits and underscores", label)
+		} else if other, ok := labels[label]; ok {
+			problem("label '%s' is also that of sources[%d]; give one a label of its own", label, other)
+		} else {
+			labels[label] = i
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	errs := []error{}
+	for _, problem := range problems {
+		errs = append(errs, fmt.Errorf("%s", problem))
+	}
+	return JoinErrors(errs)
+}
+
+// `kinds` returns which of a path, a URL, and inline manifests the
+// source has.
+func (s *BundleSource) kinds() []string {
+	kinds := []string{}
+	if s.Path != "" {
+		kinds = append(kinds, "path")
+	}
+	if s.URL != "" {
+		kinds = append(kinds, "url")
+	}
+	if s.Inline != nil {
+		kinds = append(kinds, "inline")
+	}
+	return kinds
+}
+
+// `label` returns the label of the source, which is the `i`th of its
+// bundle; see `BundleSource.Label`.
+func (s *BundleSource) label(i int) string {
+	switch {
+	case s.Label != "":
+		return s.Label
+	case s.Path != "":
+		return SourceLabel(s.Path)
+	case s.URL != "":
+		if u, err := url.Parse(s.URL); err == nil {
+			return SourceLabel(u.Path)
+		}
+	}
+	return fmt.Sprintf("inline_%d", i+1)
+}
+
+// `location` returns where the source's CRDs come from, as errors and
+// `ResolvedSource` name it.
+func (s *BundleSource) location() string {
+	switch {
+	case s.Path != "":
+		return s.Path
+	case s.URL != "":
+		return s.URL
+	}
+	return "inline"
+}
+
+// ResolveBundle reads the CRDs of each source of `b`, in order: files
+// are read relative to `dir`, the bundle's directory, and URLs fetched
+// with `client`, or `http.DefaultClient` if it's nil. The bytes of a
+// source that pins a digest must have it. Nothing is returned unless
+// every source resolves; the error names the first one that doesn't,
+// by its index and location. Errors are of `ErrSpecLoad`, but for
+// digests that don't match, which are of `ErrVerifyFailed`. If `ctx`
+// is cancelled, the error wraps `ctx.Err()`.
+func ResolveBundle(
+	ctx context.Context, client *http.Client, b *Bundle, dir string,
+) ([]*ResolvedSource, error) {
+	resolved := []*ResolvedSource{}
+	for i, source := range b.Sources {
+		r, err := resolveSource(ctx, client, source, i, dir)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+func resolveSource(
+	ctx context.Context, client *http.Client, source *BundleSource, i int, dir string,
+) (_ *ResolvedSource, err error) {
+	location := source.location()
+	defer func() {
+		if err == nil {
+			return
+		}
+		category := ErrSpecLoad
+		if errors.Is(err, ErrVerifyFailed) {
+			category = ErrVerifyFailed
+		}
+		err = Categorize(category, fmt.Errorf(
+			"Could not resolve sources[%d] ('%s') of the bundle:\n%w", i, location, err))
+	}()
+
+	var text []byte
+	name := location
+	switch {
+	case source.Path != "":
+		file := source.Path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if text, err = ioutil.ReadFile(file); err != nil {
+			return nil, err
+		}
+	case source.URL != "":
+		if text, name, err = fetch(ctx, client, source.URL, "CRDs", "application/json, application/yaml"); err != nil {
+			return nil, err
+		}
+	default:
+		// A string holds the text of manifests, and any other value is
+		// a manifest itself.
+		text, name = source.Inline, "inline.json"
+		var manifests string
+		if err := json.Unmarshal(source.Inline, &manifests); err == nil {
+			text, name = []byte(manifests), "inline"
+		}
+	}
+
+	digest := sha256.Sum256(text)
+	sha := hex.EncodeToString(digest[:])
+	if source.SHA256 != "" && sha != source.SHA256 {
+		return nil, Categorize(ErrVerifyFailed, fmt.Errorf(
+			"Its bytes have the sha256 '%s', but the bundle pins '%s'", sha, source.SHA256))
+	}
+	crds, err := ReadCRDs(name, text)
+	if err != nil {
+		return nil, err
+	}
+	return &ResolvedSource{
+		CRDSource: &CRDSource{Label: source.label(i), CRDs: crds},
+		Location:  location,
+		SHA256:    sha,
+	}, nil
+}
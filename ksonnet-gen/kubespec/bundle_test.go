@@ -0,0 +1,190 @@
+package kubespec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bundleServer serves `crdsYAML` at `/crds.yaml` and `crdV1YAML` at
+// `/v1/jobrunners.yaml`, and nothing else.
+func bundleServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crds.yaml":
+			w.Write([]byte(crdsYAML))
+		case "/v1/jobrunners.yaml":
+			w.Write([]byte(crdV1YAML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func sha256Of(text string) string {
+	digest := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(digest[:])
+}
+
+func TestResolveBundle(t *testing.T) {
+	server := bundleServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatalf("Could not create directory:\n%v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "local-crds.yaml"), []byte(crdV1YAML), 0644); err != nil {
+		t.Fatalf("Could not write CRDs:\n%v", err)
+	}
+
+	text := fmt.Sprintf(`onConflict: fork
+sources:
+- url: %s/crds.yaml
+  sha256: %s
+- url: %s/v1/jobrunners.yaml
+  label: remote
+- path: local-crds.yaml
+- inline: |
+%s
+- inline: {"apiVersion": "apiextensions.k8s.io/v1beta1", "kind": "CustomResourceDefinition", "spec": {"group": "x.io", "version": "v1", "names": {"kind": "X"}}}
+`, server.URL, sha256Of(crdsYAML), server.URL, "    "+strings.Replace(strings.TrimSuffix(crdV1YAML, "\n"), "\n", "\n    ", -1))
+	b, err := ReadBundle("bundle.yaml", []byte(text))
+	if err != nil {
+		t.Fatalf("Could not read bundle:\n%v", err)
+	}
+	if b.OnConflict != ConflictsFork || len(b.Sources) != 5 {
+		t.Fatalf("Expected a bundle of 5 sources to fork, got %+v", b)
+	}
+
+	resolved, err := ResolveBundle(context.Background(), server.Client(), b, dir)
+	if err != nil {
+		t.Fatalf("Could not resolve bundle:\n%v", err)
+	}
+	expected := []struct {
+		label, location, sha256 string
+		crds                    int
+	}{
+		{"crds", server.URL + "/crds.yaml", sha256Of(crdsYAML), 2},
+		{"remote", server.URL + "/v1/jobrunners.yaml", sha256Of(crdV1YAML), 1},
+		{"local_crds", "local-crds.yaml", sha256Of(crdV1YAML), 1},
+		{"inline_4", "inline", sha256Of(crdV1YAML), 1},
+		{"inline_5", "inline", "", 1},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("Expected %d sources, got %d", len(expected), len(resolved))
+	}
+	for i, e := range expected {
+		r := resolved[i]
+		if r.Label != e.label || r.Location != e.location || len(r.CRDs) != e.crds {
+			t.Errorf("Expected sources[%d] to be '%s' from '%s' with %d CRDs, got '%s' from '%s' with %d",
+				i, e.label, e.location, e.crds, r.Label, r.Location, len(r.CRDs))
+		}
+		if e.sha256 != "" && r.SHA256 != e.sha256 {
+			t.Errorf("Expected sources[%d] to have the digest '%s', got '%s'", i, e.sha256, r.SHA256)
+		}
+	}
+
+	// The sources merge as the bundle says: the definitions that the
+	// later sources add again are identical, so they're shared.
+	sources := []*CRDSource{}
+	for _, r := range resolved {
+		sources = append(sources, r.CRDSource)
+	}
+	s := &APISpec{Definitions: SchemaDefinitions{ObjectMetaName: &SchemaDefinition{}}}
+	if _, err := s.WithCRDSources(sources, false, b.OnConflict); err != nil {
+		t.Errorf("Expected the bundle's sources to merge, got:\n%v", err)
+	}
+}
+
+func TestResolveBundleErrors(t *testing.T) {
+	server := bundleServer()
+	defer server.Close()
+
+	for _, test := range []struct {
+		source   string
+		category error
+		message  string
+	}{
+		{
+			"url: " + server.URL + "/missing.yaml",
+			ErrSpecLoad,
+			"Could not resolve sources[1] ('" + server.URL + "/missing.yaml') of the bundle:\nCould not fetch CRDs from '" +
+				server.URL + "/missing.yaml': server responded '404 Not Found'",
+		},
+		{
+			"url: " + server.URL + "/crds.yaml\n  sha256: " + sha256Of(crdV1YAML),
+			ErrVerifyFailed,
+			"Could not resolve sources[1] ('" + server.URL + "/crds.yaml') of the bundle:\nIts bytes have the sha256 '" +
+				sha256Of(crdsYAML) + "', but the bundle pins '" + sha256Of(crdV1YAML) + "'",
+		},
+		{
+			"path: missing.yaml",
+			ErrSpecLoad,
+			"Could not resolve sources[1] ('missing.yaml') of the bundle:\nopen testdata/missing.yaml: no such file or directory",
+		},
+		{
+			"inline: {kind: ConfigMap}",
+			ErrSpecLoad,
+			"Could not resolve sources[1] ('inline') of the bundle:\nCan't read manifest 1 of 'inline.json' as a CRD, because its kind is 'ConfigMap'",
+		},
+	} {
+		text := "sources:\n- url: " + server.URL + "/crds.yaml\n  label: first\n- " + test.source + "\n"
+		b, err := ReadBundle("bundle.yaml", []byte(text))
+		if err != nil {
+			t.Fatalf("Could not read bundle:\n%v", err)
+		}
+		resolved, err := ResolveBundle(context.Background(), nil, b, "testdata")
+		if resolved != nil || err == nil || !strings.Contains(err.Error(), test.message) || !errors.Is(err, test.category) {
+			t.Errorf("Expected resolving a bundle with source '%s' to fail with:\n%s\ngot %v", test.source, test.message, err)
+		}
+		if test.category == ErrVerifyFailed && errors.Is(err, ErrSpecLoad) {
+			t.Errorf("Expected a digest that doesn't match not to be of 'ErrSpecLoad', got %v", err)
+		}
+	}
+
+	// Cancelling stops fetching.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := &Bundle{Sources: []*BundleSource{{URL: server.URL + "/crds.yaml"}}}
+	if _, err := ResolveBundle(ctx, nil, b, "."); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to wrap 'context.Canceled', got %v", err)
+	}
+}
+
+func TestReadBundleErrors(t *testing.T) {
+	for text, expected := range map[string]string{
+		"sources: []":                                       "sources: the bundle has no sources",
+		"sources:\n- {}":                                    "sources[0]: expected one of path, url, or inline",
+		"sources:\n- path: a.yaml\n  url: x":                "sources[0]: expected one of path, url, or inline, got 2: [path url]",
+		"sources:\n- url: ftp://x/a.yaml":                   "sources[0]: url 'ftp://x/a.yaml' isn't an http or https URL",
+		"sources:\n- url: https:///a.yaml":                  "sources[0]: url 'https:///a.yaml' isn't an http or https URL",
+		"sources:\n- path: a.yaml\n  sha256: ABC":           "sources[0]: sha256 'ABC' isn't a SHA-256 digest, i.e., 64 lowercase hex digits",
+		"sources:\n- inline: {}\n  sha256: " + sha256Of(""): "sources[0]: inline manifests can't pin a sha256",
+		"sources:\n- path: a.yaml\n  label: a-b":            "sources[0]: label 'a-b' isn't letters, digits and underscores",
+		"sources:\n- path: a.yaml\n- url: https://x/a.json": "sources[1]: label 'a' is also that of sources[0]; give one a label of its own",
+		"onConflict: merge\nsources:\n- path: a.yaml":       "onConflict: Unknown conflict policy 'merge'",
+		"sources:\n- path: a.yaml\n  sha: x":                `json: unknown field "sha"`,
+		"sources:\n  path: a.yaml":                          "cannot unmarshal object into Go struct field Bundle.sources",
+	} {
+		_, err := ReadBundle("bundle.yaml", []byte(text))
+		if err == nil || !strings.Contains(err.Error(), expected) || !errors.Is(err, ErrSpecLoad) {
+			t.Errorf("Expected bundle %q to fail with '%s', got %v", text, expected, err)
+		}
+	}
+
+	// Every problem is reported, and JSON reads too.
+	_, err := ReadBundle("bundle.json", []byte(`{"onConflict": "merge", "sources": [{}, {"url": "x"}]}`))
+	if err == nil || strings.Count(err.Error(), "\n") != 3 {
+		t.Errorf("Expected three problems, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package kubespec
+
+import "sort"
+
+// Codebases returns the group/versions of the spec's definitions,
+// keyed by the codebase they come from (see
+// `ParsedName.QualifiedCodebase`), e.g., `apps/v1` and `core/v1` for
+// `api`, and `apiregistration/v1beta1` for `kube-aggregator`, which
+// says which codebase contributed what to a spec merged from several.
+// Groups are named as in definition names, with `core` for the core
+// group, and each codebase's are sorted by group, then version.
+//
+// Every codebase is listed, including those whose definitions have no
+// version (e.g., the `runtime` package of `apimachinery`), which have
+// none. Definitions whose names don't parse (e.g., because of their
+// package) belong to no codebase, and are left out.
+func (s *APISpec) Codebases() map[string][]GroupVersion {
+	return s.codebases(parseName)
+}
+
+// Codebases is like `APISpec.Codebases`, but parses names with the
+// parser, and so accepts its `Prefixes`.
+func (p *Parser) Codebases(s *APISpec) map[string][]GroupVersion {
+	return s.codebases(p.ParseName)
+}
+
+func (s *APISpec) codebases(
+	parse func(DefinitionName) (ParsedName, error),
+) map[string][]GroupVersion {
+	seen := map[string]map[GroupVersion]bool{}
+	for name := range s.Definitions {
+		parsed, err := parse(name)
+		if err != nil {
+			continue
+		}
+		codebase := parsed.QualifiedCodebase()
+		if seen[codebase] == nil {
+			seen[codebase] = map[GroupVersion]bool{}
+		}
+		if !parsed.HasVersion() {
+			continue
+		}
+		group := GroupName(apiCoreGroup)
+		if parsed.HasGroup() {
+			group = parsed.Group
+		}
+		seen[codebase][GroupVersion{Group: group, Version: parsed.Version}] = true
+	}
+
+	codebases := map[string][]GroupVersion{}
+	for codebase, gvs := range seen {
+		sorted := []GroupVersion{}
+		for gv := range gvs {
+			sorted = append(sorted, gv)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].Group != sorted[j].Group {
+				return sorted[i].Group < sorted[j].Group
+			}
+			return sorted[i].Version < sorted[j].Version
+		})
+		codebases[codebase] = sorted
+	}
+	return codebases
+}
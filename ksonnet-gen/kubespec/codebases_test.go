@@ -0,0 +1,52 @@
+package kubespec
+
+import (
+	"reflect"
+	"testing"
+)
+
+// codebasesSpec is a spec merged from the core API, apimachinery, the
+// CRD and aggregator servers, and OpenShift, with a definition of a
+// package that isn't known, and a runtime one that has no version.
+func codebasesSpec() *APISpec {
+	s := &APISpec{Definitions: SchemaDefinitions{}}
+	for _, name := range []DefinitionName{
+		"io.k8s.api.apps.v1.Deployment",
+		"io.k8s.api.apps.v1beta2.Deployment",
+		"io.k8s.api.core.v1.Pod",
+		"io.k8s.api.core.v1.ConfigMap",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta",
+		"io.k8s.apimachinery.pkg.runtime.RawExtension",
+		"io.k8s.apiextensions-apiserver.pkg.apis.apiextensions.v1beta1.CustomResourceDefinition",
+		"io.k8s.kube-aggregator.pkg.apis.apiregistration.v1beta1.APIService",
+		"io.k8s.kubernetes.pkg.unknown.Thing",
+		"com.github.openshift.api.route.v1.Route",
+	} {
+		s.Definitions[name] = &SchemaDefinition{}
+	}
+	return s
+}
+
+func TestCodebases(t *testing.T) {
+	expected := map[string][]GroupVersion{
+		"api": {
+			{Group: "apps", Version: "v1"},
+			{Group: "apps", Version: "v1beta2"},
+			{Group: "core", Version: "v1"},
+		},
+		"apimachinery":            {{Group: "meta", Version: "v1"}},
+		"apiextensions-apiserver": {{Group: "apiextensions", Version: "v1beta1"}},
+		"kube-aggregator":         {{Group: "apiregistration", Version: "v1beta1"}},
+	}
+	if actual := codebasesSpec().Codebases(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected codebases %v, got %v", expected, actual)
+	}
+
+	// A parser that accepts OpenShift's names lists its codebase under
+	// its prefix.
+	p := &Parser{Prefixes: []string{"io.k8s", "com.github.openshift"}}
+	expected["com.github.openshift.api"] = []GroupVersion{{Group: "route", Version: "v1"}}
+	if actual := p.Codebases(codebasesSpec()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected codebases %v, got %v", expected, actual)
+	}
+}
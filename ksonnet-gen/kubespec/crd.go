@@ -0,0 +1,363 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// CRDPrefix is the prefix of the names of the definitions that
+// `WithCRDs` adds, e.g., `io.k8s.crd.api.stableExampleCom.v1beta1.CronTab`
+// for the `CronTab`s of `stable.example.com/v1beta1`. They only parse
+// if it's one of a parser's `Prefixes`,
+// and, like any other non-native prefix, their kinds are then grouped
+// by their API group, so that their `apiVersion` is, e.g.,
+// `stable.example.com/v1beta1`.
+const CRDPrefix = "io.k8s.crd"
+
+// crdKind is the kind of the manifests `ReadCRDs` reads.
+const crdKind = "CustomResourceDefinition"
+
+// The API versions of the CRDs `ReadCRDs` reads. Manifests with no
+// `apiVersion` are read as `apiextensions.k8s.io/v1beta1`.
+const (
+	CRDAPIVersionV1Beta1 = "apiextensions.k8s.io/v1beta1"
+	CRDAPIVersionV1      = "apiextensions.k8s.io/v1"
+)
+
+// ObjectMetaName is the definition that the `metadata` of every custom
+// resource refers to, if the spec has it; `WithEmbeddedMeta` adds it to
+// one that doesn't.
+const ObjectMetaName = DefinitionName("io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta")
+
+// CustomResourceDefinition is the part of a CRD manifest that
+// `WithCRDs` uses: the group and kind of its custom resource, and the
+// versions it's served in, with their schemas. Both
+// `apiextensions.k8s.io/v1beta1` CRDs, which may have a single
+// `version` and a `validation` schema shared by every version, and
+// `apiextensions.k8s.io/v1` CRDs, whose every version must have a
+// structural schema of its own, are read; see `IsV1`.
+type CustomResourceDefinition struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec CRDSpec `json:"spec"`
+}
+
+// CRDSpec is the `spec` of a `CustomResourceDefinition`.
+type CRDSpec struct {
+	Group   GroupName     `json:"group"`
+	Version VersionString `json:"version"` // Older CRDs' only version; see `CRDVersions`.
+	Names   struct {
+		Kind ObjectKind `json:"kind"`
+	} `json:"names"`
+	Validation *CRDSchema   `json:"validation"` // nullable.
+	Versions   []CRDVersion `json:"versions"`   // nullable.
+}
+
+// CRDVersion is one of the versions a CRD lists in `spec.versions`.
+type CRDVersion struct {
+	Name    VersionString `json:"name"`
+	Served  bool          `json:"served"`
+	Storage bool          `json:"storage"`
+	Schema  *CRDSchema    `json:"schema"` // nullable.
+}
+
+// CustomResource is the CRD that a definition `WithCRDs` added is a
+// version of, e.g., `crontabs.stable.example.com`, and whether the CRD
+// serves the version, which, unlike that of other kinds, the paths of
+// the spec can't say.
+type CustomResource struct {
+	CRD    string
+	Served bool
+}
+
+// CRDSchema holds the OpenAPI v3 schema of a custom resource, as it
+// appears in the CRD.
+type CRDSchema struct {
+	OpenAPIV3Schema json.RawMessage `json:"openAPIV3Schema"`
+}
+
+// IsV1 reports whether the CRD is an `apiextensions.k8s.io/v1` one,
+// which has neither `spec.version` nor `spec.validation`.
+func (crd *CustomResourceDefinition) IsV1() bool {
+	return crd.APIVersion == CRDAPIVersionV1
+}
+
+// CRDVersions returns the versions of the CRD, in the order it lists
+// them. An `apiextensions.k8s.io/v1beta1` CRD with no `spec.versions`
+// has only its `spec.version`, which is served, and stored.
+func (crd *CustomResourceDefinition) CRDVersions() []CRDVersion {
+	if len(crd.Spec.Versions) > 0 || crd.IsV1() {
+		return crd.Spec.Versions
+	}
+	return []CRDVersion{{Name: crd.Spec.Version, Served: true, Storage: true}}
+}
+
+// ReadCRDs deserializes the CRDs in `text`, which is a JSON manifest,
+// or a stream of YAML ones separated by `---`; `name` is where it came
+// from, and decides the format as for `UnmarshalSpec`. Every manifest
+// must be a `CustomResourceDefinition`, of either API version; both may
+// be read from the same text. Errors are of `ErrSpecLoad`.
+func ReadCRDs(name string, text []byte) (_ []*CustomResourceDefinition, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	documents := [][]byte{text}
+	if isYAML(name, text) {
+		var err error
+		if documents, err = yamljson.Documents(text); err != nil {
+			return nil, fmt.Errorf("Could not read CRDs from '%s':\n%v", name, err)
+		}
+	}
+
+	crds := []*CustomResourceDefinition{}
+	for i, document := range documents {
+		crd := &CustomResourceDefinition{}
+		if err := json.Unmarshal(document, crd); err != nil {
+			return nil, fmt.Errorf("Could not read CRD %d of '%s':\n%v", i+1, name, err)
+		}
+		if crd.Kind != crdKind {
+			return nil, fmt.Errorf(
+				"Can't read manifest %d of '%s' as a CRD, because its kind is '%s', not '%s'",
+				i+1, name, crd.Kind, crdKind)
+		}
+		if crd.APIVersion != "" && crd.APIVersion != CRDAPIVersionV1Beta1 && !crd.IsV1() {
+			return nil, fmt.Errorf(
+				"Can't read CRD %d of '%s', because its apiVersion is '%s', not '%s' or '%s'",
+				i+1, name, crd.APIVersion, CRDAPIVersionV1, CRDAPIVersionV1Beta1)
+		}
+		crds = append(crds, crd)
+	}
+	return crds, nil
+}
+
+// CRDDefinitionName returns the name `WithCRDs` gives the definition
+// of a kind of a CRD in one of its versions, e.g.,
+// `io.k8s.crd.api.stableExampleCom.v1beta1.CronTab` for
+// `stable.example.com/v1beta1` `CronTab`s. The API group is written as
+// a single lowerCamelCase segment, by `SanitizeGroupID`, so that each
+// has a package of its own; `WithCRDs` suffixes it if another API
+// group has the same identifier (see `GroupIDMap`).
+func CRDDefinitionName(group GroupName, version VersionString, kind ObjectKind) DefinitionName {
+	return crdDefinitionName(SanitizeGroupID(string(group)), version, kind)
+}
+
+func crdDefinitionName(id string, version VersionString, kind ObjectKind) DefinitionName {
+	return DefinitionName(fmt.Sprintf(
+		"%s.%s.%s.%s.%s", CRDPrefix, apiCodebase, id, version, kind))
+}
+
+// crdGroupIDs returns the identifiers of the API groups of the CRDs
+// that `definitions` has already, as their names have them, so that
+// adding more CRDs keeps them; see `crdDefinitions`.
+func crdGroupIDs(definitions SchemaDefinitions) GroupIDMap {
+	ids := GroupIDMap{}
+	prefix := CRDPrefix + "." + apiCodebase + "."
+	for name, def := range definitions {
+		if !strings.HasPrefix(string(name), prefix) || def.Fork != nil || len(def.TopLevelSpecs) == 0 {
+			continue
+		}
+		id := strings.SplitN(strings.TrimPrefix(string(name), prefix), ".", 2)[0]
+		ids[id] = string(def.TopLevelSpecs[0].Group)
+	}
+	return ids
+}
+
+// WithCRDs returns a copy of the spec with a top-level definition for
+// each version that each of `crds` serves (or, with `includeUnserved`,
+// each version it lists), named by `CRDDefinitionName`, unless the
+// identifiers of two API groups collide (e.g., `foo-bar.io` and
+// `foo.bar.io`), in which case the API group considered later by
+// `GroupIDMap.Add` gets a numeric suffix (e.g., `fooBarIo2`), as does
+// one that collides with an API group the spec has CRDs of. A version's
+// definition has its own schema, if it has one, and otherwise the
+// CRD's `validation` schema, with `apiVersion` and `kind` added if it
+// doesn't declare them, and `metadata` referring to `ObjectMeta`, if
+// the spec has it. The definition of the version that the CRD stores
+// has `StorageVersion` set. The receiver isn't modified.
+//
+// An `apiextensions.k8s.io/v1` CRD must list its versions, each with a
+// schema, which is the only one it has; it's an error if any doesn't,
+// served or not, as the API server would reject it. Errors for CRDs
+// that can't be added are of `ErrUnsupportedSchema`. Objects nested in
+// a schema that set `x-kubernetes-embedded-resource` get `apiVersion`,
+// `kind` and `metadata` the same way, so that they're emitted like
+// top-level kinds, even though they preserve unknown fields.
+//
+// Nested inline schemas are left as they are; emitting the library
+// gives them definitions of their own (see `WithInlineDefinitions`).
+// Custom resources have no paths in the spec, so their scope is
+// unknown.
+func (s *APISpec) WithCRDs(
+	crds []*CustomResourceDefinition, includeUnserved bool,
+) (_ *APISpec, err error) {
+	defer func() { err = Categorize(ErrUnsupportedSchema, err) }()
+	_, hasObjectMeta := s.Definitions[ObjectMetaName]
+	added, err := crdDefinitions(crds, includeUnserved, hasObjectMeta, s.Definitions, crdGroupIDs(s.Definitions))
+	if err != nil {
+		return nil, err
+	}
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	for name, def := range added {
+		definitions[name] = def
+	}
+	return s.withDefinitions(definitions), nil
+}
+
+// crdDefinitions returns the definitions of the versions of `crds`
+// that `WithCRDs` adds, by name, adding the API groups of `crds` to
+// `ids`, which names their packages. It's an error if two versions
+// have the same name, or if `existing` has it already.
+func crdDefinitions(
+	crds []*CustomResourceDefinition, includeUnserved, hasObjectMeta bool,
+	existing SchemaDefinitions, ids GroupIDMap,
+) (SchemaDefinitions, error) {
+	groups := []string{}
+	for _, crd := range crds {
+		if crd.Spec.Group != "" {
+			groups = append(groups, string(crd.Spec.Group))
+		}
+	}
+	ids.Add(groups...)
+
+	definitions := SchemaDefinitions{}
+	added := map[DefinitionName]string{}
+	for _, crd := range crds {
+		if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+			return nil, fmt.Errorf(
+				"Can't add CRD '%s', because it has no group or kind", crd.Metadata.Name)
+		}
+		if err := crd.checkV1Schemas(); err != nil {
+			return nil, err
+		}
+		for _, version := range crd.CRDVersions() {
+			if !version.Served && !includeUnserved {
+				continue
+			}
+			if version.Name == "" {
+				return nil, fmt.Errorf(
+					"Can't add CRD '%s', because one of its versions has no name", crd.Metadata.Name)
+			}
+			name := crdDefinitionName(ids.ID(string(crd.Spec.Group)), version.Name, crd.Spec.Names.Kind)
+			if other, ok := added[name]; ok {
+				return nil, fmt.Errorf(
+					"Can't add CRD '%s', because CRD '%s' already added '%s'",
+					crd.Metadata.Name, other, name)
+			} else if _, ok := existing[name]; ok {
+				return nil, fmt.Errorf(
+					"Can't add CRD '%s', because the spec already has '%s'", crd.Metadata.Name, name)
+			}
+			def, err := crdDefinition(crd, version, hasObjectMeta)
+			if err != nil {
+				return nil, err
+			}
+			definitions[name] = def
+			added[name] = crd.Metadata.Name
+		}
+	}
+	return definitions, nil
+}
+
+// crdDefinition returns the definition of `version` of `crd`; see
+// `WithCRDs`.
+func crdDefinition(
+	crd *CustomResourceDefinition, version CRDVersion, hasObjectMeta bool,
+) (*SchemaDefinition, error) {
+	schema := version.Schema
+	if (schema == nil || schema.OpenAPIV3Schema == nil) && !crd.IsV1() {
+		schema = crd.Spec.Validation
+	}
+	def := &SchemaDefinition{}
+	if schema != nil && schema.OpenAPIV3Schema != nil {
+		if err := json.Unmarshal(schema.OpenAPIV3Schema, def); err != nil {
+			return nil, fmt.Errorf(
+				"Could not read the schema of version '%s' of CRD '%s':\n%v",
+				version.Name, crd.Metadata.Name, err)
+		}
+	}
+	if def.Description == "" {
+		def.Description = fmt.Sprintf(
+			"%s is a custom resource, defined by the CRD '%s'.", crd.Spec.Names.Kind, crd.Metadata.Name)
+	}
+
+	if def.Properties == nil {
+		def.Properties = Properties{}
+	}
+	addResourceFields(def.Properties, hasObjectMeta)
+	embedResources(def.Properties, hasObjectMeta)
+
+	def.TopLevelSpecs = TopLevelSpecs{{
+		Group:   crd.Spec.Group,
+		Version: version.Name,
+		Kind:    crd.Spec.Names.Kind,
+	}}
+	def.StorageVersion = version.Storage
+	def.CustomResource = &CustomResource{CRD: crd.Metadata.Name, Served: version.Served}
+	return def, nil
+}
+
+// checkV1Schemas returns an error if the CRD is an
+// `apiextensions.k8s.io/v1` one that lists no versions, or has a
+// version without a schema.
+func (crd *CustomResourceDefinition) checkV1Schemas() error {
+	if !crd.IsV1() {
+		return nil
+	}
+	if len(crd.Spec.Versions) == 0 {
+		return fmt.Errorf(
+			"Can't add CRD '%s', because it's an '%s' CRD, which must list its versions in 'spec.versions'",
+			crd.Metadata.Name, CRDAPIVersionV1)
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Schema == nil || isAbsent(version.Schema.OpenAPIV3Schema) {
+			return fmt.Errorf(
+				"Can't add CRD '%s', because version '%s' has no 'schema.openAPIV3Schema', which '%s' CRDs require of every version",
+				crd.Metadata.Name, version.Name, CRDAPIVersionV1)
+		}
+	}
+	return nil
+}
+
+// addResourceFields adds the fields every Kubernetes object has to the
+// properties of a custom resource, or of an object embedded in one:
+// `apiVersion` and `kind`, as strings, if they aren't declared, and
+// `metadata`, referring to `ObjectMeta`, if the spec has it.
+func addResourceFields(properties Properties, hasObjectMeta bool) {
+	str := SchemaType("string")
+	for _, field := range []PropertyName{"apiVersion", "kind"} {
+		if _, ok := properties[field]; !ok {
+			properties[field] = &Property{Type: &str}
+		}
+	}
+	if hasObjectMeta {
+		ref := ObjectRef("#/definitions/" + ObjectMetaName)
+		properties["metadata"] = &Property{Ref: &ref}
+	}
+}
+
+// embedResources adds the fields of an object (see
+// `addResourceFields`) to each of `properties`, at any depth, that sets
+// `x-kubernetes-embedded-resource`. The properties are those of a
+// schema just read, so they're modified in place.
+func embedResources(properties Properties, hasObjectMeta bool) {
+	for _, prop := range properties {
+		embedResources(prop.Properties, hasObjectMeta)
+		if !prop.EmbeddedResource {
+			continue
+		}
+		if prop.Properties == nil {
+			prop.Properties = Properties{}
+		}
+		if prop.Type == nil {
+			object := SchemaType("object")
+			prop.Type = &object
+		}
+		addResourceFields(prop.Properties, hasObjectMeta)
+	}
+}
@@ -0,0 +1,249 @@
+package kubespec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// crdsYAML has a CRD that serves two versions with schemas of their
+// own, and lists a third it doesn't serve, which has none, and an
+// older CRD with a single version.
+const crdsYAML = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+  validation:
+    openAPIV3Schema:
+      properties:
+        schedule:
+          type: string
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        description: A v1alpha1 CronTab.
+        properties:
+          cronSpec:
+            type: string
+  - name: v1beta1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        description: A v1beta1 CronTab.
+        properties:
+          cronSpec:
+            type: string
+          replicas:
+            type: integer
+  - name: v2alpha1
+    served: false
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.my-app.io
+spec:
+  group: my-app.io
+  version: v1
+  names:
+    kind: Widget
+`
+
+func TestReadCRDs(t *testing.T) {
+	crds, err := ReadCRDs("crds.yaml", []byte(crdsYAML))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	if len(crds) != 2 || crds[0].Metadata.Name != "crontabs.stable.example.com" ||
+		crds[1].Spec.Names.Kind != "Widget" {
+		t.Fatalf("Expected the CronTab and Widget CRDs, got %+v", crds)
+	}
+	expected := []CRDVersion{{Name: "v1", Served: true, Storage: true}}
+	if actual := crds[1].CRDVersions(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected a CRD with only `version` to serve and store it, got %+v", actual)
+	}
+
+	crds, err = ReadCRDs("crd.json", []byte(`{"kind": "CustomResourceDefinition", "spec": {"group": "a.io"}}`))
+	if err != nil || len(crds) != 1 || crds[0].Spec.Group != "a.io" {
+		t.Errorf("Expected to read a JSON CRD, got %+v, %v", crds, err)
+	}
+
+	_, err = ReadCRDs("crds.yaml", []byte(crdsYAML+"---\nkind: ConfigMap\n"))
+	if err == nil || !strings.Contains(err.Error(), "manifest 3 of 'crds.yaml'") {
+		t.Errorf("Expected an error for a manifest that isn't a CRD, got %v", err)
+	}
+}
+
+func TestCRDDefinitionName(t *testing.T) {
+	if actual := CRDDefinitionName("my-app.example.com", "v1beta1", "Widget"); actual != "io.k8s.crd.api.myAppExampleCom.v1beta1.Widget" {
+		t.Errorf("Expected the API group to be a lowerCamelCase segment, got '%s'", actual)
+	}
+	p := &Parser{Prefixes: []string{NativePrefix, CRDPrefix}}
+	parsed, err := p.ParseName(CRDDefinitionName("stable.example.com", "v1", "CronTab"))
+	if err != nil || parsed.Prefix != CRDPrefix || parsed.Group != "stableExampleCom" ||
+		parsed.Version != "v1" || parsed.Kind != "CronTab" {
+		t.Errorf("Expected the name to parse with `CRDPrefix`, got %+v, %v", parsed, err)
+	}
+}
+
+func TestWithCRDs(t *testing.T) {
+	crds, err := ReadCRDs("crds.yaml", []byte(crdsYAML))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	s := &APISpec{Definitions: SchemaDefinitions{ObjectMetaName: &SchemaDefinition{}}}
+	withCRDs, err := s.WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	if len(s.Definitions) != 1 {
+		t.Errorf("Expected the receiver to be left as it is, got %v", s.Definitions)
+	}
+
+	alpha := withCRDs.Definitions["io.k8s.crd.api.stableExampleCom.v1alpha1.CronTab"]
+	beta := withCRDs.Definitions["io.k8s.crd.api.stableExampleCom.v1beta1.CronTab"]
+	widget := withCRDs.Definitions["io.k8s.crd.api.myAppIo.v1.Widget"]
+	if alpha == nil || beta == nil || widget == nil || len(withCRDs.Definitions) != 4 {
+		t.Fatalf("Expected a definition for each served version, got %v", withCRDs.Definitions)
+	}
+
+	// Each version has its own schema, and the storage version is marked.
+	if _, ok := alpha.Properties["replicas"]; ok || alpha.Description != "A v1alpha1 CronTab." || alpha.StorageVersion {
+		t.Errorf("Expected v1alpha1 to have its own schema, and not be stored, got %+v", alpha)
+	}
+	if _, ok := beta.Properties["replicas"]; !ok || !beta.StorageVersion {
+		t.Errorf("Expected v1beta1 to have its own schema, and be stored, got %+v", beta)
+	}
+	expected := TopLevelSpecs{{Group: "stable.example.com", Version: "v1beta1", Kind: "CronTab"}}
+	if !reflect.DeepEqual(beta.TopLevelSpecs, expected) {
+		t.Errorf("Expected the group/version/kind %v, got %v", expected, beta.TopLevelSpecs)
+	}
+	for _, name := range []PropertyName{"apiVersion", "kind"} {
+		if p := beta.Properties[name]; p == nil || p.Type == nil || *p.Type != "string" {
+			t.Errorf("Expected '%s' to be added as a string, got %+v", name, p)
+		}
+	}
+	if ref := beta.Properties["metadata"].Ref; ref == nil || *ref != "#/definitions/"+ObjectRef(ObjectMetaName) {
+		t.Errorf("Expected 'metadata' to refer to 'ObjectMeta', got %v", ref)
+	}
+	if !strings.Contains(widget.Description, "'widgets.my-app.io'") || !widget.StorageVersion {
+		t.Errorf("Expected a schemaless CRD to get a description, and be stored, got %+v", widget)
+	}
+
+	// Unserved versions are added only if they're asked for, with the
+	// schema of `validation` when they have none of their own.
+	withCRDs, err = s.WithCRDs(crds, true)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	unserved := withCRDs.Definitions["io.k8s.crd.api.stableExampleCom.v2alpha1.CronTab"]
+	if unserved == nil {
+		t.Fatalf("Expected the unserved version to be added")
+	}
+	if _, ok := unserved.Properties["schedule"]; !ok {
+		t.Errorf("Expected the unserved version to have the schema of `validation`, got %+v", unserved)
+	}
+
+	if _, err := s.WithCRDs(append(crds, crds[1]), false); err == nil ||
+		!strings.Contains(err.Error(), "already added 'io.k8s.crd.api.myAppIo.v1.Widget'") {
+		t.Errorf("Expected an error for a CRD that's added twice, got %v", err)
+	}
+}
+
+// crdV1YAML is an `apiextensions.k8s.io/v1` CRD, whose version has a
+// structural schema, embedding a resource of another kind.
+const crdV1YAML = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: jobrunners.batch.example.com
+spec:
+  group: batch.example.com
+  names:
+    kind: JobRunner
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              template:
+                type: object
+                x-kubernetes-embedded-resource: true
+                x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestWithCRDsV1(t *testing.T) {
+	// Both API versions are read side by side.
+	crds, err := ReadCRDs("crds.yaml", []byte(crdsYAML+"---\n"+crdV1YAML))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	if len(crds) != 3 || crds[0].IsV1() || !crds[2].IsV1() {
+		t.Fatalf("Expected two v1beta1 CRDs and a v1 one, got %+v", crds)
+	}
+	s := &APISpec{Definitions: SchemaDefinitions{ObjectMetaName: &SchemaDefinition{}}}
+	withCRDs, err := s.WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+	runner := withCRDs.Definitions["io.k8s.crd.api.batchExampleCom.v1.JobRunner"]
+	if runner == nil || !runner.StorageVersion || withCRDs.Definitions["io.k8s.crd.api.myAppIo.v1.Widget"] == nil {
+		t.Fatalf("Expected the definitions of both API versions' CRDs, got %v", withCRDs.Definitions)
+	}
+
+	// The embedded resource gets the fields of an object, and is
+	// synthesized a definition of its own, although it preserves
+	// unknown fields.
+	template := runner.Properties["spec"].Properties["template"]
+	for _, name := range []PropertyName{"apiVersion", "kind", "metadata"} {
+		if _, ok := template.Properties[name]; !ok {
+			t.Errorf("Expected the embedded resource to have '%s', got %+v", name, template.Properties)
+		}
+	}
+	if !template.isInlineObject() {
+		t.Errorf("Expected the embedded resource to be an inline object")
+	}
+	inline, names := withCRDs.WithInlineDefinitions(2)
+	embedded := inline.Definitions["io.k8s.crd.api.batchExampleCom.v1.JobRunnerSpecTemplateInline"]
+	if embedded == nil || !embedded.EmbeddedResource || !embedded.PreserveUnknownFields {
+		t.Errorf("Expected a definition for the embedded resource, got %v", names)
+	}
+
+	// v1 CRDs don't fall back to `validation` or `version`, and must
+	// have a schema for every version.
+	missing := crdV1YAML + "  - name: v2\n    served: false\n"
+	crds, err = ReadCRDs("crd.yaml", []byte(missing))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	if _, err := s.WithCRDs(crds, false); err == nil ||
+		!strings.Contains(err.Error(), "version 'v2' has no 'schema.openAPIV3Schema'") {
+		t.Errorf("Expected an error for a v1 version without a schema, got %v", err)
+	}
+	crds, err = ReadCRDs("crd.json", []byte(`{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition",
+  "metadata": {"name": "widgets.my-app.io"}, "spec": {"group": "my-app.io", "version": "v1", "names": {"kind": "Widget"}}}`))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	if _, err := s.WithCRDs(crds, false); err == nil || !strings.Contains(err.Error(), "must list its versions") {
+		t.Errorf("Expected an error for a v1 CRD with only 'spec.version', got %v", err)
+	}
+
+	_, err = ReadCRDs("crd.yaml", []byte("apiVersion: apiextensions.k8s.io/v2\nkind: CustomResourceDefinition\n"))
+	if err == nil || !strings.Contains(err.Error(), "its apiVersion is 'apiextensions.k8s.io/v2'") {
+		t.Errorf("Expected an error for an unknown apiVersion, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package kubespec
+
+import (
+	_ "embed" // For `embeddedMetaText`.
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//go:generate go run ./internal/genmeta -o embeddedmeta.json https://raw.githubusercontent.com/kubernetes/kubernetes/v1.16.0/api/openapi-spec/swagger.json
+
+// EmbeddedMetaRoots are the apimachinery definitions that
+// `EmbeddedMeta` has, along with every definition they refer to in turn
+// (e.g., `OwnerReference`, through `ObjectMeta`): the object metadata,
+// label selectors, and timestamps of `meta/v1`, `RawExtension`,
+// `IntOrString`, and `Quantity`, which objects of every API group, and
+// custom resources, refer to. Those with a group/version/kind (e.g.,
+// `Status`) are left out, so that a library of them has no kinds.
+var EmbeddedMetaRoots = []DefinitionName{
+	ObjectMetaName,
+	"io.k8s.apimachinery.pkg.apis.meta.v1.ListMeta",
+	"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector",
+	"io.k8s.apimachinery.pkg.apis.meta.v1.Time",
+	"io.k8s.apimachinery.pkg.apis.meta.v1.MicroTime",
+	"io.k8s.apimachinery.pkg.runtime.RawExtension",
+	"io.k8s.apimachinery.pkg.util.intstr.IntOrString",
+	"io.k8s.apimachinery.pkg.api.resource.Quantity",
+}
+
+// embeddedMetaText is the fallback spec `EmbeddedMeta` parses, which
+// `internal/genmeta` extracts from the pinned upstream spec named by
+// the `go:generate` directive above.
+//
+//go:embed embeddedmeta.json
+var embeddedMetaText []byte
+
+var (
+	embeddedMetaOnce sync.Once
+	embeddedMeta     *APISpec
+)
+
+// EmbeddedMeta returns the fallback spec built into kubespec: the
+// definitions of `EmbeddedMetaRoots`, as a pinned version of upstream
+// Kubernetes (its `Info.Version`) defines them, e.g., to generate a
+// library from CRDs alone (see `WithEmbeddedMeta`). It's parsed once,
+// and must not be modified.
+func EmbeddedMeta() *APISpec {
+	embeddedMetaOnce.Do(func() {
+		s, err := UnmarshalSpec("embeddedmeta.json", embeddedMetaText)
+		if err != nil {
+			panic(fmt.Sprintf("Could not deserialize the embedded apimachinery spec:\n%v", err))
+		}
+		embeddedMeta = s
+	})
+	return embeddedMeta
+}
+
+// WithEmbeddedMeta returns a copy of the spec with the definitions of
+// `EmbeddedMeta` that its dangling references refer to, and those of
+// `names` (e.g., `ObjectMetaName`, for the custom resources of CRDs to
+// refer to; see `WithCRDs`) that it doesn't have, along with every
+// definition they refer to in turn, and the names of the definitions
+// it added, sorted. Definitions the spec has are never replaced, and
+// references to definitions `EmbeddedMeta` doesn't have either are left
+// dangling. The receiver isn't modified.
+func (s *APISpec) WithEmbeddedMeta(names ...DefinitionName) (*APISpec, []DefinitionName) {
+	fallback := EmbeddedMeta()
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	roots := append([]DefinitionName{}, names...)
+	for _, ref := range s.DanglingRefs() {
+		roots = append(roots, ref.To)
+	}
+
+	added := []DefinitionName{}
+	for _, root := range roots {
+		if _, ok := definitions[root]; ok {
+			continue
+		}
+		for reachable := range fallback.Closure(root) {
+			if _, ok := definitions[reachable]; ok {
+				continue
+			}
+			if def, ok := fallback.Definitions[reachable]; ok {
+				definitions[reachable] = def
+				added = append(added, reachable)
+			}
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	return s.withDefinitions(definitions), added
+}
@@ -0,0 +1,78 @@
+package kubespec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmbeddedMeta(t *testing.T) {
+	s := EmbeddedMeta()
+	if s.Info.Version != "v1.16.0" {
+		t.Errorf("Expected the embedded spec to be pinned to v1.16.0, got '%s'", s.Info.Version)
+	}
+
+	// It has the closure of each root, and nothing else, as `genmeta`
+	// writes it.
+	expected := map[DefinitionName]bool{}
+	for _, root := range EmbeddedMetaRoots {
+		if _, ok := s.Definitions[root]; !ok {
+			t.Errorf("Expected the embedded spec to have '%s'", root)
+		}
+		for name := range s.Closure(root) {
+			expected[name] = true
+		}
+	}
+	for name, def := range s.Definitions {
+		if !expected[name] {
+			t.Errorf("Expected the embedded spec not to have '%s', which no root refers to", name)
+		}
+		if len(def.TopLevelSpecs) > 0 {
+			t.Errorf("Expected '%s' to have no group/version/kind", name)
+		}
+	}
+	if dangling := s.DanglingRefs(); len(dangling) > 0 {
+		t.Errorf("Expected the embedded spec to have no dangling references, got %v", dangling)
+	}
+}
+
+func TestWithEmbeddedMeta(t *testing.T) {
+	labelSelector := ObjectRef("#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector")
+	missing := ObjectRef("#/definitions/io.k8s.api.apps.v1.DeploymentSpec")
+	ownTime := &SchemaDefinition{Description: "The spec's own Time."}
+	s := &APISpec{Definitions: SchemaDefinitions{
+		"io.k8s.api.apps.v1.Deployment": &SchemaDefinition{Properties: Properties{
+			"selector": &Property{Ref: &labelSelector},
+			"spec":     &Property{Ref: &missing},
+		}},
+		"io.k8s.apimachinery.pkg.apis.meta.v1.Time": ownTime,
+	}}
+
+	withMeta, added := s.WithEmbeddedMeta(ObjectMetaName)
+	expected := []DefinitionName{
+		"io.k8s.apimachinery.pkg.apis.meta.v1.FieldsV1",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelectorRequirement",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.ManagedFieldsEntry",
+		ObjectMetaName,
+		"io.k8s.apimachinery.pkg.apis.meta.v1.OwnerReference",
+	}
+	if !reflect.DeepEqual(added, expected) {
+		t.Errorf("Expected to add %v, got %v", expected, added)
+	}
+	if withMeta.Definitions["io.k8s.apimachinery.pkg.apis.meta.v1.Time"] != ownTime {
+		t.Errorf("Expected the spec's own definitions to be kept")
+	}
+	// References to definitions the embedded spec doesn't have either
+	// are left dangling.
+	if dangling := withMeta.DanglingRefs(); len(dangling) != 1 || dangling[0].To != "io.k8s.api.apps.v1.DeploymentSpec" {
+		t.Errorf("Expected only 'DeploymentSpec' to dangle, got %v", dangling)
+	}
+	if len(s.Definitions) != 2 {
+		t.Errorf("Expected the receiver not to be modified")
+	}
+
+	// A spec with everything it refers to is returned as it is.
+	if _, added := withMeta.WithEmbeddedMeta(ObjectMetaName); len(added) != 0 {
+		t.Errorf("Expected nothing to add, got %v", added)
+	}
+}
@@ -0,0 +1,118 @@
+package kubespec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The categories of the errors of kubespec, and of the packages built
+// on it (`ksonnet` has the same errors under the same names), which
+// programs can tell apart with `errors.Is`, e.g., to retry a spec that
+// couldn't be fetched, but not one whose schemas aren't supported.
+// They're part of the API: an error of a category stays of it. Errors
+// keep their own messages; the categories only classify them, so an
+// error may be of none (e.g., one about options), or, if it lists
+// several problems, of each of theirs.
+var (
+	// ErrSpecLoad is the category of the errors of reading, fetching,
+	// or deserializing a spec, a model of one (see `ReadSpecFrom`), or
+	// CRDs (see `ReadCRDs`).
+	ErrSpecLoad = errors.New("the spec could not be loaded")
+
+	// ErrUnparsableName is the category of the errors of parsing
+	// definition names, and the names of refs; see `ParseName` and
+	// `MalformedNameError`.
+	ErrUnparsableName = errors.New("a definition name could not be parsed")
+
+	// ErrUnsupportedSchema is the category of the errors for specs,
+	// and CRDs, that use what can't be generated from: a version of
+	// Kubernetes there's no data for, a ref to anything but a
+	// definition, a CRD version without a schema, vendor extensions
+	// that aren't modeled (when that's an error), and definitions that
+	// conflict in the library.
+	ErrUnsupportedSchema = errors.New("the spec uses an unsupported construct")
+
+	// ErrDanglingRef is the category of the errors for properties that
+	// refer to definitions that aren't in the spec, or, where they're
+	// allowed to be elsewhere, aren't there either; see `DanglingRefs`.
+	ErrDanglingRef = errors.New("a reference refers to a definition that isn't in the spec")
+
+	// ErrVerifyFailed is the category of the errors of checks that
+	// output is as it should be, e.g., that a library's imports all
+	// resolve, or that its files match their provenance manifest, and
+	// of those that inputs are, e.g., that the sources of a bundle have
+	// the digests it pins (see `ResolveBundle`).
+	ErrVerifyFailed = errors.New("verification failed")
+)
+
+// Categorize returns `err`, with the same message, as an error of
+// `category`, one of the sentinels above, or nil if `err` is nil. What
+// `err` wraps is still wrapped.
+func Categorize(category, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorizedError{category: category, err: err}
+}
+
+// `categorizedError` is an error of a category; see `Categorize`.
+type categorizedError struct {
+	category error
+	err      error
+}
+
+func (e *categorizedError) Error() string        { return e.err.Error() }
+func (e *categorizedError) Unwrap() error        { return e.err }
+func (e *categorizedError) Is(target error) bool { return target == e.category }
+
+// categorizedf formats an error of `category`, like `fmt.Errorf`.
+func categorizedf(category error, format string, args ...interface{}) error {
+	return Categorize(category, fmt.Errorf(format, args...))
+}
+
+// JoinErrors returns one error with the message of each of `errs` on a
+// line of its own, which is of each of their categories, or nil if
+// there are none.
+func JoinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &joinedErrors{errs: append([]error{}, errs...)}
+}
+
+// `joinedErrors` are the errors `JoinErrors` joins.
+type joinedErrors struct {
+	errs []error
+}
+
+func (e *joinedErrors) Error() string {
+	messages := []string{}
+	for _, err := range e.errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *joinedErrors) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// DanglingRefsError returns an error listing each of `refs`, of
+// `ErrDanglingRef`, or nil if there are none.
+func DanglingRefsError(refs []*DanglingRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	lines := []string{}
+	for _, ref := range refs {
+		lines = append(lines, ref.String())
+	}
+	return categorizedf(
+		ErrDanglingRef, "Spec has %d dangling references:\n%s", len(refs), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,95 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// categories are the error categories, by name, for messages.
+var categories = map[string]error{
+	"ErrSpecLoad":          ErrSpecLoad,
+	"ErrUnparsableName":    ErrUnparsableName,
+	"ErrUnsupportedSchema": ErrUnsupportedSchema,
+	"ErrDanglingRef":       ErrDanglingRef,
+	"ErrVerifyFailed":      ErrVerifyFailed,
+}
+
+// checkCategory checks that `err` is of the category named `expected`,
+// and of no other.
+func checkCategory(t *testing.T, what string, err error, expected string) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s: Expected an error of %s, got none", what, expected)
+		return
+	}
+	for name, category := range categories {
+		if is := errors.Is(err, category); is != (name == expected) {
+			t.Errorf("%s: Expected errors.Is(err, %s) to be %t, got %t for:\n%v", what, name, !is, is, err)
+		}
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	_, err := ReadSpec("swagger.json", strings.NewReader(`{"swagger": "2.0", "definitions": [`))
+	checkCategory(t, "a truncated spec", err, "ErrSpecLoad")
+	_, err = ReadSpecFrom(strings.NewReader(`{"swagger": "2.0"}`))
+	checkCategory(t, "a spec read as a model", err, "ErrSpecLoad")
+	_, err = ReadCRDs("crds.yaml", []byte("apiVersion: v1\nkind: ConfigMap\n"))
+	checkCategory(t, "a manifest read as a CRD", err, "ErrSpecLoad")
+
+	_, err = ParseName("io.k8s.api..v1.Widget")
+	checkCategory(t, "a malformed name", err, "ErrUnparsableName")
+	_, err = ParseName("com.example.v1.Widget")
+	checkCategory(t, "a name of an unknown prefix", err, "ErrUnparsableName")
+
+	_, err = ParseRef("#/parameters/body")
+	checkCategory(t, "a ref to a parameter", err, "ErrUnsupportedSchema")
+	crds, err := ReadCRDs("crd.yaml", []byte(crdV1YAML+"  - name: v2\n    served: false\n"))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	_, err = (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDs(crds, false)
+	checkCategory(t, "a v1 CRD version without a schema", err, "ErrUnsupportedSchema")
+
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(graphSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	err = DanglingRefsError(s.DanglingRefs())
+	checkCategory(t, "dangling references", err, "ErrDanglingRef")
+	if expected := "Spec has 1 dangling references:\n'io.k8s.api.apps.v1.Schema' property 'missing' refers to 'io.k8s.api.apps.v1.Missing'"; err.Error() != expected {
+		t.Errorf("Expected the references to be listed:\n%s\ngot:\n%v", expected, err)
+	}
+	if err := DanglingRefsError(nil); err != nil {
+		t.Errorf("Expected no error without dangling references, got %v", err)
+	}
+
+	// Categorizing keeps the message, and what's wrapped, and joined
+	// errors are of each of their categories.
+	wrapped := Categorize(ErrVerifyFailed, &MalformedNameError{Name: "io.k8s.api..v1.Widget", Position: 4})
+	var malformed *MalformedNameError
+	if !errors.As(wrapped, &malformed) || wrapped.Error() != malformed.Error() {
+		t.Errorf("Expected the categorized error to wrap the original, with its message, got %v", wrapped)
+	}
+	if Categorize(ErrVerifyFailed, nil) != nil {
+		t.Errorf("Expected categorizing no error to be no error")
+	}
+	joined := JoinErrors([]error{DanglingRefsError(s.DanglingRefs()), errors.New("Bad option")})
+	if !errors.Is(joined, ErrDanglingRef) || errors.Is(joined, ErrSpecLoad) {
+		t.Errorf("Expected the joined errors to be of only their categories")
+	}
+	if !strings.HasSuffix(joined.Error(), "refers to 'io.k8s.api.apps.v1.Missing'\nBad option") {
+		t.Errorf("Expected the messages one to a line, got:\n%v", joined)
+	}
+
+	// Models that don't read back are specs that don't load.
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("Failed to write model:\n%v", err)
+	}
+	_, err = ReadSpecFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	checkCategory(t, "a truncated model", err, "ErrSpecLoad")
+}
@@ -0,0 +1,120 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxExampleDepth is how deeply `FormatExample` nests objects and
+	// arrays before eliding them.
+	MaxExampleDepth = 3
+
+	// MaxExampleLines is how many lines of an example `FormatExample`
+	// keeps.
+	MaxExampleLines = 20
+
+	// ExampleElision marks where `FormatExample` left part of an
+	// example out.
+	ExampleElision = "…"
+)
+
+// FormatExample renders an example value from the spec (see
+// `Property.Example`) as indented JSON, for people. Objects and arrays
+// nested more than `MaxExampleDepth` deep are elided as `{…}` and
+// `[…]`, and examples longer than `MaxExampleLines` are cut off, ending
+// with a line of `…`. It reports whether any of the example was left
+// out, in which case the text isn't valid JSON.
+func FormatExample(example interface{}) (string, bool) {
+	var buf bytes.Buffer
+	truncated := formatExample(&buf, example, 0)
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) > MaxExampleLines {
+		lines = append(lines[:MaxExampleLines], ExampleElision)
+		truncated = true
+	}
+	return strings.Join(lines, "\n"), truncated
+}
+
+func formatExample(buf *bytes.Buffer, value interface{}, depth int) bool {
+	indent := strings.Repeat("  ", depth)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return false
+		} else if depth >= MaxExampleDepth {
+			buf.WriteString("{" + ExampleElision + "}")
+			return true
+		}
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		truncated := false
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + "  " + exampleScalar(key) + ": ")
+			truncated = formatExample(buf, v[key], depth+1) || truncated
+			if i < len(keys)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+		return truncated
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return false
+		} else if depth >= MaxExampleDepth {
+			buf.WriteString("[" + ExampleElision + "]")
+			return true
+		}
+		truncated := false
+		buf.WriteString("[\n")
+		for i, element := range v {
+			buf.WriteString(indent + "  ")
+			truncated = formatExample(buf, element, depth+1) || truncated
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+		return truncated
+	default:
+		buf.WriteString(exampleScalar(v))
+		return false
+	}
+}
+
+// `exampleScalar` renders a JSON scalar (or, failing that, any value)
+// compactly, without escaping HTML characters.
+func exampleScalar(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return ExampleElision
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// maxScalarExampleLength is the longest `ScalarExample` returns.
+const maxScalarExampleLength = 60
+
+// ScalarExample returns an example value as compact JSON if it's a
+// short scalar (e.g., `8080`, `"nginx:1.7.9"`, `true`, or `null`),
+// which fits on one line of a comment.
+func ScalarExample(example interface{}) (string, bool) {
+	switch example.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", false
+	}
+	text := exampleScalar(example)
+	return text, len(text) <= maxScalarExampleLength && !strings.Contains(text, "\\n")
+}
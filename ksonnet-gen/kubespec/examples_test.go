@@ -0,0 +1,159 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var examplesSpec = `{
+  "definitions": {
+    "io.k8s.api.core.v1.ServicePort": {
+      "example": {"name": "http", "port": 80},
+      "properties": {
+        "name": {"type": "string", "example": "http"},
+        "port": {"type": "integer", "example": 8080},
+        "deadline": {"type": "integer", "format": "int64", "example": 9007199254740993},
+        "weight": {"type": "number", "example": 0.5},
+        "enabled": {"type": "boolean", "example": false},
+        "selector": {"type": "string", "example": null},
+        "labels": {"type": "object", "additionalProperties": {"type": "string"}, "example": {"app": "web"}},
+        "ports": {"type": "array", "items": {"type": "integer"}, "example": [80, 443]},
+        "protocol": {"type": "string"},
+        "strategy": {
+          "type": "object",
+          "example": {"type": "Recreate"},
+          "properties": {"type": {"type": "string", "example": "Recreate"}}
+        }
+      }
+    }
+  }
+}`
+
+// Numbers are `json.Number`s, which keep their text.
+var exampleTests = map[PropertyName]interface{}{
+	"name":     "http",
+	"port":     json.Number("8080"),
+	"deadline": json.Number("9007199254740993"), // Not a float64.
+	"weight":   json.Number("0.5"),
+	"enabled":  false,
+	"selector": nil,
+	"labels":   map[string]interface{}{"app": "web"},
+	"ports":    []interface{}{json.Number("80"), json.Number("443")},
+}
+
+func loadExamplesSpec(t *testing.T, text []byte) *APISpec {
+	s := APISpec{}
+	if err := json.Unmarshal(text, &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	s.Text = text
+	return &s
+}
+
+func checkExamples(t *testing.T, s *APISpec) {
+	port := s.Definitions["io.k8s.api.core.v1.ServicePort"]
+	expected := map[string]interface{}{"name": "http", "port": json.Number("80")}
+	if !port.HasExample || !reflect.DeepEqual(port.Example, expected) {
+		t.Errorf("Expected definition example, got %#v", port.Example)
+	}
+	for name, expected := range exampleTests {
+		prop := port.Properties[name]
+		if !prop.HasExample || !reflect.DeepEqual(prop.Example, expected) {
+			t.Errorf("Expected '%s' to have example %#v, got %#v (%t)", name, expected, prop.Example, prop.HasExample)
+		}
+	}
+	if protocol := port.Properties["protocol"]; protocol.HasExample || protocol.Example != nil {
+		t.Errorf("Expected 'protocol' to have no example, got %#v", protocol.Example)
+	}
+}
+
+func TestExampleRoundTrip(t *testing.T) {
+	s := loadExamplesSpec(t, []byte(examplesSpec))
+	checkExamples(t, s)
+
+	// Examples survive writing a subset of the spec and loading it again.
+	text, err := s.MarshalSubset(false)
+	if err != nil {
+		t.Fatalf("Could not write subset:\n%v", err)
+	}
+	checkExamples(t, loadExamplesSpec(t, text))
+
+	// And synthesizing definitions for inline objects, which keep the
+	// example, as does the property that refers to them.
+	withInline, inline := s.WithInlineDefinitions(5)
+	if len(inline) != 1 {
+		t.Fatalf("Expected one synthesized definition, got %v", inline)
+	}
+	strategy := withInline.Definitions["io.k8s.api.core.v1.ServicePort"].Properties["strategy"]
+	def := withInline.Definitions[inline[0]]
+	expected := map[string]interface{}{"type": "Recreate"}
+	if !strategy.HasExample || !reflect.DeepEqual(strategy.Example, expected) {
+		t.Errorf("Expected 'strategy' to keep its example, got %#v", strategy.Example)
+	}
+	if !def.HasExample || !reflect.DeepEqual(def.Example, expected) ||
+		def.Properties["type"].Example != "Recreate" {
+		t.Errorf("Expected '%s' to keep the examples, got %#v", inline[0], def)
+	}
+}
+
+func TestFormatExample(t *testing.T) {
+	text, truncated := FormatExample(map[string]interface{}{
+		"port": float64(80), "name": "<http>", "empty": []interface{}{},
+	})
+	expected := "{\n  \"empty\": [],\n  \"name\": \"<http>\",\n  \"port\": 80\n}"
+	if text != expected || truncated {
+		t.Errorf("Expected:\n%s\ngot (truncated: %t):\n%s", expected, truncated, text)
+	}
+
+	if text, truncated := FormatExample(nil); text != "null" || truncated {
+		t.Errorf("Expected 'null', got '%s' (truncated: %t)", text, truncated)
+	}
+
+	deep := map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{
+		map[string]interface{}{"c": "d"},
+	}}}
+	text, truncated = FormatExample(deep)
+	expected = "{\n  \"a\": {\n    \"b\": [\n      {…}\n    ]\n  }\n}"
+	if text != expected || !truncated {
+		t.Errorf("Expected deep example to be elided as:\n%s\ngot (truncated: %t):\n%s", expected, truncated, text)
+	}
+
+	long := []interface{}{}
+	for i := 0; i < 2*MaxExampleLines; i++ {
+		long = append(long, float64(i))
+	}
+	text, truncated = FormatExample(long)
+	lines := strings.Split(text, "\n")
+	if len(lines) != MaxExampleLines+1 || lines[MaxExampleLines] != ExampleElision || !truncated {
+		t.Errorf("Expected long example to be cut off after %d lines, got (truncated: %t):\n%s",
+			MaxExampleLines, truncated, text)
+	}
+}
+
+var scalarExampleTests = []struct {
+	example  interface{}
+	expected string
+	ok       bool
+}{
+	{"nginx:1.7.9", `"nginx:1.7.9"`, true},
+	{float64(8080), "8080", true},
+	{true, "true", true},
+	{nil, "null", true},
+	{"a<b", `"a<b"`, true},
+	{strings.Repeat("x", 100), "", false},
+	{"two\nlines", "", false},
+	{map[string]interface{}{"a": "b"}, "", false},
+	{[]interface{}{float64(1)}, "", false},
+}
+
+func TestScalarExample(t *testing.T) {
+	for _, test := range scalarExampleTests {
+		actual, ok := ScalarExample(test.example)
+		if ok != test.ok || (ok && actual != test.expected) {
+			t.Errorf("Expected %#v to give '%s' (%t), got '%s' (%t)",
+				test.example, test.expected, test.ok, actual, ok)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefinitionExplanation is a human-oriented breakdown of a single
+// definition in the spec: its parsed name, description, example,
+// properties, and vendor extensions. Referenced definitions can be
+// expanded inline, up to some depth. Examples are kept as the JSON the
+// spec gave, so that an example of `null` is told from none.
+type DefinitionExplanation struct {
+	Name        DefinitionName         `json:"name"`
+	Parsed      *ParsedName            `json:"parsed,omitempty"`
+	ParseError  string                 `json:"parseError,omitempty"`
+	Description string                 `json:"description"`
+	Example     json.RawMessage        `json:"example,omitempty"`
+	Extensions  map[string]string      `json:"extensions,omitempty"`
+	Properties  []*PropertyExplanation `json:"properties"`
+}
+
+// PropertyExplanation describes a single property of an explained
+// definition. `Definition` is the inline expansion of `Ref` (or
+// `ItemsRef`), if the expansion depth allowed it; `Cycle` is set if
+// expanding it would recurse into a definition already being
+// expanded.
+type PropertyExplanation struct {
+	Name        PropertyName           `json:"name"`
+	Type        string                 `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Required    bool                   `json:"required"`
+	Ref         DefinitionName         `json:"ref,omitempty"`
+	ItemsRef    DefinitionName         `json:"itemsRef,omitempty"`
+	Description string                 `json:"description"`
+	Example     json.RawMessage        `json:"example,omitempty"`
+	Extensions  map[string]string      `json:"extensions,omitempty"`
+	Definition  *DefinitionExplanation `json:"definition,omitempty"`
+	Cycle       bool                   `json:"cycle,omitempty"`
+}
+
+// FindDefinition looks up a definition either by its raw name (e.g.,
+// `io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment`), or by its
+// group/version/kind (e.g., `apps/v1beta1/Deployment`). Core kinds can
+// be given as either `v1/Pod` or `core/v1/Pod`. The query is trimmed
+// first (see `TrimName`).
+func (s *APISpec) FindDefinition(query string) (DefinitionName, error) {
+	query = TrimName(query)
+	if _, ok := s.Definitions[DefinitionName(query)]; ok {
+		return DefinitionName(query), nil
+	}
+
+	split := strings.Split(query, "/")
+	var gvk TopLevelSpec
+	switch len(split) {
+	case 2:
+		gvk = TopLevelSpec{Version: VersionString(split[0]), Kind: ObjectKind(split[1])}
+	case 3:
+		gvk = TopLevelSpec{
+			Group:   GroupName(split[0]),
+			Version: VersionString(split[1]),
+			Kind:    ObjectKind(split[2]),
+		}
+		if gvk.Group == "core" {
+			gvk.Group = ""
+		}
+	default:
+		return "", fmt.Errorf(
+			"Could not find definition '%s'; expected a definition name or group/version/kind", query)
+	}
+
+	matches := []string{}
+	for name, def := range s.Definitions {
+		for _, spec := range def.TopLevelSpecs {
+			if *spec == gvk {
+				matches = append(matches, string(name))
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("Could not find definition with group/version/kind '%s'", query)
+	case 1:
+		return DefinitionName(matches[0]), nil
+	default:
+		sort.Strings(matches)
+		return "", fmt.Errorf(
+			"Group/version/kind '%s' is ambiguous, matching: %s",
+			query, strings.Join(matches, ", "))
+	}
+}
+
+// Explain builds a `DefinitionExplanation` for the definition `name`,
+// expanding referenced definitions inline to `depth` levels (0 expands
+// nothing).
+func (s *APISpec) Explain(
+	name DefinitionName, depth int,
+) (*DefinitionExplanation, error) {
+	return s.explainDefinition(name, depth, parseName)
+}
+
+// Explain is like `APISpec.Explain`, but parses names with the parser,
+// and so accepts its `Prefixes`.
+func (p *Parser) Explain(
+	s *APISpec, name DefinitionName, depth int,
+) (*DefinitionExplanation, error) {
+	return s.explainDefinition(name, depth, p.ParseName)
+}
+
+func (s *APISpec) explainDefinition(
+	name DefinitionName, depth int, parse func(DefinitionName) (ParsedName, error),
+) (*DefinitionExplanation, error) {
+	if _, ok := s.Definitions[name]; !ok {
+		return nil, fmt.Errorf("Could not find definition '%s'", name)
+	}
+	return s.explain(name, depth, parse, map[DefinitionName]bool{}), nil
+}
+
+func (s *APISpec) explain(
+	name DefinitionName, depth int,
+	parse func(DefinitionName) (ParsedName, error), expanding map[DefinitionName]bool,
+) *DefinitionExplanation {
+	def := s.Definitions[name]
+	expanding[name] = true
+	defer delete(expanding, name)
+
+	explanation := &DefinitionExplanation{
+		Name:        name,
+		Description: def.Description,
+		Example:     explainExample(def.HasExample, def.Example),
+		Properties:  []*PropertyExplanation{},
+	}
+	if parsed, err := parse(name); err != nil {
+		explanation.ParseError = err.Error()
+	} else {
+		explanation.Parsed = &parsed
+	}
+	if len(def.TopLevelSpecs) > 0 {
+		gvks := []string{}
+		for _, gvk := range def.TopLevelSpecs {
+			gvks = append(gvks, gvk.String())
+		}
+		explanation.Extensions = map[string]string{
+			"x-kubernetes-group-version-kind": strings.Join(gvks, ", "),
+		}
+	}
+
+	required := map[string]bool{}
+	for _, propName := range def.Required {
+		required[propName] = true
+	}
+
+	for _, propName := range def.Properties.sortedNames() {
+		prop := def.Properties[propName]
+		pe := &PropertyExplanation{
+			Name:        propName,
+			Format:      prop.Format,
+			Required:    required[string(propName)],
+			Description: prop.Description,
+			Example:     explainExample(prop.HasExample, prop.Example),
+			Extensions:  prop.extensions(),
+		}
+		if prop.Type != nil {
+			pe.Type = string(*prop.Type)
+		}
+
+		var ref *ObjectRef
+		if prop.Ref != nil {
+			ref = prop.Ref
+		} else if prop.Items.Ref != nil {
+			ref = prop.Items.Ref
+		}
+		if ref != nil {
+			refName, err := parseRef(*ref)
+			if err == nil {
+				if prop.Ref != nil {
+					pe.Ref = *refName
+				} else {
+					pe.ItemsRef = *refName
+				}
+
+				_, ok := s.Definitions[*refName]
+				if expanding[*refName] {
+					pe.Cycle = true
+				} else if ok && depth > 0 {
+					pe.Definition = s.explain(*refName, depth-1, parse, expanding)
+				}
+			}
+		}
+
+		explanation.Properties = append(explanation.Properties, pe)
+	}
+
+	return explanation
+}
+
+// explainExample returns an example as JSON, or nil if there isn't
+// one.
+func explainExample(hasExample bool, example interface{}) json.RawMessage {
+	if !hasExample {
+		return nil
+	}
+	text, err := json.Marshal(example)
+	if err != nil {
+		return nil
+	}
+	return text
+}
+
+// extensions returns the vendor extensions set on a property, keyed
+// by extension name.
+func (p *Property) extensions() map[string]string {
+	exts := map[string]string{}
+	if p.PatchMergeKey != "" {
+		exts["x-kubernetes-patch-merge-key"] = p.PatchMergeKey
+	}
+	if p.PatchStrategy != "" {
+		exts["x-kubernetes-patch-strategy"] = p.PatchStrategy
+	}
+	if p.PreserveUnknownFields {
+		exts["x-kubernetes-preserve-unknown-fields"] = "true"
+	}
+	if p.EmbeddedResource {
+		exts["x-kubernetes-embedded-resource"] = "true"
+	}
+	if p.ListType != "" {
+		exts["x-kubernetes-list-type"] = string(p.ListType)
+	}
+	if len(p.ListMapKeys) > 0 {
+		exts["x-kubernetes-list-map-keys"] = strings.Join(p.ListMapKeys, ",")
+	}
+	if len(exts) == 0 {
+		return nil
+	}
+	return exts
+}
+
+func (ps Properties) sortedNames() []PropertyName {
+	names := []PropertyName{}
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
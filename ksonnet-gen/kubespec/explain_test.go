@@ -0,0 +1,146 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var explainSpec = `{
+  "definitions": {
+    "io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment": {
+      "description": "Deployment enables declarative updates for Pods and ReplicaSets.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.apis.apps.v1beta1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "apps", "Version": "v1beta1", "Kind": "Deployment"}]
+    },
+    "io.k8s.kubernetes.pkg.apis.extensions.v1beta1.Deployment": {
+      "properties": {},
+      "x-kubernetes-group-version-kind": [{"Group": "extensions", "Version": "v1beta1", "Kind": "Deployment"}]
+    },
+    "io.k8s.kubernetes.pkg.apis.apps.v1beta1.DeploymentSpec": {
+      "required": ["template"],
+      "properties": {
+        "replicas": {"type": "integer", "format": "int32"},
+        "template": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.PodTemplateSpec"}
+      }
+    },
+    "io.k8s.kubernetes.pkg.api.v1.PodTemplateSpec": {
+      "properties": {}
+    },
+    "io.k8s.kubernetes.pkg.api.v1.Pod": {
+      "x-kubernetes-group-version-kind": [{"Group": "", "Version": "v1", "Kind": "Pod"}]
+    },
+    "io.k8s.apiextensions-apiserver.pkg.apis.apiextensions.v1beta1.JSONSchemaProps": {
+      "properties": {
+        "items": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.apiextensions-apiserver.pkg.apis.apiextensions.v1beta1.JSONSchemaProps"}},
+        "not": {"$ref": "#/definitions/io.k8s.apiextensions-apiserver.pkg.apis.apiextensions.v1beta1.JSONSchemaProps"}
+      }
+    },
+    "some.unparsable.Name": {}
+  }
+}`
+
+func loadExplainSpec(t *testing.T) *APISpec {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(explainSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	return &s
+}
+
+var findDefinitionTests = map[string]DefinitionName{
+	"io.k8s.kubernetes.pkg.api.v1.Pod": "io.k8s.kubernetes.pkg.api.v1.Pod",
+	"v1/Pod":                           "io.k8s.kubernetes.pkg.api.v1.Pod",
+	"core/v1/Pod":                      "io.k8s.kubernetes.pkg.api.v1.Pod",
+	"apps/v1beta1/Deployment":          "io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment",
+	"extensions/v1beta1/Deployment":    "io.k8s.kubernetes.pkg.apis.extensions.v1beta1.Deployment",
+	"apps/v1beta2/Deployment":          "",
+	"Deployment":                       "",
+}
+
+func TestFindDefinition(t *testing.T) {
+	s := loadExplainSpec(t)
+	for query, expected := range findDefinitionTests {
+		actual, err := s.FindDefinition(query)
+		if expected == "" && err == nil {
+			t.Errorf("Expected finding '%s' to fail, got '%s'", query, actual)
+		} else if expected != "" && actual != expected {
+			t.Errorf("Expected finding '%s' to return '%s', got '%s' (%v)", query, expected, actual, err)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	s := loadExplainSpec(t)
+
+	shallow, err := s.Explain("io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment", 0)
+	if err != nil {
+		t.Fatalf("Failed to explain:\n%v", err)
+	}
+	if shallow.Parsed == nil || shallow.Parsed.Kind != "Deployment" {
+		t.Errorf("Expected parsed name with kind 'Deployment', got %#v", shallow.Parsed)
+	}
+	if shallow.Extensions["x-kubernetes-group-version-kind"] != "apps/v1beta1/Deployment" {
+		t.Errorf("Unexpected extensions: %#v", shallow.Extensions)
+	}
+	if spec := shallow.Properties[0]; spec.Ref != "io.k8s.kubernetes.pkg.apis.apps.v1beta1.DeploymentSpec" || spec.Definition != nil {
+		t.Errorf("Expected unexpanded ref to 'DeploymentSpec', got %#v", spec)
+	}
+
+	deep, err := s.Explain("io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment", 2)
+	if err != nil {
+		t.Fatalf("Failed to explain:\n%v", err)
+	}
+	spec := deep.Properties[0].Definition
+	if spec == nil || len(spec.Properties) != 2 {
+		t.Fatalf("Expected 'spec' to be expanded, got %#v", deep.Properties[0])
+	}
+	replicas, template := spec.Properties[0], spec.Properties[1]
+	if replicas.Type != "integer" || replicas.Format != "int32" || replicas.Required {
+		t.Errorf("Unexpected explanation of 'replicas': %#v", replicas)
+	}
+	if !template.Required || template.Definition == nil {
+		t.Errorf("Expected 'template' to be required and expanded: %#v", template)
+	}
+
+	// Self-references are flagged, rather than expanded forever.
+	props, err := s.Explain(
+		"io.k8s.apiextensions-apiserver.pkg.apis.apiextensions.v1beta1.JSONSchemaProps", 10)
+	if err != nil {
+		t.Fatalf("Failed to explain:\n%v", err)
+	}
+	for _, prop := range props.Properties {
+		if !prop.Cycle || prop.Definition != nil {
+			t.Errorf("Expected '%s' to be flagged as a cycle: %#v", prop.Name, prop)
+		}
+	}
+
+	unparsable, err := s.Explain("some.unparsable.Name", 1)
+	if err != nil || unparsable.ParseError == "" || unparsable.Parsed != nil {
+		t.Errorf("Expected explanation with parse error, got %#v (%v)", unparsable, err)
+	}
+
+	if _, err := s.Explain("io.k8s.kubernetes.pkg.api.v1.Missing", 1); err == nil {
+		t.Errorf("Expected explaining a missing definition to fail")
+	}
+}
+
+func TestExplainExamples(t *testing.T) {
+	s := loadExamplesSpec(t, []byte(examplesSpec))
+	explanation, err := s.Explain("io.k8s.api.core.v1.ServicePort", 0)
+	if err != nil {
+		t.Fatalf("Failed to explain:\n%v", err)
+	}
+	if string(explanation.Example) != `{"name":"http","port":80}` {
+		t.Errorf("Unexpected definition example '%s'", explanation.Example)
+	}
+
+	// An example of `null` is kept, and told from none.
+	expected := map[PropertyName]string{"protocol": "", "selector": "null", "port": "8080"}
+	for _, pe := range explanation.Properties {
+		if text, ok := expected[pe.Name]; ok && string(pe.Example) != text {
+			t.Errorf("Expected '%s' to have example '%s', got '%s'", pe.Name, text, pe.Example)
+		}
+	}
+}
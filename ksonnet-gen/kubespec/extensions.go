@@ -0,0 +1,199 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KnownExtensions returns the set of vendor extensions (e.g.,
+// `x-kubernetes-group-version-kind`) that kubespec models, for
+// `UnknownExtensions`. The set is the caller's; consumers that handle
+// further extensions themselves can add them to it.
+func KnownExtensions() map[string]bool {
+	return map[string]bool{
+		"x-kubernetes-action":                  true,
+		"x-kubernetes-embedded-resource":       true,
+		"x-kubernetes-group-version-kind":      true,
+		"x-kubernetes-int-or-string":           true,
+		"x-kubernetes-list-map-keys":           true,
+		"x-kubernetes-list-type":               true,
+		"x-kubernetes-patch-merge-key":         true,
+		"x-kubernetes-patch-strategy":          true,
+		"x-kubernetes-preserve-unknown-fields": true,
+		"x-kubernetes-unions":                  true,
+	}
+}
+
+// Extensions holds the raw vendor extensions (i.e., the fields whose
+// names begin with `x-`) of some part of the spec, keyed by name.
+type Extensions map[string]json.RawMessage
+
+const extensionPrefix = "x-"
+
+// unmarshalFields returns the raw fields of a JSON object, keyed by
+// name.
+func unmarshalFields(data []byte) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// unmarshalNumbers deserializes JSON like `json.Unmarshal`, except
+// that numbers are decoded as `json.Number`s, which keep their text,
+// rather than as `float64`s, which can't hold every `int64` exactly.
+func unmarshalNumbers(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// unmarshalExtensions collects the vendor extensions of a JSON object.
+func unmarshalExtensions(data []byte) (Extensions, error) {
+	fields, err := unmarshalFields(data)
+	if err != nil {
+		return nil, err
+	}
+	return extensionsOf(fields), nil
+}
+
+// extensionsOf returns the vendor extensions among the raw fields of a
+// JSON object.
+func extensionsOf(fields map[string]json.RawMessage) Extensions {
+	var exts Extensions
+	for name, value := range fields {
+		if strings.HasPrefix(name, extensionPrefix) {
+			if exts == nil {
+				exts = Extensions{}
+			}
+			exts[name] = value
+		}
+	}
+	return exts
+}
+
+// exampleField is the name of the field of a schema with its example
+// value.
+const exampleField = "example"
+
+// UnmarshalJSON deserializes a `SchemaDefinition`, retaining all of its
+// vendor extensions, including those it doesn't model, whether it has
+// an example (which may be `null`), and the order of its properties.
+// Numbers are decoded as `json.Number`s; see `unmarshalNumbers`.
+func (sd *SchemaDefinition) UnmarshalJSON(data []byte) error {
+	type schemaDefinition SchemaDefinition
+	if err := unmarshalNumbers(data, (*schemaDefinition)(sd)); err != nil {
+		return err
+	}
+	fields, err := unmarshalFields(data)
+	if err != nil {
+		return err
+	}
+	sd.Extensions = extensionsOf(fields)
+	_, sd.HasExample = fields[exampleField]
+	sd.PropertyOrder, err = declaredNames(fields["properties"])
+	return err
+}
+
+// UnmarshalJSON deserializes a `Property`, retaining all of its vendor
+// extensions, including those it doesn't model, and whether it has an
+// example (which may be `null`). Numbers are decoded as `json.Number`s;
+// see `unmarshalNumbers`.
+func (p *Property) UnmarshalJSON(data []byte) error {
+	type property Property
+	if err := unmarshalNumbers(data, (*property)(p)); err != nil {
+		return err
+	}
+	fields, err := unmarshalFields(data)
+	if err != nil {
+		return err
+	}
+	p.Extensions = extensionsOf(fields)
+	_, p.HasExample = fields[exampleField]
+	return nil
+}
+
+// UnmarshalJSON deserializes an `Operation`, retaining all of its
+// vendor extensions, including those it doesn't model.
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type operation Operation
+	if err := json.Unmarshal(data, (*operation)(op)); err != nil {
+		return err
+	}
+	exts, err := unmarshalExtensions(data)
+	op.Extensions = exts
+	return err
+}
+
+// UnknownExtension is a vendor extension that appears in a spec, but
+// isn't known (see `UnknownExtensions`).
+type UnknownExtension struct {
+	Name    string
+	Example string // Where it appears, e.g., `io.k8s.api.core.v1.PodSpec.containers`.
+	Count   int    // How many times it appears.
+}
+
+// UnknownExtensions returns every vendor extension used in the spec's
+// definitions, properties, and operations that isn't in `known` (e.g.,
+// `KnownExtensions()`), sorted by name.
+func (s *APISpec) UnknownExtensions(known map[string]bool) []*UnknownExtension {
+	unknown := map[string]*UnknownExtension{}
+	record := func(exts Extensions, location string) {
+		for name := range exts {
+			if known[name] {
+				continue
+			}
+			ue, ok := unknown[name]
+			if !ok {
+				ue = &UnknownExtension{Name: name, Example: location}
+				unknown[name] = ue
+			} else if location < ue.Example {
+				// Keep the first location, so that reports are stable.
+				ue.Example = location
+			}
+			ue.Count++
+		}
+	}
+
+	for defName, def := range s.Definitions {
+		record(def.Extensions, string(defName))
+		for propName, prop := range def.Properties {
+			prop.recordExtensions(fmt.Sprintf("%s.%s", defName, propName), record)
+		}
+	}
+	for path, item := range s.Paths {
+		for _, op := range item.Operations() {
+			record(op.Extensions, fmt.Sprintf("%s (%s)", path, op.OperationID))
+		}
+	}
+
+	names := []string{}
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unknownExts := []*UnknownExtension{}
+	for _, name := range names {
+		unknownExts = append(unknownExts, unknown[name])
+	}
+	return unknownExts
+}
+
+// recordExtensions records the extensions of a property, and of the
+// properties nested in it.
+func (p *Property) recordExtensions(
+	location string, record func(exts Extensions, location string),
+) {
+	record(p.Extensions, location)
+	for propName, prop := range p.Properties {
+		prop.recordExtensions(fmt.Sprintf("%s.%s", location, propName), record)
+	}
+	if ap := p.AdditionalProperties; ap != nil && ap.Schema != nil {
+		ap.Schema.recordExtensions(location+"[*]", record)
+	}
+}
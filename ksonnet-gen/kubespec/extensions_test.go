@@ -0,0 +1,78 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var extensionsSpec = `{
+  "definitions": {
+    "io.k8s.api.core.v1.Pod": {
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "Pod", "version": "v1"}],
+      "x-kubernetes-map-type": "atomic",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.core.v1.PodSpec"}
+      }
+    },
+    "io.k8s.api.core.v1.PodSpec": {
+      "properties": {
+        "containers": {
+          "type": "array",
+          "x-kubernetes-list-type": "map",
+          "x-kubernetes-list-map-keys": ["name"]
+        },
+        "port": {"type": "string", "x-kubernetes-int-or-string": true, "x-kubernetes-validations": [{"rule": "self != ''"}]},
+        "overhead": {
+          "type": "object",
+          "additionalProperties": {"type": "string", "x-kubernetes-int-or-string": true, "x-kubernetes-validations": [{"rule": "self != ''"}]}
+        }
+      }
+    }
+  },
+  "paths": {
+    "/api/v1/pods": {
+      "get": {
+        "operationId": "listCoreV1PodForAllNamespaces",
+        "x-kubernetes-action": "list",
+        "x-codegen-request-body-name": "body"
+      }
+    }
+  }
+}`
+
+func TestUnknownExtensions(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(extensionsSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	// Modeled fields are still populated alongside the raw extensions.
+	containers := s.Definitions["io.k8s.api.core.v1.PodSpec"].Properties["containers"]
+	if containers.ListType != ListTypeMap || len(containers.Extensions) != 2 {
+		t.Errorf("Expected list type and raw extensions, got %#v", containers)
+	}
+
+	expected := []UnknownExtension{
+		{"x-codegen-request-body-name", "/api/v1/pods (listCoreV1PodForAllNamespaces)", 1},
+		{"x-kubernetes-map-type", "io.k8s.api.core.v1.Pod", 1},
+		{"x-kubernetes-validations", "io.k8s.api.core.v1.PodSpec.overhead[*]", 2},
+	}
+	actual := s.UnknownExtensions(KnownExtensions())
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d unknown extensions, got %d: %#v", len(expected), len(actual), actual)
+	}
+	for i, ue := range actual {
+		if *ue != expected[i] {
+			t.Errorf("Expected unknown extension %#v, got %#v", expected[i], *ue)
+		}
+	}
+
+	known := KnownExtensions()
+	known["x-kubernetes-map-type"] = true
+	if unknown := s.UnknownExtensions(known); len(unknown) != 2 {
+		t.Errorf("Expected extensions added to the known set to be known, got %#v", unknown)
+	}
+	if KnownExtensions()["x-kubernetes-map-type"] {
+		t.Errorf("Expected 'KnownExtensions' to return a fresh set")
+	}
+}
@@ -0,0 +1,71 @@
+package kubespec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// FetchSpec downloads and deserializes the spec served at `url` (e.g.,
+// a cluster's `/openapi/v2`), using `client`, or `http.DefaultClient`
+// if it's nil. The request is cancelled if `ctx` is, in which case the
+// error wraps `ctx.Err()`. The spec's `FilePath` is the current
+// directory, since it has no file of its own. The spec may be JSON or
+// YAML; see `UnmarshalSpec`. Errors are of `ErrSpecLoad`.
+func FetchSpec(ctx context.Context, client *http.Client, url string) (_ *APISpec, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	text, path, err := fetch(ctx, client, url, "spec", "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := UnmarshalSpec(path, text)
+	if err != nil {
+		return nil, fmt.Errorf("Could not deserialize spec from '%s':\n%v", url, err)
+	}
+	digest := sha256.Sum256(text)
+	s.FilePath, s.SourceSHA256 = ".", hex.EncodeToString(digest[:])
+	return s, nil
+}
+
+// `fetch` downloads what's served at `url`, returning it with the path
+// of the URL, which decides its format (see `UnmarshalSpec`). `what`
+// names it in errors, e.g., `spec`, and `accept` is the media type
+// asked for. Errors wrap `ctx.Err()` if `ctx` is cancelled.
+func fetch(
+	ctx context.Context, client *http.Client, url, what, accept string,
+) (text []byte, path string, err error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not fetch %s from '%s':\n%v", what, url, err)
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", fmt.Errorf("Could not fetch %s from '%s': %w", what, url, ctx.Err())
+		}
+		return nil, "", fmt.Errorf("Could not fetch %s from '%s':\n%v", what, url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf(
+			"Could not fetch %s from '%s': server responded '%s'", what, url, resp.Status)
+	}
+
+	text, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, "", fmt.Errorf("Could not fetch %s from '%s': %w", what, url, ctx.Err())
+		}
+		return nil, "", fmt.Errorf("Could not read %s from '%s':\n%v", what, url, err)
+	}
+	return text, req.URL.Path, nil
+}
@@ -0,0 +1,53 @@
+package kubespec
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchSpec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openapi/v2" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(searchSpec))
+	}))
+	defer server.Close()
+
+	s, err := FetchSpec(context.Background(), nil, server.URL+"/openapi/v2")
+	if err != nil {
+		t.Fatalf("Failed to fetch spec:\n%v", err)
+	}
+	if len(s.Definitions) != 5 || string(s.Text) != searchSpec || s.FilePath != "." {
+		t.Errorf("Expected fetched spec to be deserialized, got %d definitions", len(s.Definitions))
+	}
+
+	_, err = FetchSpec(context.Background(), server.Client(), server.URL+"/missing")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected an error naming the status, got %v", err)
+	}
+}
+
+func TestFetchSpecCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := FetchSpec(ctx, nil, server.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to wrap 'context.Canceled', got %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package kubespec
+
+import "sync"
+
+// Filter returns a new spec with only the definitions for which `keep`
+// returns true, along with every definition they refer to, directly
+// or transitively, so that the result is self-contained. For example,
+// keeping the top-level definitions of the `apps` group also keeps
+// `ObjectMeta` and `PodSpec`. The receiver isn't modified, and the
+// definitions are shared with it.
+func (s *APISpec) Filter(keep func(name DefinitionName, def *SchemaDefinition) bool) *APISpec {
+	return s.filter(keep, s.Closure)
+}
+
+func (s *APISpec) filter(
+	keep func(name DefinitionName, def *SchemaDefinition) bool,
+	closure func(DefinitionName) map[DefinitionName]string,
+) *APISpec {
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		if _, ok := definitions[name]; ok || !keep(name, def) {
+			continue
+		}
+		for reachable := range closure(name) {
+			if def, ok := s.Definitions[reachable]; ok {
+				definitions[reachable] = def
+			}
+		}
+	}
+	return s.withDefinitions(definitions)
+}
+
+// ClosureCache caches the closures of the definitions of a spec (see
+// `Closure`), so that the spec can be filtered several times, e.g.,
+// once per variant of a library, without walking the same references
+// each time. It's safe for concurrent use.
+type ClosureCache struct {
+	spec *APISpec
+
+	mu       sync.Mutex
+	closures map[DefinitionName]map[DefinitionName]string
+}
+
+// NewClosureCache returns an empty cache of the closures of `s`, which
+// mustn't be modified while the cache is in use.
+func NewClosureCache(s *APISpec) *ClosureCache {
+	return &ClosureCache{spec: s, closures: map[DefinitionName]map[DefinitionName]string{}}
+}
+
+// Closure is `Closure` of the cached spec, computed once per `root`.
+// The result is shared, so it mustn't be modified.
+func (c *ClosureCache) Closure(root DefinitionName) map[DefinitionName]string {
+	c.mu.Lock()
+	closure, ok := c.closures[root]
+	c.mu.Unlock()
+	if ok {
+		return closure
+	}
+	// Computed without the lock, so that concurrent filters don't wait
+	// on each other; at worst, a closure is computed twice.
+	closure = c.spec.Closure(root)
+	c.mu.Lock()
+	c.closures[root] = closure
+	c.mu.Unlock()
+	return closure
+}
+
+// Filter is `Filter` of the cached spec, using the cached closures.
+func (c *ClosureCache) Filter(keep func(name DefinitionName, def *SchemaDefinition) bool) *APISpec {
+	return c.spec.filter(keep, c.Closure)
+}
+
+// Exclude returns a new spec without the definitions for which
+// `exclude` returns true. Unlike `Filter`, it doesn't follow
+// references, so properties that refer to an excluded definition are
+// left dangling. The receiver isn't modified, and the definitions are
+// shared with it.
+func (s *APISpec) Exclude(exclude func(name DefinitionName, def *SchemaDefinition) bool) *APISpec {
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		if !exclude(name, def) {
+			definitions[name] = def
+		}
+	}
+	return s.withDefinitions(definitions)
+}
+
+// withDefinitions returns a new spec like `s`, but with `definitions`.
+// Caches computed from the rest of the spec are recomputed, since
+// they can't be shared without sharing their synchronization.
+func (s *APISpec) withDefinitions(definitions SchemaDefinitions) *APISpec {
+	return &APISpec{
+		SwaggerVersion:      s.SwaggerVersion,
+		Info:                s.Info,
+		Definitions:         definitions,
+		Paths:               s.Paths,
+		SecurityDefinitions: s.SecurityDefinitions,
+		FilePath:            s.FilePath,
+		Text:                s.Text,
+		SourceSHA256:        s.SourceSHA256,
+		MetadataProblems:    s.MetadataProblems,
+	}
+}
+
+// InGroups returns a `Filter` predicate that keeps the top-level
+// definitions of the API groups in `groups`, each named either as in
+// the definition name (e.g., `apps`, `rbac`, or `core`) or as in its
+// group/version/kind (e.g., `rbac.authorization.k8s.io`).
+func InGroups(groups []GroupName) func(DefinitionName, *SchemaDefinition) bool {
+	return inGroups(groups, parseName)
+}
+
+// InGroups is like the `InGroups` function, but parses names with the
+// parser, and so accepts its `Prefixes`.
+func (p *Parser) InGroups(groups []GroupName) func(DefinitionName, *SchemaDefinition) bool {
+	return inGroups(groups, p.ParseName)
+}
+
+func inGroups(
+	groups []GroupName, parse func(DefinitionName) (ParsedName, error),
+) func(DefinitionName, *SchemaDefinition) bool {
+	included := map[GroupName]bool{}
+	for _, group := range groups {
+		included[group] = true
+	}
+	return func(name DefinitionName, def *SchemaDefinition) bool {
+		if len(def.TopLevelSpecs) == 0 {
+			return false
+		}
+		if parsed, err := parse(name); err == nil {
+			group := GroupName("core")
+			if parsed.HasGroup() {
+				group = parsed.Group
+			}
+			if included[group] {
+				return true
+			}
+		}
+		for _, tls := range def.TopLevelSpecs {
+			if included[tls.Group] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// InGroupVersions returns a `Filter` predicate that keeps the
+// top-level definitions of the versions in `versions`, with their
+// groups named as for `InGroups`; e.g., `apps/v1beta2` keeps
+// `io.k8s.api.apps.v1beta2.Deployment`, but not
+// `io.k8s.api.apps.v1beta1.Deployment`.
+func InGroupVersions(versions []GroupVersion) func(DefinitionName, *SchemaDefinition) bool {
+	return inGroupVersions(versions, parseName)
+}
+
+// InGroupVersions is like the `InGroupVersions` function, but parses
+// names with the parser, and so accepts its `Prefixes`.
+func (p *Parser) InGroupVersions(versions []GroupVersion) func(DefinitionName, *SchemaDefinition) bool {
+	return inGroupVersions(versions, p.ParseName)
+}
+
+func inGroupVersions(
+	versions []GroupVersion, parse func(DefinitionName) (ParsedName, error),
+) func(DefinitionName, *SchemaDefinition) bool {
+	included := map[GroupVersion]bool{}
+	for _, gv := range versions {
+		included[gv] = true
+	}
+	return func(name DefinitionName, def *SchemaDefinition) bool {
+		if len(def.TopLevelSpecs) == 0 {
+			return false
+		}
+		if parsed, err := parse(name); err == nil && parsed.HasVersion() {
+			group := GroupName("core")
+			if parsed.HasGroup() {
+				group = parsed.Group
+			}
+			if included[GroupVersion{Group: group, Version: parsed.Version}] {
+				return true
+			}
+		}
+		for _, tls := range def.TopLevelSpecs {
+			if included[GroupVersion{Group: tls.Group, Version: tls.Version}] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ExcludingStages returns a `Filter` predicate that keeps every
+// definition except those in versions of the stages in `stages`
+// (e.g., `StageAlpha`), by the version in their name. Since `Filter`
+// keeps what the remaining definitions refer to, a definition in an
+// excluded version survives if a kept one uses it. Definitions whose
+// names don't parse are kept.
+func ExcludingStages(stages []VersionStage) func(DefinitionName, *SchemaDefinition) bool {
+	return excludingStages(stages, parseName)
+}
+
+// ExcludingStages is like the `ExcludingStages` function, but parses
+// names with the parser, and so accepts its `Prefixes`.
+func (p *Parser) ExcludingStages(stages []VersionStage) func(DefinitionName, *SchemaDefinition) bool {
+	return excludingStages(stages, p.ParseName)
+}
+
+func excludingStages(
+	stages []VersionStage, parse func(DefinitionName) (ParsedName, error),
+) func(DefinitionName, *SchemaDefinition) bool {
+	excluded := map[VersionStage]bool{}
+	for _, stage := range stages {
+		excluded[stage] = true
+	}
+	return func(name DefinitionName, def *SchemaDefinition) bool {
+		parsed, err := parse(name)
+		return err != nil || !parsed.HasVersion() || !excluded[parsed.Version.Stage()]
+	}
+}
@@ -0,0 +1,240 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	s := loadSearchSpec(t)
+
+	filtered := s.Filter(func(name DefinitionName, def *SchemaDefinition) bool {
+		return len(def.TopLevelSpecs) > 0
+	})
+	names := []string{}
+	for name := range filtered.Definitions {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	expected := []string{
+		"io.k8s.kubernetes.pkg.api.v1.Container",
+		"io.k8s.kubernetes.pkg.api.v1.Pod",
+		"io.k8s.kubernetes.pkg.api.v1.PodSpec",
+		"io.k8s.kubernetes.pkg.api.v1.Quantity",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected definitions %v got %v", expected, names)
+	}
+
+	// Definitions are shared, but the original spec is unchanged.
+	const pod = "io.k8s.kubernetes.pkg.api.v1.Pod"
+	if filtered.Definitions[pod] != s.Definitions[pod] {
+		t.Errorf("Expected filtered spec to share definitions")
+	}
+	if len(s.Definitions) != 5 {
+		t.Errorf("Expected original spec to keep its definitions, got %d", len(s.Definitions))
+	}
+
+	none := s.Filter(func(DefinitionName, *SchemaDefinition) bool { return false })
+	if len(none.Definitions) != 0 {
+		t.Errorf("Expected no definitions, got %d", len(none.Definitions))
+	}
+}
+
+func TestExclude(t *testing.T) {
+	s := loadSearchSpec(t)
+
+	const podSpec = "io.k8s.kubernetes.pkg.api.v1.PodSpec"
+	excluded := s.Exclude(func(name DefinitionName, _ *SchemaDefinition) bool {
+		return name == podSpec
+	})
+	if _, ok := excluded.Definitions[podSpec]; ok {
+		t.Errorf("Expected '%s' to be excluded", podSpec)
+	}
+	if len(excluded.Definitions) != 4 {
+		t.Errorf("Expected 4 definitions, got %d", len(excluded.Definitions))
+	}
+
+	// References aren't followed, so the pod's spec now dangles, and the
+	// original spec is unchanged.
+	const pod = "io.k8s.kubernetes.pkg.api.v1.Pod"
+	ref := excluded.Definitions[pod].Properties["spec"].Ref
+	if ref == nil || *ref != "#/definitions/"+podSpec {
+		t.Errorf("Expected '%s' to still refer to '%s'", pod, podSpec)
+	}
+	if _, ok := s.Definitions[podSpec]; !ok || len(s.Definitions) != 5 {
+		t.Errorf("Expected original spec to keep its definitions")
+	}
+}
+
+// Run with `-race`.
+func TestClosureCache(t *testing.T) {
+	s := loadSearchSpec(t)
+	cache := NewClosureCache(s)
+	keeps := map[string]func(DefinitionName, *SchemaDefinition) bool{
+		"top-level": func(name DefinitionName, def *SchemaDefinition) bool {
+			return len(def.TopLevelSpecs) > 0
+		},
+		"container": func(name DefinitionName, def *SchemaDefinition) bool {
+			return name == "io.k8s.kubernetes.pkg.api.v1.Container"
+		},
+		"none": func(DefinitionName, *SchemaDefinition) bool { return false },
+	}
+
+	var wg sync.WaitGroup
+	for name, keep := range keeps {
+		wg.Add(1)
+		go func(name string, keep func(DefinitionName, *SchemaDefinition) bool) {
+			defer wg.Done()
+			expected, filtered := s.Filter(keep), cache.Filter(keep)
+			if !reflect.DeepEqual(filtered.Definitions, expected.Definitions) {
+				t.Errorf("Expected cached filter '%s' to keep the same definitions as 'Filter'", name)
+			}
+		}(name, keep)
+	}
+	wg.Wait()
+
+	const pod = "io.k8s.kubernetes.pkg.api.v1.Pod"
+	if !reflect.DeepEqual(cache.Closure(pod), s.Closure(pod)) {
+		t.Errorf("Expected cached closure of '%s' to match 'Closure'", pod)
+	}
+}
+
+// Run with `-race`.
+func TestFilterConcurrency(t *testing.T) {
+	s := loadSearchSpec(t)
+	gvk := TopLevelSpec{Version: "v1", Kind: "Pod"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			filtered := s.Filter(func(name DefinitionName, def *SchemaDefinition) bool {
+				return len(def.TopLevelSpecs) > 0
+			})
+			s.Resource(gvk)
+			filtered.Resource(gvk)
+			inline, _ := filtered.WithInlineDefinitions(5)
+			inline.Closure("io.k8s.kubernetes.pkg.api.v1.Pod")
+		}()
+	}
+	wg.Wait()
+}
+
+// stagesSpec has an alpha kind whose sub-object is shared with a beta
+// kind, alongside alpha kinds nothing else uses.
+const stagesSpec = `{
+  "definitions": {
+    "io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscaler": {
+      "properties": {"kind": {"type": "string"}},
+      "x-kubernetes-group-version-kind": [{"Group": "autoscaling", "Version": "v1", "Kind": "HorizontalPodAutoscaler"}]
+    },
+    "io.k8s.kubernetes.pkg.apis.autoscaling.v2alpha1.HorizontalPodAutoscaler": {
+      "properties": {"kind": {"type": "string"}},
+      "x-kubernetes-group-version-kind": [{"Group": "autoscaling", "Version": "v2alpha1", "Kind": "HorizontalPodAutoscaler"}]
+    },
+    "io.k8s.kubernetes.pkg.apis.settings.v1alpha1.PodPreset": {
+      "properties": {"kind": {"type": "string"}}
+    },
+    "io.k8s.kubernetes.pkg.apis.batch.v1beta1.CronJob": {
+      "properties": {
+        "jobTemplate": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.apis.batch.v2alpha1.JobTemplateSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "batch", "Version": "v1beta1", "Kind": "CronJob"}]
+    },
+    "io.k8s.kubernetes.pkg.apis.batch.v2alpha1.JobTemplateSpec": {
+      "properties": {"kind": {"type": "string"}}
+    },
+    "io.k8s.apimachinery.pkg.util.intstr.IntOrString": {
+      "type": "string",
+      "format": "int-or-string"
+    }
+  }
+}`
+
+func TestExcludingStages(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(stagesSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	tests := []struct {
+		stages   []VersionStage
+		expected []string
+	}{
+		{
+			stages: []VersionStage{StageAlpha},
+			expected: []string{
+				"io.k8s.apimachinery.pkg.util.intstr.IntOrString",
+				"io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscaler",
+				"io.k8s.kubernetes.pkg.apis.batch.v1beta1.CronJob",
+				"io.k8s.kubernetes.pkg.apis.batch.v2alpha1.JobTemplateSpec",
+			},
+		},
+		{
+			stages: []VersionStage{StageAlpha, StageBeta},
+			expected: []string{
+				"io.k8s.apimachinery.pkg.util.intstr.IntOrString",
+				"io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscaler",
+			},
+		},
+		{
+			stages: []VersionStage{},
+			expected: []string{
+				"io.k8s.apimachinery.pkg.util.intstr.IntOrString",
+				"io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscaler",
+				"io.k8s.kubernetes.pkg.apis.autoscaling.v2alpha1.HorizontalPodAutoscaler",
+				"io.k8s.kubernetes.pkg.apis.batch.v1beta1.CronJob",
+				"io.k8s.kubernetes.pkg.apis.batch.v2alpha1.JobTemplateSpec",
+				"io.k8s.kubernetes.pkg.apis.settings.v1alpha1.PodPreset",
+			},
+		},
+	}
+	p := &Parser{Prefixes: []string{NativePrefix}}
+	for _, test := range tests {
+		for _, keep := range []func(DefinitionName, *SchemaDefinition) bool{
+			ExcludingStages(test.stages), p.ExcludingStages(test.stages),
+		} {
+			filtered := s.Filter(keep)
+			names := []string{}
+			for name := range filtered.Definitions {
+				names = append(names, string(name))
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("Excluding %v, expected definitions %v got %v", test.stages, test.expected, names)
+			}
+		}
+	}
+}
+
+func TestInGroupVersions(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(stagesSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	filtered := s.Filter(InGroupVersions([]GroupVersion{
+		{Group: "batch", Version: "v1beta1"},
+		{Group: "autoscaling", Version: "v1"},
+	}))
+	names := []string{}
+	for name := range filtered.Definitions {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	// The alpha sub-object the CronJob uses is kept, but not the other
+	// version of the HorizontalPodAutoscaler.
+	expected := []string{
+		"io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscaler",
+		"io.k8s.kubernetes.pkg.apis.batch.v1beta1.CronJob",
+		"io.k8s.kubernetes.pkg.apis.batch.v2alpha1.JobTemplateSpec",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected definitions %v got %v", expected, names)
+	}
+}
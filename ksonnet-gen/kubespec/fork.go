@@ -0,0 +1,305 @@
+package kubespec
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConflictPolicy decides what `WithCRDSources` does with a definition
+// that a source adds under a name that the spec, or an earlier source,
+// already has a different schema for.
+type ConflictPolicy string
+
+const (
+	// ConflictsError fails, as `WithCRDs` does for any name that's
+	// added twice. The zero value of `ConflictPolicy` is the same.
+	ConflictsError ConflictPolicy = "error"
+
+	// ConflictsFork keeps both schemas, adding the source's under a
+	// name whose group is suffixed with the source's label (see
+	// `ForkName`), along with every definition of the source that
+	// refers to it. Definitions that are identical in both are shared.
+	ConflictsFork ConflictPolicy = "fork"
+)
+
+// ConflictPolicies lists the valid conflict policies.
+var ConflictPolicies = []ConflictPolicy{ConflictsError, ConflictsFork}
+
+// ParseConflictPolicy returns the conflict policy called `name`.
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	names := []string{}
+	for _, policy := range ConflictPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+		names = append(names, string(policy))
+	}
+	return "", fmt.Errorf(
+		"Unknown conflict policy '%s'; expected one of: %s",
+		name, strings.Join(names, ", "))
+}
+
+// CRDSource is a set of CRDs that `WithCRDSources` adds together,
+// e.g., those of a file, and the label that the definitions it forks
+// from them are named with, e.g., `v2`.
+type CRDSource struct {
+	Label string
+	CRDs  []*CustomResourceDefinition
+}
+
+// Fork is what `SchemaDefinition.Fork` records of a definition that
+// `WithCRDSources` forked: `Definition` is its name in `Source`, the
+// label of the source it came from. Conflicting is set if the spec had
+// a different schema under that name; otherwise, it was forked because
+// it refers to a definition that was.
+type Fork struct {
+	Definition  DefinitionName
+	Source      string
+	Conflicting bool
+}
+
+// sourceLabelPattern is what the labels of `CRDSource`s must match, so
+// that forked names are still identifiers once they're emitted.
+var sourceLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// nonLabelChars are what `SourceLabel` replaces.
+var nonLabelChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// SourceLabel returns the default label of a source of CRDs read from
+// `file`, a path or the path of a URL: its base name, without its
+// extension, with anything that isn't a letter, a digit or an
+// underscore replaced by an underscore, e.g., `databases_v2` for
+// `crds/databases-v2.yaml`.
+func SourceLabel(file string) string {
+	base := path.Base(filepath.ToSlash(file))
+	return nonLabelChars.ReplaceAllString(strings.TrimSuffix(base, path.Ext(base)), "_")
+}
+
+// ForkName returns the name `WithCRDSources` gives the fork of the
+// definition `name` for the source labelled `label`: its group
+// suffixed with an underscore and the label, e.g.,
+// `io.k8s.crd.api.mysqlExampleCom_v2.v1.Database`. Names without a
+// group and a version are returned as they are.
+func ForkName(name DefinitionName, label string) DefinitionName {
+	kind := strings.LastIndex(string(name), ".")
+	if kind < 0 {
+		return name
+	}
+	version := strings.LastIndex(string(name[:kind]), ".")
+	if version < 0 {
+		return name
+	}
+	return name[:version] + DefinitionName("_"+label) + name[version:]
+}
+
+// WithCRDSources returns a copy of the spec with the custom resources
+// of the CRDs of each of `sources`, in order, as `WithCRDs` would add
+// them. With `ConflictsError`, it's exactly `WithCRDs` of the CRDs of
+// every source, failing for any name that's added twice.
+//
+// With `ConflictsFork`, a definition that a source adds under a name
+// the spec (or an earlier source) already has is shared if the schemas
+// are identical, and otherwise forked: it's added under `ForkName`,
+// with `SchemaDefinition.Fork` set, and so is every definition of the
+// source that the spec already has, if it refers to a fork, directly
+// or through others. The references of the source's definitions are
+// then rewritten to the forks, and only theirs: those of the spec, and
+// of the source's definitions that are shared, are left as they are.
+// Names are still only added once by each source, and labels must be
+// distinct identifiers. The receiver isn't modified.
+func (s *APISpec) WithCRDSources(
+	sources []*CRDSource, includeUnserved bool, policy ConflictPolicy,
+) (_ *APISpec, err error) {
+	if policy == "" || policy == ConflictsError {
+		all := []*CustomResourceDefinition{}
+		for _, source := range sources {
+			all = append(all, source.CRDs...)
+		}
+		return s.WithCRDs(all, includeUnserved)
+	}
+	defer func() { err = Categorize(ErrUnsupportedSchema, err) }()
+	if policy != ConflictsFork {
+		_, err := ParseConflictPolicy(string(policy))
+		return nil, err
+	}
+
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	_, hasObjectMeta := s.Definitions[ObjectMetaName]
+	ids := crdGroupIDs(s.Definitions)
+	labels := map[string]bool{}
+	for _, source := range sources {
+		if !sourceLabelPattern.MatchString(source.Label) {
+			return nil, fmt.Errorf(
+				"Can't label a source of CRDs '%s', since labels must be letters, digits and underscores", source.Label)
+		} else if labels[source.Label] {
+			return nil, fmt.Errorf("Can't label more than one source of CRDs '%s'", source.Label)
+		}
+		labels[source.Label] = true
+
+		added, err := crdDefinitions(source.CRDs, includeUnserved, hasObjectMeta, nil, ids)
+		if err != nil {
+			return nil, err
+		}
+		if err := forkSource(definitions, added, source.Label); err != nil {
+			return nil, err
+		}
+	}
+	return s.withDefinitions(definitions), nil
+}
+
+// forkSource adds the definitions `added`, of the source labelled
+// `label`, to `definitions`, forking those that conflict with them,
+// and those that refer to a fork; see `WithCRDSources`.
+func forkSource(definitions, added SchemaDefinitions, label string) error {
+	names := []DefinitionName{}
+	for name := range added {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	forks := map[DefinitionName]DefinitionName{}
+	conflicting := map[DefinitionName]bool{}
+	for _, name := range names {
+		if existing, ok := definitions[name]; ok && !reflect.DeepEqual(existing, added[name]) {
+			forks[name] = ForkName(name, label)
+			conflicting[name] = true
+		}
+	}
+	// A definition that's shared, but refers to a fork, means the
+	// source's schema of it, so it's forked too, until none is left.
+	for forked := len(forks) > 0; forked; {
+		forked = false
+		for _, name := range names {
+			def := added[name]
+			if _, ok := forks[name]; ok {
+				continue
+			} else if _, ok := definitions[name]; ok && def.withRenamedRefs(forks) != def {
+				forks[name] = ForkName(name, label)
+				forked = true
+			}
+		}
+	}
+
+	for _, name := range names {
+		def := added[name].withRenamedRefs(forks)
+		forkName, forked := forks[name]
+		if !forked {
+			if _, ok := definitions[name]; !ok {
+				definitions[name] = def
+			}
+			continue
+		}
+		if forkName == name {
+			return fmt.Errorf(
+				"Can't fork '%s' for the source of CRDs '%s', since it has no group and version", name, label)
+		} else if _, ok := definitions[forkName]; ok {
+			return fmt.Errorf(
+				"Can't fork '%s' for the source of CRDs '%s', because the spec already has '%s'",
+				name, label, forkName)
+		}
+		if def == added[name] {
+			copied := *def
+			def = &copied
+		}
+		def.Fork = &Fork{Definition: name, Source: label, Conflicting: conflicting[name]}
+		definitions[forkName] = def
+	}
+	return nil
+}
+
+// withRenamedRefs returns the definition with its references to each
+// definition of `renamed` rewritten to refer to its new name, at any
+// depth, or the definition itself if it refers to none of them. What's
+// changed is copied, so the receiver isn't modified.
+func (sd *SchemaDefinition) withRenamedRefs(renamed map[DefinitionName]DefinitionName) *SchemaDefinition {
+	properties, changed := sd.Properties.withRenamedRefs(renamed)
+	if !changed {
+		return sd
+	}
+	copied := *sd
+	copied.Properties = properties
+	return &copied
+}
+
+// withRenamedRefs is `SchemaDefinition.withRenamedRefs` of the
+// properties of a definition or an inline object, reporting whether
+// any changed.
+func (ps Properties) withRenamedRefs(renamed map[DefinitionName]DefinitionName) (Properties, bool) {
+	var copied Properties
+	for name, prop := range ps {
+		if rewritten := prop.withRenamedRefs(renamed); rewritten != prop {
+			if copied == nil {
+				copied = Properties{}
+				for name, prop := range ps {
+					copied[name] = prop
+				}
+			}
+			copied[name] = rewritten
+		}
+	}
+	if copied == nil {
+		return ps, false
+	}
+	return copied, true
+}
+
+// withRenamedRefs is `SchemaDefinition.withRenamedRefs` of a property:
+// its own reference, its array items', its additional properties',
+// its inline object's properties', and its alternatives'.
+func (p *Property) withRenamedRefs(renamed map[DefinitionName]DefinitionName) *Property {
+	copied := *p
+	changed, anyOf := false, false
+	if ref, ok := renamedRef(p.Ref, renamed); ok {
+		copied.Ref, changed = ref, true
+	}
+	if ref, ok := renamedRef(p.Items.Ref, renamed); ok {
+		copied.Items.Ref, changed = ref, true
+	}
+	if properties, ok := p.Properties.withRenamedRefs(renamed); ok {
+		copied.Properties, changed = properties, true
+	}
+	if ap := p.AdditionalProperties; ap != nil && ap.Schema != nil {
+		if schema := ap.Schema.withRenamedRefs(renamed); schema != ap.Schema {
+			copied.AdditionalProperties = &AdditionalProperties{Allowed: ap.Allowed, Schema: schema}
+			changed = true
+		}
+	}
+	for i, alternative := range p.AnyOf {
+		if rewritten := alternative.withRenamedRefs(renamed); rewritten != alternative {
+			if !anyOf {
+				copied.AnyOf, anyOf = append([]*Property{}, p.AnyOf...), true
+			}
+			copied.AnyOf[i] = rewritten
+			changed = true
+		}
+	}
+	if !changed {
+		return p
+	}
+	return &copied
+}
+
+// renamedRef returns `ref` rewritten to refer to the new name of the
+// definition it refers to, reporting false if `renamed` doesn't have
+// it.
+func renamedRef(ref *ObjectRef, renamed map[DefinitionName]DefinitionName) (*ObjectRef, bool) {
+	if ref == nil {
+		return nil, false
+	}
+	name, err := parseRef(*ref)
+	if err != nil {
+		return nil, false
+	}
+	if to, ok := renamed[*name]; ok {
+		return to.AsObjectRef(), true
+	}
+	return nil, false
+}
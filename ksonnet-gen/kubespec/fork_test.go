@@ -0,0 +1,175 @@
+package kubespec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// forkCRD returns a CRD manifest of `kind` in `group`, serving `v1`,
+// with the property `name` of the schema `schema`, as YAML.
+func forkCRD(group, kind, name, schema string) string {
+	return fmt.Sprintf(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: %ss.%s
+spec:
+  group: %s
+  names:
+    kind: %s
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        properties:
+          %s: %s
+`, strings.ToLower(kind), group, group, kind, name, schema)
+}
+
+// forkSources returns sources of CRDs labelled `v1` and `v2`, one with
+// the CRDs of `first`, the other with those of `second`.
+func forkSources(t *testing.T, first, second []string) []*CRDSource {
+	sources := []*CRDSource{}
+	for i, manifests := range [][]string{first, second} {
+		crds, err := ReadCRDs("crds.yaml", []byte(strings.Join(manifests, "---\n")))
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		sources = append(sources, &CRDSource{Label: fmt.Sprintf("v%d", i+1), CRDs: crds})
+	}
+	return sources
+}
+
+const (
+	forkDatabase = DefinitionName("io.k8s.crd.api.mysqlExampleCom.v1.Database")
+	forkWidget   = DefinitionName("io.k8s.crd.api.myAppIo.v1.Widget")
+	forkBackup   = DefinitionName("io.k8s.crd.api.mysqlExampleCom.v1.Backup")
+	forkRestore  = DefinitionName("io.k8s.crd.api.mysqlExampleCom.v1.Restore")
+)
+
+// forkRef is the schema of a property that refers to `forkDatabase`.
+var forkRef = fmt.Sprintf("{$ref: '#/definitions/%s'}", forkDatabase)
+
+func TestWithCRDSources(t *testing.T) {
+	base := &APISpec{Definitions: SchemaDefinitions{}}
+	widget := forkCRD("my-app.io", "Widget", "color", "{type: string}")
+	sources := forkSources(t,
+		[]string{forkCRD("mysql.example.com", "Database", "size", "{type: string}"), widget},
+		[]string{forkCRD("mysql.example.com", "Database", "size", "{type: integer}"), widget})
+
+	// By default, as with `WithCRDs`, a name that's added twice fails.
+	_, err := base.WithCRDSources(sources, false, ConflictsError)
+	if err == nil || !strings.Contains(err.Error(), "already added '"+string(forkDatabase)+"'") {
+		t.Errorf("Expected the conflict to fail, got %v", err)
+	}
+
+	// Forking keeps both schemas of the conflicting definition, and
+	// shares the identical one.
+	forked, err := base.WithCRDSources(sources, false, ConflictsFork)
+	if err != nil {
+		t.Fatalf("Failed to fork:\n%v", err)
+	}
+	forkName := DefinitionName("io.k8s.crd.api.mysqlExampleCom_v2.v1.Database")
+	names := []DefinitionName{}
+	for name := range forked.Definitions {
+		names = append(names, name)
+	}
+	expected := []DefinitionName{forkWidget, forkDatabase, forkName}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected the definitions %v, got %v", expected, names)
+	}
+	forks := 0
+	for _, def := range forked.Definitions {
+		if def.Fork != nil {
+			forks++
+		}
+	}
+	if forks != 1 {
+		t.Errorf("Expected exactly one definition to be forked, got %d", forks)
+	}
+	fork := forked.Definitions[forkName]
+	if want := (&Fork{Definition: forkDatabase, Source: "v2", Conflicting: true}); !reflect.DeepEqual(fork.Fork, want) {
+		t.Errorf("Expected the fork to record %+v, got %+v", want, fork.Fork)
+	}
+	if size := fork.Properties["size"]; size == nil || *size.Type != "integer" {
+		t.Errorf("Expected the fork to have the second source's schema")
+	}
+	if size := forked.Definitions[forkDatabase].Properties["size"]; *size.Type != "string" {
+		t.Errorf("Expected the definition to keep the first source's schema")
+	}
+	if len(base.Definitions) != 0 {
+		t.Errorf("Expected the receiver to be left as it was")
+	}
+}
+
+func TestWithCRDSourcesRefs(t *testing.T) {
+	// The second source's backups and restores refer to its databases,
+	// which conflict with the first's. Its backups are otherwise
+	// identical to the first's, so they're forked too, to refer to the
+	// fork; its restores are its own, so they're only rewritten.
+	backup := forkCRD("mysql.example.com", "Backup", "database", forkRef)
+	sources := forkSources(t,
+		[]string{forkCRD("mysql.example.com", "Database", "size", "{type: string}"), backup},
+		[]string{
+			forkCRD("mysql.example.com", "Database", "size", "{type: integer}"), backup,
+			forkCRD("mysql.example.com", "Restore", "to", "{type: object, properties: {database: {items: "+forkRef+", type: array}}}"),
+		})
+	forked, err := (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDSources(sources, false, ConflictsFork)
+	if err != nil {
+		t.Fatalf("Failed to fork:\n%v", err)
+	}
+
+	forkDB := ForkName(forkDatabase, "v2")
+	forkedBackup := forked.Definitions[ForkName(forkBackup, "v2")]
+	if forkedBackup == nil || forkedBackup.Fork == nil || forkedBackup.Fork.Conflicting {
+		t.Fatalf("Expected the backups to be forked for referring to a fork, got %+v", forkedBackup)
+	}
+	if ref := forkedBackup.Properties["database"].Ref; ref == nil || *ref != *forkDB.AsObjectRef() {
+		t.Errorf("Expected the forked backups to refer to the forked databases, got %v", ref)
+	}
+	if ref := forked.Definitions[forkBackup].Properties["database"].Ref; *ref != *forkDatabase.AsObjectRef() {
+		t.Errorf("Expected the first source's backups to refer to its databases, got %v", *ref)
+	}
+	restore := forked.Definitions[forkRestore]
+	if restore == nil || restore.Fork != nil {
+		t.Fatalf("Expected the restores to be added, not forked, got %+v", restore)
+	}
+	if ref := restore.Properties["to"].Properties["database"].Items.Ref; *ref != *forkDB.AsObjectRef() {
+		t.Errorf("Expected the nested reference of the restores to be rewritten, got %v", *ref)
+	}
+	if _, ok := forked.Definitions[ForkName(forkRestore, "v2")]; ok {
+		t.Errorf("Expected no fork of the restores")
+	}
+	if dangling := forked.DanglingRefs(); len(dangling) != 0 {
+		t.Errorf("Expected no dangling references, got %v", dangling)
+	}
+
+	if _, err := ParseConflictPolicy("first-wins"); err == nil ||
+		err.Error() != "Unknown conflict policy 'first-wins'; expected one of: error, fork" {
+		t.Errorf("Expected an unknown policy to be rejected, got %v", err)
+	}
+	sources[1].Label = sources[0].Label
+	if _, err := (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDSources(sources, false, ConflictsFork); err == nil {
+		t.Errorf("Expected sources with the same label to fail")
+	}
+	sources[1].Label = "v-2"
+	_, err = (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDSources(sources, false, ConflictsFork)
+	checkCategory(t, "a label that isn't an identifier", err, "ErrUnsupportedSchema")
+}
+
+func TestForkName(t *testing.T) {
+	for name, expected := range map[DefinitionName]DefinitionName{
+		forkDatabase: "io.k8s.crd.api.mysqlExampleCom_v2.v1.Database",
+		"v1.Pod":     "v1.Pod",
+		"Pod":        "Pod",
+	} {
+		if actual := ForkName(name, "v2"); actual != expected {
+			t.Errorf("Expected the fork of '%s' to be '%s', got '%s'", name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package kubespec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SanitizeGroupID returns the identifier an API group is written as
+// in definition names and in the library, e.g., `rbacAuthorizationK8sIo`
+// for `rbac.authorization.k8s.io`: its labels, and the words of each,
+// lowerCamelCase'd, without the characters that aren't letters or
+// digits. Names that are already identifiers (e.g., `apps`) are
+// unchanged.
+//
+// Different groups can have the same identifier (e.g., `foo-bar.io`
+// and `foo.bar.io`); `GroupIDMap` tells them apart.
+func SanitizeGroupID(group string) string {
+	words := strings.FieldsFunc(group, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// GroupIDMap maps the identifiers of API groups back to the groups
+// they were sanitized from, e.g., `rbacAuthorizationK8sIo` to
+// `rbac.authorization.k8s.io`. Each group gets one identifier, and no
+// two groups get the same one, or ones that differ only by case; see
+// `Add`.
+type GroupIDMap map[string]string
+
+// Add gives each of `groups` that doesn't have an identifier yet the
+// one `SanitizeGroupID` returns, unless that's taken, in which case
+// it gets the lowest numeric suffix that's free (e.g.,
+// `fooBarIo2`). Groups that are already identifiers are considered
+// first, and then the rest in sorted order, so that the same groups
+// get the same identifiers, whatever order they're given in.
+func (m GroupIDMap) Add(groups ...string) {
+	groups = append([]string{}, groups...)
+	sort.Slice(groups, func(i, j int) bool {
+		iExact := SanitizeGroupID(groups[i]) == groups[i]
+		jExact := SanitizeGroupID(groups[j]) == groups[j]
+		if iExact != jExact {
+			return iExact
+		}
+		return groups[i] < groups[j]
+	})
+
+	taken := map[string]bool{}
+	for id := range m {
+		taken[strings.ToLower(id)] = true
+	}
+	for _, group := range groups {
+		if _, ok := m.lookup(group); ok {
+			continue
+		}
+		preferred := SanitizeGroupID(group)
+		id := preferred
+		for n := 2; taken[strings.ToLower(id)]; n++ {
+			id = fmt.Sprintf("%s%d", preferred, n)
+		}
+		m[id] = group
+		taken[strings.ToLower(id)] = true
+	}
+}
+
+// ID returns the identifier of `group`, or, if it has none, the one
+// `SanitizeGroupID` returns.
+func (m GroupIDMap) ID(group string) string {
+	if id, ok := m.lookup(group); ok {
+		return id
+	}
+	return SanitizeGroupID(group)
+}
+
+// `lookup` returns the identifier of `group`, if it has one. If the
+// map was built by hand with several, it's the first in sorted order.
+func (m GroupIDMap) lookup(group string) (string, bool) {
+	found := ""
+	for id, other := range m {
+		if other == group && (found == "" || id < found) {
+			found = id
+		}
+	}
+	return found, found != ""
+}
@@ -0,0 +1,112 @@
+package kubespec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeGroupID(t *testing.T) {
+	for group, expected := range map[string]string{
+		"apps":                      "apps",
+		"rbac.authorization.k8s.io": "rbacAuthorizationK8sIo",
+		"kube-aggregator":           "kubeAggregator",
+		"foo-bar.io":                "fooBarIo",
+		"foo.bar.io":                "fooBarIo",
+		"":                          "",
+	} {
+		if actual := SanitizeGroupID(group); actual != expected {
+			t.Errorf("Expected '%s' to be sanitized as '%s', got '%s'", group, expected, actual)
+		}
+	}
+}
+
+func TestGroupIDMap(t *testing.T) {
+	// Whatever order the groups are given in, the one that's already an
+	// identifier keeps it, and the rest are numbered in sorted order,
+	// case-insensitively.
+	expected := GroupIDMap{
+		"fooBarIo":  "fooBarIo",
+		"FooBarIo2": "Foo.bar.io",
+		"fooBarIo3": "foo-bar.io",
+		"fooBarIo4": "foo.bar.io",
+		"apps":      "apps",
+	}
+	orders := [][]string{
+		{"foo-bar.io", "foo.bar.io", "Foo.bar.io", "fooBarIo", "apps"},
+		{"apps", "Foo.bar.io", "fooBarIo", "foo.bar.io", "foo-bar.io"},
+		{"foo.bar.io", "apps", "foo-bar.io", "fooBarIo", "Foo.bar.io", "apps"},
+	}
+	for _, groups := range orders {
+		ids := GroupIDMap{}
+		ids.Add(groups...)
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected %v to be given the identifiers %v, got %v", groups, expected, ids)
+		}
+		for id, group := range expected {
+			if actual := ids.ID(group); actual != id {
+				t.Errorf("Expected the identifier of '%s' to be '%s', got '%s'", group, id, actual)
+			}
+		}
+	}
+
+	// Groups that have identifiers keep them, and new ones don't take
+	// them.
+	ids := GroupIDMap{"fooBarIo": "foo.bar.io"}
+	ids.Add("foo-bar.io", "foo.bar.io")
+	if want := (GroupIDMap{"fooBarIo": "foo.bar.io", "fooBarIo2": "foo-bar.io"}); !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+	if id := ids.ID("stable.example.com"); id != "stableExampleCom" {
+		t.Errorf("Expected a group without an identifier to be sanitized, got '%s'", id)
+	}
+}
+
+func TestWithCRDsGroupIDs(t *testing.T) {
+	dashed := forkCRD("foo-bar.io", "Widget", "color", "{type: string}")
+	dotted := forkCRD("foo.bar.io", "Widget", "size", "{type: integer}")
+	expected := map[string]DefinitionName{
+		"foo-bar.io": "io.k8s.crd.api.fooBarIo.v1.Widget",
+		"foo.bar.io": "io.k8s.crd.api.fooBarIo2.v1.Widget",
+	}
+	for _, manifests := range [][]string{{dashed, dotted}, {dotted, dashed}} {
+		crds, err := ReadCRDs("crds.yaml", []byte(strings.Join(manifests, "---\n")))
+		if err != nil {
+			t.Fatalf("Could not read CRDs:\n%v", err)
+		}
+		spec, err := (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDs(crds, false)
+		if err != nil {
+			t.Fatalf("Failed to add CRDs whose groups have the same identifier:\n%v", err)
+		}
+		for group, name := range expected {
+			def := spec.Definitions[name]
+			if def == nil || string(def.TopLevelSpecs[0].Group) != group {
+				t.Errorf("Expected the Widgets of '%s' to be '%s', got %+v", group, name, def)
+			}
+		}
+	}
+
+	// CRDs added later don't take the identifiers of the spec's.
+	crds, err := ReadCRDs("crds.yaml", []byte(dotted))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	spec, err := (&APISpec{Definitions: SchemaDefinitions{}}).WithCRDs(crds, false)
+	if err != nil {
+		t.Fatalf("Failed to add CRDs:\n%v", err)
+	}
+	if crds, err = ReadCRDs("crds.yaml", []byte(dashed)); err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	if spec, err = spec.WithCRDs(crds, false); err != nil {
+		t.Fatalf("Failed to add CRDs:\n%v", err)
+	}
+	for group, name := range map[string]DefinitionName{
+		"foo.bar.io": "io.k8s.crd.api.fooBarIo.v1.Widget",
+		"foo-bar.io": "io.k8s.crd.api.fooBarIo2.v1.Widget",
+	} {
+		if def := spec.Definitions[name]; def == nil || string(def.TopLevelSpecs[0].Group) != group {
+			t.Errorf("Expected the Widgets of '%s' to be '%s', got %+v", group, name, def)
+		}
+	}
+}
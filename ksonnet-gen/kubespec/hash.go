@@ -0,0 +1,120 @@
+package kubespec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+)
+
+// StructuralHashes returns a hash of the schema of every definition in
+// the spec, which is the same for definitions whose schemas are
+// identical but for their names, and those of the definitions they
+// refer to: each `$ref` is hashed as the schema it refers to, rather
+// than by name. `x-kubernetes-group-version-kind` isn't hashed, so the
+// same schema in several groups (e.g., the `Scale` of `apps/v1beta1`
+// and `extensions/v1beta1`) hashes the same in each.
+//
+// References to definitions that aren't in the spec are hashed by
+// name, and references back into a cycle (e.g., `JSONSchemaProps`) by
+// how many definitions back they refer.
+func (s *APISpec) StructuralHashes() map[DefinitionName]string {
+	h := &structuralHasher{
+		spec:   s,
+		hashes: map[DefinitionName]string{},
+		depths: map[DefinitionName]int{},
+	}
+	hashes := map[DefinitionName]string{}
+	for name := range s.Definitions {
+		hashes[name], _ = h.hash(name, 0)
+	}
+	return hashes
+}
+
+// `structuralHasher` computes `StructuralHashes`, caching the hashes of
+// definitions that aren't part of a cycle, which are the same wherever
+// they're reached from.
+type structuralHasher struct {
+	spec   *APISpec
+	hashes map[DefinitionName]string
+
+	// depths are how deep in the references being hashed each
+	// definition being hashed is.
+	depths map[DefinitionName]int
+}
+
+// `hash` hashes the definition `name`, reached at `depth`, returning
+// the shallowest depth it refers back to, or `math.MaxInt32` if it's
+// not part of a cycle.
+func (h *structuralHasher) hash(name DefinitionName, depth int) (string, int) {
+	if hash, ok := h.hashes[name]; ok {
+		return hash, math.MaxInt32
+	}
+	text, err := json.Marshal(h.spec.Definitions[name])
+	if err != nil {
+		log.Panicf("Could not serialize definition '%s':\n%v", name, err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(text, &schema); err != nil {
+		log.Panicf("Could not deserialize definition '%s':\n%v", name, err)
+	}
+	delete(schema, "x-kubernetes-group-version-kind")
+
+	h.depths[name] = depth
+	shallowest := h.replaceRefs(schema, depth)
+	delete(h.depths, name)
+
+	text, err = json.Marshal(schema)
+	if err != nil {
+		log.Panicf("Could not serialize the schema of definition '%s':\n%v", name, err)
+	}
+	sum := sha256.Sum256(text)
+	hash := hex.EncodeToString(sum[:])
+	if shallowest > depth {
+		h.hashes[name] = hash
+	}
+	return hash, shallowest
+}
+
+// `replaceRefs` replaces each `$ref` in `value`, part of the schema of
+// a definition at `depth`, with the hash of the definition it refers
+// to, returning the shallowest depth they refer back to, like `hash`.
+func (h *structuralHasher) replaceRefs(value interface{}, depth int) int {
+	shallowest := math.MaxInt32
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if ref, ok := field.(string); ok && key == "$ref" {
+				name, err := parseRef(ObjectRef(ref))
+				if err != nil {
+					continue
+				}
+				if refDepth, ok := h.depths[*name]; ok {
+					v[key] = fmt.Sprintf("cycle:%d", depth-refDepth)
+					if refDepth < shallowest {
+						shallowest = refDepth
+					}
+				} else if _, ok := h.spec.Definitions[*name]; ok {
+					hash, refShallowest := h.hash(*name, depth+1)
+					v[key] = "hash:" + hash
+					if refShallowest < shallowest {
+						shallowest = refShallowest
+					}
+				}
+				continue
+			}
+			if refShallowest := h.replaceRefs(field, depth); refShallowest < shallowest {
+				shallowest = refShallowest
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			if refShallowest := h.replaceRefs(element, depth); refShallowest < shallowest {
+				shallowest = refShallowest
+			}
+		}
+	}
+	return shallowest
+}
@@ -0,0 +1,93 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var hashSpec = `{
+  "definitions": {
+    "io.k8s.api.apps.v1beta1.Scale": {
+      "description": "Scale represents a scaling request for a resource.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1beta1.ScaleSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "apps", "Version": "v1beta1", "Kind": "Scale"}]
+    },
+    "io.k8s.api.apps.v1beta1.ScaleSpec": {
+      "properties": {"replicas": {"type": "integer", "format": "int32"}}
+    },
+    "io.k8s.api.extensions.v1beta1.Scale": {
+      "description": "Scale represents a scaling request for a resource.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.extensions.v1beta1.ScaleSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "extensions", "Version": "v1beta1", "Kind": "Scale"}]
+    },
+    "io.k8s.api.extensions.v1beta1.ScaleSpec": {
+      "properties": {"replicas": {"type": "integer", "format": "int32"}}
+    },
+    "io.k8s.api.autoscaling.v1.Scale": {
+      "description": "Scale represents a scaling request for a resource.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.autoscaling.v1.ScaleSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "autoscaling", "Version": "v1", "Kind": "Scale"}]
+    },
+    "io.k8s.api.autoscaling.v1.ScaleSpec": {
+      "properties": {"replicas": {"type": "integer", "format": "int64"}}
+    },
+    "io.k8s.api.apps.v1beta1.Props": {
+      "properties": {"not": {"$ref": "#/definitions/io.k8s.api.apps.v1beta1.Props"}}
+    },
+    "io.k8s.api.extensions.v1beta1.Props": {
+      "properties": {"not": {"$ref": "#/definitions/io.k8s.api.extensions.v1beta1.Props"}}
+    },
+    "io.k8s.api.apps.v1beta1.Even": {
+      "properties": {"next": {"$ref": "#/definitions/io.k8s.api.apps.v1beta1.Odd"}}
+    },
+    "io.k8s.api.apps.v1beta1.Odd": {
+      "properties": {"next": {"$ref": "#/definitions/io.k8s.api.apps.v1beta1.Even"}}
+    }
+  }
+}`
+
+func TestStructuralHashes(t *testing.T) {
+	var s APISpec
+	if err := json.Unmarshal([]byte(hashSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	hashes := s.StructuralHashes()
+	if len(hashes) != len(s.Definitions) {
+		t.Fatalf("Expected a hash of each of the %d definitions, got %d", len(s.Definitions), len(hashes))
+	}
+
+	for _, c := range []struct {
+		a, b  DefinitionName
+		equal bool
+	}{
+		// The group/version/kind and the names of what's referred to
+		// aren't hashed.
+		{"io.k8s.api.apps.v1beta1.Scale", "io.k8s.api.extensions.v1beta1.Scale", true},
+		{"io.k8s.api.apps.v1beta1.ScaleSpec", "io.k8s.api.extensions.v1beta1.ScaleSpec", true},
+		// The schemas of what's referred to are.
+		{"io.k8s.api.apps.v1beta1.Scale", "io.k8s.api.autoscaling.v1.Scale", false},
+		// Cycles are hashed by their shape, wherever they start.
+		{"io.k8s.api.apps.v1beta1.Props", "io.k8s.api.extensions.v1beta1.Props", true},
+		{"io.k8s.api.apps.v1beta1.Even", "io.k8s.api.apps.v1beta1.Odd", true},
+		{"io.k8s.api.apps.v1beta1.Props", "io.k8s.api.apps.v1beta1.Even", false},
+	} {
+		if equal := hashes[c.a] == hashes[c.b]; equal != c.equal {
+			t.Errorf("Expected the hashes of '%s' and '%s' to be equal: %v, got %v", c.a, c.b, c.equal, equal)
+		}
+	}
+
+	// Hashing doesn't depend on the order definitions are reached in.
+	for i := 0; i < 10; i++ {
+		for name, hash := range s.StructuralHashes() {
+			if hash != hashes[name] {
+				t.Fatalf("Expected the hash of '%s' to be stable", name)
+			}
+		}
+	}
+}
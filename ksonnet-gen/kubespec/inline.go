@@ -0,0 +1,158 @@
+package kubespec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// inlineSuffix ends the name of every definition synthesized by
+// `WithInlineDefinitions`, so that they stand out from real
+// definitions, which are named after Go types.
+const inlineSuffix = "Inline"
+
+// WithInlineDefinitions returns a copy of the spec in which properties
+// with an inline object schema (i.e., `type: object` with nested
+// `properties`, but no `$ref`, which is common in CRD schemas) refer
+// instead to a synthesized definition with that schema, so that they
+// can be treated exactly like properties that refer to a definition.
+// It also returns the names of the synthesized definitions, sorted.
+//
+// Synthesized definitions are in the package of the definition whose
+// property they replace, and are named after it and the property, with
+// the suffix `Inline`, e.g., `DeploymentSpecStrategyInline` for
+// `DeploymentSpec.strategy`, or `DeploymentSpecStrategyRollingUpdateInline`
+// for its `rollingUpdate`. A number is appended if that name is
+// already taken.
+//
+// Inline objects nested more than `maxDepth` levels deep (counting the
+// properties of real definitions as the first level) are left as they
+// are. Properties that preserve unknown fields are never replaced,
+// since their schemas are incomplete, unless they're embedded
+// resources (`x-kubernetes-embedded-resource`), whose `apiVersion`,
+// `kind` and `metadata` are known. The receiver isn't modified.
+func (s *APISpec) WithInlineDefinitions(maxDepth int) (*APISpec, []DefinitionName) {
+	synthesized := SchemaDefinitions{}
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+
+	names := []DefinitionName{}
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		kind := string(name)[strings.LastIndex(string(name), ".")+1:]
+		definitions[name] = synthesizeInline(
+			name, kind, s.Definitions[name], 1, maxDepth, definitions, synthesized)
+	}
+
+	inline := []DefinitionName{}
+	for name := range synthesized {
+		inline = append(inline, name)
+	}
+	sort.Slice(inline, func(i, j int) bool { return inline[i] < inline[j] })
+	return s.withDefinitions(definitions), inline
+}
+
+// synthesizeInline returns `def`, or, if any of its properties at
+// `depth` have inline object schemas, a copy of it in which they refer
+// to synthesized definitions, which are added to both `definitions`
+// and `synthesized`. `stem` is what the names of those definitions
+// begin with, i.e., the kind of the real definition they're nested
+// in, followed by the names of the properties they're nested under.
+func synthesizeInline(
+	name DefinitionName, stem string, def *SchemaDefinition, depth, maxDepth int,
+	definitions, synthesized SchemaDefinitions,
+) *SchemaDefinition {
+	if depth > maxDepth {
+		return def
+	}
+
+	var properties Properties
+	for _, propName := range def.Properties.sortedNames() {
+		prop := def.Properties[propName]
+		if !prop.isInlineObject() {
+			continue
+		}
+
+		inlineStem := stem + exportedName(string(propName))
+		inlineName := inlineDefinitionName(name, inlineStem, definitions)
+		inlineDef := &SchemaDefinition{
+			Type:        prop.Type,
+			Description: prop.Description,
+			Required:    prop.Required,
+			Properties:  prop.Properties,
+			Example:     prop.Example,
+			HasExample:  prop.HasExample,
+			Extensions:  prop.Extensions,
+
+			EmbeddedResource:      prop.EmbeddedResource,
+			PreserveUnknownFields: prop.PreserveUnknownFields,
+		}
+		inlineDef = synthesizeInline(
+			inlineName, inlineStem, inlineDef, depth+1, maxDepth, definitions, synthesized)
+		definitions[inlineName] = inlineDef
+		synthesized[inlineName] = inlineDef
+
+		if properties == nil {
+			properties = Properties{}
+			for name, prop := range def.Properties {
+				properties[name] = prop
+			}
+		}
+		properties[propName] = &Property{
+			Description: prop.Description,
+			Ref:         inlineName.AsObjectRef(),
+			Example:     prop.Example,
+			HasExample:  prop.HasExample,
+			Extensions:  prop.Extensions,
+		}
+	}
+
+	if properties == nil {
+		return def
+	}
+	copied := *def
+	copied.Properties = properties
+	return &copied
+}
+
+// isInlineObject reports whether a property declares the schema of an
+// object inline, rather than referring to a definition.
+func (p *Property) isInlineObject() bool {
+	return p.Ref == nil &&
+		p.Type != nil && *p.Type == "object" &&
+		len(p.Properties) > 0 &&
+		(!p.PreserveUnknownFields || p.EmbeddedResource)
+}
+
+// inlineDefinitionName names a definition synthesized for an inline
+// schema nested in `parent`, in its package; see
+// `WithInlineDefinitions`.
+func inlineDefinitionName(
+	parent DefinitionName, stem string, definitions SchemaDefinitions,
+) DefinitionName {
+	pkg := string(parent)[:strings.LastIndex(string(parent), ".")]
+	name := DefinitionName(fmt.Sprintf("%s.%s%s", pkg, stem, inlineSuffix))
+	for i := 2; definitions[name] != nil; i++ {
+		name = DefinitionName(fmt.Sprintf("%s.%s%s%d", pkg, stem, inlineSuffix, i))
+	}
+	return name
+}
+
+// exportedName turns a property name, which in CRD schemas may be any
+// string (e.g., `rolling-update`), into the UpperCamelCase name of a
+// Go-style type (e.g., `RollingUpdate`).
+func exportedName(propName string) string {
+	words := strings.FieldsFunc(propName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
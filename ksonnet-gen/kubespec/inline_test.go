@@ -0,0 +1,88 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var inlineSpec = `{
+  "definitions": {
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "properties": {
+        "replicas": {"type": "integer"},
+        "strategy": {
+          "description": "The deployment strategy.",
+          "type": "object",
+          "required": ["type"],
+          "properties": {
+            "type": {"type": "string"},
+            "rolling-update": {
+              "type": "object",
+              "properties": {
+                "maxSurge": {"type": "integer"},
+                "limits": {"type": "object", "properties": {"max": {"type": "integer"}}}
+              }
+            }
+          }
+        },
+        "config": {"type": "object", "x-kubernetes-preserve-unknown-fields": true, "properties": {"a": {"type": "string"}}},
+        "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+      }
+    },
+    "io.k8s.api.apps.v1.DeploymentSpecStrategyInline": {
+      "properties": {"legacy": {"type": "boolean"}}
+    }
+  }
+}`
+
+func TestWithInlineDefinitions(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(inlineSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	const deploymentSpec = "io.k8s.api.apps.v1.DeploymentSpec"
+
+	withInline, inline := s.WithInlineDefinitions(5)
+	expected := []DefinitionName{
+		"io.k8s.api.apps.v1.DeploymentSpecStrategyInline2",
+		"io.k8s.api.apps.v1.DeploymentSpecStrategyRollingUpdateInline",
+		"io.k8s.api.apps.v1.DeploymentSpecStrategyRollingUpdateLimitsInline",
+	}
+	if !reflect.DeepEqual(inline, expected) {
+		t.Errorf("Expected synthesized definitions %v got %v", expected, inline)
+	}
+
+	strategy := withInline.Definitions[deploymentSpec].Properties["strategy"]
+	if strategy.Ref == nil || *strategy.Ref.Name() != expected[0] ||
+		strategy.Description != "The deployment strategy." {
+		t.Errorf("Expected 'strategy' to refer to '%s', got %#v", expected[0], strategy)
+	}
+	def := withInline.Definitions[expected[0]]
+	if !reflect.DeepEqual(def.Required, []string{"type"}) || len(def.Properties) != 2 {
+		t.Errorf("Expected synthesized definition to have the inline schema, got %#v", def)
+	}
+	if ref := def.Properties["rolling-update"].Ref; ref == nil || *ref.Name() != expected[1] {
+		t.Errorf("Expected nested inline object to refer to '%s', got %v", expected[1], ref)
+	}
+	for _, name := range []PropertyName{"config", "labels", "replicas"} {
+		if withInline.Definitions[deploymentSpec].Properties[name].Ref != nil {
+			t.Errorf("Expected '%s' not to be replaced", name)
+		}
+	}
+
+	// The spec itself is unchanged.
+	if len(s.Definitions) != 2 || s.Definitions[deploymentSpec].Properties["strategy"].Ref != nil {
+		t.Errorf("Expected the original spec to be unchanged")
+	}
+
+	// Deeper objects are left inline.
+	withInline, inline = s.WithInlineDefinitions(2)
+	if !reflect.DeepEqual(inline, expected[:2]) {
+		t.Errorf("Expected synthesized definitions %v got %v", expected[:2], inline)
+	}
+	limits := withInline.Definitions[expected[1]].Properties["limits"]
+	if limits.Ref != nil || len(limits.Properties) != 1 {
+		t.Errorf("Expected 'limits' to be left inline, got %#v", limits)
+	}
+}
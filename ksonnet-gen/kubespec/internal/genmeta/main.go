@@ -0,0 +1,96 @@
+// Command genmeta writes the fallback spec that `kubespec.EmbeddedMeta`
+// embeds: the apimachinery definitions of a pinned upstream Kubernetes
+// spec that objects refer to (`kubespec.EmbeddedMetaRoots`, and every
+// definition they refer to in turn), with the spec's `info`, and
+// nothing else. It's run by `go generate` in package kubespec:
+//
+//	genmeta -o embeddedmeta.json [path or URL of swagger.json]
+//
+// The output has sorted keys and a trailing newline, so that
+// regenerating from the same spec doesn't change it.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func main() {
+	out := flag.String("o", "", "the file to write the fallback spec to")
+	flag.Parse()
+	if *out == "" || flag.NArg() != 1 {
+		log.Fatal("Usage: genmeta -o embeddedmeta.json [path or URL of swagger.json]")
+	}
+
+	text, err := read(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Could not read spec '%s':\n%v", flag.Arg(0), err)
+	}
+	parsed, err := kubespec.UnmarshalSpec(flag.Arg(0), text)
+	if err != nil {
+		log.Fatalf("Could not deserialize spec '%s':\n%v", flag.Arg(0), err)
+	}
+	// The definitions are copied as they are, rather than as kubespec
+	// reads them, so that nothing kubespec doesn't model is lost.
+	raw := struct {
+		Info        map[string]interface{} `json:"info"`
+		Definitions map[string]interface{} `json:"definitions"`
+	}{}
+	if err := json.Unmarshal(text, &raw); err != nil {
+		log.Fatalf("Could not deserialize spec '%s':\n%v", flag.Arg(0), err)
+	}
+
+	definitions := map[string]interface{}{}
+	for _, root := range kubespec.EmbeddedMetaRoots {
+		if _, ok := parsed.Definitions[root]; !ok {
+			log.Fatalf("Spec '%s' has no '%s'", flag.Arg(0), root)
+		}
+		for name := range parsed.Closure(root) {
+			if def, ok := raw.Definitions[string(name)]; ok {
+				definitions[string(name)] = def
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(map[string]interface{}{
+		"swagger":     "2.0",
+		"info":        map[string]interface{}{"title": raw.Info["title"], "version": raw.Info["version"]},
+		"definitions": definitions,
+	})
+	if err != nil {
+		log.Fatalf("Could not serialize fallback spec:\n%v", err)
+	}
+	if err := ioutil.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("Could not write '%s':\n%v", *out, err)
+	}
+	log.Printf("Wrote %d definitions of '%s' to '%s'", len(definitions), flag.Arg(0), *out)
+}
+
+// read returns the text of the spec at `source`, a path or an
+// `http://` or `https://` URL.
+func read(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ioutil.ReadFile(source)
+	}
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("'%s' returned %s", source, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,655 @@
+package kubespec
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// ModelFormatVersion is the version of the format `APISpec.WriteTo`
+// writes, which `ReadSpecFrom` checks. It changes whenever the format
+// itself does, including what's derived from the spec that a model
+// records; changes to the fields of `APISpec` are caught by the layout
+// digest the model also records.
+const ModelFormatVersion byte = 2
+
+// modelMagic starts every model, so that `ReadSpecFrom` can tell a
+// model from a spec, or from anything else.
+const modelMagic = "ksonnet-gen model\n"
+
+// maxModelPrealloc is the most elements `ReadSpecFrom` allocates for a
+// list or list of bytes before reading them, so that a corrupt length
+// fails when the model runs out, rather than by exhausting memory.
+const maxModelPrealloc = 1 << 16
+
+// WriteTo serializes the spec as a model, which `ReadSpecFrom` reads
+// back much faster than the spec can be parsed, e.g., to parse a spec
+// once and generate from it in a later stage of a pipeline. The model
+// has every exported field of the spec, including those that aren't
+// JSON (`Extensions`, `PropertyOrder`, `HasExample`, and the
+// provenance: `Text`, `FilePath`, `SourceSHA256`, and
+// `MetadataProblems`), so that what's generated from it is identical,
+// byte for byte, to what's generated from the spec.
+//
+// The model also records what's derived from the spec, so that the
+// spec `ReadSpecFrom` returns doesn't derive it again: the names of
+// its definitions, parsed with `NativePrefix` (see
+// `Parser.WriteModel`), the graph of the references between them, the
+// resources of its paths, and the classes of its definitions.
+//
+// A model starts with `ModelFormatVersion` and a digest of the layout
+// of `APISpec`, so that a model written by another build is rejected
+// rather than misread. Maps are written in order of their keys, so
+// the same spec is always written as the same bytes.
+func (s *APISpec) WriteTo(w io.Writer) (int64, error) {
+	return s.writeModel(w, []string{NativePrefix}, parseName)
+}
+
+// WriteModel is like `APISpec.WriteTo`, but records the names of the
+// spec's definitions as the parser parses them, so that a parser with
+// the same `Prefixes` can `Preload` them.
+func (p *Parser) WriteModel(w io.Writer, s *APISpec) (int64, error) {
+	return s.writeModel(w, p.Prefixes, p.ParseName)
+}
+
+func (s *APISpec) writeModel(
+	w io.Writer, prefixes []string, parse func(DefinitionName) (ParsedName, error),
+) (int64, error) {
+	derived, err := json.Marshal(s.derive(prefixes, parse))
+	if err != nil {
+		return 0, fmt.Errorf("Could not write model:\n%v", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	e := &modelEncoder{w: bw, layout: modelLayout{}}
+	e.w.WriteString(modelMagic)
+	e.w.WriteByte(ModelFormatVersion)
+	digest := e.layout.digest()
+	e.w.Write(digest[:])
+	e.n += int64(len(modelMagic)) + 1 + int64(len(digest))
+	if err := e.value(reflect.ValueOf(s).Elem()); err != nil {
+		return e.n, fmt.Errorf("Could not write model: %v", within(err, "spec"))
+	}
+	e.writeString(string(derived))
+	if err := bw.Flush(); err != nil {
+		return e.n, fmt.Errorf("Could not write model:\n%v", err)
+	}
+	return e.n, nil
+}
+
+// ReadSpecFrom deserializes a spec written by `APISpec.WriteTo`, with
+// what the model records that's derived from it. It fails clearly if
+// `r` isn't a model, or is one of another format version, or was
+// written by a build whose `APISpec` has other fields, in which case
+// the spec should be saved again. Errors are of `ErrSpecLoad`.
+func ReadSpecFrom(r io.Reader) (_ *APISpec, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	d := &modelDecoder{r: bufio.NewReader(r), layout: modelLayout{}}
+	magic := make([]byte, len(modelMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil || string(magic) != modelMagic {
+		return nil, fmt.Errorf("Could not read model: it isn't a model written by `--save-model`")
+	}
+	version, err := d.r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read model: it has no format version")
+	}
+	if version != ModelFormatVersion {
+		return nil, fmt.Errorf(
+			"Could not read model: it's of format version %d, but this build reads version %d; save it again with this build",
+			version, ModelFormatVersion)
+	}
+	var digest [sha256.Size]byte
+	if _, err := io.ReadFull(d.r, digest[:]); err != nil {
+		return nil, fmt.Errorf("Could not read model: it has no layout digest")
+	}
+	if digest != d.layout.digest() {
+		return nil, fmt.Errorf(
+			"Could not read model: it was written by a build whose spec model has other fields; save it again with this build")
+	}
+
+	s := &APISpec{}
+	if err := d.value(reflect.ValueOf(s).Elem()); err != nil {
+		return nil, fmt.Errorf("Could not read model: %v", within(err, "spec"))
+	}
+	text, err := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read model: %v", within(err, "derived"))
+	}
+	derived := &modelDerived{}
+	if err := json.Unmarshal([]byte(text), derived); err != nil {
+		return nil, fmt.Errorf("Could not read model: what it derived from the spec is malformed:\n%v", err)
+	}
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("Could not read model: it has data after the spec")
+	}
+	s.restore(derived)
+	return s, nil
+}
+
+// modelDerived is what a model records that's derived from its spec,
+// which is written as JSON: the names of the spec's definitions that
+// parse with `Prefixes`, the graph of the references between every
+// definition (see `APISpec.ReferenceGraph`), the resources of its
+// paths, sorted by their group/version/kind, and the classes of its
+// definitions.
+type modelDerived struct {
+	Prefixes   []string                       `json:"prefixes"`
+	Names      map[DefinitionName]ParsedName  `json:"names"`
+	References *ReferenceGraph                `json:"references"`
+	Resources  []*modelResource               `json:"resources"`
+	Classes    map[DefinitionName]ObjectClass `json:"classes"`
+}
+
+// modelResource is the resource of the kind `Kind`, since JSON can't
+// key a map by a `TopLevelSpec`.
+type modelResource struct {
+	Kind TopLevelSpec `json:"kind"`
+	Resource
+}
+
+// `derive` returns what a model of the spec records that's derived
+// from it, with its names parsed by `parse`, which accepts `prefixes`.
+func (s *APISpec) derive(
+	prefixes []string, parse func(DefinitionName) (ParsedName, error),
+) *modelDerived {
+	derived := &modelDerived{
+		Prefixes:   prefixes,
+		Names:      map[DefinitionName]ParsedName{},
+		References: s.ReferenceGraph(nil, 0, nil),
+		Resources:  []*modelResource{},
+		Classes:    s.allClasses(),
+	}
+	for name := range s.Definitions {
+		if parsed, err := parse(name); err == nil {
+			derived.Names[name] = parsed
+		}
+	}
+	for gvk, resource := range s.allResources() {
+		derived.Resources = append(derived.Resources, &modelResource{Kind: gvk, Resource: *resource})
+	}
+	sort.Slice(derived.Resources, func(i, j int) bool {
+		return derived.Resources[i].Kind.String() < derived.Resources[j].Kind.String()
+	})
+	return derived
+}
+
+// `restore` sets what's derived from the spec, which was read from a
+// model, to what the model recorded, rather than deriving it again.
+func (s *APISpec) restore(derived *modelDerived) {
+	s.model = derived
+	s.resourcesOnce.Do(func() {
+		s.resources = make(map[TopLevelSpec]*Resource, len(derived.Resources))
+		for _, resource := range derived.Resources {
+			r := resource.Resource
+			s.resources[resource.Kind] = &r
+		}
+	})
+	s.classesOnce.Do(func() {
+		s.classes = derived.Classes
+	})
+}
+
+// Preload adds the names of the definitions of `s` that its model
+// recorded (see `ReadSpecFrom`) to the parser's cache, if the model
+// parsed them with the same `Prefixes`, so that they aren't parsed
+// again. It does nothing if `s` wasn't read from a model, e.g., if
+// it's been filtered since, or if the model parsed them with other
+// prefixes, in which case names are parsed as they're needed.
+func (p *Parser) Preload(s *APISpec) {
+	if s.model == nil || !equalStrings(s.model.Prefixes, p.Prefixes) {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.names == nil {
+		p.names = make(map[DefinitionName]*parseResult, len(s.model.Names))
+	}
+	for name, parsed := range s.model.Names {
+		if _, ok := p.names[name]; !ok {
+			p.names[name] = &parseResult{parsed: parsed}
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// `modelLayout` holds the fields of the struct types of a model, by
+// type, which a model lists as it's written or read, rather than for
+// every value, since listing them takes longer than reading most
+// values.
+type modelLayout map[reflect.Type][]reflect.StructField
+
+// `digest` returns the digest of the layout of `APISpec`: the name and
+// type of each field a model has, recursively.
+func (l modelLayout) digest() [sha256.Size]byte {
+	var buf bytes.Buffer
+	l.write(&buf, reflect.TypeOf(APISpec{}), map[reflect.Type]bool{})
+	return sha256.Sum256(buf.Bytes())
+}
+
+func (l modelLayout) write(buf *bytes.Buffer, t reflect.Type, seen map[reflect.Type]bool) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		buf.WriteString("*")
+		l.write(buf, t.Elem(), seen)
+	case reflect.Slice:
+		buf.WriteString("[]")
+		l.write(buf, t.Elem(), seen)
+	case reflect.Map:
+		buf.WriteString("map[")
+		l.write(buf, t.Key(), seen)
+		buf.WriteString("]")
+		l.write(buf, t.Elem(), seen)
+	case reflect.Struct:
+		// Structs are written once, and then by name, since `Property`
+		// refers to itself.
+		buf.WriteString(t.Name())
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		buf.WriteString("{")
+		for _, field := range l.fields(t) {
+			fmt.Fprintf(buf, "%s ", field.Name)
+			l.write(buf, field.Type, seen)
+			buf.WriteString(";")
+		}
+		buf.WriteString("}")
+	default:
+		buf.WriteString(t.Kind().String())
+	}
+}
+
+// `fields` returns the fields of the struct type `t` that a model has,
+// which are its exported ones.
+func (l modelLayout) fields(t reflect.Type) []reflect.StructField {
+	if fields, ok := l[t]; ok {
+		return fields
+	}
+	fields := []reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.PkgPath == "" {
+			fields = append(fields, field)
+		}
+	}
+	l[t] = fields
+	return fields
+}
+
+// The tags of the dynamic values of a model's `interface{}` fields,
+// which are the values `encoding/json` decodes, with `json.Number`s
+// for numbers; see `SchemaDefinition.Example`.
+const (
+	modelNull byte = iota
+	modelBool
+	modelString
+	modelNumber
+	modelFloat
+	modelObject
+	modelArray
+)
+
+var (
+	boolType       = reflect.TypeOf(false)
+	stringType     = reflect.TypeOf("")
+	floatType      = reflect.TypeOf(float64(0))
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	objectType     = reflect.TypeOf(map[string]interface{}{})
+	arrayType      = reflect.TypeOf([]interface{}{})
+)
+
+// `modelError` is a problem with a value of a model, at `path`, e.g.,
+// `spec.Definitions["io.k8s.api.core.v1.Pod"].Example`, which is
+// built up as the error is returned through the values that hold it,
+// so that paths are only built when there's an error.
+type modelError struct {
+	path    string
+	problem string
+}
+
+func (e *modelError) Error() string {
+	return fmt.Sprintf("'%s' %s", e.path, e.problem)
+}
+
+// `within` prepends `segment` to the path of `err`, if it's a
+// `modelError`.
+func within(err error, segment string) error {
+	if me, ok := err.(*modelError); ok {
+		me.path = segment + me.path
+	}
+	return err
+}
+
+// errTruncated is the problem of a value the model ends within.
+const errTruncated = "is truncated"
+
+// `modelEncoder` writes the values of a model: booleans as a byte,
+// integers as varints, strings and lists of bytes by their length and
+// then their bytes, pointers, lists, and maps by whether they're nil
+// (a length of 0) and then their length plus one and their elements
+// (maps in order of their keys), structs by their fields, in order,
+// and dynamic values by their tag and then their value. `n` is how many
+// bytes were written.
+type modelEncoder struct {
+	w      *bufio.Writer
+	n      int64
+	layout modelLayout
+}
+
+func (e *modelEncoder) writeUvarint(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	e.w.Write(buf[:n])
+	e.n += int64(n)
+}
+
+func (e *modelEncoder) writeString(s string) {
+	e.writeUvarint(uint64(len(s)))
+	e.w.WriteString(s)
+	e.n += int64(len(s))
+}
+
+func (e *modelEncoder) writeByte(b byte) {
+	e.w.WriteByte(b)
+	e.n++
+}
+
+// `value` writes `v`.
+func (e *modelEncoder) value(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.writeByte(1)
+		} else {
+			e.writeByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutVarint(buf[:], v.Int())
+		e.w.Write(buf[:n])
+		e.n += int64(n)
+	case reflect.Float64:
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float()))
+		e.w.Write(buf[:])
+		e.n += 8
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.writeByte(0)
+			return nil
+		}
+		e.writeByte(1)
+		return e.value(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.writeUvarint(0)
+			return nil
+		}
+		e.writeUvarint(uint64(v.Len()) + 1)
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.w.Write(v.Bytes())
+			e.n += int64(v.Len())
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.value(v.Index(i)); err != nil {
+				return within(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return &modelError{problem: fmt.Sprintf("can't be written to a model: unexpected %s", v.Type())}
+		}
+		if v.IsNil() {
+			e.writeUvarint(0)
+			return nil
+		}
+		e.writeUvarint(uint64(v.Len()) + 1)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			e.writeString(key.String())
+			if err := e.value(v.MapIndex(key)); err != nil {
+				return within(err, fmt.Sprintf("[%q]", key.String()))
+			}
+		}
+	case reflect.Struct:
+		for _, field := range e.layout.fields(v.Type()) {
+			if err := e.value(v.Field(field.Index[0])); err != nil {
+				return within(err, "."+field.Name)
+			}
+		}
+	case reflect.Interface:
+		return e.dynamic(v)
+	default:
+		return &modelError{problem: fmt.Sprintf("can't be written to a model: unexpected %s", v.Type())}
+	}
+	return nil
+}
+
+// `dynamic` writes the value of the `interface{}` `v`.
+func (e *modelEncoder) dynamic(v reflect.Value) error {
+	if v.IsNil() {
+		e.writeByte(modelNull)
+		return nil
+	}
+	v = v.Elem()
+	switch {
+	case v.Type() == jsonNumberType:
+		e.writeByte(modelNumber)
+	case v.Type() == objectType:
+		e.writeByte(modelObject)
+	case v.Type() == arrayType:
+		e.writeByte(modelArray)
+	case v.Type() == boolType:
+		e.writeByte(modelBool)
+	case v.Type() == stringType:
+		e.writeByte(modelString)
+	case v.Type() == floatType:
+		e.writeByte(modelFloat)
+	default:
+		return &modelError{problem: fmt.Sprintf("can't be written to a model: unexpected %s", v.Type())}
+	}
+	return e.value(v)
+}
+
+// `modelDecoder` reads the values `modelEncoder` writes.
+type modelDecoder struct {
+	r      *bufio.Reader
+	layout modelLayout
+}
+
+// `length` reads the length of a list or map, returning false if it's
+// nil.
+func (d *modelDecoder) length() (int, bool, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, false, &modelError{problem: errTruncated}
+	}
+	if n == 0 {
+		return 0, false, nil
+	}
+	if n-1 > math.MaxInt32 {
+		return 0, false, &modelError{problem: fmt.Sprintf("has a length of %d", n-1)}
+	}
+	return int(n - 1), true, nil
+}
+
+// `bytes` reads `n` bytes.
+func (d *modelDecoder) bytes(n int) ([]byte, error) {
+	if n <= maxModelPrealloc {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(d.r, b); err != nil {
+			return nil, &modelError{problem: errTruncated}
+		}
+		return b, nil
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, &modelError{problem: errTruncated}
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *modelDecoder) readString() (string, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil || n > math.MaxInt32 {
+		return "", &modelError{problem: errTruncated}
+	}
+	b, err := d.bytes(int(n))
+	return string(b), err
+}
+
+// `value` reads into `v`, which is settable.
+func (d *modelDecoder) value(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return &modelError{problem: errTruncated}
+		}
+		v.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := binary.ReadVarint(d.r)
+		if err != nil {
+			return &modelError{problem: errTruncated}
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		var buf [8]byte
+		if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+			return &modelError{problem: errTruncated}
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(buf[:])))
+	case reflect.String:
+		s, err := d.readString()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Ptr:
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return &modelError{problem: errTruncated}
+		}
+		if b == 0 {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := d.value(elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		n, ok, err := d.length()
+		if err != nil || !ok {
+			return err
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.bytes(n)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		prealloc := n
+		if prealloc > maxModelPrealloc {
+			prealloc = maxModelPrealloc
+		}
+		slice := reflect.MakeSlice(v.Type(), 0, prealloc)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		for i := 0; i < n; i++ {
+			elem.Set(reflect.Zero(elem.Type()))
+			if err := d.value(elem); err != nil {
+				return within(err, fmt.Sprintf("[%d]", i))
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		v.Set(slice)
+	case reflect.Map:
+		n, ok, err := d.length()
+		if err != nil || !ok {
+			return err
+		}
+		m := reflect.MakeMap(v.Type())
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+		for i := 0; i < n; i++ {
+			s, err := d.readString()
+			if err != nil {
+				return err
+			}
+			key.SetString(s)
+			elem.Set(reflect.Zero(elem.Type()))
+			if err := d.value(elem); err != nil {
+				return within(err, fmt.Sprintf("[%q]", s))
+			}
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		for _, field := range d.layout.fields(v.Type()) {
+			if err := d.value(v.Field(field.Index[0])); err != nil {
+				return within(err, "."+field.Name)
+			}
+		}
+	case reflect.Interface:
+		return d.dynamic(v)
+	default:
+		return &modelError{problem: fmt.Sprintf("can't be read from a model: unexpected %s", v.Type())}
+	}
+	return nil
+}
+
+// `dynamic` reads the value of the `interface{}` `v`.
+func (d *modelDecoder) dynamic(v reflect.Value) error {
+	tag, err := d.r.ReadByte()
+	if err != nil {
+		return &modelError{problem: errTruncated}
+	}
+	var t reflect.Type
+	switch tag {
+	case modelNull:
+		return nil
+	case modelBool:
+		t = boolType
+	case modelString:
+		t = stringType
+	case modelNumber:
+		t = jsonNumberType
+	case modelFloat:
+		t = floatType
+	case modelObject:
+		t = objectType
+	case modelArray:
+		t = arrayType
+	default:
+		return &modelError{problem: fmt.Sprintf("has a value of unknown type %d", tag)}
+	}
+	value := reflect.New(t).Elem()
+	if err := d.value(value); err != nil {
+		return err
+	}
+	v.Set(value)
+	return nil
+}
@@ -0,0 +1,191 @@
+package kubespec
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// modelSpec has the values a model must keep exactly: zero values
+// behind pointers (`minLength: 0`, an empty `type`), empty lists as
+// well as absent ones, examples with nulls and large integers, and
+// vendor extensions.
+const modelSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.9.0", "contact": {"name": "sig-api-machinery"}},
+  "securityDefinitions": {"BearerToken": {"type": "apiKey", "name": "authorization", "in": "header"}},
+  "paths": {
+    "/apis/apps/v1/deployments": {
+      "get": {"operationId": "listAppsV1Deployment", "x-kubernetes-action": "list", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1", "kind": "Deployment"}, "x-codegen-request-body-name": "body"}
+    }
+  },
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "description": "Deployment enables declarative updates for Pods.",
+      "required": [],
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"},
+        "metadata": {"type": "object", "additionalProperties": {"type": "string"}, "x-custom": [1, null]}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "Deployment"}],
+      "example": {"spec": {"replicas": 9007199254740993, "paused": false, "selector": null}, "tags": ["a", 1.5]}
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "properties": {
+        "name": {"type": "", "minLength": 0, "maxLength": 63, "pattern": "^[a-z]+$", "example": null},
+        "replicas": {"type": "integer", "format": "int32", "minimum": 0, "exclusiveMaximum": true, "maximum": 1e3},
+        "containers": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.core.v1.Container"}, "x-kubernetes-list-type": "map", "x-kubernetes-list-map-keys": ["name"], "x-kubernetes-patch-merge-key": "name"},
+        "strategy": {"anyOf": [{"type": "integer"}, {"type": "string"}], "x-kubernetes-int-or-string": true}
+      },
+      "x-kubernetes-unions": [{"discriminator": "type", "fields-to-discriminateBy": {"rollingUpdate": "RollingUpdate"}}]
+    },
+    "io.k8s.api.core.v1.Container": {
+      "properties": {"name": {"type": "string"}}
+    }
+  }
+}`
+
+func TestModel(t *testing.T) {
+	s, err := UnmarshalSpec("swagger.json", []byte(modelSpec))
+	if err != nil {
+		t.Fatalf("Failed to unmarshal spec:\n%v", err)
+	}
+	s.FilePath = "specs"
+	s.SourceSHA256 = "0123abcd"
+	s.MetadataProblems = []string{"'info.license' isn't an object"}
+
+	var buf bytes.Buffer
+	n, err := s.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("Failed to write model:\n%v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Expected WriteTo to count the %d bytes it wrote, got %d", buf.Len(), n)
+	}
+	model := buf.Bytes()
+
+	read, err := ReadSpecFrom(bytes.NewReader(model))
+	if err != nil {
+		t.Fatalf("Failed to read model:\n%v", err)
+	}
+	if read.model == nil {
+		t.Fatalf("Expected the model to read back with what it derived from the spec")
+	}
+	derived := read.model
+	read.model = nil
+	if !reflect.DeepEqual(read, s) {
+		t.Errorf("Expected the model to read back as the spec it was written from")
+	}
+	read.model = derived
+	name := read.Definitions["io.k8s.api.apps.v1.DeploymentSpec"].Properties["name"]
+	if name.MinLength == nil || *name.MinLength != 0 || name.Type == nil || *name.Type != "" {
+		t.Errorf("Expected zero values behind pointers to be kept, got %#v", name)
+	}
+	if required := read.Definitions["io.k8s.api.apps.v1.Deployment"].Required; required == nil {
+		t.Errorf("Expected an empty list to stay empty, rather than absent")
+	}
+
+	// The same spec is always written the same, whatever the order of
+	// its maps.
+	for i := 0; i < 5; i++ {
+		var again bytes.Buffer
+		if _, err := read.WriteTo(&again); err != nil || !bytes.Equal(again.Bytes(), model) {
+			t.Fatalf("Expected the model to be written the same each time (%v)", err)
+		}
+	}
+
+	version := len(modelMagic)
+	corrupt := func(f func([]byte) []byte) []byte {
+		return f(append([]byte{}, model...))
+	}
+	failures := []struct {
+		text     []byte
+		expected string
+	}{
+		{[]byte(modelSpec), "it isn't a model written by `--save-model`"},
+		{corrupt(func(m []byte) []byte { m[version] = ModelFormatVersion + 1; return m }), "it's of format version 3, but this build reads version 2"},
+		{corrupt(func(m []byte) []byte { m[version+1] ^= 0xff; return m }), "it was written by a build whose spec model has other fields"},
+		{model[:len(model)-10], "'derived' is truncated"},
+		{append(corrupt(func(m []byte) []byte { return m }), 0), "it has data after the spec"},
+	}
+	for i, failure := range failures {
+		_, err := ReadSpecFrom(bytes.NewReader(failure.text))
+		if err == nil || !strings.Contains(err.Error(), failure.expected) {
+			t.Errorf("%d: Expected an error containing '%s', got %v", i, failure.expected, err)
+		}
+	}
+	// Truncated values are named by their path.
+	if _, err := ReadSpecFrom(bytes.NewReader(model[:len(model)/2])); err == nil || !strings.Contains(err.Error(), "'spec.") {
+		t.Errorf("Expected the truncated value to be named, got %v", err)
+	}
+}
+
+// TestModelDerived checks that a spec read from a model has what's
+// derived from the spec without deriving it again, and that it's what
+// the spec derives.
+func TestModelDerived(t *testing.T) {
+	s, err := UnmarshalSpec("swagger.json", []byte(modelSpec))
+	if err != nil {
+		t.Fatalf("Failed to unmarshal spec:\n%v", err)
+	}
+	prefixes := []string{NativePrefix, "com.github.openshift"}
+	var buf bytes.Buffer
+	if _, err := (&Parser{Prefixes: prefixes}).WriteModel(&buf, s); err != nil {
+		t.Fatalf("Failed to write model:\n%v", err)
+	}
+	read, err := ReadSpecFrom(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read model:\n%v", err)
+	}
+
+	// Restored rather than derived again, and so not from `Definitions`.
+	definitions := read.Definitions
+	read.Definitions = nil
+	if graph := read.ReferenceGraph(nil, 0, nil); !reflect.DeepEqual(graph, s.ReferenceGraph(nil, 0, nil)) {
+		t.Errorf("Expected the model's reference graph, got %#v", graph)
+	}
+	if inbound := read.InboundReferences(); !reflect.DeepEqual(inbound, s.InboundReferences()) {
+		t.Errorf("Expected the model's inbound references, got %v", inbound)
+	}
+	deployment := DefinitionName("io.k8s.api.apps.v1.Deployment")
+	if class := read.Classify(deployment); class != s.Classify(deployment) || !class.IsTopLevel() {
+		t.Errorf("Expected the model's class of '%s', got %#v", deployment, class)
+	}
+	gvk := TopLevelSpec{Group: "apps", Version: "v1", Kind: "Deployment"}
+	if resource := read.Resource(gvk); !reflect.DeepEqual(resource, s.Resource(gvk)) || resource == nil {
+		t.Errorf("Expected the model's resource of '%s', got %#v", gvk.String(), resource)
+	}
+	// The graph is a copy, which callers may change.
+	read.ReferenceGraph(nil, 0, nil).References[0].To = "changed"
+	if graph := read.ReferenceGraph(nil, 0, nil); graph.References[0].To == "changed" {
+		t.Errorf("Expected each reference graph to be a copy")
+	}
+	read.Definitions = definitions
+
+	// A parser with the model's prefixes doesn't parse its names again,
+	// and one with others does.
+	p := &Parser{Prefixes: prefixes}
+	p.Preload(read)
+	for name := range read.Definitions {
+		if _, err := p.ParseName(name); err != nil {
+			t.Errorf("Failed to parse '%s':\n%v", name, err)
+		}
+	}
+	if hits, misses, _ := p.Stats(); hits != 3 || misses != 0 {
+		t.Errorf("Expected the names to be preloaded, got %d hits and %d misses", hits, misses)
+	}
+	native := &Parser{Prefixes: []string{NativePrefix}}
+	native.Preload(read)
+	native.ParseName(deployment)
+	if _, misses, _ := native.Stats(); misses != 1 {
+		t.Errorf("Expected names parsed with other prefixes not to be preloaded")
+	}
+	// Nor does filtering keep them.
+	filtered := &Parser{Prefixes: prefixes}
+	filtered.Preload(read.Filter(func(DefinitionName, *SchemaDefinition) bool { return true }))
+	filtered.ParseName(deployment)
+	if _, misses, _ := filtered.Stats(); misses != 1 {
+		t.Errorf("Expected a filtered spec's names not to be preloaded")
+	}
+}
@@ -0,0 +1,62 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// `declaredNames` returns the names of the fields of the JSON object
+// `data` (e.g., a schema's `properties`) in the order they're written,
+// each once, where it's first written, or nil if it's absent or `null`.
+func declaredNames(data json.RawMessage) ([]PropertyName, error) {
+	if isAbsent(data) {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	names := []PropertyName{}
+	seen := map[string]bool{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if name, ok := token.(string); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, PropertyName(name))
+		}
+	}
+	return names, nil
+}
+
+// DeclaredPropertyNames returns the names of the definition's
+// properties in the order the spec declares them (see
+// `PropertyOrder`), followed, in order of name, by any it doesn't
+// declare, e.g., the `apiVersion` that `WithCRDs` adds to a custom
+// resource. The names are the same, in the same order, however often
+// the spec is loaded.
+func (sd *SchemaDefinition) DeclaredPropertyNames() []PropertyName {
+	names := []PropertyName{}
+	declared := map[PropertyName]bool{}
+	for _, name := range sd.PropertyOrder {
+		if _, ok := sd.Properties[name]; ok && !declared[name] {
+			declared[name] = true
+			names = append(names, name)
+		}
+	}
+	undeclared := []PropertyName{}
+	for name := range sd.Properties {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Slice(undeclared, func(i, j int) bool { return undeclared[i] < undeclared[j] })
+	return append(names, undeclared...)
+}
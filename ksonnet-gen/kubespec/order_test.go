@@ -0,0 +1,60 @@
+package kubespec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeclaredPropertyNames(t *testing.T) {
+	const definitions = `{"swagger": "2.0", "definitions": {"io.k8s.api.core.v1.ConfigMap": {"properties": {
+	  "kind": {"type": "string"},
+	  "apiVersion": {"type": "string"},
+	  "metadata": {"type": "object"},
+	  "data": {"type": "object"},
+	  "binaryData": {"type": "object"},
+	  "kind": {"type": "string"}
+	}}}}`
+	const yaml = `swagger: "2.0"
+definitions:
+  io.k8s.api.core.v1.ConfigMap:
+    properties:
+      kind: {type: string}
+      apiVersion: {type: string}
+      metadata: {type: object}
+      data: {type: object}
+      binaryData: {type: object}
+`
+	expected := []PropertyName{"kind", "apiVersion", "metadata", "data", "binaryData"}
+	for name, text := range map[string]string{"swagger.json": definitions, "swagger.yaml": yaml} {
+		// The order is the same however often the spec is loaded.
+		for i := 0; i < 10; i++ {
+			s, err := UnmarshalSpec(name, []byte(text))
+			if err != nil {
+				t.Fatalf("Failed to unmarshal '%s':\n%v", name, err)
+			}
+			def := s.Definitions["io.k8s.api.core.v1.ConfigMap"]
+			if names := def.DeclaredPropertyNames(); !reflect.DeepEqual(names, expected) {
+				t.Fatalf("Expected the properties of '%s' in the order %v, got %v", name, expected, names)
+			}
+		}
+	}
+
+	// Properties that aren't declared come last, by name, and those
+	// that are gone are left out.
+	str := SchemaType("string")
+	def := &SchemaDefinition{
+		Properties: Properties{
+			"spec":       &Property{Type: &str},
+			"metadata":   &Property{Type: &str},
+			"kind":       &Property{Type: &str},
+			"apiVersion": &Property{Type: &str},
+		},
+		PropertyOrder: []PropertyName{"spec", "status", "metadata"},
+	}
+	if names := def.DeclaredPropertyNames(); !reflect.DeepEqual(names, []PropertyName{"spec", "metadata", "apiVersion", "kind"}) {
+		t.Errorf("Expected the undeclared properties last, by name, got %v", names)
+	}
+	if names := (&SchemaDefinition{}).DeclaredPropertyNames(); len(names) != 0 {
+		t.Errorf("Expected a definition without properties to have none, got %v", names)
+	}
+}
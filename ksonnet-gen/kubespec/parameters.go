@@ -0,0 +1,103 @@
+package kubespec
+
+import (
+	"sort"
+)
+
+// QueryParameters returns the query parameters of the operations
+// whose `x-kubernetes-action` is `action` (e.g., `list`), merged by
+// name and sorted by it: each operation's own, and those of its path
+// (e.g., `pretty`), which the operation's override. When operations
+// declare a parameter differently, the first in order of path wins.
+func (s *APISpec) QueryParameters(action string) []*Parameter {
+	byName := map[string]*Parameter{}
+	for _, path := range s.sortedPaths() {
+		item := s.Paths[path]
+		for _, op := range item.Operations() {
+			if op.Action != action {
+				continue
+			}
+			for _, param := range operationParameters(item, op) {
+				if _, ok := byName[param.Name]; !ok && param.In == "query" {
+					byName[param.Name] = param
+				}
+			}
+		}
+	}
+
+	params := []*Parameter{}
+	for _, param := range byName {
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+// BodyDefinition returns the definition the body of the operations
+// whose `x-kubernetes-action` is `action` refers to, e.g.,
+// `io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions` for `delete`.
+// If they refer to several, it's the one most refer to, or, of those,
+// the first by name. It's false if none of them has a body that refers
+// to a definition of the spec.
+func (s *APISpec) BodyDefinition(action string) (DefinitionName, bool) {
+	counts := map[DefinitionName]int{}
+	for _, path := range s.sortedPaths() {
+		item := s.Paths[path]
+		for _, op := range item.Operations() {
+			if op.Action != action {
+				continue
+			}
+			for _, param := range operationParameters(item, op) {
+				if param.In != "body" || param.Schema == nil || param.Schema.Ref == nil {
+					continue
+				}
+				name, err := ParseRef(*param.Schema.Ref)
+				if err != nil || s.Definitions[*name] == nil {
+					continue
+				}
+				counts[*name]++
+			}
+		}
+	}
+
+	var found DefinitionName
+	for name, count := range counts {
+		if found == "" || count > counts[found] || (count == counts[found] && name < found) {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// `operationParameters` returns the parameters of `op`, at the path
+// `item`: its own, then those of the path it doesn't override, by name
+// and location.
+func operationParameters(item *PathItem, op *Operation) []*Parameter {
+	own := map[[2]string]bool{}
+	params := []*Parameter{}
+	for _, param := range op.Parameters {
+		if param != nil {
+			own[[2]string{param.Name, param.In}] = true
+			params = append(params, param)
+		}
+	}
+	for _, param := range item.Parameters {
+		if param != nil && !own[[2]string{param.Name, param.In}] {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// `sortedPaths` returns the paths of the spec that have an item, in
+// order.
+func (s *APISpec) sortedPaths() []string {
+	paths := []string{}
+	for path, item := range s.Paths {
+		if item != nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
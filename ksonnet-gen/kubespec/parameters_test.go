@@ -0,0 +1,104 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var parametersSpec = `{
+  "definitions": {
+    "io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions": {"properties": {"gracePeriodSeconds": {"type": "integer"}}},
+    "io.k8s.api.extensions.v1beta1.DeploymentRollback": {"properties": {"name": {"type": "string"}}}
+  },
+  "paths": {
+    "/api/v1/namespaces/{namespace}/pods": {
+      "get": {"x-kubernetes-action": "list", "parameters": [
+        {"name": "labelSelector", "in": "query", "type": "string"},
+        {"name": "limit", "in": "query", "type": "integer"},
+        {"name": "pretty", "in": "query", "type": "boolean"}
+      ]},
+      "delete": {"x-kubernetes-action": "deletecollection", "parameters": [
+        {"name": "fieldSelector", "in": "query", "type": "string"}
+      ]},
+      "parameters": [
+        {"name": "namespace", "in": "path", "type": "string", "required": true},
+        {"name": "pretty", "in": "query", "type": "string"}
+      ]
+    },
+    "/api/v1/namespaces/{namespace}/pods/{name}": {
+      "delete": {"x-kubernetes-action": "delete", "parameters": [
+        {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions"}},
+        {"name": "gracePeriodSeconds", "in": "query", "type": "integer"}
+      ]}
+    },
+    "/api/v1/nodes": {
+      "get": {"x-kubernetes-action": "list", "parameters": [
+        {"name": "fieldSelector", "in": "query", "type": "string"},
+        {"name": "limit", "in": "query", "type": "string"}
+      ]}
+    },
+    "/apis/extensions/v1beta1/namespaces/{namespace}/deployments/{name}/rollback": {
+      "delete": {"x-kubernetes-action": "delete", "parameters": [
+        {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/io.k8s.api.extensions.v1beta1.DeploymentRollback"}}
+      ]}
+    },
+    "/apis/apps/v1/namespaces/{namespace}/deployments/{name}": {
+      "delete": {"x-kubernetes-action": "delete", "parameters": [
+        {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions"}}
+      ]}
+    },
+    "/apis/example.com/v1/widgets/{name}": {
+      "delete": {"x-kubernetes-action": "delete", "parameters": [
+        {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/com.example.v1.Missing"}}
+      ]}
+    }
+  }
+}`
+
+func TestQueryParameters(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(parametersSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	// Operations' own parameters override their path's, and the first
+	// path's win over later ones; path parameters are left out.
+	params := s.QueryParameters("list")
+	expected := map[string]SchemaType{
+		"fieldSelector": "string",
+		"labelSelector": "string",
+		"limit":         "integer",
+		"pretty":        "boolean",
+	}
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Name)
+		if param.Type != expected[param.Name] {
+			t.Errorf("Expected '%s' to be of type '%s', got '%s'", param.Name, expected[param.Name], param.Type)
+		}
+	}
+	if want := []string{"fieldSelector", "labelSelector", "limit", "pretty"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected the query parameters %v, got %v", want, names)
+	}
+	if params := s.QueryParameters("watch"); len(params) != 0 {
+		t.Errorf("Expected no query parameters of 'watch', got %v", params)
+	}
+}
+
+func TestBodyDefinition(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(parametersSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	// The definition most deletes take wins; refs to definitions the
+	// spec doesn't have are ignored.
+	name, ok := s.BodyDefinition("delete")
+	if !ok || name != "io.k8s.apimachinery.pkg.apis.meta.v1.DeleteOptions" {
+		t.Errorf("Expected deletes to take 'DeleteOptions', got '%s'", name)
+	}
+	if name, ok := s.BodyDefinition("list"); ok {
+		t.Errorf("Expected lists to take no body, got '%s'", name)
+	}
+}
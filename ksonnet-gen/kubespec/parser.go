@@ -0,0 +1,156 @@
+package kubespec
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Parser parses `DefinitionName`s and `ObjectRef`s, caching the
+// results (including errors), since the same names are parsed many
+// times while generating a library. Programs that outlive a spec
+// (e.g., a service that reloads the spec when the cluster is upgraded)
+// can `Reset` it, and export its `Stats`.
+//
+// A Parser is safe for concurrent use once its `Prefixes` are set,
+// e.g., `&Parser{Prefixes: []string{NativePrefix}}`. The `ParsedName`s
+// and `DefinitionName`s it returns are copies, so callers may modify
+// them without affecting the cache.
+// Calls to `Reset` may race with calls to `Parse`, in which case the
+// result of a parse begun before the `Reset` may be cached after it;
+// programs that need a clean cache should stop parsing first.
+type Parser struct {
+	// Accessed atomically. First in the struct so that they're 64-bit
+	// aligned on 32-bit platforms.
+	hits, misses, errors uint64
+
+	// Prefixes are the prefixes of the definition names the parser
+	// accepts (e.g., `io.k8s` in `io.k8s.api.apps.v1.Deployment`). The
+	// rest of a name after its prefix is parsed the same way whatever
+	// the prefix, so adding, e.g., `com.github.openshift` accepts
+	// OpenShift's `com.github.openshift.api.route.v1.Route`. They're
+	// required, and must not be modified once the parser is in use.
+	Prefixes []string
+
+	mu    sync.RWMutex
+	names map[DefinitionName]*parseResult
+	refs  map[ObjectRef]*refResult
+}
+
+type parseResult struct {
+	parsed ParsedName
+	err    error
+}
+
+type refResult struct {
+	name DefinitionName
+	err  error
+}
+
+// ParseName parses a `DefinitionName` into a structured `ParsedName`,
+// returning an error if the name is malformed, or doesn't begin with
+// one of the parser's `Prefixes`. It trims the name as the `ParseName`
+// function does.
+func (p *Parser) ParseName(dn DefinitionName) (ParsedName, error) {
+	if len(p.Prefixes) == 0 {
+		err := fmt.Errorf("Could not parse '%s', since the parser has no definition prefixes", dn)
+		p.count(false, err)
+		return ParsedName{}, err
+	}
+
+	p.mu.RLock()
+	result, ok := p.names[dn]
+	p.mu.RUnlock()
+
+	if !ok {
+		parsed, err := parseWithPrefixes(dn, p.Prefixes)
+		result = &parseResult{parsed: parsed, err: err}
+
+		p.mu.Lock()
+		if p.names == nil {
+			p.names = map[DefinitionName]*parseResult{}
+		}
+		p.names[dn] = result
+		p.mu.Unlock()
+	}
+	p.count(ok, result.err)
+
+	if result.err != nil {
+		return ParsedName{}, result.err
+	}
+	return result.parsed, nil
+}
+
+// Parse is like `ParseName`, but returns a `ParsedDefinitionName`.
+//
+// Deprecated: Use `ParseName`.
+func (p *Parser) Parse(dn DefinitionName) (*ParsedDefinitionName, error) {
+	parsed, err := p.ParseName(dn)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.ParsedDefinitionName(), nil
+}
+
+// ParseRef parses a `DefinitionName` from an `ObjectRef`, returning an
+// error if the ref doesn't refer to a definition.
+func (p *Parser) ParseRef(or ObjectRef) (*DefinitionName, error) {
+	p.mu.RLock()
+	result, ok := p.refs[or]
+	p.mu.RUnlock()
+
+	if !ok {
+		result = &refResult{}
+		name, err := parseRef(or)
+		if err == nil {
+			result.name = *name
+		}
+		result.err = err
+
+		p.mu.Lock()
+		if p.refs == nil {
+			p.refs = map[ObjectRef]*refResult{}
+		}
+		p.refs[or] = result
+		p.mu.Unlock()
+	}
+	p.count(ok, result.err)
+
+	if result.err != nil {
+		return nil, result.err
+	}
+	name := result.name
+	return &name, nil
+}
+
+// Reset discards the parser's cached results, e.g., when the spec
+// they were parsed from is replaced. It doesn't reset `Stats`, which
+// are cumulative, like most metrics.
+func (p *Parser) Reset() {
+	p.mu.Lock()
+	p.names = nil
+	p.refs = nil
+	p.mu.Unlock()
+}
+
+// Stats reports how many calls to `ParseName` (or `Parse`) and
+// `ParseRef` were answered from the cache (`hits`) and how many had to
+// parse (`misses`), and how many of either returned an error. Each is read atomically, but
+// not all three together, so they may be mutually inconsistent while
+// the parser is in use.
+func (p *Parser) Stats() (hits, misses, errors uint64) {
+	return atomic.LoadUint64(&p.hits),
+		atomic.LoadUint64(&p.misses),
+		atomic.LoadUint64(&p.errors)
+}
+
+func (p *Parser) count(hit bool, err error) {
+	if hit {
+		atomic.AddUint64(&p.hits, 1)
+	} else {
+		atomic.AddUint64(&p.misses, 1)
+	}
+	if err != nil {
+		atomic.AddUint64(&p.errors, 1)
+	}
+}
@@ -0,0 +1,113 @@
+package kubespec
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	p := &Parser{Prefixes: []string{NativePrefix}}
+	const pod = "io.k8s.api.core.v1.Pod"
+
+	first, err := p.Parse(pod)
+	if err != nil {
+		t.Fatalf("Failed to parse '%s':\n%v", pod, err)
+	}
+	second, err := p.Parse(pod)
+	if err != nil || second.Kind != "Pod" {
+		t.Fatalf("Expected cached parse of '%s', got %#v and %v", pod, second, err)
+	}
+	if first == second {
+		t.Errorf("Expected each parse to return a copy")
+	}
+	first.Kind = "Modified"
+	if third, _ := p.Parse(pod); third.Kind != "Pod" {
+		t.Errorf("Expected modifying a result not to affect the cache")
+	}
+
+	if _, err := p.Parse("com.example.v1.Foo"); err == nil {
+		t.Errorf("Expected parse to fail")
+	}
+	if _, err := p.Parse("com.example.v1.Foo"); err == nil {
+		t.Errorf("Expected cached parse to fail")
+	}
+	if hits, misses, errors := p.Stats(); hits != 3 || misses != 2 || errors != 2 {
+		t.Errorf("Expected 3 hits, 2 misses, 2 errors, got %d, %d, %d", hits, misses, errors)
+	}
+
+	name, err := p.ParseRef("#/definitions/" + pod)
+	if err != nil || *name != pod {
+		t.Errorf("Expected ref to parse to '%s', got %v and %v", pod, name, err)
+	}
+	if _, err := p.ParseRef(pod); err == nil {
+		t.Errorf("Expected ref without prefix to fail")
+	}
+	p.ParseRef("#/definitions/" + pod)
+	if hits, misses, errors := p.Stats(); hits != 4 || misses != 4 || errors != 3 {
+		t.Errorf("Expected 4 hits, 4 misses, 3 errors, got %d, %d, %d", hits, misses, errors)
+	}
+
+	// Reset empties the cache, but stats are cumulative.
+	p.Reset()
+	p.Parse(pod)
+	p.ParseRef("#/definitions/" + pod)
+	if hits, misses, _ := p.Stats(); hits != 4 || misses != 6 {
+		t.Errorf("Expected 4 hits and 6 misses after reset, got %d, %d", hits, misses)
+	}
+}
+
+func TestParserPrefixes(t *testing.T) {
+	const route = "com.github.openshift.api.route.v1.Route"
+
+	p := &Parser{Prefixes: []string{"com.github.openshift"}}
+	if parsed, err := p.Parse(route); err != nil || parsed.Prefix != "com.github.openshift" {
+		t.Errorf("Expected '%s' to parse with the parser's prefixes, got %#v and %v", route, parsed, err)
+	}
+	if _, err := p.Parse("io.k8s.api.core.v1.Pod"); err == nil {
+		t.Errorf("Expected the parser's prefixes to replace the default ones")
+	}
+
+	// A parser without prefixes parses nothing.
+	p = &Parser{}
+	if _, err := p.ParseName("io.k8s.api.core.v1.Pod"); err == nil || !strings.Contains(err.Error(), "no definition prefixes") {
+		t.Errorf("Expected a parser without prefixes to fail, got %v", err)
+	}
+}
+
+// Run with `-race`.
+func TestParserConcurrency(t *testing.T) {
+	p := &Parser{Prefixes: []string{NativePrefix}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, namespace := range namespaces {
+					dn := DefinitionName(namespace)
+					parsed, err := p.Parse(dn)
+					if err != nil || parsed.Unparse() != dn {
+						t.Errorf("Expected '%s' to round-trip, got %v", dn, err)
+						return
+					}
+					if _, err := p.ParseRef(*dn.AsObjectRef()); err != nil {
+						t.Errorf("Failed to parse ref to '%s':\n%v", dn, err)
+						return
+					}
+				}
+				if i == 0 && j%10 == 0 {
+					p.Reset()
+				}
+				p.Stats()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	hits, misses, errors := p.Stats()
+	if total := uint64(8 * 100 * 2 * len(namespaces)); hits+misses != total || errors != 0 {
+		t.Errorf("Expected %d calls without errors, got %d hits, %d misses, %d errors",
+			total, hits, misses, errors)
+	}
+}
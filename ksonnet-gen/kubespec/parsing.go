@@ -2,111 +2,350 @@ package kubespec
 
 import (
 	"fmt"
-	"log"
+	"regexp"
 	"strings"
+	"unicode"
 )
 
 //-----------------------------------------------------------------------------
 // Utility methods for `DefinitionName` and `ObjectRef`.
 //-----------------------------------------------------------------------------
 
+// ParseName parses a `DefinitionName` into a structured `ParsedName`,
+// panicking with the error if the name is malformed. Use the
+// `ParseName` function to handle the error instead.
+func (dn *DefinitionName) ParseName() ParsedName {
+	parsed, err := ParseName(*dn)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// ParseName parses a `DefinitionName` into a structured `ParsedName`,
+// returning an error if the name is malformed, or doesn't begin with
+// `NativePrefix`. Results aren't cached; to cache them, or to accept
+// other prefixes, use a `Parser`.
+//
+// Whitespace and a UTF-8 byte order mark around the name are ignored
+// (see `TrimName`), as names often come from config files, but
+// whitespace within it is an error that gives its byte offset.
+func ParseName(dn DefinitionName) (ParsedName, error) {
+	return parseName(dn)
+}
+
 // Parse will parse a `DefinitionName` into a structured
-// `ParsedDefinitionName`.
+// `ParsedDefinitionName`, panicking with the error if the name is
+// malformed.
+//
+// Deprecated: Use the `ParseName` method.
 func (dn *DefinitionName) Parse() *ParsedDefinitionName {
-	split := strings.Split(string(*dn), ".")
+	return dn.ParseName().ParsedDefinitionName()
+}
+
+// ParseDefinitionName will parse a `DefinitionName` into a structured
+// `ParsedDefinitionName`, returning an error if the name is
+// malformed. Like `ParseName`, it ignores whitespace and a UTF-8 byte
+// order mark around the name, so that, e.g.,
+// `"io.k8s.api.apps.v1.Deployment "` parses to the kind `Deployment`,
+// but rejects whitespace within it.
+//
+// Deprecated: Use the `ParseName` function.
+func ParseDefinitionName(dn DefinitionName) (*ParsedDefinitionName, error) {
+	parsed, err := parseName(dn)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.ParsedDefinitionName(), nil
+}
+
+// MustParseDefinitionName is `ParseDefinitionName`, for scripts that
+// would rather not handle the error: it panics with it, so that defers
+// run, and callers embedding the package can recover it.
+func MustParseDefinitionName(dn DefinitionName) *ParsedDefinitionName {
+	parsed, err := ParseDefinitionName(dn)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// TrimName returns `name` (e.g., a definition name, ref, or prefix)
+// without the whitespace and UTF-8 byte order mark around it, which
+// names read from config files often carry. Names are trimmed the same
+// wherever they're parsed or loaded.
+func TrimName(name string) string {
+	trimmed, _ := trimName(name)
+	return trimmed
+}
+
+// `trimName` is `TrimName`, but also returns the byte offset in `name`
+// that the trimmed name starts at.
+func trimName(name string) (string, int) {
+	trimmed := strings.TrimLeftFunc(name, isSpaceOrBOM)
+	offset := len(name) - len(trimmed)
+	return strings.TrimRightFunc(trimmed, isSpaceOrBOM), offset
+}
+
+func isSpaceOrBOM(r rune) bool {
+	return unicode.IsSpace(r) || r == '\ufeff'
+}
+
+// parseName parses `dn` without caching the result, accepting only
+// `NativePrefix`. The functions, and methods of `APISpec`, that have no
+// parser use it; their `Parser` counterparts accept its `Prefixes`.
+func parseName(dn DefinitionName) (ParsedName, error) {
+	return parseWithPrefixes(dn, []string{NativePrefix})
+}
+
+// parseWithPrefixes parses `name`, accepting any of `prefixes`; see
+// `Parser.Prefixes`. Errors name `name` as it's given, and the
+// offsets they give are in it, even though it's parsed trimmed.
+func parseWithPrefixes(
+	name DefinitionName, prefixes []string,
+) (ParsedName, error) {
+	trimmed, offset := trimName(string(name))
+	dn := DefinitionName(trimmed)
+	segments := strings.Split(trimmed, ".")
+	// The error for the `i`th segment, whose problem is at byte `at`
+	// of the trimmed name.
+	malformed := func(i, at int, problem string) error {
+		return &MalformedNameError{name, i + 1, segments[i], problem, offset + at}
+	}
+
+	// Whitespace is an error wherever it is, even in a prefix.
+	if at := strings.IndexFunc(trimmed, isSpaceOrBOM); at != -1 {
+		return ParsedName{}, malformed(strings.Count(trimmed[:at], "."), at, "contains whitespace")
+	}
+	prefix, ok := definitionPrefix(dn, prefixes)
+	if !ok {
+		return ParsedName{}, categorizedf(ErrUnparsableName, "Failed to parse definition name '%s'", string(name))
+	}
+	for i, segment := range segments {
+		if segment == "" {
+			return ParsedName{}, malformed(i, segmentOffset(segments, i), "is empty")
+		}
+	}
+
+	// Parse the rest of the name as though it began with `io.k8s`, so
+	// that every prefix shares the same layout rules.
+	split := append(
+		[]string{"io", "k8s"},
+		strings.Split(strings.TrimPrefix(trimmed, prefix+"."), ".")...)
 	if len(split) < 6 {
-		log.Fatalf("Failed to parse definition name '%s'", string(*dn))
-	} else if split[0] != "io" || split[1] != "k8s" || split[3] != "pkg" {
-		log.Fatalf("Failed to parse definition name '%s'", string(*dn))
+		return ParsedName{}, categorizedf(ErrUnparsableName, "Failed to parse definition name '%s'", string(name))
 	}
 
+	parsed, err := parseDefinitionName(dn, split)
+	if err != nil {
+		return ParsedName{}, err
+	}
+	if !kindPattern.MatchString(string(parsed.Kind)) {
+		// `split` replaces the prefix with the two segments of `io.k8s`.
+		index := kindSegment(parsed) - 2 + strings.Count(prefix, ".") + 1
+		return ParsedName{}, malformed(
+			index, segmentOffset(segments, index),
+			"is not a valid kind; expected an identifier, e.g., 'Deployment'")
+	}
+	if prefix != NativePrefix {
+		parsed.Prefix = prefix
+	}
+	return parsed, nil
+}
+
+// `segmentOffset` returns the byte offset of the `i`th of `segments`
+// in the name they were split from.
+func segmentOffset(segments []string, i int) int {
+	offset := 0
+	for _, segment := range segments[:i] {
+		offset += len(segment) + 1
+	}
+	return offset
+}
+
+// MalformedNameError is the error for a definition name with a
+// malformed segment, e.g., the empty group of `io.k8s.api..v1.Foo`, or
+// the empty kind of `io.k8s.api.apps.v1.`. `Position` counts the
+// segments of the name from 1, and `Offset` is the byte offset in
+// `Name` of the problem: the whitespace in a segment that contains
+// it, or else the start of the segment.
+type MalformedNameError struct {
+	Name     DefinitionName
+	Position int
+	Segment  string
+	Problem  string
+	Offset   int
+}
+
+// Is reports that a malformed name is unparsable.
+func (e *MalformedNameError) Is(target error) bool {
+	return target == ErrUnparsableName
+}
+
+func (e *MalformedNameError) Error() string {
+	return fmt.Sprintf(
+		"Malformed definition name '%s' at byte %d: segment %d ('%s') %s",
+		e.Name, e.Offset, e.Position, e.Segment, e.Problem)
+}
+
+// kindPattern matches the kinds that make valid identifiers, e.g.,
+// `Deployment` and `JSONSchemaProps`.
+var kindPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// `kindSegment` returns the index of the kind of `parsed` in the
+// segments of its name, as though it began with `io.k8s`.
+func kindSegment(parsed ParsedName) int {
+	switch {
+	case parsed.Codebase == apiCodebase,
+		parsed.PackageType == Runtime,
+		parsed.PackageType == Version:
+		return 5
+	case parsed.PackageType == APIs:
+		return 7
+	default:
+		return 6
+	}
+}
+
+// NativePrefix is the prefix of the names of Kubernetes' own
+// definitions, which the functions that have no `Parser` accept.
+const NativePrefix = "io.k8s"
+
+// definitionPrefix returns the longest of `prefixes` that `dn` begins
+// with.
+func definitionPrefix(dn DefinitionName, prefixes []string) (string, bool) {
+	longest, ok := "", false
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(string(dn), prefix+".") && len(prefix) >= len(longest) {
+			longest, ok = prefix, true
+		}
+	}
+	return longest, ok
+}
+
+func parseDefinitionName(
+	dn DefinitionName, split []string,
+) (ParsedName, error) {
 	codebase := split[2]
 
+	if codebase == apiCodebase {
+		// Since Kubernetes v1.8, the API types live in their own
+		// repository, and names are something like:
+		// `io.k8s.api.apps.v1beta2.Deployment`, or
+		// `io.k8s.api.core.v1.Pod` for the core group.
+		parsed := ParsedName{
+			PackageType: APIs,
+			Codebase:    codebase,
+			Group:       GroupName(split[3]),
+			Version:     VersionString(split[4]),
+			Kind:        ObjectKind(split[5]),
+		}
+		if parsed.Group == apiCoreGroup {
+			parsed.PackageType = Core
+			parsed.Group = ""
+		}
+		return parsed, nil
+	} else if split[3] != "pkg" {
+		return ParsedName{}, categorizedf(ErrUnparsableName, "Failed to parse definition name '%s'", string(dn))
+	}
+
 	if split[4] == "api" {
 		// Name is something like: `io.k8s.kubernetes.pkg.api.v1.LimitRangeSpec`.
 		if len(split) < 7 {
-			log.Fatalf(
+			return ParsedName{}, categorizedf(
+				ErrUnparsableName,
 				"Expected >= 7 path components for package 'api' in path: '%s'",
-				string(*dn))
+				string(dn))
 		}
-		versionString := VersionString(split[5])
-		return &ParsedDefinitionName{
+		return ParsedName{
 			PackageType: Core,
 			Codebase:    codebase,
-			Group:       nil,
-			Version:     &versionString,
+			Version:     VersionString(split[5]),
 			Kind:        ObjectKind(split[6]),
-		}
+		}, nil
 	} else if split[4] == "apis" {
 		// Name is something like: `io.k8s.kubernetes.pkg.apis.batch.v1.JobList`.
 		if len(split) < 8 {
-			log.Fatalf(
+			return ParsedName{}, categorizedf(
+				ErrUnparsableName,
 				"Expected >= 8 path components for package 'apis' in path: '%s'",
-				string(*dn))
+				string(dn))
 		}
-		groupName := GroupName(split[5])
-		versionString := VersionString(split[6])
-		return &ParsedDefinitionName{
+		return ParsedName{
 			PackageType: APIs,
 			Codebase:    codebase,
-			Group:       &groupName,
-			Version:     &versionString,
+			Group:       GroupName(split[5]),
+			Version:     VersionString(split[6]),
 			Kind:        ObjectKind(split[7]),
-		}
+		}, nil
 	} else if split[4] == "util" {
 		if len(split) < 7 {
-			log.Fatalf(
+			return ParsedName{}, categorizedf(
+				ErrUnparsableName,
 				"Expected >= 7 path components for package 'api' in path: '%s'",
-				string(*dn))
+				string(dn))
 		}
-		versionString := VersionString(split[5])
-		return &ParsedDefinitionName{
+		return ParsedName{
 			PackageType: Util,
 			Codebase:    codebase,
-			Group:       nil,
-			Version:     &versionString,
+			Version:     VersionString(split[5]),
 			Kind:        ObjectKind(split[6]),
-		}
+		}, nil
 	} else if split[4] == "runtime" {
 		// Name is something like: `io.k8s.apimachinery.pkg.runtime.RawExtension`.
-		return &ParsedDefinitionName{
+		return ParsedName{
 			PackageType: Runtime,
 			Codebase:    codebase,
-			Group:       nil,
-			Version:     nil,
 			Kind:        ObjectKind(split[5]),
-		}
+		}, nil
 	} else if split[4] == "version" {
 		// Name is something like: `io.k8s.apimachinery.pkg.version.Info`.
-		return &ParsedDefinitionName{
+		return ParsedName{
 			PackageType: Version,
 			Codebase:    codebase,
-			Group:       nil,
-			Version:     nil,
 			Kind:        ObjectKind(split[5]),
-		}
+		}, nil
 	}
 
-	log.Fatalf("Unknown package name '%s' in path: '%s'", split[4], string(*dn))
-	return nil
+	return ParsedName{}, categorizedf(
+		ErrUnparsableName, "Unknown package name '%s' in path: '%s'", split[4], string(dn))
 }
 
 // Name parses a `DefinitionName` from an `ObjectRef`. `ObjectRef`s
 // that refer to a definition contain two parts: (1) a special prefix,
 // and (2) a `DefinitionName`, so this function simply strips the
-// prefix off.
+// prefix off. It panics with the error if the ref is malformed; use
+// `ParseRef` to handle the error instead.
 func (or *ObjectRef) Name() *DefinitionName {
-	defn := "#/definitions/"
-	ref := string(*or)
-	if !strings.HasPrefix(ref, defn) {
-		log.Fatalln(ref)
+	name, err := ParseRef(*or)
+	if err != nil {
+		panic(err)
 	}
-	name := DefinitionName(strings.TrimPrefix(ref, defn))
-	return &name
+	return name
 }
 
+// ParseRef parses a `DefinitionName` from an `ObjectRef`, returning
+// an error if the ref doesn't refer to a definition. Like `ParseName`,
+// it ignores whitespace and a UTF-8 byte order mark around the ref.
+// Results aren't cached; to cache them, use a `Parser`.
+func ParseRef(or ObjectRef) (*DefinitionName, error) {
+	return parseRef(or)
+}
+
+func parseRef(or ObjectRef) (*DefinitionName, error) {
+	ref := TrimName(string(or))
+	if !strings.HasPrefix(ref, definitionRefPrefix) {
+		return nil, categorizedf(
+			ErrUnsupportedSchema, "Expected ref '%s' to begin with '%s'", ref, definitionRefPrefix)
+	}
+	name := DefinitionName(TrimName(strings.TrimPrefix(ref, definitionRefPrefix)))
+	return &name, nil
+}
+
+const definitionRefPrefix = "#/definitions/"
+
 func (dn DefinitionName) AsObjectRef() *ObjectRef {
-	or := ObjectRef("#/definitions/" + dn)
+	or := ObjectRef(definitionRefPrefix + dn)
 	return &or
 }
 
@@ -144,11 +383,64 @@ const (
 	Version
 )
 
+const (
+	// apiCodebase is the codebase of definitions in the layout used
+	// since Kubernetes v1.8, e.g., `io.k8s.api.apps.v1beta2.Deployment`.
+	apiCodebase = "api"
+
+	// apiCoreGroup is the name of the core group in that layout, e.g.,
+	// `io.k8s.api.core.v1.Pod`.
+	apiCoreGroup = "core"
+)
+
+// ParsedName is a parsed version of a fully-qualified OpenAPI spec
+// name. For example, `io.k8s.kubernetes.pkg.api.v1.Container` would
+// parse into an instance of the struct below. The parts a name doesn't
+// have are empty (e.g., the `Group` of a core name). It's a plain
+// value, so it can be compared with `==` and used as a map key.
+type ParsedName struct {
+	Prefix      string // Empty for the native `io.k8s` prefix.
+	PackageType Package
+	Codebase    string
+	Group       GroupName     // Empty if the name has no group.
+	Version     VersionString // Empty if the name has no version.
+	Kind        ObjectKind
+}
+
+// HasGroup reports whether the name has an API group, e.g., `apps` in
+// `io.k8s.api.apps.v1.Deployment`. Core names don't.
+func (p ParsedName) HasGroup() bool {
+	return p.Group != ""
+}
+
+// HasVersion reports whether the name has an API version, e.g., `v1`
+// in `io.k8s.api.apps.v1.Deployment`. Runtime and version names don't.
+func (p ParsedName) HasVersion() bool {
+	return p.Version != ""
+}
+
+// QualifiedCodebase is the codebase of the name, preceded by its
+// prefix if it isn't the native one, e.g., `api` for
+// `io.k8s.api.apps.v1.Deployment`, and `com.github.openshift.api` for
+// `com.github.openshift.api.route.v1.Route`, so that it's the same for
+// names of the same codebase, and different for those of others.
+func (p ParsedName) QualifiedCodebase() string {
+	if p.Prefix != "" {
+		return p.Prefix + "." + p.Codebase
+	}
+	return p.Codebase
+}
+
 // ParsedDefinitionName is a parsed version of a fully-qualified
-// OpenAPI spec name. For example,
-// `io.k8s.kubernetes.pkg.api.v1.Container` would parse into an
-// instance of the struct below.
+// OpenAPI spec name, like `ParsedName`, but with pointers for the
+// optional parts.
+//
+// Deprecated: Use `ParsedName`, which can be compared and used as a
+// map key, and whose zero value is safe to use. Convert between the
+// two with `ParsedDefinitionName.ParsedName` and
+// `ParsedName.ParsedDefinitionName`.
 type ParsedDefinitionName struct {
+	Prefix      string // Empty for the native `io.k8s` prefix.
 	PackageType Package
 	Codebase    string
 	Group       *GroupName     // Pointer because it's optional.
@@ -156,6 +448,45 @@ type ParsedDefinitionName struct {
 	Kind        ObjectKind
 }
 
+// ParsedName converts `p` to a `ParsedName`, where a nil `Group` or
+// `Version` is empty.
+func (p *ParsedDefinitionName) ParsedName() ParsedName {
+	parsed := ParsedName{
+		Prefix:      p.Prefix,
+		PackageType: p.PackageType,
+		Codebase:    p.Codebase,
+		Kind:        p.Kind,
+	}
+	if p.Group != nil {
+		parsed.Group = *p.Group
+	}
+	if p.Version != nil {
+		parsed.Version = *p.Version
+	}
+	return parsed
+}
+
+// ParsedDefinitionName converts `p` to a `ParsedDefinitionName`, where
+// an empty `Group` or `Version` is nil. It doesn't share memory with
+// `p`, or with any other result.
+func (p ParsedName) ParsedDefinitionName() *ParsedDefinitionName {
+	parsed := &ParsedDefinitionName{
+		Prefix:      p.Prefix,
+		PackageType: p.PackageType,
+		Codebase:    p.Codebase,
+		Kind:        p.Kind,
+	}
+	if p.HasGroup() {
+		group := p.Group
+		parsed.Group = &group
+	}
+	if p.HasVersion() {
+		version := p.Version
+		parsed.Version = &version
+	}
+	return parsed
+}
+
 // GroupName represetents a Kubernetes group name (e.g., apps,
 // extensions, etc.)
 type GroupName string
@@ -180,56 +511,108 @@ func (vs VersionString) String() string {
 	return string(vs)
 }
 
+// Unparse transforms a `ParsedName` back into its corresponding
+// string, e.g., `io.k8s.kubernetes.pkg.api.v1.Container`. It panics
+// with the error if the name has an unknown `PackageType`; use
+// `UnparseDefinitionName` to handle the error instead.
+func (p ParsedName) Unparse() DefinitionName {
+	name, err := p.unparseName()
+	if err != nil {
+		panic(err)
+	}
+	return name
+}
+
 // Unparse transforms a `ParsedDefinitionName` back into its
-// corresponding string, e.g.,
-// `io.k8s.kubernetes.pkg.api.v1.Container`.
+// corresponding string, like `ParsedName.Unparse`.
 func (p *ParsedDefinitionName) Unparse() DefinitionName {
+	return p.ParsedName().Unparse()
+}
+
+// UnparseDefinitionName transforms a `ParsedDefinitionName` back into
+// its corresponding string, like `ParsedName.Unparse`, returning an
+// error if the name has an unknown `PackageType`.
+func UnparseDefinitionName(p *ParsedDefinitionName) (DefinitionName, error) {
+	return p.ParsedName().unparseName()
+}
+
+// MustUnparse is `UnparseDefinitionName`, panicking with the error,
+// like `MustParseDefinitionName`.
+func MustUnparse(p *ParsedDefinitionName) DefinitionName {
+	name, err := UnparseDefinitionName(p)
+	if err != nil {
+		panic(err)
+	}
+	return name
+}
+
+// `unparseName` is `Unparse`, with `Prefix` put back.
+func (p ParsedName) unparseName() (DefinitionName, error) {
+	name, err := p.unparse()
+	if err != nil {
+		return "", err
+	}
+	if p.Prefix != "" {
+		name = DefinitionName(p.Prefix + strings.TrimPrefix(string(name), NativePrefix))
+	}
+	return name, nil
+}
+
+func (p ParsedName) unparse() (DefinitionName, error) {
+	if p.Codebase == apiCodebase {
+		group := GroupName(apiCoreGroup)
+		if p.HasGroup() {
+			group = p.Group
+		}
+		return DefinitionName(fmt.Sprintf(
+			"io.k8s.%s.%s.%s.%s", p.Codebase, group, p.Version, p.Kind)), nil
+	}
+
 	switch p.PackageType {
 	case Core:
 		{
 			return DefinitionName(fmt.Sprintf(
 				"io.k8s.%s.pkg.api.%s.%s",
 				p.Codebase,
-				*p.Version,
-				p.Kind))
+				p.Version,
+				p.Kind)), nil
 		}
 	case Util:
 		{
 			return DefinitionName(fmt.Sprintf(
 				"io.k8s.%s.pkg.util.%s.%s",
 				p.Codebase,
-				*p.Version,
-				p.Kind))
+				p.Version,
+				p.Kind)), nil
 		}
 	case APIs:
 		{
 			return DefinitionName(fmt.Sprintf(
 				"io.k8s.%s.pkg.apis.%s.%s.%s",
 				p.Codebase,
-				*p.Group,
-				*p.Version,
-				p.Kind))
+				p.Group,
+				p.Version,
+				p.Kind)), nil
 		}
 	case Version:
 		{
 			return DefinitionName(fmt.Sprintf(
 				"io.k8s.%s.pkg.version.%s",
 				p.Codebase,
-				p.Kind))
+				p.Kind)), nil
 		}
 	case Runtime:
 		{
 			return DefinitionName(fmt.Sprintf(
 				"io.k8s.%s.pkg.runtime.%s",
 				p.Codebase,
-				p.Kind))
+				p.Kind)), nil
 		}
 	default:
 		{
-			log.Fatalf(
-				"Failed to unparse definition name, did not recognize kind '%d'",
+			return "", categorizedf(
+				ErrUnparsableName, "Failed to unparse definition name, did not recognize package type '%d'",
 				p.PackageType)
-			return ""
 		}
 	}
 }
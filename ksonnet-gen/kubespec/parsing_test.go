@@ -1,6 +1,9 @@
 package kubespec
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -198,6 +201,11 @@ var namespaces = []string{
 	"io.k8s.kubernetes.pkg.api.v1.ReplicationControllerList",
 	"io.k8s.kubernetes.pkg.apis.batch.v2alpha1.CronJobSpec",
 	"io.k8s.kubernetes.pkg.api.v1.EnvVar",
+
+	// Kubernetes v1.8+ layout.
+	"io.k8s.api.core.v1.Pod",
+	"io.k8s.api.apps.v1.Deployment",
+	"io.k8s.api.batch.v1beta1.CronJob",
 	"io.k8s.kubernetes.pkg.api.v1.PodStatus",
 	"io.k8s.kubernetes.pkg.apis.extensions.v1beta1.ReplicaSetList",
 	"io.k8s.kubernetes.pkg.apis.rbac.v1alpha1.RoleBinding",
@@ -349,3 +357,289 @@ func TestNamespaceParser(t *testing.T) {
 		}
 	}
 }
+
+var parsedNames = map[string]ParsedDefinitionName{
+	"io.k8s.kubernetes.pkg.api.v1.Pod": {
+		PackageType: Core, Codebase: "kubernetes", Kind: "Pod",
+	},
+	"io.k8s.api.core.v1.Pod": {
+		PackageType: Core, Codebase: "api", Kind: "Pod",
+	},
+	"io.k8s.api.apps.v1.Deployment": {
+		PackageType: APIs, Codebase: "api", Kind: "Deployment",
+	},
+}
+
+func TestParseDefinitionName(t *testing.T) {
+	for name, expected := range parsedNames {
+		parsed, err := ParseDefinitionName(DefinitionName(name))
+		if err != nil {
+			t.Errorf("Failed to parse '%s':\n%v", name, err)
+			continue
+		}
+		if parsed.PackageType != expected.PackageType ||
+			parsed.Codebase != expected.Codebase ||
+			parsed.Kind != expected.Kind {
+			t.Errorf("Expected '%s' to parse to %#v got %#v", name, expected, parsed)
+		}
+		if (parsed.PackageType == Core) != (parsed.Group == nil) {
+			t.Errorf("Expected only core names to have no group, got %#v", parsed)
+		}
+	}
+
+	for _, name := range []string{"io.k8s.api.core.v1", "com.example.v1.Foo.Bar"} {
+		if _, err := ParseDefinitionName(DefinitionName(name)); err == nil {
+			t.Errorf("Expected parsing '%s' to fail", name)
+		}
+	}
+}
+
+func TestMustParseDefinitionName(t *testing.T) {
+	dn := DefinitionName("io.k8s.api.apps.v1.Deployment")
+	parsed := MustParseDefinitionName(dn)
+	if parsed.Kind != "Deployment" {
+		t.Errorf("Expected '%s' to parse to the kind 'Deployment', got %#v", dn, parsed)
+	}
+	if unparsed := MustUnparse(parsed); unparsed != dn {
+		t.Errorf("Expected '%s' got '%s'", dn, unparsed)
+	}
+
+	// Errors are panicked with, so that callers can recover them.
+	expectPanic := func(name string, f func(), expected string) {
+		defer func() {
+			err, ok := recover().(error)
+			if !ok || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected %s to panic with an error containing '%s', got %v", name, expected, err)
+			}
+		}()
+		f()
+	}
+	expectPanic("MustParseDefinitionName", func() {
+		MustParseDefinitionName("io.k8s.api.core.v1")
+	}, "io.k8s.api.core.v1")
+	unknown := &ParsedDefinitionName{PackageType: Package(99), Codebase: "kubernetes", Kind: "Foo"}
+	expectPanic("MustUnparse", func() { MustUnparse(unknown) }, "did not recognize package type '99'")
+	if _, err := UnparseDefinitionName(unknown); err == nil {
+		t.Errorf("Expected unparsing an unknown package type to fail")
+	}
+}
+
+func TestParsedNameRoundTrip(t *testing.T) {
+	names := append([]string{
+		"io.k8s.api.core.v1.Pod",
+		"io.k8s.api.apps.v1.Deployment",
+		"io.k8s.apimachinery.pkg.runtime.RawExtension",
+		"io.k8s.apimachinery.pkg.version.Info",
+		"io.k8s.apimachinery.pkg.util.intstr.IntOrString",
+	}, namespaces...)
+
+	for _, name := range names {
+		dn := DefinitionName(name)
+		parsed, err := ParseName(dn)
+		if err != nil {
+			t.Errorf("Failed to parse '%s':\n%v", name, err)
+			continue
+		}
+		legacy, err := ParseDefinitionName(dn)
+		if err != nil {
+			t.Errorf("Failed to parse '%s':\n%v", name, err)
+			continue
+		}
+
+		// Both forms unparse to the original name, and convert to each
+		// other without loss.
+		if unparsed := parsed.Unparse(); unparsed != dn {
+			t.Errorf("Expected '%s' got '%s'", name, unparsed)
+		}
+		if unparsed := legacy.Unparse(); unparsed != dn {
+			t.Errorf("Expected '%s' got '%s'", name, unparsed)
+		}
+		if converted := legacy.ParsedName(); converted != parsed {
+			t.Errorf("Expected %#v to convert to %#v, got %#v", legacy, parsed, converted)
+		}
+		if converted := parsed.ParsedDefinitionName(); !reflect.DeepEqual(converted, legacy) {
+			t.Errorf("Expected %#v to convert to %#v, got %#v", parsed, legacy, converted)
+		}
+		if converted := legacy.ParsedName().ParsedDefinitionName(); !reflect.DeepEqual(converted, legacy) {
+			t.Errorf("Expected %#v to convert back unchanged, got %#v", legacy, converted)
+		}
+
+		// Absent parts are empty in one form and nil in the other.
+		if parsed.HasGroup() != (legacy.Group != nil) || parsed.HasVersion() != (legacy.Version != nil) {
+			t.Errorf("Expected %#v and %#v to have the same parts", parsed, legacy)
+		}
+	}
+
+	rawExtension := DefinitionName("io.k8s.apimachinery.pkg.runtime.RawExtension")
+	if runtime := rawExtension.ParseName(); runtime.HasGroup() || runtime.HasVersion() {
+		t.Errorf("Expected runtime name to have no group or version, got %#v", runtime)
+	}
+
+	// Converted names don't share their parts.
+	deployment := DefinitionName("io.k8s.api.apps.v1.Deployment")
+	parsed := deployment.ParseName()
+	legacy := parsed.ParsedDefinitionName()
+	*legacy.Group = "extensions"
+	if again := parsed.ParsedDefinitionName(); *again.Group != "apps" {
+		t.Errorf("Expected conversions not to share memory, got group '%s'", *again.Group)
+	}
+
+	// Parsed names are comparable, so they can be map keys.
+	seen := map[ParsedName]bool{parsed: true}
+	if !seen[deployment.ParseName()] {
+		t.Errorf("Expected equal names to be the same map key")
+	}
+	if other, _ := ParseName("io.k8s.api.apps.v1beta1.Deployment"); seen[other] {
+		t.Errorf("Expected names of different versions to be different map keys")
+	}
+}
+
+func TestParseDefinitionNamePrefixes(t *testing.T) {
+	const route = "com.github.openshift.api.route.v1.Route"
+	if _, err := ParseDefinitionName(route); err == nil {
+		t.Errorf("Expected '%s' to fail to parse by default", route)
+	}
+
+	p := &Parser{Prefixes: []string{NativePrefix, "com.github.openshift"}}
+	parsed, err := p.Parse(route)
+	if err != nil {
+		t.Fatalf("Failed to parse '%s':\n%v", route, err)
+	}
+	if parsed.Prefix != "com.github.openshift" || parsed.Codebase != "api" ||
+		parsed.Group == nil || *parsed.Group != "route" ||
+		parsed.Version == nil || *parsed.Version != "v1" || parsed.Kind != "Route" {
+		t.Errorf("Unexpected parse of '%s': %#v", route, parsed)
+	}
+	if unparsed := parsed.Unparse(); unparsed != route {
+		t.Errorf("Expected '%s' got '%s'", route, unparsed)
+	}
+
+	// Native names are unchanged.
+	for _, namespace := range namespaces {
+		dn := DefinitionName(namespace)
+		parsed, err := p.Parse(dn)
+		if err != nil || parsed.Prefix != "" || parsed.Unparse() != dn {
+			t.Errorf("Expected '%s' to parse as a native name, got %#v", namespace, parsed)
+		}
+	}
+
+	// The longest matching prefix wins.
+	p = &Parser{Prefixes: []string{"com.github", "com.github.openshift"}}
+	if parsed, err := p.Parse(route); err != nil || parsed.Prefix != "com.github.openshift" {
+		t.Errorf("Expected the longest prefix to match '%s', got %#v and %v", route, parsed, err)
+	}
+}
+
+var malformedNames = []struct {
+	name     DefinitionName
+	position int
+	segment  string
+	offset   int
+}{
+	{"io.k8s.api..v1.Foo", 4, "", 11},
+	{"io.k8s.api.apps.v1.", 6, "", 19},
+	{"io.k8s..api.apps.v1.Foo", 3, "", 7},
+	{"io.k8s.api.apps.v1 .Foo", 5, "v1 ", 18},
+	{"io.k8s.api.apps.v1.Foo\tBar", 6, "Foo\tBar", 22},
+	{"io.k8s.api.apps.v1.Foo Bar ", 6, "Foo Bar", 22},
+	{" io.k8s.api.apps.v1.Foo Bar", 6, "Foo Bar", 23},
+	{"\ufeffio.k8s.api.apps.v1.Foo Bar", 6, "Foo Bar", 25},
+	{"io.k8s.api.apps.v1.Foo\ufeffBar", 6, "Foo\ufeffBar", 22},
+	{"io .k8s.api.apps.v1.Foo", 1, "io ", 2},
+	{"io.k8s.api.apps.v1.Foo-Bar", 6, "Foo-Bar", 19},
+	{" io.k8s.api.apps.v1.1Foo", 6, "1Foo", 20},
+	{"io.k8s.kubernetes.pkg.apis.batch.v1.Job$", 8, "Job$", 36},
+	{"com.github.openshift.api.route.v1.Route!", 7, "Route!", 34},
+}
+
+func TestParseMalformedNames(t *testing.T) {
+	p := &Parser{Prefixes: []string{NativePrefix, "com.github.openshift"}}
+	for _, test := range malformedNames {
+		_, err := p.ParseName(test.name)
+		malformed, ok := err.(*MalformedNameError)
+		if !ok {
+			t.Errorf("Expected '%s' to be malformed, got %v", test.name, err)
+			continue
+		}
+		if malformed.Name != test.name || malformed.Position != test.position || malformed.Segment != test.segment || malformed.Offset != test.offset {
+			t.Errorf("Expected '%s' to be malformed at segment %d ('%s'), byte %d, got %#v",
+				test.name, test.position, test.segment, test.offset, malformed)
+		}
+		if !strings.Contains(err.Error(), fmt.Sprintf("at byte %d: segment %d", test.offset, test.position)) {
+			t.Errorf("Expected error to name the byte and the segment's position, got '%v'", err)
+		}
+	}
+}
+
+// Whitespace and a byte order mark around a name or ref are ignored.
+func TestParseTrimmedNames(t *testing.T) {
+	const name = "io.k8s.api.apps.v1.Deployment"
+	expected, err := ParseName(name)
+	if err != nil {
+		t.Fatalf("Failed to parse '%s':\n%v", name, err)
+	}
+	for _, padded := range []string{name + " ", "\t" + name + "\r\n", "\ufeff" + name, " \ufeff" + name + "\n"} {
+		parsed, err := ParseName(DefinitionName(padded))
+		if err != nil {
+			t.Errorf("Failed to parse %q:\n%v", padded, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, expected) || parsed.Unparse() != name {
+			t.Errorf("Expected %q to parse as '%s', got %#v", padded, name, parsed)
+		}
+		if parsed, err := ParseDefinitionName(DefinitionName(padded)); err != nil || parsed.Kind != "Deployment" {
+			t.Errorf("Expected %q to parse to the kind 'Deployment', got %#v and %v", padded, parsed, err)
+		}
+	}
+
+	for _, ref := range []ObjectRef{" #/definitions/" + name, "\ufeff#/definitions/" + name + "\n", "#/definitions/ " + name} {
+		if parsed, err := ParseRef(ref); err != nil || *parsed != name {
+			t.Errorf("Expected %q to refer to '%s', got %v and %v", ref, name, parsed, err)
+		}
+	}
+
+	if TrimName(" \ufeffio.k8s\t") != "io.k8s" {
+		t.Errorf("Expected TrimName to trim whitespace and the byte order mark")
+	}
+}
+
+// FuzzParseName checks that names either fail to parse, or parse into
+// parts that are non-empty where they're expected, and that make
+// identifiers. Its seeds include the malformed names above.
+func FuzzParseName(f *testing.F) {
+	for _, name := range append(namespaces, "io.k8s.api.core.v1.Pod", "io.k8s.apimachinery.pkg.runtime.RawExtension") {
+		f.Add(name)
+	}
+	for _, test := range malformedNames {
+		f.Add(string(test.name))
+	}
+	f.Add(" io.k8s.api.core.v1.Pod\n")
+	f.Add("\ufeffio.k8s.api.core.v1.Pod")
+	f.Fuzz(func(t *testing.T, name string) {
+		parser := &Parser{Prefixes: []string{"io.k8s", "com.github.openshift"}}
+		parsed, err := parser.ParseName(DefinitionName(name))
+		if malformed, ok := err.(*MalformedNameError); ok && (malformed.Offset < 0 || malformed.Offset > len(name)) {
+			t.Errorf("Expected the offset of the problem with '%s' to be in it, got %d", name, malformed.Offset)
+		}
+		if err != nil {
+			return
+		}
+		if parsed.Codebase == "" || !kindPattern.MatchString(string(parsed.Kind)) {
+			t.Errorf("Expected '%s' to parse into a codebase and a kind, got %#v", name, parsed)
+		}
+		if (parsed.PackageType == APIs) != parsed.HasGroup() {
+			t.Errorf("Expected only names in 'apis' packages to have a group, got %#v", parsed)
+		}
+		for _, segment := range strings.Split(TrimName(name), ".") {
+			if segment == "" || strings.IndexFunc(segment, isSpaceOrBOM) != -1 {
+				t.Errorf("Expected '%s' with segment '%s' to fail to parse", name, segment)
+			}
+		}
+
+		// A name parses the same with whitespace around it.
+		padded, err := parser.ParseName(DefinitionName(" \ufeff" + name + "\t"))
+		if err != nil || !reflect.DeepEqual(padded, parsed) {
+			t.Errorf("Expected '%s' to parse the same with whitespace around it, got %#v and %v", name, padded, err)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package kubespec
+
+import (
+	"sort"
+	"strings"
+)
+
+// Resource is everything the paths section of the spec tells us about
+// the REST resource that serves some group/version/kind: whether it's
+// namespaced, which verbs it supports, and which subresources (e.g.,
+// `status`, `scale`) it has.
+type Resource struct {
+	Scope        Scope
+	Verbs        []string // Sorted, e.g., `create`, `delete`, `get`.
+	Subresources []string // Sorted, e.g., `scale`, `status`.
+
+	// ScaleKind is the kind of the objects the `/scale` subresource
+	// reads and writes, if its operations say, e.g., `autoscaling/v1`
+	// Scale, or, before Kubernetes v1.8, `apps/v1beta1` Scale for
+	// `apps/v1beta1` Deployments.
+	ScaleKind *TopLevelSpec
+}
+
+// HasVerb reports whether the resource supports `verb`.
+func (r *Resource) HasVerb(verb string) bool {
+	return containsString(r.Verbs, verb)
+}
+
+// HasSubresource reports whether the resource has the subresource
+// `name` (e.g., `scale`).
+func (r *Resource) HasSubresource(name string) bool {
+	return containsString(r.Subresources, name)
+}
+
+// Resource returns the REST resource information for some
+// group/version/kind, or nil if the kind doesn't appear in the paths
+// section of the spec (e.g., sub-objects like `PodSpec`).
+//
+// The collection, named, namespaced, cluster-wide, and `watch`
+// variants of a resource's path templates are all merged into a single
+// record.
+func (s *APISpec) Resource(gvk TopLevelSpec) *Resource {
+	return s.allResources()[gvk]
+}
+
+func (s *APISpec) allResources() map[TopLevelSpec]*Resource {
+	s.resourcesOnce.Do(func() {
+		s.resources = s.computeResources()
+	})
+	return s.resources
+}
+
+// verbsByAction maps the `x-kubernetes-action` of an operation to the
+// corresponding Kubernetes API verb.
+var verbsByAction = map[string]string{
+	"get":              "get",
+	"list":             "list",
+	"watch":            "watch",
+	"watchlist":        "watch",
+	"post":             "create",
+	"put":              "update",
+	"patch":            "patch",
+	"delete":           "delete",
+	"deletecollection": "deletecollection",
+}
+
+// resourcePath is the parsed form of a path template like
+// `/apis/apps/v1beta1/namespaces/{namespace}/deployments/{name}/scale`.
+type resourcePath struct {
+	groupVersion string // e.g., `apps/v1beta1`, or `v1` for core.
+	plural       string // e.g., `deployments`.
+	namespaced   bool
+	subresource  string // e.g., `scale`; empty if none.
+}
+
+// key identifies the resource independently of scope and subresource,
+// so that variants of the same resource's path can be merged.
+func (rp *resourcePath) key() string {
+	return rp.groupVersion + "/" + rp.plural
+}
+
+// parseResourcePath parses a path template, returning false if it
+// doesn't name a resource (e.g., `/apis/` or `/version/`).
+func parseResourcePath(path string) (*resourcePath, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	rp := &resourcePath{}
+
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		rp.groupVersion = segments[1]
+		segments = segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		rp.groupVersion = segments[1] + "/" + segments[2]
+		segments = segments[3:]
+	default:
+		return nil, false
+	}
+
+	if segments[0] == "watch" {
+		segments = segments[1:]
+	}
+	if len(segments) >= 3 &&
+		segments[0] == "namespaces" && segments[1] == "{namespace}" {
+		rp.namespaced = true
+		segments = segments[2:]
+	}
+
+	if len(segments) == 0 || isPathParameter(segments[0]) {
+		return nil, false
+	}
+	rp.plural = segments[0]
+
+	if len(segments) >= 3 && isPathParameter(segments[1]) {
+		rp.subresource = segments[2]
+	}
+	return rp, true
+}
+
+func isPathParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func (s *APISpec) computeResources() map[TopLevelSpec]*Resource {
+	resources := make(map[TopLevelSpec]*Resource)
+	kindsByPath := make(map[string]TopLevelSpec)
+	subresources := make(map[string]map[string]bool)
+	scaleKinds := make(map[string]*TopLevelSpec)
+
+	// Sort paths so that the merged records are deterministic.
+	paths := []string{}
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	verbs := make(map[TopLevelSpec]map[string]bool)
+	for _, path := range paths {
+		item := s.Paths[path]
+		rp, ok := parseResourcePath(path)
+		if item == nil || !ok {
+			continue
+		}
+
+		// Operations on a subresource (e.g., `/scale`) usually carry
+		// the kind of the subresource body (e.g., `Scale`), so we
+		// attach them to the parent resource once we know its kind.
+		if rp.subresource != "" {
+			if subresources[rp.key()] == nil {
+				subresources[rp.key()] = make(map[string]bool)
+			}
+			subresources[rp.key()][rp.subresource] = true
+			if rp.subresource == "scale" {
+				for _, op := range item.Operations() {
+					if op.GroupVersionKind != nil {
+						scaleKinds[rp.key()] = op.GroupVersionKind
+					}
+				}
+			}
+			continue
+		}
+
+		for _, op := range item.Operations() {
+			if op.GroupVersionKind == nil {
+				continue
+			}
+			gvk := *op.GroupVersionKind
+			kindsByPath[rp.key()] = gvk
+
+			resource, ok := resources[gvk]
+			if !ok {
+				resource = &Resource{Scope: ScopeCluster}
+				resources[gvk] = resource
+				verbs[gvk] = make(map[string]bool)
+			}
+			if rp.namespaced {
+				resource.Scope = ScopeNamespaced
+			}
+			if verb, ok := verbsByAction[op.Action]; ok {
+				verbs[gvk][verb] = true
+			}
+		}
+	}
+
+	for gvk, resource := range resources {
+		resource.Verbs = sortedKeys(verbs[gvk])
+		resource.Subresources = []string{}
+	}
+	for key, names := range subresources {
+		if gvk, ok := kindsByPath[key]; ok {
+			resources[gvk].Subresources = sortedKeys(names)
+			resources[gvk].ScaleKind = scaleKinds[key]
+		}
+	}
+	return resources
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
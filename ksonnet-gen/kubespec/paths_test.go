@@ -0,0 +1,72 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var resourceSpec = `{
+  "paths": {
+    "/apis/apps/v1beta1/deployments": {
+      "get": {"x-kubernetes-action": "list", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1beta1/namespaces/{namespace}/deployments": {
+      "get": {"x-kubernetes-action": "list", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}},
+      "post": {"x-kubernetes-action": "post", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1beta1/namespaces/{namespace}/deployments/{name}": {
+      "put": {"x-kubernetes-action": "put", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}},
+      "delete": {"x-kubernetes-action": "delete", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1beta1/namespaces/{namespace}/deployments/{name}/scale": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Scale"}}
+    },
+    "/apis/apps/v1beta1/namespaces/{namespace}/deployments/{name}/status": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1beta1/watch/namespaces/{namespace}/deployments/{name}": {
+      "get": {"x-kubernetes-action": "watch", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1beta1", "kind": "Deployment"}}
+    },
+    "/api/v1/nodes/{name}": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Node"}},
+      "patch": {"x-kubernetes-action": "patch", "x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Node"}}
+    },
+    "/api/v1/nodes/{name}/status": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Node"}}
+    },
+    "/version/": {
+      "get": {"operationId": "getCodeVersion"}
+    }
+  }
+}`
+
+var resourceTests = map[TopLevelSpec]*Resource{
+	{Group: "apps", Version: "v1beta1", Kind: "Deployment"}: {
+		Scope:        ScopeNamespaced,
+		Verbs:        []string{"create", "delete", "list", "update", "watch"},
+		Subresources: []string{"scale", "status"},
+		ScaleKind:    &TopLevelSpec{Group: "apps", Version: "v1beta1", Kind: "Scale"},
+	},
+	{Group: "", Version: "v1", Kind: "Node"}: {
+		Scope:        ScopeCluster,
+		Verbs:        []string{"get", "patch"},
+		Subresources: []string{"status"},
+	},
+	{Group: "apps", Version: "v1beta1", Kind: "Scale"}:   nil,
+	{Group: "apps", Version: "v1beta1", Kind: "PodSpec"}: nil,
+}
+
+func TestResource(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(resourceSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	for gvk, expected := range resourceTests {
+		actual := s.Resource(gvk)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Expected resource for '%s' to be %#v, got %#v", gvk.Kind, expected, actual)
+		}
+	}
+}
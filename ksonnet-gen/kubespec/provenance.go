@@ -0,0 +1,195 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// UnmarshalJSON deserializes an `APISpec`. Its `info` and
+// `securityDefinitions` are only metadata, so they're deserialized
+// leniently: the parts that are malformed (e.g., an `info.version`
+// that isn't a string, or a security scheme that isn't an object) are
+// left out, and described in `MetadataProblems`, rather than failing
+// the whole load. `Info` is never nil, even if the spec has no `info`.
+func (s *APISpec) UnmarshalJSON(data []byte) error {
+	type apiSpec APISpec
+	fields := struct {
+		*apiSpec
+		Info                json.RawMessage `json:"info"`
+		SecurityDefinitions json.RawMessage `json:"securityDefinitions"`
+	}{apiSpec: (*apiSpec)(s)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	d := &metadataDecoder{}
+	s.Info = d.info(fields.Info)
+	s.SecurityDefinitions = d.securityDefinitions(fields.SecurityDefinitions)
+	s.MetadataProblems = d.problems
+	return nil
+}
+
+// `metadataDecoder` decodes the metadata of a spec, keeping the parts
+// that are well-formed, and recording a problem for each that isn't.
+type metadataDecoder struct {
+	problems []string
+}
+
+func (d *metadataDecoder) problem(format string, args ...interface{}) {
+	d.problems = append(d.problems, fmt.Sprintf(format, args...))
+}
+
+// `object` returns the fields of the JSON object `data`, the value at
+// `path`, or nil if it's absent or `null`, or isn't an object.
+func (d *metadataDecoder) object(path string, data json.RawMessage) map[string]json.RawMessage {
+	if isAbsent(data) {
+		return nil
+	}
+	fields, err := unmarshalFields(data)
+	if err != nil {
+		d.problem("'%s' isn't an object", path)
+		return nil
+	}
+	return fields
+}
+
+// `string` returns the JSON string `data`, the value at `path`, or the
+// empty string if it's absent or `null`, or isn't a string.
+func (d *metadataDecoder) string(path string, data json.RawMessage) string {
+	if isAbsent(data) {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		d.problem("'%s' isn't a string", path)
+		return ""
+	}
+	return s
+}
+
+func isAbsent(data json.RawMessage) bool {
+	return len(data) == 0 || bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func (d *metadataDecoder) info(data json.RawMessage) *SchemaInfo {
+	fields := d.object("info", data)
+	info := &SchemaInfo{
+		Title:   d.string("info.title", fields["title"]),
+		Version: d.string("info.version", fields["version"]),
+	}
+	if contact := d.object("info.contact", fields["contact"]); contact != nil {
+		info.Contact = &SchemaContact{
+			Name:  d.string("info.contact.name", contact["name"]),
+			URL:   d.string("info.contact.url", contact["url"]),
+			Email: d.string("info.contact.email", contact["email"]),
+		}
+	}
+	return info
+}
+
+func (d *metadataDecoder) securityDefinitions(data json.RawMessage) SecurityDefinitions {
+	fields := d.object("securityDefinitions", data)
+	if fields == nil {
+		return nil
+	}
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := SecurityDefinitions{}
+	for _, name := range names {
+		path := "securityDefinitions." + name
+		if _, err := unmarshalFields(fields[name]); err != nil || isAbsent(fields[name]) {
+			d.problem("'%s' isn't an object", path)
+			continue
+		}
+		scheme := &SecurityScheme{}
+		if err := json.Unmarshal(fields[name], scheme); err != nil {
+			d.problem("'%s' is malformed: %v", path, err)
+			continue
+		}
+		definitions[name] = scheme
+	}
+	return definitions
+}
+
+// Provenance is what a spec says about where it's from: the API it
+// describes, who to contact about it, and the auth modes it accepts,
+// e.g., for recording which cluster, and which auth mode, a library
+// was generated from.
+type Provenance struct {
+	Title     string         `json:"title,omitempty"`
+	Version   string         `json:"version,omitempty"`
+	Contact   *SchemaContact `json:"contact,omitempty"`
+	AuthModes []*AuthMode    `json:"authModes,omitempty"`
+}
+
+// AuthMode is how a client of an API authenticates, from one of its
+// `SecurityDefinitions`: the scheme's name, its type, and, for an
+// `apiKey`, the header or query parameter it's passed in, or, for
+// `oauth2`, its flow. The scheme's description and OAuth URLs, which
+// may name internal hosts, are left out.
+type AuthMode struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	In        string `json:"in,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Flow      string `json:"flow,omitempty"`
+}
+
+// String describes the auth mode, e.g.,
+// `BearerToken (apiKey in header 'authorization')`.
+func (am *AuthMode) String() string {
+	switch {
+	case am.In != "" || am.Parameter != "":
+		return fmt.Sprintf("%s (%s in %s '%s')", am.Name, am.Type, am.In, am.Parameter)
+	case am.Flow != "":
+		return fmt.Sprintf("%s (%s with flow '%s')", am.Name, am.Type, am.Flow)
+	case am.Type != "":
+		return fmt.Sprintf("%s (%s)", am.Name, am.Type)
+	}
+	return am.Name
+}
+
+// Contact returns who to contact about the API, from `info.contact`,
+// or nil if the spec doesn't say.
+func (s *APISpec) Contact() *SchemaContact {
+	if s.Info == nil {
+		return nil
+	}
+	return s.Info.Contact
+}
+
+// AuthModes returns the auth modes of the spec's
+// `SecurityDefinitions`, in order of name.
+func (s *APISpec) AuthModes() []*AuthMode {
+	modes := []*AuthMode{}
+	for name, scheme := range s.SecurityDefinitions {
+		modes = append(modes, &AuthMode{
+			Name:      name,
+			Type:      scheme.Type,
+			In:        scheme.In,
+			Parameter: scheme.Name,
+			Flow:      scheme.Flow,
+		})
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i].Name < modes[j].Name })
+	return modes
+}
+
+// Provenance returns what the spec says about where it's from; see
+// `Provenance`.
+func (s *APISpec) Provenance() *Provenance {
+	p := &Provenance{Contact: s.Contact(), AuthModes: s.AuthModes()}
+	if s.Info != nil {
+		p.Title, p.Version = s.Info.Title, s.Info.Version
+	}
+	if len(p.AuthModes) == 0 {
+		p.AuthModes = nil
+	}
+	return p
+}
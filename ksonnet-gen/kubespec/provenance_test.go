@@ -0,0 +1,133 @@
+package kubespec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func unmarshalMetadata(t *testing.T, metadata string) *APISpec {
+	s, err := UnmarshalSpec("swagger.json", []byte(`{"swagger": "2.0", "definitions": {}`+metadata+`}`))
+	if err != nil {
+		t.Fatalf("Failed to unmarshal spec with %s:\n%v", metadata, err)
+	}
+	return s
+}
+
+func TestProvenance(t *testing.T) {
+	full := unmarshalMetadata(t, `,
+	  "info": {
+	    "title": "Kubernetes",
+	    "version": "v1.9.0",
+	    "contact": {"name": "Platform team", "url": "https://example.com", "email": "platform@example.com"}
+	  },
+	  "securityDefinitions": {
+	    "SSO": {"type": "oauth2", "flow": "accessCode", "authorizationUrl": "https://sso/authorize", "scopes": {"admin": "Administer."}},
+	    "BearerToken": {"type": "apiKey", "name": "authorization", "in": "header", "description": "Bearer Token authentication"},
+	    "Basic": {"type": "basic"}
+	  }`)
+	if len(full.MetadataProblems) != 0 {
+		t.Errorf("Expected no problems, got %v", full.MetadataProblems)
+	}
+	if scheme := full.SecurityDefinitions["SSO"]; scheme == nil ||
+		scheme.AuthorizationURL != "https://sso/authorize" || scheme.Scopes["admin"] != "Administer." {
+		t.Errorf("Expected the whole security scheme to be kept, got %#v", scheme)
+	}
+	expected := &Provenance{
+		Title:   "Kubernetes",
+		Version: "v1.9.0",
+		Contact: &SchemaContact{Name: "Platform team", URL: "https://example.com", Email: "platform@example.com"},
+		AuthModes: []*AuthMode{
+			{Name: "Basic", Type: "basic"},
+			{Name: "BearerToken", Type: "apiKey", In: "header", Parameter: "authorization"},
+			{Name: "SSO", Type: "oauth2", Flow: "accessCode"},
+		},
+	}
+	if p := full.Provenance(); !reflect.DeepEqual(p, expected) {
+		t.Errorf("Expected the provenance %#v, got %#v", expected, p)
+	}
+	modes := []string{}
+	for _, mode := range full.AuthModes() {
+		modes = append(modes, mode.String())
+	}
+	if text := strings.Join(modes, ", "); text != "Basic (basic), BearerToken (apiKey in header 'authorization'), SSO (oauth2 with flow 'accessCode')" {
+		t.Errorf("Unexpected auth modes: %s", text)
+	}
+
+	// Filtering keeps the metadata.
+	if filtered := full.Exclude(func(DefinitionName, *SchemaDefinition) bool { return true }); !reflect.DeepEqual(filtered.Provenance(), expected) {
+		t.Errorf("Expected a filtered spec to keep its provenance, got %#v", filtered.Provenance())
+	}
+}
+
+func TestProvenanceMissing(t *testing.T) {
+	s := unmarshalMetadata(t, "")
+	if s.Info == nil || *s.Info != (SchemaInfo{}) || s.SecurityDefinitions != nil || len(s.MetadataProblems) != 0 {
+		t.Errorf("Expected a spec without metadata to have an empty info, got %#v, %#v, %v", s.Info, s.SecurityDefinitions, s.MetadataProblems)
+	}
+	if p := s.Provenance(); !reflect.DeepEqual(p, &Provenance{}) {
+		t.Errorf("Expected an empty provenance, got %#v", p)
+	}
+
+	s = unmarshalMetadata(t, `, "info": null, "securityDefinitions": null`)
+	if s.Info == nil || len(s.MetadataProblems) != 0 {
+		t.Errorf("Expected null metadata to be treated as missing, got %#v, %v", s.Info, s.MetadataProblems)
+	}
+	if (&APISpec{}).Provenance() == nil || (&APISpec{}).Contact() != nil {
+		t.Errorf("Expected a spec with a nil info to have an empty provenance")
+	}
+}
+
+func TestProvenancePartial(t *testing.T) {
+	s := unmarshalMetadata(t, `, "info": {"version": "v1.9.0"}, "securityDefinitions": {"BearerToken": {"type": "apiKey"}}`)
+	expected := &Provenance{Version: "v1.9.0", AuthModes: []*AuthMode{{Name: "BearerToken", Type: "apiKey"}}}
+	if p := s.Provenance(); !reflect.DeepEqual(p, expected) || len(s.MetadataProblems) != 0 {
+		t.Errorf("Expected the provenance %#v, got %#v, with problems %v", expected, p, s.MetadataProblems)
+	}
+}
+
+func TestProvenanceMalformed(t *testing.T) {
+	s := unmarshalMetadata(t, `,
+	  "info": {"title": 7, "version": "v1.9.0", "contact": {"name": ["x"], "email": "a@example.com"}},
+	  "securityDefinitions": {
+	    "Broken": "apiKey",
+	    "Null": null,
+	    "Scopes": {"type": "oauth2", "scopes": ["admin"]},
+	    "BearerToken": {"type": "apiKey", "name": "authorization", "in": "header"}
+	  }`)
+	expected := &Provenance{
+		Version:   "v1.9.0",
+		Contact:   &SchemaContact{Email: "a@example.com"},
+		AuthModes: []*AuthMode{{Name: "BearerToken", Type: "apiKey", In: "header", Parameter: "authorization"}},
+	}
+	if p := s.Provenance(); !reflect.DeepEqual(p, expected) {
+		t.Errorf("Expected the well-formed parts to be kept, %#v, got %#v", expected, p)
+	}
+	problems := []string{
+		"'info.title' isn't a string",
+		"'info.contact.name' isn't a string",
+		"'securityDefinitions.Broken' isn't an object",
+		"'securityDefinitions.Null' isn't an object",
+		"'securityDefinitions.Scopes' is malformed: ",
+	}
+	if len(s.MetadataProblems) != len(problems) {
+		t.Fatalf("Expected the problems %v, got %v", problems, s.MetadataProblems)
+	}
+	for i, problem := range problems {
+		if !strings.HasPrefix(s.MetadataProblems[i], problem) {
+			t.Errorf("Expected problem %d to be '%s', got '%s'", i, problem, s.MetadataProblems[i])
+		}
+	}
+
+	// Blocks that aren't objects at all are left out too.
+	s = unmarshalMetadata(t, `, "info": "Kubernetes v1.9.0", "securityDefinitions": []`)
+	if !reflect.DeepEqual(s.MetadataProblems, []string{"'info' isn't an object", "'securityDefinitions' isn't an object"}) ||
+		s.Info == nil || s.SecurityDefinitions != nil {
+		t.Errorf("Expected malformed blocks to be left out, got %#v, %#v, %v", s.Info, s.SecurityDefinitions, s.MetadataProblems)
+	}
+
+	// The rest of the spec must still be well-formed.
+	if _, err := UnmarshalSpec("swagger.json", []byte(`{"info": {}, "definitions": []}`)); err == nil {
+		t.Errorf("Expected malformed definitions to fail the load")
+	}
+}
@@ -0,0 +1,56 @@
+package kubespec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// gzipMagic is how a gzipped stream starts (RFC 1952).
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ReadSpec reads and deserializes the spec in `r`, e.g., a file or
+// stdin. A stream that starts with gzip's magic bytes is decompressed
+// first, whatever it's called. `name` is where the spec came from, as
+// for `UnmarshalSpec`; a `.gz` extension is left out when it's used to
+// decide the format, so that, e.g., `swagger.yaml.gz` is YAML. The
+// spec's `FilePath` is left for the caller to set. Errors are of
+// `ErrSpecLoad`.
+func ReadSpec(name string, r io.Reader) (_ *APISpec, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	digest := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, digest))
+	r = br
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decompress spec from '%s':\n%v", name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read spec from '%s':\n%v", name, err)
+	}
+	if len(bytes.TrimSpace(text)) == 0 {
+		return nil, fmt.Errorf("Could not read spec from '%s': it's empty", name)
+	}
+
+	if strings.HasSuffix(strings.ToLower(name), ".gz") {
+		name = name[:len(name)-len(".gz")]
+	}
+	s, err := UnmarshalSpec(name, text)
+	if err != nil {
+		return nil, fmt.Errorf("Could not deserialize spec from '%s':\n%v", name, err)
+	}
+	s.SourceSHA256 = hex.EncodeToString(digest.Sum(nil))
+	return s, nil
+}
@@ -0,0 +1,72 @@
+package kubespec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestReadSpec(t *testing.T) {
+	gzipped := func(text string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write([]byte(text)); err != nil {
+			t.Fatalf("Failed to gzip spec:\n%v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Failed to gzip spec:\n%v", err)
+		}
+		return buf.Bytes()
+	}
+
+	// Streams, e.g., stdin, are sniffed for gzip and then for JSON or
+	// YAML; a `.gz` extension is looked through.
+	tests := []struct {
+		name string
+		text []byte
+	}{
+		{"stdin", []byte(jsonSpec)},
+		{"stdin", []byte(yamlSpec)},
+		{"stdin", gzipped(jsonSpec)},
+		{"stdin", gzipped(yamlSpec)},
+		{"swagger.yaml.gz", gzipped(yamlSpec)},
+		{"swagger.json.GZ", gzipped(jsonSpec)},
+	}
+	expected, err := UnmarshalSpec("swagger.json", []byte(jsonSpec))
+	if err != nil {
+		t.Fatalf("Failed to unmarshal JSON spec:\n%v", err)
+	}
+	for i, test := range tests {
+		s, err := ReadSpec(test.name, bytes.NewReader(test.text))
+		if err != nil {
+			t.Errorf("%d: Failed to read spec '%s':\n%v", i, test.name, err)
+			continue
+		}
+		if marshalDefinitions(t, s) != marshalDefinitions(t, expected) {
+			t.Errorf("%d: Expected spec '%s' to match the JSON one", i, test.name)
+		}
+		// The digest is of the bytes as they were read, compressed or not.
+		if digest := sha256.Sum256(test.text); s.SourceSHA256 != hex.EncodeToString(digest[:]) {
+			t.Errorf("%d: Expected the digest of the bytes read, got '%s'", i, s.SourceSHA256)
+		}
+	}
+
+	failures := []struct {
+		text     []byte
+		expected string
+	}{
+		{[]byte{}, "Could not read spec from 'stdin': it's empty"},
+		{[]byte(" \n\t"), "Could not read spec from 'stdin': it's empty"},
+		{gzipped(jsonSpec)[:20], "Could not read spec from 'stdin':\nunexpected EOF"},
+		{[]byte("{"), "Could not deserialize spec from 'stdin':\n"},
+	}
+	for _, test := range failures {
+		if _, err := ReadSpec("stdin", bytes.NewReader(test.text)); err == nil ||
+			!strings.HasPrefix(err.Error(), test.expected) {
+			t.Errorf("Expected reading %q to fail with '%s', got %v", test.text, test.expected, err)
+		}
+	}
+}
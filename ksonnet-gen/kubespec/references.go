@@ -0,0 +1,379 @@
+package kubespec
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InboundReferences counts, for every definition, the number of
+// properties in the spec that reference it, directly or through their
+// array items or additional properties. Definitions with no inbound
+// references are omitted.
+func (s *APISpec) InboundReferences() map[DefinitionName]int {
+	if s.model != nil {
+		return s.model.References.InDegrees()
+	}
+	counts := map[DefinitionName]int{}
+	for _, def := range s.Definitions {
+		for _, prop := range def.Properties {
+			for _, ref := range prop.refs() {
+				if refName, err := parseRef(*ref); err == nil {
+					counts[*refName]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// Closure returns every definition reachable from `root` through
+// property references (including array items and additional
+// properties), mapped to the dotted property path by which it's first
+// reached, breadth-first and in property-name order. `root` itself
+// maps to "". Arrays and maps are traversed without any marker in the
+// path, e.g., `spec.containers.lifecycle` for the `Lifecycle` of a
+// `Pod`'s containers.
+func (s *APISpec) Closure(root DefinitionName) map[DefinitionName]string {
+	paths := map[DefinitionName]string{root: ""}
+	queue := []DefinitionName{root}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		def, ok := s.Definitions[name]
+		if !ok {
+			continue
+		}
+
+		for _, propName := range def.Properties.sortedNames() {
+			for _, ref := range def.Properties[propName].refs() {
+				refName, err := parseRef(*ref)
+				if err != nil {
+					continue
+				}
+				if _, seen := paths[*refName]; seen {
+					continue
+				}
+				paths[*refName] = joinPath(paths[name], string(propName))
+				queue = append(queue, *refName)
+			}
+		}
+	}
+	return paths
+}
+
+// DanglingRef is a reference that a property of the definition `From`
+// makes, directly or through its array items or additional
+// properties, to `To`, which isn't in the spec.
+type DanglingRef struct {
+	From     DefinitionName
+	Property PropertyName
+	To       DefinitionName
+}
+
+func (r *DanglingRef) String() string {
+	return fmt.Sprintf("'%s' property '%s' refers to '%s'", r.From, r.Property, r.To)
+}
+
+// DanglingRefs returns every reference in the spec to a definition
+// that isn't in it, sorted by `From`, then `Property`. A
+// spec that `Filter` returns has none, unless the spec it was
+// filtered from did.
+func (s *APISpec) DanglingRefs() []*DanglingRef {
+	names := []DefinitionName{}
+	for name := range s.Definitions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	dangling := []*DanglingRef{}
+	for _, name := range names {
+		def := s.Definitions[name]
+		for _, propName := range def.Properties.sortedNames() {
+			for _, ref := range def.Properties[propName].refs() {
+				refName, err := parseRef(*ref)
+				if err != nil {
+					continue
+				}
+				if _, ok := s.Definitions[*refName]; !ok {
+					dangling = append(dangling, &DanglingRef{From: name, Property: propName, To: *refName})
+				}
+			}
+		}
+	}
+	return dangling
+}
+
+// RepairRefs returns a new spec like `s`, with the definitions of
+// `original` (e.g., the spec `s` was filtered from) that the dangling
+// references of `s` refer to re-included, along with every definition
+// they refer to in turn, and the names of the definitions it added,
+// sorted. References to definitions that aren't in `original` either
+// are left dangling. The receiver isn't modified.
+func (s *APISpec) RepairRefs(original *APISpec) (*APISpec, []DefinitionName) {
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	added := []DefinitionName{}
+	for _, ref := range s.DanglingRefs() {
+		for reachable := range original.Closure(ref.To) {
+			if _, ok := definitions[reachable]; ok {
+				continue
+			}
+			if def, ok := original.Definitions[reachable]; ok {
+				definitions[reachable] = def
+				added = append(added, reachable)
+			}
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	return s.withDefinitions(definitions), added
+}
+
+// refs returns the references a property makes to other definitions,
+// directly or through its array items or additional properties.
+func (p *Property) refs() []*ObjectRef {
+	refs := []*ObjectRef{}
+	if p.Ref != nil {
+		refs = append(refs, p.Ref)
+	}
+	if p.Items.Ref != nil {
+		refs = append(refs, p.Items.Ref)
+	}
+	if ap := p.AdditionalProperties; ap != nil && ap.Schema != nil && ap.Schema.Ref != nil {
+		refs = append(refs, ap.Schema.Ref)
+	}
+	return refs
+}
+
+// Reference is an edge of a `ReferenceGraph`: the property `Property`
+// of the definition `From` refers to the definition `To`, directly or
+// through its array items or additional properties. Cycle is set if
+// the reference is part of a cycle, i.e., `From` is reachable from
+// `To` (e.g., `JSONSchemaProps`, whose properties refer to
+// `JSONSchemaProps`).
+type Reference struct {
+	From     DefinitionName
+	To       DefinitionName
+	Property PropertyName
+	Cycle    bool
+}
+
+// ReferenceGraph is a subgraph of the references between definitions;
+// see `APISpec.ReferenceGraph`.
+type ReferenceGraph struct {
+	Roots      []DefinitionName
+	Nodes      []DefinitionName // Sorted, including the roots.
+	References []*Reference     // Sorted by `From`, then `Property`, then `To`.
+}
+
+// ReferenceGraph walks the references from `roots` breadth-first,
+// following references at most `maxDepth` deep (0 follows them all),
+// and returns the definitions it reaches and the references between
+// them. If `roots` is empty, the walk starts from every definition.
+// Definitions for which `exclude` returns true (if it's non-nil) are
+// left out, along with the references to and from them, and aren't
+// walked through. References to definitions that aren't in the spec
+// are kept, so dangling references are still visible.
+//
+// The graph of every definition of a spec read from a model is the one
+// the model recorded.
+func (s *APISpec) ReferenceGraph(
+	roots []DefinitionName, maxDepth int, exclude func(DefinitionName) bool,
+) *ReferenceGraph {
+	if s.model != nil && len(roots) == 0 && maxDepth == 0 && exclude == nil {
+		return s.model.References.copy()
+	}
+	excluded := func(name DefinitionName) bool {
+		return exclude != nil && exclude(name)
+	}
+	if len(roots) == 0 {
+		for name := range s.Definitions {
+			roots = append(roots, name)
+		}
+	} else {
+		roots = append([]DefinitionName{}, roots...)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+
+	graph := &ReferenceGraph{}
+	depths := map[DefinitionName]int{}
+	queue := []DefinitionName{}
+	for _, root := range roots {
+		if _, seen := depths[root]; seen || excluded(root) {
+			continue
+		}
+		depths[root] = 0
+		graph.Roots = append(graph.Roots, root)
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		def, ok := s.Definitions[name]
+		if !ok || (maxDepth > 0 && depths[name] >= maxDepth) {
+			continue
+		}
+
+		for _, propName := range def.Properties.sortedNames() {
+			for _, ref := range def.Properties[propName].refs() {
+				refName, err := parseRef(*ref)
+				if err != nil || excluded(*refName) {
+					continue
+				}
+				graph.References = append(graph.References, &Reference{
+					From: name, To: *refName, Property: propName,
+				})
+				if _, seen := depths[*refName]; !seen {
+					depths[*refName] = depths[name] + 1
+					queue = append(queue, *refName)
+				}
+			}
+		}
+	}
+
+	for name := range depths {
+		graph.Nodes = append(graph.Nodes, name)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i] < graph.Nodes[j] })
+	sort.SliceStable(graph.References, func(i, j int) bool {
+		a, b := graph.References[i], graph.References[j]
+		switch {
+		case a.From != b.From:
+			return a.From < b.From
+		case a.Property != b.Property:
+			return a.Property < b.Property
+		default:
+			return a.To < b.To
+		}
+	})
+	graph.markCycles()
+	return graph
+}
+
+// markCycles sets `Cycle` on the references that are part of a cycle,
+// i.e., whose ends are in the same strongly connected component of the
+// graph, using Tarjan's algorithm.
+func (g *ReferenceGraph) markCycles() {
+	outbound := map[DefinitionName][]*Reference{}
+	for _, ref := range g.References {
+		outbound[ref.From] = append(outbound[ref.From], ref)
+	}
+
+	index := map[DefinitionName]int{}
+	lowlink := map[DefinitionName]int{}
+	component := map[DefinitionName]int{}
+	onStack := map[DefinitionName]bool{}
+	stack := []DefinitionName{}
+	components := 0
+
+	var connect func(name DefinitionName)
+	connect = func(name DefinitionName) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, ref := range outbound[name] {
+			if _, visited := index[ref.To]; !visited {
+				connect(ref.To)
+				if lowlink[ref.To] < lowlink[name] {
+					lowlink[name] = lowlink[ref.To]
+				}
+			} else if onStack[ref.To] && index[ref.To] < lowlink[name] {
+				lowlink[name] = index[ref.To]
+			}
+		}
+
+		if lowlink[name] == index[name] {
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				component[member] = components
+				if member == name {
+					break
+				}
+			}
+			components++
+		}
+	}
+	for _, name := range g.Nodes {
+		if _, visited := index[name]; !visited {
+			connect(name)
+		}
+	}
+
+	for _, ref := range g.References {
+		ref.Cycle = component[ref.From] == component[ref.To]
+	}
+}
+
+// `copy` returns a copy of the graph, whose references can be changed
+// without changing the graph's.
+func (g *ReferenceGraph) copy() *ReferenceGraph {
+	copied := &ReferenceGraph{}
+	if g.Roots != nil {
+		copied.Roots = append([]DefinitionName{}, g.Roots...)
+	}
+	if g.Nodes != nil {
+		copied.Nodes = append([]DefinitionName{}, g.Nodes...)
+	}
+	for _, ref := range g.References {
+		r := *ref
+		copied.References = append(copied.References, &r)
+	}
+	return copied
+}
+
+// InDegrees counts the references to each definition in the graph, so
+// that, e.g., `ObjectMeta`, which every kind refers to, can be told
+// from definitions that only one property refers to.
+func (g *ReferenceGraph) InDegrees() map[DefinitionName]int {
+	counts := map[DefinitionName]int{}
+	for _, ref := range g.References {
+		counts[ref.To]++
+	}
+	return counts
+}
+
+// TopologicalOrder returns the nodes of the graph so that each comes
+// after the definitions it refers to, e.g., `ObjectMeta` before
+// `Deployment`. References that are part of a cycle are ignored, and
+// definitions that could come in either order are sorted by name, so
+// the order is stable.
+func (g *ReferenceGraph) TopologicalOrder() []DefinitionName {
+	pending := map[DefinitionName]int{}
+	dependents := map[DefinitionName][]DefinitionName{}
+	for _, name := range g.Nodes {
+		pending[name] = 0
+	}
+	for _, ref := range g.References {
+		if _, ok := pending[ref.To]; !ok || ref.Cycle {
+			continue
+		}
+		pending[ref.From]++
+		dependents[ref.To] = append(dependents[ref.To], ref.From)
+	}
+
+	ready := []DefinitionName{}
+	for _, name := range g.Nodes {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+	order := []DefinitionName{}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range dependents[name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,221 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInboundReferences(t *testing.T) {
+	s := loadSearchSpec(t)
+
+	expected := map[DefinitionName]int{
+		"io.k8s.kubernetes.pkg.api.v1.PodSpec":   1,
+		"io.k8s.kubernetes.pkg.api.v1.Container": 1,
+		"io.k8s.kubernetes.pkg.api.v1.Quantity":  1,
+	}
+	if actual := s.InboundReferences(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected inbound references %v got %v", expected, actual)
+	}
+}
+
+func TestClosure(t *testing.T) {
+	s := loadSearchSpec(t)
+
+	closure := s.Closure("io.k8s.kubernetes.pkg.api.v1.Pod")
+	expected := map[DefinitionName]string{
+		"io.k8s.kubernetes.pkg.api.v1.Pod":       "",
+		"io.k8s.kubernetes.pkg.api.v1.PodSpec":   "spec",
+		"io.k8s.kubernetes.pkg.api.v1.Container": "spec.containers",
+		"io.k8s.kubernetes.pkg.api.v1.Quantity":  "spec.containers.resources",
+	}
+	if !reflect.DeepEqual(closure, expected) {
+		t.Errorf("Expected closure %v got %v", expected, closure)
+	}
+
+	if kinds := s.FindKind("Pod"); len(kinds) != 1 || kinds[0] != "io.k8s.kubernetes.pkg.api.v1.Pod" {
+		t.Errorf("Unexpected definitions of kind 'Pod': %v", kinds)
+	}
+	if kinds := s.FindKind("Container"); len(kinds) != 0 {
+		t.Errorf("Expected 'Container' not to be a top-level kind, got %v", kinds)
+	}
+}
+
+var graphSpec = `{
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "properties": {
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "apps", "Version": "v1", "Kind": "Deployment"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "properties": {
+        "schema": {"$ref": "#/definitions/io.k8s.api.apps.v1.Schema"},
+        "selector": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector"}
+      }
+    },
+    "io.k8s.api.apps.v1.Schema": {
+      "properties": {
+        "items": {"$ref": "#/definitions/io.k8s.api.apps.v1.Schema"},
+        "not": {"$ref": "#/definitions/io.k8s.api.apps.v1.Not"},
+        "properties": {"type": "object", "additionalProperties": {"$ref": "#/definitions/io.k8s.api.apps.v1.Schema"}},
+        "missing": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.apps.v1.Missing"}}
+      }
+    },
+    "io.k8s.api.apps.v1.Not": {
+      "properties": {
+        "schema": {"$ref": "#/definitions/io.k8s.api.apps.v1.Schema"}
+      }
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "properties": {
+        "name": {"type": "string"}
+      }
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector": {
+      "properties": {
+        "parent": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}
+      }
+    }
+  }
+}`
+
+// referenceStrings renders a graph's references for comparison, with
+// cycles marked.
+func referenceStrings(graph *ReferenceGraph) []string {
+	refs := []string{}
+	for _, ref := range graph.References {
+		text := fmt.Sprintf("%s -%s-> %s", ref.From.ParseName().Kind, ref.Property, ref.To.ParseName().Kind)
+		if ref.Cycle {
+			text += " (cycle)"
+		}
+		refs = append(refs, text)
+	}
+	return refs
+}
+
+func TestReferenceGraph(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(graphSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	graph := s.ReferenceGraph([]DefinitionName{"io.k8s.api.apps.v1.Deployment"}, 0, nil)
+	expected := []string{
+		"Deployment -metadata-> ObjectMeta",
+		"Deployment -spec-> DeploymentSpec",
+		"DeploymentSpec -schema-> Schema",
+		"DeploymentSpec -selector-> LabelSelector",
+		"Not -schema-> Schema (cycle)",
+		"Schema -items-> Schema (cycle)",
+		// Dangling references are kept.
+		"Schema -missing-> Missing",
+		"Schema -not-> Not (cycle)",
+		"Schema -properties-> Schema (cycle)",
+		"LabelSelector -parent-> ObjectMeta",
+	}
+	if actual := referenceStrings(graph); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected references:\n%v\ngot:\n%v", expected, actual)
+	}
+	if len(graph.Nodes) != 7 || !reflect.DeepEqual(graph.Roots, []DefinitionName{"io.k8s.api.apps.v1.Deployment"}) {
+		t.Errorf("Unexpected nodes %v and roots %v", graph.Nodes, graph.Roots)
+	}
+
+	// References are followed at most `maxDepth` deep, and excluded
+	// definitions are left out entirely.
+	isMeta := func(name DefinitionName) bool {
+		return name.ParseName().Group == "meta"
+	}
+	graph = s.ReferenceGraph([]DefinitionName{"io.k8s.api.apps.v1.Deployment"}, 2, isMeta)
+	expected = []string{
+		"Deployment -spec-> DeploymentSpec",
+		"DeploymentSpec -schema-> Schema",
+	}
+	if actual := referenceStrings(graph); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected references:\n%v\ngot:\n%v", expected, actual)
+	}
+	if len(graph.Nodes) != 3 {
+		t.Errorf("Expected the nodes within depth 2, got %v", graph.Nodes)
+	}
+
+	// With no roots, every definition is one.
+	graph = s.ReferenceGraph(nil, 1, isMeta)
+	if len(graph.Roots) != 4 || len(graph.References) != 7 {
+		t.Errorf("Expected every definition but meta's to be a root, got %v:\n%v", graph.Roots, referenceStrings(graph))
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(graphSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	graph := s.ReferenceGraph([]DefinitionName{"io.k8s.api.apps.v1.Deployment"}, 0, nil)
+	kinds := []string{}
+	for _, name := range graph.TopologicalOrder() {
+		kinds = append(kinds, string(name.ParseName().Kind))
+	}
+	// `Schema` and `Not` refer to each other, so they're ordered by
+	// name.
+	expected := []string{"Missing", "Not", "Schema", "ObjectMeta", "LabelSelector", "DeploymentSpec", "Deployment"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected order %v, got %v", expected, kinds)
+	}
+
+	degrees := graph.InDegrees()
+	if degrees["io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"] != 2 || degrees["io.k8s.api.apps.v1.Deployment"] != 0 {
+		t.Errorf("Unexpected in-degrees %v", degrees)
+	}
+}
+
+func TestDanglingRefs(t *testing.T) {
+	original := APISpec{}
+	if err := json.Unmarshal([]byte(graphSpec), &original); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	missing := []string{"'io.k8s.api.apps.v1.Schema' property 'missing' refers to 'io.k8s.api.apps.v1.Missing'"}
+	if actual := danglingStrings(original.DanglingRefs()); !reflect.DeepEqual(actual, missing) {
+		t.Errorf("Expected dangling references %v, got %v", missing, actual)
+	}
+
+	// Excluding `DeploymentSpec` leaves `Deployment.spec` dangling, and
+	// repairing the spec brings back `DeploymentSpec` and what only it
+	// refers to, but not the definition that was never there.
+	s := original.Exclude(func(name DefinitionName, _ *SchemaDefinition) bool {
+		return name.ParseName().Kind != "Deployment" && name.ParseName().Kind != "ObjectMeta"
+	})
+	expected := []string{"'io.k8s.api.apps.v1.Deployment' property 'spec' refers to 'io.k8s.api.apps.v1.DeploymentSpec'"}
+	if actual := danglingStrings(s.DanglingRefs()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected dangling references %v, got %v", expected, actual)
+	}
+
+	repaired, added := s.RepairRefs(&original)
+	expectedAdded := []DefinitionName{
+		"io.k8s.api.apps.v1.DeploymentSpec",
+		"io.k8s.api.apps.v1.Not",
+		"io.k8s.api.apps.v1.Schema",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.LabelSelector",
+	}
+	if !reflect.DeepEqual(added, expectedAdded) {
+		t.Errorf("Expected repairing to add %v, got %v", expectedAdded, added)
+	}
+	if len(repaired.Definitions) != len(original.Definitions) || len(s.Definitions) != 2 {
+		t.Errorf("Expected the repaired spec to have every definition, and the receiver not to change, got %d and %d",
+			len(repaired.Definitions), len(s.Definitions))
+	}
+	if actual := danglingStrings(repaired.DanglingRefs()); !reflect.DeepEqual(actual, missing) {
+		t.Errorf("Expected the missing definition to stay dangling, got %v", actual)
+	}
+}
+
+func danglingStrings(refs []*DanglingRef) []string {
+	strings := []string{}
+	for _, ref := range refs {
+		strings = append(strings, ref.String())
+	}
+	return strings
+}
@@ -0,0 +1,74 @@
+package kubespec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReplaceProperty returns a copy of the spec in which the property at
+// `path` of the definition `name` is what `replace` returns for it, or
+// is removed if that's nil. `path` is the name of the property, then
+// those of the properties of its inline object (see
+// `Property.Properties`) that lead to a nested one, if any. It's an
+// error if the definition has no such property. The receiver isn't
+// modified.
+func (s *APISpec) ReplaceProperty(
+	name DefinitionName, path []PropertyName, replace func(*Property) *Property,
+) (*APISpec, error) {
+	def, ok := s.Definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("The spec has no definition '%s'", name)
+	}
+	properties, ok := replaceProperty(def.Properties, path, replace)
+	if !ok {
+		names := []string{}
+		for _, propName := range path {
+			names = append(names, string(propName))
+		}
+		return nil, fmt.Errorf(
+			"Definition '%s' has no property '%s'", name, strings.Join(names, "."))
+	}
+
+	definitions := SchemaDefinitions{}
+	for name, def := range s.Definitions {
+		definitions[name] = def
+	}
+	replaced := *def
+	replaced.Properties = properties
+	definitions[name] = &replaced
+	return s.withDefinitions(definitions), nil
+}
+
+// replaceProperty returns a copy of `properties` in which the property
+// at `path` is replaced, like `ReplaceProperty`, reporting false if
+// there's no such property.
+func replaceProperty(
+	properties Properties, path []PropertyName, replace func(*Property) *Property,
+) (Properties, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+	prop, ok := properties[path[0]]
+	if !ok {
+		return nil, false
+	}
+
+	copied := Properties{}
+	for propName, p := range properties {
+		copied[propName] = p
+	}
+	if len(path) > 1 {
+		nested, ok := replaceProperty(prop.Properties, path[1:], replace)
+		if !ok {
+			return nil, false
+		}
+		replaced := *prop
+		replaced.Properties = nested
+		copied[path[0]] = &replaced
+	} else if replaced := replace(prop); replaced != nil {
+		copied[path[0]] = replaced
+	} else {
+		delete(copied, path[0])
+	}
+	return copied, true
+}
@@ -0,0 +1,80 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var replaceSpec = `{
+  "definitions": {
+    "io.k8s.api.core.v1.Secret": {
+      "properties": {
+        "data": {"type": "object", "additionalProperties": {"type": "string", "format": "byte"}},
+        "type": {"type": "string"}
+      }
+    },
+    "io.k8s.api.core.v1.Probe": {
+      "properties": {
+        "httpGet": {
+          "type": "object",
+          "properties": {"port": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}}
+        }
+      }
+    }
+  }
+}`
+
+func TestReplaceProperty(t *testing.T) {
+	var s APISpec
+	if err := json.Unmarshal([]byte(replaceSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	const secret, probe = "io.k8s.api.core.v1.Secret", "io.k8s.api.core.v1.Probe"
+
+	object := SchemaType("object")
+	replaced, err := s.ReplaceProperty(secret, []PropertyName{"data"}, func(p *Property) *Property {
+		return &Property{Description: p.Description, Type: &object}
+	})
+	if err != nil {
+		t.Fatalf("Could not replace 'data':\n%v", err)
+	}
+	if data := replaced.Definitions[secret].Properties["data"]; !data.IsUntyped() {
+		t.Errorf("Expected 'data' to be replaced, got %+v", data)
+	}
+	if replaced.Definitions[secret].Properties["type"] != s.Definitions[secret].Properties["type"] ||
+		replaced.Definitions[probe] != s.Definitions[probe] {
+		t.Errorf("Expected the rest of the spec to be shared")
+	}
+
+	// Properties of inline objects are replaced by their path, and
+	// replacing one with nil removes it.
+	replaced, err = s.ReplaceProperty(probe, []PropertyName{"httpGet", "port"}, func(*Property) *Property {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Could not remove 'httpGet.port':\n%v", err)
+	}
+	if httpGet := replaced.Definitions[probe].Properties["httpGet"]; len(httpGet.Properties) != 0 {
+		t.Errorf("Expected 'httpGet.port' to be removed, got %+v", httpGet.Properties)
+	}
+
+	// The original spec is unchanged.
+	if s.Definitions[secret].Properties["data"].IsUntyped() ||
+		len(s.Definitions[probe].Properties["httpGet"].Properties) != 1 {
+		t.Errorf("Expected the original spec to be unchanged")
+	}
+
+	for _, c := range []struct {
+		name DefinitionName
+		path []PropertyName
+	}{
+		{secret, []PropertyName{"stringData"}},
+		{secret, []PropertyName{"data", "foo"}},
+		{probe, []PropertyName{"httpGet", "host"}},
+		{"io.k8s.api.core.v1.Pod", []PropertyName{"spec"}},
+	} {
+		if _, err := s.ReplaceProperty(c.name, c.path, func(p *Property) *Property { return p }); err == nil {
+			t.Errorf("Expected replacing '%v' of '%s' to fail", c.path, c.name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package kubespec
+
+// Scope represents whether instances of some kind live inside a
+// namespace (e.g., `Pod`) or are global to the cluster (e.g.,
+// `Node`).
+type Scope int
+
+const (
+	// ScopeUnknown is the scope of kinds that don't appear in any
+	// path in the spec, e.g., sub-objects like `PodSpec`.
+	ScopeUnknown Scope = iota
+
+	// ScopeNamespaced is the scope of kinds that are served under
+	// `/namespaces/{namespace}/`.
+	ScopeNamespaced
+
+	// ScopeCluster is the scope of kinds that are served by the API,
+	// but never under a namespace.
+	ScopeCluster
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeNamespaced:
+		return "namespaced"
+	case ScopeCluster:
+		return "cluster"
+	default:
+		return "unknown"
+	}
+}
+
+// Scope classifies a group/version/kind as namespaced,
+// cluster-scoped, or unknown, using the paths section of the spec.
+//
+// A kind is namespaced if any operation on it is served under
+// `/namespaces/{namespace}/`, since namespaced kinds typically also
+// have a cluster-wide `list` endpoint (e.g., `/api/v1/pods`). A kind
+// that appears only in paths with no namespace is cluster-scoped, and
+// a kind that never appears in the paths section is unknown.
+func (s *APISpec) Scope(gvk TopLevelSpec) Scope {
+	if resource := s.Resource(gvk); resource != nil {
+		return resource.Scope
+	}
+	return ScopeUnknown
+}
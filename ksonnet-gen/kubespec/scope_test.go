@@ -0,0 +1,46 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var scopeSpec = `{
+  "paths": {
+    "/api/v1/namespaces/{name}": {
+      "get": {"x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Namespace"}}
+    },
+    "/api/v1/pods": {
+      "get": {"x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Pod"}}
+    },
+    "/api/v1/namespaces/{namespace}/pods/{name}": {
+      "delete": {"x-kubernetes-group-version-kind": {"group": "", "version": "v1", "kind": "Pod"}}
+    },
+    "/apis/": {
+      "get": {"operationId": "getAPIVersions"}
+    },
+    "/apis/storage.k8s.io/v1/storageclasses": {
+      "post": {"x-kubernetes-group-version-kind": {"group": "storage.k8s.io", "version": "v1", "kind": "StorageClass"}}
+    }
+  }
+}`
+
+var scopeTests = map[TopLevelSpec]Scope{
+	{Group: "", Version: "v1", Kind: "Namespace"}:                  ScopeCluster,
+	{Group: "", Version: "v1", Kind: "Pod"}:                        ScopeNamespaced,
+	{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}: ScopeCluster,
+	{Group: "", Version: "v1", Kind: "PodSpec"}:                    ScopeUnknown,
+}
+
+func TestScope(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(scopeSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	for gvk, expected := range scopeTests {
+		if actual := s.Scope(gvk); actual != expected {
+			t.Errorf("Expected '%s' to be %s, got %s", gvk.Kind, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package kubespec
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchResult is a definition or property whose description matches
+// a search. `Property` is empty if the match is in the description of
+// the definition itself. `Path` is the dotted path of the property (or
+// definition) from the root of the search (e.g.,
+// `spec.terminationGracePeriodSeconds` when searching from `Pod`), or
+// just the property name if the search wasn't restricted to a kind. `Match` is the byte range of the first
+// match in `Description`.
+type SearchResult struct {
+	Definition  DefinitionName `json:"definition"`
+	Parsed      *ParsedName    `json:"parsed,omitempty"`
+	Property    PropertyName   `json:"property,omitempty"`
+	Path        string         `json:"path,omitempty"`
+	Description string         `json:"description"`
+	Match       [2]int         `json:"match"`
+}
+
+// Search returns every definition and property whose description
+// matches `pattern`, sorted by definition name, with each definition's
+// own description first, then its properties by name. If `paths` is
+// not nil, only the definitions it contains are searched, and property
+// paths are prefixed with the path to their definition; see
+// `Closure`.
+func (s *APISpec) Search(
+	pattern *regexp.Regexp, paths map[DefinitionName]string,
+) []*SearchResult {
+	return s.search(pattern, paths, parseName)
+}
+
+// Search is like `APISpec.Search`, but parses names with the parser,
+// and so accepts its `Prefixes`.
+func (p *Parser) Search(
+	s *APISpec, pattern *regexp.Regexp, paths map[DefinitionName]string,
+) []*SearchResult {
+	return s.search(pattern, paths, p.ParseName)
+}
+
+func (s *APISpec) search(
+	pattern *regexp.Regexp, paths map[DefinitionName]string,
+	parse func(DefinitionName) (ParsedName, error),
+) []*SearchResult {
+	names := []DefinitionName{}
+	for name := range s.Definitions {
+		if _, ok := paths[name]; paths == nil || ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+
+	results := []*SearchResult{}
+	for _, name := range names {
+		def := s.Definitions[name]
+		var parsed *ParsedName
+		if p, err := parse(name); err == nil {
+			parsed = &p
+		}
+		newResult := func(description string, match []int) *SearchResult {
+			return &SearchResult{
+				Definition:  name,
+				Parsed:      parsed,
+				Path:        paths[name],
+				Description: description,
+				Match:       [2]int{match[0], match[1]},
+			}
+		}
+
+		if match := pattern.FindStringIndex(def.Description); match != nil {
+			results = append(results, newResult(def.Description, match))
+		}
+		for _, propName := range def.Properties.sortedNames() {
+			prop := def.Properties[propName]
+			match := pattern.FindStringIndex(prop.Description)
+			if match == nil {
+				continue
+			}
+			result := newResult(prop.Description, match)
+			result.Property = propName
+			result.Path = joinPath(paths[name], string(propName))
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Snippet splits the description around its match, keeping at most
+// `context` bytes on either side. The cut is moved to the nearest
+// space so that words aren't split, and is marked with `...`.
+func (r *SearchResult) Snippet(context int) (before, match, after string) {
+	start, end := r.Match[0], r.Match[1]
+	before, match, after = r.Description[:start], r.Description[start:end], r.Description[end:]
+
+	if len(before) > context {
+		before = before[len(before)-context:]
+		if i := strings.Index(before, " "); i != -1 {
+			before = before[i+1:]
+		}
+		before = "..." + before
+	}
+	if len(after) > context {
+		after = after[:context]
+		if i := strings.LastIndex(after, " "); i != -1 {
+			after = after[:i]
+		}
+		after += "..."
+	}
+	return before, match, after
+}
+
+// FindKind returns the name of every top-level definition of `kind`,
+// in any group or version, sorted.
+func (s *APISpec) FindKind(kind ObjectKind) []DefinitionName {
+	names := []DefinitionName{}
+	for name, def := range s.Definitions {
+		for _, gvk := range def.TopLevelSpecs {
+			if gvk.Kind == kind {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
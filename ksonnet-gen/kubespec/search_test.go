@@ -0,0 +1,92 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var searchSpec = `{
+  "definitions": {
+    "io.k8s.kubernetes.pkg.api.v1.Pod": {
+      "description": "Pod is a collection of containers that can run on a host.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.PodSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "", "Version": "v1", "Kind": "Pod"}]
+    },
+    "io.k8s.kubernetes.pkg.api.v1.PodSpec": {
+      "description": "PodSpec is a description of a pod.",
+      "properties": {
+        "containers": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.Container"}},
+        "terminationGracePeriodSeconds": {"description": "Optional duration in seconds the pod needs to terminate gracefully. The Termination Grace period is the duration after the processes are sent a signal.", "type": "integer"}
+      }
+    },
+    "io.k8s.kubernetes.pkg.api.v1.Container": {
+      "properties": {
+        "resources": {"type": "object", "additionalProperties": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.Quantity"}}
+      }
+    },
+    "io.k8s.kubernetes.pkg.api.v1.Quantity": {
+      "description": "Quantity is a fixed-point representation of a number, e.g., a grace amount."
+    },
+    "io.k8s.kubernetes.pkg.api.v1.DeleteOptions": {
+      "properties": {
+        "gracePeriodSeconds": {"description": "The termination grace period, in seconds.", "type": "integer"}
+      }
+    }
+  }
+}`
+
+func loadSearchSpec(t *testing.T) *APISpec {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(searchSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	return &s
+}
+
+func TestSearch(t *testing.T) {
+	s := loadSearchSpec(t)
+
+	results := s.Search(regexp.MustCompile("(?i)termination grace"), nil)
+	found := []string{}
+	for _, result := range results {
+		found = append(found, string(result.Definition)+" "+result.Path)
+	}
+	expected := []string{
+		"io.k8s.kubernetes.pkg.api.v1.DeleteOptions gracePeriodSeconds",
+		"io.k8s.kubernetes.pkg.api.v1.PodSpec terminationGracePeriodSeconds",
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected results %v got %v", expected, found)
+	}
+	if results[0].Parsed == nil || results[0].Parsed.Kind != "DeleteOptions" {
+		t.Errorf("Expected result to have a parsed name, got %#v", results[0].Parsed)
+	}
+	if results[1].Property != "terminationGracePeriodSeconds" {
+		t.Errorf("Unexpected property '%s'", results[1].Property)
+	}
+
+	before, match, after := results[1].Snippet(20)
+	if before != "...gracefully. The " || match != "Termination Grace" || after != " period is the..." {
+		t.Errorf("Unexpected snippet '%s' '%s' '%s'", before, match, after)
+	}
+
+	// Restricted to the closure of `Pod`, `DeleteOptions` is excluded,
+	// and paths are relative to `Pod`.
+	closure := s.Closure("io.k8s.kubernetes.pkg.api.v1.Pod")
+	results = s.Search(regexp.MustCompile("(?i)grace"), closure)
+	found = []string{}
+	for _, result := range results {
+		found = append(found, string(result.Definition)+" "+result.Path)
+	}
+	expected = []string{
+		"io.k8s.kubernetes.pkg.api.v1.PodSpec spec.terminationGracePeriodSeconds",
+		"io.k8s.kubernetes.pkg.api.v1.Quantity spec.containers.resources",
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected results %v got %v", expected, found)
+	}
+}
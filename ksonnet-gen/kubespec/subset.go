@@ -0,0 +1,139 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalSubset returns the swagger document the spec was loaded from
+// (i.e., `Text`), reduced to the spec's definitions, which is how a
+// spec returned by `Filter` can be written out and loaded again. Every
+// other part of the document (e.g., `info`, `securityDefinitions`, and
+// vendor extensions) is kept as is, and so are the retained
+// definitions, apart from their formatting. If `withPaths` is set, the paths of the
+// resources whose kind is retained are kept too (including their
+// subresources, like `/scale`), since generation uses them to find
+// each kind's scope and subresources; otherwise `paths` is empty.
+func (s *APISpec) MarshalSubset(withPaths bool) ([]byte, error) {
+	if s.Text == nil {
+		return nil, fmt.Errorf("Can't write a subset of a spec without its text")
+	}
+	document := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s.Text, &document); err != nil {
+		return nil, err
+	}
+
+	allDefinitions := map[DefinitionName]json.RawMessage{}
+	if err := json.Unmarshal(document["definitions"], &allDefinitions); err != nil {
+		return nil, err
+	}
+	definitions := map[DefinitionName]json.RawMessage{}
+	for name := range s.Definitions {
+		if def, ok := allDefinitions[name]; ok {
+			definitions[name] = def
+		}
+	}
+
+	paths := map[string]json.RawMessage{}
+	if withPaths && document["paths"] != nil {
+		allPaths := map[string]json.RawMessage{}
+		if err := json.Unmarshal(document["paths"], &allPaths); err != nil {
+			return nil, err
+		}
+		for path := range s.retainedPaths() {
+			paths[path] = allPaths[path]
+		}
+	}
+
+	var err error
+	if document["definitions"], err = marshalJSON(definitions); err != nil {
+		return nil, err
+	}
+	if document["paths"], err = marshalJSON(paths); err != nil {
+		return nil, err
+	}
+	return marshalJSON(document)
+}
+
+// marshalJSON is `json.MarshalIndent`, but without escaping HTML
+// characters (e.g., the `<` in descriptions), which aren't escaped in
+// specs either.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// retainedPaths returns the paths of the resources that serve a kind
+// with a definition in the spec, along with their variants (e.g.,
+// `watch`) and subresources.
+func (s *APISpec) retainedPaths() map[string]bool {
+	kinds := map[TopLevelSpec]bool{}
+	for _, def := range s.Definitions {
+		for _, tls := range def.TopLevelSpecs {
+			kinds[*tls] = true
+		}
+	}
+
+	resources := map[string]bool{}
+	for path, item := range s.Paths {
+		rp, ok := parseResourcePath(path)
+		if item == nil || !ok || rp.subresource != "" {
+			continue
+		}
+		for _, op := range item.Operations() {
+			if op.GroupVersionKind != nil && kinds[*op.GroupVersionKind] {
+				resources[rp.key()] = true
+			}
+		}
+	}
+
+	paths := map[string]bool{}
+	for path := range s.Paths {
+		if rp, ok := parseResourcePath(path); ok && resources[rp.key()] {
+			paths[path] = true
+		}
+	}
+	return paths
+}
+
+// RawDefinitions returns the definitions named `names` as they are in
+// the swagger document the spec was loaded from (i.e., `Text`),
+// decoded by `encoding/json`, but with numbers as `json.Number`s, so
+// that they keep their text. It's the original schema, including
+// anything the spec's model leaves out (e.g., vendor extensions).
+// Definitions that aren't in the document (e.g., those synthesized by
+// `WithInlineDefinitions`) are left out.
+func (s *APISpec) RawDefinitions(names []DefinitionName) (map[DefinitionName]interface{}, error) {
+	if s.Text == nil {
+		return nil, fmt.Errorf("Can't read the raw definitions of a spec without its text")
+	}
+	document := struct {
+		Definitions map[DefinitionName]json.RawMessage `json:"definitions"`
+	}{}
+	if err := json.Unmarshal(s.Text, &document); err != nil {
+		return nil, err
+	}
+
+	raw := map[DefinitionName]interface{}{}
+	for _, name := range names {
+		text, ok := document.Definitions[name]
+		if !ok {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		var def interface{}
+		if err := decoder.Decode(&def); err != nil {
+			return nil, fmt.Errorf("Could not deserialize definition '%s':\n%v", name, err)
+		}
+		raw[name] = def
+	}
+	return raw, nil
+}
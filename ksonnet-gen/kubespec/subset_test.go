@@ -0,0 +1,191 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var subsetSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.9.0", "x-vendor-build": "abc123"},
+  "securityDefinitions": {"BearerToken": {"type": "apiKey", "name": "authorization", "in": "header"}},
+  "x-vendor-top-level": true,
+  "paths": {
+    "/apis/apps/v1/namespaces/{namespace}/deployments": {
+      "get": {"x-kubernetes-action": "list", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1/namespaces/{namespace}/deployments/{name}/scale": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "autoscaling", "version": "v1", "kind": "Scale"}}
+    },
+    "/apis/batch/v1/namespaces/{namespace}/jobs": {
+      "get": {"x-kubernetes-action": "list", "x-kubernetes-group-version-kind": {"group": "batch", "version": "v1", "kind": "Job"}}
+    },
+    "/version/": {"get": {}}
+  },
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "description": "Deployment enables declarative updates for Pods & ReplicaSets.",
+      "properties": {"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}},
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "Deployment"}],
+      "x-vendor-definition": "kept"
+    },
+    "io.k8s.api.batch.v1.Job": {
+      "properties": {"metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}},
+      "x-kubernetes-group-version-kind": [{"group": "batch", "version": "v1", "kind": "Job"}]
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "properties": {"name": {"type": "string"}}
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.Status": {
+      "properties": {"message": {"type": "string"}}
+    }
+  }
+}`
+
+func TestMarshalSubset(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(subsetSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	s.Text = []byte(subsetSpec)
+
+	filtered := s.Filter(InGroups([]GroupName{"apps"}))
+	text, err := filtered.MarshalSubset(true)
+	if err != nil {
+		t.Fatalf("Could not write subset:\n%v", err)
+	}
+
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(text, &document); err != nil {
+		t.Fatalf("Subset isn't valid JSON:\n%v\n%s", err, text)
+	}
+	if !reflect.DeepEqual(keys(document["definitions"]), []string{
+		"io.k8s.api.apps.v1.Deployment",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta",
+	}) {
+		t.Errorf("Unexpected definitions %v", keys(document["definitions"]))
+	}
+	if !reflect.DeepEqual(keys(document["paths"]), []string{
+		"/apis/apps/v1/namespaces/{namespace}/deployments",
+		"/apis/apps/v1/namespaces/{namespace}/deployments/{name}/scale",
+	}) {
+		t.Errorf("Unexpected paths %v", keys(document["paths"]))
+	}
+
+	// Everything else is kept, including vendor extensions.
+	original := map[string]interface{}{}
+	json.Unmarshal([]byte(subsetSpec), &original)
+	for _, key := range []string{"swagger", "info", "securityDefinitions", "x-vendor-top-level"} {
+		if !reflect.DeepEqual(document[key], original[key]) {
+			t.Errorf("Expected '%s' to be kept, got %v", key, document[key])
+		}
+	}
+	deployment := document["definitions"].(map[string]interface{})["io.k8s.api.apps.v1.Deployment"]
+	if deployment.(map[string]interface{})["x-vendor-definition"] != "kept" {
+		t.Errorf("Expected the definition's vendor extensions to be kept")
+	}
+
+	// The subset loads into the same definitions and resources.
+	loaded := APISpec{}
+	if err := json.Unmarshal(text, &loaded); err != nil {
+		t.Fatalf("Could not deserialize subset:\n%v", err)
+	}
+	if len(loaded.Definitions) != len(filtered.Definitions) {
+		t.Errorf("Expected %d definitions, got %d", len(filtered.Definitions), len(loaded.Definitions))
+	}
+	gvk := TopLevelSpec{Group: "apps", Version: "v1", Kind: "Deployment"}
+	if resource := loaded.Resource(gvk); resource == nil || !resource.HasSubresource("scale") {
+		t.Errorf("Expected the subset to keep the '/scale' subresource, got %#v", resource)
+	}
+
+	text, err = filtered.MarshalSubset(false)
+	if err != nil {
+		t.Fatalf("Could not write subset:\n%v", err)
+	}
+	document = map[string]interface{}{}
+	json.Unmarshal(text, &document)
+	if len(keys(document["paths"])) != 0 {
+		t.Errorf("Expected no paths, got %v", keys(document["paths"]))
+	}
+
+	if _, err := (&APISpec{}).MarshalSubset(true); err == nil {
+		t.Errorf("Expected an error for a spec without text")
+	}
+}
+
+func TestRawDefinitions(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(subsetSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	s.Text = []byte(subsetSpec)
+
+	raw, err := s.RawDefinitions([]DefinitionName{
+		"io.k8s.api.apps.v1.Deployment",
+		"io.k8s.apimachinery.pkg.apis.meta.v1.Status",
+		"io.k8s.api.apps.v1.Missing",
+	})
+	if err != nil {
+		t.Fatalf("Could not read raw definitions:\n%v", err)
+	}
+	if len(raw) != 2 {
+		t.Errorf("Expected only the definitions in the document, got %v", raw)
+	}
+	deployment := raw["io.k8s.api.apps.v1.Deployment"].(map[string]interface{})
+	if deployment["x-vendor-definition"] != "kept" {
+		t.Errorf("Expected the definition's vendor extensions to be kept, got %v", deployment)
+	}
+	status := map[string]interface{}{
+		"properties": map[string]interface{}{"message": map[string]interface{}{"type": "string"}},
+	}
+	if !reflect.DeepEqual(raw["io.k8s.apimachinery.pkg.apis.meta.v1.Status"], status) {
+		t.Errorf("Expected %v, got %v", status, raw["io.k8s.apimachinery.pkg.apis.meta.v1.Status"])
+	}
+
+	// Numbers keep their text.
+	s.Text = []byte(`{"definitions": {"io.k8s.api.core.v1.Weight": {"example": 1.50, "maximum": 12345678901234567890}}}`)
+	raw, err = s.RawDefinitions([]DefinitionName{"io.k8s.api.core.v1.Weight"})
+	weight := raw["io.k8s.api.core.v1.Weight"].(map[string]interface{})
+	if err != nil || weight["example"] != json.Number("1.50") || weight["maximum"] != json.Number("12345678901234567890") {
+		t.Errorf("Expected numbers to keep their text, got %#v (%v)", weight, err)
+	}
+
+	if _, err := (&APISpec{}).RawDefinitions(nil); err == nil {
+		t.Errorf("Expected an error for a spec without text")
+	}
+}
+
+func TestInGroups(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(subsetSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+	for _, groups := range [][]GroupName{{"batch"}, {"batch", "core"}} {
+		filtered := s.Filter(InGroups(groups))
+		if _, ok := filtered.Definitions["io.k8s.api.batch.v1.Job"]; !ok || len(filtered.Definitions) != 2 {
+			t.Errorf("Expected '%v' to keep 'Job' and 'ObjectMeta', got %v", groups, keys(filtered.Definitions))
+		}
+	}
+	if filtered := s.Filter(InGroups([]GroupName{"extensions"})); len(filtered.Definitions) != 0 {
+		t.Errorf("Expected no definitions, got %v", keys(filtered.Definitions))
+	}
+}
+
+// keys returns the sorted keys of a JSON object or of definitions.
+func keys(object interface{}) []string {
+	names := []string{}
+	switch object := object.(type) {
+	case map[string]interface{}:
+		for name := range object {
+			names = append(names, name)
+		}
+	case SchemaDefinitions:
+		for name := range object {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
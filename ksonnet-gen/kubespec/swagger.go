@@ -1,27 +1,98 @@
 package kubespec
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
 // APISpec represents an OpenAPI specification of an API.
+//
+// An APISpec, and the definitions and properties it contains, must not
+// be modified once it's loaded, after which it's safe for concurrent
+// use. Operations that change it, like `Filter` and
+// `WithInlineDefinitions`, return a new APISpec instead, which shares
+// the definitions it didn't change with the original. An APISpec must
+// not be copied after first use.
 type APISpec struct {
 	SwaggerVersion string            `json:"swagger"`
 	Info           *SchemaInfo       `json:"info"`
 	Definitions    SchemaDefinitions `json:"definitions"`
+	Paths          Paths             `json:"paths"`
+
+	// SecurityDefinitions are the auth modes the API accepts, by name
+	// (e.g., `BearerToken`). Like `Info`, they're deserialized
+	// leniently; see `UnmarshalJSON`.
+	SecurityDefinitions SecurityDefinitions `json:"securityDefinitions"`
 
 	// Fields we currently ignore:
-	//   - paths
-	//   - securityDefinitions
 	//   - security
 
 	// Not part of the OpenAPI spec. Filled in later.
 	FilePath string
 	Text     []byte
+
+	// SourceSHA256 is the hex-encoded SHA-256 digest of the bytes the
+	// spec was read from, as `ReadSpec` and `FetchSpec` read them
+	// (i.e., before they're decompressed or converted from YAML), so
+	// that it can be recorded where a library came from. It's empty
+	// for specs built otherwise.
+	SourceSHA256 string
+
+	// MetadataProblems are the parts of `info` and
+	// `securityDefinitions` that were malformed, and so left out, e.g.,
+	// "'info.version' isn't a string".
+	MetadataProblems []string
+
+	// Computed lazily from `Paths`.
+	resourcesOnce sync.Once
+	resources     map[TopLevelSpec]*Resource
+
+	// Computed lazily from `Definitions` and `Paths`; see `Classify`.
+	classesOnce sync.Once
+	classes     map[DefinitionName]ObjectClass
+
+	// What the model the spec was read from derived from it, if it
+	// was; see `ReadSpecFrom`.
+	model *modelDerived
 }
 
 // SchemaInfo contains information about the the API represented with
 // `APISpec`. For example, `title` might be `"Kubernetes"`, and
-// `version` might be `"v1.7.0"`.
+// `version` might be `"v1.7.0"`. `contact` is optional.
 type SchemaInfo struct {
-	Title   string `json:"title"`
-	Version string `json:"version"`
+	Title   string         `json:"title"`
+	Version string         `json:"version"`
+	Contact *SchemaContact `json:"contact,omitempty"`
+}
+
+// SchemaContact is who to contact about the API, from `info.contact`.
+// Each field is optional.
+type SchemaContact struct {
+	Name  string `json:"name,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// SecurityDefinitions maps the name of each auth mode of an API to its
+// scheme.
+type SecurityDefinitions map[string]*SecurityScheme
+
+// SecurityScheme is one auth mode of an API, e.g., for Kubernetes,
+// `{"type": "apiKey", "name": "authorization", "in": "header"}`, a
+// bearer token. `Type` is `basic`, `apiKey`, or `oauth2`; `Name` and
+// `In` are the header or query parameter of an `apiKey`, and the rest
+// are for `oauth2`.
+type SecurityScheme struct {
+	Type             string            `json:"type"`
+	Description      string            `json:"description,omitempty"`
+	Name             string            `json:"name,omitempty"`
+	In               string            `json:"in,omitempty"`
+	Flow             string            `json:"flow,omitempty"`
+	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
+	TokenURL         string            `json:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty"`
 }
 
 // SchemaDefinition is an API object definition. For example, this
@@ -30,10 +101,94 @@ type SchemaInfo struct {
 // properties.
 type SchemaDefinition struct {
 	Type          *SchemaType   `json:"type"`
+	Format        string        `json:"format"`      // e.g., `int-or-string`.
 	Description   string        `json:"description"` // nullable.
 	Required      []string      `json:"required"`    // nullable.
 	Properties    Properties    `json:"properties"`  // nullable.
 	TopLevelSpecs TopLevelSpecs `json:"x-kubernetes-group-version-kind"`
+
+	// PropertyOrder is the names of the properties in the order the
+	// spec declares them, which `Properties`, a map, doesn't keep; see
+	// `DeclaredPropertyNames`.
+	PropertyOrder []PropertyName `json:"-"`
+
+	// Unions are the sets of the definition's fields of which at most
+	// one may be set, from `x-kubernetes-unions`. Older specs don't
+	// have them.
+	Unions []*Union `json:"x-kubernetes-unions"`
+
+	// Example is the schema's example value, if it has one, as decoded
+	// by `encoding/json`, except that numbers are `json.Number`s, so
+	// that integers too large for a `float64` keep their value.
+	// HasExample tells an example of `null` from none; see
+	// `FormatExample`.
+	Example    interface{} `json:"example"`
+	HasExample bool        `json:"-"`
+
+	// StorageVersion is set on the definition that `WithCRDs` adds for
+	// the version a CRD stores its custom resources in. Specs don't say.
+	StorageVersion bool `json:"-"`
+
+	// Fork is set on the definitions that `WithCRDSources` forked, and
+	// says which definition of which source they're a fork of.
+	Fork *Fork `json:"-"`
+
+	// CustomResource is set on the definitions that `WithCRDs` adds,
+	// and says which CRD they're a version of.
+	CustomResource *CustomResource `json:"-"`
+
+	// EmbeddedResource is set on the definitions that
+	// `WithInlineDefinitions` synthesizes for the objects CRD schemas
+	// embed; see `Property.EmbeddedResource`.
+	EmbeddedResource bool `json:"x-kubernetes-embedded-resource"`
+
+	// PreserveUnknownFields is set by CRD schemas, and copied onto the
+	// definitions `WithInlineDefinitions` synthesizes, for objects
+	// whose fields the server retains even if the schema doesn't
+	// declare them; see `Property.PreserveUnknownFields`.
+	PreserveUnknownFields bool `json:"x-kubernetes-preserve-unknown-fields"`
+
+	// All vendor extensions, including those modeled above.
+	Extensions Extensions `json:"-"`
+}
+
+// IsUntyped reports whether a definition has effectively no schema.
+// This is true of definitions with no declared properties, and of
+// definitions like `RawExtension` and apiextensions' `JSON`, whose
+// only property is the raw serialized bytes of an arbitrary value.
+func (sd *SchemaDefinition) IsUntyped() bool {
+	if len(sd.Properties) == 0 {
+		return sd.Type == nil || *sd.Type == "object"
+	} else if raw, ok := sd.Properties["Raw"]; ok && len(sd.Properties) == 1 {
+		return raw.Format == "byte"
+	}
+	return false
+}
+
+// IsIntOrString reports whether a definition is a value that may be
+// either an integer or a string, like `intstr.IntOrString`, which the
+// spec declares as a string with the format `int-or-string`.
+func (sd *SchemaDefinition) IsIntOrString() bool {
+	return sd.Format == intOrStringFormat
+}
+
+// Union is a discriminated union of a definition's fields, as
+// declared by `x-kubernetes-unions`: at most one of `Fields` may be
+// set, and, if the union has a `Discriminator` field, it holds the
+// value that names the one that is, e.g., `HTTPGet` for `httpGet`.
+type Union struct {
+	Discriminator PropertyName            `json:"discriminator"` // May be empty.
+	Fields        map[PropertyName]string `json:"fields-to-discriminateBy"`
+}
+
+// SortedFields returns the names of the fields of the union, sorted.
+func (u *Union) SortedFields() []PropertyName {
+	fields := []PropertyName{}
+	for field := range u.Fields {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i] < fields[j] })
+	return fields
 }
 
 // TopLevelSpec is a property that exists on `SchemaDefinition`s for
@@ -45,6 +200,24 @@ type TopLevelSpec struct {
 }
 type TopLevelSpecs []*TopLevelSpec
 
+// String renders a `TopLevelSpec` as group/version/kind, e.g.,
+// `apps/v1beta1/Deployment`, or `v1/Pod` for the core group.
+func (tls *TopLevelSpec) String() string {
+	if tls.Group == "" {
+		return fmt.Sprintf("%s/%s", tls.Version, tls.Kind)
+	}
+	return fmt.Sprintf("%s/%s/%s", tls.Group, tls.Version, tls.Kind)
+}
+
+// APIVersion returns the `apiVersion` of objects of the kind, e.g.,
+// `apps/v1beta1`, or just `v1` for the core group.
+func (tls *TopLevelSpec) APIVersion() string {
+	if tls.Group == "" {
+		return string(tls.Version)
+	}
+	return fmt.Sprintf("%s/%s", tls.Group, tls.Version)
+}
+
 // SchemaDefinitions is a named collection of `SchemaDefinition`s,
 // represented as a collection mapping definition name ->
 // `SchemaDefinition`.
@@ -54,10 +227,160 @@ type SchemaDefinitions map[DefinitionName]*SchemaDefinition
 // example, `v1.APIGroup` might contain a property called
 // `apiVersion`, which would be specifid by a `Property`.
 type Property struct {
-	Description string      `json:"description"`
-	Type        *SchemaType `json:"type"`
-	Ref         *ObjectRef  `json:"$ref"`
-	Items       Items       `json:"items"` // nil unless Type == "array".
+	Description          string                `json:"description"`
+	Type                 *SchemaType           `json:"type"`
+	Format               string                `json:"format"` // e.g., `int32`, `byte`.
+	Ref                  *ObjectRef            `json:"$ref"`
+	Items                Items                 `json:"items"`                // nil unless Type == "array".
+	Properties           Properties            `json:"properties"`           // nil unless inline object.
+	Required             []string              `json:"required"`             // nil unless inline object.
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties"` // nil unless map.
+
+	// PreserveUnknownFields is set by CRD schemas whose fields the
+	// server should retain even if they're not specified in the schema.
+	PreserveUnknownFields bool `json:"x-kubernetes-preserve-unknown-fields"`
+
+	// EmbeddedResource is set by CRD schemas for objects that are
+	// Kubernetes objects themselves, with an `apiVersion`, a `kind`
+	// and `metadata`, e.g., a template of another kind; see `WithCRDs`.
+	EmbeddedResource bool `json:"x-kubernetes-embedded-resource"`
+
+	// IntOrString is set by CRD schemas for fields that may be either
+	// an integer or a string, which they declare with
+	// `x-kubernetes-int-or-string` rather than a `$ref` to
+	// `intstr.IntOrString`. Some also (or instead) declare `AnyOf` the
+	// two types; see `IsIntOrString`.
+	IntOrString bool        `json:"x-kubernetes-int-or-string"`
+	AnyOf       []*Property `json:"anyOf"`
+
+	// Strategic merge patch metadata, e.g., `containers` is merged
+	// using the key `name`.
+	PatchMergeKey string `json:"x-kubernetes-patch-merge-key"`
+	PatchStrategy string `json:"x-kubernetes-patch-strategy"`
+
+	// Server-side apply metadata for arrays, which supersedes the
+	// strategic merge patch metadata, e.g., `containers` is a `map`
+	// keyed by `name`.
+	ListType    ListType `json:"x-kubernetes-list-type"`
+	ListMapKeys []string `json:"x-kubernetes-list-map-keys"` // Only set for `map` lists.
+
+	// Example is the property's example value, like
+	// `SchemaDefinition.Example`.
+	Example    interface{} `json:"example"`
+	HasExample bool        `json:"-"`
+
+	// StorageVersion is set on the definition that `WithCRDs` adds for
+	// the version a CRD stores its custom resources in. Specs don't say.
+	StorageVersion bool `json:"-"`
+
+	// Validation constraints on the property's values, which CRD
+	// schemas often set. `Minimum` and `Maximum` are empty, and
+	// `MinLength` and `MaxLength` nil, unless they're set; `Exclusive*`
+	// make the bounds strict.
+	Minimum          json.Number `json:"minimum"`
+	Maximum          json.Number `json:"maximum"`
+	ExclusiveMinimum bool        `json:"exclusiveMinimum"`
+	ExclusiveMaximum bool        `json:"exclusiveMaximum"`
+	MinLength        *int64      `json:"minLength"`
+	MaxLength        *int64      `json:"maxLength"`
+	Pattern          string      `json:"pattern"`
+
+	// All vendor extensions, including those modeled above.
+	Extensions Extensions `json:"-"`
+}
+
+// ListType describes how the elements of an array are merged, e.g.,
+// when applying a change to an object on the server.
+type ListType string
+
+const (
+	// ListTypeAtomic lists are replaced as a whole.
+	ListTypeAtomic ListType = "atomic"
+
+	// ListTypeSet lists have unique scalar elements, which are merged.
+	ListTypeSet ListType = "set"
+
+	// ListTypeMap lists have object elements, which are merged by the
+	// values of their `ListMapKeys` fields.
+	ListTypeMap ListType = "map"
+)
+
+// IsAtomicList reports whether an array property is replaced as a
+// whole, rather than merged element by element. Only
+// `x-kubernetes-list-type: atomic` makes a list atomic, regardless of
+// its strategic merge patch metadata; lists with neither are treated
+// as mergeable.
+func (p *Property) IsAtomicList() bool {
+	return p.Type != nil && *p.Type == "array" && p.ListType == ListTypeAtomic
+}
+
+const intOrStringFormat = "int-or-string"
+
+// Int64Format is the format of integer properties whose values are
+// 64-bit, e.g., `activeDeadlineSeconds`. Jsonnet numbers are doubles,
+// which only represent integers exactly up to 2^53.
+const Int64Format = "int64"
+
+// IsInt64 reports whether a property is an integer with the format
+// `int64`.
+func (p *Property) IsInt64() bool {
+	return p.Type != nil && *p.Type == "integer" && p.Format == Int64Format
+}
+
+// IsIntOrString reports whether a property may be either an integer or
+// a string, i.e., it sets `x-kubernetes-int-or-string`, its format is
+// `int-or-string`, or it's `anyOf` exactly an integer and a string.
+// Properties that `$ref` `intstr.IntOrString` aren't included, since
+// that depends on the definition; see `SchemaDefinition.IsIntOrString`.
+func (p *Property) IsIntOrString() bool {
+	if p.IntOrString || p.Format == intOrStringFormat {
+		return true
+	} else if len(p.AnyOf) != 2 {
+		return false
+	}
+	types := map[SchemaType]bool{}
+	for _, alternative := range p.AnyOf {
+		if alternative.Type != nil && alternative.Ref == nil {
+			types[*alternative.Type] = true
+		}
+	}
+	return types["integer"] && types["string"]
+}
+
+// IsUntyped reports whether a property has effectively no schema,
+// e.g., `{}`, an object with no declared properties or value type, or
+// a field that preserves unknown fields. Values of such properties
+// are arbitrary, unvalidated JSON.
+func (p *Property) IsUntyped() bool {
+	if p.PreserveUnknownFields {
+		return true
+	} else if p.Ref != nil || p.IsIntOrString() {
+		return false
+	}
+	return p.Type == nil ||
+		(*p.Type == "object" &&
+			len(p.Properties) == 0 &&
+			p.AdditionalProperties == nil)
+}
+
+// AdditionalProperties specifies the type of the values of a map
+// property (i.e., an object whose field names are arbitrary). In JSON
+// schema this is either a schema or a boolean; `Schema` is nil in the
+// latter case.
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Property
+}
+
+// UnmarshalJSON accepts both the boolean and the schema form of
+// `additionalProperties`.
+func (ap *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &ap.Allowed); err == nil {
+		return nil
+	}
+	ap.Allowed = true
+	ap.Schema = &Property{}
+	return json.Unmarshal(data, ap.Schema)
 }
 
 // Properties is a named collection of `Properties`s, represented as a
@@ -68,11 +391,72 @@ type Properties map[PropertyName]*Property
 // is used to fully specify a `Property` object whose `type` field is
 // `"array"`.
 type Items struct {
-	Ref *ObjectRef `json:"$ref"`
+	Ref    *ObjectRef  `json:"$ref"`
+	Type   *SchemaType `json:"type"`
+	Format string      `json:"format"`
+}
+
+// Paths is the set of REST endpoints exposed by the API, represented
+// as a collection mapping a path template (e.g.,
+// `/api/v1/namespaces/{namespace}/pods`) -> `PathItem`.
+type Paths map[string]*PathItem
+
+// PathItem contains the operations (e.g., `get`, `delete`) that are
+// available at some path.
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Put    *Operation `json:"put"`
+	Post   *Operation `json:"post"`
+	Delete *Operation `json:"delete"`
+	Patch  *Operation `json:"patch"`
+
+	// Parameters are the parameters of every operation at this path
+	// (e.g., `namespace`, `pretty`); see `Operation.Parameters`.
+	Parameters []*Parameter `json:"parameters"`
+}
+
+// Operations returns the non-nil operations available at this path.
+func (pi *PathItem) Operations() []*Operation {
+	ops := []*Operation{}
+	for _, op := range []*Operation{pi.Get, pi.Put, pi.Post, pi.Delete, pi.Patch} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Operation is a single API operation on a path. Kubernetes annotates
+// each operation with the kind of object it operates on.
+type Operation struct {
+	OperationID      string        `json:"operationId"`
+	Action           string        `json:"x-kubernetes-action"` // e.g., `list`.
+	GroupVersionKind *TopLevelSpec `json:"x-kubernetes-group-version-kind"`
+
+	// Parameters are the operation's own parameters, e.g., the
+	// `labelSelector` of a `list`, or the `DeleteOptions` body of a
+	// `delete`, which override those of its path of the same name and
+	// location; see `APISpec.QueryParameters`.
+	Parameters []*Parameter `json:"parameters"`
+
+	// All vendor extensions, including those modeled above.
+	Extensions Extensions `json:"-"`
 
 	// Ignored fields:
-	// - Type *SchemaType `json:"type"`
-	// - Format *string `json:"format"`
+	// - Responses map[string]*Response `json:"responses"`
+}
+
+// Parameter is a parameter of an operation: a query parameter (e.g.,
+// `labelSelector`, of type `string`), a path parameter (e.g., `name`),
+// or the body, whose schema is usually a `$ref` (e.g., to
+// `DeleteOptions`).
+type Parameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"` // e.g., `query`, `path`, `body`.
+	Description string     `json:"description"`
+	Required    bool       `json:"required"`
+	Type        SchemaType `json:"type"`   // Empty for the body.
+	Schema      *Property  `json:"schema"` // Only for the body.
 }
 
 // SchemaType represents the type of some object in an API spec. For
@@ -0,0 +1,142 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var untypedPropertyTests = map[string]bool{
+	`{}`:                           true,
+	`{"description": "Anything."}`: true,
+	`{"type": "object"}`:           true,
+	`{"type": "object", "properties": {"a": {"type": "string"}}, "x-kubernetes-preserve-unknown-fields": true}`: true,
+	`{"type": "object", "additionalProperties": {"type": "string"}}`:                                            false,
+	`{"type": "object", "additionalProperties": true}`:                                                          false,
+	`{"type": "object", "properties": {"a": {"type": "string"}}}`:                                               false,
+	`{"type": "string"}`: false,
+	`{"$ref": "#/definitions/io.k8s.apimachinery.pkg.runtime.RawExtension"}`: false,
+	`{"x-kubernetes-int-or-string": true}`:                                   false,
+	`{"anyOf": [{"type": "integer"}, {"type": "string"}]}`:                   false,
+}
+
+func TestPropertyIsUntyped(t *testing.T) {
+	for text, expected := range untypedPropertyTests {
+		prop := Property{}
+		if err := json.Unmarshal([]byte(text), &prop); err != nil {
+			t.Fatalf("Could not deserialize property '%s':\n%v", text, err)
+		}
+		if actual := prop.IsUntyped(); actual != expected {
+			t.Errorf("Expected IsUntyped of '%s' to be %v", text, expected)
+		}
+	}
+}
+
+var untypedDefinitionTests = map[string]bool{
+	`{"description": "JSON represents any valid JSON value.", "required": ["Raw"], "properties": {"Raw": {"type": "string", "format": "byte"}}}`: true,
+	`{"type": "object"}`: true,
+	`{}`:                 true,
+	`{"type": "string", "format": "date-time"}`:                      false,
+	`{"properties": {"Raw": {"type": "string"}}}`:                    false,
+	`{"properties": {"name": {"type": "string", "format": "byte"}}}`: false,
+}
+
+func TestDefinitionIsUntyped(t *testing.T) {
+	for text, expected := range untypedDefinitionTests {
+		def := SchemaDefinition{}
+		if err := json.Unmarshal([]byte(text), &def); err != nil {
+			t.Fatalf("Could not deserialize definition '%s':\n%v", text, err)
+		}
+		if actual := def.IsUntyped(); actual != expected {
+			t.Errorf("Expected IsUntyped of '%s' to be %v", text, expected)
+		}
+	}
+}
+
+var atomicListTests = map[string]bool{
+	`{"type": "array", "items": {"type": "string"}, "x-kubernetes-list-type": "atomic"}`:            true,
+	`{"type": "array", "x-kubernetes-list-type": "atomic", "x-kubernetes-patch-strategy": "merge"}`: true,
+	`{"type": "array", "x-kubernetes-list-type": "map", "x-kubernetes-list-map-keys": ["name"]}`:    false,
+	`{"type": "array", "x-kubernetes-list-type": "set"}`:                                            false,
+	`{"type": "array", "x-kubernetes-patch-strategy": "merge"}`:                                     false,
+	`{"type": "array"}`: false,
+}
+
+func TestPropertyIsAtomicList(t *testing.T) {
+	for text, expected := range atomicListTests {
+		prop := Property{}
+		if err := json.Unmarshal([]byte(text), &prop); err != nil {
+			t.Fatalf("Could not deserialize property '%s':\n%v", text, err)
+		}
+		if actual := prop.IsAtomicList(); actual != expected {
+			t.Errorf("Expected IsAtomicList of '%s' to be %v", text, expected)
+		}
+	}
+}
+
+var intOrStringPropertyTests = map[string]bool{
+	`{"x-kubernetes-int-or-string": true}`:                                                     true,
+	`{"anyOf": [{"type": "integer"}, {"type": "string"}], "x-kubernetes-int-or-string": true}`: true,
+	`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`:                                     true,
+	`{"type": "string", "format": "int-or-string"}`:                                            true,
+	`{"anyOf": [{"type": "integer"}, {"type": "string"}, {"type": "boolean"}]}`:                false,
+	`{"anyOf": [{"type": "object"}, {"type": "string"}]}`:                                      false,
+	`{"anyOf": [{"type": "integer"}, {"$ref": "#/definitions/io.k8s.api.core.v1.Quantity"}]}`:  false,
+	`{"$ref": "#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}`:                false,
+	`{"type": "integer"}`: false,
+}
+
+func TestPropertyIsIntOrString(t *testing.T) {
+	for text, expected := range intOrStringPropertyTests {
+		prop := Property{}
+		if err := json.Unmarshal([]byte(text), &prop); err != nil {
+			t.Fatalf("Could not deserialize property '%s':\n%v", text, err)
+		}
+		if actual := prop.IsIntOrString(); actual != expected {
+			t.Errorf("Expected IsIntOrString of '%s' to be %v", text, expected)
+		}
+	}
+
+	def := SchemaDefinition{}
+	text := `{"description": "IntOrString is a type that can hold an int32 or a string.", "type": "string", "format": "int-or-string"}`
+	if err := json.Unmarshal([]byte(text), &def); err != nil {
+		t.Fatalf("Could not deserialize definition '%s':\n%v", text, err)
+	}
+	if !def.IsIntOrString() {
+		t.Errorf("Expected IsIntOrString of '%s' to be true", text)
+	}
+}
+
+var int64PropertyTests = map[string]bool{
+	`{"type": "integer", "format": "int64"}`:                             true,
+	`{"type": "integer", "format": "int32"}`:                             false,
+	`{"type": "integer"}`:                                                false,
+	`{"type": "string", "format": "int64"}`:                              false,
+	`{"type": "array", "items": {"type": "integer", "format": "int64"}}`: false,
+}
+
+func TestPropertyIsInt64(t *testing.T) {
+	for text, expected := range int64PropertyTests {
+		prop := Property{}
+		if err := json.Unmarshal([]byte(text), &prop); err != nil {
+			t.Fatalf("Could not deserialize property '%s':\n%v", text, err)
+		}
+		if actual := prop.IsInt64(); actual != expected {
+			t.Errorf("Expected IsInt64 of '%s' to be %v", text, expected)
+		}
+	}
+}
+
+func TestDefinitionUnions(t *testing.T) {
+	text := `{"properties": {"type": {"type": "string"}}, "x-kubernetes-unions": [{"discriminator": "type", "fields-to-discriminateBy": {"tcpSocket": "TCPSocket", "exec": "Exec"}}, {"fields-to-discriminateBy": {"a": "A"}}]}`
+	def := SchemaDefinition{}
+	if err := json.Unmarshal([]byte(text), &def); err != nil {
+		t.Fatalf("Could not deserialize definition '%s':\n%v", text, err)
+	}
+	if len(def.Unions) != 2 || def.Unions[0].Discriminator != "type" || def.Unions[1].Discriminator != "" {
+		t.Fatalf("Expected two unions, got %#v", def.Unions)
+	}
+	fields := def.Unions[0].SortedFields()
+	if len(fields) != 2 || fields[0] != "exec" || fields[1] != "tcpSocket" || def.Unions[0].Fields["exec"] != "Exec" {
+		t.Errorf("Expected the union's fields to be 'exec' and 'tcpSocket', got %v", def.Unions[0].Fields)
+	}
+}
@@ -0,0 +1,163 @@
+package kubespec
+
+import "strings"
+
+// ObjectLevel represents whether a definition is of a top-level API
+// object, which has an `apiVersion` and `kind` of its own (e.g.,
+// `Deployment`), or of a sub-object that only appears inside others
+// (e.g., `PodSpec`).
+type ObjectLevel int
+
+const (
+	// LevelUnknown is the level of names the spec has no definition
+	// for.
+	LevelUnknown ObjectLevel = iota
+
+	// LevelSubObject is the level of definitions without an
+	// `x-kubernetes-group-version-kind`, e.g., `PodSpec`.
+	LevelSubObject
+
+	// LevelTopLevel is the level of definitions with an
+	// `x-kubernetes-group-version-kind`, e.g., `Deployment`, and of
+	// those `WithCRDs` adds, which get one from their CRD.
+	LevelTopLevel
+)
+
+func (l ObjectLevel) String() string {
+	switch l {
+	case LevelSubObject:
+		return "subObject"
+	case LevelTopLevel:
+		return "topLevel"
+	default:
+		return "unknown"
+	}
+}
+
+// ObjectClass is what the spec says about the level of a definition,
+// and, for top-level ones, how the API uses them. Not every top-level
+// object is something one would `kubectl apply`:
+//
+//   - Lists (e.g., `DeploymentList`) are top-level, and `List` is
+//     set, but they're only read, not created.
+//   - The bodies of subresources (e.g., `autoscaling/v1` `Scale`, read
+//     and written through `/scale`) are top-level, and `Subresource`
+//     is set, but no path serves them as resources of their own.
+//   - Objects the API only returns (e.g., `meta/v1` `Status`) are
+//     top-level, but neither served nor either of the above.
+//   - Custom resources (see `WithCRDs`) have no paths in the spec, so
+//     `Custom` is set, and `Served` comes from their CRD instead.
+type ObjectClass struct {
+	Level ObjectLevel
+
+	// Served is set if some path of the spec serves the kind as a
+	// resource, or, for custom resources, if the CRD serves the
+	// version.
+	Served bool
+
+	// List is set for lists of other objects, i.e., kinds that end in
+	// `List` and have an array of `items`.
+	List bool
+
+	// Subresource is set for the kinds of the bodies of subresources
+	// (e.g., `Scale` and `Eviction`) that aren't served otherwise.
+	Subresource bool
+
+	// Custom is set for the definitions `WithCRDs` adds; see
+	// `SchemaDefinition.CustomResource`.
+	Custom bool
+}
+
+// IsTopLevel reports whether the class is of a top-level API object.
+func (c ObjectClass) IsTopLevel() bool {
+	return c.Level == LevelTopLevel
+}
+
+// IsTopLevel reports whether the spec's definition of `name` is of a
+// top-level API object (e.g., `Deployment`, but also `DeploymentList`
+// and `Scale`; see `ObjectClass`), rather than a sub-object (e.g.,
+// `PodSpec`), or a name it has no definition for.
+func (s *APISpec) IsTopLevel(name ParsedName) bool {
+	return s.Level(name) == LevelTopLevel
+}
+
+// Level classifies `name` as the name of a top-level API object, of a
+// sub-object, or, if the spec has no definition for it, as unknown.
+func (s *APISpec) Level(name ParsedName) ObjectLevel {
+	dn, err := name.unparseName()
+	if err != nil {
+		return LevelUnknown
+	}
+	return s.Classify(dn).Level
+}
+
+// Classify returns the class of the definition `name`, or a class of
+// `LevelUnknown` if the spec doesn't have it. Classes are computed
+// once, from the `x-kubernetes-group-version-kind` of the definitions
+// and the paths section of the spec.
+func (s *APISpec) Classify(name DefinitionName) ObjectClass {
+	return s.allClasses()[name]
+}
+
+func (s *APISpec) allClasses() map[DefinitionName]ObjectClass {
+	s.classesOnce.Do(func() {
+		s.classes = s.computeClasses()
+	})
+	return s.classes
+}
+
+func (s *APISpec) computeClasses() map[DefinitionName]ObjectClass {
+	subresourceKinds := s.subresourceKinds()
+	classes := make(map[DefinitionName]ObjectClass, len(s.Definitions))
+	for name, def := range s.Definitions {
+		if def == nil {
+			continue
+		}
+		if len(def.TopLevelSpecs) == 0 {
+			classes[name] = ObjectClass{Level: LevelSubObject}
+			continue
+		}
+
+		class := ObjectClass{Level: LevelTopLevel}
+		if def.CustomResource != nil {
+			class.Custom, class.Served = true, def.CustomResource.Served
+		}
+		for _, gvk := range def.TopLevelSpecs {
+			if gvk == nil {
+				continue
+			}
+			if s.Resource(*gvk) != nil {
+				class.Served = true
+			}
+			if subresourceKinds[*gvk] {
+				class.Subresource = true
+			}
+			if strings.HasSuffix(string(gvk.Kind), "List") {
+				if items, ok := def.Properties["items"]; ok && items.Type != nil && *items.Type == "array" {
+					class.List = true
+				}
+			}
+		}
+		class.Subresource = class.Subresource && !class.Served
+		classes[name] = class
+	}
+	return classes
+}
+
+// `subresourceKinds` returns the kinds the operations on subresources
+// (e.g., `/scale` and `/eviction`) say they read and write.
+func (s *APISpec) subresourceKinds() map[TopLevelSpec]bool {
+	kinds := map[TopLevelSpec]bool{}
+	for path, item := range s.Paths {
+		rp, ok := parseResourcePath(path)
+		if item == nil || !ok || rp.subresource == "" {
+			continue
+		}
+		for _, op := range item.Operations() {
+			if op.GroupVersionKind != nil {
+				kinds[*op.GroupVersionKind] = true
+			}
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,115 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var classesSpec = `{
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "Deployment"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentList": {
+      "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.api.apps.v1.Deployment"}}},
+      "x-kubernetes-group-version-kind": [{"group": "apps", "version": "v1", "kind": "DeploymentList"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {},
+    "io.k8s.api.autoscaling.v1.Scale": {
+      "x-kubernetes-group-version-kind": [{"group": "autoscaling", "version": "v1", "kind": "Scale"}]
+    },
+    "io.k8s.api.policy.v1beta1.Eviction": {
+      "x-kubernetes-group-version-kind": [{"group": "policy", "version": "v1beta1", "kind": "Eviction"}]
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.Status": {
+      "x-kubernetes-group-version-kind": [{"group": "", "version": "v1", "kind": "Status"}]
+    },
+    "io.k8s.apimachinery.pkg.apis.meta.v1.WatchEventList": {
+      "properties": {"items": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.Status"}},
+      "x-kubernetes-group-version-kind": [{"group": "", "version": "v1", "kind": "WatchEventList"}]
+    }
+  },
+  "paths": {
+    "/apis/apps/v1/namespaces/{namespace}/deployments": {
+      "post": {"x-kubernetes-action": "post", "x-kubernetes-group-version-kind": {"group": "apps", "version": "v1", "kind": "Deployment"}}
+    },
+    "/apis/apps/v1/namespaces/{namespace}/deployments/{name}/scale": {
+      "get": {"x-kubernetes-action": "get", "x-kubernetes-group-version-kind": {"group": "autoscaling", "version": "v1", "kind": "Scale"}}
+    },
+    "/api/v1/namespaces/{namespace}/pods/{name}/eviction": {
+      "post": {"x-kubernetes-action": "post", "x-kubernetes-group-version-kind": {"group": "policy", "version": "v1beta1", "kind": "Eviction"}}
+    }
+  }
+}`
+
+var classTests = map[DefinitionName]ObjectClass{
+	// Served kinds are what one would `kubectl apply`.
+	"io.k8s.api.apps.v1.Deployment": {Level: LevelTopLevel, Served: true},
+
+	// Lists are top-level, but only read.
+	"io.k8s.api.apps.v1.DeploymentList": {Level: LevelTopLevel, List: true},
+
+	// The bodies of subresources are top-level, but served through
+	// their parent's path.
+	"io.k8s.api.autoscaling.v1.Scale":    {Level: LevelTopLevel, Subresource: true},
+	"io.k8s.api.policy.v1beta1.Eviction": {Level: LevelTopLevel, Subresource: true},
+
+	// `Status` is only returned, and a kind named like a list without
+	// an array of items isn't one.
+	"io.k8s.apimachinery.pkg.apis.meta.v1.Status":         {Level: LevelTopLevel},
+	"io.k8s.apimachinery.pkg.apis.meta.v1.WatchEventList": {Level: LevelTopLevel},
+
+	"io.k8s.api.apps.v1.DeploymentSpec": {Level: LevelSubObject},
+	"io.k8s.api.core.v1.PodSpec":        {Level: LevelUnknown},
+}
+
+func TestClassify(t *testing.T) {
+	s := &APISpec{}
+	if err := json.Unmarshal([]byte(classesSpec), s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	for name, expected := range classTests {
+		if actual := s.Classify(name); actual != expected {
+			t.Errorf("Expected '%s' to be classified as %+v, got %+v", name, expected, actual)
+		}
+		parsed, err := ParseName(name)
+		if err != nil {
+			t.Fatalf("Could not parse '%s':\n%v", name, err)
+		}
+		if level := s.Level(parsed); level != expected.Level {
+			t.Errorf("Expected '%s' to be %s, got %s", name, expected.Level, level)
+		}
+		if isTopLevel := s.IsTopLevel(parsed); isTopLevel != (expected.Level == LevelTopLevel) {
+			t.Errorf("Expected '%s' to be top-level: %v, got %v", name, !isTopLevel, isTopLevel)
+		}
+	}
+}
+
+func TestClassifyCRDs(t *testing.T) {
+	crds, err := ReadCRDs("crds.yaml", []byte(crdsYAML))
+	if err != nil {
+		t.Fatalf("Could not read CRDs:\n%v", err)
+	}
+	s := &APISpec{Definitions: SchemaDefinitions{ObjectMetaName: &SchemaDefinition{}}}
+	withCRDs, err := s.WithCRDs(crds, true)
+	if err != nil {
+		t.Fatalf("Could not add CRDs:\n%v", err)
+	}
+
+	// Custom resources have no paths, so whether they're served comes
+	// from their CRD, which may list versions it doesn't serve.
+	for name, expected := range map[DefinitionName]ObjectClass{
+		"io.k8s.crd.api.stableExampleCom.v1beta1.CronTab":  {Level: LevelTopLevel, Served: true, Custom: true},
+		"io.k8s.crd.api.stableExampleCom.v2alpha1.CronTab": {Level: LevelTopLevel, Custom: true},
+		ObjectMetaName: {Level: LevelSubObject},
+	} {
+		if actual := withCRDs.Classify(name); actual != expected {
+			t.Errorf("Expected '%s' to be classified as %+v, got %+v", name, expected, actual)
+		}
+	}
+	if source := withCRDs.Definitions["io.k8s.crd.api.stableExampleCom.v1beta1.CronTab"].CustomResource; source == nil ||
+		source.CRD != "crontabs.stable.example.com" {
+		t.Errorf("Expected the definition to record its CRD, got %+v", source)
+	}
+}
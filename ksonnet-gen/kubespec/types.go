@@ -0,0 +1,79 @@
+package kubespec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeString describes the type of a property for people, e.g.,
+// `integer (int64)`, `array of Container`, `map of string → Quantity`,
+// or `object (CrossVersionObjectReference)`. Definitions are named by
+// their kind, without their group or version. Integer-or-string
+// properties, whether they `$ref` `intstr.IntOrString` or say so
+// themselves, are `IntOrString`, and properties with no schema (see
+// `Property.IsUntyped`) are `object`.
+func (s *APISpec) TypeString(p *Property) string {
+	switch {
+	case p.IsIntOrString():
+		return intOrStringType
+	case p.Ref != nil:
+		if def, ok := s.refDefinition(*p.Ref); ok && len(def.Properties) > 0 {
+			return fmt.Sprintf("object (%s)", s.refType(*p.Ref))
+		}
+		return s.refType(*p.Ref)
+	case p.IsUntyped():
+		return "object"
+	}
+
+	switch *p.Type {
+	case "array":
+		if p.Items.Ref != nil {
+			return "array of " + s.refType(*p.Items.Ref)
+		} else if p.Items.Type != nil {
+			return "array of " + scalarType(*p.Items.Type, p.Items.Format)
+		}
+		return "array"
+	case "object":
+		if p.AdditionalProperties == nil {
+			return "object"
+		} else if schema := p.AdditionalProperties.Schema; schema != nil {
+			return "map of string → " + s.TypeString(schema)
+		}
+		return "map of string → object"
+	default:
+		return scalarType(*p.Type, p.Format)
+	}
+}
+
+const intOrStringType = "IntOrString"
+
+func scalarType(schemaType SchemaType, format string) string {
+	if format == intOrStringFormat {
+		return intOrStringType
+	} else if format == "" {
+		return string(schemaType)
+	}
+	return fmt.Sprintf("%s (%s)", schemaType, format)
+}
+
+// `refType` names the definition `ref` refers to by its kind, e.g.,
+// `Container` for `io.k8s.kubernetes.pkg.api.v1.Container`.
+func (s *APISpec) refType(ref ObjectRef) string {
+	if def, ok := s.refDefinition(ref); ok && def.IsIntOrString() {
+		return intOrStringType
+	}
+	name, err := parseRef(ref)
+	if err != nil {
+		return string(ref)
+	}
+	return string(*name)[strings.LastIndex(string(*name), ".")+1:]
+}
+
+func (s *APISpec) refDefinition(ref ObjectRef) (*SchemaDefinition, bool) {
+	name, err := parseRef(ref)
+	if err != nil {
+		return nil, false
+	}
+	def, ok := s.Definitions[*name]
+	return def, ok
+}
@@ -0,0 +1,89 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var typesSpec = `{
+  "definitions": {
+    "io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscalerSpec": {
+      "properties": {
+        "activeDeadlineSeconds": {"type": "integer", "format": "int64"},
+        "name": {"type": "string"},
+        "paused": {"type": "boolean"},
+        "containers": {"type": "array", "items": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.Container"}},
+        "args": {"type": "array", "items": {"type": "string"}},
+        "ports": {"type": "array", "items": {"type": "integer", "format": "int32"}},
+        "limits": {"type": "object", "additionalProperties": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity"}},
+        "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+        "nested": {"type": "object", "additionalProperties": {"type": "array", "items": {"type": "string"}}},
+        "anything": {"type": "object", "additionalProperties": true},
+        "targetPort": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString"},
+        "maxUnavailable": {"x-kubernetes-int-or-string": true},
+        "scaleTargetRef": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.apis.autoscaling.v1.CrossVersionObjectReference"},
+        "quantity": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity"},
+        "config": {"type": "object"},
+        "raw": {},
+        "preserved": {"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+        "inline": {"type": "object", "properties": {"a": {"type": "string"}}}
+      }
+    },
+    "io.k8s.kubernetes.pkg.apis.autoscaling.v1.CrossVersionObjectReference": {
+      "properties": {"kind": {"type": "string"}}
+    },
+    "io.k8s.kubernetes.pkg.api.v1.Container": {
+      "properties": {"image": {"type": "string"}}
+    },
+    "io.k8s.apimachinery.pkg.api.resource.Quantity": {
+      "type": "string"
+    },
+    "io.k8s.apimachinery.pkg.util.intstr.IntOrString": {
+      "type": "string",
+      "format": "int-or-string"
+    }
+  }
+}`
+
+var expectedTypeStrings = map[PropertyName]string{
+	"activeDeadlineSeconds": "integer (int64)",
+	"name":                  "string",
+	"paused":                "boolean",
+	"containers":            "array of Container",
+	"args":                  "array of string",
+	"ports":                 "array of integer (int32)",
+	"limits":                "map of string → Quantity",
+	"labels":                "map of string → string",
+	"nested":                "map of string → array of string",
+	"anything":              "map of string → object",
+	"targetPort":            "IntOrString",
+	"maxUnavailable":        "IntOrString",
+	"scaleTargetRef":        "object (CrossVersionObjectReference)",
+	"quantity":              "Quantity",
+	"config":                "object",
+	"raw":                   "object",
+	"preserved":             "object",
+	"inline":                "object",
+}
+
+func TestTypeString(t *testing.T) {
+	s := APISpec{}
+	if err := json.Unmarshal([]byte(typesSpec), &s); err != nil {
+		t.Fatalf("Could not deserialize spec:\n%v", err)
+	}
+
+	def := s.Definitions["io.k8s.kubernetes.pkg.apis.autoscaling.v1.HorizontalPodAutoscalerSpec"]
+	for name, expected := range expectedTypeStrings {
+		prop, ok := def.Properties[name]
+		if !ok {
+			t.Errorf("Expected property '%s' in test spec", name)
+			continue
+		}
+		if actual := s.TypeString(prop); actual != expected {
+			t.Errorf("Expected type of '%s' to be '%s' got '%s'", name, expected, actual)
+		}
+	}
+	if len(def.Properties) != len(expectedTypeStrings) {
+		t.Errorf("Expected a type string for each of %d properties", len(def.Properties))
+	}
+}
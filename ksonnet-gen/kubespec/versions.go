@@ -0,0 +1,102 @@
+package kubespec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionStage is how stable an API version is, according to
+// Kubernetes' naming convention for versions.
+type VersionStage string
+
+const (
+	// StageAlpha versions (e.g., `v2alpha1`) are disabled by default,
+	// and may change incompatibly or be removed without notice.
+	StageAlpha VersionStage = "alpha"
+
+	// StageBeta versions (e.g., `v1beta1`) are enabled by default, but
+	// may still change incompatibly in a later version.
+	StageBeta VersionStage = "beta"
+
+	// StageGA versions (e.g., `v1`) are stable.
+	StageGA VersionStage = "ga"
+
+	// StageUnknown is for versions that don't follow the convention
+	// (e.g., `intstr`, in `io.k8s.apimachinery.pkg.util.intstr`).
+	StageUnknown VersionStage = ""
+)
+
+// APIVersion is a version string broken into its parts, e.g.,
+// `v2alpha1` is major version 2, alpha, minor version 1. GA versions
+// have no minor version.
+type APIVersion struct {
+	Major int
+	Stage VersionStage
+	Minor int
+}
+
+var versionPattern = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
+
+// Parse breaks a version string into its parts, reporting whether it
+// follows Kubernetes' convention, i.e., `v<major>` optionally followed
+// by `alpha<minor>` or `beta<minor>`.
+func (vs VersionString) Parse() (APIVersion, bool) {
+	match := versionPattern.FindStringSubmatch(string(vs))
+	if match == nil {
+		return APIVersion{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[3])
+	stage := StageGA
+	if match[2] != "" {
+		stage = VersionStage(match[2])
+	}
+	return APIVersion{Major: major, Stage: stage, Minor: minor}, true
+}
+
+// Stage returns the stage of a version string, or `StageUnknown` if it
+// doesn't follow Kubernetes' convention.
+func (vs VersionString) Stage() VersionStage {
+	version, _ := vs.Parse()
+	return version.Stage
+}
+
+// GroupVersion is an API group and one of its versions, e.g.,
+// `apps/v1beta2`. The group is named either as in definition names
+// (e.g., `apps`, `rbac`, or `core`) or as in `apiVersion`s (e.g.,
+// `rbac.authorization.k8s.io`).
+type GroupVersion struct {
+	Group   GroupName
+	Version VersionString
+}
+
+// ParseGroupVersion parses a `GroupVersion` from `group/version`.
+func ParseGroupVersion(text string) (GroupVersion, error) {
+	split := strings.Split(text, "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return GroupVersion{}, fmt.Errorf(
+			"Could not parse group/version '%s'; expected e.g. 'apps/v1beta2'", text)
+	}
+	return GroupVersion{Group: GroupName(split[0]), Version: VersionString(split[1])}, nil
+}
+
+func (gv GroupVersion) String() string {
+	return fmt.Sprintf("%s/%s", gv.Group, gv.Version)
+}
+
+// MarshalText writes the group/version as e.g. `apps/v1beta2`.
+func (gv GroupVersion) MarshalText() ([]byte, error) {
+	return []byte(gv.String()), nil
+}
+
+// UnmarshalText parses the group/version with `ParseGroupVersion`.
+func (gv *GroupVersion) UnmarshalText(text []byte) error {
+	parsed, err := ParseGroupVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*gv = parsed
+	return nil
+}
@@ -0,0 +1,56 @@
+package kubespec
+
+import "testing"
+
+func TestVersionStringParse(t *testing.T) {
+	tests := []struct {
+		version  VersionString
+		expected APIVersion
+		ok       bool
+	}{
+		{"v1", APIVersion{Major: 1, Stage: StageGA}, true},
+		{"v1beta1", APIVersion{Major: 1, Stage: StageBeta, Minor: 1}, true},
+		{"v2alpha1", APIVersion{Major: 2, Stage: StageAlpha, Minor: 1}, true},
+		{"v1beta", APIVersion{}, false},
+		{"intstr", APIVersion{}, false},
+		{"", APIVersion{}, false},
+	}
+	for _, test := range tests {
+		actual, ok := test.version.Parse()
+		if actual != test.expected || ok != test.ok {
+			t.Errorf(
+				"Expected '%s' to parse to %+v (%t) got %+v (%t)",
+				test.version, test.expected, test.ok, actual, ok)
+		}
+	}
+}
+
+func TestVersionStringStage(t *testing.T) {
+	expected := map[VersionString]VersionStage{
+		"v1":       StageGA,
+		"v1beta1":  StageBeta,
+		"v2alpha1": StageAlpha,
+		"v1alpha1": StageAlpha,
+		"intstr":   StageUnknown,
+	}
+	for version, stage := range expected {
+		if actual := version.Stage(); actual != stage {
+			t.Errorf("Expected '%s' to be in stage '%s' got '%s'", version, stage, actual)
+		}
+	}
+}
+
+func TestParseGroupVersion(t *testing.T) {
+	gv, err := ParseGroupVersion("apps/v1beta2")
+	if err != nil || gv != (GroupVersion{Group: "apps", Version: "v1beta2"}) {
+		t.Errorf("Expected 'apps/v1beta2' to parse, got %+v (%v)", gv, err)
+	}
+	if gv.String() != "apps/v1beta2" {
+		t.Errorf("Expected 'apps/v1beta2' got '%s'", gv)
+	}
+	for _, text := range []string{"apps", "apps/", "/v1", "apps/v1/Deployment", ""} {
+		if _, err := ParseGroupVersion(text); err == nil {
+			t.Errorf("Expected parsing '%s' to fail", text)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package kubespec
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+// UnmarshalSpec deserializes a spec written as either JSON or YAML.
+// `name` is where the spec came from, e.g., its file name or URL: a
+// `.json`, `.yaml`, or `.yml` extension decides the format, and
+// otherwise a spec that starts with `{` is JSON, and any other is
+// YAML. YAML is converted to JSON first (see `yamljson.ToJSON`), which
+// becomes the spec's `Text`, so that the spec reads the same whichever
+// it was written in. Errors are of `ErrSpecLoad`.
+func UnmarshalSpec(name string, text []byte) (_ *APISpec, err error) {
+	defer func() { err = Categorize(ErrSpecLoad, err) }()
+	if isYAML(name, text) {
+		converted, err := yamljson.ToJSON(text)
+		if err != nil {
+			return nil, err
+		}
+		text = converted
+	}
+
+	s := APISpec{}
+	if err := json.Unmarshal(text, &s); err != nil {
+		return nil, err
+	}
+	s.Text = text
+	return &s, nil
+}
+
+// isYAML reports whether the spec called `name` is written as YAML.
+func isYAML(name string, text []byte) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return false
+	case ".yaml", ".yml":
+		return true
+	}
+	return !bytes.HasPrefix(bytes.TrimLeft(text, " \t\r\n\ufeff"), []byte("{"))
+}
@@ -0,0 +1,109 @@
+package kubespec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlSpec = `# The JSON spec below, as YAML.
+swagger: "2.0"
+info: {title: Kubernetes, version: v1.7.0}
+paths: {}
+definitions:
+  io.k8s.kubernetes.pkg.api.v1.Pod:
+    description: >-
+      Pod is a collection of containers
+      that can run on a host.
+    properties:
+      spec:
+        $ref: '#/definitions/io.k8s.kubernetes.pkg.api.v1.PodSpec'
+    x-kubernetes-group-version-kind:
+    - Group: ""
+      Kind: Pod
+      Version: v1
+  io.k8s.kubernetes.pkg.api.v1.PodSpec:
+    required: [containers]
+    properties:
+      activeDeadlineSeconds:
+        type: integer
+        format: int64
+        example: 12345678901234567890
+      containers:
+        type: array
+        items: {type: string}
+`
+
+const jsonSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.7.0"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.kubernetes.pkg.api.v1.Pod": {
+      "description": "Pod is a collection of containers that can run on a host.",
+      "properties": {
+        "spec": {"$ref": "#/definitions/io.k8s.kubernetes.pkg.api.v1.PodSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"Group": "", "Kind": "Pod", "Version": "v1"}]
+    },
+    "io.k8s.kubernetes.pkg.api.v1.PodSpec": {
+      "required": ["containers"],
+      "properties": {
+        "activeDeadlineSeconds": {"type": "integer", "format": "int64", "example": 12345678901234567890},
+        "containers": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func TestUnmarshalSpec(t *testing.T) {
+	fromJSON, err := UnmarshalSpec("swagger.json", []byte(jsonSpec))
+	if err != nil {
+		t.Fatalf("Failed to unmarshal JSON spec:\n%v", err)
+	}
+	if string(fromJSON.Text) != jsonSpec {
+		t.Errorf("Expected the text of a JSON spec to be kept")
+	}
+
+	// YAML is recognized by extension, or by not starting with `{`.
+	for _, name := range []string{"swagger.yaml", "swagger.YML", "openapi", ""} {
+		fromYAML, err := UnmarshalSpec(name, []byte(yamlSpec))
+		if err != nil {
+			t.Fatalf("Failed to unmarshal YAML spec '%s':\n%v", name, err)
+		}
+		// Extensions keep their raw JSON, which is spaced differently.
+		if !reflect.DeepEqual(marshalDefinitions(t, fromYAML), marshalDefinitions(t, fromJSON)) ||
+			!reflect.DeepEqual(fromYAML.Info, fromJSON.Info) {
+			t.Errorf("Expected YAML spec '%s' to match the JSON one", name)
+		}
+		if !strings.HasPrefix(string(fromYAML.Text), `{"swagger":"2.0",`) {
+			t.Errorf("Expected the text of YAML spec '%s' to be JSON, got:\n%s", name, fromYAML.Text)
+		}
+	}
+
+	// Numbers keep every digit.
+	fromYAML, _ := UnmarshalSpec("swagger.yaml", []byte(yamlSpec))
+	example := fromYAML.Definitions["io.k8s.kubernetes.pkg.api.v1.PodSpec"].
+		Properties["activeDeadlineSeconds"].Example
+	if example != json.Number("12345678901234567890") {
+		t.Errorf("Expected the example to keep its digits, got %v", example)
+	}
+
+	// The extension wins over sniffing.
+	if _, err := UnmarshalSpec("swagger.json", []byte(yamlSpec)); err == nil {
+		t.Errorf("Expected YAML named '.json' to be an error")
+	}
+	_, err = UnmarshalSpec("swagger.yaml", []byte("swagger: '2.0'\ninfo: {}\nswagger: '3.0'\n"))
+	if err == nil || err.Error() != "line 3: key 'swagger' is repeated (it's first set on line 1)" {
+		t.Errorf("Expected an error naming the repeated key, got %v", err)
+	}
+}
+
+func marshalDefinitions(t *testing.T, s *APISpec) string {
+	text, err := json.Marshal(s.Definitions)
+	if err != nil {
+		t.Fatalf("Failed to marshal definitions:\n%v", err)
+	}
+	return string(text)
+}
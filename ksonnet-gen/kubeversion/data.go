@@ -1,33 +1,334 @@
 package kubeversion
 
+import (
+	"fmt"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
 //-----------------------------------------------------------------------------
 // Kubernetes version-specific data for customizing code that's
 // emitted.
 //-----------------------------------------------------------------------------
 
+// initialismOverrides maps identifiers whose Go-initialism style
+// normalization would be wrong, or inconsistent with their field name,
+// to the identifier to use. They are the same for every version.
+var initialismOverrides = map[string]string{
+	"CephFSPersistentVolumeSource": "cephfsPersistentVolumeSource",
+	"CephFSVolumeSource":           "cephfsVolumeSource",
+}
+
+// idAliases maps identifiers to Jsonnet-appropriate identifiers. They
+// are the same for every version.
+var idAliases = map[string]string{
+	"hostIPC":                        "hostIpc",
+	"hostPID":                        "hostPid",
+	"targetCPUUtilizationPercentage": "targetCpuUtilizationPercentage",
+	"externalID":                     "externalId",
+	"podCIDR":                        "podCidr",
+	"providerID":                     "providerId",
+	"bootID":                         "bootId",
+	"machineID":                      "machineId",
+	"systemUUID":                     "systemUuid",
+	"volumeID":                       "volumeId",
+	"diskURI":                        "diskUri",
+	"targetWWNs":                     "targetWwns",
+	"datasetUUID":                    "datasetUuid",
+	"pdID":                           "pdId",
+	"scaleIO":                        "scaleIo",
+	"podIP":                          "podIp",
+	"hostIP":                         "hostIp",
+	"clusterIP":                      "clusterIp",
+	"externalIPs":                    "externalIps",
+	"loadBalancerIP":                 "loadBalancerIp",
+}
+
+// int64StringProperties are the `int64` properties whose values may be
+// too large for a Jsonnet number (a double) to hold exactly, e.g.,
+// resource versions and generations that CRDs declare as integers, so
+// their setters also accept strings. They are the same for every
+// version.
+var int64StringProperties = newPropertySet(
+	"generation", "observedGeneration", "resourceVersion", "revision",
+)
+
+// stringifiedProperties are the maps of strings, by the kind of their
+// definition, whose values people most often pass as numbers or
+// booleans, which the server rejects: ConfigMap data, a Secret's
+// `stringData` (its `data` is base64, so its values can't be
+// converted), and annotations. With `--emit-stringified-setters`,
+// their setters get a variant that converts the values to strings.
+// They are the same for every version.
+var stringifiedProperties = map[string]propertySet{
+	"ConfigMap":  newPropertySet("data"),
+	"Secret":     newPropertySet("stringData"),
+	"ObjectMeta": newPropertySet("annotations"),
+}
+
+// recommendedLabels are the labels Kubernetes recommends giving every
+// object, which are the same for every version. `recommendedLabels`
+// takes their parameters in this order.
+var recommendedLabels = []WellKnownKey{
+	{Name: "appName", Key: "app.kubernetes.io/name", Param: "name", Required: true, Selector: true,
+		Description: "The name of the application, e.g., `mysql`."},
+	{Name: "appInstance", Key: "app.kubernetes.io/instance", Param: "instance", Selector: true,
+		Description: "A unique name identifying the instance of the application, e.g., `mysql-abcxzy`."},
+	{Name: "appVersion", Key: "app.kubernetes.io/version", Param: "version",
+		Description: "The current version of the application, e.g., `5.7.21`."},
+	{Name: "appComponent", Key: "app.kubernetes.io/component", Param: "component",
+		Description: "The component within the architecture, e.g., `database`."},
+	{Name: "appPartOf", Key: "app.kubernetes.io/part-of", Param: "partOf",
+		Description: "The name of a higher level application this one is part of, e.g., `wordpress`."},
+	{Name: "appManagedBy", Key: "app.kubernetes.io/managed-by", Param: "managedBy",
+		Description: "The tool being used to manage the operation of the application, e.g., `ksonnet`."},
+}
+
+// nodeLabels are the labels the kubelet and cloud providers set on
+// nodes, which are commonly used in node selectors.
+var nodeLabels = []WellKnownKey{
+	{Name: "hostname", Key: "kubernetes.io/hostname",
+		Description: "The hostname of the node."},
+	{Name: "betaOs", Key: "beta.kubernetes.io/os",
+		Description: "The operating system of the node, e.g., `linux`."},
+	{Name: "betaArch", Key: "beta.kubernetes.io/arch",
+		Description: "The architecture of the node, e.g., `amd64`."},
+	{Name: "betaInstanceType", Key: "beta.kubernetes.io/instance-type",
+		Description: "The cloud provider's instance type of the node, e.g., `m3.medium`."},
+	{Name: "failureDomainZone", Key: "failure-domain.beta.kubernetes.io/zone",
+		Description: "The cloud provider's zone of the node, e.g., `us-east-1c`."},
+	{Name: "failureDomainRegion", Key: "failure-domain.beta.kubernetes.io/region",
+		Description: "The cloud provider's region of the node, e.g., `us-east-1`."},
+}
+
+// annotations are the well-known annotations that exist in every
+// version.
+var annotations = []WellKnownKey{
+	{Name: "lastAppliedConfiguration", Key: "kubectl.kubernetes.io/last-applied-configuration",
+		Description: "The configuration `kubectl apply` last applied to the object."},
+	{Name: "changeCause", Key: "kubernetes.io/change-cause",
+		Description: "Why the object was last changed, shown in rollout histories."},
+	{Name: "deploymentRevision", Key: "deployment.kubernetes.io/revision",
+		Description: "The revision of a deployment that a replica set belongs to."},
+	{Name: "criticalPod", Key: "scheduler.alpha.kubernetes.io/critical-pod",
+		Description: "Marks a pod as critical, so that it's rescheduled if evicted."},
+	{Name: "isDefaultStorageClass", Key: "storageclass.kubernetes.io/is-default-class",
+		Description: "Marks a storage class as the default for claims that don't name one."},
+}
+
+// removals are the top-level kinds that Kubernetes stops serving in
+// some release, with the apiVersion that replaces them; see
+// `ScheduledRemoval`. They are the same for every version.
+var removals = newRemovals(
+	// The workloads of `extensions/v1beta1` and the beta versions of
+	// `apps` moved to `apps/v1`.
+	Removal{APIVersion: "extensions/v1beta1", Kind: "DaemonSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "extensions/v1beta1", Kind: "Deployment", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "extensions/v1beta1", Kind: "ReplicaSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta1", Kind: "ControllerRevision", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta1", Kind: "Deployment", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta1", Kind: "StatefulSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta2", Kind: "ControllerRevision", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta2", Kind: "DaemonSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta2", Kind: "Deployment", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta2", Kind: "ReplicaSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+	Removal{APIVersion: "apps/v1beta2", Kind: "StatefulSet", RemovedIn: "v1.16", Replacement: "apps/v1"},
+
+	// The rest of `extensions/v1beta1` moved to the groups of their
+	// own.
+	Removal{APIVersion: "extensions/v1beta1", Kind: "NetworkPolicy", RemovedIn: "v1.16", Replacement: "networking.k8s.io/v1"},
+	Removal{APIVersion: "extensions/v1beta1", Kind: "PodSecurityPolicy", RemovedIn: "v1.16", Replacement: "policy/v1beta1"},
+	Removal{APIVersion: "extensions/v1beta1", Kind: "Ingress", RemovedIn: "v1.22", Replacement: "networking.k8s.io/v1"},
+)
+
+// ksonnet0Kinds are the top-level kinds whose mixins apps written
+// against ksonnet 0.x call through its hidden helpers, e.g.,
+// `deployment.mixin.spec.mixinInstance(spec)`.
+var ksonnet0Kinds = []string{
+	"ConfigMap", "DaemonSet", "Deployment", "Ingress", "ReplicaSet", "Secret", "Service", "StatefulSet",
+}
+
+// ksonnet0Helpers are the hidden helpers of the mixin namespaces of
+// ksonnet 0.x's libraries that `--compat ksonnet-0.x` emits; see
+// `LegacyHelpers`. They're the same for every version. Drop a helper,
+// or a kind, once no app needs it.
+var ksonnet0Helpers = []LegacyHelper{
+	{Name: "mixinInstance", Kinds: ksonnet0Kinds},
+	{Name: "__%sMixin", Kinds: ksonnet0Kinds},
+}
+
+// serviceAccountShim is the shim of the `serviceAccount` of the
+// `PodSpec` of `core`, a deprecated alias of `serviceAccountName`,
+// which the server copies into it.
+func serviceAccountShim(core string) DeprecatedShim {
+	return DeprecatedShim{
+		Definition:  kubespec.DefinitionName(core + "PodSpec"),
+		Name:        "serviceAccount",
+		Replacement: "serviceAccountName",
+	}
+}
+
+// eventShims are the shims of the fields of core `Event`s that the
+// `Event`s of `events.k8s.io`, new in 1.8, renamed with a `deprecated`
+// prefix, e.g., `source` to `deprecatedSource`.
+var eventShims = []DeprecatedShim{
+	{Definition: "io.k8s.api.events.v1beta1.Event", Name: "count", Replacement: "deprecatedCount"},
+	{Definition: "io.k8s.api.events.v1beta1.Event", Name: "firstTimestamp", Replacement: "deprecatedFirstTimestamp"},
+	{Definition: "io.k8s.api.events.v1beta1.Event", Name: "lastTimestamp", Replacement: "deprecatedLastTimestamp"},
+	{Definition: "io.k8s.api.events.v1beta1.Event", Name: "source", Replacement: "deprecatedSource"},
+}
+
+// exampleFields are the fields the example of each top-level kind's
+// namespace sets after calling its constructor, by kind: the one or
+// two its users most often set, as dotted paths of the names the spec
+// gives them. They are the same for every version; a field a version's
+// schema doesn't have is left out. See `ExampleFields`.
+var exampleFields = map[string][]string{
+	"ConfigMap":   {"metadata.name", "data"},
+	"CronJob":     {"spec.concurrencyPolicy", "spec.successfulJobsHistoryLimit"},
+	"DaemonSet":   {"metadata.name", "spec.template.spec.containers"},
+	"Deployment":  {"spec.replicas", "spec.template.spec.containers"},
+	"Ingress":     {"spec.rules", "spec.tls"},
+	"Job":         {"spec.backoffLimit", "spec.parallelism"},
+	"Namespace":   {"metadata.name", "metadata.labels"},
+	"Pod":         {"spec.containers", "spec.restartPolicy"},
+	"ReplicaSet":  {"spec.replicas", "spec.template.spec.containers"},
+	"Secret":      {"metadata.name", "stringData"},
+	"Service":     {"spec.selector", "spec.ports"},
+	"StatefulSet": {"spec.replicas", "spec.template.spec.containers"},
+}
+
+// securityPresets are the hardening defaults of the pods of a version
+// whose core definitions are named with `core` (e.g.,
+// `io.k8s.api.core.v1.`), keyed by what they're for. The containers'
+// fields are passed in, since they differ between versions. Neither
+// version has `seccompProfile`, so the seccomp profile is set with the
+// alpha annotation of the pod, `seccompProfile` (e.g.,
+// `runtime/default`), on a `PodTemplateSpec`.
+func securityPresets(core, seccompProfile string, containerFields ...PresetField) []Preset {
+	return []Preset{
+		{
+			Name:        "securityHardened",
+			Description: "Makes a pod spec run as a non-root user, and hardens each of the containers it has so far with `securityHardenedContainer`.",
+			Definition:  kubespec.DefinitionName(core + "PodSpec"),
+			Fields: []PresetField{
+				{Path: "securityContext.runAsNonRoot", Value: "true"},
+				{Path: "containers", Each: "securityHardenedContainer"},
+			},
+		},
+		{
+			Name:        "securityHardenedContainer",
+			Description: "Makes a container run as a non-root user, with a read-only root filesystem, and without privileges or capabilities.",
+			Definition:  kubespec.DefinitionName(core + "Container"),
+			Fields:      containerFields,
+		},
+		{
+			Name:        "seccompRuntimeDefault",
+			Description: "Gives a pod template the default seccomp profile of the container runtime.",
+			Definition:  kubespec.DefinitionName(core + "PodTemplateSpec"),
+			Fields: []PresetField{{
+				Path:  "metadata.annotations",
+				Value: fmt.Sprintf(`{"seccomp.security.alpha.kubernetes.io/pod": "%s"}`, seccompProfile),
+			}},
+		},
+		{
+			Name:        "minimalServiceAccount",
+			Description: "Keeps the token of a service account from being mounted into its pods.",
+			Definition:  kubespec.DefinitionName(core + "ServiceAccount"),
+			Fields:      []PresetField{{Path: "automountServiceAccountToken", Value: "false"}},
+		},
+	}
+}
+
+// extensionPoints are the extension points of the pods of a version
+// whose core definitions are named with `core` (e.g.,
+// `io.k8s.api.core.v1.`), in order of name: custom resources that
+// manage a secret, config map, or claim of their own name (e.g., a
+// `SealedSecret`, or a `Certificate` whose secret has its name) are
+// volumes, environment variables, and sources of them.
+func extensionPoints(core string) []ExtensionPoint {
+	return []ExtensionPoint{
+		{
+			Name:        "envFromSourcesExt",
+			Definition:  kubespec.DefinitionName(core + "EnvFromSource"),
+			Description: "Sources of the environment of containers that custom resources manage, filled by the `coreExtensions.libsonnet` of their library.",
+			Params:      []string{"resource"},
+			Sources: map[string]string{
+				"ConfigMap": "{configMapRef: {name: ref}}",
+				"Secret":    "{secretRef: {name: ref}}",
+			},
+		},
+		{
+			Name:        "envSourcesExt",
+			Definition:  kubespec.DefinitionName(core + "EnvVar"),
+			Description: "Environment variables from keys of objects that custom resources manage, filled by the `coreExtensions.libsonnet` of their library.",
+			Params:      []string{"name", "resource", "key"},
+			Sources: map[string]string{
+				"ConfigMap": "{name: name, valueFrom: {configMapKeyRef: {name: ref, key: key}}}",
+				"Secret":    "{name: name, valueFrom: {secretKeyRef: {name: ref, key: key}}}",
+			},
+		},
+		{
+			Name:        "volumeSourcesExt",
+			Definition:  kubespec.DefinitionName(core + "Volume"),
+			Description: "Volumes of objects that custom resources manage, filled by the `coreExtensions.libsonnet` of their library.",
+			Params:      []string{"name", "resource"},
+			Sources: map[string]string{
+				"ConfigMap":             "{name: name, configMap: {name: ref}}",
+				"PersistentVolumeClaim": "{name: name, persistentVolumeClaim: {claimName: ref}}",
+				"Secret":                "{name: name, secret: {secretName: ref}}",
+			},
+		},
+	}
+}
+
+func concatKeys(keys ...[]WellKnownKey) []WellKnownKey {
+	concatenated := []WellKnownKey{}
+	for _, k := range keys {
+		concatenated = append(concatenated, k...)
+	}
+	return concatenated
+}
+
 var versions = map[string]versionData{
 	"v1.7.0": versionData{
-		idAliases: map[string]string{
-			"hostIPC":                        "hostIpc",
-			"hostPID":                        "hostPid",
-			"targetCPUUtilizationPercentage": "targetCpuUtilizationPercentage",
-			"externalID":                     "externalId",
-			"podCIDR":                        "podCidr",
-			"providerID":                     "providerId",
-			"bootID":                         "bootId",
-			"machineID":                      "machineId",
-			"systemUUID":                     "systemUuid",
-			"volumeID":                       "volumeId",
-			"diskURI":                        "diskUri",
-			"targetWWNs":                     "targetWwns",
-			"datasetUUID":                    "datasetUuid",
-			"pdID":                           "pdId",
-			"scaleIO":                        "scaleIo",
-			"podIP":                          "podIp",
-			"hostIP":                         "hostIp",
-			"clusterIP":                      "clusterIp",
-			"externalIPs":                    "externalIps",
-			"loadBalancerIP":                 "loadBalancerIp",
+		layout:                LayoutKubernetesPkg,
+		idAliases:             idAliases,
+		initialismOverrides:   initialismOverrides,
+		int64StringProperties: int64StringProperties,
+		stringifiedProperties: stringifiedProperties,
+		wellKnownLabels:       concatKeys(recommendedLabels, nodeLabels),
+		removals:              removals,
+		legacyHelpers:         ksonnet0Helpers,
+		exampleFields:         exampleFields,
+		deprecatedShims:       newDeprecatedShims(serviceAccountShim("io.k8s.kubernetes.pkg.api.v1.")),
+		extensionPoints:       extensionPoints("io.k8s.kubernetes.pkg.api.v1."),
+		// `allowPrivilegeEscalation` and the `runtime/default` seccomp
+		// profile are new in 1.8.
+		presets: securityPresets("io.k8s.kubernetes.pkg.api.v1.", "docker/default",
+			PresetField{Path: "securityContext.runAsNonRoot", Value: "true"},
+			PresetField{Path: "securityContext.readOnlyRootFilesystem", Value: "true"},
+			PresetField{Path: "securityContext.privileged", Value: "false"},
+			PresetField{Path: "securityContext.capabilities.drop", Value: `["ALL"]`},
+		),
+		wellKnownAnnotations: concatKeys(annotations, []WellKnownKey{
+			// Replaced by `initContainers` in 1.8.
+			{Name: "betaInitContainers", Key: "pod.beta.kubernetes.io/init-containers",
+				Description: "The init containers of a pod, as JSON. Deprecated in favor of `spec.initContainers`."},
+		}),
+		propertyTypes: map[string]map[string]PropertyType{
+			// Secret data is base64, which users encode themselves, and
+			// then set as a whole; merging keys in is a `dataMixin`.
+			"io.k8s.kubernetes.pkg.api.v1.Secret": {"data": PropertyOpaque},
+
+			// Subsets of the spec often leave out `intstr.IntOrString`,
+			// which would leave the port untyped.
+			"io.k8s.kubernetes.pkg.api.v1.HTTPGetAction": {"port": PropertyIntOrString},
+		},
+		preferredGroups: map[string]string{
+			"Deployment":    "apps",
+			"NetworkPolicy": "networking",
+			"Scale":         "autoscaling",
 		},
 		propertyBlacklist: map[string]propertySet{
 			// Metadata fields.
@@ -139,4 +440,104 @@ var versions = map[string]versionData{
 			"io.k8s.kubernetes.pkg.apis.extensions.v1beta1.DaemonSetSpec": newPropertySet("templateGeneration"),
 		},
 	},
+	"v1.9.0": versionData{
+		layout:                LayoutAPI,
+		idAliases:             idAliases,
+		initialismOverrides:   initialismOverrides,
+		int64StringProperties: int64StringProperties,
+		stringifiedProperties: stringifiedProperties,
+		wellKnownLabels:       concatKeys(recommendedLabels, nodeLabels),
+		wellKnownAnnotations:  annotations,
+		removals:              removals,
+		legacyHelpers:         ksonnet0Helpers,
+		exampleFields:         exampleFields,
+		extensionPoints:       extensionPoints("io.k8s.api.core.v1."),
+		deprecatedShims: newDeprecatedShims(append([]DeprecatedShim{
+			serviceAccountShim("io.k8s.api.core.v1."),
+			// The `ExternalAdmissionHookConfiguration`s of 1.7 are the
+			// `ValidatingWebhookConfiguration`s of 1.9, whose hooks are
+			// its `webhooks`.
+			{
+				Definition:  "io.k8s.api.admissionregistration.v1beta1.ValidatingWebhookConfiguration",
+				Name:        "externalAdmissionHooks",
+				Replacement: "webhooks",
+			},
+		}, eventShims...)...),
+		presets: securityPresets("io.k8s.api.core.v1.", "runtime/default",
+			PresetField{Path: "securityContext.runAsNonRoot", Value: "true"},
+			PresetField{Path: "securityContext.readOnlyRootFilesystem", Value: "true"},
+			PresetField{Path: "securityContext.allowPrivilegeEscalation", Value: "false"},
+			PresetField{Path: "securityContext.privileged", Value: "false"},
+			PresetField{Path: "securityContext.capabilities.drop", Value: `["ALL"]`},
+		),
+		propertyTypes: map[string]map[string]PropertyType{
+			// See v1.7.0.
+			"io.k8s.api.core.v1.Secret":        {"data": PropertyOpaque},
+			"io.k8s.api.core.v1.HTTPGetAction": {"port": PropertyIntOrString},
+		},
+		preferredGroups: map[string]string{
+			"DaemonSet":     "apps",
+			"Deployment":    "apps",
+			"Event":         "core",
+			"Ingress":       "extensions",
+			"NetworkPolicy": "networking",
+			"ReplicaSet":    "apps",
+			"Scale":         "autoscaling",
+		},
+		propertyBlacklist: map[string]propertySet{
+			// Metadata fields.
+			"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": newPropertySet(
+				"creationTimestamp", "deletionTimestamp", "generation",
+				"ownerReferences", "resourceVersion", "selfLink", "uid",
+			),
+
+			// Fields whose types are
+			// `io.k8s.apimachinery.pkg.apis.meta.v1.ListMeta`.
+			"io.k8s.api.apps.v1.DaemonSetList":                      newPropertySet("metadata"),
+			"io.k8s.api.apps.v1.DeploymentList":                     newPropertySet("metadata"),
+			"io.k8s.api.apps.v1.ReplicaSetList":                     newPropertySet("metadata"),
+			"io.k8s.api.apps.v1.StatefulSetList":                    newPropertySet("metadata"),
+			"io.k8s.api.batch.v1.JobList":                           newPropertySet("metadata"),
+			"io.k8s.api.batch.v1beta1.CronJobList":                  newPropertySet("metadata"),
+			"io.k8s.api.core.v1.ConfigMapList":                      newPropertySet("metadata"),
+			"io.k8s.api.core.v1.NamespaceList":                      newPropertySet("metadata"),
+			"io.k8s.api.core.v1.NodeList":                           newPropertySet("metadata"),
+			"io.k8s.api.core.v1.PersistentVolumeClaimList":          newPropertySet("metadata"),
+			"io.k8s.api.core.v1.PersistentVolumeList":               newPropertySet("metadata"),
+			"io.k8s.api.core.v1.PodList":                            newPropertySet("metadata"),
+			"io.k8s.api.core.v1.SecretList":                         newPropertySet("metadata"),
+			"io.k8s.api.core.v1.ServiceList":                        newPropertySet("metadata"),
+			"io.k8s.api.extensions.v1beta1.IngressList":             newPropertySet("metadata"),
+			"io.k8s.api.networking.v1.NetworkPolicyList":            newPropertySet("metadata"),
+			"io.k8s.api.rbac.v1.ClusterRoleBindingList":             newPropertySet("metadata"),
+			"io.k8s.api.rbac.v1.ClusterRoleList":                    newPropertySet("metadata"),
+			"io.k8s.api.rbac.v1.RoleBindingList":                    newPropertySet("metadata"),
+			"io.k8s.api.rbac.v1.RoleList":                           newPropertySet("metadata"),
+			"io.k8s.api.storage.v1.StorageClassList":                newPropertySet("metadata"),
+			"io.k8s.api.autoscaling.v1.HorizontalPodAutoscalerList": newPropertySet("metadata"),
+
+			// Status fields.
+			"io.k8s.api.apps.v1.DaemonSet":                      newPropertySet("status"),
+			"io.k8s.api.apps.v1.Deployment":                     newPropertySet("status"),
+			"io.k8s.api.apps.v1.ReplicaSet":                     newPropertySet("status"),
+			"io.k8s.api.apps.v1.StatefulSet":                    newPropertySet("status"),
+			"io.k8s.api.autoscaling.v1.HorizontalPodAutoscaler": newPropertySet("status"),
+			"io.k8s.api.batch.v1.Job":                           newPropertySet("status"),
+			"io.k8s.api.batch.v1beta1.CronJob":                  newPropertySet("status"),
+			"io.k8s.api.core.v1.Namespace":                      newPropertySet("status"),
+			"io.k8s.api.core.v1.Node":                           newPropertySet("status"),
+			"io.k8s.api.core.v1.PersistentVolume":               newPropertySet("status"),
+			"io.k8s.api.core.v1.PersistentVolumeClaim":          newPropertySet("status"),
+			"io.k8s.api.core.v1.Pod":                            newPropertySet("status"),
+			"io.k8s.api.core.v1.ReplicationController":          newPropertySet("status"),
+			"io.k8s.api.core.v1.ResourceQuota":                  newPropertySet("status"),
+			"io.k8s.api.core.v1.Service":                        newPropertySet("status"),
+			"io.k8s.api.extensions.v1beta1.Ingress":             newPropertySet("status"),
+			"io.k8s.api.policy.v1beta1.PodDisruptionBudget":     newPropertySet("status"),
+
+			// Has both status and a property with type
+			// `io.k8s.apimachinery.pkg.apis.meta.v1.ListMeta`.
+			"io.k8s.apimachinery.pkg.apis.meta.v1.Status": newPropertySet("status", "metadata"),
+		},
+	},
 }
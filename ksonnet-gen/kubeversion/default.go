@@ -0,0 +1,257 @@
+package kubeversion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+//-----------------------------------------------------------------------------
+// Data passed to generation.
+//-----------------------------------------------------------------------------
+
+// Data is the version-specific data that generation is customized with,
+// for each supported version of Kubernetes. It's never modified once
+// it's made (`WithOverrides` returns a copy), so one Data may be shared
+// by any number of generations running at once, and generations with
+// different Data don't affect each other.
+type Data struct {
+	versions map[string]versionData
+}
+
+// bundled is what `Default` returns. Nothing writes to it, or to the
+// tables in `data.go` it's made of.
+var bundled = &Data{versions: versions}
+
+// Default returns the data bundled with this package, which the
+// package-level functions (e.g., `MapIdentifier`) use.
+func Default() *Data {
+	return bundled
+}
+
+// Overrides are additions to the data of one version of Kubernetes,
+// e.g., to rename a property that a cluster's CRDs add, or to leave out
+// one of their definitions. Entries replace the bundled ones they
+// collide with.
+type Overrides struct {
+	// IDAliases maps identifiers to the Jsonnet identifiers they're
+	// rewritten as (e.g., `hostIPC` -> `hostIpc`); see `MapIdentifier`.
+	IDAliases map[string]string
+
+	// BlacklistedProperties maps definition names to the properties of
+	// them that aren't emitted, and BlacklistedDefinitions lists the
+	// definitions that aren't emitted at all.
+	BlacklistedProperties  map[string][]string
+	BlacklistedDefinitions []string
+
+	// PreferredGroups maps kinds to the group that gets their
+	// unqualified alias; see `PreferredGroup`.
+	PreferredGroups map[string]string
+
+	// PropertyTypes maps definition names to the paths of their
+	// properties whose types are forced; see `PropertyTypes`.
+	PropertyTypes map[string]map[string]PropertyType
+}
+
+// WithOverrides returns a copy of the data, in which the data of the
+// supported version that `k8sVersion` belongs to (see `Lookup`) has the
+// additions of `overrides`, whose definition names are trimmed (see
+// `kubespec.TrimName`). It's an error if the version isn't supported,
+// or if a property is forced to an unknown type.
+func (d *Data) WithOverrides(k8sVersion string, overrides Overrides) (*Data, error) {
+	key, ok := d.lookupKey(k8sVersion)
+	if !ok {
+		return nil, fmt.Errorf(
+			"Unsupported Kubernetes version '%s'; supported versions are %s",
+			k8sVersion, d.supportedList())
+	}
+	for definition, paths := range overrides.PropertyTypes {
+		for path, t := range paths {
+			if !t.IsKnown() {
+				return nil, fmt.Errorf(
+					"Can't force property '%s' of '%s' to the unknown type '%s'; types are %s",
+					path, definition, t, propertyTypeList())
+			}
+		}
+	}
+
+	copied := &Data{versions: map[string]versionData{}}
+	for version, data := range d.versions {
+		copied.versions[version] = data
+	}
+	data := d.versions[key]
+
+	data.idAliases = mergeStrings(data.idAliases, overrides.IDAliases)
+	data.preferredGroups = mergeStrings(data.preferredGroups, overrides.PreferredGroups)
+
+	propertyBlacklist := map[string]propertySet{}
+	for definition, properties := range data.propertyBlacklist {
+		propertyBlacklist[definition] = properties
+	}
+	for definition, properties := range overrides.BlacklistedProperties {
+		definition = kubespec.TrimName(definition)
+		merged := newPropertySet(properties...)
+		for property := range propertyBlacklist[definition] {
+			merged[property] = true
+		}
+		propertyBlacklist[definition] = merged
+	}
+	data.propertyBlacklist = propertyBlacklist
+
+	definitionBlacklist := propertySet{}
+	for _, definition := range overrides.BlacklistedDefinitions {
+		definitionBlacklist[kubespec.TrimName(definition)] = true
+	}
+	for definition := range data.definitionBlacklist {
+		definitionBlacklist[definition] = true
+	}
+	data.definitionBlacklist = definitionBlacklist
+
+	propertyTypes := map[string]map[string]PropertyType{}
+	for definition, paths := range data.propertyTypes {
+		propertyTypes[definition] = paths
+	}
+	for definition, paths := range overrides.PropertyTypes {
+		definition = kubespec.TrimName(definition)
+		merged := map[string]PropertyType{}
+		for path, t := range propertyTypes[definition] {
+			merged[path] = t
+		}
+		for path, t := range paths {
+			merged[path] = t
+		}
+		propertyTypes[definition] = merged
+	}
+	data.propertyTypes = propertyTypes
+
+	copied.versions[key] = data
+	return copied, nil
+}
+
+// `propertyTypeList` lists the `PropertyType`s for errors.
+func propertyTypeList() string {
+	names := []string{}
+	for _, t := range propertyTypeNames {
+		names = append(names, string(t))
+	}
+	return strings.Join(names, ", ")
+}
+
+// `mergeStrings` returns a new map with the entries of `base`, and then
+// those of `overrides`.
+func mergeStrings(base, overrides map[string]string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+//-----------------------------------------------------------------------------
+// The bundled data.
+//-----------------------------------------------------------------------------
+
+// Supported is `Default().Supported`.
+func Supported() []VersionInfo {
+	return Default().Supported()
+}
+
+// Lookup is `Default().Lookup`.
+func Lookup(version string) (VersionInfo, error) {
+	return Default().Lookup(version)
+}
+
+// MapIdentifier is `Default().MapIdentifier`.
+func MapIdentifier(k8sVersion, id string) string {
+	return Default().MapIdentifier(k8sVersion, id)
+}
+
+// MapInitialism is `Default().MapInitialism`.
+func MapInitialism(k8sVersion, id string) (string, bool) {
+	return Default().MapInitialism(k8sVersion, id)
+}
+
+// IsBlacklistedProperty is `Default().IsBlacklistedProperty`.
+func IsBlacklistedProperty(
+	k8sVersion string, path kubespec.DefinitionName,
+	propertyName kubespec.PropertyName,
+) bool {
+	return Default().IsBlacklistedProperty(k8sVersion, path, propertyName)
+}
+
+// IsBlacklistedDefinition is `Default().IsBlacklistedDefinition`.
+func IsBlacklistedDefinition(
+	k8sVersion string, path kubespec.DefinitionName,
+) bool {
+	return Default().IsBlacklistedDefinition(k8sVersion, path)
+}
+
+// PreferredGroup is `Default().PreferredGroup`.
+func PreferredGroup(
+	k8sVersion string, kind kubespec.ObjectKind,
+) (kubespec.GroupName, bool) {
+	return Default().PreferredGroup(k8sVersion, kind)
+}
+
+// IsInt64StringProperty is `Default().IsInt64StringProperty`.
+func IsInt64StringProperty(
+	k8sVersion string, propertyName kubespec.PropertyName,
+) bool {
+	return Default().IsInt64StringProperty(k8sVersion, propertyName)
+}
+
+// IsStringifiedProperty is `Default().IsStringifiedProperty`.
+func IsStringifiedProperty(
+	k8sVersion string, kind kubespec.ObjectKind, propertyName kubespec.PropertyName,
+) bool {
+	return Default().IsStringifiedProperty(k8sVersion, kind, propertyName)
+}
+
+// PropertyTypes is `Default().PropertyTypes`.
+func PropertyTypes(k8sVersion string) []PropertyTypeOverride {
+	return Default().PropertyTypes(k8sVersion)
+}
+
+// WellKnownLabels is `Default().WellKnownLabels`.
+func WellKnownLabels(k8sVersion string) []WellKnownKey {
+	return Default().WellKnownLabels(k8sVersion)
+}
+
+// WellKnownAnnotations is `Default().WellKnownAnnotations`.
+func WellKnownAnnotations(k8sVersion string) []WellKnownKey {
+	return Default().WellKnownAnnotations(k8sVersion)
+}
+
+// Presets is `Default().Presets`.
+func Presets(k8sVersion string) []Preset {
+	return Default().Presets(k8sVersion)
+}
+
+// LegacyHelpers is `Default().LegacyHelpers`.
+func LegacyHelpers(k8sVersion, kind string) []LegacyHelper {
+	return Default().LegacyHelpers(k8sVersion, kind)
+}
+
+// ExampleFields is `Default().ExampleFields`.
+func ExampleFields(k8sVersion, kind string) []string {
+	return Default().ExampleFields(k8sVersion, kind)
+}
+
+// DeprecatedShims is `Default().DeprecatedShims`.
+func DeprecatedShims(k8sVersion string, definition kubespec.DefinitionName) []DeprecatedShim {
+	return Default().DeprecatedShims(k8sVersion, definition)
+}
+
+// ExtensionPoints is `Default().ExtensionPoints`.
+func ExtensionPoints(k8sVersion string) []ExtensionPoint {
+	return Default().ExtensionPoints(k8sVersion)
+}
+
+// ScheduledRemoval is `Default().ScheduledRemoval`.
+func ScheduledRemoval(k8sVersion, apiVersion, kind string) (Removal, bool) {
+	return Default().ScheduledRemoval(k8sVersion, apiVersion, kind)
+}
@@ -0,0 +1,166 @@
+package kubeversion
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+// Registry of supported versions.
+//-----------------------------------------------------------------------------
+
+// NameLayout is how the definitions in the spec of some version of
+// Kubernetes are named, which decides how `kubespec.ParseName` breaks
+// them into their group, version, and kind.
+type NameLayout string
+
+const (
+	// LayoutKubernetesPkg is the layout before Kubernetes v1.8, in
+	// which the API types lived in the main repository, e.g.,
+	// `io.k8s.kubernetes.pkg.api.v1.Pod` and
+	// `io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment`.
+	LayoutKubernetesPkg NameLayout = "kubernetes-pkg"
+
+	// LayoutAPI is the layout since Kubernetes v1.8, in which the API
+	// types live in their own repository, e.g., `io.k8s.api.core.v1.Pod`
+	// and `io.k8s.api.apps.v1beta2.Deployment`.
+	LayoutAPI NameLayout = "api"
+)
+
+// VersionInfo describes what's bundled for a supported version of
+// Kubernetes.
+type VersionInfo struct {
+	// Version is the version as it's keyed here, e.g., `v1.9.0`. Any
+	// patch release of the same minor version (e.g., `v1.9.3`) uses
+	// the same data; see `Lookup`.
+	Version string
+
+	// Layout is how the definitions of its spec are named.
+	Layout NameLayout
+
+	// PreferredGroups reports whether there's a table of the group
+	// that gets the unqualified alias of kinds that exist in more than
+	// one group; see `PreferredGroup`.
+	PreferredGroups bool
+
+	// BlacklistedProperties maps definition names to the properties of
+	// them that aren't emitted (e.g., `status`), and
+	// BlacklistedDefinitions lists the definitions that aren't emitted
+	// at all, each sorted. See `IsBlacklistedProperty` and
+	// `IsBlacklistedDefinition`.
+	BlacklistedProperties  map[string][]string
+	BlacklistedDefinitions []string
+}
+
+// Supported returns the versions of Kubernetes there's data for,
+// oldest first.
+func (d *Data) Supported() []VersionInfo {
+	supported := []VersionInfo{}
+	for version, data := range d.versions {
+		supported = append(supported, data.info(version))
+	}
+	sort.Slice(supported, func(i, j int) bool {
+		return minorVersion(supported[i].Version).less(minorVersion(supported[j].Version))
+	})
+	return supported
+}
+
+// Lookup returns the supported version that `version` belongs to,
+// matching on the major and minor version, so that, e.g., `v1.9.3`,
+// `1.9`, and `v1.9.0-beta.1` all find `v1.9.0`. It's an error if the
+// version doesn't parse, or isn't supported.
+func (d *Data) Lookup(version string) (VersionInfo, error) {
+	key, ok := d.lookupKey(version)
+	if !ok {
+		return VersionInfo{}, fmt.Errorf(
+			"Unsupported Kubernetes version '%s'; supported versions are %s",
+			version, d.supportedList())
+	}
+	return d.versions[key].info(key), nil
+}
+
+// semverPattern matches `v1.9`, `1.9.3`, `v1.9.0-beta.1`, and so on.
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.\d+)?(?:[-+].*)?$`)
+
+type minor struct {
+	major, minor int
+}
+
+// `minorVersion` returns the major and minor version of `version`, or
+// the zero value if it doesn't parse.
+func minorVersion(version string) minor {
+	match := semverPattern.FindStringSubmatch(version)
+	if match == nil {
+		return minor{}
+	}
+	major, _ := strconv.Atoi(match[1])
+	m, _ := strconv.Atoi(match[2])
+	return minor{major, m}
+}
+
+func (m minor) less(other minor) bool {
+	return m.major < other.major || (m.major == other.major && m.minor < other.minor)
+}
+
+// `lookupKey` returns the key of the data's versions that `version`
+// belongs to. Exact matches are preferred, so that data keyed by an
+// unusual version string is still found.
+func (d *Data) lookupKey(version string) (string, bool) {
+	if _, ok := d.versions[version]; ok {
+		return version, true
+	}
+	wanted := minorVersion(version)
+	if wanted == (minor{}) {
+		return "", false
+	}
+	for key := range d.versions {
+		if minorVersion(key) == wanted {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// `lookup` returns the data for the supported version that `version`
+// belongs to; see `Lookup`.
+func (d *Data) lookup(version string) (versionData, bool) {
+	key, ok := d.lookupKey(version)
+	if !ok {
+		return versionData{}, false
+	}
+	return d.versions[key], true
+}
+
+func (d *Data) supportedList() string {
+	names := []string{}
+	for _, info := range d.Supported() {
+		names = append(names, info.Version)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (data versionData) info(version string) VersionInfo {
+	info := VersionInfo{
+		Version:                version,
+		Layout:                 data.layout,
+		PreferredGroups:        len(data.preferredGroups) > 0,
+		BlacklistedProperties:  map[string][]string{},
+		BlacklistedDefinitions: []string{},
+	}
+	for definition, properties := range data.propertyBlacklist {
+		names := []string{}
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		info.BlacklistedProperties[definition] = names
+	}
+	for definition := range data.definitionBlacklist {
+		info.BlacklistedDefinitions = append(info.BlacklistedDefinitions, definition)
+	}
+	sort.Strings(info.BlacklistedDefinitions)
+	return info
+}
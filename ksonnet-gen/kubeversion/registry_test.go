@@ -0,0 +1,272 @@
+package kubeversion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+func TestSupported(t *testing.T) {
+	supported := Supported()
+	versions := []string{}
+	for _, info := range supported {
+		versions = append(versions, info.Version)
+	}
+	if expected := []string{"v1.7.0", "v1.9.0"}; !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("Expected supported versions %v got %v", expected, versions)
+	}
+
+	old, current := supported[0], supported[1]
+	if old.Layout != LayoutKubernetesPkg || current.Layout != LayoutAPI {
+		t.Errorf("Unexpected layouts '%s' and '%s'", old.Layout, current.Layout)
+	}
+	if !old.PreferredGroups || !current.PreferredGroups {
+		t.Errorf("Expected both versions to have preferred groups")
+	}
+	status := current.BlacklistedProperties["io.k8s.api.apps.v1.Deployment"]
+	if !reflect.DeepEqual(status, []string{"status"}) {
+		t.Errorf("Expected 'status' of 'Deployment' to be blacklisted, got %v", status)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	matches := map[string]string{
+		"v1.7.0":        "v1.7.0",
+		"v1.9.0":        "v1.9.0",
+		"v1.9.3":        "v1.9.0",
+		"1.9":           "v1.9.0",
+		"v1.7":          "v1.7.0",
+		"v1.9.0-beta.1": "v1.9.0",
+		"v1.9.1+k3s1":   "v1.9.0",
+	}
+	for version, expected := range matches {
+		info, err := Lookup(version)
+		if err != nil || info.Version != expected {
+			t.Errorf("Expected '%s' to find '%s' got '%s' (%v)", version, expected, info.Version, err)
+		}
+	}
+
+	for _, version := range []string{"v1.8.4", "v1.10.0", "v2.9.0", "unreleased", ""} {
+		if info, err := Lookup(version); err == nil {
+			t.Errorf("Expected '%s' to be unsupported, got '%s'", version, info.Version)
+		}
+	}
+
+	// Lookups are fuzzy everywhere, not just in `Lookup`.
+	if MapIdentifier("v1.9.3", "hostIPC") != "hostIpc" {
+		t.Errorf("Expected 'v1.9.3' to use the identifiers of 'v1.9.0'")
+	}
+}
+
+func TestScheduledRemoval(t *testing.T) {
+	for _, k8sVersion := range []string{"v1.7.0", "v1.9.3"} {
+		removal, ok := ScheduledRemoval(k8sVersion, "extensions/v1beta1", "Deployment")
+		if expected := (Removal{"extensions/v1beta1", "Deployment", "v1.16", "apps/v1"}); !ok || removal != expected {
+			t.Errorf("Expected '%s' to remove 'extensions/v1beta1' 'Deployment' as %v, got %v", k8sVersion, expected, removal)
+		}
+	}
+	if removal, ok := ScheduledRemoval("v1.9.0", "apps/v1beta2", "StatefulSet"); !ok || removal.Replacement != "apps/v1" {
+		t.Errorf("Expected 'apps/v1beta2' 'StatefulSet' to be replaced by 'apps/v1', got %v", removal)
+	}
+	for _, kind := range [][]string{{"apps/v1", "Deployment"}, {"extensions/v1beta1", "Scale"}} {
+		if removal, ok := ScheduledRemoval("v1.9.0", kind[0], kind[1]); ok {
+			t.Errorf("Expected '%s' '%s' not to be removed, got %v", kind[0], kind[1], removal)
+		}
+	}
+
+	removal, _ := ScheduledRemoval("v1.9.0", "extensions/v1beta1", "Ingress")
+	removedBy := map[string]bool{
+		"v1.21.9":        false,
+		"1.22":           true,
+		"v1.22.0-beta.0": true,
+		"v1.30.1":        true,
+		"v2.0":           true,
+		"unreleased":     false,
+	}
+	for version, expected := range removedBy {
+		if actual := removal.RemovedBy(version); actual != expected {
+			t.Errorf("Expected 'Ingress' removed by '%s' to be %v", version, expected)
+		}
+	}
+}
+
+func TestPresets(t *testing.T) {
+	for _, info := range Supported() {
+		presets := map[string]Preset{}
+		for _, preset := range Presets(info.Version) {
+			presets[preset.Name] = preset
+		}
+		if len(presets) == 0 {
+			t.Errorf("Expected '%s' to have presets", info.Version)
+		}
+		for _, preset := range presets {
+			if len(preset.Fields) == 0 {
+				t.Errorf("Expected preset '%s' of '%s' to set fields", preset.Name, info.Version)
+			}
+			for _, field := range preset.Fields {
+				if (field.Value == "") == (field.Each == "") {
+					t.Errorf("Expected field '%s' of preset '%s' to have either a value or a preset for each element", field.Path, preset.Name)
+				} else if _, ok := presets[field.Each]; field.Each != "" && !ok {
+					t.Errorf("Expected field '%s' of preset '%s' to name a preset of '%s', got '%s'", field.Path, preset.Name, info.Version, field.Each)
+				}
+			}
+		}
+	}
+	if presets := Presets("v0.1.0"); presets != nil {
+		t.Errorf("Expected no presets for an unknown version, got %v", presets)
+	}
+}
+
+func TestLegacyHelpers(t *testing.T) {
+	for _, info := range Supported() {
+		names := []string{}
+		for _, helper := range LegacyHelpers(info.Version, "Deployment") {
+			names = append(names, helper.Identifier("spec"))
+		}
+		if strings.Join(names, ", ") != "mixinInstance, __specMixin" {
+			t.Errorf("Expected 'Deployment' of '%s' to have the legacy helpers 'mixinInstance, __specMixin', got %v", info.Version, names)
+		}
+		if helpers := LegacyHelpers(info.Version, "Job"); helpers != nil {
+			t.Errorf("Expected 'Job' of '%s' to have no legacy helpers, got %v", info.Version, helpers)
+		}
+	}
+	if helpers := LegacyHelpers("v0.1.0", "Deployment"); helpers != nil {
+		t.Errorf("Expected no legacy helpers for an unknown version, got %v", helpers)
+	}
+}
+
+func TestDeprecatedShims(t *testing.T) {
+	for version, definition := range map[string]kubespec.DefinitionName{
+		"v1.7.0": "io.k8s.kubernetes.pkg.api.v1.PodSpec",
+		"v1.9.0": "io.k8s.api.core.v1.PodSpec",
+	} {
+		shims := DeprecatedShims(version, definition)
+		if len(shims) != 1 || shims[0].Name != "serviceAccount" || shims[0].Replacement != "serviceAccountName" {
+			t.Errorf("Expected '%s' of '%s' to shim 'serviceAccount' with 'serviceAccountName', got %v", definition, version, shims)
+		}
+	}
+
+	names := []string{}
+	for _, shim := range DeprecatedShims("v1.9.3", "io.k8s.api.events.v1beta1.Event") {
+		names = append(names, string(shim.Name)+" -> "+string(shim.Replacement))
+	}
+	expected := "count -> deprecatedCount, firstTimestamp -> deprecatedFirstTimestamp, lastTimestamp -> deprecatedLastTimestamp, source -> deprecatedSource"
+	if strings.Join(names, ", ") != expected {
+		t.Errorf("Expected the shims of 'Event' to be sorted by name, '%s', got '%s'", expected, strings.Join(names, ", "))
+	}
+	if shims := DeprecatedShims("v1.7.0", "io.k8s.api.events.v1beta1.Event"); shims != nil {
+		t.Errorf("Expected no shims of a definition another version has, got %v", shims)
+	}
+	if shims := DeprecatedShims("v0.1.0", "io.k8s.api.core.v1.PodSpec"); shims != nil {
+		t.Errorf("Expected no shims for an unknown version, got %v", shims)
+	}
+}
+
+func TestExtensionPoints(t *testing.T) {
+	for version, core := range map[string]string{
+		"v1.7.0": "io.k8s.kubernetes.pkg.api.v1.",
+		"v1.9.0": "io.k8s.api.core.v1.",
+	} {
+		names := []string{}
+		for _, point := range ExtensionPoints(version) {
+			names = append(names, point.Name)
+			if !strings.HasPrefix(string(point.Definition), core) {
+				t.Errorf("Expected '%s' of '%s' to be of a definition in '%s', got '%s'", point.Name, version, core, point.Definition)
+			}
+			resource := false
+			for _, param := range point.Params {
+				resource = resource || param == "resource"
+			}
+			if !resource || len(point.Sources) == 0 {
+				t.Errorf("Expected '%s' of '%s' to take the resource and have sources, got %+v", point.Name, version, point)
+			}
+		}
+		if expected := "envFromSourcesExt, envSourcesExt, volumeSourcesExt"; strings.Join(names, ", ") != expected {
+			t.Errorf("Expected the extension points of '%s' to be '%s', got '%s'", version, expected, strings.Join(names, ", "))
+		}
+	}
+	if points := ExtensionPoints("v0.1.0"); points != nil {
+		t.Errorf("Expected no extension points for an unknown version, got %v", points)
+	}
+}
+
+func TestExampleFields(t *testing.T) {
+	for _, info := range Supported() {
+		fields := ExampleFields(info.Version, "Deployment")
+		if strings.Join(fields, ", ") != "spec.replicas, spec.template.spec.containers" {
+			t.Errorf("Expected 'Deployment' of '%s' to have the example fields 'spec.replicas, spec.template.spec.containers', got %v", info.Version, fields)
+		}
+		if fields := ExampleFields(info.Version, "Widget"); fields != nil {
+			t.Errorf("Expected 'Widget' of '%s' to have no example fields, got %v", info.Version, fields)
+		}
+	}
+	if fields := ExampleFields("v0.1.0", "Deployment"); fields != nil {
+		t.Errorf("Expected no example fields for an unknown version, got %v", fields)
+	}
+}
+
+func TestWithOverrides(t *testing.T) {
+	// Definition names are trimmed, as they are when they're parsed.
+	data, err := Default().WithOverrides("v1.9.3", Overrides{
+		IDAliases:              map[string]string{"hostIPC": "hostIpcMode", "fooID": "fooId"},
+		BlacklistedProperties:  map[string][]string{"io.k8s.api.apps.v1.Deployment ": {"spec"}},
+		BlacklistedDefinitions: []string{"\ufeffio.k8s.api.core.v1.Binding"},
+		PreferredGroups:        map[string]string{"Ingress": "networking"},
+		PropertyTypes: map[string]map[string]PropertyType{
+			" io.k8s.api.core.v1.Secret\n": {"type": PropertySkip},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to override data:\n%v", err)
+	}
+
+	// The overrides apply to the version they're for, along with the
+	// bundled data they don't replace.
+	for _, c := range []struct{ id, expected string }{
+		{"hostIPC", "hostIpcMode"}, {"fooID", "fooId"}, {"hostPID", "hostPid"},
+	} {
+		if actual := data.MapIdentifier("v1.9.0", c.id); actual != c.expected {
+			t.Errorf("Expected '%s' to map to '%s', got '%s'", c.id, c.expected, actual)
+		}
+	}
+	if !data.IsBlacklistedProperty("v1.9.0", "io.k8s.api.apps.v1.Deployment", "spec") ||
+		!data.IsBlacklistedProperty("v1.9.0", "io.k8s.api.apps.v1.Deployment", "status") {
+		t.Errorf("Expected both 'spec' and 'status' of 'Deployment' to be blacklisted")
+	}
+	if !data.IsBlacklistedDefinition("v1.9.0", "io.k8s.api.core.v1.Binding") {
+		t.Errorf("Expected 'Binding' to be blacklisted")
+	}
+	if group, ok := data.PreferredGroup("v1.9.0", "Ingress"); !ok || group != "networking" {
+		t.Errorf("Expected 'networking' to be preferred for 'Ingress', got '%s'", group)
+	}
+	expected := []PropertyTypeOverride{
+		{"io.k8s.api.core.v1.HTTPGetAction", "port", PropertyIntOrString},
+		{"io.k8s.api.core.v1.Secret", "data", PropertyOpaque},
+		{"io.k8s.api.core.v1.Secret", "type", PropertySkip},
+	}
+	if actual := data.PropertyTypes("v1.9.0"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected the forced property types %v, got %v", expected, actual)
+	}
+	if data.MapIdentifier("v1.7.0", "hostIPC") != "hostIpc" {
+		t.Errorf("Expected the overrides not to apply to 'v1.7.0'")
+	}
+
+	// The data they're made from is unchanged.
+	if MapIdentifier("v1.9.0", "hostIPC") != "hostIpc" ||
+		IsBlacklistedProperty("v1.9.0", "io.k8s.api.apps.v1.Deployment", "spec") ||
+		IsBlacklistedDefinition("v1.9.0", "io.k8s.api.core.v1.Binding") ||
+		len(PropertyTypes("v1.9.0")) != 2 {
+		t.Errorf("Expected the bundled data to be unchanged")
+	}
+
+	if _, err := Default().WithOverrides("v1.8.0", Overrides{}); err == nil {
+		t.Errorf("Expected overrides of an unsupported version to fail")
+	}
+	if _, err := Default().WithOverrides("v1.9.0", Overrides{
+		PropertyTypes: map[string]map[string]PropertyType{"io.k8s.api.core.v1.Secret": {"data": "map"}},
+	}); err == nil {
+		t.Errorf("Expected overrides with an unknown property type to fail")
+	}
+}
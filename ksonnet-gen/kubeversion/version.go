@@ -11,6 +11,8 @@ package kubeversion
 
 import (
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
 )
@@ -19,10 +21,10 @@ import (
 // Jsonnet-appropriate identifier, for some version of Kubernetes. For
 // example, in Kubernetes v1.7.0, we might map `clusterIP` ->
 // `clusterIp`.
-func MapIdentifier(k8sVersion, id string) string {
-	verData, ok := versions[k8sVersion]
+func (d *Data) MapIdentifier(k8sVersion, id string) string {
+	verData, ok := d.lookup(k8sVersion)
 	if !ok {
-		log.Fatalf("Unrecognized Kubernetes version '%s'", k8sVersion)
+		log.Panicf("Unrecognized Kubernetes version '%s'", k8sVersion)
 	}
 
 	if alias, ok := verData.idAliases[id]; ok {
@@ -31,17 +33,32 @@ func MapIdentifier(k8sVersion, id string) string {
 	return id
 }
 
+// MapInitialism returns the identifier that `id` should map to when
+// identifiers are normalized Go-initialism style (e.g.,
+// `CephFSVolumeSource` -> `cephfsVolumeSource`, to match its field
+// `cephfs`), for some version of Kubernetes. It reports false if `id`
+// should be normalized as usual.
+func (d *Data) MapInitialism(k8sVersion, id string) (string, bool) {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		log.Panicf("Unrecognized Kubernetes version '%s'", k8sVersion)
+	}
+
+	override, ok := verData.initialismOverrides[id]
+	return override, ok
+}
+
 // IsBlacklistedProperty taks a definition name (e.g.,
 // `io.k8s.kubernetes.pkg.apis.apps.v1beta1.Deployment`), a property
 // name (e.g., `status`), and reports whether it is blacklisted for
 // some Kubernetes version. This is particularly useful when deciding
 // whether or not to generate mixins and property methods for a given
 // property (as we likely wouldn't in the case of, say, `status`).
-func IsBlacklistedProperty(
+func (d *Data) IsBlacklistedProperty(
 	k8sVersion string, path kubespec.DefinitionName,
 	propertyName kubespec.PropertyName,
 ) bool {
-	verData, ok := versions[k8sVersion]
+	verData, ok := d.lookup(k8sVersion)
 	if !ok {
 		return false
 	}
@@ -55,13 +72,413 @@ func IsBlacklistedProperty(
 	return ok
 }
 
+// IsBlacklistedDefinition takes a definition name (e.g.,
+// `io.k8s.kubernetes.pkg.apis.extensions.v1beta1.ThirdPartyResource`)
+// and reports whether it is blacklisted for some Kubernetes version,
+// in which case it isn't emitted at all.
+func (d *Data) IsBlacklistedDefinition(
+	k8sVersion string, path kubespec.DefinitionName,
+) bool {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return false
+	}
+	return verData.definitionBlacklist[string(path)]
+}
+
+// PreferredGroup takes the name of a kind that exists in more than
+// one API group (e.g., `NetworkPolicy`, which is in both `extensions`
+// and `networking`), and returns the group whose version of it should
+// get the kind's unqualified name in flattened namespaces, for some
+// version of Kubernetes. It reports false if there is no preference.
+func (d *Data) PreferredGroup(
+	k8sVersion string, kind kubespec.ObjectKind,
+) (kubespec.GroupName, bool) {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return "", false
+	}
+
+	group, ok := verData.preferredGroups[string(kind)]
+	return kubespec.GroupName(group), ok
+}
+
+// IsInt64StringProperty takes the name of an `int64` property (e.g.,
+// `resourceVersion`, which some CRDs declare as an integer) and
+// reports whether its setter should also accept the value as a
+// string, passing it through unchanged, since it may be too large for
+// a Jsonnet number to hold exactly, for some Kubernetes version.
+func (d *Data) IsInt64StringProperty(
+	k8sVersion string, propertyName kubespec.PropertyName,
+) bool {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return false
+	}
+	return verData.int64StringProperties[string(propertyName)]
+}
+
+// IsStringifiedProperty takes the kind of a definition (e.g.,
+// `ConfigMap`) and the name of one of its properties that's a map of
+// strings (e.g., `data`), and reports whether its setter should have a
+// variant that converts the map's values to strings, since people
+// often pass them numbers or booleans, for some Kubernetes version.
+func (d *Data) IsStringifiedProperty(
+	k8sVersion string, kind kubespec.ObjectKind, propertyName kubespec.PropertyName,
+) bool {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return false
+	}
+	return verData.stringifiedProperties[string(kind)][string(propertyName)]
+}
+
+// PropertyType is the type a property is modeled as regardless of its
+// schema, for properties the spec types wrongly, or too loosely to be
+// of use; see `PropertyTypes`.
+type PropertyType string
+
+const (
+	// The scalar types. Properties forced to one of them get a plain
+	// setter, e.g., `path(path):: {path: path}`.
+	PropertyString  PropertyType = "string"
+	PropertyInteger PropertyType = "integer"
+	PropertyNumber  PropertyType = "number"
+	PropertyBoolean PropertyType = "boolean"
+
+	// PropertyOpaque properties accept arbitrary JSON, like those the
+	// spec gives no schema: their setter replaces the field, and their
+	// `Mixin` merges into it.
+	PropertyOpaque PropertyType = "opaque"
+
+	// PropertyIntOrString properties accept an integer or a string, like
+	// those that `$ref` `intstr.IntOrString`.
+	PropertyIntOrString PropertyType = "intOrString"
+
+	// PropertySkip properties aren't modeled at all, as though the spec
+	// didn't have them.
+	PropertySkip PropertyType = "skip"
+)
+
+// propertyTypeNames are the `PropertyType`s, in the order they're
+// listed in errors.
+var propertyTypeNames = []PropertyType{
+	PropertyString, PropertyInteger, PropertyNumber, PropertyBoolean,
+	PropertyOpaque, PropertyIntOrString, PropertySkip,
+}
+
+// IsKnown reports whether `t` is one of the `PropertyType`s.
+func (t PropertyType) IsKnown() bool {
+	for _, known := range propertyTypeNames {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// PropertyTypeOverride forces the type of one property of a definition.
+type PropertyTypeOverride struct {
+	Definition kubespec.DefinitionName // e.g., `io.k8s.api.core.v1.Secret`.
+
+	// Path is the name of the property (e.g., `data`), or, for a
+	// property of an inline object, the names of the properties that
+	// lead to it, joined by dots (e.g., `spec.port`).
+	Path string
+	Type PropertyType
+}
+
+// PropertyTypes returns the properties whose types are forced for some
+// version of Kubernetes, sorted by definition and then by path, or nil
+// if the version is unrecognized. They take precedence over the spec.
+func (d *Data) PropertyTypes(k8sVersion string) []PropertyTypeOverride {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return nil
+	}
+	overrides := []PropertyTypeOverride{}
+	for definition, paths := range verData.propertyTypes {
+		for path, t := range paths {
+			overrides = append(overrides, PropertyTypeOverride{
+				Definition: kubespec.DefinitionName(definition),
+				Path:       path,
+				Type:       t,
+			})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Definition != overrides[j].Definition {
+			return overrides[i].Definition < overrides[j].Definition
+		}
+		return overrides[i].Path < overrides[j].Path
+	})
+	return overrides
+}
+
+// WellKnownKey is a label or annotation key that Kubernetes or its
+// tooling gives a meaning to, e.g., `app.kubernetes.io/name`.
+type WellKnownKey struct {
+	Name        string // Identifier of its constant, e.g., `appName`.
+	Key         string // e.g., `app.kubernetes.io/name`.
+	Description string
+
+	// For the recommended labels, the parameter of `recommendedLabels`
+	// that sets it (e.g., `name`), whether the parameter is required,
+	// and whether the label also belongs in selectors, because it
+	// doesn't change over the life of the application.
+	Param    string
+	Required bool
+	Selector bool
+}
+
+// WellKnownLabels returns the well-known label keys for some version
+// of Kubernetes, in the order they should be documented, or nil if
+// the version is unrecognized.
+func (d *Data) WellKnownLabels(k8sVersion string) []WellKnownKey {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.wellKnownLabels
+}
+
+// WellKnownAnnotations returns the well-known annotation keys for some
+// version of Kubernetes, in the order they should be documented, or
+// nil if the version is unrecognized.
+func (d *Data) WellKnownAnnotations(k8sVersion string) []WellKnownKey {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.wellKnownAnnotations
+}
+
+// Preset is a set of best-practice defaults for objects of one
+// definition, e.g., the hardened security settings of a `PodSpec`,
+// which the library emits as a function of its `presets` namespace
+// that returns a mixin of them, made of the setters the library has of
+// their fields.
+type Preset struct {
+	Name        string                  // e.g., `securityHardened`.
+	Description string                  // A sentence for its comment.
+	Definition  kubespec.DefinitionName // e.g., `io.k8s.api.core.v1.PodSpec`.
+	Fields      []PresetField
+}
+
+// PresetField is a field a `Preset` sets.
+type PresetField struct {
+	// Path is the name of the property, or, for a property of a nested
+	// object, the names of the properties that lead to it, joined by
+	// dots (e.g., `securityContext.runAsNonRoot`).
+	Path string
+
+	// Value is the Jsonnet expression passed to the property's setter,
+	// e.g., `true` or `["ALL"]`. If Each is set instead, the property
+	// is an array, and each of its elements is merged with the mixin of
+	// the preset named Each, e.g., `securityHardenedContainer` for the
+	// `containers` of a `PodSpec`.
+	Value string
+	Each  string
+}
+
+// Presets returns the presets for some version of Kubernetes, in the
+// order they should be emitted, or nil if the version is
+// unrecognized.
+func (d *Data) Presets(k8sVersion string) []Preset {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.presets
+}
+
+// LegacyHelper is a hidden function that the mixin namespaces of
+// ksonnet 0.x's libraries had, and apps written against them still
+// call, e.g., `mixinInstance`, which the library emits, with
+// `--compat ksonnet-0.x`, as a wrapper of the namespace's mixin.
+type LegacyHelper struct {
+	// Name is the helper's identifier, where `%s`, if it has one, is
+	// replaced by the identifier of the namespace's property, e.g.,
+	// `__%sMixin` is `__specMixin` in `deployment.mixin.spec`.
+	Name string
+
+	// Kinds are the top-level kinds (e.g., `Deployment`) under whose
+	// `mixin` namespaces the helper is emitted.
+	Kinds []string
+}
+
+// Identifier returns the helper's identifier in the mixin namespace of
+// the property whose identifier is `property`, e.g., `spec`.
+func (h LegacyHelper) Identifier(property string) string {
+	return strings.Replace(h.Name, "%s", property, -1)
+}
+
+// LegacyHelpers returns the legacy helpers emitted under the mixin
+// namespaces of the top-level kind `kind` (e.g., `Deployment`), for
+// some version of Kubernetes, or nil if there are none.
+func (d *Data) LegacyHelpers(k8sVersion, kind string) []LegacyHelper {
+	verData, _ := d.lookup(k8sVersion)
+	var helpers []LegacyHelper
+	for _, helper := range verData.legacyHelpers {
+		for _, k := range helper.Kinds {
+			if k == kind {
+				helpers = append(helpers, helper)
+				break
+			}
+		}
+	}
+	return helpers
+}
+
+// ExampleFields returns the fields, as dotted paths (e.g.,
+// `spec.template.spec.containers`), that the example in the comment of
+// the top-level kind `kind` (e.g., `Deployment`) sets, for some version
+// of Kubernetes, or nil if it has none, in which case the example sets
+// the first two fields the library has setters for.
+func (d *Data) ExampleFields(k8sVersion, kind string) []string {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.exampleFields[kind]
+}
+
+// DeprecatedShim is a setter that a definition's property had under
+// another name (e.g., `serviceAccount` of a `PodSpec`), which the
+// library emits as a wrapper of the setter of the property that
+// replaced it (e.g., `serviceAccountName`), so that apps written
+// against the old name keep working. A shim is only emitted while the
+// definition doesn't have a property of its old name, whose own setter
+// still works.
+type DeprecatedShim struct {
+	Definition  kubespec.DefinitionName // e.g., `io.k8s.api.core.v1.PodSpec`.
+	Name        kubespec.PropertyName   // e.g., `serviceAccount`.
+	Replacement kubespec.PropertyName   // e.g., `serviceAccountName`.
+}
+
+// DeprecatedShims returns the shims of the definition `definition`
+// (e.g., `io.k8s.api.core.v1.PodSpec`), for some version of
+// Kubernetes, in order of their old name, or nil if it has none.
+func (d *Data) DeprecatedShims(k8sVersion string, definition kubespec.DefinitionName) []DeprecatedShim {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.deprecatedShims[definition]
+}
+
+// ExtensionPoint is a hidden hook (e.g., `volumeSourcesExt`) that the
+// library emits, with `--emit-extension-points`, as an empty object in
+// each namespace of a definition (e.g., in that of `Volume`, which the
+// `volumesType` of pod specs point at), and that a library generated on
+// its own (e.g., of CRDs) fills with an overlay, which users merge into
+// the library, so that neither library imports the other.
+type ExtensionPoint struct {
+	Name        string                  // e.g., `volumeSourcesExt`.
+	Definition  kubespec.DefinitionName // e.g., `io.k8s.api.core.v1.Volume`.
+	Description string                  // A sentence for its comment.
+
+	// Params are the parameters of the functions that custom resources
+	// fill the hook with, where `resource`, which is named after the
+	// resource's kind, stands for the custom resource, e.g., `["name",
+	// "resource"]` for `fromSealedSecret(name, sealedSecret)`.
+	Params []string
+
+	// Sources maps the kinds of the objects that a custom resource may
+	// manage, by the resource's name (e.g., the `Secret` that a
+	// `SealedSecret` decrypts to), to the Jsonnet expression the
+	// functions return, in which `ref` is that name, e.g., `{name: name,
+	// secret: {secretName: ref}}`.
+	Sources map[string]string
+}
+
+// ExtensionPoints returns the extension points for some version of
+// Kubernetes, in order of name, or nil if the version is unrecognized.
+func (d *Data) ExtensionPoints(k8sVersion string) []ExtensionPoint {
+	verData, _ := d.lookup(k8sVersion)
+	return verData.extensionPoints
+}
+
+// Removal is a top-level kind that Kubernetes stops serving in some
+// release, e.g., `extensions/v1beta1` `Deployment` in `v1.16`, and the
+// apiVersion of the kind that replaces it.
+type Removal struct {
+	APIVersion string // e.g., `extensions/v1beta1`.
+	Kind       string // e.g., `Deployment`.
+	RemovedIn  string // e.g., `v1.16`.
+
+	// Replacement is the apiVersion to move to, e.g., `apps/v1`, whose
+	// kind has the same name. It's empty if nothing replaces the kind.
+	Replacement string
+}
+
+// ScheduledRemoval takes the apiVersion (e.g., `extensions/v1beta1`)
+// and kind (e.g., `Deployment`) of a top-level API object, and returns
+// when Kubernetes stops serving it and what replaces it, for some
+// version of Kubernetes. It reports false if it isn't scheduled for
+// removal.
+func (d *Data) ScheduledRemoval(k8sVersion, apiVersion, kind string) (Removal, bool) {
+	verData, ok := d.lookup(k8sVersion)
+	if !ok {
+		return Removal{}, false
+	}
+	removal, ok := verData.removals[removalKey(apiVersion, kind)]
+	return removal, ok
+}
+
+// RemovedBy reports whether the kind is no longer served by `version`
+// of Kubernetes, e.g., `v1.16.0` or `1.17`, comparing minor versions.
+// It's false if `version` doesn't parse; see `IsVersion`.
+func (r Removal) RemovedBy(version string) bool {
+	if !IsVersion(version) {
+		return false
+	}
+	return !minorVersion(version).less(minorVersion(r.RemovedIn))
+}
+
+// IsVersion reports whether `version` parses as a version of
+// Kubernetes, e.g., `v1.16`, `1.16.2`, or `v1.16.0-beta.1`, whether or
+// not there's data for it.
+func IsVersion(version string) bool {
+	return semverPattern.MatchString(version)
+}
+
 //-----------------------------------------------------------------------------
 // Core data structures for specifying version information.
 //-----------------------------------------------------------------------------
 
 type versionData struct {
+	layout NameLayout // How the spec's definitions are named.
+
 	idAliases         map[string]string
 	propertyBlacklist map[string]propertySet
+	preferredGroups   map[string]string // kind -> group, e.g., `Event` -> `core`.
+
+	// Definitions that aren't emitted at all, e.g., because they're
+	// broken in the spec of that version.
+	definitionBlacklist map[string]bool
+
+	// Names of `int64` properties whose setters also accept strings.
+	int64StringProperties propertySet
+
+	// Maps of strings whose setters have a variant that converts their
+	// values to strings, by kind and then by name.
+	stringifiedProperties map[string]propertySet
+
+	// Forced types of properties, by definition and then by path; see
+	// `PropertyTypes`.
+	propertyTypes map[string]map[string]PropertyType
+
+	// Exceptions to Go-initialism style identifiers.
+	initialismOverrides map[string]string
+
+	wellKnownLabels      []WellKnownKey
+	wellKnownAnnotations []WellKnownKey
+
+	// Kinds that are scheduled for removal, keyed by `removalKey`.
+	removals map[string]Removal
+
+	// Best-practice defaults; see `Presets`.
+	presets []Preset
+
+	// Hidden helpers of ksonnet 0.x; see `LegacyHelpers`.
+	legacyHelpers []LegacyHelper
+
+	// Fields the examples of kinds set, by kind; see `ExampleFields`.
+	exampleFields map[string][]string
+
+	// Setters of renamed properties, by definition; see
+	// `DeprecatedShims`.
+	deprecatedShims map[kubespec.DefinitionName][]DeprecatedShim
+
+	// Hooks for other libraries, in order of name; see
+	// `ExtensionPoints`.
+	extensionPoints []ExtensionPoint
 }
 
 type propertySet map[string]bool
@@ -74,3 +491,26 @@ func newPropertySet(strings ...string) propertySet {
 
 	return ps
 }
+
+func newDeprecatedShims(shims ...DeprecatedShim) map[kubespec.DefinitionName][]DeprecatedShim {
+	byDefinition := map[kubespec.DefinitionName][]DeprecatedShim{}
+	for _, shim := range shims {
+		byDefinition[shim.Definition] = append(byDefinition[shim.Definition], shim)
+	}
+	for _, shims := range byDefinition {
+		sort.Slice(shims, func(i, j int) bool { return shims[i].Name < shims[j].Name })
+	}
+	return byDefinition
+}
+
+func newRemovals(removals ...Removal) map[string]Removal {
+	byKey := make(map[string]Removal, len(removals))
+	for _, removal := range removals {
+		byKey[removalKey(removal.APIVersion, removal.Kind)] = removal
+	}
+	return byKey
+}
+
+func removalKey(apiVersion, kind string) string {
+	return apiVersion + "/" + kind
+}
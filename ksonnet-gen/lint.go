@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// lint checks a spec, and the CRDs of any CRD files, for schema
+// patterns that make for poor bindings (see `ksonnet.LintRules`), e.g.,
+// before publishing a library of a new CRD bundle. Findings are
+// printed grouped by definition, and fail the command if any are as
+// severe as --fail-on.
+func lint(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	failOn := flags.String(
+		"fail-on", "error", "fail if any finding is at least this severe: 'info', 'warning', or 'error'")
+	asJSON := flags.Bool("json", false, "print the findings as JSON")
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		log.Fatal(usage)
+	}
+	threshold, err := ksonnet.ParseLintSeverity(*failOn)
+	if err != nil {
+		log.Fatalf("Invalid --fail-on:\n%v", err)
+	}
+
+	logger := &cliLogger{}
+	s := loadSpec(ctx, flags.Arg(0), logger)
+	s = addCRDs(s, &crdFlags{files: flags.Args()[1:]}, prefixes, logger)
+	findings := ksonnet.Lint(s, ksonnet.LintRules)
+
+	if *asJSON {
+		text, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not serialize lint findings:\n%v", err)
+		}
+		fmt.Println(string(text))
+	} else {
+		writeLintFindings(os.Stdout, findings)
+	}
+
+	counts := map[ksonnet.LintSeverity]int{}
+	failed := 0
+	for _, finding := range findings {
+		counts[finding.Severity]++
+		if finding.Severity.AtLeast(threshold) {
+			failed++
+		}
+	}
+	log.Printf(
+		"Lint found %d errors, %d warnings, and %d infos in %d definitions",
+		counts[ksonnet.LintError], counts[ksonnet.LintWarning], counts[ksonnet.LintInfo], len(s.Definitions))
+	if failed > 0 {
+		log.Printf("Lint failed: %d findings are at least of severity '%s'", failed, threshold)
+		os.Exit(exitCode(kubespec.ErrVerifyFailed))
+	}
+}
+
+// writeLintFindings writes `findings`, which are sorted by definition,
+// under a line naming each definition, e.g.:
+//
+//	io.k8s.crd.api.exampleCom.v1.Widget
+//	    error    invalid-identifier           spec.rolling-update: 'rolling-update' isn't a Jsonnet identifier, ...
+func writeLintFindings(w io.Writer, findings []*ksonnet.LintFinding) {
+	var definition kubespec.DefinitionName
+	for i, finding := range findings {
+		if i == 0 || finding.Definition != definition {
+			definition = finding.Definition
+			fmt.Fprintln(w, definition)
+		}
+		location := finding.Message
+		if finding.Path != "" {
+			location = fmt.Sprintf("%s: %s", finding.Path, finding.Message)
+		}
+		fmt.Fprintf(w, "    %-8s %-28s %s\n", finding.Severity, finding.Rule, location)
+	}
+}
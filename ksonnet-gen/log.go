@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cliLogger implements `ksonnet.Logger` for the command line. With
+// `--v`, every event is printed as it happens; with `--timing`,
+// events that carry a duration are collected and printed as a table
+// by `printTiming`. Both go to stderr, so that generated output can
+// still be piped from stdout. The zero value logs nothing.
+type cliLogger struct {
+	verbose  bool
+	timing   bool
+	progress bool
+	phases   []timedPhase
+}
+
+type timedPhase struct {
+	name     string
+	details  string
+	duration time.Duration
+}
+
+// logFlags registers the logging flags on `flags`, returning the
+// logger they configure.
+func logFlags(flags *flag.FlagSet) *cliLogger {
+	logger := &cliLogger{}
+	flags.BoolVar(
+		&logger.verbose, "v", false,
+		"log each phase of generation, with durations and counts, to stderr")
+	flags.BoolVar(
+		&logger.timing, "timing", false,
+		"print a summary of how long each phase of generation took to stderr")
+	flags.BoolVar(
+		&logger.progress, "progress", false,
+		"show the progress of parsing and emitting on stderr")
+	return logger
+}
+
+func (l *cliLogger) Log(msg string, keysAndValues ...interface{}) {
+	var duration *time.Duration
+	details := []string{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, value := keysAndValues[i], keysAndValues[i+1]
+		if d, ok := value.(time.Duration); ok && key == "duration" {
+			duration = &d
+			continue
+		}
+		details = append(details, fmt.Sprintf("%v=%v", key, value))
+	}
+
+	if msg == "compact" {
+		logCompaction(keysAndValues...)
+	}
+
+	if l.verbose {
+		line := append([]string{msg}, details...)
+		if duration != nil {
+			line = append(line, fmt.Sprintf("duration=%v", *duration))
+		}
+		fmt.Fprintln(os.Stderr, strings.Join(line, " "))
+	}
+
+	if l.timing && duration != nil {
+		l.phases = append(l.phases, timedPhase{
+			name:     msg,
+			details:  strings.Join(details, " "),
+			duration: *duration,
+		})
+	}
+}
+
+// logCompaction prints how much smaller `--compact` made
+// `k8s.libsonnet`, from the `compact` event, whether or not `--v` is
+// set.
+func logCompaction(keysAndValues ...interface{}) {
+	values := map[interface{}]int{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if n, ok := keysAndValues[i+1].(int); ok {
+			values[keysAndValues[i]] = n
+		}
+	}
+	bytes, expanded := values["bytes"], values["expanded"]
+	if expanded == 0 {
+		return
+	}
+	log.Printf(
+		"Compacted `k8s.libsonnet` from %d to %d bytes (%.1f%% smaller), sharing the mixins of %d definitions",
+		expanded, bytes, 100*float64(expanded-bytes)/float64(expanded), values["shared"])
+}
+
+// progressFunc returns the `ksonnet.Options.Progress` callback that
+// renders `--progress`, or nil if it's not set.
+func (l *cliLogger) progressFunc() func(phase string, done, total int) {
+	if !l.progress {
+		return nil
+	}
+	return (&progressLine{w: os.Stderr}).update
+}
+
+// progressLine renders progress as a single line, e.g.,
+// `emit [=====     ] 12/24`, which is redrawn in place with each
+// update, and ended when the phase is done.
+type progressLine struct {
+	w       io.Writer
+	percent int // Last rendered, to avoid redrawing for every definition.
+}
+
+const progressWidth = 30
+
+func (p *progressLine) update(phase string, done, total int) {
+	percent := 100
+	if total > 0 {
+		percent = 100 * done / total
+	}
+	if percent == p.percent && done != 1 && done != total {
+		return
+	}
+	p.percent = percent
+
+	filled := progressWidth * percent / 100
+	fmt.Fprintf(
+		p.w, "\r%-6s [%s%s] %d/%d", phase,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+		done, total)
+	if done == total {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// printTiming prints the phases collected with `--timing`, in the
+// order they finished.
+func (l *cliLogger) printTiming() {
+	if l.timing {
+		writeTiming(os.Stderr, l.phases)
+	}
+}
+
+func writeTiming(w io.Writer, phases []timedPhase) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tDURATION\tDETAILS")
+	var total time.Duration
+	for _, phase := range phases {
+		fmt.Fprintf(tw, "%s\t%v\t%s\n", phase.name, phase.duration, phase.details)
+		// Groups are emitted within the `emit` phase, so don't count
+		// them twice.
+		if phase.name != "emit group" {
+			total += phase.duration
+		}
+	}
+	fmt.Fprintf(tw, "total\t%v\t\n", total)
+	tw.Flush()
+}
@@ -1,51 +1,939 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
 	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubeversion"
 )
 
-var usage = "Usage: ksonnet-gen [path to k8s OpenAPI swagger.json] [output dir]"
+var usage = `Usage:
+  ksonnet-gen [--profile minimal|full|profile.yaml] [--print-profile] [--strict-refs] [--emit-tests] [--no-index-doc] [--checksums] [--provenance] [--skip-report skipped.json] [--crd crds.yaml]... [--include-unserved] [--on-conflict error|fork] [--source-label file=label]... [--save-model model] [--output-format dir|tar|zip] [emit flags] [path to k8s OpenAPI swagger.json] [output dir]
+  ksonnet-gen [flags as above] --from-model [model] [output dir]
+  ksonnet-gen [flags as above] -o [output dir or archive] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen [flags as above] --crd crds.yaml... -o [output dir or archive]
+  ksonnet-gen check --baseline [symbols.json] [--update-baseline] [emit flags] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen explain [--json] [--recursive depth] [path to k8s OpenAPI swagger.json] [definition name or group/version/kind]
+  ksonnet-gen stats --weights|--codebases|--provenance [--json] [emit flags] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen graph [--kind kind] [--depth n] [--exclude-meta] [-o graph.dot] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen search [--json] [--regex] [--limit n] [--kind kind] [path to k8s OpenAPI swagger.json] [term]
+  ksonnet-gen prune-to-usage --jsonnet-dir [dir] [--force] [--strict-refs] [--no-index-doc] [--checksums] [--skip-report skipped.json] [--output-format dir|tar|zip] [emit flags] -o [output dir or archive] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen samples [--jsonnet path] [emit flags] -o [output dir] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen subset [--include-group [group]...] [--exclude-alpha] [--exclude-beta] [--paths=false] -o [subset.json] [path to k8s OpenAPI swagger.json]
+  ksonnet-gen migrate-imports --from-index [old symbols.json] --to-index [new symbols.json] --dir [dir] [--write]
+  ksonnet-gen verify-cluster [--kubeconfig file] [--context name] [--server url] [--strict] [generated dir or symbols.json]
+  ksonnet-gen verify-provenance [generated dir]
+  ksonnet-gen roundtrip --manifests [dir] [--strict-nulls] [--jsonnet path] [generated dir]
+  ksonnet-gen lint [--fail-on info|warning|error] [--json] [path to k8s OpenAPI swagger.json] [crds.yaml]...
+  ksonnet-gen bundle --file [bundle.yaml] [--on-conflict error|fork] [--include-unserved] [--no-embedded-meta] [--no-index-doc] [--checksums] [--output-format dir|tar|zip] [emit flags] -o [output dir or archive] [path to k8s OpenAPI swagger.json]
+
+The spec may be JSON or YAML, gzipped or not, and a path of '-' reads it from stdin. '--save-model' also writes the spec as it was read (before '--crd' adds its CRDs, which are passed to each run) to a file, with the names of its definitions as parsed with '--definition-prefixes', the references between them, and the resources of its paths, from which '--from-model' loads it in place of the spec much faster than the spec can be parsed and those derived, e.g., in a later stage of a pipeline; a model is only read by builds whose spec model has the same fields as the one that wrote it.
+
+'bundle' generates from the CRDs of the sources that a JSON or YAML bundle file lists under 'sources', in order, each with one of 'path' (relative to the bundle), 'url' (http or https), or 'inline' (a manifest, or a string of YAML manifests), an optional 'sha256' that a path's or URL's bytes must have, and an optional 'label' for its forks; 'onConflict' is the bundle's --on-conflict, which the flag overrides. Every source is fetched and checked, and the library emitted, before anything is written, so a source that fails, which the error names, leaves the output as it was; a digest that doesn't match exits with 7.
+
+Emit flags:
+  --deprecate-cluster-namespace  emit 'metadata.namespace' mixins for cluster-scoped kinds with a deprecation comment, rather than omitting them
+  --customizations [dir]         merge each '[namespace path].libsonnet' file in dir (e.g., 'apps.v1beta1.deployment.libsonnet') into that namespace
+  --allow-overrides              allow customizations to redefine generated fields, rather than failing
+  --namespace [key=namespace]    emit the definitions of a codebase, or of one of its groups, in another top-level namespace, e.g., 'kube-aggregator/apiregistration=aggregator'; codebases are named as in definition names (e.g., 'kubernetes', 'api', 'kube-aggregator'), and apiVersions are unchanged; may be repeated
+  --max-inline-depth [n]         how many levels of nested inline object schemas (e.g., in CRDs) get mixins of their own (default 5); deeper objects get plain setters
+  --lenient                      skip definitions whose names don't parse, with a warning, rather than failing; properties that refer to them accept arbitrary JSON
+  --only [group/version]         only generate the kinds of this version (e.g., 'apps/v1beta2'), and, as hidden objects, the definitions they use; may be repeated, and can't be combined with --include-group
+  --fail-on-removed-in [version] fail, listing each one, if the library has a kind that this version of Kubernetes (e.g., 'v1.16') no longer serves, e.g., 'extensions/v1beta1' 'Deployment'; such kinds are always emitted with a comment saying when they're removed and what replaces them
+  --comment-examples             add the examples the spec gives properties to their comments, if they're short scalars (e.g., '8080'); INDEX.md has every example
+  --embed-raw-schemas            add the hidden 'rawSchemas' namespace, with the JSON schema of each unparsable, malformed, or unsupported definition (e.g., 'rawSchemas["io.k8s.apimachinery.pkg.runtime.RawExtension"].schema'); filtered and blacklisted definitions aren't embedded
+  --emit-patch-helpers           add a 'patch' namespace to each kind, e.g., 'deployment.patch.spec.replace("replicas", 3)' for a JSON Patch operation, and 'pod.patch.spec.mergeListItem("containers", "app", {image: "x"})' for a strategic-merge patch of a list with a patch merge key; opt-in, since it's large
+  --emit-presets                 add the 'presets' namespace, with mixins of best-practice defaults made of the library's setters: 'securityHardened()' for a pod spec and its containers (non-root, read-only root filesystem, no privilege escalation, all capabilities dropped), 'seccompRuntimeDefault()' for a pod template, and 'minimalServiceAccount()', which disables token automount; fields the spec doesn't have are left out
+  --emit-field-order             add a hidden 'fieldOrder' array to each top-level kind (e.g., 'apps.v1.deployment.fieldOrder'), listing 'apiVersion', 'kind', 'metadata', and 'spec' first, then the rest of its fields in the order the spec declares them, and the 'util' namespace, whose 'reorder(obj, order)' rebuilds an object adding its fields in that order, for rendering pipelines that keep it (e.g., to write YAML to review)
+  --emit-gvk-index               also write 'gvkIndex.libsonnet', which maps the apiVersion and kind of each top-level kind (e.g., 'apps/v1beta2:Deployment') to a function returning its namespace of the library, and add 'util.forManifest(obj)', which returns the namespace of the kind of a manifest, e.g., to apply a mixin to whatever workload it is; kinds that more than one namespace has, or that the library skips, are left out with a comment
+  --emit-merge                   add 'util.merge(kindPath, a, b)' and a 'merge(other)' mixin to each top-level kind, which merge objects by their schemas: lists with a merge key item by item (e.g., containers by name), sets by value, and maps field by field, replacing atomic lists and scalars; can't be combined with --split-by-group
+  --emit-stringified-setters     give the setters of ConfigMap 'data', Secret 'stringData', and annotations a 'Stringified' variant (e.g., 'configMap.withDataStringified({replicas: 3, debug: true})'), which converts numbers, booleans, objects, and arrays to strings with 'util.toString'; the plain setters pass values through unchanged; can't be combined with --split-by-group
+  --emit-quantity                add 'util.quantity', to compute with quantities such as resource requests and limits: 'parse(q)' returns a quantity's value in base units (e.g., 0.5 for '500m', 1610612736 for '1.5Gi'), 'format(n, suffix)' writes a number with a suffix (e.g., 'format(1610612736, "Gi")' is '1.5Gi'), and 'add(a, b)' and 'scale(q, factor)' return quantities of the first one's suffix (e.g., 'scale("768Mi", 2)' is '1536Mi'); binary (Ki to Ei) and decimal (n to E) suffixes and exponents (e.g., '1e3') are accepted, and an unknown suffix fails
+  --emit-options-helpers         add helpers to 'util' for the parameters of operations, from the spec's paths: 'deleteOptions.new(...)', with a named parameter for each field of the body of deletes (e.g., 'deleteOptions.new(propagationPolicy="Foreground", gracePeriodSeconds=0)'), 'listOptions.new(...)', with one for each query parameter of lists (e.g., 'limit'), and 'listSelectors(labelMap, fieldMap)', which renders maps as a 'labelSelector' and a 'fieldSelector', escaping backslashes, commas and equals signs in values; arguments are checked against their types
+  --emit-validation              make the setters of properties whose schemas set 'minimum', 'maximum', 'minLength' or 'maxLength' (as CRD schemas often do) assert them, e.g., "'replicas' must be at least 0, got -1"; assertions fail when the object is manifested, and 'pattern' is only documented, since Jsonnet has no regular expressions
+  --split-by-group               write the library as a file per group (e.g., 'apps.libsonnet'), which 'k8s.libsonnet' imports as the group's namespace, and 'hidden.libsonnet', with the hidden objects, rather than as one 'k8s.libsonnet'; the symbol index maps each group to its file
+  --file-naming [strategy]       how --split-by-group names files: 'dashes' (default) lowercases the API group of the group's kinds and replaces every run of other characters than letters and digits with '-' (e.g., 'rbac-authorization-k8s-io.libsonnet'), 'underscores' does the same with '_', and 'namespace' uses the group's namespace (e.g., 'rbac.libsonnet'); names that collide, ignoring case, get '-2', '-3', and so on, in order of namespace
+  --split-hidden                 with --split-by-group, write the hidden objects of each group to a file of their own too (e.g., 'hidden.core.libsonnet'), which 'hidden.libsonnet' imports, so that evaluating a program parses only the files of the groups the kinds it uses refer to, rather than every group's hidden objects
+  --kind-collisions [policy]    how a kind that more than one API group has is named in k.libsonnet, INDEX.md, the samples, and gvkIndex.libsonnet: 'qualify-on-conflict' (default) gives the preferred (or else alphabetically first) group the plain name and qualifies the others with their group's first label (e.g., 'postgresDatabase'), 'qualify-always' qualifies every kind that way, and 'error' fails generation
+  --import-base [path]          make the library's files import each other by absolute paths under 'path' (e.g., 'github.com/ourorg/k8s-libsonnet/apps.libsonnet' rather than 'apps.libsonnet'), for a library vendored at that path of a Jsonnet library path (e.g., 'jsonnet -J vendor'); it's checked that every import resolves with the library there
+  --namespace-prefix [path]     nest the whole tree of the library under this dotted path of identifiers (e.g., 'lib.k8s' for 'lib.k8s.apps.v1.deployment'), as are the aliases of k.libsonnet (e.g., 'lib.k8s.deployment'); gvkIndex.libsonnet, the symbol index, INDEX.md, the samples, and the smoke tests follow it
+  --header-template [file]      replace the comments that start each '.libsonnet' file of the library (e.g., with a license header) with the output of the Go text/template in file, executed with the file's name ('{{.File}}'), the spec's version and digest ('{{.KubernetesVersion}}', '{{.SpecSHA256}}'), the options ('{{.Options}}'), and the header the file would have otherwise ('{{.Default}}'); an error in the template fails generation, with its line
+  --external-refs [lib=dir]     link the library to a library generated on its own (e.g., 'core=./k8s' for a library of CRDs, whose 'metadata' refers to the core library's 'ObjectMeta'): a property that refers to a definition the spec lacks gets a type alias into that library's 'k8s.libsonnet', imported as 'dir/k8s.libsonnet' through the library path (e.g., 'jsonnet -J vendor' with the core library in 'vendor/k8s'), rather than only opaque setters; the fallback apimachinery definitions that it has aren't added; may be repeated
+  --external-index [lib=file]   the symbol index of a library of --external-refs (e.g., 'core=./k8s/symbols.json', as 'check --update-baseline' writes it), which says where the library has each definition; generation fails, listing them, if a reference is to a definition that no external library has
+  --emit-extension-points        emit the hooks of the Kubernetes version's table (e.g., 'volumeSourcesExt' in the namespace of 'Volume', which the 'volumesType' of pod specs points at; 'envSourcesExt' in that of 'EnvVar'; 'envFromSourcesExt' in that of 'EnvFromSource') as empty hidden objects, indexed as extension points, for a library generated on its own with --extends to fill
+  --extends [file]               the symbol index of a library generated with --emit-extension-points (e.g., './k8s/symbols.json', as 'check --update-baseline' writes it), whose hooks --extension fills in 'coreExtensions.libsonnet', an overlay that users merge into that library, e.g., '(import "k8s/k8s.libsonnet") + (import "crds/coreExtensions.libsonnet")'; neither library imports the other; requires --extension
+  --extension [hook=Kind:Manages]  fill a hook of the library of --extends with a kind of this library whose resources manage an object of their own name, of a kind the hook has a source for: e.g., 'volumeSourcesExt=SealedSecret:Secret' adds 'volumeSourcesExt.fromSealedSecret(name, sealedSecret)', which returns a volume of the secret, and 'volumeSourcesExt.sealedSecret', the kind's namespace; a kind that more than one group has is qualified with its apiVersion (e.g., 'mysql.example.com/v1/Database'); generation fails if the hook isn't in the index, listing those that are; may be repeated
+  --diff-friendly                emit each definition's namespace under a banner comment with its name, order the namespaces of each version by definition name, and write the list of group/versions to 'apiVersions.json' rather than the header of 'k8s.libsonnet', so that regenerating from a newer spec changes as few lines as possible
+  --no-comments                  leave the comments out of the library (descriptions, '@type' lines, and so on), but for the header of each file, for a smaller library to deploy; it evaluates the same
+  --comments [mode]              how much of each description from the spec comments keep: 'full' (default), or 'first-sentence', which keeps its first sentence (an 'e.g.' or a version such as '1.16' doesn't end one); 'section=mode' sets the mode of one section, 'kinds' (the namespaces of definitions) or 'properties' (their setters and mixins), whose budget then replaces the default's, e.g., '--comments first-sentence --comments kinds=full'; may be repeated
+  --max-comment-lines [n]        keep at most n lines of each description in comments, ending those cut short with an ellipsis and a pointer to 'ksonnet-gen explain'; sections as for --comments; INDEX.md and the symbol index always have whole descriptions
+  --compact                      share the mixins of definitions that more than --compact-threshold properties refer to (e.g., 'ObjectMeta') as locals of 'k8s.libsonnet', which those properties' namespaces call, rather than expanding them under each; the library evaluates the same, and the size it saves is logged; can't be combined with --split-by-group
+  --compact-threshold [n]        how many properties must refer to a definition for --compact to share its mixins, less one (default 2)
+  --share-identical-kinds        emit the body of top-level kinds whose schemas are identical in several groups or versions (e.g., 'Scale') once, as a local of 'k8s.libsonnet' that each of their namespaces calls with its apiVersion, rather than a copy under each; each kind evaluates the same; can't be combined with --split-by-group
+  --full-beta-duplicates         emit top-level kinds of beta versions that are identical to the same kind in a GA version of their group (e.g., 'apps/v1beta2' 'Deployment') in full, rather than as the GA kind's namespace whose constructor sets the beta apiVersion, with a comment recommending the GA kind
+  --provenance-fields [fields]   record these fields of the spec's 'info' and 'securityDefinitions' in the header of 'k8s.libsonnet' and in the symbol index, e.g., to record which cluster and auth mode the library was generated from: a comma-separated list of 'title', 'version', 'contact' (whose email may be personal), and 'authModes' (each scheme's name and type, and its header or OAuth flow, without descriptions or URLs); nothing is recorded by default
+  --compat ksonnet-0.x           also emit the hidden helpers of the mixin namespaces of ksonnet 0.x's libraries (e.g., 'deployment.mixin.spec.mixinInstance(spec)'), for the kinds whose apps call them, as deprecated wrappers of the modern mixins, so that apps written against ksonnet 0.x keep working; can't be combined with --compact
+  --warn-deprecated-shims        make the shims that the library emits for properties renamed between versions of Kubernetes (e.g., 'pod.mixin.spec.serviceAccount', a wrapper of 'serviceAccountName') warn with 'std.trace', naming their replacement, each time they're evaluated; they're silent by default
+  --naming [strategy]            how identifiers from the spec are named: 'curated' (default) rewrites only a hand-curated list (e.g., 'hostIPC' -> 'hostIpc'), 'go-initialisms' lowercases every initialism (e.g., 'externalIPs' -> 'externalIps'), and 'uniform-params' names identifiers as 'curated' does, and every parameter that takes a property after it, e.g., 'withStorageClassName(storageClassName)', with a trailing '_' if that's reserved or taken (e.g., 'std_')
+
+Spec flags (every command):
+  --definition-prefixes [list]  comma-separated prefixes of the definition names to parse (default 'io.k8s'); e.g., 'io.k8s,com.github.openshift' also generates OpenShift's kinds, grouped by their API group (e.g., 'routeOpenshiftIo')
+
+Spec flags (generate and check):
+  --strict-extensions  fail if the spec uses vendor extensions that ksonnet-gen doesn't model, rather than logging them
+
+Spec flags (generate and prune-to-usage):
+  --strict-refs  fail, listing each one, if a property refers to a definition that isn't in the spec after filtering or pruning, rather than re-including the definition from the full spec (or, if it isn't there either, warning and emitting the property as an opaque setter)
+
+Spec flags (generate and subset):
+  --include-group [group]  only keep the kinds of this API group, named as in the library (e.g., 'apps', 'core') or as in their apiVersion (e.g., 'rbac.authorization.k8s.io'), and the definitions they use; may be repeated
+  --exclude-alpha          drop alpha versions (e.g., 'autoscaling/v2alpha1'), keeping only the definitions in them that other versions use
+  --exclude-beta           drop beta versions (e.g., 'apps/v1beta1') likewise
+
+CRD flags (generate):
+  --crd [file]          also generate the custom resources of the CRDs in this JSON or YAML file (YAML may hold several, separated by '---'), with a namespace for each version they serve (e.g., 'stableExampleCom.v1beta1.cronTab'), whose constructor sets that version's apiVersion; each version has its own schema, or the CRD's 'validation' schema, and the version the CRD stores is noted in its comments and marked 'storage' in the symbol index; may be repeated
+  --include-unserved    also generate the versions the CRDs list with 'served: false'
+  --on-conflict [policy]  what to do with a definition (e.g., 'io.k8s.crd.api.mysqlExampleCom.v1.Database') that more than one --crd file adds, with different schemas, e.g., CRDs of two clusters at different versions of an operator: 'error' (default) fails, and 'fork' keeps both, under the group of the first file's (e.g., 'mysqlExampleCom'), and of the later file's suffixed with its label (e.g., 'mysqlExampleCom_databases_v2'), with the same apiVersion; the file's definitions that refer to a fork are rewritten to refer to it, and those the spec already has, identically, are forked too, while every other definition is left as it is; identical definitions are shared, and each fork is logged and recorded under 'forks' in the symbol index
+  --source-label [file=label]  the label of a --crd file's forks (letters, digits and underscores); defaults to the file's name, without its extension, e.g., 'databases_v2' for 'databases-v2.yaml'; may be repeated
+  --no-embedded-meta    don't fall back to the built-in apimachinery definitions (ObjectMeta, LabelSelector, Time, IntOrString, Quantity, and so on, from a pinned Kubernetes spec) when the spec lacks the ones the CRDs need for 'metadata' or its properties refer to, e.g., when generating from CRDs alone, with no spec; each one taken from it is logged
+
+Profile flags (generate):
+  --profile [name or file]  start from a profile of settings: 'minimal' (no alpha or beta versions, no INDEX.md), 'full' (every optional feature), or a YAML file whose keys are those --print-profile writes (e.g., 'excludeAlpha: true'); flags passed as well override it
+  --print-profile           print the effective profile, with flags applied, as YAML, rather than generating
+
+Output flags (generate and prune-to-usage):
+  --no-index-doc              don't write 'INDEX.md', a table of contents of the library's groups, versions, and kinds, to the output dir
+  --output-format [format]    write the library, with its index, tests, and checksums, to a directory ('dir', the default), creating it if need be, or to the gzipped tar ('tar') or zip ('zip') archive that -o (or the output dir argument) names; each file of a directory, and an archive as a whole, is written to a temporary file that replaces the target once it's complete
+
+Report flags (generate and prune-to-usage):
+  --checksums           also write 'SHA256SUMS' to the output dir, with the SHA-256 digest of every generated file in the format of 'sha256sum'
+  --provenance          (generate) also write 'provenance.json' to the output dir, a manifest of what the library was generated from, for supply-chain checks: the SHA-256 digests of the spec, CRD files, and external symbol indexes, the effective profile (as --print-profile shows it), the generator's version and commit, and the digest of every generated file; it's canonical JSON (sorted keys, no whitespace, integers only), so that a detached signature of it, made with other tools, can be checked; 'verify-provenance [dir]' checks the files against it
+  --skip-report [file]  write a JSON array of every definition that isn't emitted, with its name, reason ('unparsable', 'malformed', 'unsupported', 'filtered', or 'blacklisted'), detail, and source file, sorted by name; written even if it's empty
+
+Log flags (generate, check, and prune-to-usage):
+  --v         log each phase of generation, with durations and counts, to stderr
+  --timing    print a summary of how long each phase took to stderr
+  --progress  show a progress line for parsing and emitting on stderr`
+
+// commands maps the name of each subcommand to the function that
+// implements it. Any invocation that doesn't start with one of these
+// names generates `k8s.libsonnet`.
+var commands = map[string]func(ctx context.Context, args []string){
+	"bundle":            bundle,
+	"check":             check,
+	"explain":           explain,
+	"graph":             graph,
+	"lint":              lint,
+	"migrate-imports":   migrateImports,
+	"prune-to-usage":    pruneToUsage,
+	"roundtrip":         roundTrip,
+	"samples":           samples,
+	"search":            search,
+	"stats":             stats,
+	"subset":            subset,
+	"verify-cluster":    verifyCluster,
+	"verify-provenance": verifyProvenance,
+}
 
 func main() {
-	if len(os.Args) != 3 {
+	// Interrupting stops fetching the spec and emitting the library;
+	// see `exitIfInterrupted`.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	defer exitIfPanicked()
+
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(ctx, os.Args[2:])
+			return
+		}
+	}
+
+	generate(ctx, os.Args[1:])
+}
+
+// exitIfInterrupted exits with the conventional status for SIGINT if
+// `err` is because the command was interrupted. The library is emitted
+// in memory before any of it is written, so an interrupted emit leaves
+// the output directory as it was.
+func exitIfInterrupted(err error) {
+	if errors.Is(err, context.Canceled) {
+		log.Printf("Interrupted:\n%v", err)
+		os.Exit(130)
+	}
+}
+
+func generate(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("ksonnet-gen", flag.ExitOnError)
+	emitTests := flags.Bool(
+		"emit-tests", false,
+		"also write Jsonnet smoke tests for each API group to '[output dir]/tests'")
+	target := flags.String("o", "", "the directory or archive to write the library to, rather than the output dir argument")
+	format := outputFormatFlag(flags)
+	opts := emitOptionFlags(flags)
+	logger := logFlags(flags)
+	strict := strictExtensionsFlag(flags)
+	strictRefs := strictRefsFlag(flags)
+	groups := includeGroupsFlag(flags)
+	stages := excludeStagesFlags(flags)
+	skipReport := skipReportFlag(flags)
+	checksums := checksumsFlag(flags)
+	provenance := provenanceFlag(flags)
+	noIndexDoc := noIndexDocFlag(flags)
+	crds := crdFlagsFor(flags)
+	models := modelFlagsFor(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	profileName, printOnly := profileFlags(flags)
+	flags.Parse(args)
+
+	p := resolveProfile(*profileName, flags, &generateProfile{
+		Options:            *opts,
+		DefinitionPrefixes: *prefixes,
+		IncludeGroups:      *groups,
+		ExcludeAlpha:       stages.alpha,
+		ExcludeBeta:        stages.beta,
+		StrictExtensions:   *strict,
+		StrictRefs:         *strictRefs,
+		EmitTests:          *emitTests,
+		NoIndexDoc:         *noIndexDoc,
+		Checksums:          *checksums,
+		Provenance:         *provenance,
+		SkipReport:         *skipReport,
+	})
+	if *printOnly {
+		printProfile(p)
+		return
+	}
+	// A model takes the place of the spec.
+	positional := flags.Args()
+	if models.from != "" {
+		positional = append([]string{models.from}, positional...)
+	}
+	if *target == "" && len(positional) == 2 {
+		*target = positional[1]
+	} else if *target == "" || (len(positional) != 1 && !(len(positional) == 0 && len(crds.files) > 0)) {
 		log.Fatal(usage)
 	}
+	swaggerPath := ""
+	if len(positional) > 0 {
+		swaggerPath = positional[0]
+	}
+	*prefixes = p.DefinitionPrefixes
+	*groups = p.IncludeGroups
+	*stages = stagesFlags{alpha: p.ExcludeAlpha, beta: p.ExcludeBeta}
+	*opts = p.Options
+	opts.Logger = logger
+	opts.Progress = logger.progressFunc()
+
+	opts.Parser = prefixes.parser()
+
+	var loaded *kubespec.APISpec
+	if models.from != "" {
+		loaded = loadModel(models.from, opts.Parser, logger)
+	} else if swaggerPath == "" {
+		loaded = crdOnlySpec()
+	} else {
+		loaded = loadSpec(ctx, swaggerPath, logger)
+	}
+	if models.save != "" {
+		saveModel(loaded, models.save, opts.Parser, logger)
+	}
+	withCRDs := addCRDs(loaded, crds, prefixes, logger)
+	original := externalizeEmbeddedMeta(loaded, withCRDs, opts.ExternalIndexes, logger)
+	checkVersion(original, logger)
+	checkExtensions(original, p.StrictExtensions)
+	s := checkRefs(original, filterSpec(original, *groups, stages, opts.Parser), p.StrictRefs, opts.ExternalIndexes, logger)
+
+	out := openOutput(*format, *target)
+	artifacts := writeLibrary(ctx, s, *opts, out, !p.NoIndexDoc, logger)
+	warnReservedIdentifiers(s, *opts)
+	if p.EmitTests {
+		for name, artifact := range writeTests(s, *opts, out, logger) {
+			artifacts[name] = artifact
+		}
+	}
+	if p.Checksums {
+		writeChecksums(artifacts, out, logger)
+	}
+	if p.Provenance {
+		inputs := provenanceInputs(swaggerPath, loaded, crds, opts.ExternalIndexes)
+		writeProvenance(p, inputs, artifacts, out, logger)
+	}
+	out.finalize()
+	reportSkipped(
+		p.SkipReport, swaggerPath, original, s, *opts,
+		filterDetail(*groups, stages))
+	logger.printTiming()
+}
 
-	swaggerPath := os.Args[1]
-	text, err := ioutil.ReadFile(swaggerPath)
+// writeLibrary writes `k8s.libsonnet` (and, if `opts.SplitByGroup` is
+// set, the files it imports), `k.libsonnet`, `labels.libsonnet`, and,
+// if `indexDoc` is set, `INDEX.md` to `out`, returning them keyed by
+// file name.
+func writeLibrary(
+	ctx context.Context, s *kubespec.APISpec, opts ksonnet.Options, out *output, indexDoc bool,
+	logger *cliLogger,
+) map[string]*ksonnet.Artifact {
+	artifacts, err := ksonnet.EmitArtifacts(ctx, s, opts)
 	if err != nil {
-		log.Fatalf("Could not read file at '%s':\n%v", swaggerPath, err)
+		fatal(fmt.Errorf("Could not write ksonnet library:\n%w", err))
 	}
+	if !indexDoc {
+		delete(artifacts, ksonnet.IndexDocFile)
+	}
+
+	// The aliases import the library from the same directory, so
+	// they're written to it.
+	names := []string{}
+	for name := range artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		start := time.Now()
+		out.writeFile(name, artifacts[name].Text)
+		logger.Log(
+			"write", "path", out.path(name), "bytes", len(artifacts[name].Text),
+			"duration", time.Since(start))
+	}
+	return artifacts
+}
 
-	// Deserialize the API object.
-	s := kubespec.APISpec{}
-	err = json.Unmarshal(text, &s)
+// noIndexDocFlag registers `--no-index-doc` on `flags`.
+func noIndexDocFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool(
+		"no-index-doc", false,
+		"don't write a table of contents of the library to '[output dir]/INDEX.md'")
+}
+
+// writeTests writes the smoke tests for the library to the `tests`
+// directory of `out`, returning them keyed by their path relative to
+// it.
+func writeTests(
+	s *kubespec.APISpec, opts ksonnet.Options, out *output, logger *cliLogger,
+) map[string]*ksonnet.Artifact {
+	tests, err := ksonnet.EmitTests(s, opts)
 	if err != nil {
-		log.Fatalf("Could not deserialize schema:\n%v", err)
+		fatal(fmt.Errorf("Could not write ksonnet library tests:\n%w", err))
 	}
-	s.Text = text
-	s.FilePath = filepath.Dir(swaggerPath)
 
-	// Emit Jsonnet code.
-	jsonnetBytes, err := ksonnet.Emit(&s)
+	start := time.Now()
+	names := []string{}
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	artifacts := map[string]*ksonnet.Artifact{}
+	for _, name := range names {
+		out.writeFile("tests/"+name, tests[name])
+		artifacts["tests/"+name] = ksonnet.NewArtifact(tests[name])
+	}
+	logger.Log(
+		"write tests", "path", out.path("tests"), "files", len(tests),
+		"duration", time.Since(start))
+	return artifacts
+}
+
+// emitOptionFlags registers the flags that customize the generated
+// library on `flags`, returning the `Options` they populate. These
+// are shared by every subcommand that emits (or indexes) the library,
+// so that they all agree on what the library contains.
+func emitOptionFlags(flags *flag.FlagSet) *ksonnet.Options {
+	opts := &ksonnet.Options{}
+	flags.BoolVar(
+		&opts.DeprecateClusterScopedNamespace, "deprecate-cluster-namespace", false,
+		"emit 'metadata.namespace' mixins for cluster-scoped kinds with a deprecation comment")
+	flags.Var(
+		(*namingStrategyFlag)(&opts.NamingStrategy), "naming",
+		"how identifiers are named: 'curated' (default), 'go-initialisms', or 'uniform-params'")
+	flags.Var(
+		(*customizationsFlag)(&opts.Customizations), "customizations",
+		"directory of Jsonnet files (e.g., 'apps.v1beta1.deployment.libsonnet') to merge into the namespaces they're named after")
+	flags.BoolVar(
+		&opts.AllowOverrides, "allow-overrides", false,
+		"allow customizations to redefine generated fields")
+	flags.Var(
+		(*namespacesFlag)(&opts.Namespaces), "namespace",
+		"emit a codebase, or one of its groups, in another top-level namespace (e.g., 'kube-aggregator/apiregistration=aggregator'); may be repeated")
+	flags.IntVar(
+		&opts.MaxInlineDepth, "max-inline-depth", ksonnet.DefaultMaxInlineDepth,
+		"how many levels of nested inline object schemas get mixins of their own")
+	flags.BoolVar(
+		&opts.Lenient, "lenient", false,
+		"skip definitions whose names don't parse, with a warning, rather than failing")
+	flags.Var(
+		(*versionsFlag)(&opts.OnlyVersions), "only",
+		"only generate the kinds of this group/version (e.g., 'apps/v1beta2'), and the definitions they use; may be repeated")
+	flags.StringVar(
+		&opts.FailOnRemovedIn, "fail-on-removed-in", "",
+		"fail if the library has a kind that this version of Kubernetes (e.g., 'v1.16') no longer serves")
+	flags.BoolVar(
+		&opts.CommentExamples, "comment-examples", false,
+		"add short scalar examples from the spec to the comments of properties")
+	flags.BoolVar(
+		&opts.EmitPatchHelpers, "emit-patch-helpers", false,
+		"add a 'patch' namespace to each kind, with helpers that return JSON Patch operations and strategic-merge patches")
+	flags.BoolVar(
+		&opts.EmitPresets, "emit-presets", false,
+		"add the 'presets' namespace, with mixins of best-practice defaults, e.g., 'presets.securityHardened()'")
+	flags.BoolVar(
+		&opts.EmitValidation, "emit-validation", false,
+		"make setters assert the minimum, maximum, minLength and maxLength their schemas set")
+	flags.BoolVar(
+		&opts.EmitFieldOrder, "emit-field-order", false,
+		"add a hidden 'fieldOrder' to each top-level kind, and 'util.reorder', which rebuilds an object in that order")
+	flags.BoolVar(
+		&opts.EmitGVKIndex, "emit-gvk-index", false,
+		"also write 'gvkIndex.libsonnet', mapping the apiVersion and kind of each top-level kind to its namespace, and add 'util.forManifest'")
+	flags.BoolVar(
+		&opts.EmitMerge, "emit-merge", false,
+		"add 'util.merge(kindPath, a, b)' and a 'merge(other)' mixin to each top-level kind, which merge objects by their schemas, e.g., lists of containers by name")
+	flags.BoolVar(
+		&opts.EmitStringifiedSetters, "emit-stringified-setters", false,
+		"give the setters of ConfigMap data, Secret stringData, and annotations a 'Stringified' variant, which converts their values to strings")
+	flags.BoolVar(
+		&opts.EmitQuantity, "emit-quantity", false,
+		"add 'util.quantity', whose 'parse', 'format', 'add', and 'scale' compute with quantities such as '500m' and '1.5Gi'")
+	flags.BoolVar(
+		&opts.EmitOptionsHelpers, "emit-options-helpers", false,
+		"add 'util.deleteOptions.new', 'util.listOptions.new', and 'util.listSelectors', for the bodies and query parameters of deletes and lists")
+	flags.BoolVar(
+		&opts.SplitByGroup, "split-by-group", false,
+		"write a file per group, which k8s.libsonnet imports, rather than one k8s.libsonnet")
+	flags.Var(
+		(*fileNamingFlag)(&opts.FileNaming), "file-naming",
+		"how --split-by-group names files: 'dashes' (default), 'underscores', or 'namespace'")
+	flags.BoolVar(
+		&opts.SplitHidden, "split-hidden", false,
+		"with --split-by-group, write the hidden objects of each group to a file hidden.libsonnet imports, so that programs load only those of the groups they use")
+	flags.Var(
+		(*collisionPolicyFlag)(&opts.KindCollisions), "kind-collisions",
+		"how kinds that more than one API group has are named: 'qualify-on-conflict' (default), 'qualify-always', or 'error'")
+	flags.StringVar(
+		&opts.ImportBase, "import-base", "",
+		"import the library's files from each other by absolute paths under this path (e.g., 'github.com/ourorg/k8s-libsonnet'), resolved through the library path, rather than relative ones")
+	flags.StringVar(
+		&opts.NamespacePrefix, "namespace-prefix", "",
+		"nest the whole tree of the library under this dotted path (e.g., 'lib.k8s')")
+	flags.Var(
+		(*headerTemplateFlag)(&opts.HeaderTemplate), "header-template",
+		"a Go text/template file whose output replaces the comments that start each '.libsonnet' file")
+	flags.Var(
+		(*externalRefsFlag)(&opts.ExternalRefs), "external-refs",
+		"refer to the definitions the spec lacks in the library generated in this directory of the library path (e.g., 'core=./k8s'), rather than emitting opaque setters; may be repeated")
+	flags.Var(
+		(*externalIndexesFlag)(&opts.ExternalIndexes), "external-index",
+		"the symbol index of a library of --external-refs (e.g., 'core=./k8s/symbols.json'); may be repeated")
+	flags.BoolVar(
+		&opts.EmitExtensionPoints, "emit-extension-points", false,
+		"emit empty hidden hooks (e.g., 'volumeSourcesExt') that a library of CRDs generated with --extends fills, without either library importing the other")
+	flags.Var(
+		&extendsFlag{index: &opts.Extends}, "extends",
+		"the symbol index of a library generated with --emit-extension-points (e.g., './k8s/symbols.json'), whose hooks --extension fills in 'coreExtensions.libsonnet'")
+	flags.Var(
+		(*extensionsFlag)(&opts.Extensions), "extension",
+		"fill a hook of the library of --extends with a kind that manages an object of its name, e.g., 'volumeSourcesExt=SealedSecret:Secret'; may be repeated")
+	flags.BoolVar(
+		&opts.DiffFriendly, "diff-friendly", false,
+		"emit the library so that regenerating it from a newer spec changes as few lines as possible")
+	flags.BoolVar(
+		&opts.OmitComments, "no-comments", false,
+		"leave the comments out of the library, but for the header of each file")
+	flags.Var(
+		(*commentModesFlag)(&opts.CommentBudgets), "comments",
+		"how much of each description comments keep: 'full' (default) or 'first-sentence'; 'section=mode' sets a section's ('kinds' or 'properties'); may be repeated")
+	flags.Var(
+		(*maxCommentLinesFlag)(&opts.CommentBudgets), "max-comment-lines",
+		"keep at most this many lines of each description in comments, ending with a pointer to 'explain'; 'section=n' sets a section's ('kinds' or 'properties'); may be repeated")
+	flags.BoolVar(
+		&opts.Compact, "compact", false,
+		"share the mixins of definitions that many properties refer to, rather than expanding them under each")
+	flags.IntVar(
+		&opts.CompactThreshold, "compact-threshold", ksonnet.DefaultCompactThreshold,
+		"share the mixins of definitions that more than this many properties refer to, with --compact")
+	flags.BoolVar(
+		&opts.ShareIdenticalKinds, "share-identical-kinds", false,
+		"emit the body of top-level kinds that are identical in several groups once, rather than a copy under each")
+	flags.BoolVar(
+		&opts.FullBetaDuplicates, "full-beta-duplicates", false,
+		"emit beta kinds identical to a GA kind of their group in full, rather than as an alias of the GA kind")
+	flags.Var(
+		(*provenanceFieldsFlag)(&opts.ProvenanceFields), "provenance-fields",
+		"record these fields of the spec's info and securityDefinitions in the header of k8s.libsonnet and the symbol index: a comma-separated list of 'title', 'version', 'contact', and 'authModes'")
+	flags.Var(
+		(*compatFlag)(&opts.Compat), "compat",
+		"also emit the hidden helpers of a legacy generator's libraries as deprecated wrappers: 'ksonnet-0.x'")
+	flags.BoolVar(
+		&opts.WarnDeprecatedShims, "warn-deprecated-shims", false,
+		"make the shims of renamed properties warn with 'std.trace', naming their replacement, when they're evaluated")
+	flags.BoolVar(
+		&opts.EmbedRawSchemas, "embed-raw-schemas", false,
+		"embed the JSON schemas of definitions the library can't model in the hidden 'rawSchemas' namespace")
+	return opts
+}
+
+// customizationsFlag loads `ksonnet.Options.Customizations` from a
+// directory, in which each `[namespace path].libsonnet` file holds the
+// customizations for that namespace.
+type customizationsFlag map[string]string
+
+func (f *customizationsFlag) String() string {
+	return ""
+}
+
+func (f *customizationsFlag) Set(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.libsonnet"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("No '.libsonnet' files in customizations directory '%s'", dir)
+	}
+
+	customizations := map[string]string{}
+	for _, file := range files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		path := strings.TrimSuffix(filepath.Base(file), ".libsonnet")
+		customizations[path] = string(text)
+	}
+	*f = customizations
+	return nil
+}
+
+// headerTemplateFlag loads `ksonnet.Options.HeaderTemplate` from a
+// file.
+type headerTemplateFlag string
+
+func (f *headerTemplateFlag) String() string {
+	return ""
+}
+
+func (f *headerTemplateFlag) Set(file string) error {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	*f = headerTemplateFlag(text)
+	return nil
+}
+
+// namespacesFlag adapts a repeated `key=namespace` flag to
+// `ksonnet.Options.Namespaces`.
+type namespacesFlag map[string]string
+
+func (f *namespacesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	entries := []string{}
+	for key, namespace := range *f {
+		entries = append(entries, key+"="+namespace)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func (f *namespacesFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i == -1 {
+		return fmt.Errorf("Expected 'codebase=namespace' or 'codebase/group=namespace', got '%s'", value)
+	}
+	if *f == nil {
+		*f = map[string]string{}
+	}
+	(*f)[value[:i]] = value[i+1:]
+	return nil
+}
+
+// namingStrategyFlag adapts `jsonnet.NamingStrategy` to `flag.Value`.
+type namingStrategyFlag jsonnet.NamingStrategy
+
+func (f *namingStrategyFlag) String() string {
+	if f == nil || *f == "" {
+		return string(jsonnet.NamingStrategyCurated)
+	}
+	return string(*f)
+}
+
+func (f *namingStrategyFlag) Set(value string) error {
+	ns, err := jsonnet.ParseNamingStrategy(value)
 	if err != nil {
-		log.Fatalf("Could not write ksonnet library:\n%v", err)
+		return err
 	}
+	*f = namingStrategyFlag(ns)
+	return nil
+}
+
+// fileNamingFlag adapts `ksonnet.FileNaming` to `flag.Value`.
+type fileNamingFlag ksonnet.FileNaming
+
+func (f *fileNamingFlag) String() string {
+	if f == nil || *f == "" {
+		return string(ksonnet.FileNamingDashes)
+	}
+	return string(*f)
+}
+
+func (f *fileNamingFlag) Set(value string) error {
+	fn, err := ksonnet.ParseFileNaming(value)
+	if err != nil {
+		return err
+	}
+	*f = fileNamingFlag(fn)
+	return nil
+}
+
+// collisionPolicyFlag adapts `ksonnet.CollisionPolicy` to `flag.Value`.
+type collisionPolicyFlag ksonnet.CollisionPolicy
+
+func (f *collisionPolicyFlag) String() string {
+	if f == nil || *f == "" {
+		return string(ksonnet.CollisionsQualifyOnConflict)
+	}
+	return string(*f)
+}
 
-	// Write out.
-	outfile := fmt.Sprintf("%s/%s", os.Args[2], "k8s.libsonnet")
-	err = ioutil.WriteFile(outfile, jsonnetBytes, 0644)
+func (f *collisionPolicyFlag) Set(value string) error {
+	policy, err := ksonnet.ParseCollisionPolicy(value)
 	if err != nil {
-		log.Fatalf("Could not write `kube.libsonnet`:\n%v", err)
+		return err
+	}
+	*f = collisionPolicyFlag(policy)
+	return nil
+}
+
+// compatFlag adapts `ksonnet.Compat` to `flag.Value`.
+type compatFlag ksonnet.Compat
+
+func (f *compatFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *compatFlag) Set(value string) error {
+	compat, err := ksonnet.ParseCompat(value)
+	if err != nil {
+		return err
+	}
+	*f = compatFlag(compat)
+	return nil
+}
+
+// provenanceFieldsFlag adapts a comma-separated flag to
+// `ksonnet.Options.ProvenanceFields`.
+type provenanceFieldsFlag []ksonnet.ProvenanceField
+
+func (f *provenanceFieldsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	fields := []string{}
+	for _, field := range *f {
+		fields = append(fields, string(field))
+	}
+	return strings.Join(fields, ",")
+}
+
+func (f *provenanceFieldsFlag) Set(value string) error {
+	fields := provenanceFieldsFlag{}
+	for _, name := range strings.Split(value, ",") {
+		field, err := ksonnet.ParseProvenanceField(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+	}
+	*f = fields
+	return nil
+}
+
+// commentModesFlag adapts a repeated `[section=]mode` flag to the
+// modes of `ksonnet.Options.CommentBudgets`; a mode without a section
+// is `ksonnet.CommentSectionDefault`'s.
+type commentModesFlag map[ksonnet.CommentSection]ksonnet.CommentBudget
+
+func (f *commentModesFlag) String() string {
+	return commentBudgetsString(*f, func(budget ksonnet.CommentBudget) string {
+		return string(budget.Mode)
+	})
+}
+
+func (f *commentModesFlag) Set(value string) error {
+	section, value, err := commentSection(value)
+	if err != nil {
+		return err
+	}
+	mode, err := ksonnet.ParseCommentMode(value)
+	if err != nil {
+		return err
+	}
+	if *f == nil {
+		*f = commentModesFlag{}
+	}
+	budget := (*f)[section]
+	budget.Mode = mode
+	(*f)[section] = budget
+	return nil
+}
+
+// maxCommentLinesFlag adapts a repeated `[section=]n` flag to the
+// `MaxLines` of `ksonnet.Options.CommentBudgets`, like
+// `commentModesFlag`.
+type maxCommentLinesFlag map[ksonnet.CommentSection]ksonnet.CommentBudget
+
+func (f *maxCommentLinesFlag) String() string {
+	return commentBudgetsString(*f, func(budget ksonnet.CommentBudget) string {
+		if budget.MaxLines == 0 {
+			return ""
+		}
+		return strconv.Itoa(budget.MaxLines)
+	})
+}
+
+func (f *maxCommentLinesFlag) Set(value string) error {
+	section, value, err := commentSection(value)
+	if err != nil {
+		return err
+	}
+	lines, err := strconv.Atoi(value)
+	if err != nil || lines < 0 {
+		return fmt.Errorf("Expected a number of lines of at least 0, got '%s'", value)
+	}
+	if *f == nil {
+		*f = maxCommentLinesFlag{}
+	}
+	budget := (*f)[section]
+	budget.MaxLines = lines
+	(*f)[section] = budget
+	return nil
+}
+
+// commentSection splits the section off a `[section=]value` flag.
+func commentSection(value string) (ksonnet.CommentSection, string, error) {
+	i := strings.Index(value, "=")
+	if i == -1 {
+		return ksonnet.CommentSectionDefault, value, nil
+	}
+	section, err := ksonnet.ParseCommentSection(value[:i])
+	return section, value[i+1:], err
+}
+
+// commentBudgetsString formats the field of `budgets` that `field`
+// returns as `section=value` entries, with `field` returning "" for
+// unset values.
+func commentBudgetsString(
+	budgets map[ksonnet.CommentSection]ksonnet.CommentBudget,
+	field func(ksonnet.CommentBudget) string,
+) string {
+	entries := []string{}
+	for section, budget := range budgets {
+		if value := field(budget); value != "" {
+			entries = append(entries, fmt.Sprintf("%s=%s", section, value))
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// versionsFlag adapts a repeated flag to `ksonnet.Options.OnlyVersions`.
+type versionsFlag []kubespec.GroupVersion
+
+func (f *versionsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	versions := []string{}
+	for _, gv := range *f {
+		versions = append(versions, gv.String())
+	}
+	return strings.Join(versions, ",")
+}
+
+func (f *versionsFlag) Set(value string) error {
+	gv, err := kubespec.ParseGroupVersion(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, gv)
+	return nil
+}
+
+// definitionPrefixesFlag registers `--definition-prefixes` on
+// `flags`, the prefixes of the definition names to parse, which are
+// `kubespec.NativePrefix` by default.
+func definitionPrefixesFlag(flags *flag.FlagSet) *prefixesFlag {
+	prefixes := &prefixesFlag{kubespec.NativePrefix}
+	flags.Var(
+		prefixes, "definition-prefixes",
+		"comma-separated prefixes of the definition names to parse, e.g., 'io.k8s,com.github.openshift'")
+	return prefixes
+}
+
+// prefixesFlag adapts a list of definition prefixes to `flag.Value`.
+type prefixesFlag []string
+
+func (f *prefixesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(*f, ",")
+}
+
+// parser returns a parser of the definition names with the prefixes.
+func (f *prefixesFlag) parser() *kubespec.Parser {
+	return &kubespec.Parser{Prefixes: *f}
+}
+
+func (f *prefixesFlag) Set(value string) error {
+	prefixes := []string{}
+	for _, prefix := range strings.Split(value, ",") {
+		if prefix = strings.Trim(kubespec.TrimName(prefix), "."); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return fmt.Errorf("No definition prefixes in '%s'", value)
+	}
+	*f = prefixes
+	return nil
+}
+
+// loadSpec reads and deserializes the swagger spec at `swaggerPath`,
+// which may be gzipped (see `kubespec.ReadSpec`), or reads it from
+// stdin if `swaggerPath` is `-`, or fetches it if it's an `http://` or
+// `https://` URL (e.g., a cluster's `/openapi/v2`, through `kubectl
+// proxy`).
+func loadSpec(
+	ctx context.Context, swaggerPath string, logger *cliLogger,
+) *kubespec.APISpec {
+	start := time.Now()
+	if strings.HasPrefix(swaggerPath, "http://") || strings.HasPrefix(swaggerPath, "https://") {
+		s, err := kubespec.FetchSpec(ctx, nil, swaggerPath)
+		if err != nil {
+			fatal(err)
+		}
+		logger.Log(
+			"fetch", "url", swaggerPath, "bytes", len(s.Text),
+			"definitions", len(s.Definitions), "duration", time.Since(start))
+		return s
+	}
+
+	if swaggerPath == "-" {
+		// Waiting on a terminal for a spec to be pasted is never
+		// what's meant. `/dev/null` is a character device too, but is
+		// just empty.
+		if stdinIsTerminal() {
+			fatal(kubespec.Categorize(kubespec.ErrSpecLoad, errors.New(
+				"Could not read spec from stdin: it's a terminal; pipe the spec in, or pass its path instead of '-'")))
+		}
+		s, err := kubespec.ReadSpec("stdin", os.Stdin)
+		if err != nil {
+			fatal(err)
+		}
+		// Like a fetched spec, it has no file of its own.
+		s.FilePath = "."
+		logger.Log(
+			"load", "path", "stdin", "bytes", len(s.Text),
+			"definitions", len(s.Definitions), "duration", time.Since(start))
+		return s
+	}
+
+	f, err := os.Open(swaggerPath)
+	if err != nil {
+		fatal(kubespec.Categorize(kubespec.ErrSpecLoad, fmt.Errorf(
+			"Could not read file at '%s':\n%w", swaggerPath, err)))
+	}
+	defer f.Close()
+
+	// Deserialize the API object, which may be JSON or YAML.
+	s, err := kubespec.ReadSpec(swaggerPath, f)
+	if err != nil {
+		fatal(err)
+	}
+	s.FilePath = filepath.Dir(swaggerPath)
+	logger.Log(
+		"load", "path", swaggerPath, "bytes", len(s.Text),
+		"definitions", len(s.Definitions), "duration", time.Since(start))
+
+	return s
+}
+
+// stdinIsTerminal reports whether stdin is a character device other
+// than `os.DevNull`, which is as close as the standard library gets to
+// telling whether it's a terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// strictExtensionsFlag registers `--strict-extensions` on `flags`.
+func strictExtensionsFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool(
+		"strict-extensions", false,
+		"fail if the spec uses vendor extensions that ksonnet-gen doesn't model")
+}
+
+// checkVersion exits if the version of Kubernetes that `s` is for
+// (its `info.version`) isn't supported by `kubeversion`, since
+// generating its library would fail partway. Patch releases are
+// matched to the data for their minor version (see
+// `kubeversion.Lookup`). It warns about the parts of the spec's `info`
+// and `securityDefinitions` that were malformed, and so ignored.
+func checkVersion(s *kubespec.APISpec, logger *cliLogger) {
+	info, err := kubeversion.Lookup(s.Info.Version)
+	if err != nil {
+		fatal(kubespec.Categorize(kubespec.ErrUnsupportedSchema, fmt.Errorf(
+			"Could not generate library:\n%w", err)))
+	}
+	logger.Log("version", "spec", s.Info.Version, "data", info.Version, "layout", info.Layout)
+	for _, problem := range s.MetadataProblems {
+		log.Printf("Warning: ignoring malformed metadata: %s", problem)
+	}
+}
+
+// checkExtensions reports the vendor extensions in `s` that kubespec
+// doesn't model, so that we notice when Kubernetes introduces new
+// ones. With `strict` it exits; otherwise it logs a one-line summary.
+func checkExtensions(s *kubespec.APISpec, strict bool) {
+	unknown := s.UnknownExtensions(kubespec.KnownExtensions())
+	if len(unknown) == 0 {
+		return
+	}
+
+	summaries := []string{}
+	for _, ue := range unknown {
+		summaries = append(summaries, fmt.Sprintf("%s (e.g., %s)", ue.Name, ue.Example))
+	}
+	summary := fmt.Sprintf(
+		"Spec uses %d unknown vendor extensions: %s",
+		len(unknown), strings.Join(summaries, ", "))
+	if strict {
+		fatal(kubespec.Categorize(kubespec.ErrUnsupportedSchema, errors.New(summary)))
 	}
+	log.Print(summary)
 }
 
 func init() {
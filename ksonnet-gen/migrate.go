@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// migrateImports rewrites the imports of, and references to, a library
+// in a tree of Jsonnet files from the layout of one symbol index to
+// another's, e.g., after the library is regenerated with
+// --split-by-group, or a group is moved with --namespace. It prints the
+// lines it would change as a diff, and only writes them with --write.
+func migrateImports(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("migrate-imports", flag.ExitOnError)
+	fromPath := flags.String(
+		"from-index", "", "the symbol index of the library the Jsonnet files use now")
+	toPath := flags.String(
+		"to-index", "", "the symbol index of the library to migrate them to")
+	dir := flags.String(
+		"dir", "", "the directory of '.jsonnet' and '.libsonnet' files to migrate, recursively")
+	write := flags.Bool("write", false, "rewrite the files, rather than only printing the diff")
+	flags.Parse(args)
+
+	if *fromPath == "" || *toPath == "" || *dir == "" || flags.NArg() != 0 {
+		log.Fatal(usage)
+	}
+	from, to := readSymbolIndex(*fromPath), readSymbolIndex(*toPath)
+
+	files, err := readJsonnetDir(*dir)
+	if err != nil {
+		log.Fatalf("Could not read Jsonnet files in '%s':\n%v", *dir, err)
+	} else if len(files) == 0 {
+		log.Fatalf("No '.jsonnet' or '.libsonnet' files in '%s'", *dir)
+	}
+
+	migration := ksonnet.MigrateImports(from, to, files)
+	fmt.Print(migration.Diff())
+	for _, ref := range migration.Unresolved {
+		log.Printf("Warning: can't migrate %s", ref)
+	}
+
+	rewritten := migration.Files()
+	if !*write {
+		if len(rewritten) > 0 {
+			log.Printf("Would rewrite %d files; pass --write to rewrite them", len(rewritten))
+		}
+		return
+	}
+	for path, text := range rewritten {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Fatalf("Could not rewrite '%s':\n%v", path, err)
+		}
+		if err = ioutil.WriteFile(path, text, info.Mode()); err != nil {
+			log.Fatalf("Could not rewrite '%s':\n%v", path, err)
+		}
+	}
+	log.Printf("Rewrote %d files", len(rewritten))
+}
+
+// readSymbolIndex reads the symbol index at `path` (see
+// `loadSymbolIndex`), exiting if it can't.
+func readSymbolIndex(path string) *ksonnet.SymbolIndex {
+	index, err := loadSymbolIndex(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return index
+}
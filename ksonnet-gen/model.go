@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// modelFlags are where `--save-model` writes the spec `generate` loads
+// as a model (see `kubespec.APISpec.WriteTo`), and where `--from-model`
+// reads one from instead of parsing a spec, e.g., to parse the spec
+// once in one stage of a pipeline and generate from it in another.
+type modelFlags struct {
+	save string
+	from string
+}
+
+// modelFlagsFor registers `--save-model` and `--from-model` on `flags`.
+func modelFlagsFor(flags *flag.FlagSet) *modelFlags {
+	models := &modelFlags{}
+	flags.StringVar(
+		&models.save, "save-model", "",
+		"also write the spec as loaded to this file as a model, which --from-model reads much faster than the spec can be parsed")
+	flags.StringVar(
+		&models.from, "from-model", "",
+		"read the spec from this model, written by --save-model, rather than from a spec")
+	return models
+}
+
+// loadModel reads the model at `path`, which `saveModel` wrote, and
+// preloads `parser` with the names it recorded.
+func loadModel(path string, parser *kubespec.Parser, logger *cliLogger) *kubespec.APISpec {
+	start := time.Now()
+	f, err := os.Open(path)
+	if err != nil {
+		fatal(kubespec.Categorize(kubespec.ErrSpecLoad, fmt.Errorf(
+			"Could not read model at '%s':\n%w", path, err)))
+	}
+	defer f.Close()
+	s, err := kubespec.ReadSpecFrom(f)
+	if err != nil {
+		fatal(fmt.Errorf("Could not read model at '%s':\n%w", path, err))
+	}
+	parser.Preload(s)
+	logger.Log(
+		"load model", "path", path, "definitions", len(s.Definitions),
+		"duration", time.Since(start))
+	return s
+}
+
+// saveModel writes `s` to `path` as a model, with its names as
+// `parser` parses them.
+func saveModel(s *kubespec.APISpec, path string, parser *kubespec.Parser, logger *cliLogger) {
+	start := time.Now()
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Could not write model to '%s':\n%v", path, err)
+	}
+	n, err := parser.WriteModel(f, s)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Could not write model to '%s':\n%v", path, err)
+	}
+	logger.Log("save model", "path", path, "bytes", n, "duration", time.Since(start))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// outputFormatFlag registers `--output-format` on `flags`.
+func outputFormatFlag(flags *flag.FlagSet) *outputFormat {
+	format := outputDir
+	flags.Var(
+		&format, "output-format",
+		"write the library to a directory ('dir', the default), or to a gzipped tar ('tar') or zip ('zip') archive")
+	return &format
+}
+
+// outputFormat adapts `--output-format` to `flag.Value`.
+type outputFormat string
+
+const (
+	outputDir outputFormat = "dir"
+	outputTar outputFormat = "tar"
+	outputZip outputFormat = "zip"
+)
+
+func (f *outputFormat) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *outputFormat) Set(value string) error {
+	switch format := outputFormat(value); format {
+	case outputDir, outputTar, outputZip:
+		*f = format
+		return nil
+	}
+	return fmt.Errorf("Unknown output format '%s'; expected 'dir', 'tar', or 'zip'", value)
+}
+
+// output is where a command writes the library: a `ksonnet.Sink` of
+// `target`, which is a directory or an archive.
+type output struct {
+	ksonnet.Sink
+	format outputFormat
+	target string
+
+	// The file an archive is written to, until it's finalized and
+	// replaces `target`, so that an archive is never left half-written.
+	archive *os.File
+}
+
+// openOutput returns the output of `format` at `target`, exiting if
+// an archive can't be created there.
+func openOutput(format outputFormat, target string) *output {
+	out := &output{format: format, target: target}
+	if format == outputDir {
+		out.Sink = ksonnet.NewDirSink(target)
+		return out
+	}
+
+	archive, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".tmp-")
+	if err != nil {
+		log.Fatalf("Could not create archive '%s':\n%v", target, err)
+	}
+	out.archive = archive
+	if format == outputTar {
+		out.Sink = ksonnet.NewTarSink(archive)
+	} else {
+		out.Sink = ksonnet.NewZipSink(archive)
+	}
+	return out
+}
+
+// finalize finishes writing the output, exiting if it can't.
+func (out *output) finalize() {
+	err := out.Finalize()
+	if out.archive != nil {
+		if closeErr := out.archive.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(out.archive.Name(), 0644)
+		}
+		if err == nil {
+			err = os.Rename(out.archive.Name(), out.target)
+		}
+		if err != nil {
+			os.Remove(out.archive.Name())
+		}
+	}
+	if err != nil {
+		log.Fatalf("Could not write '%s':\n%v", out.target, err)
+	}
+}
+
+// writeFile writes the file `name` of the library to the output,
+// exiting if it can't.
+func (out *output) writeFile(name string, text []byte) {
+	if err := ksonnet.WriteFile(out, name, text); err != nil {
+		log.Fatalf("Could not write `%s` to '%s':\n%v", name, out.target, err)
+	}
+}
+
+// path returns the path of the file `name` of the library, as it's
+// logged, e.g., `lib/k8s.libsonnet`, or `lib.tar.gz:k8s.libsonnet` in
+// an archive.
+func (out *output) path(name string) string {
+	if out.format == outputDir {
+		return filepath.Join(out.target, filepath.FromSlash(name))
+	}
+	return out.target + ":" + name
+}
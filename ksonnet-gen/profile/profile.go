@@ -0,0 +1,551 @@
+// Package profile reads and writes generation profiles (see
+// `ksonnet-gen --profile`): YAML files whose keys name the fields of a
+// struct, by their `yaml` tags. The YAML is read with `yamljson`, so a
+// profile may be written in any of the YAML it handles, and written
+// back as the subset of it that configuration needs: block mappings and
+// sequences of scalars, plain and quoted scalars, and literal block
+// scalars (`|`) for multi-line text.
+package profile
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/yamljson"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal parses the YAML in `text` into the struct `v` points at.
+// Each key names a field by its `yaml` tag; the fields of embedded
+// structs tagged `yaml:",inline"` are keys of the outer struct, and
+// fields without a tag, or tagged `yaml:"-"`, can't be set. Fields
+// that aren't in the YAML are left as they are, and null sets them to
+// their zero value.
+//
+// Fields may be booleans, integers, strings, `encoding.TextUnmarshaler`s
+// (e.g., `kubespec.GroupVersion`), or slices, string-keyed maps, and
+// structs of them. It's an error if a key doesn't name a field (which
+// catches typos, and suggests the key that was probably meant), or if
+// a value doesn't fit its field; every such error is reported, each with
+// the path of its value, in the order they're written.
+func Unmarshal(text []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Can't unmarshal a profile into %T; expected a pointer to a struct", v)
+	}
+	j, err := yamljson.ToJSON(text)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	root, err := readValue(dec)
+	if err != nil {
+		return err
+	}
+	switch root.(type) {
+	case nil:
+		return nil
+	case *mapping:
+	default:
+		return fmt.Errorf("Profiles must be a mapping of keys to values")
+	}
+
+	d := &decoder{}
+	d.decode(root, rv.Elem(), "")
+	if len(d.errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(d.errs, "\n"))
+	}
+	return nil
+}
+
+// mapping is a JSON object, with its keys in the order they're
+// written, so that errors are reported in the order of the YAML.
+type mapping struct {
+	keys   []string
+	values []interface{}
+}
+
+// readValue reads the next value of `dec`, which must use numbers, as
+// `nil`, a `bool`, a `json.Number`, a `string`, a `[]interface{}`, or a
+// `*mapping`.
+func readValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('['):
+		items := []interface{}{}
+		for dec.More() {
+			item, err := readValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	case json.Delim('{'):
+		m := &mapping{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, key.(string))
+			m.values = append(m.values, value)
+		}
+		_, err := dec.Token()
+		return m, err
+	}
+	return token, nil
+}
+
+type decoder struct {
+	errs []string
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) {
+	d.errs = append(d.errs, fmt.Sprintf(format, args...))
+}
+
+// decode sets `rv` to `value`, as `readValue` reads it. `path` names
+// the value in errors, e.g., `onlyVersions[1]`.
+func (d *decoder) decode(value interface{}, rv reflect.Value, path string) {
+	if value == nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return
+	}
+
+	if reflect.PtrTo(rv.Type()).Implements(textUnmarshalerType) {
+		s, ok := value.(string)
+		if !ok {
+			d.errorf("'%s' must be a string", path)
+			return
+		}
+		if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			d.errorf("'%s': %v", path, err)
+		}
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			rv.SetBool(v)
+		case string:
+			d.errorf("'%s' must be true or false, got '%s'", path, v)
+		default:
+			d.errorf("'%s' must be true or false", path)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := value.(type) {
+		case json.Number:
+			i, err := strconv.ParseInt(v.String(), 10, 64)
+			if err != nil || rv.OverflowInt(i) {
+				d.errorf("'%s' must be an integer, got '%s'", path, v)
+				return
+			}
+			rv.SetInt(i)
+		case string:
+			d.errorf("'%s' must be an integer, got '%s'", path, v)
+		default:
+			d.errorf("'%s' must be an integer", path)
+		}
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			d.errorf("'%s' must be a string", path)
+			return
+		}
+		rv.SetString(s)
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			d.errorf("'%s' must be a list", path)
+			return
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			d.decode(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+		rv.Set(slice)
+	case reflect.Map:
+		m, ok := value.(*mapping)
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			d.errorf("'%s' must be a mapping", path)
+			return
+		}
+		entries := reflect.MakeMapWithSize(rv.Type(), len(m.keys))
+		for i, key := range m.keys {
+			entry := reflect.New(rv.Type().Elem()).Elem()
+			d.decode(m.values[i], entry, fmt.Sprintf("%s[%s]", path, strconv.Quote(key)))
+			entries.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), entry)
+		}
+		rv.Set(entries)
+	case reflect.Struct:
+		m, ok := value.(*mapping)
+		if !ok {
+			d.errorf("'%s' must be a mapping", path)
+			return
+		}
+		fields := structFields(rv.Type())
+		for i, key := range m.keys {
+			field, ok := fieldNamed(fields, key)
+			if !ok {
+				d.errorf("unknown key '%s'%s", joinPath(path, key), suggest(fields, path, key))
+				continue
+			}
+			d.decode(m.values[i], rv.FieldByIndex(field.index), joinPath(path, key))
+		}
+	default:
+		d.errorf("'%s' can't be set by a profile", path)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// field is a field of a struct that a profile can set, by its key.
+type field struct {
+	key   string
+	index []int // See `reflect.Value.FieldByIndex`.
+}
+
+// structFields returns the fields of `t` that have keys, in the order
+// they're declared, including those of inlined structs.
+func structFields(t reflect.Type) []field {
+	fields := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("yaml")
+		if !ok || tag == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && strings.HasSuffix(tag, ",inline") {
+			for _, inner := range structFields(f.Type) {
+				fields = append(fields, field{key: inner.key, index: append([]int{i}, inner.index...)})
+			}
+			continue
+		}
+		if name != "" {
+			fields = append(fields, field{key: name, index: []int{i}})
+		}
+	}
+	return fields
+}
+
+func fieldNamed(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// suggest returns a hint naming the key that `key` is probably a typo
+// of: one that differs only by case, or by at most two edits.
+func suggest(fields []field, path, key string) string {
+	best, bestDistance := "", 3
+	for _, f := range fields {
+		distance := editDistance(strings.ToLower(f.key), strings.ToLower(key))
+		if distance < bestDistance {
+			best, bestDistance = f.key, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean '%s'?", joinPath(path, best))
+}
+
+// editDistance returns the Levenshtein distance between `a` and `b`.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// Marshal writes the struct `v` (or the struct it points at) as YAML
+// that `Unmarshal` reads back into an equal struct, with a key for
+// every field a profile can set, in the order they're declared. Maps
+// are written sorted by key, multi-line strings as literal block
+// scalars, and strings are only quoted if they must be.
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Can't marshal %T as a profile; expected a struct", v)
+	}
+	var buf strings.Builder
+	if err := marshalStruct(&buf, rv, 0); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+// Values returns the struct `v` (or the struct it points at) as the
+// values `Marshal` writes, keyed as the YAML is, e.g., to serialize a
+// profile as JSON: booleans, `int64`s, strings (as which
+// `encoding.TextMarshaler`s are written), and `[]interface{}`s and
+// `map[string]interface{}`s of them.
+func Values(v interface{}) (map[string]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Can't convert %T to the values of a profile; expected a struct", v)
+	}
+	return structValues(rv, "")
+}
+
+func structValues(rv reflect.Value, path string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, f := range structFields(rv.Type()) {
+		value, err := fieldValue(rv.FieldByIndex(f.index), joinPath(path, f.key))
+		if err != nil {
+			return nil, err
+		}
+		values[f.key] = value
+	}
+	return values, nil
+}
+
+// fieldValue returns the value of `rv`, the field of a profile called
+// `path` in errors, as `Values` does.
+func fieldValue(rv reflect.Value, path string) (interface{}, error) {
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("Can't convert '%s':\n%v", path, err)
+		}
+		return string(text), nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		items := []interface{}{}
+		for i := 0; i < rv.Len(); i++ {
+			item, err := fieldValue(rv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case reflect.Map:
+		entries := map[string]interface{}{}
+		for _, k := range rv.MapKeys() {
+			entry, err := fieldValue(rv.MapIndex(k), fmt.Sprintf("%s[%s]", path, strconv.Quote(k.String())))
+			if err != nil {
+				return nil, err
+			}
+			entries[k.String()] = entry
+		}
+		return entries, nil
+	case reflect.Struct:
+		return structValues(rv, path)
+	}
+	return nil, fmt.Errorf("Can't convert '%s' of type %s", path, rv.Type())
+}
+
+func marshalStruct(buf *strings.Builder, rv reflect.Value, indent int) error {
+	for _, f := range structFields(rv.Type()) {
+		if err := marshalEntry(buf, f.key, rv.FieldByIndex(f.index), indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalEntry writes `key: value`, at `indent`, with nested values on
+// the lines that follow.
+func marshalEntry(buf *strings.Builder, key string, rv reflect.Value, indent int) error {
+	prefix := strings.Repeat(" ", indent) + quote(key) + ":"
+	if text, ok, err := scalarText(rv); err != nil {
+		return fmt.Errorf("Can't marshal '%s':\n%v", key, err)
+	} else if ok {
+		buf.WriteString(prefix)
+		writeScalar(buf, text, indent)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.Len() == 0 {
+			buf.WriteString(prefix + " []\n")
+			return nil
+		}
+		buf.WriteString(prefix + "\n")
+		for i := 0; i < rv.Len(); i++ {
+			text, ok, err := scalarText(rv.Index(i))
+			if err != nil || !ok {
+				return fmt.Errorf("Can't marshal '%s': only lists of scalars are supported", key)
+			}
+			buf.WriteString(strings.Repeat(" ", indent+2) + "-")
+			writeScalar(buf, text, indent+2)
+		}
+	case reflect.Map:
+		if rv.Len() == 0 {
+			buf.WriteString(prefix + " {}\n")
+			return nil
+		}
+		buf.WriteString(prefix + "\n")
+		keys := []string{}
+		values := map[string]reflect.Value{}
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+			values[k.String()] = rv.MapIndex(k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := marshalEntry(buf, k, values[k], indent+2); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		buf.WriteString(prefix + "\n")
+		return marshalStruct(buf, rv, indent+2)
+	default:
+		return fmt.Errorf("Can't marshal '%s' of type %s", key, rv.Type())
+	}
+	return nil
+}
+
+// scalarText returns the text of `rv`, if it's a scalar.
+func scalarText(rv reflect.Value) (*string, bool, error) {
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, false, err
+		}
+		s := quote(string(text))
+		return &s, true, nil
+	}
+	var s string
+	switch rv.Kind() {
+	case reflect.Bool:
+		s = strconv.FormatBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(rv.Int(), 10)
+	case reflect.String:
+		if literal, ok := literalText(rv.String()); ok {
+			return &literal, true, nil
+		}
+		s = quote(rv.String())
+	default:
+		return nil, false, nil
+	}
+	return &s, true, nil
+}
+
+// writeScalar writes the text of a scalar after its key or `-`, with
+// the lines of literal block scalars indented past `indent`.
+func writeScalar(buf *strings.Builder, text *string, indent int) {
+	lines := strings.Split(*text, "\n")
+	buf.WriteString(" " + lines[0] + "\n")
+	for _, l := range lines[1:] {
+		if l == "" {
+			buf.WriteString("\n")
+		} else {
+			buf.WriteString(strings.Repeat(" ", indent+2) + l + "\n")
+		}
+	}
+}
+
+// literalText returns `s` as a literal block scalar (its header, `|`
+// or `|-`, followed by its lines), if it's multi-line text that can be
+// written as one, e.g., a file.
+func literalText(s string) (string, bool) {
+	if !strings.Contains(s, "\n") || strings.HasSuffix(s, "\n\n") ||
+		strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\n") || strings.ContainsAny(s, "\r\t") {
+		return "", false
+	}
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) == "" && l != "" {
+			// Lines of only spaces wouldn't survive being indented.
+			return "", false
+		}
+	}
+
+	if body := strings.TrimSuffix(s, "\n"); body != s {
+		return "|\n" + body, true
+	}
+	return "|-\n" + s, true
+}
+
+// quote returns `s` as a plain scalar if it reads back as the same
+// string in any YAML parser, and double-quoted otherwise.
+func quote(s string) string {
+	if s == "" || !plainPattern(s) {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if j, err := yamljson.ToJSON([]byte(s)); err != nil || j[0] != '"' {
+		// E.g., `0x1F`, which is a number.
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func plainPattern(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		case i > 0 && (r == '.' || r == '/' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package profile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+type inner struct {
+	Lenient  bool                    `yaml:"lenient"`
+	Depth    int                     `yaml:"depth"`
+	Versions []kubespec.GroupVersion `yaml:"versions"`
+	Ignored  func()                  `yaml:"-"`
+}
+
+type testProfile struct {
+	inner `yaml:",inline"`
+
+	Name     string            `yaml:"name"`
+	Groups   []string          `yaml:"groups"`
+	Files    map[string]string `yaml:"files"`
+	Nested   nested            `yaml:"nested"`
+	Untagged bool
+}
+
+type nested struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	text := `---
+# A profile.
+lenient: true
+depth: 3   # Trailing comments are ignored.
+versions: [apps/v1, "batch/v1"]
+name: 'it''s # not a comment'
+groups:
+- apps
+- "core"   # Quoted.
+files:
+  apps.v1.deployment: |
+    withFoo(foo):: {foo: foo},
+
+    # Not a comment, in a block scalar.
+  core.v1.pod: |-
+    bar:: 1,
+  "empty": ""
+nested:
+  enabled: TRUE
+`
+
+	actual := testProfile{}
+	if err := Unmarshal([]byte(text), &actual); err != nil {
+		t.Fatalf("Failed to unmarshal profile:\n%v", err)
+	}
+	expected := testProfile{
+		inner: inner{
+			Lenient: true,
+			Depth:   3,
+			Versions: []kubespec.GroupVersion{
+				{Group: "apps", Version: "v1"}, {Group: "batch", Version: "v1"},
+			},
+		},
+		Name:   "it's # not a comment",
+		Groups: []string{"apps", "core"},
+		Files: map[string]string{
+			"apps.v1.deployment": "withFoo(foo):: {foo: foo},\n\n# Not a comment, in a block scalar.\n",
+			"core.v1.pod":        "bar:: 1,",
+			"empty":              "",
+		},
+		Nested: nested{Enabled: true},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected:\n%#v\ngot:\n%#v", expected, actual)
+	}
+
+	// Fields that aren't in the YAML are kept, and null clears them.
+	actual = testProfile{Name: "kept", Groups: []string{"apps"}}
+	if err := Unmarshal([]byte("groups: ~\ndepth:\n"), &actual); err != nil {
+		t.Fatalf("Failed to unmarshal profile:\n%v", err)
+	}
+	if actual.Name != "kept" || actual.Groups != nil || actual.Depth != 0 {
+		t.Errorf("Expected only the null fields to be cleared, got %#v", actual)
+	}
+
+	// An empty document sets nothing.
+	actual = testProfile{Name: "kept"}
+	if err := Unmarshal([]byte("# Nothing here.\n"), &actual); err != nil || actual.Name != "kept" {
+		t.Errorf("Expected an empty profile to set nothing, got %#v (%v)", actual, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for text, expected := range map[string]string{
+		// Typos are reported with the key that was probably meant, and
+		// every error is reported.
+		"lenien: true\nnmae: x\nbogus: 1": "unknown key 'lenien'; did you mean 'lenient'?\n" +
+			"unknown key 'nmae'; did you mean 'name'?\n" +
+			"unknown key 'bogus'",
+		"Lenient: true":           "unknown key 'Lenient'; did you mean 'lenient'?",
+		"nested:\n  enable: true": "unknown key 'nested.enable'; did you mean 'nested.enabled'?",
+		"Untagged: true":          "unknown key 'Untagged'",
+		"Ignored: x":              "unknown key 'Ignored'",
+		"lenient: maybe":          "'lenient' must be true or false, got 'maybe'",
+		"lenient: \"true\"":       "'lenient' must be true or false, got 'true'",
+		"lenient: [true]":         "'lenient' must be true or false",
+		"depth: 1.5":              "'depth' must be an integer, got '1.5'",
+		"depth: \"1\"":            "'depth' must be an integer, got '1'",
+		"depth: [1]":              "'depth' must be an integer",
+		"groups: apps":            "'groups' must be a list",
+		"groups:\n  - [apps]":     "'groups[0]' must be a string",
+		"groups:\n- name: x":      "'groups[0]' must be a string",
+		"files: [a]":              "'files' must be a mapping",
+		"name:\n  first: x":       "'name' must be a string",
+		"versions: [apps]":        "'versions[0]': Could not parse group/version 'apps'",
+		"just text":               "Profiles must be a mapping of keys to values",
+		"- apps":                  "Profiles must be a mapping of keys to values",
+		// Syntax errors are `yamljson`'s, with their lines.
+		"name: x\nname: y":         "line 2: key 'name' is repeated (it's first set on line 1)",
+		"name: x\n  depth: 1":      "line 2: unexpected indentation",
+		"name: x\n\tdepth: 1":      "line 2: YAML can't be indented with tabs",
+		"name: &anchor x":          "line 1: anchors, aliases and tags aren't supported",
+		"name: !!str x":            "line 1: anchors, aliases and tags aren't supported",
+		"groups: [apps":            "line 1: unterminated flow collection",
+		"name: 'unterminated":      "line 1: unterminated quoted string",
+		"name: \"bad \\q escape\"": `line 1: invalid escape '\q' in double-quoted string`,
+		"name: x\n---\nname: y":    "line 3: expected a single YAML document, got 2",
+		"files:\n  a: x\n  - b":    "line 3: expected a key, got a list item",
+		// The rest of the YAML that `yamljson` reads is fine.
+		"name: >\n  folded\n  text":  "",
+		"files: {a: b}":              "",
+		"nested:\n  enabled: true\n": "",
+	} {
+		err := Unmarshal([]byte(text), &testProfile{})
+		if expected == "" {
+			if err != nil {
+				t.Errorf("Expected no error unmarshaling:\n%s\ngot:\n%v", text, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Expected an error unmarshaling:\n%s", text)
+		} else if !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("Expected an error unmarshaling:\n%s\nlike:\n%s\ngot:\n%v", text, expected, err)
+		}
+	}
+
+	if err := Unmarshal([]byte("name: x"), testProfile{}); err == nil {
+		t.Errorf("Expected an error unmarshaling into a struct rather than a pointer")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	profile := testProfile{
+		inner: inner{
+			Lenient:  true,
+			Depth:    5,
+			Versions: []kubespec.GroupVersion{{Group: "apps", Version: "v1beta2"}},
+		},
+		Name:   "true",
+		Groups: []string{"apps", "rbac.authorization.k8s.io", "has space", "", "0x1F"},
+		Files: map[string]string{
+			"b": "withFoo(foo):: {foo: foo},\n\nbar:: 1,\n",
+			"a": "one line",
+			"c": "no trailing newline\nbar:: 2,",
+			"d": "  indented\nfirst line\n",
+			"e": "two trailing newlines\n\n",
+		},
+		Untagged: true,
+	}
+	text, err := Marshal(&profile)
+	if err != nil {
+		t.Fatalf("Failed to marshal profile:\n%v", err)
+	}
+	expected := `lenient: true
+depth: 5
+versions:
+  - apps/v1beta2
+name: "true"
+groups:
+  - apps
+  - rbac.authorization.k8s.io
+  - "has space"
+  - ""
+  - "0x1F"
+files:
+  a: "one line"
+  b: |
+    withFoo(foo):: {foo: foo},
+
+    bar:: 1,
+  c: |-
+    no trailing newline
+    bar:: 2,
+  d: "  indented\nfirst line\n"
+  e: "two trailing newlines\n\n"
+nested:
+  enabled: false
+`
+	if string(text) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, text)
+	}
+
+	// What's marshaled unmarshals to the same profile, except for the
+	// fields a profile can't set.
+	actual := testProfile{}
+	if err := Unmarshal(text, &actual); err != nil {
+		t.Fatalf("Failed to unmarshal marshaled profile:\n%v", err)
+	}
+	profile.Untagged = false
+	if !reflect.DeepEqual(actual, profile) {
+		t.Errorf("Expected a round trip, got:\n%#v", actual)
+	}
+
+	// Empty collections are written inline.
+	text, err = Marshal(testProfile{})
+	if err != nil {
+		t.Fatalf("Failed to marshal profile:\n%v", err)
+	}
+	if !strings.Contains(string(text), "versions: []\n") || !strings.Contains(string(text), "files: {}\n") {
+		t.Errorf("Expected empty collections to be written inline, got:\n%s", text)
+	}
+	actual = testProfile{Groups: []string{"kept"}}
+	if err := Unmarshal(text, &actual); err != nil || len(actual.Groups) != 0 || actual.Files == nil {
+		t.Errorf("Expected empty collections to round-trip, got %#v (%v)", actual, err)
+	}
+}
+
+func TestValues(t *testing.T) {
+	profile := testProfile{
+		inner: inner{
+			Depth:    5,
+			Versions: []kubespec.GroupVersion{{Group: "apps", Version: "v1beta2"}},
+		},
+		Name:     "web",
+		Files:    map[string]string{"a": "one line"},
+		Untagged: true,
+	}
+	values, err := Values(&profile)
+	if err != nil {
+		t.Fatalf("Failed to convert profile:\n%v", err)
+	}
+	expected := map[string]interface{}{
+		"lenient":  false,
+		"depth":    int64(5),
+		"versions": []interface{}{"apps/v1beta2"},
+		"name":     "web",
+		"groups":   []interface{}{},
+		"files":    map[string]interface{}{"a": "one line"},
+		"nested":   map[string]interface{}{"enabled": false},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected:\n%#v\ngot:\n%#v", expected, values)
+	}
+
+	if _, err := Values(struct {
+		Callback func() `yaml:"callback"`
+	}{}); err == nil || !strings.Contains(err.Error(), "Can't convert 'callback' of type func()") {
+		t.Errorf("Expected an error naming the field that can't be converted, got %v", err)
+	}
+	if _, err := Values("profile"); err == nil {
+		t.Errorf("Expected an error converting a string")
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/profile"
+)
+
+// generateProfile is everything a `--profile` can set for `generate`:
+// the emit options, and the flags that filter the spec and choose what
+// else is written. Flags passed on the command line override it.
+type generateProfile struct {
+	ksonnet.Options `yaml:",inline"`
+
+	DefinitionPrefixes []string             `yaml:"definitionPrefixes"`
+	IncludeGroups      []kubespec.GroupName `yaml:"includeGroups"`
+	ExcludeAlpha       bool                 `yaml:"excludeAlpha"`
+	ExcludeBeta        bool                 `yaml:"excludeBeta"`
+	StrictExtensions   bool                 `yaml:"strictExtensions"`
+	StrictRefs         bool                 `yaml:"strictRefs"`
+
+	EmitTests  bool   `yaml:"emitTests"`
+	NoIndexDoc bool   `yaml:"noIndexDoc"`
+	Checksums  bool   `yaml:"checksums"`
+	Provenance bool   `yaml:"provenance"`
+	SkipReport string `yaml:"skipReport"`
+}
+
+// builtinProfiles are the profiles `--profile` takes by name, rather
+// than as a file: `minimal`, the stable kinds alone, and `full`, every
+// optional feature.
+var builtinProfiles = map[string]generateProfile{
+	"minimal": {
+		ExcludeAlpha: true,
+		ExcludeBeta:  true,
+		NoIndexDoc:   true,
+	},
+	"full": {
+		Options: ksonnet.Options{
+			DeprecateClusterScopedNamespace: true,
+			CommentExamples:                 true,
+			EmbedRawSchemas:                 true,
+			EmitPatchHelpers:                true,
+			EmitPresets:                     true,
+			EmitValidation:                  true,
+			EmitFieldOrder:                  true,
+			EmitGVKIndex:                    true,
+		},
+		EmitTests:  true,
+		Checksums:  true,
+		Provenance: true,
+	},
+}
+
+// profileOverrides maps the flags that a profile can set to a function
+// copying the flag's value from `cli` to `p`.
+var profileOverrides = map[string]func(p, cli *generateProfile){
+	"deprecate-cluster-namespace": func(p, cli *generateProfile) {
+		p.DeprecateClusterScopedNamespace = cli.DeprecateClusterScopedNamespace
+	},
+	"naming":                   func(p, cli *generateProfile) { p.NamingStrategy = cli.NamingStrategy },
+	"customizations":           func(p, cli *generateProfile) { p.Customizations = cli.Customizations },
+	"allow-overrides":          func(p, cli *generateProfile) { p.AllowOverrides = cli.AllowOverrides },
+	"namespace":                func(p, cli *generateProfile) { p.Namespaces = cli.Namespaces },
+	"max-inline-depth":         func(p, cli *generateProfile) { p.MaxInlineDepth = cli.MaxInlineDepth },
+	"lenient":                  func(p, cli *generateProfile) { p.Lenient = cli.Lenient },
+	"only":                     func(p, cli *generateProfile) { p.OnlyVersions = cli.OnlyVersions },
+	"fail-on-removed-in":       func(p, cli *generateProfile) { p.FailOnRemovedIn = cli.FailOnRemovedIn },
+	"comment-examples":         func(p, cli *generateProfile) { p.CommentExamples = cli.CommentExamples },
+	"embed-raw-schemas":        func(p, cli *generateProfile) { p.EmbedRawSchemas = cli.EmbedRawSchemas },
+	"emit-patch-helpers":       func(p, cli *generateProfile) { p.EmitPatchHelpers = cli.EmitPatchHelpers },
+	"emit-presets":             func(p, cli *generateProfile) { p.EmitPresets = cli.EmitPresets },
+	"emit-validation":          func(p, cli *generateProfile) { p.EmitValidation = cli.EmitValidation },
+	"emit-field-order":         func(p, cli *generateProfile) { p.EmitFieldOrder = cli.EmitFieldOrder },
+	"emit-gvk-index":           func(p, cli *generateProfile) { p.EmitGVKIndex = cli.EmitGVKIndex },
+	"emit-merge":               func(p, cli *generateProfile) { p.EmitMerge = cli.EmitMerge },
+	"emit-stringified-setters": func(p, cli *generateProfile) { p.EmitStringifiedSetters = cli.EmitStringifiedSetters },
+	"emit-quantity":            func(p, cli *generateProfile) { p.EmitQuantity = cli.EmitQuantity },
+	"emit-options-helpers":     func(p, cli *generateProfile) { p.EmitOptionsHelpers = cli.EmitOptionsHelpers },
+	"split-by-group":           func(p, cli *generateProfile) { p.SplitByGroup = cli.SplitByGroup },
+	"file-naming":              func(p, cli *generateProfile) { p.FileNaming = cli.FileNaming },
+	"split-hidden":             func(p, cli *generateProfile) { p.SplitHidden = cli.SplitHidden },
+	"import-base":              func(p, cli *generateProfile) { p.ImportBase = cli.ImportBase },
+	"namespace-prefix":         func(p, cli *generateProfile) { p.NamespacePrefix = cli.NamespacePrefix },
+	"header-template":          func(p, cli *generateProfile) { p.HeaderTemplate = cli.HeaderTemplate },
+	"external-refs":            func(p, cli *generateProfile) { p.ExternalRefs = cli.ExternalRefs },
+	"external-index":           func(p, cli *generateProfile) { p.ExternalIndexes = cli.ExternalIndexes },
+	"emit-extension-points":    func(p, cli *generateProfile) { p.EmitExtensionPoints = cli.EmitExtensionPoints },
+	"extends":                  func(p, cli *generateProfile) { p.Extends = cli.Extends },
+	"extension":                func(p, cli *generateProfile) { p.Extensions = cli.Extensions },
+	"kind-collisions":          func(p, cli *generateProfile) { p.KindCollisions = cli.KindCollisions },
+	"diff-friendly":            func(p, cli *generateProfile) { p.DiffFriendly = cli.DiffFriendly },
+	"no-comments":              func(p, cli *generateProfile) { p.OmitComments = cli.OmitComments },
+	"comments": func(p, cli *generateProfile) {
+		p.CommentBudgets = overrideCommentBudgets(p.CommentBudgets, cli.CommentBudgets,
+			func(budget *ksonnet.CommentBudget, from ksonnet.CommentBudget) { budget.Mode = from.Mode })
+	},
+	"max-comment-lines": func(p, cli *generateProfile) {
+		p.CommentBudgets = overrideCommentBudgets(p.CommentBudgets, cli.CommentBudgets,
+			func(budget *ksonnet.CommentBudget, from ksonnet.CommentBudget) { budget.MaxLines = from.MaxLines })
+	},
+	"compact":           func(p, cli *generateProfile) { p.Compact = cli.Compact },
+	"compact-threshold": func(p, cli *generateProfile) { p.CompactThreshold = cli.CompactThreshold },
+	"share-identical-kinds": func(p, cli *generateProfile) {
+		p.ShareIdenticalKinds = cli.ShareIdenticalKinds
+	},
+	"full-beta-duplicates": func(p, cli *generateProfile) {
+		p.FullBetaDuplicates = cli.FullBetaDuplicates
+	},
+	"provenance-fields": func(p, cli *generateProfile) {
+		p.ProvenanceFields = cli.ProvenanceFields
+	},
+	"compat": func(p, cli *generateProfile) { p.Compat = cli.Compat },
+	"warn-deprecated-shims": func(p, cli *generateProfile) {
+		p.WarnDeprecatedShims = cli.WarnDeprecatedShims
+	},
+
+	"definition-prefixes": func(p, cli *generateProfile) { p.DefinitionPrefixes = cli.DefinitionPrefixes },
+	"include-group":       func(p, cli *generateProfile) { p.IncludeGroups = cli.IncludeGroups },
+	"exclude-alpha":       func(p, cli *generateProfile) { p.ExcludeAlpha = cli.ExcludeAlpha },
+	"exclude-beta":        func(p, cli *generateProfile) { p.ExcludeBeta = cli.ExcludeBeta },
+	"strict-extensions":   func(p, cli *generateProfile) { p.StrictExtensions = cli.StrictExtensions },
+	"strict-refs":         func(p, cli *generateProfile) { p.StrictRefs = cli.StrictRefs },
+
+	"emit-tests":   func(p, cli *generateProfile) { p.EmitTests = cli.EmitTests },
+	"no-index-doc": func(p, cli *generateProfile) { p.NoIndexDoc = cli.NoIndexDoc },
+	"checksums":    func(p, cli *generateProfile) { p.Checksums = cli.Checksums },
+	"provenance":   func(p, cli *generateProfile) { p.Provenance = cli.Provenance },
+	"skip-report":  func(p, cli *generateProfile) { p.SkipReport = cli.SkipReport },
+}
+
+// overrideCommentBudgets returns the comment budgets of a profile,
+// with the field that `set` copies replaced by `cli`'s in every
+// section, since `--comments` and `--max-comment-lines` each set one
+// field of `ksonnet.Options.CommentBudgets`.
+func overrideCommentBudgets(
+	budgets, cli map[ksonnet.CommentSection]ksonnet.CommentBudget,
+	set func(budget *ksonnet.CommentBudget, from ksonnet.CommentBudget),
+) map[ksonnet.CommentSection]ksonnet.CommentBudget {
+	overridden := map[ksonnet.CommentSection]ksonnet.CommentBudget{}
+	for section, budget := range budgets {
+		set(&budget, ksonnet.CommentBudget{})
+		overridden[section] = budget
+	}
+	for section, from := range cli {
+		budget := overridden[section]
+		set(&budget, from)
+		overridden[section] = budget
+	}
+	return overridden
+}
+
+// profileFlags registers `--profile` and `--print-profile` on `flags`.
+func profileFlags(flags *flag.FlagSet) (name *string, printOnly *bool) {
+	name = flags.String(
+		"profile", "",
+		"the generation profile to start from: 'minimal', 'full', or a YAML file; flags override it")
+	printOnly = flags.Bool(
+		"print-profile", false,
+		"print the effective profile, after flags, as YAML, rather than generating")
+	return name, printOnly
+}
+
+// resolveProfile returns the profile `generate` runs with: the profile
+// called `name` (or, if it isn't a built-in one, in the file at that
+// path), or the defaults if it's empty, overridden by the flags that
+// were passed, whose values are in `cli`. It exits if the profile
+// can't be read, or is invalid.
+func resolveProfile(name string, flags *flag.FlagSet, cli *generateProfile) *generateProfile {
+	p := &generateProfile{}
+	if builtin, ok := builtinProfiles[name]; ok {
+		*p = builtin
+	} else if name != "" {
+		text, err := ioutil.ReadFile(name)
+		if err != nil {
+			log.Fatalf("Could not read profile '%s':\n%v", name, err)
+		}
+		if err := profile.Unmarshal(text, p); err != nil {
+			log.Fatalf("Could not read profile '%s':\n%v", name, err)
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if override, ok := profileOverrides[f.Name]; ok {
+			override(p, cli)
+		}
+	})
+
+	// Fill in the defaults, so that `--print-profile` shows them.
+	if p.NamingStrategy == "" {
+		p.NamingStrategy = jsonnet.NamingStrategyCurated
+	}
+	if p.MaxInlineDepth == 0 {
+		p.MaxInlineDepth = ksonnet.DefaultMaxInlineDepth
+	}
+	if len(p.DefinitionPrefixes) == 0 {
+		p.DefinitionPrefixes = []string{kubespec.NativePrefix}
+	}
+	// Prefixes are trimmed as `--definition-prefixes` trims them.
+	prefixes := []string{}
+	for _, prefix := range p.DefinitionPrefixes {
+		prefixes = append(prefixes, strings.Trim(kubespec.TrimName(prefix), "."))
+	}
+	p.DefinitionPrefixes = prefixes
+
+	if err := p.validate(); err != nil {
+		log.Fatalf("Invalid profile:\n%v\n\n%s", err, usage)
+	}
+	return p
+}
+
+// validate reports the settings of the profile that are invalid, or
+// that contradict each other.
+func (p *generateProfile) validate() error {
+	if err := p.Options.Validate(); err != nil {
+		return err
+	}
+	if len(p.IncludeGroups) > 0 && len(p.OnlyVersions) > 0 {
+		return fmt.Errorf(
+			"--include-group %s and --only %s can't be used together, since each restricts the library to what it names; list every version to keep with --only",
+			(*groupsFlag)(&p.IncludeGroups), (*versionsFlag)(&p.OnlyVersions))
+	}
+	for _, gv := range p.OnlyVersions {
+		stage := gv.Version.Stage()
+		if (stage == kubespec.StageAlpha && p.ExcludeAlpha) || (stage == kubespec.StageBeta && p.ExcludeBeta) {
+			return fmt.Errorf(
+				"--only %s is %s, which --exclude-%s removes", gv, stage, stage)
+		}
+	}
+	for _, prefix := range p.DefinitionPrefixes {
+		if prefix == "" {
+			return fmt.Errorf("definitionPrefixes can't include an empty prefix")
+		}
+	}
+	return nil
+}
+
+// printProfile writes the profile to stdout as YAML, which `--profile`
+// reads back.
+func printProfile(p *generateProfile) {
+	text, err := profile.Marshal(p)
+	if err != nil {
+		log.Fatalf("Could not write profile:\n%v", err)
+	}
+	os.Stdout.Write(text)
+}
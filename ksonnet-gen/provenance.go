@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/profile"
+)
+
+// provenanceFlag registers `--provenance` on `flags`.
+func provenanceFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool(
+		"provenance", false,
+		"also write '[output dir]/provenance.json', recording the digests of the inputs and files, the effective profile, and the generator's build, for 'verify-provenance'")
+}
+
+// provenanceInputs returns what `generate` read to generate the
+// library, for its provenance manifest: the spec at `swaggerPath`, if
+// there is one, which `loaded` was read from, the CRD files of
+// `crds`, and the external libraries' symbol indexes, whose digests
+// are of their `ksonnet.CanonicalJSON`, since they're read as values.
+func provenanceInputs(
+	swaggerPath string, loaded *kubespec.APISpec, crds *crdFlags,
+	indexes map[string]*ksonnet.SymbolIndex,
+) []*ksonnet.ProvenanceInput {
+	inputs := []*ksonnet.ProvenanceInput{}
+	if swaggerPath != "" {
+		name := swaggerPath
+		if name == "-" {
+			name = "stdin"
+		}
+		inputs = append(inputs, &ksonnet.ProvenanceInput{
+			Kind: "spec", Name: name, SHA256: loaded.SourceSHA256,
+		})
+	}
+	inputs = append(inputs, crds.inputs...)
+	for library, index := range indexes {
+		text, err := ksonnet.CanonicalJSON(index)
+		if err != nil {
+			log.Fatalf("Could not serialize the symbol index of library '%s':\n%v", library, err)
+		}
+		inputs = append(inputs, &ksonnet.ProvenanceInput{
+			Kind: "externalIndex", Name: library, SHA256: sha256Hex(text),
+		})
+	}
+	return inputs
+}
+
+// writeProvenance writes `ksonnet.ProvenanceManifestFile` to `out`,
+// with `inputs`, the effective profile `p`, and the digests of
+// `artifacts`, which are computed from the text that was written, as
+// `writeChecksums`'s are.
+func writeProvenance(
+	p *generateProfile, inputs []*ksonnet.ProvenanceInput,
+	artifacts map[string]*ksonnet.Artifact, out *output, logger *cliLogger,
+) {
+	start := time.Now()
+	m := ksonnet.NewProvenanceManifest(artifacts)
+	m.Inputs = inputs
+	options, err := profile.Values(p)
+	if err != nil {
+		log.Fatalf("Could not record the profile in the provenance manifest:\n%v", err)
+	}
+	m.Options = options
+	text, err := m.Canonical()
+	if err != nil {
+		log.Fatalf("Could not write provenance manifest:\n%v", err)
+	}
+	out.writeFile(ksonnet.ProvenanceManifestFile, text)
+	logger.Log(
+		"write", "path", out.path(ksonnet.ProvenanceManifestFile), "inputs", len(inputs),
+		"files", len(artifacts), "duration", time.Since(start))
+}
+
+// verifyProvenance checks the files of a generated dir against its
+// `provenance.json`: that the manifest is in canonical form (so that a
+// detached signature of it can be checked), that every file it lists
+// has the digest it records, and that the dir has no other files but
+// `SHA256SUMS`. It prints what the library was generated from, and
+// exits nonzero, listing them, if any file doesn't match.
+func verifyProvenance(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("verify-provenance", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+	dir := flags.Arg(0)
+
+	path := filepath.Join(dir, ksonnet.ProvenanceManifestFile)
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Could not read provenance manifest at '%s':\n%v", path, err)
+	}
+	m, err := ksonnet.ReadProvenanceManifest(text)
+	if err != nil {
+		fatal(fmt.Errorf("Could not read provenance manifest at '%s':\n%w", path, err))
+	}
+
+	inputs := []string{}
+	for _, input := range m.Inputs {
+		inputs = append(inputs, fmt.Sprintf("  %s %s: %s", input.Kind, input.Name, input.SHA256))
+	}
+	sort.Strings(inputs)
+	fmt.Printf(
+		"Generated by ksonnet-gen %s (commit %s, modified %t, %s) from:\n",
+		orUnknown(m.Generator.Version), orUnknown(m.Generator.Commit), m.Generator.Modified,
+		orUnknown(m.Generator.GoVersion))
+	for _, input := range inputs {
+		fmt.Println(input)
+	}
+
+	verified, err := m.Verify(os.DirFS(dir))
+	if err != nil {
+		fatal(fmt.Errorf("Could not verify '%s':\n%w", dir, err))
+	}
+	fmt.Printf("Verified %d files against '%s'\n", verified, path)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func sha256Hex(text []byte) string {
+	digest := sha256.Sum256(text)
+	return hex.EncodeToString(digest[:])
+}
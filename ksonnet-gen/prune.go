@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// pruneToUsage generates a library with only the top-level kinds that
+// a tree of Jsonnet files refers to, and the definitions they depend
+// on. If it finds references it can't resolve statically (e.g.,
+// `k8s.apps[version]`), it warns and generates the whole library,
+// unless `--force` is passed.
+func pruneToUsage(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("prune-to-usage", flag.ExitOnError)
+	jsonnetDir := flags.String(
+		"jsonnet-dir", "", "the directory of '.jsonnet' and '.libsonnet' files to scan, recursively")
+	target := flags.String("o", "", "the directory or archive to write the library to")
+	format := outputFormatFlag(flags)
+	force := flags.Bool(
+		"force", false,
+		"prune even if some references can't be resolved statically, which may remove kinds that are used")
+	strictRefs := strictRefsFlag(flags)
+	opts := emitOptionFlags(flags)
+	logger := logFlags(flags)
+	skipReport := skipReportFlag(flags)
+	checksums := checksumsFlag(flags)
+	noIndexDoc := noIndexDocFlag(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if *jsonnetDir == "" || *target == "" || flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+	opts.Logger = logger
+	opts.Progress = logger.progressFunc()
+	opts.Parser = prefixes.parser()
+
+	files, err := readJsonnetDir(*jsonnetDir)
+	if err != nil {
+		log.Fatalf("Could not read Jsonnet files in '%s':\n%v", *jsonnetDir, err)
+	} else if len(files) == 0 {
+		log.Fatalf("No '.jsonnet' or '.libsonnet' files in '%s'", *jsonnetDir)
+	}
+
+	s := loadSpec(ctx, flags.Arg(0), logger)
+	checkVersion(s, logger)
+	scan, err := ksonnet.ScanUsage(s, *opts, files)
+	if err != nil {
+		log.Fatalf("Could not scan Jsonnet files:\n%v", err)
+	}
+	for _, ref := range scan.Unresolved {
+		log.Printf("Warning: '%s' doesn't refer to a kind in the library", ref)
+	}
+	for _, ref := range scan.Computed {
+		log.Printf("Warning: can't tell which kinds '%s' refers to", ref)
+	}
+
+	pruned, ok := scan.Prune(s, *force)
+	if !ok {
+		log.Printf(
+			"Warning: not pruning, because %d references can't be resolved statically; pass --force to prune anyway",
+			len(scan.Computed))
+	} else {
+		log.Printf(
+			"Keeping %d kinds and %d definitions in total",
+			len(scan.Definitions), len(pruned.Definitions))
+	}
+
+	pruned = checkRefs(s, pruned, *strictRefs, opts.ExternalIndexes, logger)
+
+	out := openOutput(*format, *target)
+	artifacts := writeLibrary(ctx, pruned, *opts, out, !*noIndexDoc, logger)
+	if *checksums {
+		writeChecksums(artifacts, out, logger)
+	}
+	out.finalize()
+	reportSkipped(
+		*skipReport, flags.Arg(0), s, pruned, *opts,
+		fmt.Sprintf("not used by the Jsonnet in '%s'", *jsonnetDir))
+	logger.printTiming()
+}
+
+// readJsonnetDir reads every `.jsonnet` and `.libsonnet` file under
+// `dir`, keyed by its path.
+func readJsonnetDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() ||
+			!(strings.HasSuffix(path, ".jsonnet") || strings.HasSuffix(path, ".libsonnet")) {
+			return nil
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[path] = text
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// strictRefsFlag registers `--strict-refs` on `flags`.
+func strictRefsFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool(
+		"strict-refs", false,
+		"fail if a property refers to a definition that isn't in the spec after filtering, rather than re-including it")
+}
+
+// checkRefs checks that every property of `s`, the spec after
+// filtering or pruning, refers to a definition in it. With `strict`,
+// it exits, listing each dangling reference. Otherwise, it re-includes
+// the definitions of `original` that are referred to, logging each,
+// and warns about the references left dangling because `original`
+// doesn't have them either, which the library emits as opaque
+// setters. References to definitions that one of `externals` has (see
+// `ksonnet.Options.ExternalRefs`) are left as they are, since the
+// library refers to them in its external library. It returns the
+// repaired spec.
+func checkRefs(
+	original, s *kubespec.APISpec, strict bool, externals map[string]*ksonnet.SymbolIndex,
+	logger *cliLogger,
+) *kubespec.APISpec {
+	dangling := []*kubespec.DanglingRef{}
+	for _, ref := range s.DanglingRefs() {
+		if !hasExternal(externals, ref.To) {
+			dangling = append(dangling, ref)
+		}
+	}
+	if len(dangling) == 0 {
+		return s
+	}
+	if strict {
+		fatal(kubespec.DanglingRefsError(dangling))
+	}
+
+	repaired, added := s.RepairRefs(original)
+	for _, name := range added {
+		logger.Log("repair ref", "definition", name)
+	}
+	if len(added) > 0 {
+		log.Printf(
+			"Re-included %d definitions that the filtered spec refers to; pass --strict-refs to fail instead",
+			len(added))
+	}
+	for _, ref := range repaired.DanglingRefs() {
+		if hasExternal(externals, ref.To) {
+			continue
+		}
+		log.Printf(
+			"Warning: %s, which isn't in the spec, so '%s' is emitted as an opaque setter",
+			ref, ref.Property)
+	}
+	return repaired
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/jsonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// warnReservedIdentifiers warns about each setter and namespace of the
+// library that was renamed because the property it's for is named like
+// a reserved Jsonnet identifier (e.g., `std_` for `std`), so that
+// users aren't surprised by the trailing underscore.
+func warnReservedIdentifiers(s *kubespec.APISpec, opts ksonnet.Options) {
+	opts.Logger = nil
+	opts.Progress = nil
+	index, err := ksonnet.BuildSymbolIndex(s, opts)
+	if err != nil {
+		log.Fatalf("Could not build symbol index:\n%v", err)
+	}
+	for _, symbol := range index.Symbols {
+		if symbol.RenamedFrom != "" && jsonnet.IsReservedIdentifier(jsonnet.Identifier(symbol.RenamedFrom)) {
+			log.Printf(
+				"Warning: renamed '%s' of '%s' to '%s', since '%s' is reserved in Jsonnet",
+				symbol.Property, symbol.Definition, symbol.Path, symbol.RenamedFrom)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// roundTrip checks a generated library against a corpus of manifests:
+// each is rebuilt with the library's constructor and setters (see
+// `ksonnet.RoundTripProgram`), evaluated with the `jsonnet` binary,
+// and compared to the original (see `ksonnet.DiffManifests`). The
+// library must be generated with --emit-gvk-index, for
+// `util.forManifest`. Each manifest that doesn't round-trip is
+// reported with its differences, and fails the command; fields that
+// the library has no setter for are merged in as they are, and listed.
+func roundTrip(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("roundtrip", flag.ExitOnError)
+	manifestsDir := flags.String(
+		"manifests", "", "the directory of manifests ('.yaml', '.yml' or '.json' files, of one or more objects) to round-trip")
+	strictNulls := flags.Bool(
+		"strict-nulls", false, "report fields that are null in one manifest and absent in the other, rather than treating them as the same")
+	jsonnetPath := flags.String(
+		"jsonnet", "", "the `jsonnet` binary to evaluate the library with (default the one on $PATH)")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *manifestsDir == "" {
+		log.Fatal(usage)
+	}
+	dir := flags.Arg(0)
+	index := filepath.Join(dir, ksonnet.GVKIndexFile)
+	if _, err := os.Stat(index); err != nil {
+		log.Fatalf(
+			"`%s` has no `%s`, which round-tripping dispatches manifests with; regenerate it with --emit-gvk-index:\n%v",
+			dir, ksonnet.GVKIndexFile, err)
+	}
+	if *jsonnetPath == "" {
+		path, err := exec.LookPath("jsonnet")
+		if err != nil {
+			log.Fatalf("Could not find `jsonnet`; install it, or pass --jsonnet:\n%v", err)
+		}
+		*jsonnetPath = path
+	}
+
+	manifests, err := ksonnet.ReadManifests(*manifestsDir)
+	if err != nil {
+		log.Fatalf("Could not read the manifests of '%s':\n%v", *manifestsDir, err)
+	}
+	scratch, err := ioutil.TempDir("", "roundtrip")
+	if err != nil {
+		log.Fatalf("Could not create a directory for the programs:\n%v", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	failed := 0
+	for i, manifest := range manifests {
+		problems, merged := roundTripManifest(
+			ctx, *jsonnetPath, dir, filepath.Join(scratch, fmt.Sprintf("%d.jsonnet", i)), manifest, *strictNulls)
+		status := "PASS"
+		if len(problems) > 0 {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s %s (%s)\n", status, manifest.Name, describeManifest(manifest.Object))
+		for _, problem := range problems {
+			fmt.Printf("    %s\n", strings.Replace(problem, "\n", "\n    ", -1))
+		}
+		if len(merged) > 0 {
+			fmt.Printf("    merged without a setter: %s\n", strings.Join(merged, ", "))
+		}
+	}
+
+	if failed == 0 {
+		log.Printf("Round-trip passed: all %d manifests of '%s' were rebuilt by the library", len(manifests), *manifestsDir)
+		return
+	}
+	log.Printf("Round-trip failed: %d of %d manifests of '%s' weren't rebuilt by the library", failed, len(manifests), *manifestsDir)
+	os.Exit(exitCode(ksonnet.ErrVerifyFailed))
+}
+
+// roundTripManifest rebuilds `manifest` with the library in `dir`,
+// writing its program to `path`, and returns why it didn't round-trip
+// (the error evaluating it, or its differences), and the fields that
+// were merged in as they are, because the library has no setter for
+// them (e.g., `status`), which don't fail it.
+func roundTripManifest(
+	ctx context.Context, jsonnet, dir, path string, manifest ksonnet.Manifest, strictNulls bool,
+) (problems, merged []string) {
+	program, err := ksonnet.RoundTripProgram(manifest.Object)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	if err := ioutil.WriteFile(path, []byte(program), 0644); err != nil {
+		log.Fatalf("Could not write the program of '%s':\n%v", manifest.Name, err)
+	}
+	out, err := exec.CommandContext(ctx, jsonnet, "-J", dir, path).Output()
+	exitIfInterrupted(ctx.Err())
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return []string{fmt.Sprintf("Could not evaluate:\n%s", strings.TrimSpace(string(exitErr.Stderr)))}, nil
+		}
+		return []string{fmt.Sprintf("Could not evaluate:\n%v", err)}, nil
+	}
+	var result struct {
+		Object interface{} `json:"object"`
+		Merged []string    `json:"merged"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return []string{fmt.Sprintf("Could not read the result:\n%v", err)}, nil
+	}
+	return ksonnet.DiffManifests(manifest.Object, result.Object, strictNulls), result.Merged
+}
+
+// describeManifest returns the apiVersion, kind and name of a manifest,
+// as `roundtrip` reports it by.
+func describeManifest(obj interface{}) string {
+	fields, _ := obj.(map[string]interface{})
+	metadata, _ := fields["metadata"].(map[string]interface{})
+	parts := []string{}
+	for _, value := range []interface{}{fields["apiVersion"], fields["kind"], metadata["name"]} {
+		if s, ok := value.(string); ok {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// samples writes a sample manifest of each top-level kind of the
+// library, as YAML, rendered by evaluating a program that calls the
+// kind's constructor with placeholder arguments (see
+// `ksonnet.EmitSamples`) with the `jsonnet` binary, and prints a
+// summary of the kinds it skipped: those it has no placeholders for,
+// and those whose programs don't evaluate.
+func samples(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("samples", flag.ExitOnError)
+	output := flags.String("o", "", "the directory to write the samples to")
+	jsonnetPath := flags.String(
+		"jsonnet", "", "the `jsonnet` binary to evaluate the samples with (default the one on $PATH)")
+	opts := emitOptionFlags(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *output == "" {
+		log.Fatal(usage)
+	}
+	if *jsonnetPath == "" {
+		path, err := exec.LookPath("jsonnet")
+		if err != nil {
+			log.Fatalf("Could not find `jsonnet`; install it, or pass --jsonnet:\n%v", err)
+		}
+		*jsonnetPath = path
+	}
+
+	opts.Parser = prefixes.parser()
+	logger := &cliLogger{}
+	s := loadSpec(ctx, flags.Arg(0), logger)
+	checkVersion(s, logger)
+	library, err := ksonnet.Emit(ctx, s, *opts)
+	if err != nil {
+		fatal(fmt.Errorf("Could not write ksonnet library:\n%w", err))
+	}
+	samples, skipped, err := ksonnet.EmitSamples(s, *opts)
+	if err != nil {
+		log.Fatalf("Could not write samples:\n%v", err)
+	}
+
+	// The programs import the library two directories up, so they're
+	// evaluated from a tree like the one they were written for.
+	scratch, err := ioutil.TempDir("", "samples")
+	if err != nil {
+		log.Fatalf("Could not create a directory for the programs:\n%v", err)
+	}
+	defer os.RemoveAll(scratch)
+	if err := ioutil.WriteFile(filepath.Join(scratch, ksonnet.LibraryFile), library, 0644); err != nil {
+		log.Fatalf("Could not write ksonnet library:\n%v", err)
+	}
+
+	written := 0
+	for _, sample := range samples {
+		manifest, err := evaluateSample(ctx, *jsonnetPath, scratch, sample)
+		if err != nil {
+			skipped = append(skipped, &ksonnet.SkippedSample{Kind: sample.Kind, Reason: err.Error()})
+			continue
+		}
+		path := filepath.Join(*output, filepath.FromSlash(sample.Path)+".yaml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatalf("Could not create directory '%s':\n%v", filepath.Dir(path), err)
+		}
+		if err := ioutil.WriteFile(path, manifest, 0644); err != nil {
+			log.Fatalf("Could not write `%s`:\n%v", path, err)
+		}
+		written++
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %d samples to '%s'", written, *output)
+	if len(skipped) == 0 {
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	sort.SliceStable(skipped, func(i, j int) bool { return skipped[i].Kind < skipped[j].Kind })
+	fmt.Fprintf(os.Stderr, "; skipped %d:\n", len(skipped))
+	for _, sample := range skipped {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", sample.Kind, sample.Reason)
+	}
+}
+
+// evaluateSample writes the program of `sample` under `dir`, which
+// has the library, and returns the manifest it renders, or why it
+// couldn't be evaluated: the first line of `jsonnet`'s error.
+func evaluateSample(ctx context.Context, jsonnet, dir string, sample *ksonnet.Sample) ([]byte, error) {
+	path := filepath.Join(dir, filepath.FromSlash(sample.Path)+".jsonnet")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatalf("Could not create directory '%s':\n%v", filepath.Dir(path), err)
+	}
+	if err := ioutil.WriteFile(path, sample.Program, 0644); err != nil {
+		log.Fatalf("Could not write the program of `%s`:\n%v", sample.Kind, err)
+	}
+	out, err := exec.CommandContext(ctx, jsonnet, "-S", path).Output()
+	exitIfInterrupted(ctx.Err())
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("its constructor doesn't evaluate: %s",
+				strings.SplitN(strings.TrimSpace(string(exitErr.Stderr)), "\n", 2)[0])
+		}
+		return nil, fmt.Errorf("its constructor doesn't evaluate: %v", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// snippetContext is the number of bytes of description printed on
+// either side of a match.
+const snippetContext = 60
+
+// search prints the definitions and properties whose descriptions
+// match a term, which answers "which field controls X?" without
+// grepping the spec.
+func search(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	isRegex := flags.Bool("regex", false, "treat the term as a regular expression")
+	limit := flags.Int("limit", 20, "print at most this many results (0 prints all)")
+	kind := flags.String(
+		"kind", "",
+		"only search definitions reachable from this kind (e.g., Pod, or apps/v1beta1/Deployment)")
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		log.Fatal(usage)
+	}
+
+	term := flags.Arg(1)
+	if !*isRegex {
+		term = regexp.QuoteMeta(term)
+	}
+	pattern, err := regexp.Compile("(?i)" + term)
+	if err != nil {
+		log.Fatalf("Could not compile search term '%s':\n%v", flags.Arg(1), err)
+	}
+
+	s := loadSpec(ctx, flags.Arg(0), &cliLogger{})
+	var paths map[kubespec.DefinitionName]string
+	if *kind != "" {
+		paths = kindClosure(s, *kind)
+	}
+
+	results := prefixes.parser().Search(s, pattern, paths)
+	total := len(results)
+	if *limit > 0 && total > *limit {
+		results = results[:*limit]
+	}
+
+	if *asJSON {
+		text, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatalf("Could not serialize search results:\n%v", err)
+		}
+		fmt.Println(string(text))
+		return
+	}
+
+	writeSearchResults(os.Stdout, results, isTerminal(os.Stdout))
+	if len(results) < total {
+		fmt.Printf(
+			"... %d more results; pass --limit %d to print them all\n",
+			total-len(results), total)
+	}
+}
+
+// kindClosure returns every definition reachable from `query`, which
+// is either a bare kind (e.g., `Pod`), in which case every version of
+// it is searched, or anything `FindDefinition` accepts.
+func kindClosure(s *kubespec.APISpec, query string) map[kubespec.DefinitionName]string {
+	roots := []kubespec.DefinitionName{}
+	if name, err := s.FindDefinition(query); err == nil {
+		roots = append(roots, name)
+	} else if !strings.Contains(query, "/") {
+		roots = s.FindKind(kubespec.ObjectKind(query))
+	}
+	if len(roots) == 0 {
+		log.Fatalf("Could not find kind '%s'", query)
+	}
+
+	paths := map[kubespec.DefinitionName]string{}
+	for _, root := range roots {
+		for name, path := range s.Closure(root) {
+			if existing, ok := paths[name]; !ok || path < existing {
+				paths[name] = path
+			}
+		}
+	}
+	return paths
+}
+
+func writeSearchResults(w io.Writer, results []*kubespec.SearchResult, color bool) {
+	highlight := func(text string) string { return "**" + text + "**" }
+	if color {
+		highlight = func(text string) string { return "\x1b[1;31m" + text + "\x1b[0m" }
+	}
+
+	for _, result := range results {
+		name := string(result.Definition)
+		if p := result.Parsed; p != nil {
+			name = p.Kind.String()
+			if p.HasVersion() {
+				name = fmt.Sprintf("%s.%s", p.Version, name)
+			}
+			if p.HasGroup() {
+				name = fmt.Sprintf("%s.%s", p.Group, name)
+			}
+		}
+		if result.Path != "" {
+			name = fmt.Sprintf("%s  %s", name, result.Path)
+		}
+
+		before, match, after := result.Snippet(snippetContext)
+		fmt.Fprintln(w, name)
+		fmt.Fprintf(w, "    %s%s%s\n", before, highlight(match), after)
+	}
+}
+
+// isTerminal reports whether `f` is a terminal, in which case matches
+// are highlighted with color rather than `**`.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
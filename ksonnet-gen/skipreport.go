@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// skipReportFlag registers `--skip-report` on `flags`.
+func skipReportFlag(flags *flag.FlagSet) *string {
+	return flags.String(
+		"skip-report", "",
+		"write a JSON array of the definitions that aren't emitted, and why, to this file")
+}
+
+// reportSkipped warns about the definitions of `original` that were
+// skipped because their names don't parse (see `--lenient`), and, if
+// `reportPath` is set, writes every definition that isn't emitted from
+// `s`, the spec after filtering, to it; see `ksonnet.SkippedDefinition`.
+// `filterDetail` explains why definitions were filtered out.
+func reportSkipped(
+	reportPath, swaggerPath string, original, s *kubespec.APISpec,
+	opts ksonnet.Options, filterDetail string,
+) {
+	if reportPath == "" && !opts.Lenient {
+		return
+	}
+
+	skipped := append(
+		ksonnet.FilteredDefinitions(original, s, filterDetail),
+		ksonnet.SkippedDefinitions(s, opts)...)
+	ksonnet.SortSkippedDefinitions(skipped)
+	for _, sd := range skipped {
+		sd.Source = swaggerPath
+		if sd.Reason == ksonnet.SkipUnparsable || sd.Reason == ksonnet.SkipMalformed {
+			log.Printf("Warning: skipping definition: %s", sd.Detail)
+		}
+	}
+	if reportPath == "" {
+		return
+	}
+
+	text, err := json.MarshalIndent(skipped, "", "  ")
+	if err != nil {
+		log.Fatalf("Could not serialize skipped definitions:\n%v", err)
+	}
+	if err := ioutil.WriteFile(reportPath, append(text, '\n'), 0644); err != nil {
+		log.Fatalf("Could not write skip report '%s':\n%v", reportPath, err)
+	}
+}
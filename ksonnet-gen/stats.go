@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// weightsReport is how much each definition weighs in the spec and in
+// the generated library, which helps decide what to prune.
+type weightsReport struct {
+	Definitions []*definitionWeight `json:"definitions"`
+
+	// TotalBytes is the size of the whole library, including the
+	// bytes that belong to no definition (e.g., group namespaces), so
+	// that each definition's share can be computed.
+	TotalBytes int `json:"totalBytes"`
+}
+
+type definitionWeight struct {
+	Name              kubespec.DefinitionName `json:"name"`
+	InboundReferences int                     `json:"inboundReferences"`
+	Dependencies      int                     `json:"dependencies"` // Transitive.
+	Bytes             int                     `json:"bytes"`
+}
+
+// stats reports statistics about a swagger spec and the library
+// generated from it: `--weights`, `--codebases`, or `--provenance`.
+func stats(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	weights := flags.Bool(
+		"weights", false,
+		"report the inbound references, transitive dependencies, and emitted size of each definition")
+	codebases := flags.Bool(
+		"codebases", false,
+		"report the groups and versions each codebase contributed to the spec")
+	provenance := flags.Bool(
+		"provenance", false,
+		"report the spec's info and securityDefinitions: its title, version, contact, and auth modes")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	opts := emitOptionFlags(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	modes := 0
+	for _, mode := range []bool{*weights, *codebases, *provenance} {
+		if mode {
+			modes++
+		}
+	}
+	if modes != 1 || flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+
+	opts.Parser = prefixes.parser()
+	logger := &cliLogger{}
+	s := loadSpec(ctx, flags.Arg(0), logger)
+	checkVersion(s, logger)
+	if *provenance {
+		report := &provenanceReport{Provenance: s.Provenance(), Problems: s.MetadataProblems}
+		if *asJSON {
+			printJSONReport(report)
+			return
+		}
+		writeProvenanceReport(os.Stdout, report)
+		return
+	}
+	if *codebases {
+		report := opts.Parser.Codebases(s)
+		if *asJSON {
+			printJSONReport(report)
+			return
+		}
+		writeCodebasesReport(os.Stdout, report)
+		return
+	}
+
+	report, err := buildWeightsReport(s, *opts)
+	if err != nil {
+		fatal(fmt.Errorf("Could not compute definition weights:\n%w", err))
+	}
+
+	if *asJSON {
+		printJSONReport(report)
+		return
+	}
+
+	writeWeightsReport(os.Stdout, report)
+}
+
+func printJSONReport(report interface{}) {
+	text, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Could not serialize report:\n%v", err)
+	}
+	fmt.Println(string(text))
+}
+
+func buildWeightsReport(
+	s *kubespec.APISpec, opts ksonnet.Options,
+) (*weightsReport, error) {
+	sizes, total, err := ksonnet.EmittedSizes(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	inbound := s.InboundReferences()
+
+	report := &weightsReport{TotalBytes: total}
+	for name := range s.Definitions {
+		report.Definitions = append(report.Definitions, &definitionWeight{
+			Name:              name,
+			InboundReferences: inbound[name],
+			Dependencies:      len(s.Closure(name)) - 1,
+			Bytes:             sizes[name],
+		})
+	}
+
+	// Heaviest first, by size, then by how widely they're used.
+	sort.Slice(report.Definitions, func(i, j int) bool {
+		a, b := report.Definitions[i], report.Definitions[j]
+		switch {
+		case a.Bytes != b.Bytes:
+			return a.Bytes > b.Bytes
+		case a.InboundReferences != b.InboundReferences:
+			return a.InboundReferences > b.InboundReferences
+		case a.Dependencies != b.Dependencies:
+			return a.Dependencies > b.Dependencies
+		default:
+			return a.Name < b.Name
+		}
+	})
+	return report, nil
+}
+
+func writeWeightsReport(w io.Writer, report *weightsReport) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "BYTES\t%\tINBOUND\tDEPENDENCIES\t  DEFINITION")
+	attributed, inbound := 0, 0
+	for _, dw := range report.Definitions {
+		fmt.Fprintf(
+			tw, "%d\t%s\t%d\t%d\t  %s\n",
+			dw.Bytes, percent(dw.Bytes, report.TotalBytes), dw.InboundReferences,
+			dw.Dependencies, dw.Name)
+		attributed += dw.Bytes
+		inbound += dw.InboundReferences
+	}
+	other := report.TotalBytes - attributed
+	fmt.Fprintf(
+		tw, "%d\t%s\t\t\t  (other)\n", other, percent(other, report.TotalBytes))
+	fmt.Fprintf(
+		tw, "%d\t%s\t%d\t\t  total (%d definitions)\n",
+		report.TotalBytes, percent(report.TotalBytes, report.TotalBytes), inbound,
+		len(report.Definitions))
+	tw.Flush()
+}
+
+// writeCodebasesReport writes a line for each codebase, in order of
+// name, with its group/versions, or `-` if it has none.
+func writeCodebasesReport(w io.Writer, report map[string][]kubespec.GroupVersion) {
+	names := []string{}
+	for name := range report {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CODEBASE\tGROUP/VERSIONS")
+	for _, name := range names {
+		gvs := []string{}
+		for _, gv := range report[name] {
+			gvs = append(gvs, gv.String())
+		}
+		if len(gvs) == 0 {
+			gvs = append(gvs, "-")
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, strings.Join(gvs, ", "))
+	}
+	tw.Flush()
+}
+
+// provenanceReport is what a spec says about where it's from, and the
+// parts of its metadata that were malformed, and so ignored.
+type provenanceReport struct {
+	*kubespec.Provenance
+	Problems []string `json:"problems,omitempty"`
+}
+
+// writeProvenanceReport writes a line for each field of the spec's
+// provenance, or `-` if it doesn't have it, and then one for each
+// auth mode and each problem.
+func writeProvenanceReport(w io.Writer, report *provenanceReport) {
+	or := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+	contact := "-"
+	if c := report.Contact; c != nil {
+		parts := []string{}
+		for _, part := range []string{c.Name, c.Email, c.URL} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		contact = or(strings.Join(parts, ", "))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "TITLE\t%s\n", or(report.Title))
+	fmt.Fprintf(tw, "VERSION\t%s\n", or(report.Version))
+	fmt.Fprintf(tw, "CONTACT\t%s\n", contact)
+	if len(report.AuthModes) == 0 {
+		fmt.Fprintln(tw, "AUTH MODE\t-")
+	}
+	for _, mode := range report.AuthModes {
+		fmt.Fprintf(tw, "AUTH MODE\t%s\n", mode)
+	}
+	for _, problem := range report.Problems {
+		fmt.Fprintf(tw, "MALFORMED\t%s\n", problem)
+	}
+	tw.Flush()
+}
+
+func percent(part, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", 100*float64(part)/float64(total))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/kubespec"
+)
+
+// subset writes the part of a swagger spec that a library generated
+// with `--include-group` depends on, so that it can be committed in
+// place of the full spec. Generating from the subset, with the same
+// groups, produces the same library.
+func subset(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("subset", flag.ExitOnError)
+	output := flags.String("o", "", "the file to write the subset to")
+	withPaths := flags.Bool(
+		"paths", true,
+		"keep the paths of the retained kinds, which generation uses for their scope and subresources")
+	groups := includeGroupsFlag(flags)
+	stages := excludeStagesFlags(flags)
+	prefixes := definitionPrefixesFlag(flags)
+	flags.Parse(args)
+
+	if *output == "" || (len(*groups) == 0 && len(stages.stages()) == 0) || flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+
+	s := filterSpec(loadSpec(ctx, flags.Arg(0), &cliLogger{}), *groups, stages, prefixes.parser())
+	text, err := s.MarshalSubset(*withPaths)
+	if err != nil {
+		log.Fatalf("Could not write spec subset:\n%v", err)
+	}
+	if err = ioutil.WriteFile(*output, text, 0644); err != nil {
+		log.Fatalf("Could not write `%s`:\n%v", *output, err)
+	}
+}
+
+// includeGroupsFlag registers `--include-group` on `flags`, which may
+// be passed more than once.
+func includeGroupsFlag(flags *flag.FlagSet) *groupsFlag {
+	groups := &groupsFlag{}
+	flags.Var(
+		groups, "include-group",
+		"only generate the kinds of this API group (e.g., 'apps', or 'core'), and the definitions they use; may be repeated")
+	return groups
+}
+
+// excludeStagesFlags registers `--exclude-alpha` and `--exclude-beta`
+// on `flags`.
+func excludeStagesFlags(flags *flag.FlagSet) *stagesFlags {
+	stages := &stagesFlags{}
+	flags.BoolVar(
+		&stages.alpha, "exclude-alpha", false,
+		"don't generate alpha versions (e.g., 'v2alpha1'), except for the definitions that other versions use")
+	flags.BoolVar(
+		&stages.beta, "exclude-beta", false,
+		"don't generate beta versions (e.g., 'v1beta1'), except for the definitions that other versions use")
+	return stages
+}
+
+// stagesFlags are the version stages excluded by `--exclude-alpha` and
+// `--exclude-beta`.
+type stagesFlags struct {
+	alpha, beta bool
+}
+
+func (f *stagesFlags) stages() []kubespec.VersionStage {
+	stages := []kubespec.VersionStage{}
+	if f.alpha {
+		stages = append(stages, kubespec.StageAlpha)
+	}
+	if f.beta {
+		stages = append(stages, kubespec.StageBeta)
+	}
+	return stages
+}
+
+// filterSpec removes the versions of the excluded stages from `s`, and
+// then restricts it to `groups`, if there are any, parsing names with
+// `parser`.
+func filterSpec(
+	s *kubespec.APISpec, groups groupsFlag, stages *stagesFlags, parser *kubespec.Parser,
+) *kubespec.APISpec {
+	if excluded := stages.stages(); len(excluded) > 0 {
+		s = s.Filter(parser.ExcludingStages(excluded))
+	}
+	if len(groups) == 0 {
+		return s
+	}
+	return s.Filter(parser.InGroups(groups))
+}
+
+// filterDetail explains why `filterSpec` removed a definition, for the
+// skip report.
+func filterDetail(groups groupsFlag, stages *stagesFlags) string {
+	reasons := []string{}
+	for _, stage := range stages.stages() {
+		reasons = append(reasons, fmt.Sprintf(
+			"in an excluded %s version (--exclude-%s) that no other version uses", stage, stage))
+	}
+	if len(groups) > 0 {
+		reasons = append(reasons, fmt.Sprintf(
+			"not used by the kinds of --include-group %s", &groups))
+	}
+	return strings.Join(reasons, ", or ")
+}
+
+// groupsFlag adapts a repeated flag to a list of API groups.
+type groupsFlag []kubespec.GroupName
+
+func (f *groupsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	groups := []string{}
+	for _, group := range *f {
+		groups = append(groups, string(group))
+	}
+	return strings.Join(groups, ",")
+}
+
+func (f *groupsFlag) Set(value string) error {
+	*f = append(*f, kubespec.GroupName(value))
+	return nil
+}
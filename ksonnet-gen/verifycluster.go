@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/discovery"
+	"github.com/ksonnet/ksonnet-lib/ksonnet-gen/ksonnet"
+)
+
+// verifyCluster checks that a cluster serves every group/version of
+// the kinds of a generated library, which catches generating from a
+// newer spec than the cluster runs. The group/versions are read from
+// the header of `k8s.libsonnet` in the generated dir, or from a symbol
+// index (see `check --update-baseline`), and the cluster's from its
+// discovery endpoints. Missing group/versions are reported; with
+// `--strict`, they fail the command.
+func verifyCluster(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("verify-cluster", flag.ExitOnError)
+	kubeconfig := flags.String(
+		"kubeconfig", "",
+		"the kubeconfig file of the cluster (default $KUBECONFIG, or ~/.kube/config)")
+	contextName := flags.String(
+		"context", "", "the context of the kubeconfig to use (default its current context)")
+	server := flags.String(
+		"server", "",
+		"the URL of the API server (e.g., 'http://localhost:8001' for 'kubectl proxy'), instead of a kubeconfig")
+	strict := flags.Bool(
+		"strict", false, "exit nonzero if the cluster doesn't serve a group/version of the library")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal(usage)
+	}
+
+	emitted := emittedAPIVersions(flags.Arg(0))
+	client := &discovery.Client{Server: *server}
+	if *server == "" {
+		client = kubeconfigClient(*kubeconfig, *contextName)
+	}
+
+	served, err := client.GroupVersions(ctx)
+	exitIfInterrupted(err)
+	if err != nil {
+		log.Fatalf("Could not discover the group/versions of '%s':\n%v", client.Server, err)
+	}
+
+	isServed := map[string]bool{}
+	for _, groupVersion := range served {
+		isServed[groupVersion] = true
+	}
+	missing := []string{}
+	for _, apiVersion := range emitted {
+		if !isServed[apiVersion] {
+			missing = append(missing, apiVersion)
+		}
+	}
+	for _, apiVersion := range missing {
+		fmt.Printf("missing: %s\n", apiVersion)
+	}
+
+	if len(missing) == 0 {
+		log.Printf(
+			"Cluster check passed: '%s' serves all %d group/versions of the library",
+			client.Server, len(emitted))
+	} else if *strict {
+		log.Printf(
+			"Cluster check failed: '%s' doesn't serve %d of %d group/versions of the library",
+			client.Server, len(missing), len(emitted))
+		os.Exit(exitCode(ksonnet.ErrVerifyFailed))
+	} else {
+		log.Printf(
+			"Warning: '%s' doesn't serve %d of %d group/versions of the library; pass --strict to fail",
+			client.Server, len(missing), len(emitted))
+	}
+}
+
+// emittedAPIVersions returns the group/versions of the kinds of the
+// library at `path`: a generated dir, whose `k8s.libsonnet` lists them
+// in its header, or, if it was generated with --diff-friendly, whose
+// `apiVersions.json` does, or a symbol index.
+func emittedAPIVersions(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Fatalf("Could not read '%s':\n%v", path, err)
+	}
+
+	if !info.IsDir() {
+		return readSymbolIndex(path).APIVersions()
+	}
+
+	libraryPath := filepath.Join(path, ksonnet.LibraryFile)
+	text, err := ioutil.ReadFile(libraryPath)
+	if err != nil {
+		log.Fatalf("Could not read `%s`:\n%v", libraryPath, err)
+	}
+	if apiVersions, ok := ksonnet.LibraryAPIVersions(text); ok {
+		return apiVersions
+	}
+
+	apiVersionsPath := filepath.Join(path, ksonnet.APIVersionsFile)
+	text, err = ioutil.ReadFile(apiVersionsPath)
+	if os.IsNotExist(err) {
+		log.Fatalf(
+			"`%s` doesn't list its group/versions, and there's no `%s`; regenerate it, or pass a symbol index",
+			libraryPath, ksonnet.APIVersionsFile)
+	} else if err != nil {
+		log.Fatalf("Could not read `%s`:\n%v", apiVersionsPath, err)
+	}
+	apiVersions, err := ksonnet.ParseAPIVersions(text)
+	if err != nil {
+		log.Fatalf("Could not read `%s`:\n%v", apiVersionsPath, err)
+	}
+	return apiVersions
+}
+
+// kubeconfigClient makes a discovery client for the context
+// `contextName` of the kubeconfig at `path`, or at `$KUBECONFIG` or
+// `~/.kube/config` if it's empty.
+func kubeconfigClient(path, contextName string) *discovery.Client {
+	if path == "" {
+		path = os.Getenv("KUBECONFIG")
+		if list := filepath.SplitList(path); len(list) > 0 {
+			path = list[0]
+		}
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			log.Fatalf("Could not find the default kubeconfig; pass --kubeconfig:\n%v", err)
+		}
+		path = filepath.Join(home, ".kube", "config")
+	}
+
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Could not read kubeconfig '%s':\n%v", path, err)
+	}
+	config, err := discovery.ParseConfig(text, filepath.Dir(path))
+	if err != nil {
+		log.Fatalf("Could not read kubeconfig '%s':\n%v", path, err)
+	}
+	client, err := config.Client(contextName)
+	if err != nil {
+		log.Fatalf("Could not use kubeconfig '%s':\n%v", path, err)
+	}
+	return client
+}
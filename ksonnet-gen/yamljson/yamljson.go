@@ -0,0 +1,991 @@
+// Package yamljson converts YAML to JSON, so that swagger specs and
+// manifests written in YAML can be read like those written in JSON,
+// without depending on a YAML library. It handles the YAML that specs
+// and manifests are written in: block and flow mappings and sequences
+// (including mappings in sequences, e.g., `- name: x`), plain, quoted,
+// multi-line, literal (`|`) and folded (`>`) scalars, comments, and
+// streams of several documents separated by `---`. Anchors, aliases,
+// tags, and complex keys aren't supported, and are errors rather than
+// being misread.
+//
+// Plain scalars are resolved as in YAML 1.2's core schema: `true` and
+// `false` are booleans, `~` and `null` are null, and numbers are
+// written to the JSON as they're spelled in the YAML (rewritten only
+// where JSON's grammar is stricter, e.g., `+1` or `0x1F`), so that
+// decoding with `json.Decoder.UseNumber` keeps every digit. Mapping
+// keys are written in the order they appear in the YAML, and a key that
+// appears twice in a mapping is an error that names it and its lines.
+package yamljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ToJSON converts `text`, a single YAML document, to JSON. An empty
+// document is `null`.
+func ToJSON(text []byte) ([]byte, error) {
+	documents, err := parseStream(string(text))
+	if err != nil {
+		return nil, err
+	}
+	switch len(documents) {
+	case 0:
+		return []byte("null"), nil
+	case 1:
+		return documents[0].json()
+	}
+	return nil, fmt.Errorf(
+		"line %d: expected a single YAML document, got %d", documents[1].line, len(documents))
+}
+
+// Documents converts each document of the YAML stream `text` to JSON.
+// Documents are separated by `---` (and may be ended by `...`), at the
+// start of a line; empty documents, e.g., before the first `---`, are
+// skipped.
+func Documents(text []byte) ([][]byte, error) {
+	documents, err := parseStream(string(text))
+	if err != nil {
+		return nil, err
+	}
+	converted := [][]byte{}
+	for _, document := range documents {
+		j, err := document.json()
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, j)
+	}
+	return converted, nil
+}
+
+//-----------------------------------------------------------------------------
+// Nodes.
+//-----------------------------------------------------------------------------
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	sequenceNode
+	mappingNode
+)
+
+// node is a parsed YAML value, with the line it starts on, so that
+// errors can point at it.
+type node struct {
+	kind nodeKind
+	line int
+
+	// The text of a scalar, and whether it was unquoted, in which case
+	// it's resolved to null, a boolean, or a number if it spells one.
+	value string
+	plain bool
+
+	items []*node // Of a sequence.
+
+	// The keys of a mapping, in the order they're written, and their
+	// values.
+	keys   []string
+	values []*node
+}
+
+func nullNode(num int) *node {
+	return &node{kind: scalarNode, line: num, plain: true}
+}
+
+func (n *node) json() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := n.writeJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *node) writeJSON(buf *bytes.Buffer) error {
+	switch n.kind {
+	case sequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := item.writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case mappingNode:
+		buf.WriteByte('{')
+		for i, key := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, key)
+			buf.WriteByte(':')
+			if err := n.values[i].writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		if !n.plain {
+			writeString(buf, n.value)
+			return nil
+		}
+		resolved, err := resolvePlain(n.value)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", n.line, err)
+		}
+		buf.WriteString(resolved)
+	}
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	// Marshaling a string can't fail.
+	text, _ := json.Marshal(s)
+	buf.Write(text)
+}
+
+var (
+	intPattern     = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	octalPattern   = regexp.MustCompile(`^0o[0-7]+$`)
+	hexPattern     = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	floatPattern   = regexp.MustCompile(`^([-+]?)(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	infNaNPattern  = regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+	leadingZeroes  = regexp.MustCompile(`^0+([0-9])`)
+	resolvedValues = map[string]string{
+		"": "null", "~": "null", "null": "null", "Null": "null", "NULL": "null",
+		"true": "true", "True": "true", "TRUE": "true",
+		"false": "false", "False": "false", "FALSE": "false",
+	}
+)
+
+// resolvePlain returns the JSON for the plain scalar `text`: null, a
+// boolean, a number, or otherwise a string.
+func resolvePlain(text string) (string, error) {
+	if resolved, ok := resolvedValues[text]; ok {
+		return resolved, nil
+	}
+	switch {
+	case intPattern.MatchString(text):
+		sign := ""
+		if text[0] == '-' || text[0] == '+' {
+			sign, text = strings.TrimPrefix(text[:1], "+"), text[1:]
+		}
+		return sign + leadingZeroes.ReplaceAllString(text, "$1"), nil
+	case octalPattern.MatchString(text), hexPattern.MatchString(text):
+		base := 8
+		if text[1] == 'x' {
+			base = 16
+		}
+		n, _ := new(big.Int).SetString(text[2:], base)
+		return n.String(), nil
+	case floatPattern.MatchString(text):
+		match := floatPattern.FindStringSubmatch(text)
+		sign, mantissa, exponent := strings.TrimPrefix(match[1], "+"), match[2], match[4]
+		whole, fraction := mantissa, ""
+		if dot := strings.Index(mantissa, "."); dot >= 0 {
+			whole, fraction = mantissa[:dot], mantissa[dot+1:]
+		}
+		if whole == "" {
+			whole = "0"
+		}
+		number := sign + leadingZeroes.ReplaceAllString(whole, "$1")
+		if fraction != "" {
+			number += "." + fraction
+		}
+		return number + exponent, nil
+	case infNaNPattern.MatchString(text):
+		return "", fmt.Errorf("'%s' can't be represented in JSON", text)
+	}
+	var buf bytes.Buffer
+	writeString(&buf, text)
+	return buf.String(), nil
+}
+
+//-----------------------------------------------------------------------------
+// Block parsing.
+//-----------------------------------------------------------------------------
+
+// line is a line of YAML that has content, i.e., that's neither blank
+// nor only a comment.
+type line struct {
+	num    int // 1-based.
+	indent int
+	text   string // Without indentation, comments, or trailing space.
+}
+
+// parser parses the document in `lines[pos:end]`.
+type parser struct {
+	lines    []string
+	pos, end int
+}
+
+// parseStream splits `text` into documents, and parses each one that
+// isn't empty.
+func parseStream(text string) ([]*node, error) {
+	text = strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\ufeff")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	documents := []*node{}
+	start, content := 0, false
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) {
+			raw := lines[i]
+			trimmed := strings.TrimSpace(raw)
+			marker := raw == "---" || raw == "..." ||
+				strings.HasPrefix(raw, "--- ") || strings.HasPrefix(raw, "... ")
+			if !marker {
+				if !content && strings.HasPrefix(raw, "%") {
+					// A directive, e.g., `%YAML 1.2`, before the document.
+					lines[i] = ""
+				} else if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+					content = true
+				}
+				continue
+			}
+			if rest := strings.TrimSpace(raw[3:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf(
+					"line %d: content after a document marker isn't supported; start it on the next line", i+1)
+			}
+		}
+
+		if content {
+			p := &parser{lines: lines, pos: start, end: i}
+			document, err := p.parseDocument()
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, document)
+		}
+		start, content = i+1, false
+	}
+	return documents, nil
+}
+
+func (p *parser) parseDocument() (*node, error) {
+	l, _, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	var document *node
+	if isSequenceItem(l.text) || isKey(l.text) {
+		document, err = p.parseBlock(l.indent)
+	} else {
+		p.pos++
+		document, err = p.parseValue(-1, l.text, l.num, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok, err := p.peek(); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.pos+1)
+	}
+	return document, nil
+}
+
+// peek returns the next line with content, without consuming it; the
+// blank and comment lines before it are consumed.
+func (p *parser) peek() (*line, bool, error) {
+	for ; p.pos < p.end; p.pos++ {
+		raw := p.lines[p.pos]
+		trimmed := strings.TrimLeft(raw, " \t")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indentation := raw[:len(raw)-len(trimmed)]
+		if strings.Contains(indentation, "\t") {
+			return nil, false, fmt.Errorf("line %d: YAML can't be indented with tabs", p.pos+1)
+		}
+		return &line{num: p.pos + 1, indent: len(indentation), text: stripComment(trimmed)}, true, nil
+	}
+	return nil, false, nil
+}
+
+// stripComment removes the comment, if any, from the end of `text`: a
+// `#` that starts the text or follows whitespace, outside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			// Quotes only open at the start of a scalar.
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return strings.TrimRight(text, " \t")
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isKey reports whether `text` starts a block mapping entry, e.g.,
+// `name: x` or `"name":`.
+func isKey(text string) bool {
+	_, _, ok := splitKey(text)
+	return ok && text[0] != '[' && text[0] != '{'
+}
+
+// splitKey splits `text` at the colon that ends its key: for quoted
+// keys, the first one after the closing quote, and otherwise the first
+// one followed by a space or the end of the line.
+func splitKey(text string) (key, rest string, ok bool) {
+	if text == "" {
+		return "", "", false
+	} else if text[0] == '"' || text[0] == '\'' {
+		end := quoteEnd(text, text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		after := strings.TrimLeft(text[end+1:], " ")
+		if !strings.HasPrefix(after, ":") {
+			return "", "", false
+		}
+		return text[:end+1], strings.TrimSpace(after[1:]), true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), i > 0
+		}
+	}
+	return "", "", false
+}
+
+// parseBlock parses the mapping or sequence whose lines are indented
+// by `indent`.
+func (p *parser) parseBlock(indent int) (*node, error) {
+	l, _, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if isSequenceItem(l.text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *parser) parseMapping(indent int) (*node, error) {
+	mapping := &node{kind: mappingNode}
+	seen := map[string]int{}
+	for {
+		l, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		} else if !ok || l.indent < indent {
+			break
+		} else if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		} else if isSequenceItem(l.text) {
+			return nil, fmt.Errorf("line %d: expected a key, got a list item", l.num)
+		}
+		if mapping.line == 0 {
+			mapping.line = l.num
+		}
+
+		keyText, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value', got '%s'", l.num, l.text)
+		}
+		key, err := parseKey(keyText, l.num)
+		if err != nil {
+			return nil, err
+		}
+		if first, ok := seen[key]; ok {
+			return nil, fmt.Errorf(
+				"line %d: key '%s' is repeated (it's first set on line %d)", l.num, key, first)
+		}
+		seen[key] = l.num
+		p.pos++
+
+		value, err := p.parseValue(indent, rest, l.num, true)
+		if err != nil {
+			return nil, err
+		}
+		mapping.keys = append(mapping.keys, key)
+		mapping.values = append(mapping.values, value)
+	}
+	return mapping, nil
+}
+
+func (p *parser) parseSequence(indent int) (*node, error) {
+	sequence := &node{kind: sequenceNode}
+	for {
+		l, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		} else if !ok || l.indent < indent || (l.indent == indent && !isSequenceItem(l.text)) {
+			break
+		} else if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.num)
+		}
+		if sequence.line == 0 {
+			sequence.line = l.num
+		}
+
+		var item *node
+		rest := strings.TrimLeft(l.text[1:], " ")
+		if isSequenceItem(rest) || isKey(rest) {
+			// A mapping or sequence that starts on the item's line (e.g.,
+			// `- name: x`), whose other lines are indented as far as its
+			// first. The item's line is rewritten to be its first line.
+			itemIndent := l.indent + len(l.text) - len(rest)
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + rest
+			item, err = p.parseBlock(itemIndent)
+		} else {
+			p.pos++
+			item, err = p.parseValue(indent, rest, l.num, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sequence.items = append(sequence.items, item)
+	}
+	return sequence, nil
+}
+
+// parseValue parses the value of a key or list item on line `num`,
+// whose text after the `key:` or `-` is `rest`. If that's empty, the
+// value is on the following lines, if they're indented further than
+// `indent` (or, for block sequences that are the value of a key, by as
+// much), and otherwise null.
+func (p *parser) parseValue(indent int, rest string, num int, ofKey bool) (*node, error) {
+	if rest == "" {
+		next, ok, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || !(next.indent > indent || (ofKey && next.indent == indent && isSequenceItem(next.text))) {
+			return nullNode(num), nil
+		}
+		if isSequenceItem(next.text) || isKey(next.text) {
+			return p.parseBlock(next.indent)
+		}
+		p.pos++
+		return p.parseValue(indent, next.text, next.num, ofKey)
+	}
+
+	switch rest[0] {
+	case '|', '>':
+		return p.parseBlockScalar(indent, rest, num)
+	case '&', '*', '!':
+		return nil, fmt.Errorf("line %d: anchors, aliases and tags aren't supported", num)
+	case '?':
+		if rest == "?" || strings.HasPrefix(rest, "? ") {
+			return nil, fmt.Errorf("line %d: complex keys ('?') aren't supported", num)
+		}
+	case '[', '{':
+		return p.parseFlow(rest, num)
+	case '"', '\'':
+		return p.parseQuoted(rest, num)
+	}
+	return p.parsePlain(indent, rest, num)
+}
+
+// parseKey parses the text of a block mapping's key.
+func parseKey(text string, num int) (string, error) {
+	switch text[0] {
+	case '"', '\'':
+		return unquote(text, num)
+	case '&', '*', '!':
+		return "", fmt.Errorf("line %d: anchors, aliases and tags aren't supported", num)
+	case '?', '[', '{':
+		return "", fmt.Errorf("line %d: complex keys aren't supported", num)
+	}
+	if text == "<<" {
+		return "", fmt.Errorf("line %d: merge keys ('<<') aren't supported", num)
+	}
+	return text, nil
+}
+
+// parsePlain parses a plain scalar that starts with `first`, and
+// continues on the following lines that are indented further than
+// `indent`. Line breaks are folded into spaces, and blank lines into
+// line breaks. A following line that's a key, e.g., `b: 1` after
+// `a: 1`, is indented too far, rather than part of the scalar.
+func (p *parser) parsePlain(indent int, first string, num int) (*node, error) {
+	text, blanks := first, 0
+	for i := p.pos; i < p.end; i++ {
+		raw := p.lines[i]
+		trimmed := strings.TrimLeft(raw, " ")
+		if strings.TrimSpace(raw) == "" {
+			blanks++
+			continue
+		} else if len(raw)-len(trimmed) <= indent || strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		if isKey(trimmed) || isSequenceItem(trimmed) {
+			return nil, fmt.Errorf("line %d: unexpected indentation", i+1)
+		}
+		if blanks == 0 {
+			text += " "
+		} else {
+			text += strings.Repeat("\n", blanks)
+		}
+		text += strings.TrimLeft(stripComment(trimmed), " \t")
+		p.pos, blanks = i+1, 0
+	}
+	return &node{kind: scalarNode, line: num, value: text, plain: true}, nil
+}
+
+// parseQuoted parses a quoted scalar that starts with `first`, and may
+// continue on the following lines.
+func (p *parser) parseQuoted(first string, num int) (*node, error) {
+	text := first
+	end := quoteEnd(text, text[0])
+	for end < 0 {
+		if p.pos >= p.end {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", num)
+		}
+		text += "\n" + p.lines[p.pos]
+		p.pos++
+		end = quoteEnd(text, text[0])
+	}
+	if after := strings.TrimSpace(text[end+1:]); after != "" && !strings.HasPrefix(after, "#") {
+		return nil, fmt.Errorf("line %d: unexpected '%s' after quoted string", num, after)
+	}
+	value, err := unquote(text[:end+1], num)
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: scalarNode, line: num, value: value}, nil
+}
+
+// parseBlockScalar parses a literal (`|`) or folded (`>`) block
+// scalar, whose header (e.g., `|-` or `>2`) is `header`, from the
+// lines indented further than `indent` that follow. Its indentation is
+// that of its first line, unless the header gives it.
+func (p *parser) parseBlockScalar(indent int, header string, num int) (*node, error) {
+	folded, chomp, explicit := header[0] == '>', byte(0), 0
+	for i := 1; i < len(header); i++ {
+		switch c := header[i]; {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = c
+		case c >= '1' && c <= '9' && explicit == 0:
+			explicit = int(c - '0')
+		default:
+			return nil, fmt.Errorf("line %d: invalid block scalar header '%s'", num, header)
+		}
+	}
+
+	blockIndent := -1
+	if explicit > 0 {
+		if indent < 0 {
+			indent = 0
+		}
+		blockIndent = indent + explicit
+	}
+	lines := []string{}
+	for ; p.pos < p.end; p.pos++ {
+		raw := p.lines[p.pos]
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lineIndent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if blockIndent == -1 {
+			if lineIndent <= indent {
+				break
+			}
+			blockIndent = lineIndent
+		}
+		if lineIndent < blockIndent {
+			break
+		}
+		lines = append(lines, raw[blockIndent:])
+	}
+
+	// Trailing blank lines are only kept with `|+`; otherwise, the text
+	// ends with a single newline, or none with `|-`.
+	content := len(lines)
+	for content > 0 && lines[content-1] == "" {
+		content--
+	}
+	var text string
+	if folded {
+		text = fold(lines[:content])
+	} else {
+		text = strings.Join(lines[:content], "\n")
+	}
+	switch {
+	case content == 0:
+		text = ""
+	case chomp == '+':
+		text += strings.Repeat("\n", len(lines)-content+1)
+	case chomp == 0:
+		text += "\n"
+	}
+	// The trailing blank lines may belong to what follows.
+	if chomp != '+' {
+		p.pos -= len(lines) - content
+	}
+	return &node{kind: scalarNode, line: num, value: text}, nil
+}
+
+// fold joins the lines of a folded block scalar: the line break
+// between two lines is a space, or, if there are blank lines between
+// them, the blank lines' breaks alone. Breaks next to lines that are
+// indented further than the first are kept.
+func fold(lines []string) string {
+	moreIndented := func(l string) bool {
+		return strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")
+	}
+	var b strings.Builder
+	previous, blanks := -1, 0
+	for i, l := range lines {
+		if l == "" {
+			if previous < 0 {
+				b.WriteByte('\n')
+			} else {
+				blanks++
+			}
+			continue
+		}
+		if previous >= 0 {
+			switch {
+			case moreIndented(lines[previous]) || moreIndented(l):
+				b.WriteString(strings.Repeat("\n", blanks+1))
+			case blanks == 0:
+				b.WriteByte(' ')
+			default:
+				b.WriteString(strings.Repeat("\n", blanks))
+			}
+		}
+		b.WriteString(l)
+		previous, blanks = i, 0
+	}
+	return b.String()
+}
+
+//-----------------------------------------------------------------------------
+// Quoted scalars.
+//-----------------------------------------------------------------------------
+
+// quoteEnd returns the index of the quote that closes the string that
+// starts `text`, or -1 if it isn't closed.
+func quoteEnd(text string, quote byte) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v",
+	'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`,
+	'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+var hexEscapeLengths = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// unquote returns the value of the quoted scalar `text`, which may span
+// several lines. Line breaks are folded as in plain scalars, except
+// for escaped ones in double-quoted scalars, which are dropped.
+// Double-quoted scalars take YAML's escapes, which include JSON's.
+func unquote(text string, num int) (string, error) {
+	quote, body := text[0], text[1:len(text)-1]
+	out := []byte{}
+	kept := 0 // Escaped whitespace at the end of `out` isn't trimmed.
+
+	// skipBreaks skips the line breaks and indentation from `i`, and
+	// returns the number of breaks, and where the text resumes.
+	skipBreaks := func(i int) (int, int) {
+		breaks := 0
+		for ; i < len(body); i++ {
+			if body[i] == '\n' {
+				breaks++
+			} else if body[i] != ' ' && body[i] != '\t' {
+				break
+			}
+		}
+		return breaks, i
+	}
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\n':
+			for len(out) > kept && (out[len(out)-1] == ' ' || out[len(out)-1] == '\t') {
+				out = out[:len(out)-1]
+			}
+			breaks, next := skipBreaks(i)
+			if breaks == 1 {
+				out = append(out, ' ')
+			} else {
+				out = append(out, strings.Repeat("\n", breaks-1)...)
+			}
+			i = next - 1
+		case quote == '\'' && c == '\'':
+			// Only doubled quotes are left in the body.
+			out = append(out, '\'')
+			i++
+		case quote == '"' && c == '\\':
+			i++
+			if i == len(body) {
+				return "", fmt.Errorf("line %d: invalid double-quoted string %s", num, text)
+			}
+			e := body[i]
+			if e == '\n' {
+				_, next := skipBreaks(i + 1)
+				i = next - 1
+				break
+			}
+			if escaped, ok := escapes[e]; ok {
+				out = append(out, escaped...)
+			} else if length, ok := hexEscapeLengths[e]; ok {
+				digits := body[i+1:]
+				if len(digits) > length {
+					digits = digits[:length]
+				}
+				code, err := strconv.ParseUint(digits, 16, 32)
+				if err != nil || len(digits) < length || !utf8.ValidRune(rune(code)) {
+					return "", fmt.Errorf(
+						"line %d: invalid escape '\\%c%s' in double-quoted string", num, e, digits)
+				}
+				out = append(out, string(rune(code))...)
+				i += length
+			} else {
+				return "", fmt.Errorf("line %d: invalid escape '\\%c' in double-quoted string", num, e)
+			}
+			kept = len(out)
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out), nil
+}
+
+//-----------------------------------------------------------------------------
+// Flow collections.
+//-----------------------------------------------------------------------------
+
+// parseFlow parses a flow collection (e.g., `[a, b]` or `{a: 1}`) that
+// starts with `first`, and may continue on the following lines.
+func (p *parser) parseFlow(first string, num int) (*node, error) {
+	text := first
+	end := flowEnd(text)
+	for end < 0 {
+		if p.pos >= p.end {
+			return nil, fmt.Errorf("line %d: unterminated flow collection", num)
+		}
+		text += "\n" + stripComment(strings.TrimLeft(p.lines[p.pos], " \t"))
+		p.pos++
+		end = flowEnd(text)
+	}
+	if after := strings.TrimSpace(text[end+1:]); after != "" {
+		return nil, fmt.Errorf("line %d: unexpected '%s' after flow collection", num, after)
+	}
+	f := &flowParser{text: text[:end+1], num: num}
+	return f.value()
+}
+
+// flowEnd returns the index of the bracket that closes the collection
+// that starts `text`, or -1 if it isn't closed.
+func flowEnd(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t\n[{,:", rune(text[i-1]))):
+			end := quoteEnd(text[i:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+type flowParser struct {
+	text string
+	pos  int
+	num  int // The line `text` starts on.
+}
+
+func (f *flowParser) line() int {
+	return f.num + strings.Count(f.text[:f.pos], "\n")
+}
+
+func (f *flowParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: "+format, append([]interface{}{f.line()}, args...)...)
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.text) && strings.ContainsRune(" \t\n", rune(f.text[f.pos])) {
+		f.pos++
+	}
+}
+
+func (f *flowParser) peek() byte {
+	f.skipSpace()
+	if f.pos == len(f.text) {
+		return 0
+	}
+	return f.text[f.pos]
+}
+
+func (f *flowParser) value() (*node, error) {
+	num := f.line()
+	switch c := f.peek(); c {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case ',', ']', '}', ':':
+		return nullNode(num), nil
+	case '&', '*', '!':
+		return nil, f.errorf("anchors, aliases and tags aren't supported")
+	case '"', '\'':
+		end := quoteEnd(f.text[f.pos:], c)
+		if end < 0 {
+			return nil, f.errorf("unterminated quoted string")
+		}
+		value, err := unquote(f.text[f.pos:f.pos+end+1], num)
+		if err != nil {
+			return nil, err
+		}
+		f.pos += end + 1
+		return &node{kind: scalarNode, line: num, value: value}, nil
+	}
+
+	// A plain scalar ends at a flow indicator, or at a colon that's
+	// followed by one, or by whitespace.
+	start := f.pos
+	for ; f.pos < len(f.text); f.pos++ {
+		c := f.text[f.pos]
+		if strings.ContainsRune(",[]{}", rune(c)) {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.text) || strings.ContainsRune(" \t\n,[]{}", rune(f.text[f.pos+1]))) {
+			break
+		}
+	}
+	lines := strings.Split(f.text[start:f.pos], "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return &node{kind: scalarNode, line: num, value: strings.Join(lines, " "), plain: true}, nil
+}
+
+func (f *flowParser) sequence() (*node, error) {
+	sequence := &node{kind: sequenceNode, line: f.line(), items: []*node{}}
+	f.pos++
+	for {
+		if f.peek() == ']' {
+			f.pos++
+			return sequence, nil
+		}
+		item, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		if f.peek() == ':' {
+			// A single pair, e.g., `[name: x]`, is a mapping.
+			f.pos++
+			key, err := flowKey(item)
+			if err != nil {
+				return nil, err
+			}
+			value, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			item = &node{kind: mappingNode, line: item.line, keys: []string{key}, values: []*node{value}}
+		}
+		sequence.items = append(sequence.items, item)
+
+		switch f.peek() {
+		case ',':
+			f.pos++
+		case ']':
+		default:
+			return nil, f.errorf("expected ',' or ']' in flow sequence")
+		}
+	}
+}
+
+func (f *flowParser) mapping() (*node, error) {
+	mapping := &node{kind: mappingNode, line: f.line()}
+	seen := map[string]int{}
+	f.pos++
+	for {
+		if f.peek() == '}' {
+			f.pos++
+			return mapping, nil
+		}
+		keyNode, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		key, err := flowKey(keyNode)
+		if err != nil {
+			return nil, err
+		}
+		if first, ok := seen[key]; ok {
+			return nil, fmt.Errorf(
+				"line %d: key '%s' is repeated (it's first set on line %d)", keyNode.line, key, first)
+		}
+		seen[key] = keyNode.line
+
+		value := nullNode(keyNode.line)
+		if f.peek() == ':' {
+			f.pos++
+			if value, err = f.value(); err != nil {
+				return nil, err
+			}
+		}
+		mapping.keys = append(mapping.keys, key)
+		mapping.values = append(mapping.values, value)
+
+		switch f.peek() {
+		case ',':
+			f.pos++
+		case '}':
+		default:
+			return nil, f.errorf("expected ',' or '}' in flow mapping")
+		}
+	}
+}
+
+// flowKey returns the key that `n`, a key of a flow mapping, spells.
+func flowKey(n *node) (string, error) {
+	if n.kind != scalarNode {
+		return "", fmt.Errorf("line %d: complex keys aren't supported", n.line)
+	}
+	return n.value, nil
+}
@@ -0,0 +1,169 @@
+package yamljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := map[string]string{
+		// Block collections, including mappings in sequences, and
+		// sequences that are the value of a key at its indentation.
+		"name: x\nlabels:\n  app: web\n  tier: \"1\"\n":                  `{"name":"x","labels":{"app":"web","tier":"1"}}`,
+		"containers:\n- name: a\n  ports:\n  - 80\n  - 443\n- name: b\n": `{"containers":[{"name":"a","ports":[80,443]},{"name":"b"}]}`,
+		"- - a\n  - b\n- c\n":       `[["a","b"],"c"]`,
+		"a:\n-\n- ~\n- null\nb: \n": `{"a":[null,null,null],"b":null}`,
+
+		// Key order is kept.
+		"z: 1\na: 2\nm: 3\n": `{"z":1,"a":2,"m":3}`,
+
+		// Flow collections, which may span lines.
+		"args: [a, \"b, c\", 'd']\n":              `{"args":["a","b, c","d"]}`,
+		"m: {a: 1, b: [x, {c: null}], d}\n":       `{"m":{"a":1,"b":["x",{"c":null}],"d":null}}`,
+		"m: {\n  a: 1,  # one\n  b: 2,\n}\n":      `{"m":{"a":1,"b":2}}`,
+		"e: []\nf: {}\ng: [name: x]\n":            `{"e":[],"f":{},"g":[{"name":"x"}]}`,
+		`{"json": {"keys": [1, true]}, "b": "c"}`: `{"json":{"keys":[1,true]},"b":"c"}`,
+
+		// Scalars.
+		"a: true\nb: False\nc: yes\nd: ~\n":                     `{"a":true,"b":false,"c":"yes","d":null}`,
+		"a: 'it''s'\nb: \"tab\\tquote\\\" \\u00e9\"":            `{"a":"it's","b":"tab\tquote\" é"}`,
+		"a: 1:2\nb: http://x # comment\nc: a#b\n":               `{"a":"1:2","b":"http://x","c":"a#b"}`,
+		"\"quoted key\": 1\n'other': 2\n":                       `{"quoted key":1,"other":2}`,
+		"a: plain text\n  continues here\n\n  and here\nb: 1\n": `{"a":"plain text continues here\nand here","b":1}`,
+		"a: \"double\n  quoted\n\n  here\\\n  joined\"\n":       `{"a":"double quoted\nherejoined"}`,
+		"a:\n  text on the next line\n":                         `{"a":"text on the next line"}`,
+
+		// Block scalars.
+		"a: |\n  one\n  two\n\nb: 1\n":        `{"a":"one\ntwo\n","b":1}`,
+		"a: |-\n  one\n    two\n":             `{"a":"one\n  two"}`,
+		"a: |+\n  one\n\n":                    `{"a":"one\n\n"}`,
+		"a: >\n  one\n  two\n\n  three\n":     `{"a":"one two\nthree\n"}`,
+		"a: >-\n  one\n    indented\n  two\n": `{"a":"one\n  indented\ntwo"}`,
+		"a: |2\n    indented\n  b\n":          `{"a":"  indented\nb\n"}`,
+
+		// Empty documents are null, and a leading marker is allowed.
+		"":                    `null`,
+		"# Nothing.\n":        `null`,
+		"---\na: 1\n":         `{"a":1}`,
+		"%YAML 1.2\n---\n1\n": `1`,
+	}
+	for text, expected := range tests {
+		actual, err := ToJSON([]byte(text))
+		if err != nil {
+			t.Errorf("Expected no error converting:\n%s\ngot:\n%v", text, err)
+			continue
+		}
+		if string(actual) != expected {
+			t.Errorf("Expected:\n%s\nto convert to:\n%s\ngot:\n%s", text, expected, actual)
+		}
+		if !json.Valid(actual) {
+			t.Errorf("Expected valid JSON for:\n%s\ngot:\n%s", text, actual)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := map[string]string{
+		"12345678901234567890":    "12345678901234567890",
+		"9007199254740993":        "9007199254740993",
+		"-0":                      "-0",
+		"+12":                     "12",
+		"007":                     "7",
+		"0x1F":                    "31",
+		"0o17":                    "15",
+		"1.50":                    "1.50",
+		"+.5":                     "0.5",
+		"2.":                      "2",
+		"-1.25e+10":               "-1.25e+10",
+		"3E5":                     "3E5",
+		"0.100000000000000000001": "0.100000000000000000001",
+	}
+	for text, expected := range tests {
+		actual, err := ToJSON([]byte("n: " + text))
+		if err != nil {
+			t.Errorf("Expected no error converting '%s', got:\n%v", text, err)
+			continue
+		}
+		if want := `{"n":` + expected + `}`; string(actual) != want {
+			t.Errorf("Expected '%s' to convert to %s, got %s", text, want, actual)
+		}
+
+		// Decoding with `UseNumber` keeps every digit.
+		d := json.NewDecoder(bytes.NewReader(actual))
+		d.UseNumber()
+		var v map[string]json.Number
+		if err := d.Decode(&v); err != nil || v["n"].String() != expected {
+			t.Errorf("Expected '%s' to decode to %s, got %v (%v)", text, expected, v["n"], err)
+		}
+	}
+
+	// Quoted numbers are strings.
+	if actual, _ := ToJSON([]byte(`n: "12"`)); string(actual) != `{"n":"12"}` {
+		t.Errorf("Expected a quoted number to be a string, got %s", actual)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	text := "# CRDs.\n---\nkind: A\n---\n# Empty.\n---\nkind: B\nspec:\n  x: |\n    ---\n...\n---\nkind: C\n"
+	documents, err := Documents([]byte(text))
+	if err != nil {
+		t.Fatalf("Failed to convert documents:\n%v", err)
+	}
+	actual := []string{}
+	for _, document := range documents {
+		actual = append(actual, string(document))
+	}
+	expected := []string{`{"kind":"A"}`, `{"kind":"B","spec":{"x":"---\n"}}`, `{"kind":"C"}`}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected documents %v, got %v", expected, actual)
+	}
+
+	if _, err := ToJSON([]byte(text)); err == nil ||
+		err.Error() != "line 7: expected a single YAML document, got 3" {
+		t.Errorf("Expected an error converting several documents with 'ToJSON', got %v", err)
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	tests := map[string]string{
+		"a: 1\nb: 2\na: 3":         "line 3: key 'a' is repeated (it's first set on line 1)",
+		"m:\n  'a': 1\n  \"a\": 2": "line 3: key 'a' is repeated (it's first set on line 2)",
+		"m: {a: 1,\n  a: 2}":       "line 2: key 'a' is repeated (it's first set on line 1)",
+		"a: 1\n  b: 2":             "line 2: unexpected indentation",
+		"a:\n\tb: 1":               "line 2: YAML can't be indented with tabs",
+		"a: 1\n- b":                "line 2: expected a key, got a list item",
+		"a: 1\nb":                  "line 2: expected 'key: value', got 'b'",
+		"a: &anchor x":             "line 1: anchors, aliases and tags aren't supported",
+		"a: *anchor":               "line 1: anchors, aliases and tags aren't supported",
+		"a: !!str x":               "line 1: anchors, aliases and tags aren't supported",
+		"<<: {a: 1}":               "line 1: merge keys ('<<') aren't supported",
+		"? a\n: b":                 "line 1: complex keys ('?') aren't supported",
+		"a: [1, 2":                 "line 1: unterminated flow collection",
+		"a: [1] 2":                 "line 1: unexpected '2' after flow collection",
+		"a: {b: [1] c}":            "line 1: expected ',' or '}' in flow mapping",
+		"a: \"open":                "line 1: unterminated quoted string",
+		"a: \"x\" y":               "line 1: unexpected 'y' after quoted string",
+		"a: \"\\q\"":               "line 1: invalid escape '\\q' in double-quoted string",
+		"a: \"\\u12\"":             "line 1: invalid escape '\\u12' in double-quoted string",
+		"a: .inf":                  "line 1: '.inf' can't be represented in JSON",
+		"a: |x\n  b":               "line 1: invalid block scalar header '|x'",
+		"--- a: 1":                 "line 1: content after a document marker isn't supported; start it on the next line",
+		"a: 1\n---\nb: 2\nb: 3\n":  "line 4: key 'b' is repeated (it's first set on line 3)",
+		"list:\n- a: 1\n  a: 2\n":  "line 3: key 'a' is repeated (it's first set on line 2)",
+		"a: [b, {c: 1, c: 2}]":     "line 1: key 'c' is repeated (it's first set on line 1)",
+	}
+	for text, expected := range tests {
+		_, err := ToJSON([]byte(text))
+		if strings.Contains(text, "---") {
+			_, err = Documents([]byte(text))
+		}
+		if err == nil {
+			t.Errorf("Expected an error converting:\n%s", text)
+		} else if err.Error() != expected {
+			t.Errorf("Expected an error converting:\n%s\nlike:\n%s\ngot:\n%v", text, expected, err)
+		}
+	}
+}